@@ -353,6 +353,48 @@ func (cl *commandline) database(cmd *cobra.Command) {
 	}
 	addDbTruncateFlags(truncateCmd)
 
+	cloneCmd := &cobra.Command{
+		Use:               "clone",
+		Short:             "Clone database into a new one",
+		Example:           "clone {database_name} {new_database_name} --until-tx {tx_id}",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			untilTxID, err := cmd.Flags().GetUint64("until-tx")
+			if err != nil {
+				return err
+			}
+
+			res, err := cl.immuClient.CloneDatabase(cl.context, args[0], args[1], untilTxID)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "database '%s' successfully cloned into '%s' up to tx %d\n", args[0], res.Database, res.TxId)
+			return nil
+		},
+		Args: cobra.ExactArgs(2),
+	}
+	cloneCmd.Flags().Uint64("until-tx", 0, "latest transaction to include in the clone (the latest committed one if not set)")
+
+	renameCmd := &cobra.Command{
+		Use:               "rename",
+		Short:             "Rename database",
+		Example:           "rename {database_name} {new_database_name}",
+		PersistentPreRunE: cl.ConfigChain(cl.connect),
+		PersistentPostRun: cl.disconnect,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := cl.immuClient.RenameDatabase(cl.context, args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "database '%s' successfully renamed to '%s'\n", args[0], args[1])
+			return nil
+		},
+		Args: cobra.ExactArgs(2),
+	}
+
 	dbCmd.AddCommand(listCmd)
 	dbCmd.AddCommand(createCmd)
 	dbCmd.AddCommand(loadCmd)
@@ -363,6 +405,8 @@ func (cl *commandline) database(cmd *cobra.Command) {
 	dbCmd.AddCommand(flushCmd)
 	dbCmd.AddCommand(compactCmd)
 	dbCmd.AddCommand(truncateCmd)
+	dbCmd.AddCommand(cloneCmd)
+	dbCmd.AddCommand(renameCmd)
 
 	cmd.AddCommand(dbCmd)
 }
@@ -70,6 +70,9 @@ func (cl *Commandline) setupFlags(cmd *cobra.Command, options *server.Options) {
 	cmd.Flags().Bool("pgsql-server", true, "enable or disable pgsql server")
 	cmd.Flags().Int("pgsql-server-port", 5432, "pgsql server port")
 	cmd.Flags().Bool("pprof", false, "add pprof profiling endpoint on the metrics server")
+	cmd.Flags().Int("shared-index-cache-size", 0, "bytes of index nodes cached for all databases together, when zero each index uses its own cache")
+	cmd.Flags().String("backup-dir", "", "directory incremental backups of all databases are written to, backups are disabled when empty")
+	cmd.Flags().Duration("backup-interval", 0, "interval between incremental backups (e.g. 1h), when zero backups are not scheduled")
 	cmd.Flags().Bool("s3-storage", false, "enable or disable s3 storage")
 	cmd.Flags().String("s3-endpoint", "", "s3 endpoint")
 	cmd.Flags().String("s3-access-key-id", "", "s3 access key id")
@@ -77,7 +80,33 @@ func (cl *Commandline) setupFlags(cmd *cobra.Command, options *server.Options) {
 	cmd.Flags().String("s3-bucket-name", "", "s3 bucket name")
 	cmd.Flags().String("s3-location", "", "s3 location (region)")
 	cmd.Flags().String("s3-path-prefix", "", "s3 path prefix (multiple immudb instances can share the same bucket if they have different prefixes)")
+	cmd.Flags().Bool("azure-storage", false, "enable or disable azure blob storage")
+	cmd.Flags().String("azure-endpoint", "", "azure blob storage endpoint (default is the public endpoint of the storage account)")
+	cmd.Flags().String("azure-account-name", "", "azure storage account name")
+	cmd.Flags().String("azure-account-key", "", "azure storage account access key")
+	cmd.Flags().String("azure-sas-token", "", "azure shared access signature token, used if no account key is set")
+	cmd.Flags().String("azure-managed-identity-client-id", "", "client id of the user-assigned managed identity used if neither an account key nor a SAS token are set (default is the system-assigned identity)")
+	cmd.Flags().String("azure-container-name", "", "azure blob storage container name")
+	cmd.Flags().String("azure-path-prefix", "", "azure blob storage path prefix (multiple immudb instances can share the same container if they have different prefixes)")
+	cmd.Flags().Bool("gcs-storage", false, "enable or disable google cloud storage")
+	cmd.Flags().String("gcs-endpoint", "", "google cloud storage endpoint (default is the public endpoint)")
+	cmd.Flags().String("gcs-credentials-file", "", "google cloud service account key file (default is the service account of the workload, e.g. through workload identity)")
+	cmd.Flags().String("gcs-bucket-name", "", "google cloud storage bucket name")
+	cmd.Flags().String("gcs-path-prefix", "", "google cloud storage path prefix (multiple immudb instances can share the same bucket if they have different prefixes)")
+	cmd.Flags().String("remote-storage-cache-dir", "", "local directory where chunks read from the remote storage are cached (its content is discarded on startup)")
+	cmd.Flags().Int64("remote-storage-cache-size", 0, "bytes of remote storage chunks cached on disk, when zero chunks are not cached")
 	cmd.Flags().Int("max-sessions", 100, "maximum number of simultaneously opened sessions")
+	cmd.Flags().String("oidc-issuer", "", "issuer of the tokens accepted as passwords to login, external authentication is disabled when empty")
+	cmd.Flags().String("oidc-audience", "", "audience the accepted tokens must be issued for, any audience is accepted when empty")
+	cmd.Flags().String("oidc-jwks-url", "", "url of the public keys of the issuer, discovered from the issuer when empty")
+	cmd.Flags().String("oidc-username-claim", "sub", "token claim holding the username")
+	cmd.Flags().String("oidc-permissions-claim", "immudb_permissions", "token claim mapping database names to permissions (read, readwrite or admin)")
+	cmd.Flags().Int("max-sessions-per-user", 0, "maximum number of simultaneously opened sessions of a single user, 0 means no limit other than max-sessions")
+	cmd.Flags().Int("max-sessions-per-database", 0, "maximum number of simultaneously opened sessions on a single database, 0 means no limit other than max-sessions")
+	cmd.Flags().Int("user-writes-per-sec", 0, "maximum write requests per second of every user, 0 means no limit")
+	cmd.Flags().Int("user-read-entries-per-sec", 0, "maximum entries read per second by every user, 0 means no limit")
+	cmd.Flags().Int("database-writes-per-sec", 0, "maximum write requests per second on every database, 0 means no limit")
+	cmd.Flags().Int("database-read-entries-per-sec", 0, "maximum entries read per second from every database, 0 means no limit")
 	cmd.Flags().Duration("max-session-inactivity-time", 3*time.Minute, "max session inactivity time is a duration after which an active session is declared inactive by the server. A session is kept active if server is still receiving requests from client (keep-alive or other methods)")
 	cmd.Flags().Duration("max-session-age-time", 0, "the current default value is infinity. max session age time is a duration after which session will be forcibly closed")
 	cmd.Flags().Duration("session-timeout", 2*time.Minute, "session timeout is a duration after which an inactive session is forcibly closed by the server")
@@ -129,6 +158,9 @@ func setupDefaults(options *server.Options) {
 	viper.SetDefault("pgsql-server", true)
 	viper.SetDefault("pgsql-server-port", 5432)
 	viper.SetDefault("pprof", false)
+	viper.SetDefault("shared-index-cache-size", 0)
+	viper.SetDefault("backup-dir", "")
+	viper.SetDefault("backup-interval", 0)
 	viper.SetDefault("s3-storage", false)
 	viper.SetDefault("s3-endpoint", "")
 	viper.SetDefault("s3-access-key-id", "")
@@ -136,7 +168,33 @@ func setupDefaults(options *server.Options) {
 	viper.SetDefault("s3-bucket-name", "")
 	viper.SetDefault("s3-location", "")
 	viper.SetDefault("s3-path-prefix", "")
+	viper.SetDefault("azure-storage", false)
+	viper.SetDefault("azure-endpoint", "")
+	viper.SetDefault("azure-account-name", "")
+	viper.SetDefault("azure-account-key", "")
+	viper.SetDefault("azure-sas-token", "")
+	viper.SetDefault("azure-managed-identity-client-id", "")
+	viper.SetDefault("azure-container-name", "")
+	viper.SetDefault("azure-path-prefix", "")
+	viper.SetDefault("gcs-storage", false)
+	viper.SetDefault("gcs-endpoint", "")
+	viper.SetDefault("gcs-credentials-file", "")
+	viper.SetDefault("gcs-bucket-name", "")
+	viper.SetDefault("gcs-path-prefix", "")
+	viper.SetDefault("remote-storage-cache-dir", "")
+	viper.SetDefault("remote-storage-cache-size", 0)
 	viper.SetDefault("max-sessions", 100)
+	viper.SetDefault("oidc-issuer", "")
+	viper.SetDefault("oidc-audience", "")
+	viper.SetDefault("oidc-jwks-url", "")
+	viper.SetDefault("oidc-username-claim", "sub")
+	viper.SetDefault("oidc-permissions-claim", "immudb_permissions")
+	viper.SetDefault("max-sessions-per-user", 0)
+	viper.SetDefault("max-sessions-per-database", 0)
+	viper.SetDefault("user-writes-per-sec", 0)
+	viper.SetDefault("user-read-entries-per-sec", 0)
+	viper.SetDefault("database-writes-per-sec", 0)
+	viper.SetDefault("database-read-entries-per-sec", 0)
 	viper.SetDefault("max-session-inactivity-time", 3*time.Minute)
 	viper.SetDefault("max-session-age-time", 0)
 	viper.SetDefault("session-timeout", 2*time.Minute)
@@ -80,6 +80,8 @@ func parseOptions() (options *server.Options, err error) {
 
 	pprof := viper.GetBool("pprof")
 
+	sharedIndexCacheSize := viper.GetInt("shared-index-cache-size")
+
 	s3Storage := viper.GetBool("s3-storage")
 	s3Endpoint := viper.GetString("s3-endpoint")
 	s3AccessKeyID := viper.GetString("s3-access-key-id")
@@ -88,6 +90,24 @@ func parseOptions() (options *server.Options, err error) {
 	s3Location := viper.GetString("s3-location")
 	s3PathPrefix := viper.GetString("s3-path-prefix")
 
+	azureStorage := viper.GetBool("azure-storage")
+	azureEndpoint := viper.GetString("azure-endpoint")
+	azureAccountName := viper.GetString("azure-account-name")
+	azureAccountKey := viper.GetString("azure-account-key")
+	azureSASToken := viper.GetString("azure-sas-token")
+	azureManagedIdentityClientID := viper.GetString("azure-managed-identity-client-id")
+	azureContainerName := viper.GetString("azure-container-name")
+	azurePathPrefix := viper.GetString("azure-path-prefix")
+
+	gcsStorage := viper.GetBool("gcs-storage")
+	gcsEndpoint := viper.GetString("gcs-endpoint")
+	gcsCredentialsFile := viper.GetString("gcs-credentials-file")
+	gcsBucketName := viper.GetString("gcs-bucket-name")
+	gcsPathPrefix := viper.GetString("gcs-path-prefix")
+
+	remoteStorageCacheDir := viper.GetString("remote-storage-cache-dir")
+	remoteStorageCacheSize := viper.GetInt64("remote-storage-cache-size")
+
 	remoteStorageOptions := server.DefaultRemoteStorageOptions().
 		WithS3Storage(s3Storage).
 		WithS3Endpoint(s3Endpoint).
@@ -95,10 +115,44 @@ func parseOptions() (options *server.Options, err error) {
 		WithS3SecretKey(s3SecretKey).
 		WithS3BucketName(s3BucketName).
 		WithS3Location(s3Location).
-		WithS3PathPrefix(s3PathPrefix)
+		WithS3PathPrefix(s3PathPrefix).
+		WithAzureStorage(azureStorage).
+		WithAzureEndpoint(azureEndpoint).
+		WithAzureAccountName(azureAccountName).
+		WithAzureAccountKey(azureAccountKey).
+		WithAzureSASToken(azureSASToken).
+		WithAzureManagedIdentityClientID(azureManagedIdentityClientID).
+		WithAzureContainerName(azureContainerName).
+		WithAzurePathPrefix(azurePathPrefix).
+		WithGCSStorage(gcsStorage).
+		WithGCSEndpoint(gcsEndpoint).
+		WithGCSCredentialsFile(gcsCredentialsFile).
+		WithGCSBucketName(gcsBucketName).
+		WithGCSPathPrefix(gcsPathPrefix).
+		WithCacheDir(remoteStorageCacheDir).
+		WithCacheSize(remoteStorageCacheSize)
+
+	oidcOptions := server.DefaultOIDCOptions().
+		WithIssuer(viper.GetString("oidc-issuer")).
+		WithAudience(viper.GetString("oidc-audience")).
+		WithJWKSURL(viper.GetString("oidc-jwks-url")).
+		WithUsernameClaim(viper.GetString("oidc-username-claim")).
+		WithPermissionsClaim(viper.GetString("oidc-permissions-claim"))
+
+	rateLimitOptions := server.DefaultRateLimitOptions().
+		WithUserWritesPerSec(viper.GetInt("user-writes-per-sec")).
+		WithUserReadEntriesPerSec(viper.GetInt("user-read-entries-per-sec")).
+		WithDatabaseWritesPerSec(viper.GetInt("database-writes-per-sec")).
+		WithDatabaseReadEntriesPerSec(viper.GetInt("database-read-entries-per-sec"))
+
+	backupOptions := server.DefaultBackupOptions().
+		WithDir(viper.GetString("backup-dir")).
+		WithInterval(viper.GetDuration("backup-interval"))
 
 	sessionOptions := sessions.DefaultOptions().
 		WithMaxSessions(viper.GetInt("max-sessions")).
+		WithMaxSessionsPerUser(viper.GetInt("max-sessions-per-user")).
+		WithMaxSessionsPerDatabase(viper.GetInt("max-sessions-per-database")).
 		WithSessionGuardCheckInterval(viper.GetDuration("sessions-guard-check-interval")).
 		WithMaxSessionInactivityTime(viper.GetDuration("max-session-inactivity-time")).
 		WithMaxSessionAgeTime(viper.GetDuration("max-session-age-time")).
@@ -129,6 +183,9 @@ func parseOptions() (options *server.Options, err error) {
 		WithSigningKey(signingKey).
 		WithSynced(synced).
 		WithRemoteStorageOptions(remoteStorageOptions).
+		WithBackupOptions(backupOptions).
+		WithRateLimitOptions(rateLimitOptions).
+		WithOIDCOptions(oidcOptions).
 		WithTokenExpiryTime(tokenExpTime).
 		WithMetricsServer(metricsServer).
 		WithMetricsServerPort(metricsServerPort).
@@ -138,6 +195,7 @@ func parseOptions() (options *server.Options, err error) {
 		WithPgsqlServerPort(pgsqlServerPort).
 		WithSessionOptions(sessionOptions).
 		WithPProf(pprof).
+		WithSharedIndexCacheSize(sharedIndexCacheSize).
 		WithLogFormat(logFormat)
 
 	return options, nil
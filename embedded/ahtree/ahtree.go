@@ -29,6 +29,7 @@ import (
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
 	"github.com/codenotary/immudb/embedded/cache"
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/multierr"
 )
 
@@ -77,6 +78,8 @@ type AHtree struct {
 	syncThld int
 	readOnly bool
 
+	hashAlg hashing.Algorithm
+
 	closed bool
 	mutex  sync.Mutex
 
@@ -114,6 +117,7 @@ func Open(path string, opts *Options) (*AHtree, error) {
 		WithAutoSync(opts.autoSync).
 		WithFileSize(opts.fileSize).
 		WithFileMode(opts.fileMode).
+		WithEncryption(opts.encryptionKeyID, opts.keyProvider).
 		WithMetadata(metadata.Bytes())
 
 	appFactory := opts.appFactory
@@ -198,6 +202,7 @@ func OpenWith(pLog, dLog, cLog appendable.Appendable, opts *Options) (*AHtree, e
 		dCache:           dCache,
 		syncThld:         opts.syncThld,
 		readOnly:         opts.readOnly,
+		hashAlg:          opts.hashAlgorithm,
 		cLogBuf:          cLogBuf,
 	}
 
@@ -289,7 +294,7 @@ func (t *AHtree) Append(d []byte) (n uint64, h [sha256.Size]byte, err error) {
 	b[0] = LeafPrefix
 	copy(b[1:], d) // payload
 
-	h = sha256.Sum256(b)
+	h = t.hashAlg.Sum(b)
 	copy(t._digests[:], h[:])
 	dCount := 1
 
@@ -310,7 +315,7 @@ func (t *AHtree) Append(d []byte) (n uint64, h [sha256.Size]byte, err error) {
 			copy(b[1:], hkl[:])
 			copy(b[1+sha256.Size:], h[:])
 
-			h = sha256.Sum256(b[:])
+			h = t.hashAlg.Sum(b[:])
 
 			copy(t._digests[dCount*sha256.Size:], h[:])
 			dCount++
@@ -539,10 +544,42 @@ func (t *AHtree) InclusionProof(i, j uint64) (p [][sha256.Size]byte, err error)
 		return nil, ErrUnexistentData
 	}
 
-	return t.inclusionProof(i, j, bits.Len64(j-1))
+	return t.inclusionProof(i, j, bits.Len64(j-1), nil)
 }
 
-func (t *AHtree) inclusionProof(i, j uint64, height int) ([][sha256.Size]byte, error) {
+// InclusionProofs returns the inclusion proofs of every leaf in is up to the tree of size j,
+// nodes shared by multiple proofs are read once
+func (t *AHtree) InclusionProofs(is []uint64, j uint64) (ps [][][sha256.Size]byte, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	if j > t.size() {
+		return nil, ErrUnexistentData
+	}
+
+	memo := make(nodeMemo)
+
+	ps = make([][][sha256.Size]byte, len(is))
+
+	for n, i := range is {
+		if i > j {
+			return nil, ErrIllegalArguments
+		}
+
+		ps[n], err = t.inclusionProof(i, j, bits.Len64(j-1), memo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ps, nil
+}
+
+func (t *AHtree) inclusionProof(i, j uint64, height int, memo nodeMemo) ([][sha256.Size]byte, error) {
 	var proof [][sha256.Size]byte
 
 	for h := height - 1; h >= 0; h-- {
@@ -550,13 +587,13 @@ func (t *AHtree) inclusionProof(i, j uint64, height int) ([][sha256.Size]byte, e
 			k := (j - 1) >> h << h
 
 			if i <= k {
-				hNode, err := t.highestNode(j, h)
+				hNode, err := t.highestNode(j, h, memo)
 				if err != nil {
 					return nil, err
 				}
 				proof = append([][sha256.Size]byte{hNode}, proof...)
 
-				p, err := t.inclusionProof(i, k, h)
+				p, err := t.inclusionProof(i, k, h, memo)
 				if err != nil {
 					return nil, err
 				}
@@ -565,7 +602,7 @@ func (t *AHtree) inclusionProof(i, j uint64, height int) ([][sha256.Size]byte, e
 				return proof, nil
 			}
 
-			n, err := t.node(k, h)
+			n, err := t.proofNode(k, h, memo)
 			if err != nil {
 				return nil, err
 			}
@@ -593,10 +630,42 @@ func (t *AHtree) ConsistencyProof(i, j uint64) (p [][sha256.Size]byte, err error
 		return nil, ErrUnexistentData
 	}
 
-	return t.consistencyProof(i, j, bits.Len64(j-1))
+	return t.consistencyProof(i, j, bits.Len64(j-1), nil)
 }
 
-func (t *AHtree) consistencyProof(i, j uint64, height int) ([][sha256.Size]byte, error) {
+// ConsistencyProofs returns the consistency proofs between the trees of every size in is
+// and the tree of size j, nodes shared by multiple proofs are read once
+func (t *AHtree) ConsistencyProofs(is []uint64, j uint64) (ps [][][sha256.Size]byte, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	if j > t.size() {
+		return nil, ErrUnexistentData
+	}
+
+	memo := make(nodeMemo)
+
+	ps = make([][][sha256.Size]byte, len(is))
+
+	for n, i := range is {
+		if i > j {
+			return nil, ErrIllegalArguments
+		}
+
+		ps[n], err = t.consistencyProof(i, j, bits.Len64(j-1), memo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ps, nil
+}
+
+func (t *AHtree) consistencyProof(i, j uint64, height int, memo nodeMemo) ([][sha256.Size]byte, error) {
 	var proof [][sha256.Size]byte
 
 	for h := height - 1; h >= 0; h-- {
@@ -604,14 +673,14 @@ func (t *AHtree) consistencyProof(i, j uint64, height int) ([][sha256.Size]byte,
 			k := (j - 1) >> h << h
 
 			if i <= k {
-				hNode, err := t.highestNode(j, h)
+				hNode, err := t.highestNode(j, h, memo)
 				if err != nil {
 					return nil, err
 				}
 				proof = append([][sha256.Size]byte{hNode}, proof...)
 
 				if i < k {
-					p, err := t.consistencyProof(i, k, h)
+					p, err := t.consistencyProof(i, k, h, memo)
 					if err != nil {
 						return nil, err
 					}
@@ -620,7 +689,7 @@ func (t *AHtree) consistencyProof(i, j uint64, height int) ([][sha256.Size]byte,
 				}
 
 				if i == k {
-					hNode, err := t.highestNode(i, h)
+					hNode, err := t.highestNode(i, h, memo)
 					if err != nil {
 						return nil, err
 					}
@@ -630,14 +699,14 @@ func (t *AHtree) consistencyProof(i, j uint64, height int) ([][sha256.Size]byte,
 				return proof, nil
 			}
 
-			n, err := t.node(k, h)
+			n, err := t.proofNode(k, h, memo)
 			if err != nil {
 				return nil, err
 			}
 			proof = append([][sha256.Size]byte{n}, proof...)
 
 			if i == j {
-				hNode, err := t.highestNode(i, h)
+				hNode, err := t.highestNode(i, h, memo)
 				if err != nil {
 					return nil, err
 				}
@@ -650,14 +719,39 @@ func (t *AHtree) consistencyProof(i, j uint64, height int) ([][sha256.Size]byte,
 	return proof, nil
 }
 
-func (t *AHtree) highestNode(i uint64, d int) ([sha256.Size]byte, error) {
+func (t *AHtree) highestNode(i uint64, d int, memo nodeMemo) ([sha256.Size]byte, error) {
 	l := 0
 	for r := d - 1; r >= 0; r-- {
 		if (i-1)&(1<<r) > 0 {
 			l++
 		}
 	}
-	return t.node(i, l)
+	return t.proofNode(i, l, memo)
+}
+
+// nodeMemo holds the nodes read while building multiple proofs so they are read only once
+type nodeMemo map[uint64][sha256.Size]byte
+
+func (t *AHtree) proofNode(n uint64, l int, memo nodeMemo) ([sha256.Size]byte, error) {
+	if memo == nil {
+		return t.node(n, l)
+	}
+
+	i := nodesUntil(n) + uint64(l)
+
+	h, ok := memo[i]
+	if ok {
+		return h, nil
+	}
+
+	h, err := t.nodeAt(i)
+	if err != nil {
+		return h, err
+	}
+
+	memo[i] = h
+
+	return h, nil
 }
 
 func (t *AHtree) Size() uint64 {
@@ -28,6 +28,7 @@ import (
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/mocked"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/hashing"
 
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -711,6 +712,121 @@ func TestInclusionAndConsistencyProofs(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestBatchInclusionAndConsistencyProofs(t *testing.T) {
+	tree, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer tree.Close()
+
+	N := 300
+
+	for i := 1; i <= N; i++ {
+		_, _, err := tree.Append([]byte{byte(i)})
+		require.NoError(t, err)
+	}
+
+	is := make([]uint64, N)
+	for i := range is {
+		is[i] = uint64(i + 1)
+	}
+
+	for _, j := range []uint64{1, 128, 255, uint64(N)} {
+		jroot, err := tree.RootAt(j)
+		require.NoError(t, err)
+
+		iproofs, err := tree.InclusionProofs(is[:j], j)
+		require.NoError(t, err)
+		require.Len(t, iproofs, int(j))
+
+		cproofs, err := tree.ConsistencyProofs(is[:j], j)
+		require.NoError(t, err)
+		require.Len(t, cproofs, int(j))
+
+		for n, i := range is[:j] {
+			iproof, err := tree.InclusionProof(i, j)
+			require.NoError(t, err)
+			require.Equal(t, iproof, iproofs[n])
+
+			h := sha256.Sum256([]byte{LeafPrefix, byte(i)})
+			require.True(t, VerifyInclusion(iproofs[n], i, j, h, jroot))
+
+			cproof, err := tree.ConsistencyProof(i, j)
+			require.NoError(t, err)
+			require.Equal(t, cproof, cproofs[n])
+
+			iroot, err := tree.RootAt(i)
+			require.NoError(t, err)
+			require.True(t, VerifyConsistency(cproofs[n], i, j, iroot, jroot))
+		}
+	}
+
+	_, err = tree.InclusionProofs([]uint64{1, 3}, 2)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = tree.ConsistencyProofs([]uint64{1, 3}, 2)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = tree.InclusionProofs([]uint64{1}, uint64(N+1))
+	require.ErrorIs(t, err, ErrUnexistentData)
+
+	_, err = tree.ConsistencyProofs([]uint64{1}, uint64(N+1))
+	require.ErrorIs(t, err, ErrUnexistentData)
+
+	ps, err := tree.InclusionProofs(nil, uint64(N))
+	require.NoError(t, err)
+	require.Empty(t, ps)
+}
+
+func TestInclusionAndConsistencyProofsWithHashAlgorithm(t *testing.T) {
+	tree, err := Open(t.TempDir(), DefaultOptions().WithHashAlgorithm(hashing.BLAKE2b256))
+	require.NoError(t, err)
+	defer tree.Close()
+
+	N := 64
+
+	for i := 1; i <= N; i++ {
+		_, _, err := tree.Append([]byte{byte(i)})
+		require.NoError(t, err)
+	}
+
+	for i := 1; i <= N; i++ {
+		for j := i; j <= N; j++ {
+			iproof, err := tree.InclusionProof(uint64(i), uint64(j))
+			require.NoError(t, err)
+
+			jroot, err := tree.RootAt(uint64(j))
+			require.NoError(t, err)
+
+			h := hashing.BLAKE2b256.Sum([]byte{LeafPrefix, byte(i)})
+
+			require.True(t, VerifyInclusionWith(hashing.BLAKE2b256, iproof, uint64(i), uint64(j), h, jroot))
+
+			cproof, err := tree.ConsistencyProof(uint64(i), uint64(j))
+			require.NoError(t, err)
+
+			iroot, err := tree.RootAt(uint64(i))
+			require.NoError(t, err)
+
+			require.True(t, VerifyConsistencyWith(hashing.BLAKE2b256, cproof, uint64(i), uint64(j), iroot, jroot))
+
+			if i < j {
+				require.False(t, VerifyInclusion(iproof, uint64(i), uint64(j), h, jroot))
+				require.False(t, VerifyConsistency(cproof, uint64(i), uint64(j), iroot, jroot))
+			}
+		}
+	}
+
+	_, root, err := tree.Root()
+	require.NoError(t, err)
+
+	iproof, err := tree.InclusionProof(uint64(N), uint64(N))
+	require.NoError(t, err)
+
+	h := hashing.BLAKE2b256.Sum([]byte{LeafPrefix, byte(N)})
+
+	require.True(t, VerifyLastInclusionWith(hashing.BLAKE2b256, iproof, uint64(N), h, root))
+	require.False(t, VerifyLastInclusionWith(hashing.Algorithm(255), iproof, uint64(N), h, root))
+}
+
 func TestReOpenningImmudbStore(t *testing.T) {
 	dir := t.TempDir()
 
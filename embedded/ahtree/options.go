@@ -22,6 +22,7 @@ import (
 
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/hashing"
 )
 
 const DefaultFileSize = multiapp.DefaultFileSize
@@ -55,6 +56,11 @@ type Options struct {
 	dataCacheSlots    int
 	digestsCacheSlots int
 
+	hashAlgorithm hashing.Algorithm
+
+	encryptionKeyID string
+	keyProvider     appendable.KeyProvider
+
 	// Options below are only set during initialization and stored as metadata
 	fileSize          int
 	compressionFormat int
@@ -75,6 +81,8 @@ func DefaultOptions() *Options {
 		dataCacheSlots:    DefaultDataCacheSlots,
 		digestsCacheSlots: DefaultDigestsCacheSlots,
 
+		hashAlgorithm: hashing.SHA256,
+
 		// Options below are only set during initialization and stored as metadata
 		fileSize:          DefaultFileSize,
 		compressionFormat: DefaultCompressionFormat,
@@ -99,6 +107,10 @@ func (opts *Options) Validate() error {
 		return fmt.Errorf("%w: invalid digestsCacheSlots", ErrInvalidOptions)
 	}
 
+	if opts.hashAlgorithm.Validate() != nil {
+		return fmt.Errorf("%w: invalid hashAlgorithm", ErrInvalidOptions)
+	}
+
 	if opts.readBufferSize <= 0 {
 		return fmt.Errorf("%w: invalid readBufferSize", ErrInvalidOptions)
 	}
@@ -111,6 +123,10 @@ func (opts *Options) Validate() error {
 		return fmt.Errorf("%w: invalid syncThld", ErrInvalidOptions)
 	}
 
+	if opts.encryptionKeyID != "" && opts.keyProvider == nil {
+		return fmt.Errorf("%w: invalid keyProvider", ErrInvalidOptions)
+	}
+
 	return nil
 }
 
@@ -159,6 +175,19 @@ func (opts *Options) WithDigestsCacheSlots(cacheSlots int) *Options {
 	return opts
 }
 
+// WithHashAlgorithm sets the algorithm used to calculate the digests of the tree,
+// the same algorithm must be used every time the tree is opened
+func (opts *Options) WithHashAlgorithm(hashAlg hashing.Algorithm) *Options {
+	opts.hashAlgorithm = hashAlg
+	return opts
+}
+
+func (opts *Options) WithEncryption(keyID string, keyProvider appendable.KeyProvider) *Options {
+	opts.encryptionKeyID = keyID
+	opts.keyProvider = keyProvider
+	return opts
+}
+
 func (opts *Options) WithFileSize(fileSize int) *Options {
 	opts.fileSize = fileSize
 	return opts
@@ -21,6 +21,7 @@ import (
 
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/stretchr/testify/require"
 )
 
@@ -37,6 +38,8 @@ func TestInvalidOptions(t *testing.T) {
 		{"ReadBufferSize", DefaultOptions().WithReadBufferSize(0)},
 		{"SyncThld", DefaultOptions().WithReadOnly(false).WithSyncThld(0)},
 		{"WriteBufferSize", DefaultOptions().WithReadOnly(false).WithWriteBufferSize(0)},
+		{"HashAlgorithm", DefaultOptions().WithHashAlgorithm(hashing.Algorithm(255))},
+		{"KeyProvider", DefaultOptions().WithEncryption("key1", nil)},
 	} {
 		t.Run(d.n, func(t *testing.T) {
 			require.ErrorIs(t, d.opts.Validate(), ErrInvalidOptions)
@@ -62,6 +65,9 @@ func TestValidOptions(t *testing.T) {
 	require.Equal(t, DefaultDataCacheSlots, opts.WithDataCacheSlots(DefaultDataCacheSlots).dataCacheSlots)
 	require.Equal(t, DefaultDigestsCacheSlots, opts.WithDigestsCacheSlots(DefaultDigestsCacheSlots).digestsCacheSlots)
 	require.NotNil(t, opts.WithAppFactory(dummyAppFactory).appFactory)
+	require.Equal(t, hashing.BLAKE2b256, opts.WithHashAlgorithm(hashing.BLAKE2b256).hashAlgorithm)
+	require.Equal(t, "key1", opts.WithEncryption("key1", nil).encryptionKeyID)
+	require.Nil(t, opts.WithEncryption("", nil).keyProvider)
 
 	require.True(t, opts.WithReadOnly(true).readOnly)
 	require.Equal(t, multiapp.DefaultReadBufferSize, opts.WithReadBufferSize(multiapp.DefaultReadBufferSize).readBufferSize)
@@ -16,19 +16,32 @@ limitations under the License.
 
 package ahtree
 
-import "crypto/sha256"
+import (
+	"crypto/sha256"
+
+	"github.com/codenotary/immudb/embedded/hashing"
+)
 
 func VerifyInclusion(iproof [][sha256.Size]byte, i, j uint64, iLeaf, jRoot [sha256.Size]byte) bool {
-	if i > j || i == 0 || (i < j && len(iproof) == 0) {
+	return VerifyInclusionWith(hashing.SHA256, iproof, i, j, iLeaf, jRoot)
+}
+
+// VerifyInclusionWith verifies an inclusion proof of a tree built with the given hash algorithm
+func VerifyInclusionWith(hashAlg hashing.Algorithm, iproof [][sha256.Size]byte, i, j uint64, iLeaf, jRoot [sha256.Size]byte) bool {
+	if i > j || i == 0 || (i < j && len(iproof) == 0) || hashAlg.Validate() != nil {
 		return false
 	}
 
-	ciRoot := EvalInclusion(iproof, i, j, iLeaf)
+	ciRoot := EvalInclusionWith(hashAlg, iproof, i, j, iLeaf)
 
 	return jRoot == ciRoot
 }
 
 func EvalInclusion(iproof [][sha256.Size]byte, i, j uint64, iLeaf [sha256.Size]byte) [sha256.Size]byte {
+	return EvalInclusionWith(hashing.SHA256, iproof, i, j, iLeaf)
+}
+
+func EvalInclusionWith(hashAlg hashing.Algorithm, iproof [][sha256.Size]byte, i, j uint64, iLeaf [sha256.Size]byte) [sha256.Size]byte {
 	i1 := i - 1
 	j1 := j - 1
 
@@ -46,7 +59,7 @@ func EvalInclusion(iproof [][sha256.Size]byte, i, j uint64, iLeaf [sha256.Size]b
 			copy(b[sha256.Size+1:], ciRoot[:])
 		}
 
-		ciRoot = sha256.Sum256(b[:])
+		ciRoot = hashAlg.Sum(b[:])
 
 		i1 >>= 1
 		j1 >>= 1
@@ -56,7 +69,12 @@ func EvalInclusion(iproof [][sha256.Size]byte, i, j uint64, iLeaf [sha256.Size]b
 }
 
 func VerifyConsistency(cproof [][sha256.Size]byte, i, j uint64, iRoot, jRoot [sha256.Size]byte) bool {
-	if i > j || i == 0 || (i < j && len(cproof) == 0) {
+	return VerifyConsistencyWith(hashing.SHA256, cproof, i, j, iRoot, jRoot)
+}
+
+// VerifyConsistencyWith verifies a consistency proof of a tree built with the given hash algorithm
+func VerifyConsistencyWith(hashAlg hashing.Algorithm, cproof [][sha256.Size]byte, i, j uint64, iRoot, jRoot [sha256.Size]byte) bool {
+	if i > j || i == 0 || (i < j && len(cproof) == 0) || hashAlg.Validate() != nil {
 		return false
 	}
 
@@ -64,12 +82,16 @@ func VerifyConsistency(cproof [][sha256.Size]byte, i, j uint64, iRoot, jRoot [sh
 		return iRoot == jRoot
 	}
 
-	ciRoot, cjRoot := EvalConsistency(cproof, i, j)
+	ciRoot, cjRoot := EvalConsistencyWith(hashAlg, cproof, i, j)
 
 	return iRoot == ciRoot && jRoot == cjRoot
 }
 
 func EvalConsistency(cproof [][sha256.Size]byte, i, j uint64) ([sha256.Size]byte, [sha256.Size]byte) {
+	return EvalConsistencyWith(hashing.SHA256, cproof, i, j)
+}
+
+func EvalConsistencyWith(hashAlg hashing.Algorithm, cproof [][sha256.Size]byte, i, j uint64) ([sha256.Size]byte, [sha256.Size]byte) {
 	fn := i - 1
 	sn := j - 1
 
@@ -87,10 +109,10 @@ func EvalConsistency(cproof [][sha256.Size]byte, i, j uint64) ([sha256.Size]byte
 			copy(b[1:], h[:])
 
 			copy(b[1+sha256.Size:], ciRoot[:])
-			ciRoot = sha256.Sum256(b[:])
+			ciRoot = hashAlg.Sum(b[:])
 
 			copy(b[1+sha256.Size:], cjRoot[:])
-			cjRoot = sha256.Sum256(b[:])
+			cjRoot = hashAlg.Sum(b[:])
 
 			for fn%2 == 0 && fn != 0 {
 				fn >>= 1
@@ -99,7 +121,7 @@ func EvalConsistency(cproof [][sha256.Size]byte, i, j uint64) ([sha256.Size]byte
 		} else {
 			copy(b[1:], cjRoot[:])
 			copy(b[1+sha256.Size:], h[:])
-			cjRoot = sha256.Sum256(b[:])
+			cjRoot = hashAlg.Sum(b[:])
 		}
 		fn >>= 1
 		sn >>= 1
@@ -109,14 +131,23 @@ func EvalConsistency(cproof [][sha256.Size]byte, i, j uint64) ([sha256.Size]byte
 }
 
 func VerifyLastInclusion(iproof [][sha256.Size]byte, i uint64, leaf, root [sha256.Size]byte) bool {
-	if i == 0 {
+	return VerifyLastInclusionWith(hashing.SHA256, iproof, i, leaf, root)
+}
+
+// VerifyLastInclusionWith verifies a last inclusion proof of a tree built with the given hash algorithm
+func VerifyLastInclusionWith(hashAlg hashing.Algorithm, iproof [][sha256.Size]byte, i uint64, leaf, root [sha256.Size]byte) bool {
+	if i == 0 || hashAlg.Validate() != nil {
 		return false
 	}
 
-	return root == EvalLastInclusion(iproof, i, leaf)
+	return root == EvalLastInclusionWith(hashAlg, iproof, i, leaf)
 }
 
 func EvalLastInclusion(iproof [][sha256.Size]byte, i uint64, leaf [sha256.Size]byte) [sha256.Size]byte {
+	return EvalLastInclusionWith(hashing.SHA256, iproof, i, leaf)
+}
+
+func EvalLastInclusionWith(hashAlg hashing.Algorithm, iproof [][sha256.Size]byte, i uint64, leaf [sha256.Size]byte) [sha256.Size]byte {
 	i1 := i - 1
 
 	root := leaf
@@ -128,7 +159,7 @@ func EvalLastInclusion(iproof [][sha256.Size]byte, i uint64, leaf [sha256.Size]b
 		copy(b[1:], h[:])
 		copy(b[sha256.Size+1:], root[:])
 
-		root = sha256.Sum256(b[:])
+		root = hashAlg.Sum(b[:])
 
 		i1 >>= 1
 	}
@@ -40,6 +40,10 @@ const (
 	HuffmanOnly        = flate.HuffmanOnly
 )
 
+// KeyProvider resolves the encryption key registered under keyID.
+// Keys rotated out must remain resolvable for as long as data encrypted with them is kept
+type KeyProvider func(keyID string) ([]byte, error)
+
 type Appendable interface {
 	Metadata() []byte
 	Size() (int64, error)
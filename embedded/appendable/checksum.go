@@ -0,0 +1,127 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appendable
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+var ErrChecksumMismatch = errors.New("appendable: checksum mismatch")
+var ErrNoChecksums = errors.New("appendable: no checksums were written")
+
+// MetaChecksumBlockSize is the metadata key recording the amount of data bytes covered
+// by each checksum, it's absent in files written without checksums
+const MetaChecksumBlockSize = "CHECKSUM_BLOCK_SIZE"
+
+const DefaultChecksumBlockSize = 4096
+
+// ChecksumSize is the amount of bytes of the CRC32C checksum stored after each block of data
+const ChecksumSize = 4
+
+var ChecksumTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Verify checks the checksums of the file at path, or of every file in it when path is
+// a directory, as it's the case of multi-file appendables. Files without checksums are
+// reported with ErrNoChecksums unless they are part of a directory, in which case they
+// are skipped, as checksums may have been enabled after some files were written.
+// The data of the latest block of each file is not verified until the block is complete
+func Verify(path string) error {
+	finfo, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if !finfo.IsDir() {
+		return verifyFile(path)
+	}
+
+	fis, err := ioutil.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	for _, fi := range fis {
+		if fi.IsDir() {
+			continue
+		}
+
+		err = verifyFile(filepath.Join(path, fi.Name()))
+		if errors.Is(err, ErrNoChecksums) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func verifyFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+
+	var mLenBs [4]byte
+	_, err = io.ReadFull(r, mLenBs[:])
+	if err != nil {
+		return fmt.Errorf("%w: invalid header of '%s'", ErrNoChecksums, path)
+	}
+
+	mBs := make([]byte, binary.BigEndian.Uint32(mLenBs[:]))
+	_, err = io.ReadFull(r, mBs)
+	if err != nil {
+		return fmt.Errorf("%w: invalid header of '%s'", ErrNoChecksums, path)
+	}
+
+	blockSize, ok := NewMetadata(mBs).GetInt(MetaChecksumBlockSize)
+	if !ok || blockSize <= 0 {
+		return fmt.Errorf("%w: '%s'", ErrNoChecksums, path)
+	}
+
+	block := make([]byte, blockSize+ChecksumSize)
+
+	for i := 0; ; i++ {
+		n, err := io.ReadFull(r, block)
+		if err == io.EOF || (err == io.ErrUnexpectedEOF && n <= blockSize) {
+			// incomplete blocks have no checksum yet
+			return nil
+		}
+		if err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("%w: incomplete checksum of block %d of '%s'", ErrChecksumMismatch, i, path)
+		}
+		if err != nil {
+			return err
+		}
+
+		if crc32.Checksum(block[:blockSize], ChecksumTable) != binary.BigEndian.Uint32(block[blockSize:]) {
+			return fmt.Errorf("%w: block %d of '%s'", ErrChecksumMismatch, i, path)
+		}
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/fileutils"
@@ -110,6 +111,18 @@ type MultiFileAppendable struct {
 	fileExt        string
 	readBufferSize int
 
+	encryptionKeyID string
+	keyProvider     appendable.KeyProvider
+
+	checksumBlockSize int
+	verifyChecksums   bool
+
+	mmap bool
+
+	maxChunkAge       time.Duration
+	currAppWrittenAt  time.Time // when the current appendable started being written
+	chunkRotationHook ChunkRotationHook
+
 	writeBuffer []byte // shared write-buffer only used by active appendable
 
 	closed bool
@@ -168,6 +181,10 @@ func OpenWithHooks(path string, hooks MultiFileAppendableHooks, opts *Options) (
 		WithFileMode(opts.fileMode).
 		WithCompressionFormat(opts.compressionFormat).
 		WithCompresionLevel(opts.compressionLevel).
+		WithEncryption(opts.encryptionKeyID, opts.keyProvider).
+		WithChecksums(opts.checksumBlockSize).
+		WithVerifyChecksums(opts.verifyChecksums).
+		WithMmap(opts.mmap).
 		WithReadBufferSize(opts.readBufferSize).
 		WithWriteBuffer(writeBuffer).
 		WithMetadata(m.Bytes())
@@ -185,20 +202,28 @@ func OpenWithHooks(path string, hooks MultiFileAppendableHooks, opts *Options) (
 	fileSize, _ := appendable.NewMetadata(currApp.Metadata()).GetInt(metaFileSize)
 
 	return &MultiFileAppendable{
-		appendables:    appendableLRUCache{cache: cache},
-		currAppID:      currAppID,
-		currApp:        currApp,
-		path:           path,
-		readOnly:       opts.readOnly,
-		retryableSync:  opts.retryableSync,
-		autoSync:       opts.autoSync,
-		fileMode:       opts.fileMode,
-		fileSize:       fileSize,
-		fileExt:        opts.fileExt,
-		readBufferSize: opts.readBufferSize,
-		writeBuffer:    writeBuffer,
-		closed:         false,
-		hooks:          hooks,
+		appendables:       appendableLRUCache{cache: cache},
+		currAppID:         currAppID,
+		currApp:           currApp,
+		path:              path,
+		readOnly:          opts.readOnly,
+		retryableSync:     opts.retryableSync,
+		autoSync:          opts.autoSync,
+		fileMode:          opts.fileMode,
+		fileSize:          fileSize,
+		fileExt:           opts.fileExt,
+		readBufferSize:    opts.readBufferSize,
+		encryptionKeyID:   opts.encryptionKeyID,
+		keyProvider:       opts.keyProvider,
+		checksumBlockSize: opts.checksumBlockSize,
+		verifyChecksums:   opts.verifyChecksums,
+		mmap:              opts.mmap,
+		maxChunkAge:       opts.maxChunkAge,
+		currAppWrittenAt:  time.Now(),
+		chunkRotationHook: opts.chunkRotationHook,
+		writeBuffer:       writeBuffer,
+		closed:            false,
+		hooks:             hooks,
 	}, nil
 }
 
@@ -317,7 +342,20 @@ func (mf *MultiFileAppendable) Append(bs []byte) (off int64, n int, err error) {
 	for n < len(bs) {
 		available := mf.fileSize - int(mf.currApp.Offset())
 
+		if available > 0 && mf.maxChunkAge > 0 && mf.currApp.Offset() > 0 &&
+			time.Since(mf.currAppWrittenAt) >= mf.maxChunkAge {
+			// the space left in the chunk is skipped
+			available = 0
+		}
+
 		if available <= 0 {
+			if mf.chunkRotationHook != nil {
+				err = mf.currApp.Sync()
+				if err != nil {
+					return off, n, err
+				}
+			}
+
 			// by switching to read-only mode, the write buffer is freed
 			err = mf.currApp.SwitchToReadOnlyMode()
 			if err != nil {
@@ -338,6 +376,8 @@ func (mf *MultiFileAppendable) Append(bs []byte) (off int64, n int, err error) {
 
 			}
 
+			rotatedAppID := mf.currAppID
+
 			mf.currAppID++
 			currApp, err := mf.openAppendable(appendableName(mf.currAppID, mf.fileExt), true)
 			if err != nil {
@@ -346,8 +386,13 @@ func (mf *MultiFileAppendable) Append(bs []byte) (off int64, n int, err error) {
 			currApp.SetOffset(0)
 
 			mf.currApp = currApp
+			mf.currAppWrittenAt = time.Now()
 
 			available = mf.fileSize
+
+			if mf.chunkRotationHook != nil {
+				mf.chunkRotationHook(rotatedAppID, filepath.Join(mf.path, appendableName(rotatedAppID, mf.fileExt)))
+			}
 		}
 
 		var d int
@@ -374,17 +419,24 @@ func (mf *MultiFileAppendable) Append(bs []byte) (off int64, n int, err error) {
 }
 
 func (mf *MultiFileAppendable) openAppendable(appname string, activeChunk bool) (appendable.Appendable, error) {
+	// chunks other than the active one are only read, thus they can be memory-mapped
+	readOnly := mf.readOnly || (mf.mmap && !activeChunk)
+
 	appendableOpts := singleapp.DefaultOptions().
-		WithReadOnly(mf.readOnly).
+		WithReadOnly(readOnly).
 		WithRetryableSync(mf.retryableSync).
 		WithAutoSync(mf.autoSync).
 		WithFileMode(mf.fileMode).
 		WithReadBufferSize(mf.readBufferSize).
 		WithCompressionFormat(mf.currApp.CompressionFormat()).
 		WithCompresionLevel(mf.currApp.CompressionLevel()).
+		WithEncryption(mf.encryptionKeyID, mf.keyProvider).
+		WithChecksums(mf.checksumBlockSize).
+		WithVerifyChecksums(mf.verifyChecksums).
+		WithMmap(mf.mmap).
 		WithMetadata(mf.currApp.Metadata())
 
-	if activeChunk && !mf.readOnly {
+	if activeChunk && !readOnly {
 		appendableOpts.WithWriteBuffer(mf.writeBuffer)
 	}
 
@@ -461,6 +513,7 @@ func (mf *MultiFileAppendable) SetOffset(off int64) error {
 
 		mf.currAppID = appID
 		mf.currApp = app
+		mf.currAppWrittenAt = time.Now()
 	}
 
 	return mf.currApp.SetOffset(off % int64(mf.fileSize))
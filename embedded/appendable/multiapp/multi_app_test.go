@@ -22,6 +22,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/singleapp"
@@ -376,3 +377,225 @@ func TestMultiAppDiscard(t *testing.T) {
 	err = a.Close()
 	require.NoError(t, err)
 }
+
+func TestMultiAppEncryptionKeyRotation(t *testing.T) {
+	keys := map[string][]byte{
+		"key1": []byte("0123456789abcdef"),
+		"key2": []byte("fedcba9876543210"),
+	}
+
+	keyProvider := func(keyID string) ([]byte, error) {
+		return keys[keyID], nil
+	}
+
+	path := t.TempDir()
+
+	a, err := Open(path, DefaultOptions().WithFileSize(8).WithEncryption("key1", keyProvider))
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte("chunk-0-"))
+	require.NoError(t, err)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	// rotated key is used for new chunks only
+	a, err = Open(path, DefaultOptions().WithFileSize(8).WithEncryption("key2", keyProvider))
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte("chunk-1-"))
+	require.NoError(t, err)
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	bs := make([]byte, 16)
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte("chunk-0-chunk-1-"), bs)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	for i, keyID := range []string{"key1", "key2"} {
+		f, err := singleapp.Open(filepath.Join(path, appendableName(int64(i), "aof")), singleapp.DefaultOptions().WithReadOnly(true))
+		require.ErrorIs(t, err, singleapp.ErrMissingEncryptionKey)
+		require.Nil(t, f)
+
+		f, err = singleapp.Open(filepath.Join(path, appendableName(int64(i), "aof")), singleapp.DefaultOptions().
+			WithReadOnly(true).
+			WithEncryption("", func(id string) ([]byte, error) {
+				require.Equal(t, keyID, id)
+				return keys[id], nil
+			}))
+		require.NoError(t, err)
+
+		err = f.Close()
+		require.NoError(t, err)
+	}
+}
+
+func TestMultiAppChecksums(t *testing.T) {
+	path := t.TempDir()
+
+	opts := DefaultOptions().
+		WithFileSize(32).
+		WithChecksums(16).
+		WithVerifyChecksums(true)
+
+	a, err := Open(path, opts)
+	require.NoError(t, err)
+
+	data := []byte("data spread over multiple chunks with checksums")
+
+	_, _, err = a.Append(data)
+	require.NoError(t, err)
+
+	bs := make([]byte, len(data))
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, data, bs)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	require.NoError(t, appendable.Verify(path))
+
+	chunk := filepath.Join(path, appendableName(0, "aof"))
+
+	raw, err := ioutil.ReadFile(chunk)
+	require.NoError(t, err)
+
+	// last data byte of the first chunk
+	raw[len(raw)-1-appendable.ChecksumSize] ^= 0xff
+
+	err = ioutil.WriteFile(chunk, raw, 0644)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, appendable.Verify(path), appendable.ErrChecksumMismatch)
+
+	a, err = Open(path, opts.WithReadOnly(true))
+	require.NoError(t, err)
+
+	_, err = a.ReadAt(bs, 0)
+	require.ErrorIs(t, err, appendable.ErrChecksumMismatch)
+
+	_, err = a.ReadAt(bs[:len(data)-32], 32)
+	require.NoError(t, err)
+	require.Equal(t, data[32:], bs[:len(data)-32])
+
+	err = a.Close()
+	require.NoError(t, err)
+}
+
+func TestMultiAppChunkRotation(t *testing.T) {
+	path := t.TempDir()
+
+	var rotated []int64
+
+	hook := func(appID int64, chunkPath string) {
+		require.Equal(t, filepath.Join(path, appendableName(appID, "aof")), chunkPath)
+
+		// the chunk is no longer written
+		_, err := os.Stat(chunkPath)
+		require.NoError(t, err)
+
+		rotated = append(rotated, appID)
+	}
+
+	a, err := Open(path, DefaultOptions().WithFileSize(8).WithChunkRotationHook(hook))
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte("0123456789abcdefghij"))
+	require.NoError(t, err)
+	require.Equal(t, []int64{0, 1}, rotated)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	t.Run("chunks are rotated by age", func(t *testing.T) {
+		path := t.TempDir()
+
+		rotated = nil
+
+		a, err := Open(path, DefaultOptions().
+			WithFileSize(8).
+			WithMaxChunkAge(10*time.Millisecond).
+			WithChunkRotationHook(func(appID int64, chunkPath string) {
+				rotated = append(rotated, appID)
+			}))
+		require.NoError(t, err)
+
+		off, _, err := a.Append([]byte("ab"))
+		require.NoError(t, err)
+		require.Zero(t, off)
+
+		off, _, err = a.Append([]byte("cd"))
+		require.NoError(t, err)
+		require.Equal(t, int64(2), off)
+		require.Empty(t, rotated)
+
+		time.Sleep(20 * time.Millisecond)
+
+		// the space left in the first chunk is skipped
+		off, _, err = a.Append([]byte("ef"))
+		require.NoError(t, err)
+		require.Equal(t, int64(8), off)
+		require.Equal(t, []int64{0}, rotated)
+
+		err = a.Flush()
+		require.NoError(t, err)
+
+		bs := make([]byte, 4)
+		_, err = a.ReadAt(bs, 0)
+		require.NoError(t, err)
+		require.Equal(t, []byte("abcd"), bs)
+
+		_, err = a.ReadAt(bs[:2], 8)
+		require.NoError(t, err)
+		require.Equal(t, []byte("ef"), bs[:2])
+
+		sz, err := a.Size()
+		require.NoError(t, err)
+		require.Equal(t, int64(10), sz)
+
+		err = a.Close()
+		require.NoError(t, err)
+	})
+}
+
+func TestMultiAppMmap(t *testing.T) {
+	path := t.TempDir()
+
+	a, err := Open(path, DefaultOptions().WithFileSize(16).WithMaxOpenedFiles(1).WithMmap(true))
+	require.NoError(t, err)
+
+	data := []byte("data spread over multiple memory-mapped chunks")
+
+	_, _, err = a.Append(data)
+	require.NoError(t, err)
+
+	err = a.Flush()
+	require.NoError(t, err)
+
+	// rotated chunks are either kept opened or reopened in read-only mode
+	bs := make([]byte, len(data))
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, data, bs)
+
+	// moving the offset back into a mapped chunk makes it writable again
+	err = a.SetOffset(10)
+	require.NoError(t, err)
+
+	_, _, err = a.Append([]byte("overwritten"))
+	require.NoError(t, err)
+
+	bs = make([]byte, 21)
+	_, err = a.ReadAt(bs, 0)
+	require.NoError(t, err)
+	require.Equal(t, append(data[:10:10], []byte("overwritten")...), bs)
+
+	err = a.Close()
+	require.NoError(t, err)
+}
@@ -19,6 +19,7 @@ package multiapp
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/codenotary/immudb/embedded/appendable"
 )
@@ -46,8 +47,24 @@ type Options struct {
 	maxOpenedFiles    int
 	compressionFormat int
 	compressionLevel  int
+
+	encryptionKeyID string
+	keyProvider     appendable.KeyProvider
+
+	checksumBlockSize int  // zero means no checksums are written in new chunks
+	verifyChecksums   bool // if verifyChecksums is enabled, chunks are verified when read
+
+	mmap bool // if mmap is enabled, chunks no longer written are memory-mapped when the platform supports it
+
+	maxChunkAge       time.Duration // zero means chunks are only rotated once full
+	chunkRotationHook ChunkRotationHook
 }
 
+// ChunkRotationHook is called once a chunk has been rotated, it's no longer written
+// unless the offset of the appendable is moved back into it.
+// It's called while appending, thus long-running work should be done asynchronously
+type ChunkRotationHook func(appID int64, chunkPath string)
+
 func DefaultOptions() *Options {
 	return &Options{
 		readOnly:          false,
@@ -89,6 +106,18 @@ func (opts *Options) Validate() error {
 		return fmt.Errorf("%w: invalid writeBufferSize", ErrInvalidOptions)
 	}
 
+	if opts.encryptionKeyID != "" && opts.keyProvider == nil {
+		return fmt.Errorf("%w: invalid keyProvider", ErrInvalidOptions)
+	}
+
+	if opts.checksumBlockSize < 0 {
+		return fmt.Errorf("%w: invalid checksumBlockSize", ErrInvalidOptions)
+	}
+
+	if opts.maxChunkAge < 0 {
+		return fmt.Errorf("%w: invalid maxChunkAge", ErrInvalidOptions)
+	}
+
 	return nil
 }
 
@@ -142,6 +171,53 @@ func (opt *Options) WithCompresionLevel(compressionLevel int) *Options {
 	return opt
 }
 
+// WithEncryption sets the key used to encrypt newly created chunks, thus a key
+// rotation takes effect from the next chunk on. Existing chunks are decrypted
+// with the key recorded in their metadata, resolved through keyProvider
+func (opt *Options) WithEncryption(keyID string, keyProvider appendable.KeyProvider) *Options {
+	opt.encryptionKeyID = keyID
+	opt.keyProvider = keyProvider
+	return opt
+}
+
+// WithChecksums makes newly created chunks store a CRC32C checksum after every blockSize
+// bytes of data, zero disables them. A block size dividing the file size ensures
+// rotated chunks are completely covered by checksums
+func (opt *Options) WithChecksums(blockSize int) *Options {
+	opt.checksumBlockSize = blockSize
+	return opt
+}
+
+// WithVerifyChecksums makes reads fail with appendable.ErrChecksumMismatch when the
+// data read doesn't match its checksum. Chunks without checksums are read as usual
+func (opt *Options) WithVerifyChecksums(verify bool) *Options {
+	opt.verifyChecksums = verify
+	return opt
+}
+
+// WithMmap makes reads of chunks which are no longer written be served from a memory
+// mapping instead of read syscalls, chunks are read as usual when mapping is not supported
+func (opt *Options) WithMmap(mmap bool) *Options {
+	opt.mmap = mmap
+	return opt
+}
+
+// WithMaxChunkAge rotates chunks which have been written for longer than maxAge, even if
+// they are not full. The age of a chunk is measured since it started being written, or since
+// the appendable was opened. The offset of the appendable skips the space left in chunks
+// rotated before being full, thus callers must not expect offsets to be contiguous
+func (opt *Options) WithMaxChunkAge(maxAge time.Duration) *Options {
+	opt.maxChunkAge = maxAge
+	return opt
+}
+
+// WithChunkRotationHook registers a function called every time a chunk is rotated, e.g. to
+// archive it. Chunks are synced before the hook is called
+func (opt *Options) WithChunkRotationHook(hook ChunkRotationHook) *Options {
+	opt.chunkRotationHook = hook
+	return opt
+}
+
 func (opts *Options) WithReadBufferSize(size int) *Options {
 	opts.readBufferSize = size
 	return opts
@@ -160,6 +236,10 @@ func (opt *Options) GetFileMode() os.FileMode {
 	return opt.fileMode
 }
 
+func (opt *Options) GetMetadata() []byte {
+	return opt.metadata
+}
+
 func (opts *Options) GetReadBufferSize() int {
 	return opts.readBufferSize
 }
@@ -167,3 +247,27 @@ func (opts *Options) GetReadBufferSize() int {
 func (opts *Options) GetWriteBufferSize() int {
 	return opts.writeBufferSize
 }
+
+func (opt *Options) GetEncryptionKeyID() string {
+	return opt.encryptionKeyID
+}
+
+func (opt *Options) GetKeyProvider() appendable.KeyProvider {
+	return opt.keyProvider
+}
+
+func (opt *Options) GetMaxChunkAge() time.Duration {
+	return opt.maxChunkAge
+}
+
+func (opt *Options) GetChecksumBlockSize() int {
+	return opt.checksumBlockSize
+}
+
+func (opt *Options) GetVerifyChecksums() bool {
+	return opt.verifyChecksums
+}
+
+func (opt *Options) GetMmap() bool {
+	return opt.mmap
+}
@@ -18,6 +18,7 @@ package multiapp
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -35,6 +36,9 @@ func TestInvalidOptions(t *testing.T) {
 		{"FileExt", DefaultOptions().WithFileExt("")},
 		{"ReadBufferSize", DefaultOptions().WithReadBufferSize(0)},
 		{"WriteBufferSize", DefaultOptions().WithReadOnly(false).WithWriteBufferSize(0)},
+		{"KeyProvider", DefaultOptions().WithEncryption("key1", nil)},
+		{"ChecksumBlockSize", DefaultOptions().WithChecksums(-1)},
+		{"MaxChunkAge", DefaultOptions().WithMaxChunkAge(-1)},
 	} {
 		t.Run(d.n, func(t *testing.T) {
 			require.ErrorIs(t, d.opts.Validate(), ErrInvalidOptions)
@@ -53,9 +57,17 @@ func TestValidOptions(t *testing.T) {
 	require.Equal(t, "aof", opts.WithFileExt("aof").GetFileExt())
 	require.Equal(t, DefaultFileMode, opts.WithFileMode(DefaultFileMode).fileMode)
 	require.Equal(t, DefaultFileMode, opts.WithFileMode(DefaultFileMode).GetFileMode())
+	require.Equal(t, []byte{1, 2, 3}, opts.WithMetadata([]byte{1, 2, 3}).GetMetadata())
 	require.Equal(t, DefaultFileSize, opts.WithFileSize(DefaultFileSize).fileSize)
 	require.Equal(t, DefaultMaxOpenedFiles, opts.WithMaxOpenedFiles(DefaultMaxOpenedFiles).maxOpenedFiles)
 	require.Equal(t, []byte{}, opts.WithMetadata([]byte{}).metadata)
+	require.Equal(t, "key1", opts.WithEncryption("key1", nil).GetEncryptionKeyID())
+	require.Nil(t, opts.WithEncryption("", nil).GetKeyProvider())
+	require.Equal(t, 512, opts.WithChecksums(512).GetChecksumBlockSize())
+	require.True(t, opts.WithVerifyChecksums(true).GetVerifyChecksums())
+	require.True(t, opts.WithMmap(true).GetMmap())
+	require.Equal(t, time.Hour, opts.WithMaxChunkAge(time.Hour).GetMaxChunkAge())
+	require.NotNil(t, opts.WithChunkRotationHook(func(int64, string) {}).chunkRotationHook)
 	require.Equal(t, DefaultCompressionFormat, opts.WithCompressionFormat(DefaultCompressionFormat).compressionFormat)
 	require.Equal(t, DefaultCompressionLevel, opts.WithCompresionLevel(DefaultCompressionLevel).compressionLevel)
 
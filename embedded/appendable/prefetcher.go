@@ -0,0 +1,128 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appendable
+
+import (
+	"io"
+	"sync"
+)
+
+// prefetchSequentialReadsThld is the amount of consecutive sequential reads
+// required before data starts being read ahead
+const prefetchSequentialReadsThld = 2
+
+// Prefetcher wraps a reader so sequential scans made of small reads are served from larger
+// reads issued ahead of the consumer. Reads are considered sequential when they start where
+// the previous one ended, any other access pattern is served by the wrapped reader as is.
+// Data read ahead is assumed not to change, SetLimit excludes data which may still change
+type Prefetcher struct {
+	rAt io.ReaderAt
+
+	windowSize int
+	window     []byte // allocated once data is read ahead
+	windowOff  int64
+	windowLen  int
+
+	limit int64 // data from limit on is not read ahead, negative means no limit
+
+	nextOff         int64 // where the latest read ended
+	sequentialReads int
+
+	mutex sync.Mutex
+}
+
+func NewPrefetcher(rAt io.ReaderAt, windowSize int) *Prefetcher {
+	return &Prefetcher{
+		rAt:        rAt,
+		windowSize: windowSize,
+		limit:      -1,
+		nextOff:    -1,
+	}
+}
+
+// SetLimit prevents data from off on to be read ahead, e.g. because it may be overwritten
+func (p *Prefetcher) SetLimit(off int64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.limit = off
+}
+
+// Reset discards the data read ahead
+func (p *Prefetcher) Reset() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.windowLen = 0
+	p.nextOff = -1
+	p.sequentialReads = 0
+}
+
+func (p *Prefetcher) ReadAt(bs []byte, off int64) (n int, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if off == p.nextOff {
+		p.sequentialReads++
+	} else {
+		p.sequentialReads = 0
+	}
+
+	p.nextOff = off + int64(len(bs))
+
+	if off >= p.windowOff && off < p.windowOff+int64(p.windowLen) {
+		n = copy(bs, p.window[off-p.windowOff:p.windowLen])
+		if n == len(bs) {
+			return n, nil
+		}
+	}
+
+	off += int64(n)
+
+	windowSize := p.windowSize
+	if p.limit >= 0 && p.limit-off < int64(windowSize) {
+		windowSize = int(p.limit - off)
+	}
+
+	if p.sequentialReads < prefetchSequentialReadsThld || windowSize <= len(bs)-n {
+		rn, err := p.rAt.ReadAt(bs[n:], off)
+		return n + rn, err
+	}
+
+	// the consumer is expected to keep reading the data that follows
+	if p.window == nil {
+		p.window = make([]byte, p.windowSize)
+	}
+
+	wn, err := p.rAt.ReadAt(p.window[:windowSize], off)
+	if err != nil && err != io.EOF {
+		p.windowLen = 0
+		return n, err
+	}
+
+	p.windowOff = off
+	p.windowLen = wn
+
+	cn := copy(bs[n:], p.window[:wn])
+	n += cn
+
+	if n < len(bs) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
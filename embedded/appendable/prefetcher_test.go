@@ -0,0 +1,125 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package appendable
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type countingReaderAt struct {
+	rAt   io.ReaderAt
+	reads int
+}
+
+func (r *countingReaderAt) ReadAt(bs []byte, off int64) (int, error) {
+	r.reads++
+	return r.rAt.ReadAt(bs, off)
+}
+
+func TestPrefetcher(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	rAt := &countingReaderAt{rAt: bytes.NewReader(data)}
+
+	p := NewPrefetcher(rAt, 100)
+
+	bs := make([]byte, 10)
+
+	for off := 0; off < len(data); off += len(bs) {
+		_, err := p.ReadAt(bs, int64(off))
+		require.NoError(t, err)
+		require.Equal(t, data[off:off+len(bs)], bs)
+	}
+
+	// two reads before the access is detected as sequential, then a read per window
+	require.Equal(t, 2+10, rAt.reads)
+
+	_, err := p.ReadAt(bs, int64(len(data)))
+	require.ErrorIs(t, err, io.EOF)
+
+	t.Run("random reads are not read ahead", func(t *testing.T) {
+		p.Reset()
+		rAt.reads = 0
+
+		for _, off := range []int64{500, 100, 700, 300} {
+			_, err := p.ReadAt(bs, off)
+			require.NoError(t, err)
+			require.Equal(t, data[off:off+int64(len(bs))], bs)
+		}
+
+		require.Equal(t, 4, rAt.reads)
+	})
+
+	t.Run("reads across the window", func(t *testing.T) {
+		p.Reset()
+
+		bs := make([]byte, 30)
+
+		for off := 0; off+len(bs) <= len(data); off += len(bs) {
+			_, err := p.ReadAt(bs, int64(off))
+			require.NoError(t, err)
+			require.Equal(t, data[off:off+len(bs)], bs)
+		}
+	})
+
+	t.Run("reads larger than the window", func(t *testing.T) {
+		p.Reset()
+
+		bs := make([]byte, 200)
+
+		for off := 0; off < len(data); off += len(bs) {
+			_, err := p.ReadAt(bs, int64(off))
+			require.NoError(t, err)
+			require.Equal(t, data[off:off+len(bs)], bs)
+		}
+	})
+
+	t.Run("data beyond the limit is not read ahead", func(t *testing.T) {
+		p.Reset()
+		p.SetLimit(55)
+		defer p.SetLimit(-1)
+
+		rAt.reads = 0
+
+		for off := 0; off < 100; off += len(bs) {
+			_, err := p.ReadAt(bs, int64(off))
+			require.NoError(t, err)
+			require.Equal(t, data[off:off+len(bs)], bs)
+		}
+
+		// 2 sequential reads, a window up to the limit and 5 more reads
+		require.Equal(t, 2+1+5, rAt.reads)
+	})
+
+	t.Run("read errors", func(t *testing.T) {
+		p := NewPrefetcher(&mockedIOReaderAt{}, 100)
+
+		for off := int64(0); off < 30; off += 10 {
+			_, err := p.ReadAt(bs, off)
+			require.Error(t, err)
+			require.False(t, errors.Is(err, io.EOF))
+		}
+	})
+}
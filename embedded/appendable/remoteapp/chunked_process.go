@@ -18,9 +18,14 @@ package remoteapp
 
 import (
 	"context"
+	"fmt"
 	"math"
 	"math/rand"
+	"sync"
 	"time"
+
+	"github.com/codenotary/immudb/embedded/remotestorage"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type chunkedProcess struct {
@@ -31,6 +36,65 @@ type chunkedProcess struct {
 	retryMaxDelay time.Duration
 	retryDelayExp float64
 	retryJitter   float64
+
+	maxAttempts    int           // 0 means retrying until the context is cancelled
+	requestTimeout time.Duration // 0 means requests are not bounded
+	budget         *retryBudget  // nil means retries are not throttled
+}
+
+// retryBudget throttles retries when most requests are failing, so that an unavailable
+// remote storage is not flooded with them. Every failure consumes a token and every success
+// gives back a fraction of a token, retries are allowed while more than half of the tokens are left
+type retryBudget struct {
+	maxTokens  float64
+	tokenRatio float64
+	tokens     float64
+
+	mutex sync.Mutex
+}
+
+func newRetryBudget(maxTokens, tokenRatio float64) *retryBudget {
+	if maxTokens <= 0 {
+		return nil
+	}
+
+	return &retryBudget{
+		maxTokens:  maxTokens,
+		tokenRatio: tokenRatio,
+		tokens:     maxTokens,
+	}
+}
+
+func (b *retryBudget) succeeded() {
+	if b == nil {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.tokens = math.Min(b.tokens+b.tokenRatio, b.maxTokens)
+}
+
+// failed records a failed request and tells if it can be retried
+func (b *retryBudget) failed() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.tokens = math.Max(b.tokens-1, 0)
+
+	return b.tokens > b.maxTokens/2
+}
+
+func withRequestTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
 }
 
 func (c *chunkedProcess) exponentialBackoff(retries int) time.Duration {
@@ -92,6 +156,44 @@ func (c *chunkedProcess) RetryableStep(
 	}
 }
 
+// RetryableRequest executes a request to the remote storage, retrying it with an exponential
+// backoff delay as long as it fails with an error classified as retryable.
+//
+// Every attempt is bounded by the request timeout. Retries stop once the maximum number of
+// attempts is reached or the retry budget is exhausted, the latest error is then stored
+// as the result of the process
+func (c *chunkedProcess) RetryableRequest(retried prometheus.Counter, request func(ctx context.Context) error) {
+	c.RetryableStep(func(retries int, delay time.Duration) (bool, error) {
+		ctx, cancel := withRequestTimeout(c.ctx, c.requestTimeout)
+		defer cancel()
+
+		err := request(ctx)
+		if err == nil {
+			c.budget.succeeded()
+			return false, nil
+		}
+
+		if c.ctx.Err() != nil {
+			return false, c.ctx.Err()
+		}
+
+		if !remotestorage.IsRetryable(err) {
+			return false, err
+		}
+
+		if c.maxAttempts > 0 && retries+1 >= c.maxAttempts {
+			return false, err
+		}
+
+		if !c.budget.failed() {
+			return false, fmt.Errorf("%w: %v", ErrRetryBudgetExhausted, err)
+		}
+
+		retried.Inc()
+		return true, nil
+	})
+}
+
 func (c *chunkedProcess) Err() error {
 	return c.err
 }
@@ -22,6 +22,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/codenotary/immudb/embedded/remotestorage"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
 )
 
@@ -157,3 +159,135 @@ func TestChunkedProcessNoRetryAfterError(t *testing.T) {
 	require.Equal(t, 1, firstStepCalled)
 	require.Equal(t, 1, secondStepCalled)
 }
+
+func retryableRequestProcess(ctx context.Context) *chunkedProcess {
+	return &chunkedProcess{
+		ctx:           ctx,
+		retryMinDelay: time.Millisecond,
+		retryMaxDelay: 2 * time.Millisecond,
+		retryDelayExp: 2,
+	}
+}
+
+func TestChunkedProcessRetryableRequest(t *testing.T) {
+	retried := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_retried"})
+
+	t.Run("retryable errors are retried until success", func(t *testing.T) {
+		cp := retryableRequestProcess(context.Background())
+
+		attempts := 0
+		cp.RetryableRequest(retried, func(ctx context.Context) error {
+			attempts++
+			if attempts < 3 {
+				return &remotestorage.ResponseError{Err: errors.New("unavailable"), StatusCode: 503}
+			}
+			return nil
+		})
+		require.NoError(t, cp.Err())
+		require.Equal(t, 3, attempts)
+	})
+
+	t.Run("permanent errors are not retried", func(t *testing.T) {
+		cp := retryableRequestProcess(context.Background())
+
+		errPermanent := &remotestorage.ResponseError{Err: errors.New("forbidden"), StatusCode: 403}
+
+		attempts := 0
+		cp.RetryableRequest(retried, func(ctx context.Context) error {
+			attempts++
+			return errPermanent
+		})
+		require.ErrorIs(t, cp.Err(), errPermanent)
+		require.Equal(t, 1, attempts)
+	})
+
+	t.Run("attempts are limited", func(t *testing.T) {
+		cp := retryableRequestProcess(context.Background())
+		cp.maxAttempts = 4
+
+		errTransient := errors.New("transient")
+
+		attempts := 0
+		cp.RetryableRequest(retried, func(ctx context.Context) error {
+			attempts++
+			return errTransient
+		})
+		require.ErrorIs(t, cp.Err(), errTransient)
+		require.Equal(t, 4, attempts)
+	})
+
+	t.Run("requests exceeding the timeout are retried", func(t *testing.T) {
+		cp := retryableRequestProcess(context.Background())
+		cp.requestTimeout = 10 * time.Millisecond
+
+		attempts := 0
+		cp.RetryableRequest(retried, func(ctx context.Context) error {
+			attempts++
+			if attempts == 1 {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+
+			_, hasDeadline := ctx.Deadline()
+			require.True(t, hasDeadline)
+			return nil
+		})
+		require.NoError(t, cp.Err())
+		require.Equal(t, 2, attempts)
+	})
+
+	t.Run("cancelled process is not retried", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cp := retryableRequestProcess(ctx)
+
+		attempts := 0
+		cp.RetryableRequest(retried, func(ctx context.Context) error {
+			attempts++
+			cancel()
+			return errors.New("transient")
+		})
+		require.ErrorIs(t, cp.Err(), context.Canceled)
+		require.Equal(t, 1, attempts)
+	})
+
+	t.Run("retries stop when the budget is exhausted", func(t *testing.T) {
+		cp := retryableRequestProcess(context.Background())
+		cp.budget = newRetryBudget(4, 0.5)
+
+		attempts := 0
+		cp.RetryableRequest(retried, func(ctx context.Context) error {
+			attempts++
+			return errors.New("transient")
+		})
+		require.ErrorIs(t, cp.Err(), ErrRetryBudgetExhausted)
+		require.Equal(t, 2, attempts)
+	})
+}
+
+func TestRetryBudget(t *testing.T) {
+	require.Nil(t, newRetryBudget(0, 0.1))
+
+	var disabled *retryBudget
+	require.True(t, disabled.failed())
+	disabled.succeeded()
+
+	b := newRetryBudget(4, 0.5)
+
+	require.True(t, b.failed())
+	require.False(t, b.failed())
+
+	// successes give back tokens at the configured ratio
+	b.succeeded()
+	b.succeeded()
+	require.False(t, b.failed())
+
+	b.succeeded()
+	b.succeeded()
+	b.succeeded()
+	require.True(t, b.failed())
+
+	for i := 0; i < 20; i++ {
+		b.succeeded()
+	}
+	require.Equal(t, 4.0, b.tokens)
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remoteapp
+
+import (
+	"container/list"
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/codenotary/immudb/embedded/remotestorage"
+)
+
+// DiskCache keeps local copies of the chunks read from a remote storage, so that
+// reopening them does not require downloading them again. Once the cached chunks exceed
+// the size budget, the least recently used ones are evicted. A single cache can be shared
+// by several remote appendables
+type DiskCache struct {
+	dir     string
+	maxSize int64
+
+	size     int64
+	entries  map[string]*list.Element // values of the lru list are *diskCacheEntry
+	lru      *list.List               // the least recently used entry is at the front
+	fetching map[string]chan struct{} // closed once the fetch of the object completes
+
+	hits   uint64
+	misses uint64
+
+	mutex sync.Mutex
+}
+
+type diskCacheEntry struct {
+	name string
+	size int64
+}
+
+// NewDiskCache creates a cache storing up to maxSize bytes of chunks in dir,
+// the content of dir left by previous executions is discarded
+func NewDiskCache(dir string, maxSize int64) (*DiskCache, error) {
+	if dir == "" || maxSize <= 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	err := os.RemoveAll(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DiskCache{
+		dir:      dir,
+		maxSize:  maxSize,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		fetching: make(map[string]chan struct{}),
+	}, nil
+}
+
+// Size returns the amount of bytes currently cached
+func (c *DiskCache) Size() int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.size
+}
+
+// HitRatio returns the fraction of chunk reads served by the cache
+func (c *DiskCache) HitRatio() float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.hitRatio()
+}
+
+func (c *DiskCache) hitRatio() float64 {
+	if c.hits+c.misses == 0 {
+		return 0
+	}
+	return float64(c.hits) / float64(c.hits+c.misses)
+}
+
+func (c *DiskCache) fileName(name string) string {
+	// Escaping keeps the object path in a single file name
+	return filepath.Join(c.dir, url.PathEscape(name))
+}
+
+// open returns the cached copy of a remote object, fetching it if needed
+func (c *DiskCache) open(ctx context.Context, storage remotestorage.Storage, name string) (*os.File, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.entries[name]; ok {
+		c.hits++
+		metricsCacheHits.Inc()
+	} else {
+		c.misses++
+		metricsCacheMisses.Inc()
+	}
+	metricsCacheHitRatio.Set(c.hitRatio())
+
+	for {
+		if e, ok := c.entries[name]; ok {
+			c.lru.MoveToBack(e)
+
+			// The file is opened while holding the lock so that it can not be evicted in the meantime,
+			// once opened it remains readable even if evicted afterwards
+			return os.Open(c.fileName(name))
+		}
+
+		err := c.fetch(ctx, storage, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// prefetch fetches a remote object unless it is already cached or being fetched
+func (c *DiskCache) prefetch(ctx context.Context, storage remotestorage.Storage, name string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	_, cached := c.entries[name]
+	_, fetching := c.fetching[name]
+	if cached || fetching {
+		return nil
+	}
+
+	metricsCachePrefetches.Inc()
+
+	return c.fetch(ctx, storage, name)
+}
+
+// fetch downloads a remote object into the cache, or waits for a concurrent fetch of the same
+// object to complete. It must be called with the mutex locked, which is released while downloading
+func (c *DiskCache) fetch(ctx context.Context, storage remotestorage.Storage, name string) error {
+	if done, ok := c.fetching[name]; ok {
+		c.mutex.Unlock()
+		<-done
+		c.mutex.Lock()
+		return nil
+	}
+
+	done := make(chan struct{})
+	c.fetching[name] = done
+
+	c.mutex.Unlock()
+	size, err := c.download(ctx, storage, name)
+	c.mutex.Lock()
+
+	delete(c.fetching, name)
+	close(done)
+
+	if err != nil {
+		metricsCacheErrors.Inc()
+		return err
+	}
+
+	e := c.lru.PushBack(&diskCacheEntry{name: name, size: size})
+	c.entries[name] = e
+	c.size += size
+
+	// The fetched object is kept even if it does not fit into the budget on its own
+	for c.size > c.maxSize && c.lru.Front() != e {
+		c.evict(c.lru.Front())
+	}
+
+	metricsCacheBytes.Set(float64(c.size))
+
+	return nil
+}
+
+func (c *DiskCache) download(ctx context.Context, storage remotestorage.Storage, name string) (int64, error) {
+	fileName := c.fileName(name)
+
+	// Downloading to a temporary file first, a partially downloaded
+	// object must never be served from the cache
+	fileNameTmp := fileName + ".tmp_download"
+	defer func() { _ = os.Remove(fileNameTmp) }()
+
+	data, err := storage.Get(ctx, name, 0, -1)
+	if err != nil {
+		return 0, err
+	}
+	defer data.Close()
+
+	flTmp, err := os.OpenFile(fileNameTmp, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0600)
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := io.Copy(flTmp, data)
+	if err != nil {
+		flTmp.Close()
+		return 0, err
+	}
+
+	metricsUncachedReads.Inc()
+	metricsUncachedReadBytes.Add(float64(size))
+
+	err = flTmp.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	err = os.Rename(fileNameTmp, fileName)
+	if err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// invalidate discards the cached copy of an object, e.g. because it is about to be replaced
+func (c *DiskCache) invalidate(name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.entries[name]
+	if !ok {
+		return
+	}
+
+	c.evict(e)
+	metricsCacheBytes.Set(float64(c.size))
+}
+
+func (c *DiskCache) evict(e *list.Element) {
+	entry := c.lru.Remove(e).(*diskCacheEntry)
+	delete(c.entries, entry.name)
+	c.size -= entry.size
+
+	// Readers holding the file open can still read from it
+	_ = os.Remove(c.fileName(entry.name))
+
+	metricsCacheEvictions.Inc()
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remoteapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/remotestorage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func readCached(t *testing.T, c *DiskCache, s *remoteStorageMockingWrapper, name string) []byte {
+	f, err := c.open(context.Background(), s, name)
+	require.NoError(t, err)
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(f)
+	require.NoError(t, err)
+
+	return data
+}
+
+func countingStorage(t *testing.T, gets *int64) *remoteStorageMockingWrapper {
+	return &remoteStorageMockingWrapper{
+		wrapped: memory.Open(),
+		fnGet: func(ctx context.Context, name string, offs, size int64, next func() (io.ReadCloser, error)) (io.ReadCloser, error) {
+			atomic.AddInt64(gets, 1)
+			return next()
+		},
+	}
+}
+
+func TestNewDiskCacheInvalidArguments(t *testing.T) {
+	_, err := NewDiskCache("", 100)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = NewDiskCache(t.TempDir(), 0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
+
+func TestDiskCache(t *testing.T) {
+	var gets int64
+	s := countingStorage(t, &gets)
+
+	for i := 0; i < 4; i++ {
+		storeData(t, s, fmt.Sprintf("dir/obj%d", i), []byte(fmt.Sprintf("data of object %d", i)))
+	}
+
+	dir := filepath.Join(t.TempDir(), "cache")
+
+	c, err := NewDiskCache(dir, 50)
+	require.NoError(t, err)
+
+	// The first read is a miss, the following ones are served by the cache
+	require.Equal(t, []byte("data of object 0"), readCached(t, c, s, "dir/obj0"))
+	require.Equal(t, []byte("data of object 0"), readCached(t, c, s, "dir/obj0"))
+	require.EqualValues(t, 1, gets)
+	require.EqualValues(t, 16, c.Size())
+	require.Equal(t, 0.5, c.HitRatio())
+	require.FileExists(t, filepath.Join(dir, "dir%2Fobj0"))
+
+	require.Equal(t, []byte("data of object 1"), readCached(t, c, s, "dir/obj1"))
+	require.Equal(t, []byte("data of object 2"), readCached(t, c, s, "dir/obj2"))
+	require.EqualValues(t, 3, gets)
+	require.EqualValues(t, 48, c.Size())
+
+	// obj0 is the least recently used one, it gets evicted to make room for obj3
+	f, err := c.open(context.Background(), s, "dir/obj0")
+	require.NoError(t, err)
+	readCached(t, c, s, "dir/obj1")
+	readCached(t, c, s, "dir/obj2")
+	readCached(t, c, s, "dir/obj3")
+	require.EqualValues(t, 4, gets)
+	require.EqualValues(t, 48, c.Size())
+	require.NoFileExists(t, filepath.Join(dir, "dir%2Fobj0"))
+
+	// Files opened before the eviction remain readable
+	data, err := ioutil.ReadAll(f)
+	require.NoError(t, err)
+	require.Equal(t, []byte("data of object 0"), data)
+	require.NoError(t, f.Close())
+
+	readCached(t, c, s, "dir/obj0")
+	require.EqualValues(t, 5, gets)
+
+	t.Run("invalidated objects are fetched again", func(t *testing.T) {
+		storeData(t, s, "dir/obj0", []byte("new data of obj 0"))
+		c.invalidate("dir/obj0")
+
+		require.Equal(t, []byte("new data of obj 0"), readCached(t, c, s, "dir/obj0"))
+		require.EqualValues(t, 6, gets)
+	})
+
+	t.Run("prefetched objects are served by the cache", func(t *testing.T) {
+		c.invalidate("dir/obj1")
+
+		require.NoError(t, c.prefetch(context.Background(), s, "dir/obj1"))
+		require.EqualValues(t, 7, gets)
+
+		// Already cached
+		require.NoError(t, c.prefetch(context.Background(), s, "dir/obj1"))
+		require.EqualValues(t, 7, gets)
+
+		readCached(t, c, s, "dir/obj1")
+		require.EqualValues(t, 7, gets)
+	})
+
+	t.Run("objects larger than the budget are cached until the next fetch", func(t *testing.T) {
+		storeData(t, s, "dir/large", make([]byte, 100))
+
+		require.Len(t, readCached(t, c, s, "dir/large"), 100)
+		require.EqualValues(t, 100, c.Size())
+
+		readCached(t, c, s, "dir/obj2")
+		require.EqualValues(t, 16, c.Size())
+	})
+
+	t.Run("read errors", func(t *testing.T) {
+		_, err := c.open(context.Background(), s, "dir/missing")
+		require.Error(t, err)
+
+		require.NoFileExists(t, filepath.Join(dir, "dir%2Fmissing"))
+		require.NoFileExists(t, filepath.Join(dir, "dir%2Fmissing.tmp_download"))
+	})
+
+	t.Run("previous content is discarded", func(t *testing.T) {
+		_, err := NewDiskCache(dir, 50)
+		require.NoError(t, err)
+
+		fis, err := ioutil.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, fis)
+	})
+}
+
+func TestDiskCacheConcurrentReads(t *testing.T) {
+	var gets int64
+	s := countingStorage(t, &gets)
+
+	storeData(t, s, "obj", []byte("data"))
+
+	// Slow down the download so that all readers wait for the same fetch
+	release := make(chan struct{})
+	s.fnGet = func(ctx context.Context, name string, offs, size int64, next func() (io.ReadCloser, error)) (io.ReadCloser, error) {
+		atomic.AddInt64(&gets, 1)
+		<-release
+		return next()
+	}
+
+	c, err := NewDiskCache(t.TempDir(), 100)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.Equal(t, []byte("data"), readCached(t, c, s, "obj"))
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require.EqualValues(t, 1, gets)
+}
+
+func TestDiskCacheFetchError(t *testing.T) {
+	errInjected := errors.New("injected error")
+
+	s := &remoteStorageMockingWrapper{
+		wrapped: memory.Open(),
+		fnGet: func(ctx context.Context, name string, offs, size int64, next func() (io.ReadCloser, error)) (io.ReadCloser, error) {
+			return nil, errInjected
+		},
+	}
+
+	c, err := NewDiskCache(t.TempDir(), 100)
+	require.NoError(t, err)
+
+	_, err = c.open(context.Background(), s, "obj")
+	require.ErrorIs(t, err, errInjected)
+
+	err = c.prefetch(context.Background(), s, "obj")
+	require.ErrorIs(t, err, errInjected)
+
+	require.EqualValues(t, 0, c.Size())
+}
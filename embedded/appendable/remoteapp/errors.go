@@ -26,6 +26,8 @@ var (
 	ErrInvalidChunkState       = errors.New("invalid chunk state")
 	ErrChunkUploaded           = errors.New("already uploaded chunk is not writable")
 	ErrCompressionNotSupported = errors.New("compression is currently not supported")
+	ErrEncryptionNotSupported  = errors.New("encryption is currently not supported")
 	ErrCantDownload            = errors.New("can not download chunk")
 	ErrCorruptedMetadata       = errors.New("corrupted metadata in a remote chunk")
+	ErrRetryBudgetExhausted    = errors.New("retry budget exhausted")
 )
@@ -42,9 +42,10 @@ var (
 		Help: "Direct (uncached) read event counters for immudb remote storage",
 	}, []string{"event"})
 
-	metricsUncachedReads      = metricsUncachedReadEvents.WithLabelValues("total_reads")
-	metricsUncachedReadErrors = metricsUncachedReadEvents.WithLabelValues("errors")
-	metricsUncachedReadBytes  = promauto.NewCounter(prometheus.CounterOpts{
+	metricsUncachedReads       = metricsUncachedReadEvents.WithLabelValues("total_reads")
+	metricsUncachedReadErrors  = metricsUncachedReadEvents.WithLabelValues("errors")
+	metricsUncachedReadRetried = metricsUncachedReadEvents.WithLabelValues("retried")
+	metricsUncachedReadBytes   = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "immudb_remoteapp_uncached_read_bytes",
 		Help: "Direct (uncached) read byte counters for immudb remote storage",
 	})
@@ -62,6 +63,29 @@ var (
 		Help: "Total number of bytes read from immudb remote storage (including cached reads)",
 	})
 
+	// ---- Disk cache ---------------------------------------
+
+	metricsCacheEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "immudb_remoteapp_cache_events",
+		Help: "Disk cache event counters for immudb remote storage",
+	}, []string{"event"})
+
+	metricsCacheHits       = metricsCacheEvents.WithLabelValues("hits")
+	metricsCacheMisses     = metricsCacheEvents.WithLabelValues("misses")
+	metricsCacheEvictions  = metricsCacheEvents.WithLabelValues("evictions")
+	metricsCachePrefetches = metricsCacheEvents.WithLabelValues("prefetches")
+	metricsCacheErrors     = metricsCacheEvents.WithLabelValues("errors")
+
+	metricsCacheHitRatio = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "immudb_remoteapp_cache_hit_ratio",
+		Help: "Fraction of remote chunk reads served by the disk cache",
+	})
+
+	metricsCacheBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "immudb_remoteapp_cache_bytes",
+		Help: "Number of bytes of remote chunks stored in the disk cache",
+	})
+
 	// ---- Uploads ---------------------------------------
 
 	metricsUploadEvents = promauto.NewCounterVec(prometheus.CounterOpts{
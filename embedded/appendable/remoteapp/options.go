@@ -17,9 +17,11 @@ limitations under the License.
 package remoteapp
 
 import (
+	"os"
 	"time"
 
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/pkg/logger"
 )
 
 type Options struct {
@@ -30,6 +32,16 @@ type Options struct {
 	retryMaxDelay    time.Duration
 	retryDelayExp    float64
 	retryDelayJitter float64
+
+	retryMaxAttempts      int
+	retryBudgetTokens     float64
+	retryBudgetTokenRatio float64
+	requestTimeout        time.Duration
+
+	diskCache           *DiskCache
+	cachePrefetchChunks int
+
+	logger logger.Logger
 }
 
 func DefaultOptions() *Options {
@@ -40,6 +52,14 @@ func DefaultOptions() *Options {
 		retryMaxDelay:    2 * time.Minute,
 		retryDelayExp:    2,
 		retryDelayJitter: 0.1,
+
+		retryMaxAttempts:      5,
+		retryBudgetTokens:     10,
+		retryBudgetTokenRatio: 0.1,
+
+		cachePrefetchChunks: 1,
+
+		logger: logger.NewSimpleLogger("immudb ", os.Stderr),
 	}
 }
 
@@ -52,7 +72,13 @@ func (opts *Options) Valid() bool {
 		opts.parallelUploads < 100000 &&
 		opts.retryMinDelay > 0 &&
 		opts.retryMaxDelay > 0 &&
-		opts.retryDelayExp > 1
+		opts.retryDelayExp > 1 &&
+		opts.retryMaxAttempts > 0 &&
+		opts.retryBudgetTokens >= 0 &&
+		opts.retryBudgetTokenRatio >= 0 &&
+		opts.requestTimeout >= 0 &&
+		opts.cachePrefetchChunks >= 0 &&
+		opts.logger != nil
 }
 
 func (opts *Options) WithParallelUploads(parallelUploads int) *Options {
@@ -79,3 +105,47 @@ func (opts *Options) WithRetryDelayJitter(retryDelayJitter float64) *Options {
 	opts.retryDelayJitter = retryDelayJitter
 	return opts
 }
+
+// WithRetryMaxAttempts sets how many times requests reading remote chunks are attempted before the
+// failure is returned. Background uploads and downloads are retried until they succeed or fail permanently
+func (opts *Options) WithRetryMaxAttempts(retryMaxAttempts int) *Options {
+	opts.retryMaxAttempts = retryMaxAttempts
+	return opts
+}
+
+// WithRetryBudgetTokens sets the size of the retry budget, every failed read consumes a token and
+// retries are only attempted while more than half of the tokens are available. 0 disables the budget
+func (opts *Options) WithRetryBudgetTokens(retryBudgetTokens float64) *Options {
+	opts.retryBudgetTokens = retryBudgetTokens
+	return opts
+}
+
+// WithRetryBudgetTokenRatio sets the fraction of a token given back to the retry budget by every successful read
+func (opts *Options) WithRetryBudgetTokenRatio(retryBudgetTokenRatio float64) *Options {
+	opts.retryBudgetTokenRatio = retryBudgetTokenRatio
+	return opts
+}
+
+// WithRequestTimeout bounds the duration of every request to the remote storage, 0 means no timeout
+func (opts *Options) WithRequestTimeout(requestTimeout time.Duration) *Options {
+	opts.requestTimeout = requestTimeout
+	return opts
+}
+
+// WithDiskCache reads remote chunks through a disk cache, which may be shared with other appendables
+func (opts *Options) WithDiskCache(diskCache *DiskCache) *Options {
+	opts.diskCache = diskCache
+	return opts
+}
+
+// WithCachePrefetchChunks sets how many of the chunks following a remote chunk being read
+// are fetched into the disk cache in the background
+func (opts *Options) WithCachePrefetchChunks(cachePrefetchChunks int) *Options {
+	opts.cachePrefetchChunks = cachePrefetchChunks
+	return opts
+}
+
+func (opts *Options) WithLogger(logger logger.Logger) *Options {
+	opts.logger = logger
+	return opts
+}
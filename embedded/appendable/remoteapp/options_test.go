@@ -20,12 +20,19 @@ import (
 	"testing"
 	"time"
 
+	"github.com/codenotary/immudb/pkg/logger"
 	"github.com/stretchr/testify/require"
 )
 
 func TestInvalidOptions(t *testing.T) {
 	require.False(t, (*Options)(nil).Valid())
 	require.False(t, (&Options{}).Valid())
+	require.False(t, DefaultOptions().WithCachePrefetchChunks(-1).Valid())
+	require.False(t, DefaultOptions().WithRetryMaxAttempts(0).Valid())
+	require.False(t, DefaultOptions().WithRetryBudgetTokens(-1).Valid())
+	require.False(t, DefaultOptions().WithRetryBudgetTokenRatio(-0.1).Valid())
+	require.False(t, DefaultOptions().WithRequestTimeout(-time.Second).Valid())
+	require.False(t, DefaultOptions().WithLogger(nil).Valid())
 }
 
 func TestDefaultOptions(t *testing.T) {
@@ -40,6 +47,18 @@ func TestValidOptions(t *testing.T) {
 	require.Equal(t, 7*time.Second, opts.WithRetryMaxDelay(7*time.Second).retryMaxDelay)
 	require.Equal(t, 1.3, opts.WithRetryDelayExp(1.3).retryDelayExp)
 	require.Equal(t, 0.2, opts.WithRetryDelayJitter(0.2).retryDelayJitter)
+	require.Equal(t, 3, opts.WithCachePrefetchChunks(3).cachePrefetchChunks)
+	require.Equal(t, 2, opts.WithRetryMaxAttempts(2).retryMaxAttempts)
+	require.Equal(t, 20.0, opts.WithRetryBudgetTokens(20).retryBudgetTokens)
+	require.Equal(t, 0.5, opts.WithRetryBudgetTokenRatio(0.5).retryBudgetTokenRatio)
+	require.Equal(t, 5*time.Second, opts.WithRequestTimeout(5*time.Second).requestTimeout)
+
+	diskCache, err := NewDiskCache(t.TempDir(), 1024)
+	require.NoError(t, err)
+	require.Equal(t, diskCache, opts.WithDiskCache(diskCache).diskCache)
+
+	l := logger.NewMemoryLogger()
+	require.Equal(t, l, opts.WithLogger(l).logger)
 
 	require.True(t, opts.Valid())
 }
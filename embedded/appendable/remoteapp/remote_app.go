@@ -22,7 +22,6 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"os"
 	"path"
 	"path/filepath"
@@ -37,6 +36,7 @@ import (
 	"github.com/codenotary/immudb/embedded/appendable/singleapp"
 	"github.com/codenotary/immudb/embedded/cache"
 	"github.com/codenotary/immudb/embedded/remotestorage"
+	"github.com/codenotary/immudb/pkg/logger"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -63,6 +63,16 @@ type RemoteStorageAppendable struct {
 	retryDelayExp float64
 	retryJitter   float64
 
+	retryMaxAttempts int
+	retryBudget      *retryBudget
+	requestTimeout   time.Duration
+
+	diskCache           *DiskCache
+	cachePrefetchChunks int
+	prefetchWaitGroup   sync.WaitGroup
+
+	logger logger.Logger
+
 	mainContext           context.Context
 	mainCancelFunc        context.CancelFunc
 	uploadThrottler       chan struct{}
@@ -87,23 +97,29 @@ func Open(path string, remotePath string, storage remotestorage.Storage, opts *O
 		return nil, ErrIllegalArguments
 	}
 
-	log.Printf("Opening remote storage at %s%s", storage, remotePath)
+	opts.logger.Infof("Opening remote storage at %s%s", storage, remotePath)
 
 	mainContext, mainCancelFunc := context.WithCancel(context.Background())
 
 	ret := &RemoteStorageAppendable{
-		rStorage:        storage,
-		path:            path,
-		fileExt:         opts.GetFileExt(),
-		fileMode:        opts.GetFileMode(),
-		remotePath:      remotePath,
-		retryMinDelay:   opts.retryMinDelay,
-		retryMaxDelay:   opts.retryMaxDelay,
-		retryDelayExp:   opts.retryDelayExp,
-		retryJitter:     opts.retryDelayJitter,
-		mainContext:     mainContext,
-		mainCancelFunc:  mainCancelFunc,
-		uploadThrottler: make(chan struct{}, opts.parallelUploads),
+		rStorage:            storage,
+		path:                path,
+		fileExt:             opts.GetFileExt(),
+		fileMode:            opts.GetFileMode(),
+		remotePath:          remotePath,
+		retryMinDelay:       opts.retryMinDelay,
+		retryMaxDelay:       opts.retryMaxDelay,
+		retryDelayExp:       opts.retryDelayExp,
+		retryJitter:         opts.retryDelayJitter,
+		retryMaxAttempts:    opts.retryMaxAttempts,
+		retryBudget:         newRetryBudget(opts.retryBudgetTokens, opts.retryBudgetTokenRatio),
+		requestTimeout:      opts.requestTimeout,
+		diskCache:           opts.diskCache,
+		cachePrefetchChunks: opts.cachePrefetchChunks,
+		mainContext:         mainContext,
+		mainCancelFunc:      mainCancelFunc,
+		uploadThrottler:     make(chan struct{}, opts.parallelUploads),
+		logger:              opts.logger,
 	}
 	ret.chunkUploadFinished = sync.NewCond(&ret.mutex)
 	ret.chunkDownloadFinished = sync.NewCond(&ret.mutex)
@@ -133,21 +149,33 @@ func chunkIdFromName(filename string) (int64, error) {
 	return strconv.ParseInt(strings.TrimSuffix(filename, filepath.Ext(filename)), 10, 64)
 }
 
+// chunkedProcess creates a process for background operations, retryable requests
+// are retried until they succeed, fail permanently or the context is cancelled
 func (r *RemoteStorageAppendable) chunkedProcess(ctx context.Context) *chunkedProcess {
 	return &chunkedProcess{
-		ctx:           ctx,
-		retryMinDelay: r.retryMinDelay,
-		retryMaxDelay: r.retryMaxDelay,
-		retryDelayExp: r.retryDelayExp,
-		retryJitter:   r.retryJitter,
+		ctx:            ctx,
+		retryMinDelay:  r.retryMinDelay,
+		retryMaxDelay:  r.retryMaxDelay,
+		retryDelayExp:  r.retryDelayExp,
+		retryJitter:    r.retryJitter,
+		requestTimeout: r.requestTimeout,
 	}
 }
 
+// requestProcess creates a process for requests a caller is waiting for,
+// retries are limited in number and throttled by the retry budget
+func (r *RemoteStorageAppendable) requestProcess(ctx context.Context) *chunkedProcess {
+	cp := r.chunkedProcess(ctx)
+	cp.maxAttempts = r.retryMaxAttempts
+	cp.budget = r.retryBudget
+	return cp
+}
+
 func (r *RemoteStorageAppendable) uploadFinished(chunkID int64, state chunkState) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	log.Printf("Uploadnig of chunk %d finished in state: %v", chunkID, state)
+	r.logger.Infof("Uploading of chunk %d finished in state: %v", chunkID, state)
 
 	r.chunkInfos[chunkID].state = state
 	r.chunkInfos[chunkID].cancelUpload = nil
@@ -203,39 +231,37 @@ func (r *RemoteStorageAppendable) uploadChunk(chunkID int64, dontRemoveFile bool
 			return nil
 		})
 
-		// Upload the chunk
-		cp.RetryableStep(func(retries int, delay time.Duration) (bool, error) {
-			defer prometheus.NewTimer(metricsUploadTime).ObserveDuration()
-			err := r.rStorage.Put(ctx, r.remotePath+appName, fileName)
-			if err == nil {
-				return false, nil
+		// A copy of a previous version of the chunk must not be read anymore
+		cp.Step(func() error {
+			if r.diskCache != nil {
+				r.diskCache.invalidate(r.remotePath + appName)
 			}
+			return nil
+		})
 
-			metricsUploadRetried.Inc()
-			return true, nil
+		// Upload the chunk
+		cp.RetryableRequest(metricsUploadRetried, func(ctx context.Context) error {
+			defer prometheus.NewTimer(metricsUploadTime).ObserveDuration()
+			return r.rStorage.Put(ctx, r.remotePath+appName, fileName)
 		})
 
 		// Wait for the chunk to become ready
-		cp.RetryableStep(func(retries int, delay time.Duration) (bool, error) {
+		cp.RetryableRequest(metricsUploadRetried, func(ctx context.Context) error {
 			exists, err := r.rStorage.Exists(ctx, r.remotePath+appName)
-			if err == nil && exists {
-				return false, nil
+			if err == nil && !exists {
+				return ErrMissingRemoteChunk
 			}
-
-			metricsUploadRetried.Inc()
-			return true, nil
+			return err
 		})
 
 		// Open new appendable from the remote storage
-		cp.RetryableStep(func(retries int, delay time.Duration) (bool, error) {
-			app, err := r.openRemoteAppendableReader(appName)
-			if err == nil {
-				newApp = app
-				return false, nil
+		cp.RetryableRequest(metricsUploadRetried, func(ctx context.Context) error {
+			app, err := r.openRemoteAppendableReader(ctx, appName)
+			if err != nil {
+				return err
 			}
-
-			metricsUploadRetried.Inc()
-			return true, nil
+			newApp = app
+			return nil
 		})
 
 		// Replace the cached instance of appendable for the chunk
@@ -275,14 +301,14 @@ func (r *RemoteStorageAppendable) uploadChunk(chunkID int64, dontRemoveFile bool
 
 		if ctx.Err() != nil {
 			// Context has been cancelled
-			log.Printf("Uploading chunk %d cancelled", chunkID)
+			r.logger.Warningf("Uploading chunk %d cancelled", chunkID)
 			r.uploadFinished(chunkID, chunkState_Local)
 			metricsUploadCancelled.Inc()
 			return
 		}
 
 		if cp.Err() != nil {
-			log.Printf("Uploading chunk %d failed: %v", chunkID, cp.Err())
+			r.logger.Errorf("Uploading chunk %d failed: %v", chunkID, cp.Err())
 			r.uploadFinished(chunkID, chunkState_UploadError)
 			metricsUploadFailed.Inc()
 			return
@@ -298,7 +324,7 @@ func (r *RemoteStorageAppendable) downloadFinished(chunkID int64, state chunkSta
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	log.Printf("Downloading of chunk %d finished in state: %v", chunkID, state)
+	r.logger.Infof("Downloading of chunk %d finished in state: %v", chunkID, state)
 
 	r.chunkInfos[chunkID].state = state
 	r.chunkInfos[chunkID].cancelUpload = nil
@@ -331,27 +357,27 @@ func (r *RemoteStorageAppendable) downloadChunk(chunkID int64) {
 		defer func() { flTmp.Close() }()
 
 		cp := r.chunkedProcess(ctx)
-		cp.RetryableStep(func(retries int, delay time.Duration) (retryNeeded bool, err error) {
-
+		cp.RetryableRequest(metricsDownloadRetried, func(ctx context.Context) error {
 			data, err := r.rStorage.Get(ctx, r.remotePath+r.appendableName(chunkID), 0, -1)
 			if err != nil {
-				metricsDownloadRetried.Inc()
-				return true, nil
+				return err
 			}
 			defer data.Close()
 
-			flTmp, err = os.OpenFile(fileNameTmp, os.O_CREATE|os.O_RDWR, r.fileMode)
-			if err != nil {
-				// Couldn't create temporary local file, something is broken on local FS
-				return false, err
+			if flTmp != nil {
+				// Leftover of a previous attempt
+				flTmp.Close()
 			}
 
-			_, err = io.Copy(flTmp, data)
+			// Failing to create the temporary local file is not retried,
+			// something is broken on local FS
+			flTmp, err = os.OpenFile(fileNameTmp, os.O_CREATE|os.O_TRUNC|os.O_RDWR, r.fileMode)
 			if err != nil {
-				metricsDownloadRetried.Inc()
-				return true, nil
+				return err
 			}
-			return false, nil
+
+			_, err = io.Copy(flTmp, data)
+			return err
 		})
 		cp.Step(func() error {
 			return flTmp.Sync()
@@ -369,14 +395,14 @@ func (r *RemoteStorageAppendable) downloadChunk(chunkID int64) {
 		})
 
 		if ctx.Err() != nil {
-			log.Printf("Downloading chunk %d cancelled", chunkID)
+			r.logger.Warningf("Downloading chunk %d cancelled", chunkID)
 			metricsDownloadCancelled.Inc()
 			r.downloadFinished(chunkID, chunkState_DownloadError)
 			return
 		}
 
 		if cp.Err() != nil {
-			log.Printf("Downloading chunk %d failed: %v", chunkID, cp.Err())
+			r.logger.Errorf("Downloading chunk %d failed: %v", chunkID, cp.Err())
 			metricsDownloadFailed.Inc()
 			r.downloadFinished(chunkID, chunkState_DownloadError)
 			return
@@ -409,6 +435,7 @@ func (r *RemoteStorageAppendable) Close() error {
 
 	r.mainCancelFunc()
 	r.statsUpdaterWaitGroup.Wait()
+	r.prefetchWaitGroup.Wait()
 	return nil
 }
 
@@ -417,6 +444,10 @@ func (r *RemoteStorageAppendable) OpenAppendable(options *singleapp.Options, app
 		return nil, ErrCompressionNotSupported
 	}
 
+	if options.GetEncryptionKeyID() != "" {
+		return nil, ErrEncryptionNotSupported
+	}
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -489,7 +520,9 @@ func (r *RemoteStorageAppendable) OpenAppendable(options *singleapp.Options, app
 				continue
 			}
 
-			return r.openRemoteAppendableReader(appname)
+			r.prefetchChunks(appID)
+
+			return r.openRemoteAppendable(appname)
 
 		case chunkState_Downloading:
 			r.chunkDownloadFinished.Wait()
@@ -501,7 +534,7 @@ func (r *RemoteStorageAppendable) OpenAppendable(options *singleapp.Options, app
 			}
 			// Even though the chunk couldn't be downloaded locally,
 			// it's still available remotely and we should be able to read from it
-			return r.openRemoteAppendableReader(appname)
+			return r.openRemoteAppendable(appname)
 
 		default:
 			return nil, ErrInvalidChunkState
@@ -542,9 +575,16 @@ func (r *RemoteStorageAppendable) OpenInitialAppendable(opts *multiapp.Options,
 	}
 
 	// Scan remote chunks
-	remoteEntries, _, err := r.rStorage.ListEntries(context.Background(), r.remotePath)
-	if err != nil {
-		return nil, 0, err
+	var remoteEntries []remotestorage.EntryInfo
+
+	cp := r.requestProcess(r.mainContext)
+	cp.RetryableRequest(metricsUncachedReadRetried, func(ctx context.Context) error {
+		var err error
+		remoteEntries, _, err = r.rStorage.ListEntries(ctx, r.remotePath)
+		return err
+	})
+	if cp.Err() != nil {
+		return nil, 0, cp.Err()
 	}
 
 	for _, entry := range remoteEntries {
@@ -567,7 +607,7 @@ func (r *RemoteStorageAppendable) OpenInitialAppendable(opts *multiapp.Options,
 				// Chunk size can only grow in size,
 				// if the local file is smaller than the remote object,
 				// there must have been some corruption of local file
-				log.Printf("Chunk validation failed, remote chunk %d has more data than the local file", id)
+				r.logger.Errorf("Chunk validation failed, remote chunk %d has more data than the local file", id)
 				return nil, 0, ErrInvalidRemoteStorage
 			}
 		} else {
@@ -580,7 +620,7 @@ func (r *RemoteStorageAppendable) OpenInitialAppendable(opts *multiapp.Options,
 	for id, info := range chunkInfos {
 		if info.state == chunkState_Invalid {
 			// Chunk was not found in neither local nor remote storage
-			log.Printf("Chunk validation failed, missing chunk %d", id)
+			r.logger.Errorf("Chunk validation failed, missing chunk %d", id)
 			return nil, 0, ErrMissingRemoteChunk
 		}
 	}
@@ -605,13 +645,70 @@ func (r *RemoteStorageAppendable) OpenInitialAppendable(opts *multiapp.Options,
 	return app, appID, nil
 }
 
-func (r *RemoteStorageAppendable) openRemoteAppendableReader(name string) (appendable.Appendable, error) {
+// openRemoteAppendable opens a reader for a remote chunk, retrying
+// failed requests as long as the retry policy allows it
+func (r *RemoteStorageAppendable) openRemoteAppendable(name string) (appendable.Appendable, error) {
+	var app appendable.Appendable
+
+	cp := r.requestProcess(r.mainContext)
+	cp.RetryableRequest(metricsUncachedReadRetried, func(ctx context.Context) error {
+		var err error
+		app, err = r.openRemoteAppendableReader(ctx, name)
+		return err
+	})
+	if cp.Err() != nil {
+		return nil, cp.Err()
+	}
+
+	return app, nil
+}
+
+func (r *RemoteStorageAppendable) openRemoteAppendableReader(ctx context.Context, name string) (appendable.Appendable, error) {
+	if r.diskCache != nil {
+		return openCachedRemoteStorageReader(
+			ctx,
+			r.diskCache,
+			r.rStorage,
+			r.remotePath+name,
+		)
+	}
+
 	return openRemoteStorageReader(
+		ctx,
 		r.rStorage,
 		r.remotePath+name,
 	)
 }
 
+// prefetchChunks fetches the remote chunks following appID into the disk cache,
+// sequential reads are likely to need them next. Must be called with the mutex locked
+func (r *RemoteStorageAppendable) prefetchChunks(appID int64) {
+	if r.diskCache == nil {
+		return
+	}
+
+	for id := appID + 1; id <= appID+int64(r.cachePrefetchChunks) && id < int64(len(r.chunkInfos)); id++ {
+		if r.chunkInfos[id].state != chunkState_Remote {
+			continue
+		}
+
+		name := r.remotePath + r.appendableName(id)
+
+		r.prefetchWaitGroup.Add(1)
+		go func() {
+			defer r.prefetchWaitGroup.Done()
+
+			ctx, cancel := withRequestTimeout(r.mainContext, r.requestTimeout)
+			defer cancel()
+
+			err := r.diskCache.prefetch(ctx, r.rStorage, name)
+			if err != nil && r.mainContext.Err() == nil {
+				r.logger.Warningf("Prefetching of %s failed: %v", name, err)
+			}
+		}()
+	}
+}
+
 func (r *RemoteStorageAppendable) startStatsUpdater() {
 	r.statsUpdaterWaitGroup.Add(1)
 	go func() {
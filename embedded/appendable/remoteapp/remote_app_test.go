@@ -92,6 +92,17 @@ func TestOpenRemoteStorageAppendableCompression(t *testing.T) {
 	require.Nil(t, app)
 }
 
+func TestOpenRemoteStorageAppendableEncryption(t *testing.T) {
+	opts := DefaultOptions()
+	opts.WithEncryption("key1", func(keyID string) ([]byte, error) {
+		return make([]byte, 32), nil
+	})
+
+	app, err := Open(t.TempDir(), "", memory.Open(), opts)
+	require.ErrorIs(t, err, ErrEncryptionNotSupported)
+	require.Nil(t, app)
+}
+
 func TestRemoteStorageOpenAppendableInvalidName(t *testing.T) {
 	app, err := Open(t.TempDir(), "", memory.Open(), DefaultOptions())
 	require.NoError(t, err)
@@ -929,3 +940,59 @@ func TestRemoteStorageOpenInitialAppendableCorruptedLocalFile(t *testing.T) {
 	require.Equal(t, ErrInvalidRemoteStorage, err)
 	require.Nil(t, app)
 }
+
+func TestRemoteStorageDiskCache(t *testing.T) {
+	path := t.TempDir()
+
+	var gets int64
+	mem := countingStorage(t, &gets)
+
+	opts := DefaultOptions()
+	opts.WithFileExt("tst")
+	opts.WithFileSize(10)
+	opts.WithMaxOpenedFiles(1)
+
+	app, err := Open(path, "", mem, opts)
+	require.NoError(t, err)
+
+	dataWritten := []byte("Some pretty long string to cross a chunk boundary")
+
+	_, _, err = app.Append(dataWritten)
+	require.NoError(t, err)
+
+	err = app.Close()
+	require.NoError(t, err)
+
+	err = os.RemoveAll(path)
+	require.NoError(t, err)
+
+	diskCache, err := NewDiskCache(t.TempDir(), 1<<20)
+	require.NoError(t, err)
+
+	app, err = Open(path, "", mem, opts.WithDiskCache(diskCache).WithCachePrefetchChunks(2))
+	require.NoError(t, err)
+	defer app.Close()
+
+	require.True(t, waitForFile(fmt.Sprintf("%s/00000004.tst", path), time.Second))
+
+	readAll := func() {
+		dataRead := make([]byte, len(dataWritten))
+		n, err := app.ReadAt(dataRead, 0)
+		require.NoError(t, err)
+		require.EqualValues(t, len(dataWritten), n)
+		require.Equal(t, dataWritten, dataRead)
+	}
+
+	getsBefore := atomic.LoadInt64(&gets)
+
+	readAll()
+
+	// Chunks 0 to 3 are read from the remote storage once, either when opened or prefetched
+	app.prefetchWaitGroup.Wait()
+	require.EqualValues(t, 4, atomic.LoadInt64(&gets)-getsBefore)
+
+	// Only one chunk is kept opened, reopening them is served by the disk cache
+	readAll()
+	require.EqualValues(t, 4, atomic.LoadInt64(&gets)-getsBefore)
+	require.Greater(t, diskCache.HitRatio(), 0.0)
+}
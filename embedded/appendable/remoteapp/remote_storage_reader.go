@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"io"
 	"io/ioutil"
+	"os"
 
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/remotestorage"
@@ -15,14 +16,13 @@ type remoteStorageReader struct {
 	r          remotestorage.Storage
 	name       string
 	baseOffset int64
-	dataCache  []byte // Initially we read the whole object into data cache
+	dataCache  []byte   // Initially we read the whole object into data cache
+	cachedFile *os.File // Set instead of the data cache when reading through the disk cache
 }
 
-func openRemoteStorageReader(r remotestorage.Storage, name string) (*remoteStorageReader, error) {
+func openRemoteStorageReader(ctx context.Context, r remotestorage.Storage, name string) (*remoteStorageReader, error) {
 	defer prometheus.NewTimer(metricsOpenTime).ObserveDuration()
 
-	ctx := context.Background()
-
 	// Read header
 	reader, err := r.Get(ctx, name, 0, -1)
 	if err != nil {
@@ -58,6 +58,45 @@ func openRemoteStorageReader(r remotestorage.Storage, name string) (*remoteStora
 	}, nil
 }
 
+func openCachedRemoteStorageReader(ctx context.Context, c *DiskCache, r remotestorage.Storage, name string) (*remoteStorageReader, error) {
+	defer prometheus.NewTimer(metricsOpenTime).ObserveDuration()
+
+	f, err := c.open(ctx, r, name)
+	if err != nil {
+		metricsUncachedReadErrors.Inc()
+		return nil, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	var header [4]byte
+
+	_, err = f.ReadAt(header[:], 0)
+	if err != nil {
+		f.Close()
+		metricsCorruptedMetadata.Inc()
+		return nil, ErrCorruptedMetadata
+	}
+
+	baseOffset := int64(4 + binary.BigEndian.Uint32(header[:]))
+	if baseOffset > fi.Size() {
+		f.Close()
+		metricsCorruptedMetadata.Inc()
+		return nil, ErrCorruptedMetadata
+	}
+
+	return &remoteStorageReader{
+		r:          r,
+		name:       name,
+		baseOffset: baseOffset,
+		cachedFile: f,
+	}, nil
+}
+
 func (r *remoteStorageReader) Metadata() []byte {
 	panic("unimplemented")
 }
@@ -107,6 +146,17 @@ func (r *remoteStorageReader) ReadAt(bs []byte, off int64) (int, error) {
 		return 0, ErrIllegalArguments
 	}
 
+	if r.cachedFile != nil {
+		readBytes, err := r.cachedFile.ReadAt(bs, off+r.baseOffset)
+		if err != nil && err != io.EOF {
+			metricsReadErrors.Inc()
+			return readBytes, err
+		}
+		metricsReads.Inc()
+		metricsReadBytes.Add(float64(readBytes))
+		return readBytes, err
+	}
+
 	if off > int64(len(r.dataCache)) {
 		return 0, io.EOF
 	}
@@ -134,6 +184,9 @@ func (r *remoteStorageReader) ReadAt(bs []byte, off int64) (int, error) {
 }
 
 func (r *remoteStorageReader) Close() error {
+	if r.cachedFile != nil {
+		return r.cachedFile.Close()
+	}
 	return nil
 }
 
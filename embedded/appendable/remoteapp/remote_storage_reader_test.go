@@ -65,7 +65,7 @@ func TestRemoteStorageReadAt(t *testing.T) {
 		1, 2, 3, 4, // Data, 4 bytes
 	})
 
-	r, err := openRemoteStorageReader(m, "fl")
+	r, err := openRemoteStorageReader(context.Background(), m, "fl")
 	require.NoError(t, err)
 
 	b := make([]byte, 4)
@@ -95,6 +95,48 @@ func TestRemoteStorageReadAt(t *testing.T) {
 	require.Equal(t, io.EOF, err)
 }
 
+func TestCachedRemoteStorageReadAt(t *testing.T) {
+	m := memory.Open()
+	storeData(t, m, "fl", []byte{
+		0, 0, 0, 4, // Dummy empty header
+		0, 0, 0, 0,
+		1, 2, 3, 4, // Data, 4 bytes
+	})
+	storeData(t, m, "corrupted", []byte{0, 0, 0, 10})
+
+	c, err := NewDiskCache(t.TempDir(), 1024)
+	require.NoError(t, err)
+
+	r, err := openCachedRemoteStorageReader(context.Background(), c, m, "fl")
+	require.NoError(t, err)
+
+	b := make([]byte, 4)
+	n, err := r.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, 4, n)
+	require.Equal(t, []byte{1, 2, 3, 4}, b)
+
+	n, err = r.ReadAt(make([]byte, 2), 3)
+	require.EqualValues(t, 1, n)
+	require.Equal(t, io.EOF, err)
+
+	n, err = r.ReadAt(make([]byte, 2), -1)
+	require.EqualValues(t, 0, n)
+	require.Equal(t, ErrIllegalArguments, err)
+
+	n, err = r.ReadAt(make([]byte, 2), 5)
+	require.EqualValues(t, 0, n)
+	require.Equal(t, io.EOF, err)
+
+	require.NoError(t, r.Close())
+
+	_, err = openCachedRemoteStorageReader(context.Background(), c, m, "corrupted")
+	require.ErrorIs(t, err, ErrCorruptedMetadata)
+
+	_, err = openCachedRemoteStorageReader(context.Background(), c, m, "missing")
+	require.Error(t, err)
+}
+
 func TestRemoteStorageCorruptedHeader(t *testing.T) {
 	for _, d := range []struct {
 		name  string
@@ -107,7 +149,7 @@ func TestRemoteStorageCorruptedHeader(t *testing.T) {
 			m := memory.Open()
 			storeData(t, m, "fl", d.bytes)
 
-			r, err := openRemoteStorageReader(m, "fl")
+			r, err := openRemoteStorageReader(context.Background(), m, "fl")
 			require.Equal(t, ErrCorruptedMetadata, err)
 			require.Nil(t, r)
 		})
@@ -140,7 +182,7 @@ func TestRemoteStorageOpenError(t *testing.T) {
 			1, 2, 3, 4, // Data, 4 bytes
 		})
 
-		r, err := openRemoteStorageReader(m, "fl")
+		r, err := openRemoteStorageReader(context.Background(), m, "fl")
 		require.Equal(t, m.err, err)
 		require.Nil(t, r)
 	}
@@ -0,0 +1,221 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package singleapp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+)
+
+// Files with checksums store a checksum right after every block of checksumBlockSize data
+// bytes, the latest block gets its checksum appended once it's complete. Stored offsets
+// (see storedSize) don't account for the checksums, physicalOffset maps them into the file.
+// Checksums are calculated over the stored bytes, i.e. after compression and encryption,
+// thus files can be verified without knowing the encryption keys (see appendable.Verify)
+
+// physicalOffset returns where the stored byte at offset off is, relative to fileBaseOffset
+func (aof *AppendableFile) physicalOffset(off int64) int64 {
+	if aof.checksumBlockSize == 0 {
+		return off
+	}
+
+	return off + (off/int64(aof.checksumBlockSize))*appendable.ChecksumSize
+}
+
+// dataSize returns the amount of data bytes stored in size bytes of the file, when the
+// checksum of the latest block is incomplete, tornChecksum is returned as true
+func dataSize(size int64, checksumBlockSize int) (n int64, tornChecksum bool) {
+	if checksumBlockSize == 0 {
+		return size, false
+	}
+
+	bs := int64(checksumBlockSize)
+
+	blocks := size / (bs + appendable.ChecksumSize)
+	rem := size % (bs + appendable.ChecksumSize)
+
+	if rem > bs {
+		return blocks*bs + bs, true
+	}
+
+	return blocks*bs + rem, false
+}
+
+// repairChecksum writes the checksum of the latest block when it was not completely written,
+// size being the amount of stored bytes
+func (aof *AppendableFile) repairChecksum(size int64) error {
+	bs := int64(aof.checksumBlockSize)
+	blockOff := aof.fileBaseOffset + aof.physicalOffset(size-bs)
+
+	block := make([]byte, bs)
+
+	_, err := aof.f.ReadAt(block, blockOff)
+	if err != nil {
+		return err
+	}
+
+	var checksum [appendable.ChecksumSize]byte
+	binary.BigEndian.PutUint32(checksum[:], crc32.Checksum(block, appendable.ChecksumTable))
+
+	_, err = aof.f.WriteAt(checksum[:], blockOff+bs)
+
+	return err
+}
+
+// withChecksums returns the stored bytes to be written at offset off with the checksums
+// of the blocks they complete inserted after them
+func (aof *AppendableFile) withChecksums(data []byte, off int64) ([]byte, error) {
+	bs := int64(aof.checksumBlockSize)
+
+	if aof.tailChecksumOffset != off {
+		// the checksum of the data already stored in the latest block is calculated again as
+		// the file was just opened or its offset was moved back
+		blockStart := off - off%bs
+
+		stored := make([]byte, off-blockStart)
+
+		_, err := aof.f.ReadAt(stored, aof.fileBaseOffset+aof.physicalOffset(blockStart))
+		if err != nil {
+			return nil, err
+		}
+
+		aof.tailChecksum = crc32.Checksum(stored, appendable.ChecksumTable)
+		aof.tailChecksumOffset = off
+	}
+
+	buf := aof.checksumBuffer[:0]
+
+	for len(data) > 0 {
+		n := minInt(len(data), int(bs-off%bs))
+
+		buf = append(buf, data[:n]...)
+		aof.tailChecksum = crc32.Update(aof.tailChecksum, appendable.ChecksumTable, data[:n])
+
+		data = data[n:]
+		off += int64(n)
+
+		if off%bs == 0 {
+			var checksum [appendable.ChecksumSize]byte
+			binary.BigEndian.PutUint32(checksum[:], aof.tailChecksum)
+
+			buf = append(buf, checksum[:]...)
+			aof.tailChecksum = 0
+		}
+	}
+
+	aof.tailChecksumOffset = off
+	aof.checksumBuffer = buf
+
+	return buf, nil
+}
+
+// readFileAt reads the bytes stored at offset off, skipping the checksums and verifying
+// them when required. Only bytes storing data up to fileOffset are read
+func (aof *AppendableFile) readFileAt(bs []byte, off int64) (n int, err error) {
+	if aof.checksumBlockSize == 0 {
+		return aof.fileReadAt(bs, aof.fileBaseOffset+off)
+	}
+
+	blockSize := int64(aof.checksumBlockSize)
+	size := aof.storedSize(aof.fileOffset)
+
+	for n < len(bs) && off < size {
+		blockID := off / blockSize
+		inBlockOff := off % blockSize
+
+		chunkSize := minInt(len(bs)-n, int(blockSize-inBlockOff))
+		if int64(chunkSize) > size-off {
+			chunkSize = int(size - off)
+		}
+
+		if aof.verifyChecksums {
+			block, err := aof.verifiedBlock(blockID)
+			if err != nil {
+				return n, err
+			}
+
+			copy(bs[n:n+chunkSize], block[inBlockOff:])
+		} else {
+			_, err = aof.fileReadAt(bs[n:n+chunkSize], aof.fileBaseOffset+aof.physicalOffset(off))
+			if err != nil {
+				return n, err
+			}
+		}
+
+		n += chunkSize
+		off += int64(chunkSize)
+	}
+
+	if n < len(bs) {
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+// verifiedBlock returns the stored data of the block blockID, once verified against its
+// checksum. The latest block is returned as is if it's not yet complete
+func (aof *AppendableFile) verifiedBlock(blockID int64) ([]byte, error) {
+	if aof.verifiedBlockID == blockID {
+		return aof.blockBuffer[:aof.checksumBlockSize], nil
+	}
+
+	blockSize := int64(aof.checksumBlockSize)
+	blockStart := blockID * blockSize
+
+	// the buffer is about to be overwritten
+	aof.verifiedBlockID = -1
+
+	if len(aof.blockBuffer) == 0 {
+		aof.blockBuffer = make([]byte, blockSize+appendable.ChecksumSize)
+	}
+
+	size := aof.storedSize(aof.fileOffset)
+
+	if size-blockStart < blockSize {
+		block := aof.blockBuffer[:size-blockStart]
+
+		_, err := aof.fileReadAt(block, aof.fileBaseOffset+aof.physicalOffset(blockStart))
+		if err != nil {
+			return nil, err
+		}
+
+		return block, nil
+	}
+
+	_, err := aof.fileReadAt(aof.blockBuffer, aof.fileBaseOffset+aof.physicalOffset(blockStart))
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: incomplete checksum of block %d", appendable.ErrChecksumMismatch, blockID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := binary.BigEndian.Uint32(aof.blockBuffer[blockSize:])
+
+	if crc32.Checksum(aof.blockBuffer[:blockSize], appendable.ChecksumTable) != checksum {
+		return nil, fmt.Errorf("%w: block %d", appendable.ErrChecksumMismatch, blockID)
+	}
+
+	aof.verifiedBlockID = blockID
+
+	return aof.blockBuffer[:blockSize], nil
+}
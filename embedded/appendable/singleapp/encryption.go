@@ -0,0 +1,389 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package singleapp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+)
+
+// Encrypted files store data in blocks of encryptionBlockSize bytes. Every block is stored
+// right after the random nonce it's encrypted with (AES-CTR) and, once complete, followed by
+// its tag: an HMAC-SHA256 of the file IV, the block number, the nonce and the ciphertext.
+// Thus tampered, truncated or misplaced blocks are rejected when read. As with checksums,
+// the latest block gets its tag appended once it's complete and it's not authenticated before.
+//
+// Stored bytes are never written twice with the same keystream: when data already written
+// into a block is overwritten (see SetOffset), the block is encrypted again with a new nonce.
+// Offsets exposed by the appendable don't account for nonces and tags, storedSize maps them.
+
+const (
+	encryptionIVSize           = aes.BlockSize
+	encryptionNonceSize        = aes.BlockSize
+	encryptionTagSize          = sha256.Size
+	defaultEncryptionBlockSize = 4096
+)
+
+type blockCipher struct {
+	block     cipher.Block
+	macKey    []byte
+	iv        [encryptionIVSize]byte
+	blockSize int
+}
+
+func newBlockCipher(keyID string, iv []byte, blockSize int, keyProvider appendable.KeyProvider) (*blockCipher, error) {
+	if keyProvider == nil {
+		return nil, fmt.Errorf("%w: key '%s'", ErrMissingEncryptionKey, keyID)
+	}
+
+	if len(iv) != encryptionIVSize || blockSize <= 0 {
+		return nil, ErrCorruptedMetadata
+	}
+
+	key, err := keyProvider(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key '%s': %v", ErrMissingEncryptionKey, keyID, err)
+	}
+
+	_, err = aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid encryption key '%s': %v", ErrIllegalArguments, keyID, err)
+	}
+
+	// distinct keys are used for encryption and authentication
+	block, err := aes.NewCipher(deriveKey(key, "encryption")[:len(key)])
+	if err != nil {
+		return nil, err
+	}
+
+	c := &blockCipher{
+		block:     block,
+		macKey:    deriveKey(key, "authentication"),
+		blockSize: blockSize,
+	}
+	copy(c.iv[:], iv)
+
+	return c, nil
+}
+
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+func newNonce() ([]byte, error) {
+	nonce := make([]byte, encryptionNonceSize)
+
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return nonce, nil
+}
+
+// xorKeyStream encrypts or decrypts src into dst, src being stored at offset off of the
+// block encrypted with nonce
+func (c *blockCipher) xorKeyStream(dst, src []byte, nonce []byte, off int) {
+	var ctr [encryptionNonceSize]byte
+	copy(ctr[:], nonce)
+
+	// 128-bit big-endian addition of the block index to the nonce
+	lo := binary.BigEndian.Uint64(ctr[8:])
+	nlo := lo + uint64(off/aes.BlockSize)
+	binary.BigEndian.PutUint64(ctr[8:], nlo)
+
+	if nlo < lo {
+		binary.BigEndian.PutUint64(ctr[:8], binary.BigEndian.Uint64(ctr[:8])+1)
+	}
+
+	stream := cipher.NewCTR(c.block, ctr[:])
+
+	skip := off % aes.BlockSize
+	if skip > 0 {
+		var discard [aes.BlockSize]byte
+		stream.XORKeyStream(discard[:skip], discard[:skip])
+	}
+
+	stream.XORKeyStream(dst, src)
+}
+
+// newMAC returns the hash authenticating the ciphertext of the block blockID encrypted with nonce
+func (c *blockCipher) newMAC(blockID int64, nonce []byte) hash.Hash {
+	mac := hmac.New(sha256.New, c.macKey)
+	mac.Write(c.iv[:])
+
+	var id [8]byte
+	binary.BigEndian.PutUint64(id[:], uint64(blockID))
+	mac.Write(id[:])
+
+	mac.Write(nonce)
+
+	return mac
+}
+
+// storedBlockSize returns the amount of bytes taken by a complete block
+func (c *blockCipher) storedBlockSize() int64 {
+	return encryptionNonceSize + int64(c.blockSize) + encryptionTagSize
+}
+
+// storedSize returns the amount of bytes taken by size bytes of data, which accounts for the
+// nonces and tags when the file is encrypted. Checksums are not included (see physicalOffset)
+func (aof *AppendableFile) storedSize(size int64) int64 {
+	if aof.cipher == nil {
+		return size
+	}
+
+	bs := int64(aof.cipher.blockSize)
+
+	n := (size / bs) * aof.cipher.storedBlockSize()
+
+	if size%bs > 0 {
+		n += encryptionNonceSize + size%bs
+	}
+
+	return n
+}
+
+// encryptedDataSize returns the amount of data bytes taken by size stored bytes, when the
+// tag of the latest block is incomplete, tornTag is returned as true
+func encryptedDataSize(size int64, encryptionBlockSize int) (n int64, tornTag bool) {
+	if encryptionBlockSize == 0 {
+		return size, false
+	}
+
+	bs := int64(encryptionBlockSize)
+	storedBlockSize := encryptionNonceSize + bs + encryptionTagSize
+
+	blocks := size / storedBlockSize
+	rem := size % storedBlockSize
+
+	if rem <= encryptionNonceSize {
+		// the nonce of the latest block, if any, was not completely written
+		return blocks * bs, false
+	}
+
+	if rem >= encryptionNonceSize+bs {
+		return blocks*bs + bs, true
+	}
+
+	return blocks*bs + rem - encryptionNonceSize, false
+}
+
+// repairTag writes the tag of the latest block when it was not completely written
+func (aof *AppendableFile) repairTag() error {
+	bs := int64(aof.cipher.blockSize)
+	blockID := aof.fileOffset/bs - 1
+	blockOff := blockID * aof.cipher.storedBlockSize()
+
+	stored := make([]byte, encryptionNonceSize+bs)
+
+	_, err := aof.readFileAt(stored, blockOff)
+	if err != nil {
+		return err
+	}
+
+	mac := aof.cipher.newMAC(blockID, stored[:encryptionNonceSize])
+	mac.Write(stored[encryptionNonceSize:])
+
+	return aof.writeStored(mac.Sum(nil), blockOff+int64(len(stored)))
+}
+
+// encrypt returns the stored bytes of data, which is written at fileOffset, together with the
+// stored offset they must be written at. It's where the latest block begins when the block
+// gets a new nonce, as the data already stored in it is encrypted again
+func (aof *AppendableFile) encrypt(data []byte) ([]byte, int64, error) {
+	c := aof.cipher
+	bs := int64(c.blockSize)
+
+	off := aof.fileOffset
+	storedOff := aof.storedSize(off)
+
+	buf := aof.encBuffer[:0]
+
+	if off%bs > 0 && (aof.rotateNonce || aof.tailMACOffset != off) {
+		// the state of the latest block is loaded as the file was just opened
+		// or its offset was moved back
+		blockID := off / bs
+		blockOff := blockID * c.storedBlockSize()
+
+		stored := make([]byte, encryptionNonceSize+off%bs)
+
+		if !aof.rotateNonce || aof.rotatedPrefix == nil {
+			_, err := aof.readFileAt(stored, blockOff)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+
+		nonce := stored[:encryptionNonceSize]
+		ciphertext := stored[encryptionNonceSize:]
+
+		if aof.rotateNonce {
+			// data following the offset was already encrypted with the current nonce,
+			// so it can not be used to encrypt the data overwriting it
+			if aof.rotatedPrefix == nil {
+				aof.rotatedPrefix = make([]byte, len(ciphertext))
+				c.xorKeyStream(aof.rotatedPrefix, ciphertext, nonce, 0)
+			}
+
+			newNonce, err := newNonce()
+			if err != nil {
+				return nil, 0, err
+			}
+
+			copy(nonce, newNonce)
+			c.xorKeyStream(ciphertext, aof.rotatedPrefix, nonce, 0)
+
+			buf = append(buf, stored...)
+			storedOff = blockOff
+			aof.seekRequired = true
+		}
+
+		copy(aof.tailNonce[:], nonce)
+
+		aof.tailMAC = c.newMAC(blockID, nonce)
+		aof.tailMAC.Write(ciphertext)
+	}
+
+	for len(data) > 0 {
+		if off%bs == 0 {
+			nonce, err := newNonce()
+			if err != nil {
+				return nil, 0, err
+			}
+
+			copy(aof.tailNonce[:], nonce)
+			buf = append(buf, nonce...)
+
+			aof.tailMAC = c.newMAC(off/bs, nonce)
+		}
+
+		n := minInt(len(data), int(bs-off%bs))
+
+		buf = append(buf, data[:n]...)
+		ciphertext := buf[len(buf)-n:]
+
+		c.xorKeyStream(ciphertext, ciphertext, aof.tailNonce[:], int(off%bs))
+		aof.tailMAC.Write(ciphertext)
+
+		data = data[n:]
+		off += int64(n)
+
+		if off%bs == 0 {
+			buf = aof.tailMAC.Sum(buf)
+		}
+	}
+
+	aof.tailMACOffset = off
+	aof.encBuffer = buf
+
+	return buf, storedOff, nil
+}
+
+// readDecryptedAt reads data stored in the encrypted file, authenticating complete blocks.
+// Only data up to fileOffset is read
+func (aof *AppendableFile) readDecryptedAt(bs []byte, off int64) (n int, err error) {
+	blockSize := int64(aof.cipher.blockSize)
+
+	for n < len(bs) && off < aof.fileOffset {
+		block, err := aof.decryptedBlock(off / blockSize)
+		if err != nil {
+			return n, err
+		}
+
+		chunkSize := copy(bs[n:], block[off%blockSize:])
+
+		n += chunkSize
+		off += int64(chunkSize)
+	}
+
+	if n < len(bs) {
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+// decryptedBlock returns the data of the block blockID, once authenticated against its tag.
+// The latest block is returned as is, up to fileOffset, if it's not yet complete
+func (aof *AppendableFile) decryptedBlock(blockID int64) ([]byte, error) {
+	bs := int64(aof.cipher.blockSize)
+
+	if aof.decryptedBlockID == blockID {
+		return aof.plainBuffer[:bs], nil
+	}
+
+	// the buffer is about to be overwritten
+	aof.decryptedBlockID = -1
+
+	if len(aof.plainBuffer) == 0 {
+		aof.storedBuffer = make([]byte, aof.cipher.storedBlockSize())
+		aof.plainBuffer = make([]byte, bs)
+	}
+
+	size := bs
+	if aof.fileOffset-blockID*bs < bs {
+		size = aof.fileOffset - blockID*bs
+	}
+
+	complete := size == bs
+
+	stored := aof.storedBuffer[:encryptionNonceSize+size]
+	if complete {
+		stored = aof.storedBuffer
+	}
+
+	_, err := aof.readFileAt(stored, blockID*aof.cipher.storedBlockSize())
+	if err == io.EOF && complete {
+		return nil, fmt.Errorf("%w: incomplete tag of block %d", ErrAuthenticationFailed, blockID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := stored[:encryptionNonceSize]
+	ciphertext := stored[encryptionNonceSize : encryptionNonceSize+size]
+
+	if complete {
+		mac := aof.cipher.newMAC(blockID, nonce)
+		mac.Write(ciphertext)
+
+		if !hmac.Equal(mac.Sum(nil), stored[encryptionNonceSize+size:]) {
+			return nil, fmt.Errorf("%w: block %d", ErrAuthenticationFailed, blockID)
+		}
+	}
+
+	block := aof.plainBuffer[:size]
+	aof.cipher.xorKeyStream(block, ciphertext, nonce, 0)
+
+	if complete {
+		aof.decryptedBlockID = blockID
+	}
+
+	return block, nil
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package singleapp
+
+import "io"
+
+// mapIfRequired memory-maps the file when enabled, on failure reads keep using the file
+func (aof *AppendableFile) mapIfRequired() {
+	if !aof.mmap || aof.mapped != nil {
+		return
+	}
+
+	finfo, err := aof.f.Stat()
+	if err != nil {
+		return
+	}
+
+	mapped, err := mmapFile(aof.f, int(finfo.Size()))
+	if err != nil {
+		return
+	}
+
+	aof.mapped = mapped
+}
+
+func (aof *AppendableFile) unmap() {
+	if aof.mapped == nil {
+		return
+	}
+
+	munmapFile(aof.mapped)
+	aof.mapped = nil
+}
+
+// fileReadAt reads from the memory-mapped file if available, from the file otherwise
+func (aof *AppendableFile) fileReadAt(bs []byte, off int64) (int, error) {
+	if aof.mapped == nil {
+		return aof.f.ReadAt(bs, off)
+	}
+
+	if off >= int64(len(aof.mapped)) {
+		return 0, io.EOF
+	}
+
+	n := copy(bs, aof.mapped[off:])
+	if n < len(bs) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
@@ -42,6 +42,14 @@ type Options struct {
 	compressionFormat int
 	compressionLevel  int
 
+	encryptionKeyID string
+	keyProvider     appendable.KeyProvider
+
+	checksumBlockSize int  // zero means no checksums are written, only used when the file is created
+	verifyChecksums   bool // if verifyChecksums is enabled, blocks are verified when read
+
+	mmap bool // if mmap is enabled, read-only files are memory-mapped when the platform supports it
+
 	metadata []byte
 }
 
@@ -71,6 +79,14 @@ func (opts *Options) Validate() error {
 		return fmt.Errorf("%w: invalid writeBuffer", ErrInvalidOptions)
 	}
 
+	if opts.encryptionKeyID != "" && opts.keyProvider == nil {
+		return fmt.Errorf("%w: invalid keyProvider", ErrInvalidOptions)
+	}
+
+	if opts.checksumBlockSize < 0 {
+		return fmt.Errorf("%w: invalid checksumBlockSize", ErrInvalidOptions)
+	}
+
 	return nil
 }
 
@@ -107,6 +123,26 @@ func (opts *Options) GetCompressionLevel() int {
 	return opts.compressionLevel
 }
 
+func (opts *Options) GetEncryptionKeyID() string {
+	return opts.encryptionKeyID
+}
+
+func (opts *Options) GetKeyProvider() appendable.KeyProvider {
+	return opts.keyProvider
+}
+
+func (opts *Options) GetChecksumBlockSize() int {
+	return opts.checksumBlockSize
+}
+
+func (opts *Options) GetVerifyChecksums() bool {
+	return opts.verifyChecksums
+}
+
+func (opts *Options) GetMmap() bool {
+	return opts.mmap
+}
+
 func (opts *Options) GetReadBufferSize() int {
 	return opts.readBufferSize
 }
@@ -120,6 +156,36 @@ func (opts *Options) WithCompresionLevel(compressionLevel int) *Options {
 	return opts
 }
 
+// WithEncryption sets the key used to encrypt and authenticate newly created files.
+// Existing files are decrypted with the key recorded in their metadata, resolved through keyProvider
+func (opts *Options) WithEncryption(keyID string, keyProvider appendable.KeyProvider) *Options {
+	opts.encryptionKeyID = keyID
+	opts.keyProvider = keyProvider
+	return opts
+}
+
+// WithChecksums makes newly created files store a CRC32C checksum after every blockSize bytes
+// of data, zero disables them. Existing files keep the block size recorded in their metadata
+func (opts *Options) WithChecksums(blockSize int) *Options {
+	opts.checksumBlockSize = blockSize
+	return opts
+}
+
+// WithVerifyChecksums makes reads fail with appendable.ErrChecksumMismatch when the data
+// read doesn't match its checksum. It has no effect on files without checksums
+func (opts *Options) WithVerifyChecksums(verify bool) *Options {
+	opts.verifyChecksums = verify
+	return opts
+}
+
+// WithMmap makes reads of read-only files, including files switched to read-only mode,
+// be served from a memory mapping instead of read syscalls. Files are read as usual
+// when mapping them is not supported by the platform or fails
+func (opts *Options) WithMmap(mmap bool) *Options {
+	opts.mmap = mmap
+	return opts
+}
+
 func (opts *Options) WithMetadata(metadata []byte) *Options {
 	opts.metadata = metadata
 	return opts
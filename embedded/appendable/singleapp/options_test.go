@@ -31,6 +31,8 @@ func TestInvalidOptions(t *testing.T) {
 		{"empty", &Options{}},
 		{"ReadBufferSize", DefaultOptions().WithReadBufferSize(0)},
 		{"WriteBuffer", DefaultOptions().WithReadOnly(false).WithWriteBuffer(nil)},
+		{"KeyProvider", DefaultOptions().WithEncryption("key1", nil)},
+		{"ChecksumBlockSize", DefaultOptions().WithChecksums(-1)},
 	} {
 		t.Run(d.n, func(t *testing.T) {
 			require.ErrorIs(t, d.opts.Validate(), ErrInvalidOptions)
@@ -52,6 +54,13 @@ func TestValidOptions(t *testing.T) {
 	require.Equal(t, DefaultCompressionLevel, opts.WithCompresionLevel(DefaultCompressionLevel).compressionLevel)
 	require.Equal(t, DefaultCompressionLevel, opts.WithCompresionLevel(DefaultCompressionLevel).GetCompressionLevel())
 
+	require.Equal(t, "key1", opts.WithEncryption("key1", nil).GetEncryptionKeyID())
+	require.Nil(t, opts.WithEncryption("", nil).GetKeyProvider())
+
+	require.Equal(t, 512, opts.WithChecksums(512).GetChecksumBlockSize())
+	require.True(t, opts.WithVerifyChecksums(true).GetVerifyChecksums())
+	require.True(t, opts.WithMmap(true).GetMmap())
+
 	require.True(t, opts.WithRetryableSync(true).retryableSync)
 	require.True(t, opts.WithAutoSync(true).autoSync)
 
@@ -23,9 +23,11 @@ import (
 	"compress/gzip"
 	"compress/lzw"
 	"compress/zlib"
+	"crypto/rand"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 	"path/filepath"
@@ -43,11 +45,18 @@ var ErrReadOnly = errors.New("singleapp: read-only mode")
 var ErrCorruptedMetadata = errors.New("singleapp: corrupted metadata")
 var ErrBufferFull = errors.New("singleapp: buffer full")
 var ErrNegativeOffset = errors.New("singleapp: negative offset")
+var ErrMissingEncryptionKey = errors.New("singleapp: encryption key not available")
+var ErrAuthenticationFailed = errors.New("singleapp: encrypted data authentication failed")
+
+var errMmapUnsupported = errors.New("singleapp: memory mapping not supported")
 
 const (
-	metaCompressionFormat = "COMPRESSION_FORMAT"
-	metaCompressionLevel  = "COMPRESSION_LEVEL"
-	metaWrappedMeta       = "WRAPPED_METADATA"
+	metaCompressionFormat   = "COMPRESSION_FORMAT"
+	metaCompressionLevel    = "COMPRESSION_LEVEL"
+	metaWrappedMeta         = "WRAPPED_METADATA"
+	metaEncryptionKeyID     = "ENCRYPTION_KEY_ID"
+	metaEncryptionIV        = "ENCRYPTION_IV"
+	metaEncryptionBlockSize = "ENCRYPTION_BLOCK_SIZE"
 )
 
 var _ appendable.Appendable = (*AppendableFile)(nil)
@@ -70,6 +79,31 @@ type AppendableFile struct {
 	compressionFormat int
 	compressionLevel  int
 
+	// cipher is nil when the file is not encrypted
+	cipher           *blockCipher
+	tailNonce        [encryptionNonceSize]byte // nonce of the latest block
+	tailMAC          hash.Hash                 // tag of the data stored in the latest block
+	tailMACOffset    int64                     // offset up to which tailMAC was calculated
+	rotateNonce      bool                      // the latest block must be encrypted with a new nonce
+	rotatedPrefix    []byte                    // data of the latest block being encrypted again
+	encBuffer        []byte
+	storedBuffer     []byte
+	plainBuffer      []byte
+	decryptedBlockID int64
+
+	// checksumBlockSize is zero when the file has no checksums
+	checksumBlockSize  int
+	verifyChecksums    bool
+	tailChecksum       uint32 // checksum of the data stored in the latest block
+	tailChecksumOffset int64  // offset up to which tailChecksum was calculated
+	checksumBuffer     []byte
+	blockBuffer        []byte
+	verifiedBlockID    int64
+
+	// mapped holds the whole file once it's memory-mapped, reads are served from it
+	mmap   bool
+	mapped []byte
+
 	metadata []byte
 
 	closed bool
@@ -98,6 +132,23 @@ func Open(fileName string, opts *Options) (*AppendableFile, error) {
 		return nil, err
 	}
 
+	var encryptionIV []byte
+	var cipher *blockCipher
+
+	if notExist && opts.encryptionKeyID != "" {
+		encryptionIV = make([]byte, encryptionIVSize)
+
+		_, err = rand.Read(encryptionIV)
+		if err != nil {
+			return nil, err
+		}
+
+		cipher, err = newBlockCipher(opts.encryptionKeyID, encryptionIV, defaultEncryptionBlockSize, opts.keyProvider)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	f, err := os.OpenFile(fileName, flag, opts.fileMode)
 	if err != nil {
 		return nil, err
@@ -106,6 +157,7 @@ func Open(fileName string, opts *Options) (*AppendableFile, error) {
 	var metadata []byte
 	var compressionFormat int
 	var compressionLevel int
+	var checksumBlockSize int
 	var fileBaseOffset int64
 
 	if notExist {
@@ -114,6 +166,16 @@ func Open(fileName string, opts *Options) (*AppendableFile, error) {
 		m.PutInt(metaCompressionLevel, opts.compressionLevel)
 		m.Put(metaWrappedMeta, opts.metadata)
 
+		if cipher != nil {
+			m.Put(metaEncryptionKeyID, []byte(opts.encryptionKeyID))
+			m.Put(metaEncryptionIV, encryptionIV)
+			m.PutInt(metaEncryptionBlockSize, cipher.blockSize)
+		}
+
+		if opts.checksumBlockSize > 0 {
+			m.PutInt(appendable.MetaChecksumBlockSize, opts.checksumBlockSize)
+		}
+
 		mBs := m.Bytes()
 		mLenBs := make([]byte, 4)
 		binary.BigEndian.PutUint32(mLenBs, uint32(len(mBs)))
@@ -147,6 +209,7 @@ func Open(fileName string, opts *Options) (*AppendableFile, error) {
 
 		compressionFormat = opts.compressionFormat
 		compressionLevel = opts.compressionLevel
+		checksumBlockSize = opts.checksumBlockSize
 		metadata = opts.metadata
 
 		fileBaseOffset = int64(4 + len(mBs))
@@ -184,28 +247,92 @@ func Open(fileName string, opts *Options) (*AppendableFile, error) {
 			return nil, ErrCorruptedMetadata
 		}
 
+		keyID, encrypted := m.Get(metaEncryptionKeyID)
+		if encrypted {
+			iv, _ := m.Get(metaEncryptionIV)
+			blockSize, _ := m.GetInt(metaEncryptionBlockSize)
+
+			cipher, err = newBlockCipher(string(keyID), iv, blockSize, opts.keyProvider)
+			if err != nil {
+				f.Close()
+				return nil, err
+			}
+		}
+
+		checksumBlockSize, _ = m.GetInt(appendable.MetaChecksumBlockSize)
+		if checksumBlockSize < 0 {
+			f.Close()
+			return nil, ErrCorruptedMetadata
+		}
+
 		fileBaseOffset = int64(4 + len(mBs))
 	}
 
-	fileOffset, err := f.Seek(0, io.SeekEnd)
+	fileSize, err := f.Seek(0, io.SeekEnd)
 	if err != nil {
 		return nil, err
 	}
 
-	return &AppendableFile{
-		f:                 f,
-		fileBaseOffset:    fileBaseOffset,
-		fileOffset:        fileOffset - fileBaseOffset,
-		writeBuffer:       opts.writeBuffer,
-		readBufferSize:    opts.readBufferSize,
-		compressionFormat: compressionFormat,
-		compressionLevel:  compressionLevel,
-		metadata:          metadata,
-		readOnly:          opts.readOnly,
-		retryableSync:     opts.retryableSync,
-		autoSync:          opts.autoSync,
-		closed:            false,
-	}, nil
+	storedSize, tornChecksum := dataSize(fileSize-fileBaseOffset, checksumBlockSize)
+
+	var encryptionBlockSize int
+	if cipher != nil {
+		encryptionBlockSize = cipher.blockSize
+	}
+
+	fileOffset, tornTag := encryptedDataSize(storedSize, encryptionBlockSize)
+
+	aof := &AppendableFile{
+		f:                  f,
+		fileBaseOffset:     fileBaseOffset,
+		fileOffset:         fileOffset,
+		writeBuffer:        opts.writeBuffer,
+		readBufferSize:     opts.readBufferSize,
+		compressionFormat:  compressionFormat,
+		compressionLevel:   compressionLevel,
+		cipher:             cipher,
+		tailMACOffset:      -1,
+		decryptedBlockID:   -1,
+		checksumBlockSize:  checksumBlockSize,
+		verifyChecksums:    opts.verifyChecksums,
+		tailChecksumOffset: -1,
+		verifiedBlockID:    -1,
+		mmap:               opts.mmap,
+		metadata:           metadata,
+		readOnly:           opts.readOnly,
+		retryableSync:      opts.retryableSync,
+		autoSync:           opts.autoSync,
+		closed:             false,
+	}
+
+	if tornChecksum && !opts.readOnly {
+		// the latest block was written but its checksum was not completely stored
+		err = aof.repairChecksum(storedSize)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	if cipher != nil {
+		// bytes following the latest block may not have been completely written
+		aof.seekRequired = storedSize != aof.storedSize(fileOffset)
+	}
+
+	if tornTag && !opts.readOnly {
+		// the latest block was written but its tag was not completely stored
+		err = aof.repairTag()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	if opts.readOnly {
+		aof.mapIfRequired()
+	}
+
+	return aof, nil
 }
 
 func (aof *AppendableFile) Copy(dstPath string) error {
@@ -311,6 +438,18 @@ func (aof *AppendableFile) SetOffset(newOffset int64) error {
 	aof.fileOffset = newOffset
 	aof.seekRequired = true
 
+	// written blocks are going to be overwritten
+	aof.verifiedBlockID = -1
+
+	if aof.cipher != nil {
+		aof.decryptedBlockID = -1
+		aof.tailMACOffset = -1
+		aof.rotatedPrefix = nil
+
+		// data following the offset was encrypted with the nonce of its block
+		aof.rotateNonce = newOffset%int64(aof.cipher.blockSize) > 0
+	}
+
 	// discard in-memory data
 	aof.wbufFlushedOffset = 0
 	aof.wbufUnwrittenOffset = 0
@@ -463,7 +602,14 @@ func (aof *AppendableFile) readAt(bs []byte, off int64) (n int, err error) {
 	var boff int
 
 	if off < aof.fileOffset {
-		n, err = aof.f.ReadAt(bs, aof.fileBaseOffset+off)
+		if aof.cipher != nil {
+			n, err = aof.readDecryptedAt(bs, off)
+		} else {
+			n, err = aof.readFileAt(bs, off)
+		}
+		if err != nil && err != io.EOF {
+			return n, err
+		}
 	} else {
 		boff = int(off - aof.fileOffset)
 	}
@@ -567,6 +713,8 @@ func (aof *AppendableFile) SwitchToReadOnlyMode() error {
 	aof.writeBuffer = nil
 	aof.readOnly = true
 
+	aof.mapIfRequired()
+
 	return nil
 }
 
@@ -585,12 +733,24 @@ func (aof *AppendableFile) Flush() error {
 	return aof.flush()
 }
 
-func (aof *AppendableFile) seekIfRequired() error {
+// seekIfRequired ensures that the file is written at the stored offset off
+func (aof *AppendableFile) seekIfRequired(off int64) error {
 	if !aof.seekRequired {
 		return nil
 	}
 
-	_, err := aof.f.Seek(aof.fileBaseOffset+aof.fileOffset, io.SeekStart)
+	fileOffset := aof.fileBaseOffset + aof.physicalOffset(off)
+
+	if aof.checksumBlockSize > 0 || aof.cipher != nil {
+		// data following the offset is discarded, otherwise it would be
+		// mixed with the data written from now on into the same blocks
+		err := aof.f.Truncate(fileOffset)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := aof.f.Seek(fileOffset, io.SeekStart)
 	if err != nil {
 		return err
 	}
@@ -600,6 +760,33 @@ func (aof *AppendableFile) seekIfRequired() error {
 	return nil
 }
 
+// writeStored writes the stored bytes data at the stored offset off, with checksums when required.
+// Nothing is considered to be written unless the whole data is
+func (aof *AppendableFile) writeStored(data []byte, off int64) error {
+	err := aof.seekIfRequired(off)
+	if err != nil {
+		return err
+	}
+
+	if aof.checksumBlockSize > 0 {
+		data, err = aof.withChecksums(data, off)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = aof.f.Write(data)
+	if err != nil {
+		// everything is written again from the current offset
+		aof.seekRequired = true
+		aof.tailChecksumOffset = -1
+		aof.tailMACOffset = -1
+		return err
+	}
+
+	return nil
+}
+
 // flush writes buffered data into the underlying file.
 // When retryableSync is used, the buffer space is released
 // after sync succeeds to prevent data loss under unexpected conditions
@@ -609,19 +796,47 @@ func (aof *AppendableFile) flush() error {
 		return nil
 	}
 
-	// ensure that the file is written at the expected location
-	err := aof.seekIfRequired()
-	if err != nil {
-		return err
-	}
+	data := aof.writeBuffer[aof.wbufFlushedOffset:aof.wbufUnwrittenOffset]
 
-	n, err := aof.f.Write(aof.writeBuffer[aof.wbufFlushedOffset:aof.wbufUnwrittenOffset])
+	if aof.checksumBlockSize > 0 || aof.cipher != nil {
+		dataLen := len(data)
+		storedOff := aof.fileOffset
 
-	aof.fileOffset += int64(n)
-	aof.wbufFlushedOffset += n
+		if aof.cipher != nil {
+			var err error
 
-	if err != nil {
-		return err
+			// the write buffer is kept in plain text as it's also used to serve reads
+			data, storedOff, err = aof.encrypt(data)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := aof.writeStored(data, storedOff)
+		if err != nil {
+			return err
+		}
+
+		aof.rotateNonce = false
+		aof.rotatedPrefix = nil
+
+		aof.fileOffset += int64(dataLen)
+		aof.wbufFlushedOffset += dataLen
+	} else {
+		// ensure that the file is written at the expected location
+		err := aof.seekIfRequired(aof.fileOffset)
+		if err != nil {
+			return err
+		}
+
+		n, err := aof.f.Write(data)
+
+		aof.fileOffset += int64(n)
+		aof.wbufFlushedOffset += n
+
+		if err != nil {
+			return err
+		}
 	}
 
 	if !aof.retryableSync {
@@ -697,6 +912,8 @@ func (aof *AppendableFile) Close() error {
 
 	aof.closed = true
 
+	aof.unmap()
+
 	return aof.f.Close()
 }
 
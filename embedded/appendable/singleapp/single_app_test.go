@@ -18,12 +18,15 @@ package singleapp
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"io"
 	"io/ioutil"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/codenotary/immudb/embedded/appendable"
@@ -562,3 +565,527 @@ func BenchmarkAppendFlushless(b *testing.B) {
 	err = app.Close()
 	require.NoError(b, err)
 }
+
+func TestSingleAppEncryption(t *testing.T) {
+	keys := map[string][]byte{
+		"key1": []byte("0123456789abcdef0123456789abcdef"),
+	}
+
+	keyProvider := func(keyID string) ([]byte, error) {
+		key, ok := keys[keyID]
+		if !ok {
+			return nil, errors.New("unknown key")
+		}
+		return key, nil
+	}
+
+	fileName := filepath.Join(t.TempDir(), "testdata.aof")
+
+	opts := DefaultOptions().
+		WithWriteBuffer(make([]byte, 7)).
+		WithEncryption("key1", keyProvider)
+
+	a, err := Open(fileName, opts)
+	require.NoError(t, err)
+
+	plain := []byte("sensitive value stored in plain text")
+
+	for i := 0; i < 3; i++ {
+		_, _, err = a.Append(plain)
+		require.NoError(t, err)
+	}
+
+	// reading across flushed and buffered data
+	bs := make([]byte, len(plain))
+	_, err = a.ReadAt(bs, int64(len(plain))+5)
+	require.NoError(t, err)
+	require.Equal(t, append(plain[5:], plain[:5]...), bs)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	raw, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	require.False(t, bytes.Contains(raw, plain))
+
+	_, err = Open(fileName, DefaultOptions())
+	require.ErrorIs(t, err, ErrMissingEncryptionKey)
+
+	delete(keys, "key1")
+
+	_, err = Open(fileName, DefaultOptions().WithEncryption("", keyProvider))
+	require.ErrorIs(t, err, ErrMissingEncryptionKey)
+
+	keys["key1"] = []byte("invalid")
+
+	_, err = Open(fileName, DefaultOptions().WithEncryption("", keyProvider))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	keys["key1"] = []byte("0123456789abcdef0123456789abcdef")
+
+	// the key recorded in the file takes precedence
+	keys["key2"] = []byte("fedcba9876543210")
+
+	a, err = Open(fileName, DefaultOptions().WithReadOnly(true).WithEncryption("key2", keyProvider))
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = a.ReadAt(bs, int64(i*len(plain)))
+		require.NoError(t, err)
+		require.Equal(t, plain, bs)
+	}
+
+	err = a.Close()
+	require.NoError(t, err)
+}
+
+func TestSingleAppEncryptionAuthentication(t *testing.T) {
+	keyProvider := func(keyID string) ([]byte, error) {
+		return []byte("0123456789abcdef0123456789abcdef"), nil
+	}
+
+	const storedBlockSize = encryptionNonceSize + defaultEncryptionBlockSize + encryptionTagSize
+
+	fileName := filepath.Join(t.TempDir(), "testdata.aof")
+
+	opts := DefaultOptions().
+		WithWriteBuffer(make([]byte, 64)).
+		WithEncryption("key1", keyProvider)
+
+	a, err := Open(fileName, opts)
+	require.NoError(t, err)
+
+	fileBaseOffset := a.fileBaseOffset
+
+	data := make([]byte, 3*defaultEncryptionBlockSize+100)
+	rand.Read(data)
+
+	_, _, err = a.Append(data)
+	require.NoError(t, err)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	checkData := func(t *testing.T, data []byte) {
+		a, err := Open(fileName, DefaultOptions().WithReadOnly(true).WithEncryption("", keyProvider))
+		require.NoError(t, err)
+
+		sz, err := a.Size()
+		require.NoError(t, err)
+		require.Equal(t, int64(len(data)), sz)
+
+		bs := make([]byte, len(data))
+		_, err = a.ReadAt(bs, 0)
+		require.NoError(t, err)
+		require.Equal(t, data, bs)
+
+		err = a.Close()
+		require.NoError(t, err)
+	}
+
+	checkData(t, data)
+
+	t.Run("tampered ciphertext is rejected", func(t *testing.T) {
+		raw, err := ioutil.ReadFile(fileName)
+		require.NoError(t, err)
+
+		tampered := make([]byte, len(raw))
+		copy(tampered, raw)
+
+		// first data byte of the second block
+		tampered[fileBaseOffset+storedBlockSize+encryptionNonceSize] ^= 0xff
+
+		err = ioutil.WriteFile(fileName, tampered, 0644)
+		require.NoError(t, err)
+
+		a, err := Open(fileName, DefaultOptions().WithReadOnly(true).WithEncryption("", keyProvider))
+		require.NoError(t, err)
+
+		bs := make([]byte, 10)
+
+		_, err = a.ReadAt(bs, 0)
+		require.NoError(t, err)
+		require.Equal(t, data[:10], bs)
+
+		_, err = a.ReadAt(bs, defaultEncryptionBlockSize+1)
+		require.ErrorIs(t, err, ErrAuthenticationFailed)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		err = ioutil.WriteFile(fileName, raw, 0644)
+		require.NoError(t, err)
+	})
+
+	t.Run("misplaced blocks are rejected", func(t *testing.T) {
+		raw, err := ioutil.ReadFile(fileName)
+		require.NoError(t, err)
+
+		swapped := make([]byte, len(raw))
+		copy(swapped, raw)
+
+		firstBlock := swapped[fileBaseOffset : fileBaseOffset+storedBlockSize]
+		secondBlock := raw[fileBaseOffset+storedBlockSize : fileBaseOffset+2*storedBlockSize]
+		copy(firstBlock, secondBlock)
+
+		err = ioutil.WriteFile(fileName, swapped, 0644)
+		require.NoError(t, err)
+
+		a, err := Open(fileName, DefaultOptions().WithReadOnly(true).WithEncryption("", keyProvider))
+		require.NoError(t, err)
+
+		_, err = a.ReadAt(make([]byte, 10), 0)
+		require.ErrorIs(t, err, ErrAuthenticationFailed)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		err = ioutil.WriteFile(fileName, raw, 0644)
+		require.NoError(t, err)
+	})
+
+	t.Run("overwritten data is encrypted with a new nonce", func(t *testing.T) {
+		raw, err := ioutil.ReadFile(fileName)
+		require.NoError(t, err)
+
+		latestBlockOff := fileBaseOffset + 3*storedBlockSize
+		nonce := raw[latestBlockOff : latestBlockOff+encryptionNonceSize]
+
+		a, err := Open(fileName, opts)
+		require.NoError(t, err)
+
+		err = a.SetOffset(3*defaultEncryptionBlockSize + 50)
+		require.NoError(t, err)
+
+		overwrite := make([]byte, 50)
+		rand.Read(overwrite)
+
+		_, _, err = a.Append(overwrite)
+		require.NoError(t, err)
+
+		data = append(data[:3*defaultEncryptionBlockSize+50], overwrite...)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		overwritten, err := ioutil.ReadFile(fileName)
+		require.NoError(t, err)
+		require.Len(t, overwritten, len(raw))
+
+		// complete blocks are left untouched
+		require.Equal(t, raw[:latestBlockOff], overwritten[:latestBlockOff])
+
+		newNonce := overwritten[latestBlockOff : latestBlockOff+encryptionNonceSize]
+		require.NotEqual(t, nonce, newNonce)
+
+		// none of the latest block is stored as it was
+		for i := latestBlockOff + encryptionNonceSize; i+16 <= int64(len(raw)); i += 16 {
+			require.NotEqual(t, raw[i:i+16], overwritten[i:i+16])
+		}
+
+		checkData(t, data)
+	})
+
+	t.Run("incomplete tags are repaired", func(t *testing.T) {
+		a, err := Open(fileName, opts)
+		require.NoError(t, err)
+
+		// data is made to end with a complete block
+		err = a.SetOffset(3 * defaultEncryptionBlockSize)
+		require.NoError(t, err)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		data = data[:3*defaultEncryptionBlockSize]
+
+		err = os.Truncate(fileName, fileBaseOffset+3*storedBlockSize-10)
+		require.NoError(t, err)
+
+		a, err = Open(fileName, DefaultOptions().WithReadOnly(true).WithEncryption("", keyProvider))
+		require.NoError(t, err)
+
+		_, err = a.ReadAt(make([]byte, 10), 2*defaultEncryptionBlockSize)
+		require.ErrorIs(t, err, ErrAuthenticationFailed)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		a, err = Open(fileName, opts)
+		require.NoError(t, err)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		checkData(t, data)
+	})
+
+	t.Run("encrypted data with checksums", func(t *testing.T) {
+		fileName := filepath.Join(t.TempDir(), "testdata.aof")
+
+		opts := opts.WithChecksums(100)
+
+		a, err := Open(fileName, opts)
+		require.NoError(t, err)
+
+		_, _, err = a.Append(data)
+		require.NoError(t, err)
+
+		err = a.SetOffset(defaultEncryptionBlockSize + 10)
+		require.NoError(t, err)
+
+		_, _, err = a.Append(data[:20])
+		require.NoError(t, err)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		require.NoError(t, appendable.Verify(fileName))
+
+		a, err = Open(fileName, DefaultOptions().WithVerifyChecksums(true).WithEncryption("", keyProvider))
+		require.NoError(t, err)
+
+		bs := make([]byte, defaultEncryptionBlockSize+30)
+		_, err = a.ReadAt(bs, 0)
+		require.NoError(t, err)
+		require.Equal(t, append(data[:defaultEncryptionBlockSize+10:defaultEncryptionBlockSize+10], data[:20]...), bs)
+
+		err = a.Close()
+		require.NoError(t, err)
+	})
+}
+
+func TestSingleAppChecksums(t *testing.T) {
+	const blockSize = 16
+
+	fileName := filepath.Join(t.TempDir(), "testdata.aof")
+
+	opts := DefaultOptions().
+		WithWriteBuffer(make([]byte, 7)).
+		WithChecksums(blockSize)
+
+	a, err := Open(fileName, opts)
+	require.NoError(t, err)
+
+	data := make([]byte, 100)
+	rand.Read(data)
+
+	for i := 0; i < len(data); i += 10 {
+		_, _, err = a.Append(data[i : i+10])
+		require.NoError(t, err)
+	}
+
+	// reading across blocks, flushed and buffered data
+	bs := make([]byte, 50)
+	_, err = a.ReadAt(bs, 45)
+	require.NoError(t, err)
+	require.Equal(t, data[45:95], bs)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	fi, err := os.Stat(fileName)
+	require.NoError(t, err)
+	require.Equal(t, a.fileBaseOffset+int64(len(data))+6*appendable.ChecksumSize, fi.Size())
+
+	require.NoError(t, appendable.Verify(fileName))
+
+	checkData := func(t *testing.T, data []byte) {
+		a, err := Open(fileName, DefaultOptions().WithReadOnly(true).WithVerifyChecksums(true))
+		require.NoError(t, err)
+		defer a.Close()
+
+		sz, err := a.Size()
+		require.NoError(t, err)
+		require.Equal(t, int64(len(data)), sz)
+
+		bs := make([]byte, len(data))
+		_, err = a.ReadAt(bs, 0)
+		require.NoError(t, err)
+		require.Equal(t, data, bs)
+
+		for i := 0; i < len(data); i++ {
+			b := make([]byte, 1)
+			_, err = a.ReadAt(b, int64(i))
+			require.NoError(t, err)
+			require.Equal(t, data[i], b[0])
+		}
+	}
+
+	checkData(t, data)
+
+	t.Run("data is appended and overwritten into existing blocks", func(t *testing.T) {
+		// checksums settings recorded in the file take precedence
+		a, err := Open(fileName, DefaultOptions().WithWriteBuffer(make([]byte, 7)))
+		require.NoError(t, err)
+
+		_, _, err = a.Append(data[:20])
+		require.NoError(t, err)
+
+		data = append(data, data[:20]...)
+
+		err = a.SetOffset(37)
+		require.NoError(t, err)
+
+		_, _, err = a.Append(data[:5])
+		require.NoError(t, err)
+
+		data = append(data[:37], data[:5]...)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		require.NoError(t, appendable.Verify(fileName))
+
+		checkData(t, data)
+	})
+
+	t.Run("corrupted blocks are detected", func(t *testing.T) {
+		raw, err := ioutil.ReadFile(fileName)
+		require.NoError(t, err)
+
+		corrupted := make([]byte, len(raw))
+		copy(corrupted, raw)
+
+		// first byte of the second block
+		off := a.fileBaseOffset + blockSize + appendable.ChecksumSize
+		corrupted[off] ^= 0xff
+
+		err = ioutil.WriteFile(fileName, corrupted, 0644)
+		require.NoError(t, err)
+
+		require.ErrorIs(t, appendable.Verify(fileName), appendable.ErrChecksumMismatch)
+		require.ErrorIs(t, appendable.Verify(filepath.Dir(fileName)), appendable.ErrChecksumMismatch)
+
+		a, err := Open(fileName, DefaultOptions().WithReadOnly(true).WithVerifyChecksums(true))
+		require.NoError(t, err)
+
+		bs := make([]byte, blockSize)
+
+		_, err = a.ReadAt(bs, 0)
+		require.NoError(t, err)
+
+		_, err = a.ReadAt(bs, blockSize+1)
+		require.ErrorIs(t, err, appendable.ErrChecksumMismatch)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		a, err = Open(fileName, DefaultOptions().WithReadOnly(true))
+		require.NoError(t, err)
+
+		_, err = a.ReadAt(bs, blockSize+1)
+		require.NoError(t, err)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		err = ioutil.WriteFile(fileName, raw, 0644)
+		require.NoError(t, err)
+	})
+
+	t.Run("incomplete checksums are repaired", func(t *testing.T) {
+		a, err := Open(fileName, DefaultOptions())
+		require.NoError(t, err)
+
+		// data is made to end with a complete block
+		err = a.SetOffset(blockSize)
+		require.NoError(t, err)
+
+		_, _, err = a.Append(data[blockSize : 2*blockSize])
+		require.NoError(t, err)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		data = data[:2*blockSize]
+
+		fi, err := os.Stat(fileName)
+		require.NoError(t, err)
+
+		err = os.Truncate(fileName, fi.Size()-2)
+		require.NoError(t, err)
+
+		require.ErrorIs(t, appendable.Verify(fileName), appendable.ErrChecksumMismatch)
+
+		a, err = Open(fileName, DefaultOptions())
+		require.NoError(t, err)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		require.NoError(t, appendable.Verify(fileName))
+
+		checkData(t, data)
+	})
+
+	t.Run("files without checksums", func(t *testing.T) {
+		noChecksumsFile := filepath.Join(t.TempDir(), "testdata.aof")
+
+		a, err := Open(noChecksumsFile, DefaultOptions().WithVerifyChecksums(true))
+		require.NoError(t, err)
+
+		_, _, err = a.Append(data)
+		require.NoError(t, err)
+
+		err = a.Close()
+		require.NoError(t, err)
+
+		require.ErrorIs(t, appendable.Verify(noChecksumsFile), appendable.ErrNoChecksums)
+		require.NoError(t, appendable.Verify(filepath.Dir(noChecksumsFile)))
+	})
+}
+
+func TestSingleAppMmap(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "testdata.aof")
+
+	a, err := Open(fileName, DefaultOptions().WithChecksums(16).WithMmap(true))
+	require.NoError(t, err)
+
+	data := make([]byte, 100)
+	rand.Read(data)
+
+	_, _, err = a.Append(data[:50])
+	require.NoError(t, err)
+
+	// writable files are not mapped
+	require.Nil(t, a.mapped)
+
+	err = a.SwitchToReadOnlyMode()
+	require.NoError(t, err)
+
+	checkData := func(t *testing.T, a *AppendableFile, data []byte) {
+		if runtime.GOOS == "linux" {
+			require.NotNil(t, a.mapped)
+		}
+
+		bs := make([]byte, len(data))
+		_, err := a.ReadAt(bs, 0)
+		require.NoError(t, err)
+		require.Equal(t, data, bs)
+
+		_, err = a.ReadAt(make([]byte, 1), int64(len(data)))
+		require.ErrorIs(t, err, io.EOF)
+	}
+
+	checkData(t, a, data[:50])
+
+	err = a.Close()
+	require.NoError(t, err)
+	require.Nil(t, a.mapped)
+
+	a, err = Open(fileName, DefaultOptions())
+	require.NoError(t, err)
+
+	_, _, err = a.Append(data[50:])
+	require.NoError(t, err)
+
+	err = a.Close()
+	require.NoError(t, err)
+
+	a, err = Open(fileName, DefaultOptions().WithReadOnly(true).WithVerifyChecksums(true).WithMmap(true))
+	require.NoError(t, err)
+	defer a.Close()
+
+	checkData(t, a, data)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package singlefile
+
+import (
+	"fmt"
+	"os"
+)
+
+const DefaultFileMode = os.FileMode(0644)
+const DefaultPageSize = 4096
+
+const minPageSize = pageHeaderSize + 1
+
+type Options struct {
+	readOnly bool
+	fileMode os.FileMode
+
+	// size of the pages the file is split into, each page holds data of a single section
+	pageSize int
+}
+
+func DefaultOptions() *Options {
+	return &Options{
+		readOnly: false,
+		fileMode: DefaultFileMode,
+		pageSize: DefaultPageSize,
+	}
+}
+
+func (opts *Options) Validate() error {
+	if opts == nil {
+		return fmt.Errorf("%w: nil options", ErrInvalidOptions)
+	}
+
+	if opts.pageSize < minPageSize {
+		return fmt.Errorf("%w: invalid pageSize", ErrInvalidOptions)
+	}
+
+	return nil
+}
+
+func (opts *Options) WithReadOnly(readOnly bool) *Options {
+	opts.readOnly = readOnly
+	return opts
+}
+
+func (opts *Options) WithFileMode(fileMode os.FileMode) *Options {
+	opts.fileMode = fileMode
+	return opts
+}
+
+func (opts *Options) WithPageSize(pageSize int) *Options {
+	opts.pageSize = pageSize
+	return opts
+}
+
+func (opts *Options) GetPageSize() int {
+	return opts.pageSize
+}
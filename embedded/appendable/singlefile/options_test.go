@@ -0,0 +1,53 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package singlefile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvalidOptions(t *testing.T) {
+	for _, d := range []struct {
+		n    string
+		opts *Options
+	}{
+		{"nil", nil},
+		{"empty", &Options{}},
+		{"PageSize", DefaultOptions().WithPageSize(pageHeaderSize)},
+	} {
+		t.Run(d.n, func(t *testing.T) {
+			require.ErrorIs(t, d.opts.Validate(), ErrInvalidOptions)
+		})
+	}
+}
+
+func TestDefaultOptions(t *testing.T) {
+	require.NoError(t, DefaultOptions().Validate())
+}
+
+func TestValidOptions(t *testing.T) {
+	opts := &Options{}
+
+	require.Equal(t, DefaultFileMode, opts.WithFileMode(DefaultFileMode).fileMode)
+	require.Equal(t, DefaultPageSize, opts.WithPageSize(DefaultPageSize).pageSize)
+	require.Equal(t, DefaultPageSize, opts.GetPageSize())
+	require.True(t, opts.WithReadOnly(true).readOnly)
+
+	require.NoError(t, opts.Validate())
+}
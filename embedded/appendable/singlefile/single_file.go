@@ -0,0 +1,888 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package singlefile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+)
+
+var ErrIllegalArguments = errors.New("singlefile: illegal arguments")
+var ErrInvalidOptions = fmt.Errorf("%w: invalid options", ErrIllegalArguments)
+var ErrorPathIsADirectory = errors.New("singlefile: path is a directory")
+var ErrAlreadyClosed = errors.New("singlefile: already closed")
+var ErrReadOnly = errors.New("singlefile: read-only mode")
+var ErrCorruptedFile = errors.New("singlefile: corrupted file")
+var ErrNegativeOffset = errors.New("singlefile: negative offset")
+var ErrSectionAlreadyOpened = errors.New("singlefile: section already opened")
+
+const (
+	fileMagic          = "IMMUSF01"
+	fileHeaderSize     = len(fileMagic) + 4 // magic + pageSize
+	pageHeaderSize     = 4 + 8 + 4          // sectionID + seq + used
+	catalogSectionID   = 1
+	freePageSectionID  = 0
+	maxSectionNameLen  = 1<<16 - 1
+	catalogEntryMinLen = 4 + 2 + 4 // sectionID + nameLen + mdLen
+)
+
+// SingleFile keeps several appendables, named sections, within a single file.
+//
+// The file is split into fixed-size pages, the first one holding the file header. Every other page
+// belongs to a single section and its header holds the section ID, the position of the page within
+// the section and the number of bytes in use. Sections are allocated pages as they grow, pages
+// released by truncation are reused by any section.
+//
+// Section names and metadata are kept in a catalog, which is stored as a section itself.
+type SingleFile struct {
+	f *os.File
+
+	pageSize int
+	readOnly bool
+
+	pageCount int64 // number of pages in the file, including the header page
+	freePages []int64
+
+	sections       map[uint32]*Section
+	sectionsByName map[string]*Section
+	maxSectionID   uint32
+
+	catalog *Section
+
+	closed bool
+
+	mutex sync.RWMutex
+}
+
+var _ appendable.Appendable = (*Section)(nil)
+
+// Section is an appendable whose data is stored in pages of a SingleFile
+type Section struct {
+	sf *SingleFile
+
+	id       uint32
+	name     string
+	metadata []byte
+
+	firstSeq int64   // position of the first page still in use
+	pages    []int64 // page indexes holding the data of the section, starting from firstSeq
+	offset   int64
+
+	readOnly bool
+	opened   bool
+}
+
+func Open(path string, opts *Options) (*SingleFile, error) {
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	finfo, err := os.Stat(path)
+	if err == nil && finfo.IsDir() {
+		return nil, ErrorPathIsADirectory
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	notExist := os.IsNotExist(err)
+
+	var flag int
+
+	if opts.readOnly {
+		flag = os.O_RDONLY
+	} else {
+		flag = os.O_CREATE | os.O_RDWR
+	}
+
+	f, err := os.OpenFile(path, flag, opts.fileMode)
+	if err != nil {
+		return nil, err
+	}
+
+	sf := &SingleFile{
+		f:              f,
+		pageSize:       opts.pageSize,
+		readOnly:       opts.readOnly,
+		sections:       make(map[uint32]*Section),
+		sectionsByName: make(map[string]*Section),
+	}
+
+	if notExist {
+		err = sf.init()
+	} else {
+		err = sf.load()
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return sf, nil
+}
+
+func (sf *SingleFile) init() error {
+	var hdr [fileHeaderSize]byte
+	copy(hdr[:], fileMagic)
+	binary.BigEndian.PutUint32(hdr[len(fileMagic):], uint32(sf.pageSize))
+
+	_, err := sf.f.WriteAt(hdr[:], 0)
+	if err != nil {
+		return err
+	}
+
+	err = sf.f.Sync()
+	if err != nil {
+		return err
+	}
+
+	sf.pageCount = 1
+	sf.maxSectionID = catalogSectionID
+	sf.catalog = sf.newSection(catalogSectionID, "", nil)
+
+	return nil
+}
+
+type pageInfo struct {
+	page int64
+	used int
+}
+
+func (sf *SingleFile) load() error {
+	var hdr [fileHeaderSize]byte
+
+	_, err := sf.f.ReadAt(hdr[:], 0)
+	if err != nil {
+		return fmt.Errorf("%w: unable to read file header: %v", ErrCorruptedFile, err)
+	}
+
+	if !bytes.Equal(hdr[:len(fileMagic)], []byte(fileMagic)) {
+		return fmt.Errorf("%w: invalid file header", ErrCorruptedFile)
+	}
+
+	sf.pageSize = int(binary.BigEndian.Uint32(hdr[len(fileMagic):]))
+	if sf.pageSize < minPageSize {
+		return fmt.Errorf("%w: invalid page size", ErrCorruptedFile)
+	}
+
+	finfo, err := sf.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	fileSize := finfo.Size()
+
+	sf.pageCount = (fileSize + int64(sf.pageSize) - 1) / int64(sf.pageSize)
+	if sf.pageCount < 1 {
+		sf.pageCount = 1
+	}
+
+	pagesByID := make(map[uint32]map[int64]pageInfo)
+
+	for page := int64(1); page < sf.pageCount; page++ {
+		var phdr [pageHeaderSize]byte
+
+		n, err := sf.f.ReadAt(phdr[:], page*int64(sf.pageSize))
+		if err == io.EOF && n < pageHeaderSize {
+			// partially written page header
+			sf.freePages = append(sf.freePages, page)
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		id := binary.BigEndian.Uint32(phdr[:])
+		seq := int64(binary.BigEndian.Uint64(phdr[4:]))
+		used := int(binary.BigEndian.Uint32(phdr[12:]))
+
+		if id == freePageSectionID {
+			sf.freePages = append(sf.freePages, page)
+			continue
+		}
+
+		if seq < 0 || used > sf.dataSize() {
+			return fmt.Errorf("%w: invalid page header at page %d", ErrCorruptedFile, page)
+		}
+
+		// data of the last page may be partially written
+		available := int(fileSize - page*int64(sf.pageSize) - pageHeaderSize)
+		if used > available {
+			used = available
+		}
+
+		if id > sf.maxSectionID {
+			sf.maxSectionID = id
+		}
+
+		pages, ok := pagesByID[id]
+		if !ok {
+			pages = make(map[int64]pageInfo)
+			pagesByID[id] = pages
+		}
+
+		pages[seq] = pageInfo{page: page, used: used}
+	}
+
+	if sf.maxSectionID < catalogSectionID {
+		sf.maxSectionID = catalogSectionID
+	}
+
+	sf.catalog = sf.newSection(catalogSectionID, "", nil)
+
+	err = sf.catalog.restorePages(pagesByID[catalogSectionID])
+	if err != nil {
+		return err
+	}
+	delete(pagesByID, catalogSectionID)
+
+	err = sf.loadCatalog()
+	if err != nil {
+		return err
+	}
+
+	for id, pages := range pagesByID {
+		s, ok := sf.sections[id]
+		if !ok {
+			// section creation was not persisted in the catalog, pages can be reused
+			for _, p := range pages {
+				sf.freePages = append(sf.freePages, p.page)
+			}
+			continue
+		}
+
+		err = s.restorePages(pages)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sf *SingleFile) loadCatalog() error {
+	if sf.catalog.offset == 0 {
+		return nil
+	}
+
+	var off int64
+
+	if sf.catalog.firstSeq > 0 {
+		return fmt.Errorf("%w: catalog was truncated", ErrCorruptedFile)
+	}
+
+	data := make([]byte, sf.catalog.offset)
+
+	_, err := sf.catalog.readAt(data, 0)
+	if err != nil {
+		return err
+	}
+
+	for len(data) >= catalogEntryMinLen {
+		id := binary.BigEndian.Uint32(data)
+		nameLen := int(binary.BigEndian.Uint16(data[4:]))
+
+		if len(data) < 4+2+nameLen+4 {
+			break
+		}
+
+		name := string(data[6 : 6+nameLen])
+		mdLen := int(binary.BigEndian.Uint32(data[6+nameLen:]))
+
+		entryLen := 4 + 2 + nameLen + 4 + mdLen
+
+		if len(data) < entryLen {
+			break
+		}
+
+		var md []byte
+		if mdLen > 0 {
+			md = make([]byte, mdLen)
+			copy(md, data[6+nameLen+4:entryLen])
+		}
+
+		if id <= catalogSectionID {
+			return fmt.Errorf("%w: invalid catalog entry", ErrCorruptedFile)
+		}
+
+		s := sf.newSection(id, name, md)
+		sf.sections[id] = s
+		sf.sectionsByName[name] = s
+
+		if id > sf.maxSectionID {
+			sf.maxSectionID = id
+		}
+
+		data = data[entryLen:]
+		off += int64(entryLen)
+	}
+
+	if off < sf.catalog.offset && !sf.readOnly {
+		// discard partially written catalog entry
+		return sf.catalog.setOffset(off)
+	}
+
+	return nil
+}
+
+func (sf *SingleFile) newSection(id uint32, name string, metadata []byte) *Section {
+	return &Section{
+		sf:       sf,
+		id:       id,
+		name:     name,
+		metadata: metadata,
+		readOnly: sf.readOnly,
+	}
+}
+
+func (sf *SingleFile) dataSize() int {
+	return sf.pageSize - pageHeaderSize
+}
+
+// OpenSection opens the section with the given name, it's created if it doesn't exist yet.
+// The provided metadata is only stored when the section is created
+func (sf *SingleFile) OpenSection(name string, metadata []byte) (*Section, error) {
+	if len(name) == 0 || len(name) > maxSectionNameLen {
+		return nil, ErrIllegalArguments
+	}
+
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+
+	if sf.closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	s, ok := sf.sectionsByName[name]
+	if ok {
+		if s.opened {
+			return nil, fmt.Errorf("%w: '%s'", ErrSectionAlreadyOpened, name)
+		}
+
+		s.opened = true
+		s.readOnly = sf.readOnly
+
+		return s, nil
+	}
+
+	if sf.readOnly {
+		return nil, ErrReadOnly
+	}
+
+	id := sf.maxSectionID + 1
+
+	entry := make([]byte, 4+2+len(name)+4+len(metadata))
+	binary.BigEndian.PutUint32(entry, id)
+	binary.BigEndian.PutUint16(entry[4:], uint16(len(name)))
+	copy(entry[6:], name)
+	binary.BigEndian.PutUint32(entry[6+len(name):], uint32(len(metadata)))
+	copy(entry[6+len(name)+4:], metadata)
+
+	_, _, err := sf.catalog.append(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	md := make([]byte, len(metadata))
+	copy(md, metadata)
+
+	s = sf.newSection(id, name, md)
+	s.opened = true
+
+	sf.maxSectionID = id
+	sf.sections[id] = s
+	sf.sectionsByName[name] = s
+
+	return s, nil
+}
+
+// Sections returns the names of the sections stored in the file
+func (sf *SingleFile) Sections() []string {
+	sf.mutex.RLock()
+	defer sf.mutex.RUnlock()
+
+	names := make([]string, 0, len(sf.sectionsByName))
+	for name := range sf.sectionsByName {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func (sf *SingleFile) Sync() error {
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+
+	if sf.closed {
+		return ErrAlreadyClosed
+	}
+
+	if sf.readOnly {
+		return ErrReadOnly
+	}
+
+	return sf.f.Sync()
+}
+
+func (sf *SingleFile) Close() error {
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+
+	if sf.closed {
+		return ErrAlreadyClosed
+	}
+
+	sf.closed = true
+
+	if !sf.readOnly {
+		err := sf.f.Sync()
+		if err != nil {
+			sf.f.Close()
+			return err
+		}
+	}
+
+	return sf.f.Close()
+}
+
+func (sf *SingleFile) allocPage() int64 {
+	if len(sf.freePages) > 0 {
+		page := sf.freePages[len(sf.freePages)-1]
+		sf.freePages = sf.freePages[:len(sf.freePages)-1]
+		return page
+	}
+
+	page := sf.pageCount
+	sf.pageCount++
+
+	return page
+}
+
+func (sf *SingleFile) releasePage(page int64) error {
+	var phdr [pageHeaderSize]byte // freePageSectionID
+
+	_, err := sf.f.WriteAt(phdr[:], page*int64(sf.pageSize))
+	if err != nil {
+		return err
+	}
+
+	sf.freePages = append(sf.freePages, page)
+
+	return nil
+}
+
+func (sf *SingleFile) pageHeader(id uint32, seq int64, used int) []byte {
+	phdr := make([]byte, pageHeaderSize)
+	binary.BigEndian.PutUint32(phdr, id)
+	binary.BigEndian.PutUint64(phdr[4:], uint64(seq))
+	binary.BigEndian.PutUint32(phdr[12:], uint32(used))
+	return phdr
+}
+
+func (s *Section) restorePages(pages map[int64]pageInfo) error {
+	if len(pages) == 0 {
+		return nil
+	}
+
+	first := int64(-1)
+	last := int64(-1)
+
+	for seq := range pages {
+		if first == -1 || seq < first {
+			first = seq
+		}
+		if seq > last {
+			last = seq
+		}
+	}
+
+	if int64(len(pages)) != last-first+1 {
+		return fmt.Errorf("%w: non contiguous pages found for section %d", ErrCorruptedFile, s.id)
+	}
+
+	s.firstSeq = first
+	s.pages = make([]int64, len(pages))
+
+	for seq, p := range pages {
+		if seq < last && p.used != s.sf.dataSize() {
+			return fmt.Errorf("%w: partially filled page found for section %d", ErrCorruptedFile, s.id)
+		}
+
+		s.pages[seq-first] = p.page
+	}
+
+	s.offset = last*int64(s.sf.dataSize()) + int64(pages[last].used)
+
+	return nil
+}
+
+func (s *Section) lastSeq() int64 {
+	return s.firstSeq + int64(len(s.pages)) - 1
+}
+
+func (s *Section) Name() string {
+	return s.name
+}
+
+func (s *Section) Metadata() []byte {
+	return s.metadata
+}
+
+func (s *Section) Size() (int64, error) {
+	s.sf.mutex.RLock()
+	defer s.sf.mutex.RUnlock()
+
+	if !s.opened {
+		return 0, ErrAlreadyClosed
+	}
+
+	return s.offset, nil
+}
+
+func (s *Section) Offset() int64 {
+	s.sf.mutex.RLock()
+	defer s.sf.mutex.RUnlock()
+
+	return s.offset
+}
+
+func (s *Section) SetOffset(off int64) error {
+	s.sf.mutex.Lock()
+	defer s.sf.mutex.Unlock()
+
+	if !s.opened {
+		return ErrAlreadyClosed
+	}
+
+	if s.readOnly {
+		return ErrReadOnly
+	}
+
+	return s.setOffset(off)
+}
+
+func (s *Section) setOffset(off int64) error {
+	if off < 0 {
+		return ErrNegativeOffset
+	}
+
+	if off > s.offset {
+		return fmt.Errorf("%w: provided offset %d is bigger than current one %d", ErrIllegalArguments, off, s.offset)
+	}
+
+	if off == s.offset {
+		return nil
+	}
+
+	dataSize := int64(s.sf.dataSize())
+
+	if off < s.firstSeq*dataSize {
+		return fmt.Errorf("%w: provided offset %d was already discarded", ErrIllegalArguments, off)
+	}
+
+	// pages are kept as long as they hold at least one byte
+	keptPages := (off+dataSize-1)/dataSize - s.firstSeq
+
+	if keptPages > 0 {
+		lastSeq := s.firstSeq + keptPages - 1
+
+		_, err := s.sf.f.WriteAt(s.sf.pageHeader(s.id, lastSeq, int(off-lastSeq*dataSize)), s.pages[keptPages-1]*int64(s.sf.pageSize))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, page := range s.pages[keptPages:] {
+		err := s.sf.releasePage(page)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.pages = s.pages[:keptPages]
+
+	if keptPages == 0 {
+		s.firstSeq = off / dataSize
+	}
+
+	s.offset = off
+
+	return nil
+}
+
+func (s *Section) DiscardUpto(off int64) error {
+	s.sf.mutex.Lock()
+	defer s.sf.mutex.Unlock()
+
+	if !s.opened {
+		return ErrAlreadyClosed
+	}
+
+	if s.readOnly {
+		return ErrReadOnly
+	}
+
+	if s.offset < off {
+		return fmt.Errorf("%w: discard beyond existent data boundaries", ErrIllegalArguments)
+	}
+
+	dataSize := int64(s.sf.dataSize())
+
+	// the last page is always kept, thus the offset of the section is preserved upon reopening
+	for len(s.pages) > 1 && (s.firstSeq+1)*dataSize <= off {
+		err := s.sf.releasePage(s.pages[0])
+		if err != nil {
+			return err
+		}
+
+		s.pages = s.pages[1:]
+		s.firstSeq++
+	}
+
+	return nil
+}
+
+func (s *Section) Append(bs []byte) (off int64, n int, err error) {
+	s.sf.mutex.Lock()
+	defer s.sf.mutex.Unlock()
+
+	if !s.opened {
+		return 0, 0, ErrAlreadyClosed
+	}
+
+	if s.readOnly {
+		return 0, 0, ErrReadOnly
+	}
+
+	if len(bs) == 0 {
+		return 0, 0, ErrIllegalArguments
+	}
+
+	return s.append(bs)
+}
+
+func (s *Section) append(bs []byte) (off int64, n int, err error) {
+	off = s.offset
+
+	dataSize := int64(s.sf.dataSize())
+
+	for n < len(bs) {
+		seq := s.offset / dataSize
+		inPageOff := int(s.offset % dataSize)
+
+		chunkLen := len(bs) - n
+		if chunkLen > int(dataSize)-inPageOff {
+			chunkLen = int(dataSize) - inPageOff
+		}
+
+		if inPageOff == 0 {
+			// a new page is needed, header and data are written at once
+			page := s.sf.allocPage()
+
+			buf := make([]byte, pageHeaderSize+chunkLen)
+			copy(buf, s.sf.pageHeader(s.id, seq, chunkLen))
+			copy(buf[pageHeaderSize:], bs[n:n+chunkLen])
+
+			_, err = s.sf.f.WriteAt(buf, page*int64(s.sf.pageSize))
+			if err != nil {
+				s.sf.freePages = append(s.sf.freePages, page)
+				return off, n, err
+			}
+
+			if len(s.pages) == 0 {
+				s.firstSeq = seq
+			}
+			s.pages = append(s.pages, page)
+		} else {
+			pageOff := s.pages[seq-s.firstSeq] * int64(s.sf.pageSize)
+
+			_, err = s.sf.f.WriteAt(bs[n:n+chunkLen], pageOff+pageHeaderSize+int64(inPageOff))
+			if err != nil {
+				return off, n, err
+			}
+
+			_, err = s.sf.f.WriteAt(s.sf.pageHeader(s.id, seq, inPageOff+chunkLen), pageOff)
+			if err != nil {
+				return off, n, err
+			}
+		}
+
+		n += chunkLen
+		s.offset += int64(chunkLen)
+	}
+
+	return off, n, nil
+}
+
+func (s *Section) Flush() error {
+	s.sf.mutex.RLock()
+	defer s.sf.mutex.RUnlock()
+
+	if !s.opened {
+		return ErrAlreadyClosed
+	}
+
+	if s.readOnly {
+		return ErrReadOnly
+	}
+
+	// writes are not buffered
+	return nil
+}
+
+func (s *Section) Sync() error {
+	s.sf.mutex.RLock()
+	defer s.sf.mutex.RUnlock()
+
+	if !s.opened {
+		return ErrAlreadyClosed
+	}
+
+	if s.readOnly {
+		return ErrReadOnly
+	}
+
+	return s.sf.f.Sync()
+}
+
+func (s *Section) SwitchToReadOnlyMode() error {
+	s.sf.mutex.Lock()
+	defer s.sf.mutex.Unlock()
+
+	if !s.opened {
+		return ErrAlreadyClosed
+	}
+
+	if s.readOnly {
+		return ErrReadOnly
+	}
+
+	s.readOnly = true
+
+	return nil
+}
+
+func (s *Section) ReadAt(bs []byte, off int64) (int, error) {
+	s.sf.mutex.RLock()
+	defer s.sf.mutex.RUnlock()
+
+	if !s.opened {
+		return 0, ErrAlreadyClosed
+	}
+
+	if off < 0 {
+		return 0, ErrNegativeOffset
+	}
+
+	return s.readAt(bs, off)
+}
+
+func (s *Section) readAt(bs []byte, off int64) (int, error) {
+	dataSize := int64(s.sf.dataSize())
+
+	r := 0
+
+	for r < len(bs) {
+		pos := off + int64(r)
+
+		seq := pos / dataSize
+
+		if pos >= s.offset || seq < s.firstSeq {
+			return r, io.EOF
+		}
+
+		inPageOff := pos % dataSize
+
+		chunkLen := int64(len(bs) - r)
+		if chunkLen > dataSize-inPageOff {
+			chunkLen = dataSize - inPageOff
+		}
+		if chunkLen > s.offset-pos {
+			chunkLen = s.offset - pos
+		}
+
+		pageOff := s.pages[seq-s.firstSeq] * int64(s.sf.pageSize)
+
+		n, err := s.sf.f.ReadAt(bs[r:r+int(chunkLen)], pageOff+pageHeaderSize+inPageOff)
+		r += n
+		if err != nil {
+			return r, err
+		}
+	}
+
+	return r, nil
+}
+
+func (s *Section) Close() error {
+	s.sf.mutex.Lock()
+	defer s.sf.mutex.Unlock()
+
+	if !s.opened {
+		return ErrAlreadyClosed
+	}
+
+	s.opened = false
+
+	return nil
+}
+
+// Copy writes the data of the section into a regular file
+func (s *Section) Copy(dstPath string) error {
+	s.sf.mutex.RLock()
+	defer s.sf.mutex.RUnlock()
+
+	if !s.opened {
+		return ErrAlreadyClosed
+	}
+
+	dstFile, err := os.Create(dstPath)
+	if err != nil {
+		return err
+	}
+	defer dstFile.Close()
+
+	start := s.firstSeq * int64(s.sf.dataSize())
+
+	_, err = io.Copy(dstFile, io.NewSectionReader(readerAtFunc(s.readAt), start, s.offset-start))
+	if err != nil {
+		return err
+	}
+
+	return dstFile.Sync()
+}
+
+type readerAtFunc func(bs []byte, off int64) (int, error)
+
+func (f readerAtFunc) ReadAt(bs []byte, off int64) (int, error) {
+	return f(bs, off)
+}
+
+func (s *Section) CompressionFormat() int {
+	return appendable.NoCompression
+}
+
+func (s *Section) CompressionLevel() int {
+	return appendable.DefaultCompressionLevel
+}
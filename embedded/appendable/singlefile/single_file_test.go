@@ -0,0 +1,274 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package singlefile
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenInvalidPath(t *testing.T) {
+	_, err := Open(t.TempDir(), DefaultOptions())
+	require.ErrorIs(t, err, ErrorPathIsADirectory)
+
+	_, err = Open(filepath.Join(t.TempDir(), "data"), nil)
+	require.ErrorIs(t, err, ErrInvalidOptions)
+
+	_, err = Open(filepath.Join(t.TempDir(), "data"), DefaultOptions().WithReadOnly(true))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	path := filepath.Join(t.TempDir(), "data")
+	require.NoError(t, os.WriteFile(path, []byte("not a single file store"), 0644))
+
+	_, err = Open(path, DefaultOptions())
+	require.ErrorIs(t, err, ErrCorruptedFile)
+}
+
+func TestSingleFileSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+
+	sf, err := Open(path, DefaultOptions().WithPageSize(pageHeaderSize+4))
+	require.NoError(t, err)
+
+	_, err = sf.OpenSection("", nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	s1, err := sf.OpenSection("s1", []byte("md1"))
+	require.NoError(t, err)
+	require.Equal(t, "s1", s1.Name())
+	require.Equal(t, []byte("md1"), s1.Metadata())
+	require.Equal(t, appendable.NoCompression, s1.CompressionFormat())
+
+	_, err = sf.OpenSection("s1", nil)
+	require.ErrorIs(t, err, ErrSectionAlreadyOpened)
+
+	s2, err := sf.OpenSection("s2", nil)
+	require.NoError(t, err)
+
+	_, _, err = s1.Append(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	// interleaved appends make pages of both sections to be mixed within the file
+	off, n, err := s1.Append([]byte("hello"))
+	require.NoError(t, err)
+	require.Equal(t, int64(0), off)
+	require.Equal(t, 5, n)
+
+	off, n, err = s2.Append([]byte("abcdefghij"))
+	require.NoError(t, err)
+	require.Equal(t, int64(0), off)
+	require.Equal(t, 10, n)
+
+	off, n, err = s1.Append([]byte(" world"))
+	require.NoError(t, err)
+	require.Equal(t, int64(5), off)
+	require.Equal(t, 6, n)
+
+	sz, err := s1.Size()
+	require.NoError(t, err)
+	require.Equal(t, int64(11), sz)
+	require.Equal(t, int64(11), s1.Offset())
+
+	b := make([]byte, 11)
+	_, err = s1.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello world"), b)
+
+	b = make([]byte, 4)
+	_, err = s2.ReadAt(b, 3)
+	require.NoError(t, err)
+	require.Equal(t, []byte("defg"), b)
+
+	n, err = s2.ReadAt(b, 8)
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 2, n)
+
+	_, err = s2.ReadAt(b, -1)
+	require.ErrorIs(t, err, ErrNegativeOffset)
+
+	require.NoError(t, s1.Flush())
+	require.NoError(t, s1.Sync())
+
+	require.ElementsMatch(t, []string{"s1", "s2"}, sf.Sections())
+
+	t.Run("set offset should release trailing pages", func(t *testing.T) {
+		err := s2.SetOffset(11)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		err = s2.SetOffset(-1)
+		require.ErrorIs(t, err, ErrNegativeOffset)
+
+		err = s2.SetOffset(3)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), s2.Offset())
+
+		_, _, err = s2.Append([]byte("XYZ"))
+		require.NoError(t, err)
+
+		b := make([]byte, 6)
+		_, err = s2.ReadAt(b, 0)
+		require.NoError(t, err)
+		require.Equal(t, []byte("abcXYZ"), b)
+	})
+
+	t.Run("discarded data should not be readable", func(t *testing.T) {
+		err := s1.DiscardUpto(12)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		err = s1.DiscardUpto(9)
+		require.NoError(t, err)
+
+		b := make([]byte, 2)
+		_, err = s1.ReadAt(b, 0)
+		require.ErrorIs(t, err, io.EOF)
+
+		_, err = s1.ReadAt(b, 8)
+		require.NoError(t, err)
+		require.Equal(t, []byte("rl"), b)
+
+		err = s1.SetOffset(4)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	copyPath := filepath.Join(t.TempDir(), "copy")
+	require.NoError(t, s2.Copy(copyPath))
+
+	copied, err := os.ReadFile(copyPath)
+	require.NoError(t, err)
+	require.Equal(t, []byte("abcXYZ"), copied)
+
+	require.NoError(t, s1.Close())
+	require.ErrorIs(t, s1.Close(), ErrAlreadyClosed)
+
+	_, _, err = s1.Append([]byte("a"))
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	require.NoError(t, s2.Close())
+	require.NoError(t, sf.Close())
+	require.ErrorIs(t, sf.Close(), ErrAlreadyClosed)
+
+	_, err = sf.OpenSection("s1", nil)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+
+	t.Run("sections should be recovered upon reopening", func(t *testing.T) {
+		sf, err := Open(path, DefaultOptions())
+		require.NoError(t, err)
+		defer sf.Close()
+
+		s1, err := sf.OpenSection("s1", []byte("ignored"))
+		require.NoError(t, err)
+		require.Equal(t, []byte("md1"), s1.Metadata())
+		require.Equal(t, int64(11), s1.Offset())
+
+		b := make([]byte, 3)
+		_, err = s1.ReadAt(b, 8)
+		require.NoError(t, err)
+		require.Equal(t, []byte("rld"), b)
+
+		_, err = s1.ReadAt(b, 0)
+		require.ErrorIs(t, err, io.EOF)
+
+		s2, err := sf.OpenSection("s2", nil)
+		require.NoError(t, err)
+		require.Equal(t, int64(6), s2.Offset())
+
+		b = make([]byte, 6)
+		_, err = s2.ReadAt(b, 0)
+		require.NoError(t, err)
+		require.Equal(t, []byte("abcXYZ"), b)
+
+		// released pages are reused
+		s3, err := sf.OpenSection("s3", nil)
+		require.NoError(t, err)
+
+		_, _, err = s3.Append([]byte("0123456789"))
+		require.NoError(t, err)
+
+		b = make([]byte, 10)
+		_, err = s3.ReadAt(b, 0)
+		require.NoError(t, err)
+		require.Equal(t, []byte("0123456789"), b)
+	})
+
+	t.Run("read-only mode", func(t *testing.T) {
+		sf, err := Open(path, DefaultOptions().WithReadOnly(true))
+		require.NoError(t, err)
+		defer sf.Close()
+
+		_, err = sf.OpenSection("s4", nil)
+		require.ErrorIs(t, err, ErrReadOnly)
+
+		s3, err := sf.OpenSection("s3", nil)
+		require.NoError(t, err)
+
+		b := make([]byte, 10)
+		_, err = s3.ReadAt(b, 0)
+		require.NoError(t, err)
+		require.Equal(t, []byte("0123456789"), b)
+
+		_, _, err = s3.Append([]byte("a"))
+		require.ErrorIs(t, err, ErrReadOnly)
+
+		require.ErrorIs(t, s3.SetOffset(0), ErrReadOnly)
+		require.ErrorIs(t, s3.DiscardUpto(0), ErrReadOnly)
+		require.ErrorIs(t, s3.Flush(), ErrReadOnly)
+		require.ErrorIs(t, s3.Sync(), ErrReadOnly)
+		require.ErrorIs(t, s3.SwitchToReadOnlyMode(), ErrReadOnly)
+		require.ErrorIs(t, sf.Sync(), ErrReadOnly)
+	})
+}
+
+func TestSingleFileRecoveryWithPartialWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+
+	sf, err := Open(path, DefaultOptions().WithPageSize(pageHeaderSize+8))
+	require.NoError(t, err)
+
+	s, err := sf.OpenSection("s", nil)
+	require.NoError(t, err)
+
+	_, _, err = s.Append([]byte("0123456789"))
+	require.NoError(t, err)
+
+	require.NoError(t, sf.Close())
+
+	// simulate an interrupted write of the last page
+	finfo, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, finfo.Size()-1))
+
+	sf, err = Open(path, DefaultOptions())
+	require.NoError(t, err)
+	defer sf.Close()
+
+	s, err = sf.OpenSection("s", nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(9), s.Offset())
+
+	_, _, err = s.Append([]byte("X"))
+	require.NoError(t, err)
+
+	b := make([]byte, 10)
+	_, err = s.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte("012345678X"), b)
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import "time"
+
+// Cache is implemented by LRUCache and ShardedCache, so that the level of
+// concurrency of a cache can be chosen without affecting its users
+type Cache interface {
+	Resize(size int)
+	Put(key interface{}, value interface{}) (rkey interface{}, rvalue interface{}, err error)
+	PutWithTTL(key interface{}, value interface{}, ttl time.Duration) (rkey interface{}, rvalue interface{}, err error)
+	Get(key interface{}) (interface{}, error)
+	Pop(key interface{}) (interface{}, error)
+	Replace(key interface{}, value interface{}) (interface{}, error)
+	Size() int
+	Cost() int
+	EntriesCount() int
+	Apply(fun func(k interface{}, v interface{}) error) error
+}
+
+var _ Cache = (*LRUCache)(nil)
+var _ Cache = (*ShardedCache)(nil)
+
+// New creates an LRU cache of the given size, split into the given number of shards
+// when it's greater than 1 so that concurrent accesses don't contend for a single lock
+func New(size int, shards int, costFunc CostFunc) (Cache, error) {
+	if shards == 1 {
+		return NewWeightedLRUCache(size, costFunc)
+	}
+
+	return NewShardedCache(size, shards, costFunc)
+}
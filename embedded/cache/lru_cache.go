@@ -21,75 +21,142 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 )
 
 var ErrIllegalArguments = errors.New("illegal arguments")
 var ErrKeyNotFound = errors.New("key not found")
 var ErrIllegalState = errors.New("illegal state")
 
+// CostFunc returns the cost of an entry, e.g. its serialized size in bytes
+type CostFunc func(key interface{}, value interface{}) int
+
+// LRUCache evicts the least recently used entries once the cost of the stored entries exceeds its size.
+// Unless a cost function is provided every entry costs 1, thus the size bounds the number of entries
 type LRUCache struct {
-	data    map[interface{}]*entry
-	lruList *list.List
-	size    int
+	data     map[interface{}]*entry
+	lruList  *list.List
+	size     int
+	cost     int
+	costFunc CostFunc
+
+	now func() time.Time
 
 	mutex sync.Mutex
 }
 
 type entry struct {
-	value interface{}
-	order *list.Element
+	value     interface{}
+	order     *list.Element
+	cost      int
+	expiresAt time.Time // zero if the entry does not expire
 }
 
 func NewLRUCache(size int) (*LRUCache, error) {
+	return NewWeightedLRUCache(size, nil)
+}
+
+// NewWeightedLRUCache creates a cache holding entries up to a total cost of size,
+// as computed by costFunc when every entry is stored
+func NewWeightedLRUCache(size int, costFunc CostFunc) (*LRUCache, error) {
 	if size < 1 {
 		return nil, ErrIllegalArguments
 	}
 
+	capacity := size
+	if costFunc != nil {
+		// the number of entries is unknown
+		capacity = 0
+	}
+
 	return &LRUCache{
-		data:    make(map[interface{}]*entry, size),
-		lruList: list.New(),
-		size:    size,
+		data:     make(map[interface{}]*entry, capacity),
+		lruList:  list.New(),
+		size:     size,
+		costFunc: costFunc,
+		now:      time.Now,
 	}, nil
 }
 
+// Resize changes the size of the cache, evicting entries until their total cost fits in it
 func (c *LRUCache) Resize(size int) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	for size < c.lruList.Len() {
+	for size < c.cost && c.lruList.Len() > 0 {
 		c.evict()
 	}
 
 	c.size = size
 }
 
+// Put stores an entry which does not expire. In a weighted cache storing
+// an entry may evict several others, only the least recently used is returned
 func (c *LRUCache) Put(key interface{}, value interface{}) (rkey interface{}, rvalue interface{}, err error) {
+	return c.PutWithTTL(key, value, 0)
+}
+
+// PutWithTTL stores an entry which is not returned anymore once ttl has elapsed, 0 means no expiration
+func (c *LRUCache) PutWithTTL(key interface{}, value interface{}, ttl time.Duration) (rkey interface{}, rvalue interface{}, err error) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	if key == nil || value == nil {
+	if key == nil || value == nil || ttl < 0 {
 		return nil, nil, ErrIllegalArguments
 	}
 
+	cost := 1
+	if c.costFunc != nil {
+		cost = c.costFunc(key, value)
+		if cost < 0 {
+			return nil, nil, ErrIllegalArguments
+		}
+	}
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = c.now().Add(ttl)
+	}
+
 	e, ok := c.data[key]
 
 	if ok {
+		c.cost += cost - e.cost
+
 		e.value = value
+		e.cost = cost
+		e.expiresAt = expiresAt
 		c.lruList.MoveToBack(e.order)
-		return
+	} else {
+		e = &entry{
+			value:     value,
+			order:     c.lruList.PushBack(key),
+			cost:      cost,
+			expiresAt: expiresAt,
+		}
+		c.data[key] = e
+		c.cost += cost
 	}
 
-	e = &entry{
-		value: value,
-		order: c.lruList.PushBack(key),
-	}
-	c.data[key] = e
+	return c.evictOverflow()
+}
 
-	if c.lruList.Len() > c.size {
-		return c.evict()
+// evictOverflow evicts the least recently used entries until their total cost fits in the cache,
+// the most recently used entry is kept even if it doesn't fit on its own.
+// Only the first evicted entry is returned
+func (c *LRUCache) evictOverflow() (rkey interface{}, rvalue interface{}, err error) {
+	for c.cost > c.size && c.lruList.Len() > 1 {
+		k, v, err := c.evict()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if rkey == nil {
+			rkey, rvalue = k, v
+		}
 	}
 
-	return nil, nil, nil
+	return rkey, rvalue, nil
 }
 
 func (c *LRUCache) evict() (rkey interface{}, rvalue interface{}, err error) {
@@ -103,12 +170,36 @@ func (c *LRUCache) evict() (rkey interface{}, rvalue interface{}, err error) {
 	re := c.data[rkey]
 	rvalue = re.value
 
-	delete(c.data, rkey)
-	c.lruList.Remove(lruEntry)
+	c.remove(rkey, re)
 
 	return rkey, rvalue, nil
 }
 
+func (c *LRUCache) remove(key interface{}, e *entry) {
+	delete(c.data, key)
+	c.lruList.Remove(e.order)
+	c.cost -= e.cost
+}
+
+func (c *LRUCache) expired(e *entry) bool {
+	return !e.expiresAt.IsZero() && !c.now().Before(e.expiresAt)
+}
+
+// lookup returns the entry stored for key, expired entries are removed
+func (c *LRUCache) lookup(key interface{}) (*entry, bool) {
+	e, ok := c.data[key]
+	if !ok {
+		return nil, false
+	}
+
+	if c.expired(e) {
+		c.remove(key, e)
+		return nil, false
+	}
+
+	return e, true
+}
+
 func (c *LRUCache) Get(key interface{}) (interface{}, error) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
@@ -117,7 +208,7 @@ func (c *LRUCache) Get(key interface{}) (interface{}, error) {
 		return nil, ErrIllegalArguments
 	}
 
-	e, ok := c.data[key]
+	e, ok := c.lookup(key)
 	if !ok {
 		return nil, ErrKeyNotFound
 	}
@@ -135,16 +226,17 @@ func (c *LRUCache) Pop(key interface{}) (interface{}, error) {
 		return nil, ErrIllegalArguments
 	}
 
-	e, ok := c.data[key]
+	e, ok := c.lookup(key)
 	if !ok {
 		return nil, ErrKeyNotFound
 	}
 
-	c.lruList.Remove(e.order)
-	delete(c.data, key)
+	c.remove(key, e)
 	return e.value, nil
 }
 
+// Replace updates the value of an existing entry, which becomes the most recently used one.
+// In a weighted cache other entries are evicted when the new value increases the total cost above the size
 func (c *LRUCache) Replace(k interface{}, v interface{}) (interface{}, error) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
@@ -152,12 +244,29 @@ func (c *LRUCache) Replace(k interface{}, v interface{}) (interface{}, error) {
 	if k == nil {
 		return nil, ErrIllegalArguments
 	}
-	e, ok := c.data[k]
+	e, ok := c.lookup(k)
 	if !ok {
 		return nil, ErrKeyNotFound
 	}
+
+	if c.costFunc != nil {
+		cost := c.costFunc(k, v)
+		if cost < 0 {
+			return nil, ErrIllegalArguments
+		}
+
+		c.cost += cost - e.cost
+		e.cost = cost
+	}
+
 	oldV := e.value
 	e.value = v
+	c.lruList.MoveToBack(e.order)
+
+	_, _, err := c.evictOverflow()
+	if err != nil {
+		return nil, err
+	}
 
 	return oldV, nil
 }
@@ -169,6 +278,15 @@ func (c *LRUCache) Size() int {
 	return c.size
 }
 
+// Cost returns the total cost of the stored entries
+func (c *LRUCache) Cost() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.cost
+}
+
+// EntriesCount returns the number of stored entries, including expired ones not removed yet
 func (c *LRUCache) EntriesCount() int {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
@@ -176,11 +294,16 @@ func (c *LRUCache) EntriesCount() int {
 	return c.lruList.Len()
 }
 
+// Apply calls fun for every entry that has not expired
 func (c *LRUCache) Apply(fun func(k interface{}, v interface{}) error) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
 	for k, e := range c.data {
+		if c.expired(e) {
+			continue
+		}
+
 		err := fun(k, e.value)
 		if err != nil {
 			return err
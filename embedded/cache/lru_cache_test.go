@@ -19,6 +19,7 @@ package cache
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -213,3 +214,166 @@ func TestCacheResizing(t *testing.T) {
 		require.NoError(t, err)
 	}
 }
+
+func TestWeightedCache(t *testing.T) {
+	_, err := NewWeightedLRUCache(0, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	cost := func(k, v interface{}) int { return len(v.(string)) }
+
+	cache, err := NewWeightedLRUCache(10, cost)
+	require.NoError(t, err)
+
+	_, _, err = cache.Put("a", "aaaa")
+	require.NoError(t, err)
+	_, _, err = cache.Put("b", "bbbb")
+	require.NoError(t, err)
+	require.Equal(t, 8, cache.Cost())
+
+	_, err = cache.Get("a")
+	require.NoError(t, err)
+
+	// "b" is the least recently used entry
+	rkey, rvalue, err := cache.Put("c", "cccc")
+	require.NoError(t, err)
+	require.Equal(t, "b", rkey)
+	require.Equal(t, "bbbb", rvalue)
+	require.Equal(t, 8, cache.Cost())
+	require.Equal(t, 2, cache.EntriesCount())
+
+	// several entries are evicted to make room for a costly one
+	rkey, _, err = cache.Put("d", "ddddddddd")
+	require.NoError(t, err)
+	require.Equal(t, "a", rkey)
+	require.Equal(t, 9, cache.Cost())
+	require.Equal(t, 1, cache.EntriesCount())
+
+	// an entry exceeding the size is kept alone
+	_, _, err = cache.Put("e", "eeeeeeeeeeeeeeee")
+	require.NoError(t, err)
+	require.Equal(t, 16, cache.Cost())
+	require.Equal(t, 1, cache.EntriesCount())
+
+	// updating an entry updates its cost
+	_, _, err = cache.Put("e", "e")
+	require.NoError(t, err)
+	require.Equal(t, 1, cache.Cost())
+
+	_, err = cache.Replace("e", "ee")
+	require.NoError(t, err)
+	require.Equal(t, 2, cache.Cost())
+
+	_, err = cache.Pop("e")
+	require.NoError(t, err)
+	require.Zero(t, cache.Cost())
+
+	// replacing a value evicts entries when its cost grows
+	_, _, err = cache.Put("f", "ffff")
+	require.NoError(t, err)
+	_, _, err = cache.Put("g", "gggg")
+	require.NoError(t, err)
+
+	_, err = cache.Replace("f", "ffffffff")
+	require.NoError(t, err)
+	require.Equal(t, 8, cache.Cost())
+	require.Equal(t, 1, cache.EntriesCount())
+
+	_, err = cache.Get("g")
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	val, err := cache.Get("f")
+	require.NoError(t, err)
+	require.Equal(t, "ffffffff", val)
+
+	negative, err := NewWeightedLRUCache(10, func(k, v interface{}) int { return -1 })
+	require.NoError(t, err)
+
+	_, _, err = negative.Put("a", "a")
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
+
+func TestWeightedCacheResizing(t *testing.T) {
+	cache, err := NewWeightedLRUCache(100, func(k, v interface{}) int { return v.(int) })
+	require.NoError(t, err)
+
+	for i := 1; i <= 10; i++ {
+		_, _, err = cache.Put(i, 10)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 100, cache.Cost())
+
+	cache.Resize(35)
+	require.Equal(t, 35, cache.Size())
+	require.Equal(t, 30, cache.Cost())
+
+	for i := 1; i <= 7; i++ {
+		_, err = cache.Get(i)
+		require.ErrorIs(t, err, ErrKeyNotFound)
+	}
+
+	for i := 8; i <= 10; i++ {
+		_, err = cache.Get(i)
+		require.NoError(t, err)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	cache, err := NewLRUCache(10)
+	require.NoError(t, err)
+
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	_, _, err = cache.PutWithTTL(1, 10, -time.Second)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, _, err = cache.PutWithTTL(1, 10, time.Second)
+	require.NoError(t, err)
+
+	_, _, err = cache.PutWithTTL(2, 20, time.Minute)
+	require.NoError(t, err)
+
+	_, _, err = cache.Put(3, 30)
+	require.NoError(t, err)
+
+	v, err := cache.Get(1)
+	require.NoError(t, err)
+	require.Equal(t, 10, v)
+
+	now = now.Add(time.Second)
+
+	_, err = cache.Get(1)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	_, err = cache.Replace(1, 11)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	require.Equal(t, 2, cache.EntriesCount())
+
+	now = now.Add(time.Hour)
+
+	c := 0
+	err = cache.Apply(func(k, v interface{}) error {
+		require.Equal(t, 3, k)
+		c++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, c)
+
+	_, err = cache.Pop(2)
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	// storing an entry again resets its expiration
+	_, _, err = cache.PutWithTTL(3, 31, time.Second)
+	require.NoError(t, err)
+
+	_, _, err = cache.Put(3, 32)
+	require.NoError(t, err)
+
+	now = now.Add(time.Hour)
+
+	v, err = cache.Get(3)
+	require.NoError(t, err)
+	require.Equal(t, 32, v)
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// ShardedCache spreads entries over several LRU caches, each guarded by its own lock,
+// by the hash of their keys. Eviction is LRU within every shard, which gets an equal
+// part of the size, so the least recently used entries are evicted only approximately
+type ShardedCache struct {
+	shards []*LRUCache
+}
+
+// NewShardedCache creates a cache holding entries up to a total cost of size split into shards,
+// the number of shards is lowered if needed so that every shard has a size of at least 1
+func NewShardedCache(size int, shards int, costFunc CostFunc) (*ShardedCache, error) {
+	if size < 1 || shards < 1 {
+		return nil, ErrIllegalArguments
+	}
+
+	if shards > size {
+		shards = size
+	}
+
+	c := &ShardedCache{
+		shards: make([]*LRUCache, shards),
+	}
+
+	for i := range c.shards {
+		shard, err := NewWeightedLRUCache(c.shardSize(size, i), costFunc)
+		if err != nil {
+			return nil, err
+		}
+
+		c.shards[i] = shard
+	}
+
+	return c, nil
+}
+
+// shardSize returns the part of size given to the i-th shard
+func (c *ShardedCache) shardSize(size int, i int) int {
+	shardSize := size / len(c.shards)
+	if i < size%len(c.shards) {
+		shardSize++
+	}
+	return shardSize
+}
+
+func (c *ShardedCache) shard(key interface{}) *LRUCache {
+	return c.shards[keyHash(key)%uint64(len(c.shards))]
+}
+
+func keyHash(key interface{}) uint64 {
+	switch k := key.(type) {
+	case int:
+		return mix64(uint64(k))
+	case int64:
+		return mix64(uint64(k))
+	case uint64:
+		return mix64(k)
+	case uint32:
+		return mix64(uint64(k))
+	case string:
+		h := fnv.New64a()
+		h.Write([]byte(k))
+		return h.Sum64()
+	default:
+		h := fnv.New64a()
+		fmt.Fprint(h, k)
+		return h.Sum64()
+	}
+}
+
+// mix64 spreads sequential or aligned integers, e.g. offsets, evenly over the shards
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// Resize changes the size of the cache, the new size is split evenly among the shards
+func (c *ShardedCache) Resize(size int) {
+	for i, shard := range c.shards {
+		shard.Resize(c.shardSize(size, i))
+	}
+}
+
+func (c *ShardedCache) Put(key interface{}, value interface{}) (rkey interface{}, rvalue interface{}, err error) {
+	return c.PutWithTTL(key, value, 0)
+}
+
+func (c *ShardedCache) PutWithTTL(key interface{}, value interface{}, ttl time.Duration) (rkey interface{}, rvalue interface{}, err error) {
+	if key == nil {
+		return nil, nil, ErrIllegalArguments
+	}
+
+	return c.shard(key).PutWithTTL(key, value, ttl)
+}
+
+func (c *ShardedCache) Get(key interface{}) (interface{}, error) {
+	if key == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	return c.shard(key).Get(key)
+}
+
+func (c *ShardedCache) Pop(key interface{}) (interface{}, error) {
+	if key == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	return c.shard(key).Pop(key)
+}
+
+func (c *ShardedCache) Replace(key interface{}, value interface{}) (interface{}, error) {
+	if key == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	return c.shard(key).Replace(key, value)
+}
+
+func (c *ShardedCache) Size() int {
+	size := 0
+	for _, shard := range c.shards {
+		size += shard.Size()
+	}
+	return size
+}
+
+func (c *ShardedCache) Cost() int {
+	cost := 0
+	for _, shard := range c.shards {
+		cost += shard.Cost()
+	}
+	return cost
+}
+
+func (c *ShardedCache) EntriesCount() int {
+	count := 0
+	for _, shard := range c.shards {
+		count += shard.EntriesCount()
+	}
+	return count
+}
+
+// Apply calls fun for every entry, shards are locked one at a time
+func (c *ShardedCache) Apply(fun func(k interface{}, v interface{}) error) error {
+	for _, shard := range c.shards {
+		err := shard.Apply(fun)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
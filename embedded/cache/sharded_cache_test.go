@@ -0,0 +1,181 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardedCacheCreation(t *testing.T) {
+	_, err := NewShardedCache(0, 4, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = NewShardedCache(10, 0, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	c, err := NewShardedCache(3, 8, nil)
+	require.NoError(t, err)
+	require.Len(t, c.shards, 3)
+	require.Equal(t, 3, c.Size())
+
+	c, err = NewShardedCache(10, 4, nil)
+	require.NoError(t, err)
+	require.Len(t, c.shards, 4)
+	require.Equal(t, 10, c.Size())
+
+	lru, err := New(10, 1, nil)
+	require.NoError(t, err)
+	require.IsType(t, &LRUCache{}, lru)
+
+	sharded, err := New(10, 2, nil)
+	require.NoError(t, err)
+	require.IsType(t, &ShardedCache{}, sharded)
+
+	_, err = New(10, 0, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
+
+func TestShardedCache(t *testing.T) {
+	c, err := NewShardedCache(1000, 8, nil)
+	require.NoError(t, err)
+
+	_, _, err = c.Put(nil, 1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = c.Get(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = c.Pop(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = c.Replace(nil, 1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	for i := 0; i < 100; i++ {
+		_, _, err = c.Put(int64(i), i)
+		require.NoError(t, err)
+
+		_, _, err = c.Put(strconv.Itoa(i), i)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 200, c.EntriesCount())
+	require.Equal(t, 200, c.Cost())
+
+	for _, shard := range c.shards {
+		require.NotZero(t, shard.EntriesCount())
+	}
+
+	for i := 0; i < 100; i++ {
+		v, err := c.Get(int64(i))
+		require.NoError(t, err)
+		require.Equal(t, i, v)
+
+		v, err = c.Get(strconv.Itoa(i))
+		require.NoError(t, err)
+		require.Equal(t, i, v)
+	}
+
+	v, err := c.Replace(int64(5), 50)
+	require.NoError(t, err)
+	require.Equal(t, 5, v)
+
+	v, err = c.Pop(int64(5))
+	require.NoError(t, err)
+	require.Equal(t, 50, v)
+
+	_, err = c.Get(int64(5))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	count := 0
+	err = c.Apply(func(k, v interface{}) error {
+		count++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 199, count)
+
+	err = c.Apply(func(k, v interface{}) error {
+		return errors.New("expected error")
+	})
+	require.Error(t, err)
+
+	c.Resize(16)
+	require.Equal(t, 16, c.Size())
+	require.LessOrEqual(t, c.EntriesCount(), 16)
+}
+
+func TestShardedCacheEviction(t *testing.T) {
+	c, err := NewShardedCache(100, 4, func(k, v interface{}) int { return 10 })
+	require.NoError(t, err)
+
+	evicted := 0
+	for i := 0; i < 1000; i++ {
+		rkey, _, err := c.Put(i, i)
+		require.NoError(t, err)
+
+		if rkey != nil {
+			evicted++
+		}
+	}
+
+	require.LessOrEqual(t, c.Cost(), 100)
+	require.Equal(t, 1000, evicted+c.EntriesCount())
+}
+
+const benchmarkCacheSize = 10_000
+
+func benchmarkCacheParallel(b *testing.B, c Cache) {
+	for i := 0; i < benchmarkCacheSize; i++ {
+		c.Put(int64(i), i)
+	}
+
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		rnd := rand.New(rand.NewSource(rand.Int63()))
+
+		for pb.Next() {
+			key := int64(rnd.Intn(2 * benchmarkCacheSize))
+
+			// mostly reads, as for the tx log and node caches
+			if rnd.Intn(10) == 0 {
+				c.Put(key, 0)
+			} else {
+				c.Get(key)
+			}
+		}
+	})
+}
+
+func BenchmarkLRUCacheParallel(b *testing.B) {
+	c, err := NewLRUCache(benchmarkCacheSize)
+	require.NoError(b, err)
+
+	benchmarkCacheParallel(b, c)
+}
+
+func BenchmarkShardedCacheParallel(b *testing.B) {
+	c, err := NewShardedCache(benchmarkCacheSize, 16, nil)
+	require.NoError(b, err)
+
+	benchmarkCacheParallel(b, c)
+}
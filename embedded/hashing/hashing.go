@@ -0,0 +1,94 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hashing
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+// Size is the length of the digests produced by every supported algorithm.
+// It matches sha256.Size so digests can be kept in the same fixed-size arrays
+const Size = sha256.Size
+
+var ErrUnsupportedAlgorithm = errors.New("hashing: unsupported algorithm")
+
+// Algorithm identifies the hash function used to build the cryptographic structures
+// (entry digests, hash trees, accumulative linear hashes and binary linking)
+type Algorithm byte
+
+const (
+	SHA256 Algorithm = iota
+	BLAKE2b256
+	SHA3_256
+)
+
+func (alg Algorithm) Validate() error {
+	switch alg {
+	case SHA256, BLAKE2b256, SHA3_256:
+		return nil
+	}
+
+	return fmt.Errorf("%w: %d", ErrUnsupportedAlgorithm, alg)
+}
+
+func (alg Algorithm) String() string {
+	switch alg {
+	case SHA256:
+		return "sha256"
+	case BLAKE2b256:
+		return "blake2b-256"
+	case SHA3_256:
+		return "sha3-256"
+	}
+
+	return fmt.Sprintf("unknown(%d)", byte(alg))
+}
+
+// ParseAlgorithm returns the algorithm with the given name as returned by String
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch strings.ToLower(name) {
+	case "sha256":
+		return SHA256, nil
+	case "blake2b-256":
+		return BLAKE2b256, nil
+	case "sha3-256":
+		return SHA3_256, nil
+	}
+
+	return 0, fmt.Errorf("%w: '%s'", ErrUnsupportedAlgorithm, name)
+}
+
+// Sum returns the digest of the data. It panics if the algorithm is not supported,
+// callers are expected to validate algorithms at configuration time
+func (alg Algorithm) Sum(data []byte) [Size]byte {
+	switch alg {
+	case SHA256:
+		return sha256.Sum256(data)
+	case BLAKE2b256:
+		return blake2b.Sum256(data)
+	case SHA3_256:
+		return sha3.Sum256(data)
+	}
+
+	panic(fmt.Errorf("%w: %d", ErrUnsupportedAlgorithm, alg))
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hashing
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+func TestAlgorithms(t *testing.T) {
+	data := []byte("immudb")
+
+	require.NoError(t, SHA256.Validate())
+	require.Equal(t, sha256.Sum256(data), SHA256.Sum(data))
+
+	require.NoError(t, BLAKE2b256.Validate())
+	require.Equal(t, [Size]byte(blake2b.Sum256(data)), BLAKE2b256.Sum(data))
+
+	require.NoError(t, SHA3_256.Validate())
+	require.Equal(t, [Size]byte(sha3.Sum256(data)), SHA3_256.Sum(data))
+
+	require.NotEqual(t, SHA256.Sum(data), BLAKE2b256.Sum(data))
+	require.NotEqual(t, SHA256.Sum(data), SHA3_256.Sum(data))
+
+	for _, alg := range []Algorithm{SHA256, BLAKE2b256, SHA3_256} {
+		parsed, err := ParseAlgorithm(alg.String())
+		require.NoError(t, err)
+		require.Equal(t, alg, parsed)
+	}
+}
+
+func TestUnsupportedAlgorithm(t *testing.T) {
+	alg := Algorithm(255)
+
+	require.ErrorIs(t, alg.Validate(), ErrUnsupportedAlgorithm)
+	require.Equal(t, "unknown(255)", alg.String())
+
+	require.Panics(t, func() { alg.Sum(nil) })
+
+	_, err := ParseAlgorithm("md5")
+	require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package htree
+
+import (
+	"crypto/sha256"
+
+	"github.com/codenotary/immudb/embedded/hashing"
+)
+
+// Builder calculates the root of a tree built from a sequence of digests without holding
+// the whole sequence in memory. Only the roots of the complete subtrees added so far are
+// kept, thus memory usage is logarithmic with the amount of digests added.
+// The calculated root is the same one HTree.BuildWith produces for the same digests.
+type Builder struct {
+	hashAlg hashing.Algorithm
+
+	// roots of complete subtrees, from the leftmost and highest to the rightmost one
+	nodes [][sha256.Size]byte
+	width int
+}
+
+func NewBuilder() (*Builder, error) {
+	return NewBuilderWith(hashing.SHA256)
+}
+
+// NewBuilderWith creates a builder whose nodes are calculated with the given hash algorithm
+func NewBuilderWith(hashAlg hashing.Algorithm) (*Builder, error) {
+	if hashAlg.Validate() != nil {
+		return nil, ErrIllegalArguments
+	}
+
+	return &Builder{hashAlg: hashAlg}, nil
+}
+
+// Add appends a digest as the next leaf of the tree
+func (b *Builder) Add(digest [sha256.Size]byte) {
+	leaf := [1 + sha256.Size]byte{LeafPrefix}
+	copy(leaf[1:], digest[:])
+
+	b.nodes = append(b.nodes, b.hashAlg.Sum(leaf[:]))
+
+	// each trailing bit set in the previous width is a complete subtree of the
+	// same height as the one being built, so they get merged
+	for w := b.width; w&1 == 1; w >>= 1 {
+		n := len(b.nodes)
+		b.nodes[n-2] = b.hashNode(b.nodes[n-2], b.nodes[n-1])
+		b.nodes = b.nodes[:n-1]
+	}
+
+	b.width++
+}
+
+// Width returns the amount of digests added so far
+func (b *Builder) Width() int {
+	return b.width
+}
+
+// Finalize returns the root of the tree built with the digests added so far.
+// The builder is not modified, so more digests may be added afterwards
+func (b *Builder) Finalize() (root [sha256.Size]byte, err error) {
+	if b.width == 0 {
+		err = ErrIllegalState
+		return
+	}
+
+	root = b.nodes[len(b.nodes)-1]
+
+	for i := len(b.nodes) - 2; i >= 0; i-- {
+		root = b.hashNode(b.nodes[i], root)
+	}
+
+	return root, nil
+}
+
+// Reset discards the digests added so far
+func (b *Builder) Reset() {
+	b.nodes = b.nodes[:0]
+	b.width = 0
+}
+
+func (b *Builder) hashNode(l, r [sha256.Size]byte) [sha256.Size]byte {
+	bs := [1 + 2*sha256.Size]byte{NodePrefix}
+	copy(bs[1:], l[:])
+	copy(bs[1+sha256.Size:], r[:])
+	return b.hashAlg.Sum(bs[:])
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package htree
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/hashing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	_, err := NewBuilderWith(hashing.Algorithm(255))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	for _, alg := range []hashing.Algorithm{hashing.SHA256, hashing.BLAKE2b256} {
+		t.Run(alg.String(), func(t *testing.T) {
+			b, err := NewBuilderWith(alg)
+			require.NoError(t, err)
+
+			_, err = b.Finalize()
+			require.ErrorIs(t, err, ErrIllegalState)
+
+			const maxWidth = 130
+
+			digests := make([][sha256.Size]byte, maxWidth)
+
+			for i := 0; i < maxWidth; i++ {
+				var bs [8]byte
+				binary.BigEndian.PutUint64(bs[:], uint64(i))
+				digests[i] = sha256.Sum256(bs[:])
+
+				b.Add(digests[i])
+				require.Equal(t, i+1, b.Width())
+
+				// the amount of nodes kept is the amount of complete subtrees
+				require.LessOrEqual(t, len(b.nodes), 8)
+
+				root, err := b.Finalize()
+				require.NoError(t, err)
+
+				tree, err := NewWith(i+1, alg)
+				require.NoError(t, err)
+
+				err = tree.BuildWith(digests[:i+1])
+				require.NoError(t, err)
+
+				expectedRoot, err := tree.Root()
+				require.NoError(t, err)
+				require.Equal(t, expectedRoot, root)
+			}
+
+			b.Reset()
+			require.Zero(t, b.Width())
+
+			_, err = b.Finalize()
+			require.ErrorIs(t, err, ErrIllegalState)
+		})
+	}
+}
+
+func BenchmarkBuilder(b *testing.B) {
+	builder, err := NewBuilder()
+	require.NoError(b, err)
+
+	var digest [sha256.Size]byte
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		builder.Reset()
+
+		for j := 0; j < 100_000; j++ {
+			binary.BigEndian.PutUint64(digest[:], uint64(j))
+			builder.Add(digest)
+		}
+
+		_, err = builder.Finalize()
+		require.NoError(b, err)
+	}
+}
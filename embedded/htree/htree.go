@@ -20,6 +20,8 @@ import (
 	"crypto/sha256"
 	"errors"
 	"math/bits"
+
+	"github.com/codenotary/immudb/embedded/hashing"
 )
 
 var ErrMaxWidthExceeded = errors.New("htree: max width exceeded")
@@ -34,6 +36,7 @@ type HTree struct {
 	maxWidth int
 	width    int
 	root     [sha256.Size]byte
+	hashAlg  hashing.Algorithm
 }
 
 type InclusionProof struct {
@@ -43,7 +46,12 @@ type InclusionProof struct {
 }
 
 func New(maxWidth int) (*HTree, error) {
-	if maxWidth < 1 {
+	return NewWith(maxWidth, hashing.SHA256)
+}
+
+// NewWith creates a tree whose nodes are calculated with the given hash algorithm
+func NewWith(maxWidth int, hashAlg hashing.Algorithm) (*HTree, error) {
+	if maxWidth < 1 || hashAlg.Validate() != nil {
 		return nil, ErrIllegalArguments
 	}
 
@@ -62,6 +70,7 @@ func New(maxWidth int) (*HTree, error) {
 	return &HTree{
 		levels:   levels,
 		maxWidth: maxWidth,
+		hashAlg:  hashAlg,
 	}, nil
 }
 
@@ -77,7 +86,7 @@ func (t *HTree) BuildWith(digests [][sha256.Size]byte) error {
 	for i, d := range digests {
 		leaf := [1 + sha256.Size]byte{LeafPrefix}
 		copy(leaf[1:], d[:])
-		t.levels[0][i] = sha256.Sum256(leaf[:])
+		t.levels[0][i] = t.hashAlg.Sum(leaf[:])
 	}
 
 	l := 0
@@ -91,7 +100,7 @@ func (t *HTree) BuildWith(digests [][sha256.Size]byte) error {
 		for i := 0; i+1 < w; i += 2 {
 			copy(b[1:], t.levels[l][i][:])
 			copy(b[1+sha256.Size:], t.levels[l][i+1][:])
-			t.levels[l+1][wn] = sha256.Sum256(b[:])
+			t.levels[l+1][wn] = t.hashAlg.Sum(b[:])
 			wn++
 		}
 
@@ -166,14 +175,19 @@ func (t *HTree) InclusionProof(i int) (proof *InclusionProof, err error) {
 }
 
 func VerifyInclusion(proof *InclusionProof, digest, root [sha256.Size]byte) bool {
-	if proof == nil {
+	return VerifyInclusionWith(hashing.SHA256, proof, digest, root)
+}
+
+// VerifyInclusionWith verifies an inclusion proof of a tree built with the given hash algorithm
+func VerifyInclusionWith(hashAlg hashing.Algorithm, proof *InclusionProof, digest, root [sha256.Size]byte) bool {
+	if proof == nil || hashAlg.Validate() != nil {
 		return false
 	}
 
 	leaf := [1 + sha256.Size]byte{LeafPrefix}
 	copy(leaf[1:], digest[:])
 
-	calcRoot := sha256.Sum256(leaf[:])
+	calcRoot := hashAlg.Sum(leaf[:])
 	i := proof.Leaf
 	r := proof.Width - 1
 
@@ -188,7 +202,7 @@ func VerifyInclusion(proof *InclusionProof, digest, root [sha256.Size]byte) bool
 			copy(b[1+sha256.Size:], calcRoot[:])
 		}
 
-		calcRoot = sha256.Sum256(b[:])
+		calcRoot = hashAlg.Sum(b[:])
 		i /= 2
 		r /= 2
 	}
@@ -21,6 +21,8 @@ import (
 	"encoding/binary"
 	"testing"
 
+	"github.com/codenotary/immudb/embedded/hashing"
+
 	"github.com/stretchr/testify/require"
 )
 
@@ -82,3 +84,45 @@ func TestHTree(t *testing.T) {
 	_, err = tree.InclusionProof(maxWidth)
 	require.ErrorIs(t, err, ErrIllegalArguments)
 }
+
+func TestHTreeWithHashAlgorithm(t *testing.T) {
+	const maxWidth = 17
+
+	_, err := NewWith(maxWidth, hashing.Algorithm(255))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	tree, err := NewWith(maxWidth, hashing.BLAKE2b256)
+	require.NoError(t, err)
+
+	sha256Tree, err := New(maxWidth)
+	require.NoError(t, err)
+
+	digests := make([][sha256.Size]byte, maxWidth)
+
+	for i := 0; i < len(digests); i++ {
+		digests[i] = sha256.Sum256([]byte{byte(i)})
+	}
+
+	err = tree.BuildWith(digests)
+	require.NoError(t, err)
+
+	err = sha256Tree.BuildWith(digests)
+	require.NoError(t, err)
+
+	root, err := tree.Root()
+	require.NoError(t, err)
+
+	sha256Root, err := sha256Tree.Root()
+	require.NoError(t, err)
+
+	require.NotEqual(t, sha256Root, root)
+
+	for i := 0; i < len(digests); i++ {
+		proof, err := tree.InclusionProof(i)
+		require.NoError(t, err)
+
+		require.True(t, VerifyInclusionWith(hashing.BLAKE2b256, proof, digests[i], root))
+		require.False(t, VerifyInclusion(proof, digests[i], root))
+		require.False(t, VerifyInclusionWith(hashing.Algorithm(255), proof, digests[i], root))
+	}
+}
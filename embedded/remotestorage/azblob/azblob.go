@@ -0,0 +1,541 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azblob
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/remotestorage"
+)
+
+// apiVersion is the blob service REST API version requests are made against,
+// it must be at least 2017-11-09 for bearer tokens to be accepted
+const apiVersion = "2020-10-02"
+
+// DefaultBlockSize is the size of the blocks large files are uploaded in
+const DefaultBlockSize = 8 << 20
+
+type Storage struct {
+	endpoint   string
+	account    string
+	container  string
+	prefix     string
+	credential Credential
+	blockSize  int64
+	httpClient *http.Client
+}
+
+var (
+	ErrInvalidArguments                = errors.New("invalid arguments")
+	ErrInvalidArgumentsOffsSize        = fmt.Errorf("%w: negative offset or zero size", ErrInvalidArguments)
+	ErrInvalidArgumentsNameStartSlash  = fmt.Errorf("%w: name can not start with /", ErrInvalidArguments)
+	ErrInvalidArgumentsNameEndSlash    = fmt.Errorf("%w: name can not end with /", ErrInvalidArguments)
+	ErrInvalidArgumentsInvalidName     = fmt.Errorf("%w: invalid name", ErrInvalidArguments)
+	ErrInvalidArgumentsPathNoEndSlash  = fmt.Errorf("%w: path must end with /", ErrInvalidArguments)
+	ErrInvalidArgumentsAccountEmpty    = fmt.Errorf("%w: account name can not be empty", ErrInvalidArguments)
+	ErrInvalidArgumentsContainerSlash  = fmt.Errorf("%w: container name can not contain / character", ErrInvalidArguments)
+	ErrInvalidArgumentsContainerEmpty  = fmt.Errorf("%w: container name can not be empty", ErrInvalidArguments)
+	ErrInvalidArgumentsNoCredential    = fmt.Errorf("%w: credential can not be nil", ErrInvalidArguments)
+	ErrInvalidArgumentsInvalidKey      = fmt.Errorf("%w: account key must be base64 encoded", ErrInvalidArguments)
+	ErrInvalidArgumentsInvalidSASToken = fmt.Errorf("%w: invalid SAS token", ErrInvalidArguments)
+
+	ErrInvalidResponse                     = errors.New("invalid response code")
+	ErrInvalidResponseXmlDecodeError       = fmt.Errorf("%w: xml decode error", ErrInvalidResponse)
+	ErrInvalidResponseEntriesNotSorted     = fmt.Errorf("%w: entries are not sorted", ErrInvalidResponse)
+	ErrInvalidResponseEntryNameWrongPrefix = fmt.Errorf("%w: entry do not have correct prefix", ErrInvalidResponse)
+	ErrInvalidResponseEntryNameMalicious   = fmt.Errorf("%w: entry name contains invalid characters", ErrInvalidResponse)
+	ErrInvalidResponseSubPathsNotSorted    = fmt.Errorf("%w: sub-paths are not sorted", ErrInvalidResponse)
+	ErrInvalidResponseSubPathsWrongPrefix  = fmt.Errorf("%w: sub-paths do not have correct prefix", ErrInvalidResponse)
+	ErrInvalidResponseSubPathsWrongSuffix  = fmt.Errorf("%w: sub-paths do end with '/' suffix", ErrInvalidResponse)
+	ErrInvalidResponseSubPathMalicious     = fmt.Errorf("%w: sub-paths contain invalid characters", ErrInvalidResponse)
+	ErrInvalidResponseTokenDecodeError     = fmt.Errorf("%w: access token decode error", ErrInvalidResponse)
+)
+
+// Open creates a remote storage backed by an Azure Blob Storage container.
+// When endpoint is empty, the public endpoint of the storage account is used,
+// otherwise the endpoint must include the account if the service is addressed
+// path-style, e.g. http://127.0.0.1:10000/devstoreaccount1 for the Azurite emulator
+func Open(
+	endpoint string,
+	account string,
+	container string,
+	prefix string,
+	credential Credential,
+) (remotestorage.Storage, error) {
+
+	if account == "" {
+		return nil, ErrInvalidArgumentsAccountEmpty
+	}
+
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", account)
+	}
+
+	// Endpoint must always end with '/'
+	endpoint = strings.TrimRight(endpoint, "/") + "/"
+
+	// Container must have no '/' at all
+	container = strings.Trim(container, "/")
+	if strings.Contains(container, "/") {
+		return nil, ErrInvalidArgumentsContainerSlash
+	}
+
+	// Container name must not be empty
+	if container == "" {
+		return nil, ErrInvalidArgumentsContainerEmpty
+	}
+
+	if credential == nil {
+		return nil, ErrInvalidArgumentsNoCredential
+	}
+
+	// if prefix is not empty, it must end with '/'
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix = prefix + "/"
+	}
+
+	return &Storage{
+		endpoint:   endpoint,
+		account:    account,
+		container:  container,
+		prefix:     prefix,
+		credential: credential,
+		blockSize:  DefaultBlockSize,
+		httpClient: &http.Client{},
+	}, nil
+}
+
+func (s *Storage) Kind() string {
+	return "azblob"
+}
+
+func (s *Storage) String() string {
+	url, err := s.blobURL("")
+	if err != nil {
+		return "azblob(misconfigured)"
+	}
+	return "azblob:" + url
+}
+
+func (s *Storage) containerURL() string {
+	return s.endpoint + s.container
+}
+
+func (s *Storage) blobURL(blobName string) (string, error) {
+	reqURL, err := url.Parse(s.containerURL() + "/")
+	if err != nil {
+		return "", err
+	}
+
+	// Blob names are escaped segment by segment so that
+	// the '/' separators remain part of the path
+	segments := strings.Split(s.prefix+blobName, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+
+	blobURL, err := url.Parse(strings.Join(segments, "/"))
+	if err != nil {
+		return "", err
+	}
+
+	return reqURL.ResolveReference(blobURL).String(), nil
+}
+
+func (s *Storage) validateName(name string, isFolder bool) error {
+	if strings.HasPrefix(name, "/") {
+		return ErrInvalidArgumentsNameStartSlash
+	}
+	if isFolder && name != "" && !strings.HasSuffix(name, "/") {
+		// The path must end with `/` so that we don't match entries in parent directory with same prefix name,
+		// blobs are listed by prefix without a clear notion of directories.
+		return ErrInvalidArgumentsPathNoEndSlash
+	}
+	if !isFolder && strings.HasSuffix(name, "/") {
+		return ErrInvalidArgumentsNameEndSlash
+	}
+	if strings.Contains(name, "//") {
+		return ErrInvalidArgumentsInvalidName
+	}
+	if strings.Contains("/"+name, "/./") || strings.Contains("/"+name, "/../") {
+		return ErrInvalidArgumentsInvalidName
+	}
+	return nil
+}
+
+func (s *Storage) request(
+	ctx context.Context,
+	method string,
+	reqURL string,
+	validStatusCodes []int,
+	body io.Reader,
+	setupRequest func(req *http.Request),
+) (*http.Response, error) {
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("x-ms-version", apiVersion)
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+
+	if setupRequest != nil {
+		setupRequest(req)
+	}
+
+	err = s.credential.authorize(ctx, s, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		log.Printf("AzBlob %s %s failed: %v", req.Method, req.URL.Path, err)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	for _, validStatus := range validStatusCodes {
+		if resp.StatusCode == validStatus {
+			return resp, nil
+		}
+	}
+	resp.Body.Close()
+
+	log.Printf(
+		"AzBlob %s %s failed with status code %d (%s)",
+		req.Method,
+		req.URL.Path,
+		resp.StatusCode,
+		resp.Status,
+	)
+	return nil, &remotestorage.ResponseError{
+		Err:        ErrInvalidResponse,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+	}
+}
+
+// Get opens a remote blob
+func (s *Storage) Get(ctx context.Context, name string, offs, size int64) (io.ReadCloser, error) {
+	if offs < 0 || size == 0 {
+		return nil, ErrInvalidArgumentsOffsSize
+	}
+	err := s.validateName(name, false)
+	if err != nil {
+		return nil, err
+	}
+
+	url, err := s.blobURL(name)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.request(
+		ctx,
+		"GET",
+		url,
+		[]int{200, 206},
+		nil,
+		func(req *http.Request) {
+			if size < 0 {
+				req.Header.Set("x-ms-range", fmt.Sprintf("bytes=%d-", offs))
+			} else {
+				req.Header.Set("x-ms-range", fmt.Sprintf("bytes=%d-%d", offs, offs+size-1))
+			}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &metricsCountingReadCloser{
+		r: resp.Body,
+		c: metricsDownloadBytes,
+	}, nil
+}
+
+// Put writes a remote blob, files larger than the block size are uploaded
+// in blocks which are committed together once all of them are uploaded
+func (s *Storage) Put(ctx context.Context, name string, fileName string) error {
+	err := s.validateName(name, false)
+	if err != nil {
+		return err
+	}
+
+	putURL, err := s.blobURL(name)
+	if err != nil {
+		return err
+	}
+
+	fl, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer fl.Close()
+
+	flStat, err := fl.Stat()
+	if err != nil {
+		return err
+	}
+
+	if flStat.Size() <= s.blockSize {
+		return s.putBlob(ctx, putURL, fl, flStat.Size())
+	}
+
+	var blockIDs []string
+
+	for offs := int64(0); offs < flStat.Size(); offs += s.blockSize {
+		blockSize := s.blockSize
+		if flStat.Size()-offs < blockSize {
+			blockSize = flStat.Size() - offs
+		}
+
+		// Block IDs must have the same length within a blob
+		blockID := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%016d", len(blockIDs))))
+
+		err = s.putBlock(ctx, putURL, blockID, io.NewSectionReader(fl, offs, blockSize), blockSize)
+		if err != nil {
+			return err
+		}
+
+		blockIDs = append(blockIDs, blockID)
+	}
+
+	return s.putBlockList(ctx, putURL, blockIDs)
+}
+
+func (s *Storage) putBlob(ctx context.Context, putURL string, r io.Reader, size int64) error {
+	resp, err := s.request(
+		ctx,
+		"PUT",
+		putURL,
+		[]int{201},
+		&metricsCountingReadCloser{
+			r: ioutil.NopCloser(r),
+			c: metricsUploadBytes,
+		},
+		func(req *http.Request) {
+			req.ContentLength = size
+			if size == 0 {
+				// Otherwise the body would be sent chunked, without the required content length
+				req.Body = http.NoBody
+			}
+			req.Header.Set("Content-Type", "application/octet-stream")
+			req.Header.Set("x-ms-blob-type", "BlockBlob")
+		},
+	)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *Storage) putBlock(ctx context.Context, putURL string, blockID string, r io.Reader, size int64) error {
+	query := url.Values{}
+	query.Set("comp", "block")
+	query.Set("blockid", blockID)
+
+	resp, err := s.request(
+		ctx,
+		"PUT",
+		putURL+"?"+query.Encode(),
+		[]int{201},
+		&metricsCountingReadCloser{
+			r: ioutil.NopCloser(r),
+			c: metricsUploadBytes,
+		},
+		func(req *http.Request) {
+			req.ContentLength = size
+		},
+	)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *Storage) putBlockList(ctx context.Context, putURL string, blockIDs []string) error {
+	blockList := struct {
+		XMLName xml.Name `xml:"BlockList"`
+		Latest  []string `xml:"Latest"`
+	}{
+		Latest: blockIDs,
+	}
+
+	data, err := xml.Marshal(&blockList)
+	if err != nil {
+		return err
+	}
+	data = append([]byte(xml.Header), data...)
+
+	resp, err := s.request(
+		ctx,
+		"PUT",
+		putURL+"?comp=blocklist",
+		[]int{201},
+		bytes.NewReader(data),
+		func(req *http.Request) {
+			req.Header.Set("Content-Type", "application/xml")
+			req.Header.Set("x-ms-blob-content-type", "application/octet-stream")
+		},
+	)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Exists checks if a remote blob exists and can be read
+func (s *Storage) Exists(ctx context.Context, name string) (bool, error) {
+	err := s.validateName(name, false)
+	if err != nil {
+		return false, err
+	}
+
+	url, err := s.blobURL(name)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := s.request(ctx, "HEAD", url, []int{200, 404}, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode == 200, nil
+}
+
+func (s *Storage) ListEntries(ctx context.Context, path string) ([]remotestorage.EntryInfo, []string, error) {
+	err := s.validateName(path, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prefix := s.prefix + path
+
+	query := url.Values{}
+	query.Set("restype", "container")
+	query.Set("comp", "list")
+	query.Set("delimiter", "/")
+	query.Set("prefix", prefix)
+
+	entries := []remotestorage.EntryInfo{}
+	subPaths := []string{}
+
+	for {
+		resp, err := s.request(ctx, "GET", s.containerURL()+"?"+query.Encode(), []int{200}, nil, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		respParsed := struct {
+			Blobs struct {
+				Blob []struct {
+					Name       string
+					Properties struct {
+						ContentLength int64 `xml:"Content-Length"`
+					}
+				}
+				BlobPrefix []struct {
+					Name string
+				}
+			}
+			NextMarker string
+		}{}
+
+		err = xml.NewDecoder(resp.Body).Decode(&respParsed)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidResponseXmlDecodeError, err)
+		}
+
+		for _, blob := range respParsed.Blobs.Blob {
+			if !strings.HasPrefix(blob.Name, prefix) {
+				return nil, nil, ErrInvalidResponseEntryNameWrongPrefix
+			}
+
+			err = s.validateName(blob.Name, false)
+			if err != nil {
+				return nil, nil, ErrInvalidResponseEntryNameMalicious
+			}
+
+			entryName := strings.TrimPrefix(blob.Name, prefix)
+			if strings.Contains(entryName, "/") {
+				return nil, nil, ErrInvalidResponseEntryNameMalicious
+			}
+
+			entries = append(entries, remotestorage.EntryInfo{
+				Name: entryName,
+				Size: blob.Properties.ContentLength,
+			})
+		}
+
+		for _, blobPrefix := range respParsed.Blobs.BlobPrefix {
+			if !strings.HasPrefix(blobPrefix.Name, prefix) {
+				return nil, nil, ErrInvalidResponseSubPathsWrongPrefix
+			}
+			if !strings.HasSuffix(blobPrefix.Name, "/") {
+				return nil, nil, ErrInvalidResponseSubPathsWrongSuffix
+			}
+
+			p := blobPrefix.Name[len(prefix) : len(blobPrefix.Name)-1]
+			if p == "" || p == "." || p == ".." || strings.ContainsAny(p, "\\/:") {
+				// Avoid exploitation by a malicious server
+				return nil, nil, ErrInvalidResponseSubPathMalicious
+			}
+
+			subPaths = append(subPaths, p)
+		}
+
+		if respParsed.NextMarker == "" {
+			break
+		}
+
+		query.Set("marker", respParsed.NextMarker)
+	}
+
+	if !sort.SliceIsSorted(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name }) {
+		return nil, nil, ErrInvalidResponseEntriesNotSorted
+	}
+	if !sort.StringsAreSorted(subPaths) {
+		return nil, nil, ErrInvalidResponseSubPathsNotSorted
+	}
+
+	return entries, subPaths, nil
+}
+
+var _ remotestorage.Storage = (*Storage)(nil)
@@ -0,0 +1,470 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azblob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBlobService implements the subset of the blob service REST API used by the storage
+type fakeBlobService struct {
+	t         *testing.T
+	container string
+	blobs     map[string][]byte
+	blocks    map[string][]byte
+	mutex     sync.Mutex
+}
+
+func newFakeBlobService(t *testing.T, container string) *httptest.Server {
+	f := &fakeBlobService{
+		t:         t,
+		container: container,
+		blobs:     map[string][]byte{},
+		blocks:    map[string][]byte{},
+	}
+	return httptest.NewServer(f)
+}
+
+func (f *fakeBlobService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	require.Equal(f.t, apiVersion, r.Header.Get("x-ms-version"))
+
+	if r.Header.Get("Authorization") == "" && r.URL.Query().Get("sig") == "" {
+		http.Error(w, "unauthorized", http.StatusForbidden)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if path == f.container && r.URL.Query().Get("comp") == "list" {
+		f.list(w, r)
+		return
+	}
+
+	if !strings.HasPrefix(path, f.container+"/") {
+		http.Error(w, "container not found", http.StatusNotFound)
+		return
+	}
+	name := strings.TrimPrefix(path, f.container+"/")
+
+	switch r.Method {
+	case "PUT":
+		data, err := ioutil.ReadAll(r.Body)
+		require.NoError(f.t, err)
+
+		switch r.URL.Query().Get("comp") {
+		case "":
+			require.Equal(f.t, "BlockBlob", r.Header.Get("x-ms-blob-type"))
+			require.EqualValues(f.t, len(data), r.ContentLength)
+			f.blobs[name] = data
+		case "block":
+			f.blocks[name+"#"+r.URL.Query().Get("blockid")] = data
+		case "blocklist":
+			blockList := struct {
+				Latest []string
+			}{}
+			require.NoError(f.t, xml.Unmarshal(data, &blockList))
+
+			var blob []byte
+			for _, id := range blockList.Latest {
+				block, ok := f.blocks[name+"#"+id]
+				require.True(f.t, ok)
+				blob = append(blob, block...)
+			}
+			f.blobs[name] = blob
+		}
+		w.WriteHeader(http.StatusCreated)
+
+	case "GET", "HEAD":
+		data, ok := f.blobs[name]
+		if !ok {
+			http.Error(w, "blob not found", http.StatusNotFound)
+			return
+		}
+		if r.Method == "HEAD" {
+			return
+		}
+
+		var start, end int
+		_, err := fmt.Sscanf(r.Header.Get("x-ms-range"), "bytes=%d-%d", &start, &end)
+		if err != nil {
+			end = len(data) - 1
+		}
+		if end >= len(data) {
+			end = len(data) - 1
+		}
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(data[start : end+1])
+	}
+}
+
+func (f *fakeBlobService) list(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	require.Equal(f.t, "/", r.URL.Query().Get("delimiter"))
+
+	// Blobs and blob prefixes are listed together, sorted by name
+	items := map[string]bool{}
+	for name := range f.blobs {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(name, prefix)
+		if i := strings.Index(rest, "/"); i >= 0 {
+			items[prefix+rest[:i+1]] = true
+		} else {
+			items[name] = false
+		}
+	}
+
+	var names []string
+	for name := range items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Results are returned one per page to exercise the continuation marker
+	marker := r.URL.Query().Get("marker")
+	for len(names) > 0 && names[0] <= marker {
+		names = names[1:]
+	}
+
+	xmlResp := "<?xml version=\"1.0\" encoding=\"utf-8\"?><EnumerationResults><Blobs>"
+	nextMarker := ""
+
+	if len(names) > 0 {
+		name := names[0]
+		if items[name] {
+			xmlResp += "<BlobPrefix><Name>" + name + "</Name></BlobPrefix>"
+		} else {
+			xmlResp += "<Blob><Name>" + name + "</Name><Properties><Content-Length>" +
+				strconv.Itoa(len(f.blobs[name])) + "</Content-Length></Properties></Blob>"
+		}
+		if len(names) > 1 {
+			nextMarker = name
+		}
+	}
+
+	xmlResp += "</Blobs><NextMarker>" + nextMarker + "</NextMarker></EnumerationResults>"
+	w.Write([]byte(xmlResp))
+}
+
+func tmpFile(t *testing.T, data []byte) string {
+	fileName := filepath.Join(t.TempDir(), "data")
+	require.NoError(t, ioutil.WriteFile(fileName, data, 0644))
+	return fileName
+}
+
+func testSASCredential(t *testing.T) Credential {
+	c, err := SASCredential("?sv=2020-10-02&sig=signature")
+	require.NoError(t, err)
+	return c
+}
+
+func TestOpen(t *testing.T) {
+	s, err := Open("", "account", "immudb", "prefix", testSASCredential(t))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	require.Equal(t, "azblob", s.Kind())
+	require.Equal(t, "azblob:https://account.blob.core.windows.net/immudb/prefix/", s.String())
+
+	s, err = Open("http://127.0.0.1:10000/devstoreaccount1/", "devstoreaccount1", "immudb", "", testSASCredential(t))
+	require.NoError(t, err)
+	require.Equal(t, "azblob:http://127.0.0.1:10000/devstoreaccount1/immudb/", s.String())
+}
+
+func TestValidateName(t *testing.T) {
+	for _, d := range []struct {
+		name     string
+		isFolder bool
+		err      error
+	}{
+		{"", false, nil},
+		{"", true, nil},
+		{"test", false, nil},
+		{"test/", true, nil},
+		{"test/name", false, nil},
+		{"test/name/", true, nil},
+		{"/test", false, ErrInvalidArgumentsNameStartSlash},
+		{"/test", true, ErrInvalidArgumentsNameStartSlash},
+		{"test/", false, ErrInvalidArgumentsNameEndSlash},
+		{"test", true, ErrInvalidArgumentsPathNoEndSlash},
+		{"test//name", false, ErrInvalidArgumentsInvalidName},
+		{"test/./name", false, ErrInvalidArgumentsInvalidName},
+		{"test/../test", false, ErrInvalidArgumentsInvalidName},
+		{"./test", false, ErrInvalidArgumentsInvalidName},
+		{"../test", false, ErrInvalidArgumentsInvalidName},
+	} {
+		t.Run(fmt.Sprintf("%+v", d), func(t *testing.T) {
+			s := Storage{}
+			err := s.validateName(d.name, d.isFolder)
+			require.ErrorIs(t, err, d.err)
+		})
+	}
+}
+
+func TestCornerCases(t *testing.T) {
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := Open("", "", "immudb", "", testSASCredential(t))
+		require.ErrorIs(t, err, ErrInvalidArgumentsAccountEmpty)
+
+		_, err = Open("", "account", "", "", testSASCredential(t))
+		require.ErrorIs(t, err, ErrInvalidArgumentsContainerEmpty)
+
+		_, err = Open("", "account", "immudb/test", "", testSASCredential(t))
+		require.ErrorIs(t, err, ErrInvalidArgumentsContainerSlash)
+
+		_, err = Open("", "account", "immudb", "", nil)
+		require.ErrorIs(t, err, ErrInvalidArgumentsNoCredential)
+
+		_, err = SharedKeyCredential("not*base64")
+		require.ErrorIs(t, err, ErrInvalidArgumentsInvalidKey)
+
+		_, err = SASCredential("sv=2020-10-02")
+		require.ErrorIs(t, err, ErrInvalidArgumentsInvalidSASToken)
+	})
+
+	t.Run("prefix must be correctly normalized", func(t *testing.T) {
+		for _, prefix := range []string{"/test/", "/test", "test"} {
+			s, err := Open("", "account", "immudb", prefix, testSASCredential(t))
+			require.NoError(t, err)
+			require.Equal(t, "test/", s.(*Storage).prefix)
+		}
+	})
+
+	t.Run("invalid names and arguments", func(t *testing.T) {
+		s, err := Open("", "account", "immudb", "", testSASCredential(t))
+		require.NoError(t, err)
+
+		ctx := context.Background()
+
+		_, err = s.Get(ctx, "/file", 0, -1)
+		require.ErrorIs(t, err, ErrInvalidArgumentsNameStartSlash)
+
+		_, err = s.Get(ctx, "file", 0, 0)
+		require.ErrorIs(t, err, ErrInvalidArgumentsOffsSize)
+
+		err = s.Put(ctx, "/file", "/tmp/test.txt")
+		require.ErrorIs(t, err, ErrInvalidArgumentsNameStartSlash)
+
+		_, err = s.Exists(ctx, "/file")
+		require.ErrorIs(t, err, ErrInvalidArgumentsNameStartSlash)
+
+		_, _, err = s.ListEntries(ctx, "prefix-no-slash")
+		require.ErrorIs(t, err, ErrInvalidArgumentsPathNoEndSlash)
+	})
+
+	t.Run("invalid http status code from the server", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "test error", http.StatusInternalServerError)
+		}))
+		defer ts.Close()
+
+		s, err := Open(ts.URL, "account", "immudb", "", testSASCredential(t))
+		require.NoError(t, err)
+
+		ctx := context.Background()
+
+		_, err = s.Get(ctx, "object1", 0, -1)
+		require.ErrorIs(t, err, ErrInvalidResponse)
+
+		_, err = s.Exists(ctx, "object1")
+		require.ErrorIs(t, err, ErrInvalidResponse)
+
+		_, _, err = s.ListEntries(ctx, "")
+		require.ErrorIs(t, err, ErrInvalidResponse)
+	})
+
+	t.Run("malicious list response", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("<EnumerationResults><Blobs><BlobPrefix><Name>../</Name></BlobPrefix></Blobs></EnumerationResults>"))
+		}))
+		defer ts.Close()
+
+		s, err := Open(ts.URL, "account", "immudb", "", testSASCredential(t))
+		require.NoError(t, err)
+
+		_, _, err = s.ListEntries(context.Background(), "")
+		require.ErrorIs(t, err, ErrInvalidResponseSubPathMalicious)
+	})
+
+	t.Run("invalid upload file path", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Fail(t, "Should not call the server")
+		}))
+		defer ts.Close()
+
+		s, err := Open(ts.URL, "account", "immudb", "", testSASCredential(t))
+		require.NoError(t, err)
+
+		err = s.Put(context.Background(), "object1", "/invalid/file/path/that/does/not/exist")
+		require.IsType(t, &fs.PathError{}, err)
+	})
+}
+
+func TestStorage(t *testing.T) {
+	ts := newFakeBlobService(t, "immudb")
+	defer ts.Close()
+
+	s, err := Open(ts.URL, "account", "immudb", "prefix", testSASCredential(t))
+	require.NoError(t, err)
+
+	// Force multi-block uploads for larger files
+	s.(*Storage).blockSize = 10
+
+	ctx := context.Background()
+
+	exists, err := s.Exists(ctx, "dir/small")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	large := []byte(strings.Repeat("0123456789abcdef", 5))
+
+	require.NoError(t, s.Put(ctx, "dir/small", tmpFile(t, []byte("small"))))
+	require.NoError(t, s.Put(ctx, "dir/large", tmpFile(t, large)))
+	require.NoError(t, s.Put(ctx, "dir/empty", tmpFile(t, nil)))
+	require.NoError(t, s.Put(ctx, "dir/sub1/file", tmpFile(t, []byte("file"))))
+	require.NoError(t, s.Put(ctx, "dir/sub2/file", tmpFile(t, []byte("file"))))
+
+	exists, err = s.Exists(ctx, "dir/small")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	r, err := s.Get(ctx, "dir/large", 0, -1)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, large, data)
+
+	r, err = s.Get(ctx, "dir/large", 16, 10)
+	require.NoError(t, err)
+	data, err = ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, large[16:26], data)
+
+	entries, subPaths, err := s.ListEntries(ctx, "dir/")
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, "empty", entries[0].Name)
+	require.EqualValues(t, 0, entries[0].Size)
+	require.Equal(t, "large", entries[1].Name)
+	require.EqualValues(t, len(large), entries[1].Size)
+	require.Equal(t, "small", entries[2].Name)
+	require.EqualValues(t, 5, entries[2].Size)
+	require.Equal(t, []string{"sub1", "sub2"}, subPaths)
+
+	entries, subPaths, err = s.ListEntries(ctx, "")
+	require.NoError(t, err)
+	require.Empty(t, entries)
+	require.Equal(t, []string{"dir"}, subPaths)
+}
+
+func TestSharedKeyCredential(t *testing.T) {
+	c, err := SharedKeyCredential("a2V5")
+	require.NoError(t, err)
+
+	s, err := Open("", "account", "immudb", "", c)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("GET", "https://account.blob.core.windows.net/immudb?restype=container&comp=list", nil)
+	require.NoError(t, err)
+	req.Header.Set("x-ms-date", "Fri, 26 Jun 2015 23:39:12 GMT")
+	req.Header.Set("x-ms-version", "2015-02-21")
+
+	err = c.authorize(context.Background(), s.(*Storage), req)
+	require.NoError(t, err)
+
+	require.Equal(t, "SharedKey account:"+expectedSignature(t, []byte("key"),
+		"GET\n\n\n\n\n\n\n\n\n\n\n\n"+
+			"x-ms-date:Fri, 26 Jun 2015 23:39:12 GMT\n"+
+			"x-ms-version:2015-02-21\n"+
+			"/account/immudb\ncomp:list\nrestype:container",
+	), req.Header.Get("Authorization"))
+}
+
+func TestManagedIdentityCredential(t *testing.T) {
+	tokenRequests := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "true", r.Header.Get("Metadata"))
+		require.Equal(t, "https://storage.azure.com/", r.URL.Query().Get("resource"))
+		require.Equal(t, "client-id", r.URL.Query().Get("client_id"))
+
+		tokenRequests++
+		fmt.Fprintf(w, `{"access_token":"token%d","expires_on":"%d"}`, tokenRequests, 4102444800)
+	}))
+	defer ts.Close()
+
+	c := ManagedIdentityCredential("client-id")
+	c.(*managedIdentityCredential).endpoint = ts.URL
+
+	s, err := Open("", "account", "immudb", "", c)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("GET", "https://account.blob.core.windows.net/immudb/blob", nil)
+		require.NoError(t, err)
+
+		err = c.authorize(context.Background(), s.(*Storage), req)
+		require.NoError(t, err)
+		require.Equal(t, "Bearer token1", req.Header.Get("Authorization"))
+	}
+
+	// The token is cached until it is about to expire
+	require.Equal(t, 1, tokenRequests)
+
+	t.Run("token request failure", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "no identity", http.StatusBadRequest)
+		}))
+		defer ts.Close()
+
+		c := ManagedIdentityCredential("")
+		c.(*managedIdentityCredential).endpoint = ts.URL
+
+		req, err := http.NewRequest("GET", "https://account.blob.core.windows.net/immudb/blob", nil)
+		require.NoError(t, err)
+
+		err = c.authorize(context.Background(), s.(*Storage), req)
+		require.ErrorIs(t, err, ErrInvalidResponse)
+	})
+}
+
+func expectedSignature(t *testing.T, key []byte, stringToSign string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azblob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultManagedIdentityEndpoint is the token endpoint of the Azure Instance Metadata Service
+const DefaultManagedIdentityEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+// tokenRefreshMargin is how long before its expiration an access token is renewed
+const tokenRefreshMargin = 5 * time.Minute
+
+// Credential authorizes the requests sent to the blob service
+type Credential interface {
+	authorize(ctx context.Context, s *Storage, req *http.Request) error
+}
+
+type sharedKeyCredential struct {
+	key []byte
+}
+
+// SharedKeyCredential signs requests with the access key of the storage account
+func SharedKeyCredential(accountKey string) (Credential, error) {
+	key, err := base64.StdEncoding.DecodeString(accountKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArgumentsInvalidKey, err)
+	}
+
+	return &sharedKeyCredential{key: key}, nil
+}
+
+func (c *sharedKeyCredential) authorize(ctx context.Context, s *Storage, req *http.Request) error {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	var msHeaders []string
+	for h := range req.Header {
+		h = strings.ToLower(h)
+		if strings.HasPrefix(h, "x-ms-") {
+			msHeaders = append(msHeaders, h)
+		}
+	}
+	sort.Strings(msHeaders)
+
+	canonicalizedHeaders := ""
+	for _, h := range msHeaders {
+		canonicalizedHeaders += h + ":" + strings.TrimSpace(req.Header.Get(h)) + "\n"
+	}
+
+	canonicalizedResource := "/" + s.account + req.URL.EscapedPath()
+
+	query := req.URL.Query()
+	params := make([]string, 0, len(query))
+	for p := range query {
+		params = append(params, p)
+	}
+	sort.Strings(params)
+
+	for _, p := range params {
+		values := query[p]
+		sort.Strings(values)
+		canonicalizedResource += "\n" + strings.ToLower(p) + ":" + strings.Join(values, ",")
+	}
+
+	stringToSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, x-ms-date is used instead
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		canonicalizedHeaders + canonicalizedResource,
+	}, "\n")
+
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(stringToSign))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", s.account, signature))
+
+	return nil
+}
+
+type sasCredential struct {
+	query url.Values
+}
+
+// SASCredential authorizes requests with a shared access signature token,
+// the token must grant access to the container
+func SASCredential(sasToken string) (Credential, error) {
+	query, err := url.ParseQuery(strings.TrimPrefix(sasToken, "?"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArgumentsInvalidSASToken, err)
+	}
+
+	if query.Get("sig") == "" {
+		return nil, ErrInvalidArgumentsInvalidSASToken
+	}
+
+	return &sasCredential{query: query}, nil
+}
+
+func (c *sasCredential) authorize(ctx context.Context, s *Storage, req *http.Request) error {
+	query := req.URL.Query()
+	for p, values := range c.query {
+		query[p] = values
+	}
+	req.URL.RawQuery = query.Encode()
+
+	return nil
+}
+
+type managedIdentityCredential struct {
+	endpoint   string
+	clientID   string
+	httpClient *http.Client
+
+	token     string
+	expiresOn time.Time
+
+	mutex sync.Mutex
+}
+
+// ManagedIdentityCredential authorizes requests with access tokens issued to the managed identity
+// of the Azure resource immudb runs on. clientID selects a user-assigned identity, the
+// system-assigned identity is used when it is empty
+func ManagedIdentityCredential(clientID string) Credential {
+	return &managedIdentityCredential{
+		endpoint:   DefaultManagedIdentityEndpoint,
+		clientID:   clientID,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *managedIdentityCredential) authorize(ctx context.Context, s *Storage, req *http.Request) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return nil
+}
+
+func (c *managedIdentityCredential) accessToken(ctx context.Context) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.token != "" && time.Now().Add(tokenRefreshMargin).Before(c.expiresOn) {
+		return c.token, nil
+	}
+
+	query := url.Values{}
+	query.Set("api-version", "2018-02-01")
+	query.Set("resource", "https://storage.azure.com/")
+	if c.clientID != "" {
+		query.Set("client_id", c.clientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata", "true")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf(
+			"%w: access token request failed with status code %d (%s)",
+			ErrInvalidResponse, resp.StatusCode, resp.Status,
+		)
+	}
+
+	respParsed := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresOn   string `json:"expires_on"`
+	}{}
+
+	err = json.NewDecoder(resp.Body).Decode(&respParsed)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidResponseTokenDecodeError, err)
+	}
+
+	expiresOn, err := strconv.ParseInt(respParsed.ExpiresOn, 10, 64)
+	if err != nil || respParsed.AccessToken == "" {
+		return "", ErrInvalidResponseTokenDecodeError
+	}
+
+	c.token = respParsed.AccessToken
+	c.expiresOn = time.Unix(expiresOn, 0)
+
+	return c.token, nil
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcs
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMetadataTokenEndpoint is the token endpoint of the metadata server
+// available to workloads running on Google Cloud, e.g. through GKE workload identity
+const DefaultMetadataTokenEndpoint = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// storageScope grants read and write access to objects
+const storageScope = "https://www.googleapis.com/auth/devstorage.read_write"
+
+// tokenRefreshMargin is how long before its expiration an access token is renewed
+const tokenRefreshMargin = 5 * time.Minute
+
+// Credential provides the OAuth2 access tokens requests are authorized with
+type Credential interface {
+	accessToken(ctx context.Context) (string, error)
+}
+
+// tokenCache keeps an access token until it is about to expire
+type tokenCache struct {
+	httpClient *http.Client
+	fetch      func(ctx context.Context, httpClient *http.Client) (token string, expiresIn time.Duration, err error)
+
+	token     string
+	expiresAt time.Time
+
+	mutex sync.Mutex
+}
+
+func (c *tokenCache) accessToken(ctx context.Context) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.token != "" && time.Now().Add(tokenRefreshMargin).Before(c.expiresAt) {
+		return c.token, nil
+	}
+
+	token, expiresIn, err := c.fetch(ctx, c.httpClient)
+	if err != nil {
+		return "", err
+	}
+
+	c.token = token
+	c.expiresAt = time.Now().Add(expiresIn)
+
+	return c.token, nil
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func decodeTokenResponse(resp *http.Response) (string, time.Duration, error) {
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", 0, fmt.Errorf(
+			"%w: access token request failed with status code %d (%s)",
+			ErrInvalidResponse, resp.StatusCode, resp.Status,
+		)
+	}
+
+	var respParsed tokenResponse
+
+	err := json.NewDecoder(resp.Body).Decode(&respParsed)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %v", ErrInvalidResponseTokenDecodeError, err)
+	}
+
+	if respParsed.AccessToken == "" || respParsed.ExpiresIn <= 0 {
+		return "", 0, ErrInvalidResponseTokenDecodeError
+	}
+
+	return respParsed.AccessToken, time.Duration(respParsed.ExpiresIn) * time.Second, nil
+}
+
+type serviceAccountKey struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// ServiceAccountCredential obtains access tokens for the service account
+// whose JSON key file content is provided
+func ServiceAccountCredential(jsonKey []byte) (Credential, error) {
+	var key serviceAccountKey
+
+	err := json.Unmarshal(jsonKey, &key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArgumentsInvalidKeyFile, err)
+	}
+
+	if key.ClientEmail == "" || key.TokenURI == "" {
+		return nil, ErrInvalidArgumentsInvalidKeyFile
+	}
+
+	block, _ := pem.Decode([]byte(key.PrivateKey))
+	if block == nil {
+		return nil, ErrInvalidArgumentsInvalidKeyEntry
+	}
+
+	var privateKey *rsa.PrivateKey
+
+	parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err == nil {
+		rsaKey, ok := parsedKey.(*rsa.PrivateKey)
+		if !ok {
+			return nil, ErrInvalidArgumentsInvalidKeyEntry
+		}
+		privateKey = rsaKey
+	} else {
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArgumentsInvalidKeyEntry, err)
+		}
+	}
+
+	return &tokenCache{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		fetch: func(ctx context.Context, httpClient *http.Client) (string, time.Duration, error) {
+			assertion, err := signedJWT(key.ClientEmail, key.TokenURI, privateKey, time.Now())
+			if err != nil {
+				return "", 0, err
+			}
+
+			form := url.Values{}
+			form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+			form.Set("assertion", assertion)
+
+			req, err := http.NewRequestWithContext(ctx, "POST", key.TokenURI, strings.NewReader(form.Encode()))
+			if err != nil {
+				return "", 0, err
+			}
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				return "", 0, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+			}
+
+			return decodeTokenResponse(resp)
+		},
+	}, nil
+}
+
+// signedJWT builds the assertion exchanged for an access token of the service account
+func signedJWT(clientEmail, audience string, key *rsa.PrivateKey, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   clientEmail,
+		"scope": storageScope,
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// MetadataCredential obtains access tokens of the service account attached
+// to the workload from the metadata server, as done with GKE workload identity
+func MetadataCredential() Credential {
+	return metadataCredential(DefaultMetadataTokenEndpoint)
+}
+
+func metadataCredential(endpoint string) *tokenCache {
+	return &tokenCache{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		fetch: func(ctx context.Context, httpClient *http.Client) (string, time.Duration, error) {
+			req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+			if err != nil {
+				return "", 0, err
+			}
+			req.Header.Set("Metadata-Flavor", "Google")
+
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				return "", 0, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+			}
+
+			return decodeTokenResponse(resp)
+		},
+	}
+}
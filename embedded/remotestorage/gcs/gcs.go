@@ -0,0 +1,513 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/remotestorage"
+)
+
+// DefaultEndpoint is the endpoint of the Cloud Storage JSON API
+const DefaultEndpoint = "https://storage.googleapis.com"
+
+// DefaultChunkSize is the size of the chunks files are uploaded in,
+// it must be a multiple of 256KiB
+const DefaultChunkSize = 8 << 20
+
+// maxChunkRetries is how many times the upload of a chunk is resumed after a failure
+const maxChunkRetries = 3
+
+type Storage struct {
+	endpoint   string
+	bucket     string
+	prefix     string
+	credential Credential
+	chunkSize  int64
+	httpClient *http.Client
+}
+
+var (
+	ErrInvalidArguments                = errors.New("invalid arguments")
+	ErrInvalidArgumentsOffsSize        = fmt.Errorf("%w: negative offset or zero size", ErrInvalidArguments)
+	ErrInvalidArgumentsNameStartSlash  = fmt.Errorf("%w: name can not start with /", ErrInvalidArguments)
+	ErrInvalidArgumentsNameEndSlash    = fmt.Errorf("%w: name can not end with /", ErrInvalidArguments)
+	ErrInvalidArgumentsInvalidName     = fmt.Errorf("%w: invalid name", ErrInvalidArguments)
+	ErrInvalidArgumentsPathNoEndSlash  = fmt.Errorf("%w: path must end with /", ErrInvalidArguments)
+	ErrInvalidArgumentsBucketSlash     = fmt.Errorf("%w: bucket name can not contain / character", ErrInvalidArguments)
+	ErrInvalidArgumentsBucketEmpty     = fmt.Errorf("%w: bucket name can not be empty", ErrInvalidArguments)
+	ErrInvalidArgumentsNoCredential    = fmt.Errorf("%w: credential can not be nil", ErrInvalidArguments)
+	ErrInvalidArgumentsInvalidKeyFile  = fmt.Errorf("%w: invalid service account key file", ErrInvalidArguments)
+	ErrInvalidArgumentsInvalidKeyEntry = fmt.Errorf("%w: invalid service account private key", ErrInvalidArguments)
+
+	ErrInvalidResponse                     = errors.New("invalid response code")
+	ErrInvalidResponseJsonDecodeError      = fmt.Errorf("%w: json decode error", ErrInvalidResponse)
+	ErrInvalidResponseEntriesNotSorted     = fmt.Errorf("%w: entries are not sorted", ErrInvalidResponse)
+	ErrInvalidResponseEntryNameWrongPrefix = fmt.Errorf("%w: entry do not have correct prefix", ErrInvalidResponse)
+	ErrInvalidResponseEntryNameMalicious   = fmt.Errorf("%w: entry name contains invalid characters", ErrInvalidResponse)
+	ErrInvalidResponseSubPathsNotSorted    = fmt.Errorf("%w: sub-paths are not sorted", ErrInvalidResponse)
+	ErrInvalidResponseSubPathsWrongPrefix  = fmt.Errorf("%w: sub-paths do not have correct prefix", ErrInvalidResponse)
+	ErrInvalidResponseSubPathsWrongSuffix  = fmt.Errorf("%w: sub-paths do end with '/' suffix", ErrInvalidResponse)
+	ErrInvalidResponseSubPathMalicious     = fmt.Errorf("%w: sub-paths contain invalid characters", ErrInvalidResponse)
+	ErrInvalidResponseNoUploadSession      = fmt.Errorf("%w: missing upload session location", ErrInvalidResponse)
+	ErrInvalidResponseUploadRange          = fmt.Errorf("%w: invalid range of uploaded data", ErrInvalidResponse)
+	ErrInvalidResponseTokenDecodeError     = fmt.Errorf("%w: access token decode error", ErrInvalidResponse)
+)
+
+// Open creates a remote storage backed by a Google Cloud Storage bucket,
+// the default endpoint is used when endpoint is empty
+func Open(
+	endpoint string,
+	bucket string,
+	prefix string,
+	credential Credential,
+) (remotestorage.Storage, error) {
+
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	endpoint = strings.TrimRight(endpoint, "/")
+
+	// Bucket must have no '/' at all
+	bucket = strings.Trim(bucket, "/")
+	if strings.Contains(bucket, "/") {
+		return nil, ErrInvalidArgumentsBucketSlash
+	}
+
+	// Bucket name must not be empty
+	if bucket == "" {
+		return nil, ErrInvalidArgumentsBucketEmpty
+	}
+
+	if credential == nil {
+		return nil, ErrInvalidArgumentsNoCredential
+	}
+
+	// if prefix is not empty, it must end with '/'
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix = prefix + "/"
+	}
+
+	return &Storage{
+		endpoint:   endpoint,
+		bucket:     bucket,
+		prefix:     prefix,
+		credential: credential,
+		chunkSize:  DefaultChunkSize,
+		httpClient: &http.Client{},
+	}, nil
+}
+
+func (s *Storage) Kind() string {
+	return "gcs"
+}
+
+func (s *Storage) String() string {
+	return "gcs:gs://" + s.bucket + "/" + s.prefix
+}
+
+func (s *Storage) objectURL(objectName string) string {
+	// The object name is a single path segment, '/' characters included
+	return s.endpoint + "/storage/v1/b/" + url.PathEscape(s.bucket) + "/o/" + url.PathEscape(s.prefix+objectName)
+}
+
+func (s *Storage) validateName(name string, isFolder bool) error {
+	if strings.HasPrefix(name, "/") {
+		return ErrInvalidArgumentsNameStartSlash
+	}
+	if isFolder && name != "" && !strings.HasSuffix(name, "/") {
+		// The path must end with `/` so that we don't match entries in parent directory with same prefix name,
+		// objects are listed by prefix without a clear notion of directories.
+		return ErrInvalidArgumentsPathNoEndSlash
+	}
+	if !isFolder && strings.HasSuffix(name, "/") {
+		return ErrInvalidArgumentsNameEndSlash
+	}
+	if strings.Contains(name, "//") {
+		return ErrInvalidArgumentsInvalidName
+	}
+	if strings.Contains("/"+name, "/./") || strings.Contains("/"+name, "/../") {
+		return ErrInvalidArgumentsInvalidName
+	}
+	return nil
+}
+
+func (s *Storage) request(
+	ctx context.Context,
+	method string,
+	reqURL string,
+	validStatusCodes []int,
+	body io.Reader,
+	setupRequest func(req *http.Request),
+) (*http.Response, error) {
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if setupRequest != nil {
+		setupRequest(req)
+	}
+
+	token, err := s.credential.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		log.Printf("GCS %s %s failed: %v", req.Method, req.URL.Path, err)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	for _, validStatus := range validStatusCodes {
+		if resp.StatusCode == validStatus {
+			return resp, nil
+		}
+	}
+	resp.Body.Close()
+
+	log.Printf(
+		"GCS %s %s failed with status code %d (%s)",
+		req.Method,
+		req.URL.Path,
+		resp.StatusCode,
+		resp.Status,
+	)
+	return nil, &remotestorage.ResponseError{
+		Err:        ErrInvalidResponse,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+	}
+}
+
+// Get opens a remote object
+func (s *Storage) Get(ctx context.Context, name string, offs, size int64) (io.ReadCloser, error) {
+	if offs < 0 || size == 0 {
+		return nil, ErrInvalidArgumentsOffsSize
+	}
+	err := s.validateName(name, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.request(
+		ctx,
+		"GET",
+		s.objectURL(name)+"?alt=media",
+		[]int{200, 206},
+		nil,
+		func(req *http.Request) {
+			if size < 0 {
+				req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offs))
+			} else {
+				req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offs, offs+size-1))
+			}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &metricsCountingReadCloser{
+		r: resp.Body,
+		c: metricsDownloadBytes,
+	}, nil
+}
+
+// Put writes a remote object through a resumable upload session,
+// the upload of a chunk is resumed from the data persisted so far when it fails
+func (s *Storage) Put(ctx context.Context, name string, fileName string) error {
+	err := s.validateName(name, false)
+	if err != nil {
+		return err
+	}
+
+	fl, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer fl.Close()
+
+	flStat, err := fl.Stat()
+	if err != nil {
+		return err
+	}
+	size := flStat.Size()
+
+	sessionURL, err := s.startUpload(ctx, name, size)
+	if err != nil {
+		return err
+	}
+
+	if size == 0 {
+		_, err = s.uploadChunk(ctx, sessionURL, nil, 0, 0, 0)
+		return err
+	}
+
+	for offs, retries := int64(0), 0; offs < size; {
+		chunkSize := s.chunkSize
+		if size-offs < chunkSize {
+			chunkSize = size - offs
+		}
+
+		persisted, err := s.uploadChunk(ctx, sessionURL, io.NewSectionReader(fl, offs, chunkSize), offs, chunkSize, size)
+		if err != nil {
+			if ctx.Err() != nil || retries == maxChunkRetries {
+				return err
+			}
+			retries++
+
+			log.Printf("GCS upload of %s interrupted, resuming: %v", name, err)
+
+			persisted, err = s.uploadStatus(ctx, sessionURL, size)
+			if err != nil {
+				return err
+			}
+		} else {
+			retries = 0
+		}
+
+		if persisted < offs || persisted > size {
+			return ErrInvalidResponseUploadRange
+		}
+		offs = persisted
+	}
+
+	return nil
+}
+
+func (s *Storage) startUpload(ctx context.Context, name string, size int64) (string, error) {
+	query := url.Values{}
+	query.Set("uploadType", "resumable")
+	query.Set("name", s.prefix+name)
+
+	resp, err := s.request(
+		ctx,
+		"POST",
+		s.endpoint+"/upload/storage/v1/b/"+url.PathEscape(s.bucket)+"/o?"+query.Encode(),
+		[]int{200},
+		http.NoBody,
+		func(req *http.Request) {
+			req.Header.Set("X-Upload-Content-Type", "application/octet-stream")
+			req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(size, 10))
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	sessionURL := resp.Header.Get("Location")
+	if sessionURL == "" {
+		return "", ErrInvalidResponseNoUploadSession
+	}
+
+	return sessionURL, nil
+}
+
+// uploadChunk sends size bytes starting at offs and returns
+// the amount of data persisted by the upload session
+func (s *Storage) uploadChunk(ctx context.Context, sessionURL string, r io.Reader, offs, size, totalSize int64) (int64, error) {
+	var body io.Reader = http.NoBody
+	if size > 0 {
+		body = &metricsCountingReadCloser{
+			r: ioutil.NopCloser(r),
+			c: metricsUploadBytes,
+		}
+	}
+
+	resp, err := s.request(
+		ctx,
+		"PUT",
+		sessionURL,
+		[]int{200, 201, 308},
+		body,
+		func(req *http.Request) {
+			req.ContentLength = size
+			if size == 0 {
+				req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", totalSize))
+			} else {
+				req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offs, offs+size-1, totalSize))
+			}
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	return persistedSize(resp, totalSize)
+}
+
+// uploadStatus returns the amount of data persisted by the upload session
+func (s *Storage) uploadStatus(ctx context.Context, sessionURL string, totalSize int64) (int64, error) {
+	return s.uploadChunk(ctx, sessionURL, nil, 0, 0, totalSize)
+}
+
+func persistedSize(resp *http.Response, totalSize int64) (int64, error) {
+	if resp.StatusCode != 308 {
+		// The upload is complete
+		return totalSize, nil
+	}
+
+	rng := resp.Header.Get("Range")
+	if rng == "" {
+		// Nothing persisted yet
+		return 0, nil
+	}
+
+	var start, end int64
+	_, err := fmt.Sscanf(rng, "bytes=%d-%d", &start, &end)
+	if err != nil || start != 0 {
+		return 0, ErrInvalidResponseUploadRange
+	}
+
+	return end + 1, nil
+}
+
+// Exists checks if a remote object exists and can be read
+func (s *Storage) Exists(ctx context.Context, name string) (bool, error) {
+	err := s.validateName(name, false)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := s.request(ctx, "GET", s.objectURL(name)+"?fields=name", []int{200, 404}, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode == 200, nil
+}
+
+func (s *Storage) ListEntries(ctx context.Context, path string) ([]remotestorage.EntryInfo, []string, error) {
+	err := s.validateName(path, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prefix := s.prefix + path
+
+	query := url.Values{}
+	query.Set("delimiter", "/")
+	query.Set("prefix", prefix)
+	query.Set("fields", "items(name,size),prefixes,nextPageToken")
+
+	entries := []remotestorage.EntryInfo{}
+	subPaths := []string{}
+
+	for {
+		resp, err := s.request(
+			ctx,
+			"GET",
+			s.endpoint+"/storage/v1/b/"+url.PathEscape(s.bucket)+"/o?"+query.Encode(),
+			[]int{200},
+			nil,
+			nil,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		respParsed := struct {
+			Items []struct {
+				Name string `json:"name"`
+				Size int64  `json:"size,string"`
+			} `json:"items"`
+			Prefixes      []string `json:"prefixes"`
+			NextPageToken string   `json:"nextPageToken"`
+		}{}
+
+		err = json.NewDecoder(resp.Body).Decode(&respParsed)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidResponseJsonDecodeError, err)
+		}
+
+		for _, object := range respParsed.Items {
+			if !strings.HasPrefix(object.Name, prefix) {
+				return nil, nil, ErrInvalidResponseEntryNameWrongPrefix
+			}
+
+			err = s.validateName(object.Name, false)
+			if err != nil {
+				return nil, nil, ErrInvalidResponseEntryNameMalicious
+			}
+
+			entryName := strings.TrimPrefix(object.Name, prefix)
+			if strings.Contains(entryName, "/") {
+				return nil, nil, ErrInvalidResponseEntryNameMalicious
+			}
+
+			entries = append(entries, remotestorage.EntryInfo{
+				Name: entryName,
+				Size: object.Size,
+			})
+		}
+
+		for _, subPathPrefix := range respParsed.Prefixes {
+			if !strings.HasPrefix(subPathPrefix, prefix) {
+				return nil, nil, ErrInvalidResponseSubPathsWrongPrefix
+			}
+			if !strings.HasSuffix(subPathPrefix, "/") {
+				return nil, nil, ErrInvalidResponseSubPathsWrongSuffix
+			}
+
+			p := subPathPrefix[len(prefix) : len(subPathPrefix)-1]
+			if p == "" || p == "." || p == ".." || strings.ContainsAny(p, "\\/:") {
+				// Avoid exploitation by a malicious server
+				return nil, nil, ErrInvalidResponseSubPathMalicious
+			}
+
+			subPaths = append(subPaths, p)
+		}
+
+		if respParsed.NextPageToken == "" {
+			break
+		}
+
+		query.Set("pageToken", respParsed.NextPageToken)
+	}
+
+	if !sort.SliceIsSorted(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name }) {
+		return nil, nil, ErrInvalidResponseEntriesNotSorted
+	}
+	if !sort.StringsAreSorted(subPaths) {
+		return nil, nil, ErrInvalidResponseSubPathsNotSorted
+	}
+
+	return entries, subPaths, nil
+}
+
+var _ remotestorage.Storage = (*Storage)(nil)
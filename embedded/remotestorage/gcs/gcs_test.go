@@ -0,0 +1,531 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gcs
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type staticCredential string
+
+func (c staticCredential) accessToken(ctx context.Context) (string, error) {
+	return string(c), nil
+}
+
+type fakeUpload struct {
+	name string
+	size int64
+	data []byte
+}
+
+// fakeGCS implements the subset of the JSON API used by the storage,
+// every upload session persists only part of the first chunk it receives
+// and fails the second one to exercise resumption
+type fakeGCS struct {
+	t       *testing.T
+	url     string
+	bucket  string
+	objects map[string][]byte
+	uploads map[string]*fakeUpload
+	chunks  int
+	mutex   sync.Mutex
+}
+
+func newFakeGCS(t *testing.T, bucket string) (*fakeGCS, *httptest.Server) {
+	f := &fakeGCS{
+		t:       t,
+		bucket:  bucket,
+		objects: map[string][]byte{},
+		uploads: map[string]*fakeUpload{},
+	}
+	ts := httptest.NewServer(f)
+	f.url = ts.URL
+	return f, ts
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	require.Equal(f.t, "Bearer token", r.Header.Get("Authorization"))
+
+	objectsPath := "/storage/v1/b/" + f.bucket + "/o"
+
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/upload"+objectsPath:
+		require.Equal(f.t, "resumable", r.URL.Query().Get("uploadType"))
+
+		var size int64
+		fmt.Sscanf(r.Header.Get("X-Upload-Content-Length"), "%d", &size)
+
+		id := fmt.Sprintf("session%d", len(f.uploads))
+		f.uploads[id] = &fakeUpload{name: r.URL.Query().Get("name"), size: size}
+
+		w.Header().Set("Location", f.url+"/upload/session/"+id)
+		w.WriteHeader(http.StatusOK)
+
+	case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/upload/session/"):
+		f.uploadChunk(w, r, f.uploads[strings.TrimPrefix(r.URL.Path, "/upload/session/")])
+
+	case r.Method == "GET" && r.URL.Path == objectsPath:
+		f.list(w, r)
+
+	case r.Method == "GET" && strings.HasPrefix(r.URL.RawPath, objectsPath+"/"):
+		name, err := url.PathUnescape(strings.TrimPrefix(r.URL.RawPath, objectsPath+"/"))
+		require.NoError(f.t, err)
+
+		data, ok := f.objects[name]
+		if !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		if r.URL.Query().Get("alt") != "media" {
+			json.NewEncoder(w).Encode(map[string]string{"name": name})
+			return
+		}
+
+		var start, end int
+		_, err = fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end)
+		if err != nil || end >= len(data) {
+			end = len(data) - 1
+		}
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(data[start : end+1])
+
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+func (f *fakeGCS) uploadChunk(w http.ResponseWriter, r *http.Request, upload *fakeUpload) {
+	require.NotNil(f.t, upload)
+
+	data, err := ioutil.ReadAll(r.Body)
+	require.NoError(f.t, err)
+
+	contentRange := r.Header.Get("Content-Range")
+
+	var start, end, total int64
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%d", &start, &end, &total); err == nil {
+		require.EqualValues(f.t, end-start+1, len(data))
+		require.Equal(f.t, upload.size, total)
+
+		f.chunks++
+
+		switch f.chunks {
+		case 1:
+			// Persist only half of the chunk
+			data = data[:len(data)/2]
+		case 2:
+			http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		require.EqualValues(f.t, len(upload.data), start)
+		upload.data = append(upload.data, data...)
+	} else {
+		// Status query
+		require.Equal(f.t, fmt.Sprintf("bytes */%d", upload.size), contentRange)
+		require.Empty(f.t, data)
+	}
+
+	if int64(len(upload.data)) == upload.size {
+		f.objects[upload.name] = upload.data
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if len(upload.data) > 0 {
+		w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(upload.data)-1))
+	}
+	w.WriteHeader(http.StatusPermanentRedirect)
+}
+
+func (f *fakeGCS) list(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	require.Equal(f.t, "/", r.URL.Query().Get("delimiter"))
+
+	items := map[string]bool{}
+	for name := range f.objects {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(name, prefix)
+		if i := strings.Index(rest, "/"); i >= 0 {
+			items[prefix+rest[:i+1]] = true
+		} else {
+			items[name] = false
+		}
+	}
+
+	var names []string
+	for name := range items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Results are returned one per page to exercise the page token
+	pageToken := r.URL.Query().Get("pageToken")
+	for len(names) > 0 && names[0] <= pageToken {
+		names = names[1:]
+	}
+
+	resp := struct {
+		Items         []map[string]string `json:"items,omitempty"`
+		Prefixes      []string            `json:"prefixes,omitempty"`
+		NextPageToken string              `json:"nextPageToken,omitempty"`
+	}{}
+
+	if len(names) > 0 {
+		name := names[0]
+		if items[name] {
+			resp.Prefixes = append(resp.Prefixes, name)
+		} else {
+			resp.Items = append(resp.Items, map[string]string{
+				"name": name,
+				"size": fmt.Sprintf("%d", len(f.objects[name])),
+			})
+		}
+		if len(names) > 1 {
+			resp.NextPageToken = name
+		}
+	}
+
+	json.NewEncoder(w).Encode(&resp)
+}
+
+func tmpFile(t *testing.T, data []byte) string {
+	fileName := filepath.Join(t.TempDir(), "data")
+	require.NoError(t, ioutil.WriteFile(fileName, data, 0644))
+	return fileName
+}
+
+func TestOpen(t *testing.T) {
+	s, err := Open("", "immudb", "prefix", staticCredential("token"))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	require.Equal(t, "gcs", s.Kind())
+	require.Equal(t, "gcs:gs://immudb/prefix/", s.String())
+	require.Equal(t, DefaultEndpoint+"/storage/v1/b/immudb/o/prefix%2Fdir%2Fobject", s.(*Storage).objectURL("dir/object"))
+}
+
+func TestValidateName(t *testing.T) {
+	for _, d := range []struct {
+		name     string
+		isFolder bool
+		err      error
+	}{
+		{"", false, nil},
+		{"", true, nil},
+		{"test", false, nil},
+		{"test/", true, nil},
+		{"test/name", false, nil},
+		{"test/name/", true, nil},
+		{"/test", false, ErrInvalidArgumentsNameStartSlash},
+		{"/test", true, ErrInvalidArgumentsNameStartSlash},
+		{"test/", false, ErrInvalidArgumentsNameEndSlash},
+		{"test", true, ErrInvalidArgumentsPathNoEndSlash},
+		{"test//name", false, ErrInvalidArgumentsInvalidName},
+		{"test/./name", false, ErrInvalidArgumentsInvalidName},
+		{"test/../test", false, ErrInvalidArgumentsInvalidName},
+		{"./test", false, ErrInvalidArgumentsInvalidName},
+		{"../test", false, ErrInvalidArgumentsInvalidName},
+	} {
+		t.Run(fmt.Sprintf("%+v", d), func(t *testing.T) {
+			s := Storage{}
+			err := s.validateName(d.name, d.isFolder)
+			require.ErrorIs(t, err, d.err)
+		})
+	}
+}
+
+func TestCornerCases(t *testing.T) {
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := Open("", "", "", staticCredential("token"))
+		require.ErrorIs(t, err, ErrInvalidArgumentsBucketEmpty)
+
+		_, err = Open("", "immudb/test", "", staticCredential("token"))
+		require.ErrorIs(t, err, ErrInvalidArgumentsBucketSlash)
+
+		_, err = Open("", "immudb", "", nil)
+		require.ErrorIs(t, err, ErrInvalidArgumentsNoCredential)
+	})
+
+	t.Run("prefix must be correctly normalized", func(t *testing.T) {
+		for _, prefix := range []string{"/test/", "/test", "test"} {
+			s, err := Open("", "immudb", prefix, staticCredential("token"))
+			require.NoError(t, err)
+			require.Equal(t, "test/", s.(*Storage).prefix)
+		}
+	})
+
+	t.Run("invalid names and arguments", func(t *testing.T) {
+		s, err := Open("", "immudb", "", staticCredential("token"))
+		require.NoError(t, err)
+
+		ctx := context.Background()
+
+		_, err = s.Get(ctx, "/file", 0, -1)
+		require.ErrorIs(t, err, ErrInvalidArgumentsNameStartSlash)
+
+		_, err = s.Get(ctx, "file", 0, 0)
+		require.ErrorIs(t, err, ErrInvalidArgumentsOffsSize)
+
+		err = s.Put(ctx, "/file", "/tmp/test.txt")
+		require.ErrorIs(t, err, ErrInvalidArgumentsNameStartSlash)
+
+		_, err = s.Exists(ctx, "/file")
+		require.ErrorIs(t, err, ErrInvalidArgumentsNameStartSlash)
+
+		_, _, err = s.ListEntries(ctx, "prefix-no-slash")
+		require.ErrorIs(t, err, ErrInvalidArgumentsPathNoEndSlash)
+	})
+
+	t.Run("invalid http status code from the server", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "test error", http.StatusInternalServerError)
+		}))
+		defer ts.Close()
+
+		s, err := Open(ts.URL, "immudb", "", staticCredential("token"))
+		require.NoError(t, err)
+
+		ctx := context.Background()
+
+		_, err = s.Get(ctx, "object1", 0, -1)
+		require.ErrorIs(t, err, ErrInvalidResponse)
+
+		_, err = s.Exists(ctx, "object1")
+		require.ErrorIs(t, err, ErrInvalidResponse)
+
+		_, _, err = s.ListEntries(ctx, "")
+		require.ErrorIs(t, err, ErrInvalidResponse)
+
+		err = s.Put(ctx, "object1", tmpFile(t, []byte("data")))
+		require.ErrorIs(t, err, ErrInvalidResponse)
+	})
+
+	t.Run("missing upload session", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer ts.Close()
+
+		s, err := Open(ts.URL, "immudb", "", staticCredential("token"))
+		require.NoError(t, err)
+
+		err = s.Put(context.Background(), "object1", tmpFile(t, []byte("data")))
+		require.ErrorIs(t, err, ErrInvalidResponseNoUploadSession)
+	})
+
+	t.Run("malicious list response", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"prefixes":["../"]}`))
+		}))
+		defer ts.Close()
+
+		s, err := Open(ts.URL, "immudb", "", staticCredential("token"))
+		require.NoError(t, err)
+
+		_, _, err = s.ListEntries(context.Background(), "")
+		require.ErrorIs(t, err, ErrInvalidResponseSubPathMalicious)
+	})
+
+	t.Run("invalid upload file path", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Fail(t, "Should not call the server")
+		}))
+		defer ts.Close()
+
+		s, err := Open(ts.URL, "immudb", "", staticCredential("token"))
+		require.NoError(t, err)
+
+		err = s.Put(context.Background(), "object1", "/invalid/file/path/that/does/not/exist")
+		require.IsType(t, &fs.PathError{}, err)
+	})
+}
+
+func TestStorage(t *testing.T) {
+	f, ts := newFakeGCS(t, "immudb")
+	defer ts.Close()
+
+	s, err := Open(ts.URL, "immudb", "prefix", staticCredential("token"))
+	require.NoError(t, err)
+
+	s.(*Storage).chunkSize = 16
+
+	ctx := context.Background()
+
+	exists, err := s.Exists(ctx, "dir/large")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	large := []byte(strings.Repeat("0123456789abcdef", 5))
+
+	// The first chunk is partially persisted and the second one fails,
+	// yet the upload completes by resuming from the persisted data
+	require.NoError(t, s.Put(ctx, "dir/large", tmpFile(t, large)))
+	require.Equal(t, large, f.objects["prefix/dir/large"])
+
+	require.NoError(t, s.Put(ctx, "dir/small", tmpFile(t, []byte("small"))))
+	require.NoError(t, s.Put(ctx, "dir/empty", tmpFile(t, nil)))
+	require.NoError(t, s.Put(ctx, "dir/sub1/file", tmpFile(t, []byte("file"))))
+	require.NoError(t, s.Put(ctx, "dir/sub2/file", tmpFile(t, []byte("file"))))
+
+	exists, err = s.Exists(ctx, "dir/large")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	r, err := s.Get(ctx, "dir/large", 0, -1)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, large, data)
+
+	r, err = s.Get(ctx, "dir/large", 16, 10)
+	require.NoError(t, err)
+	data, err = ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, large[16:26], data)
+
+	entries, subPaths, err := s.ListEntries(ctx, "dir/")
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, "empty", entries[0].Name)
+	require.EqualValues(t, 0, entries[0].Size)
+	require.Equal(t, "large", entries[1].Name)
+	require.EqualValues(t, len(large), entries[1].Size)
+	require.Equal(t, "small", entries[2].Name)
+	require.EqualValues(t, 5, entries[2].Size)
+	require.Equal(t, []string{"sub1", "sub2"}, subPaths)
+
+	entries, subPaths, err = s.ListEntries(ctx, "")
+	require.NoError(t, err)
+	require.Empty(t, entries)
+	require.Equal(t, []string{"dir"}, subPaths)
+}
+
+func TestServiceAccountCredential(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+
+	tokenRequests := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.PostForm.Get("grant_type"))
+
+		parts := strings.Split(r.PostForm.Get("assertion"), ".")
+		require.Len(t, parts, 3)
+
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		require.NoError(t, err)
+
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		require.NoError(t, rsa.VerifyPKCS1v15(&privateKey.PublicKey, crypto.SHA256, digest[:], signature))
+
+		claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+		require.NoError(t, err)
+
+		var claims map[string]interface{}
+		require.NoError(t, json.Unmarshal(claimsJSON, &claims))
+		require.Equal(t, "immudb@project.iam.gserviceaccount.com", claims["iss"])
+		require.Equal(t, storageScope, claims["scope"])
+
+		tokenRequests++
+		fmt.Fprintf(w, `{"access_token":"token%d","expires_in":3600}`, tokenRequests)
+	}))
+	defer ts.Close()
+
+	jsonKey, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "immudb@project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})),
+		"token_uri":    ts.URL,
+	})
+	require.NoError(t, err)
+
+	c, err := ServiceAccountCredential(jsonKey)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		token, err := c.accessToken(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "token1", token)
+	}
+
+	// The token is cached until it is about to expire
+	require.Equal(t, 1, tokenRequests)
+
+	t.Run("invalid key files", func(t *testing.T) {
+		_, err := ServiceAccountCredential([]byte("not json"))
+		require.ErrorIs(t, err, ErrInvalidArgumentsInvalidKeyFile)
+
+		_, err = ServiceAccountCredential([]byte(`{"client_email":"immudb"}`))
+		require.ErrorIs(t, err, ErrInvalidArgumentsInvalidKeyFile)
+
+		_, err = ServiceAccountCredential([]byte(`{"client_email":"immudb","token_uri":"uri","private_key":"key"}`))
+		require.ErrorIs(t, err, ErrInvalidArgumentsInvalidKeyEntry)
+	})
+}
+
+func TestMetadataCredential(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			http.Error(w, "missing metadata flavor", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"access_token":"token","expires_in":3600,"token_type":"Bearer"}`))
+	}))
+	defer ts.Close()
+
+	token, err := metadataCredential(ts.URL).accessToken(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token", token)
+
+	ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no service account", http.StatusNotFound)
+	})
+
+	_, err = metadataCredential(ts.URL).accessToken(context.Background())
+	require.ErrorIs(t, err, ErrInvalidResponse)
+}
@@ -19,13 +19,59 @@ package remotestorage
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"io/fs"
+	"net/http"
 )
 
 var (
 	ErrNotFound = errors.New("object not found")
 )
 
+// ResponseError reports a request rejected by the remote storage service
+type ResponseError struct {
+	Err        error // The error of the storage implementation
+	StatusCode int
+	Status     string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%v: request failed with status code %d (%s)", e.Err, e.StatusCode, e.Status)
+}
+
+func (e *ResponseError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable tells if a failed operation may succeed when retried. Cancellations,
+// missing objects, local file errors and requests rejected by the service are not retryable,
+// unless the service reports a timeout, throttling or a failure of its own
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrNotFound) {
+		return false
+	}
+
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return false
+	}
+
+	var respErr *ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.StatusCode == http.StatusRequestTimeout ||
+			respErr.StatusCode == http.StatusTooManyRequests ||
+			respErr.StatusCode >= 500
+	}
+
+	// Network failures, timeouts and unknown errors
+	return true
+}
+
 type EntryInfo struct {
 	Name string
 	Size int64
@@ -0,0 +1,63 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotestorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRetryable(t *testing.T) {
+	errInvalidResponse := errors.New("invalid response")
+
+	for _, d := range []struct {
+		err       error
+		retryable bool
+	}{
+		{nil, false},
+		{context.Canceled, false},
+		{fmt.Errorf("wrapped: %w", context.Canceled), false},
+		{context.DeadlineExceeded, true},
+		{ErrNotFound, false},
+		{&fs.PathError{Op: "open", Path: "/file", Err: fs.ErrNotExist}, false},
+		{&ResponseError{Err: errInvalidResponse, StatusCode: http.StatusForbidden}, false},
+		{&ResponseError{Err: errInvalidResponse, StatusCode: http.StatusNotFound}, false},
+		{&ResponseError{Err: errInvalidResponse, StatusCode: http.StatusRequestTimeout}, true},
+		{&ResponseError{Err: errInvalidResponse, StatusCode: http.StatusTooManyRequests}, true},
+		{&ResponseError{Err: errInvalidResponse, StatusCode: http.StatusInternalServerError}, true},
+		{&ResponseError{Err: errInvalidResponse, StatusCode: http.StatusServiceUnavailable}, true},
+		{fmt.Errorf("%w: connection reset by peer", errInvalidResponse), true},
+	} {
+		t.Run(fmt.Sprintf("%v", d.err), func(t *testing.T) {
+			require.Equal(t, d.retryable, IsRetryable(d.err))
+		})
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	errInvalidResponse := errors.New("invalid response code")
+
+	err := error(&ResponseError{Err: errInvalidResponse, StatusCode: 503, Status: "503 Service Unavailable"})
+	require.ErrorIs(t, err, errInvalidResponse)
+	require.EqualError(t, err, "invalid response code: request failed with status code 503 (503 Service Unavailable)")
+}
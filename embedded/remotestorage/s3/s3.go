@@ -456,10 +456,11 @@ func (s *Storage) requestWithRedirects(
 				resp.StatusCode,
 				resp.Status,
 			)
-			return nil, fmt.Errorf(
-				"%w: request failed with status code %d (%s)",
-				ErrInvalidResponse, resp.StatusCode, resp.Status,
-			)
+			return nil, &remotestorage.ResponseError{
+				Err:        ErrInvalidResponse,
+				StatusCode: resp.StatusCode,
+				Status:     resp.Status,
+			}
 		}
 	}
 	log.Printf("S3 %s %s failed - too many redirects", method, reqURL)
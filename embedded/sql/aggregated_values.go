@@ -110,9 +110,20 @@ func (v *CountValue) selectorRanges(table *Table, asTable string, params map[str
 
 type SumValue struct {
 	s   int64
+	d   *Decimal // sum of DECIMAL values, nil when summing INTEGER values
 	sel string
 }
 
+func newSumValue(sel string, t SQLValueType) *SumValue {
+	v := &SumValue{sel: sel}
+
+	if t == DecimalType {
+		v.d = decimalFromInt(0)
+	}
+
+	return v
+}
+
 func (v *SumValue) Selector() string {
 	return v.sel
 }
@@ -122,6 +133,10 @@ func (v *SumValue) ColBounded() bool {
 }
 
 func (v *SumValue) Type() SQLValueType {
+	if v.d != nil {
+		return DecimalType
+	}
+
 	return IntegerType
 }
 
@@ -130,10 +145,18 @@ func (v *SumValue) IsNull() bool {
 }
 
 func (v *SumValue) Value() interface{} {
+	if v.d != nil {
+		return v.d.Value()
+	}
+
 	return v.s
 }
 
 func (v *SumValue) Compare(val TypedValue) (int, error) {
+	if v.d != nil {
+		return v.d.Compare(val)
+	}
+
 	if val.Type() != IntegerType {
 		return 0, ErrNotComparableValues
 	}
@@ -152,6 +175,17 @@ func (v *SumValue) Compare(val TypedValue) (int, error) {
 }
 
 func (v *SumValue) updateWith(val TypedValue) error {
+	if v.d != nil {
+		d, err := addDecimal(v.d, val)
+		if err != nil {
+			return err
+		}
+
+		v.d = d
+
+		return nil
+	}
+
 	if val.Type() != IntegerType {
 		return ErrNotComparableValues
 	}
@@ -164,11 +198,11 @@ func (v *SumValue) updateWith(val TypedValue) error {
 // ValueExp
 
 func (v *SumValue) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	return IntegerType, nil
+	return v.Type(), nil
 }
 
 func (v *SumValue) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if t != IntegerType {
+	if t != v.Type() {
 		return ErrNotComparableValues
 	}
 	return nil
@@ -386,10 +420,29 @@ func (v *MaxValue) selectorRanges(table *Table, asTable string, params map[strin
 
 type AVGValue struct {
 	s   int64
+	d   *Decimal // sum of DECIMAL values, nil when averaging INTEGER values
 	c   int64
 	sel string
 }
 
+func newAVGValue(sel string, t SQLValueType) *AVGValue {
+	v := &AVGValue{sel: sel}
+
+	if t == DecimalType {
+		v.d = decimalFromInt(0)
+	}
+
+	return v
+}
+
+func (v *AVGValue) decimalAvg() *Decimal {
+	if v.c == 0 {
+		return v.d
+	}
+
+	return v.d.quo(decimalFromInt(v.c))
+}
+
 func (v *AVGValue) Selector() string {
 	return v.sel
 }
@@ -399,6 +452,10 @@ func (v *AVGValue) ColBounded() bool {
 }
 
 func (v *AVGValue) Type() SQLValueType {
+	if v.d != nil {
+		return DecimalType
+	}
+
 	return IntegerType
 }
 
@@ -407,10 +464,18 @@ func (v *AVGValue) IsNull() bool {
 }
 
 func (v *AVGValue) Value() interface{} {
+	if v.d != nil {
+		return v.decimalAvg().Value()
+	}
+
 	return v.s / v.c
 }
 
 func (v *AVGValue) Compare(val TypedValue) (int, error) {
+	if v.d != nil {
+		return v.decimalAvg().Compare(val)
+	}
+
 	if val.Type() != IntegerType {
 		return 0, ErrNotComparableValues
 	}
@@ -430,6 +495,22 @@ func (v *AVGValue) Compare(val TypedValue) (int, error) {
 }
 
 func (v *AVGValue) updateWith(val TypedValue) error {
+	if v.d != nil {
+		if val.IsNull() {
+			return nil
+		}
+
+		d, err := addDecimal(v.d, val)
+		if err != nil {
+			return err
+		}
+
+		v.d = d
+		v.c++
+
+		return nil
+	}
+
 	if val.Type() != IntegerType {
 		return ErrNotComparableValues
 	}
@@ -443,11 +524,11 @@ func (v *AVGValue) updateWith(val TypedValue) error {
 // ValueExp
 
 func (v *AVGValue) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	return IntegerType, nil
+	return v.Type(), nil
 }
 
 func (v *AVGValue) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if t != IntegerType {
+	if t != v.Type() {
 		return ErrNotComparableValues
 	}
 
@@ -477,3 +558,17 @@ func (v *AVGValue) isConstant() bool {
 func (v *AVGValue) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
 	return nil
 }
+
+// addDecimal adds the value to the accumulated DECIMAL value, NULL values are ignored
+func addDecimal(acc *Decimal, val TypedValue) (*Decimal, error) {
+	if val.IsNull() {
+		return acc, nil
+	}
+
+	d, ok := asDecimal(val)
+	if !ok {
+		return nil, ErrNotComparableValues
+	}
+
+	return acc.add(d), nil
+}
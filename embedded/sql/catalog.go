@@ -19,7 +19,9 @@ package sql
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"strings"
 	"time"
 
@@ -38,6 +40,18 @@ type Database struct {
 	tables       []*Table
 	tablesByID   map[uint32]*Table
 	tablesByName map[string]*Table
+	views        []*View
+	viewsByID    map[uint32]*View
+	viewsByName  map[string]*View
+	maxViewID    uint32
+}
+
+type View struct {
+	db    *Database
+	id    uint32
+	name  string
+	sql   string
+	query DataSource
 }
 
 type Table struct {
@@ -47,20 +61,42 @@ type Table struct {
 	cols            []*Column
 	colsByID        map[uint32]*Column
 	colsByName      map[string]*Column
+	droppedColsByID map[uint32]*Column // dropped columns are kept so to decode previously stored rows
 	indexes         []*Index
 	indexesByName   map[string]*Index
 	indexesByColID  map[uint32][]*Index
 	primaryIndex    *Index
+	checks          []*Check
+	foreignKeys     []*ForeignKey
 	autoIncrementPK bool
 	maxPK           int64
 }
 
+// Check is a boolean expression every row of the table must satisfy
+type Check struct {
+	table *Table
+	id    uint32
+	sql   string
+	exp   ValueExp
+}
+
+// ForeignKey requires the values of its columns to match the primary key of an existing
+// row of the referenced table, referenced rows can not be deleted
+type ForeignKey struct {
+	table    *Table
+	id       uint32
+	cols     []*Column
+	refTable *Table
+}
+
 type Index struct {
 	table    *Table
 	id       uint32
 	unique   bool
 	cols     []*Column
 	colsByID map[uint32]*Column
+	where    ValueExp // partial indexes only include the rows satisfying it
+	whereSQL string
 }
 
 type Column struct {
@@ -69,8 +105,11 @@ type Column struct {
 	colName       string
 	colType       SQLValueType
 	maxLen        int
+	precision     int
+	scale         int
 	autoIncrement bool
 	notNull       bool
+	dropped       bool
 }
 
 func newCatalog() *Catalog {
@@ -97,6 +136,8 @@ func (c *Catalog) newDatabase(id uint32, name string) (*Database, error) {
 		name:         name,
 		tablesByID:   map[uint32]*Table{},
 		tablesByName: map[string]*Table{},
+		viewsByID:    map[uint32]*View{},
+		viewsByName:  map[string]*View{},
 	}
 
 	c.dbsByID[db.id] = db
@@ -174,6 +215,40 @@ func (db *Database) GetTableByID(id uint32) (*Table, error) {
 	return table, nil
 }
 
+func (db *Database) ExistView(view string) bool {
+	_, exists := db.viewsByName[view]
+	return exists
+}
+
+func (db *Database) GetViews() []*View {
+	return db.views
+}
+
+func (db *Database) GetViewByName(name string) (*View, error) {
+	view, exists := db.viewsByName[name]
+	if !exists {
+		return nil, fmt.Errorf("%w (%s)", ErrViewDoesNotExist, name)
+	}
+	return view, nil
+}
+
+func (v *View) ID() uint32 {
+	return v.id
+}
+
+func (v *View) Name() string {
+	return v.name
+}
+
+func (v *View) Database() *Database {
+	return v.db
+}
+
+// SQL returns the query the view was defined with
+func (v *View) SQL() string {
+	return v.sql
+}
+
 func (t *Table) ID() uint32 {
 	return t.id
 }
@@ -190,6 +265,19 @@ func (t *Table) ColsByName() map[string]*Column {
 	return t.colsByName
 }
 
+// DroppedColsByID returns the columns which were dropped from the table, as they may still be part of previously stored rows
+func (t *Table) DroppedColsByID() map[uint32]*Column {
+	return t.droppedColsByID
+}
+
+func (t *Table) Checks() []*Check {
+	return t.checks
+}
+
+func (t *Table) ForeignKeys() []*ForeignKey {
+	return t.foreignKeys
+}
+
 func (t *Table) Name() string {
 	return t.name
 }
@@ -198,6 +286,10 @@ func (t *Table) PrimaryIndex() *Index {
 	return t.primaryIndex
 }
 
+func (t *Table) Indexes() []*Index {
+	return t.indexes
+}
+
 func (t *Table) IsIndexed(colName string) (indexed bool, err error) {
 	c, exists := t.colsByName[colName]
 	if !exists {
@@ -229,6 +321,27 @@ func (t *Table) GetColumnByID(id uint32) (*Column, error) {
 	return col, nil
 }
 
+func (c *Check) ID() uint32 {
+	return c.id
+}
+
+// SQL returns the source text of the check expression
+func (c *Check) SQL() string {
+	return c.sql
+}
+
+func (fk *ForeignKey) ID() uint32 {
+	return fk.id
+}
+
+func (fk *ForeignKey) Cols() []*Column {
+	return fk.cols
+}
+
+func (fk *ForeignKey) ReferencedTable() *Table {
+	return fk.refTable
+}
+
 func (i *Index) IsPrimary() bool {
 	return i.id == PKIndexID
 }
@@ -241,6 +354,59 @@ func (i *Index) Cols() []*Column {
 	return i.cols
 }
 
+func (i *Index) IsPartial() bool {
+	return i.where != nil
+}
+
+// Predicate returns the source text of the condition of a partial index
+func (i *Index) Predicate() string {
+	return i.whereSQL
+}
+
+// includes returns true if the row must have an entry in the index
+func (i *Index) includes(tx *SQLTx, row *Row) (bool, error) {
+	if i.where == nil {
+		return true, nil
+	}
+
+	val, err := i.where.reduce(tx, row, i.table.db.name, i.table.name)
+	if err != nil {
+		return false, err
+	}
+
+	if val.IsNull() {
+		return false, nil
+	}
+
+	included, ok := val.Value().(bool)
+	if !ok {
+		return false, ErrInvalidCondition
+	}
+
+	return included, nil
+}
+
+// usablePrefixLen returns the number of leading columns of the index for which a range is known,
+// only the last of them may be a non-unitary range
+func (i *Index) usablePrefixLen(rangesByColID map[uint32]*typedValueRange) int {
+	n := 0
+
+	for _, col := range i.cols {
+		colRange, ok := rangesByColID[col.id]
+		if !ok {
+			break
+		}
+
+		n++
+
+		if !colRange.unitary() {
+			break
+		}
+	}
+
+	return n
+}
+
 func (i *Index) IncludesCol(colID uint32) bool {
 	_, ok := i.colsByID[colID]
 	return ok
@@ -309,22 +475,27 @@ func (db *Database) newTable(name string, colsSpec []*ColSpec) (table *Table, er
 		return nil, fmt.Errorf("%w (%s)", ErrTableAlreadyExists, name)
 	}
 
+	if db.ExistView(name) {
+		return nil, fmt.Errorf("%w (%s)", ErrViewAlreadyExists, name)
+	}
+
 	id := len(db.tables) + 1
 
 	table = &Table{
-		id:             uint32(id),
-		db:             db,
-		name:           name,
-		cols:           make([]*Column, len(colsSpec)),
-		colsByID:       make(map[uint32]*Column),
-		colsByName:     make(map[string]*Column),
-		indexesByName:  make(map[string]*Index),
-		indexesByColID: make(map[uint32][]*Index),
+		id:              uint32(id),
+		db:              db,
+		name:            name,
+		cols:            make([]*Column, 0, len(colsSpec)),
+		colsByID:        make(map[uint32]*Column),
+		colsByName:      make(map[string]*Column),
+		droppedColsByID: make(map[uint32]*Column),
+		indexesByName:   make(map[string]*Index),
+		indexesByColID:  make(map[uint32][]*Index),
 	}
 
 	for i, cs := range colsSpec {
 		_, colExists := table.colsByName[cs.colName]
-		if colExists {
+		if colExists && !cs.dropped {
 			return nil, ErrDuplicatedColumn
 		}
 
@@ -336,7 +507,12 @@ func (db *Database) newTable(name string, colsSpec []*ColSpec) (table *Table, er
 			return nil, ErrLimitedMaxLen
 		}
 
-		id := len(table.colsByID) + 1
+		precision, scale, valid := precisionForType(cs.precision, cs.scale, cs.colType)
+		if !valid {
+			return nil, ErrLimitedPrecision
+		}
+
+		id := i + 1
 
 		col := &Column{
 			id:            uint32(id),
@@ -344,11 +520,19 @@ func (db *Database) newTable(name string, colsSpec []*ColSpec) (table *Table, er
 			colName:       cs.colName,
 			colType:       cs.colType,
 			maxLen:        cs.maxLen,
+			precision:     precision,
+			scale:         scale,
 			autoIncrement: cs.autoIncrement,
 			notNull:       cs.notNull,
+			dropped:       cs.dropped,
+		}
+
+		if col.dropped {
+			table.droppedColsByID[col.id] = col
+			continue
 		}
 
-		table.cols[i] = col
+		table.cols = append(table.cols, col)
 		table.colsByID[col.id] = col
 		table.colsByName[col.colName] = col
 	}
@@ -360,11 +544,151 @@ func (db *Database) newTable(name string, colsSpec []*ColSpec) (table *Table, er
 	return table, nil
 }
 
-func (t *Table) newIndex(unique bool, colIDs []uint32) (index *Index, err error) {
+func (db *Database) newView(id uint32, name, sql string, query DataSource) (*View, error) {
+	if id == 0 || len(name) == 0 || query == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	if db.ExistView(name) {
+		return nil, fmt.Errorf("%w (%s)", ErrViewAlreadyExists, name)
+	}
+
+	if db.ExistTable(name) {
+		return nil, fmt.Errorf("%w (%s)", ErrTableAlreadyExists, name)
+	}
+
+	_, exists := db.viewsByID[id]
+	if exists {
+		return nil, ErrCorruptedData
+	}
+
+	view := &View{
+		db:    db,
+		id:    id,
+		name:  name,
+		sql:   sql,
+		query: query,
+	}
+
+	db.views = append(db.views, view)
+	db.viewsByID[view.id] = view
+	db.viewsByName[view.name] = view
+
+	if id > db.maxViewID {
+		db.maxViewID = id
+	}
+
+	return view, nil
+}
+
+func (db *Database) dropView(name string) (*View, error) {
+	view, err := db.GetViewByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range db.views {
+		if v.id != view.id && db.dependsOn(v.query, view.name) {
+			return nil, fmt.Errorf("%w: %s is used by %s", ErrViewIsReferenced, view.name, v.name)
+		}
+	}
+
+	for i, v := range db.views {
+		if v.id == view.id {
+			db.views = append(db.views[:i], db.views[i+1:]...)
+			break
+		}
+	}
+
+	delete(db.viewsByID, view.id)
+	delete(db.viewsByName, view.name)
+
+	return view, nil
+}
+
+// dependsOn returns true if the data source references, either directly, through subqueries
+// or through other views, a table or view with the given name
+func (db *Database) dependsOn(ds DataSource, name string) bool {
+	switch s := ds.(type) {
+	case *tableRef:
+		{
+			if s.db != "" && s.db != db.name {
+				return false
+			}
+
+			if s.table == name {
+				return true
+			}
+
+			view, exists := db.viewsByName[s.table]
+			if exists {
+				return db.dependsOn(view.query, name)
+			}
+		}
+	case *SelectStmt:
+		{
+			if db.dependsOn(s.ds, name) {
+				return true
+			}
+
+			exps := []ValueExp{s.where, s.having}
+
+			for _, sel := range s.selectors {
+				exps = append(exps, sel)
+			}
+
+			for _, join := range s.joins {
+				if db.dependsOn(join.ds, name) {
+					return true
+				}
+
+				exps = append(exps, join.cond)
+			}
+
+			for _, exp := range exps {
+				for _, q := range subQueriesIn(exp) {
+					if db.dependsOn(q, name) {
+						return true
+					}
+				}
+			}
+		}
+	case *UnionStmt:
+		{
+			return db.dependsOn(s.left, name) || db.dependsOn(s.right, name)
+		}
+	case *SetOpStmt:
+		{
+			return db.dependsOn(s.left, name) || db.dependsOn(s.right, name)
+		}
+	}
+
+	return false
+}
+
+func (t *Table) newIndex(unique bool, colIDs []uint32, whereSQL string, where ValueExp) (index *Index, err error) {
 	if len(colIDs) < 1 {
 		return nil, ErrIllegalArguments
 	}
 
+	if where != nil {
+		if len(t.indexes) == 0 {
+			// the primary index must include every row
+			return nil, ErrIllegalArguments
+		}
+
+		params := make(map[string]SQLValueType)
+
+		err := where.requiresType(BooleanType, t.colDescriptors(0), params, t.db.name, t.name)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(params) > 0 {
+			return nil, ErrParameterizedIndexPredicate
+		}
+	}
+
 	// validate column ids
 	cols := make([]*Column, len(colIDs))
 	colsByID := make(map[uint32]*Column, len(colIDs))
@@ -380,6 +704,10 @@ func (t *Table) newIndex(unique bool, colIDs []uint32) (index *Index, err error)
 			return nil, ErrDuplicatedColumn
 		}
 
+		if col.colType == JSONType {
+			return nil, ErrLimitedKeyType
+		}
+
 		cols[i] = col
 		colsByID[colID] = col
 	}
@@ -390,6 +718,8 @@ func (t *Table) newIndex(unique bool, colIDs []uint32) (index *Index, err error)
 		unique:   unique,
 		cols:     cols,
 		colsByID: colsByID,
+		where:    where,
+		whereSQL: whereSQL,
 	}
 
 	_, exists := t.indexesByName[index.Name()]
@@ -426,12 +756,17 @@ func (t *Table) newColumn(spec *ColSpec) (*Column, error) {
 		return nil, fmt.Errorf("%w (%s)", ErrLimitedMaxLen, spec.colName)
 	}
 
+	precision, scale, valid := precisionForType(spec.precision, spec.scale, spec.colType)
+	if !valid {
+		return nil, fmt.Errorf("%w (%s)", ErrLimitedPrecision, spec.colName)
+	}
+
 	_, exists := t.colsByName[spec.colName]
 	if exists {
 		return nil, fmt.Errorf("%w (%s)", ErrColumnAlreadyExists, spec.colName)
 	}
 
-	id := len(t.cols) + 1
+	id := len(t.colsByID) + len(t.droppedColsByID) + 1
 
 	col := &Column{
 		id:            uint32(id),
@@ -439,6 +774,8 @@ func (t *Table) newColumn(spec *ColSpec) (*Column, error) {
 		colName:       spec.colName,
 		colType:       spec.colType,
 		maxLen:        spec.maxLen,
+		precision:     precision,
+		scale:         scale,
 		autoIncrement: spec.autoIncrement,
 		notNull:       spec.notNull,
 	}
@@ -465,6 +802,11 @@ func (t *Table) renameColumn(oldName, newName string) (*Column, error) {
 		return nil, fmt.Errorf("%w (%s)", ErrColumnAlreadyExists, newName)
 	}
 
+	// check expressions refer to columns by name
+	if t.isCheckedCol(col) {
+		return nil, fmt.Errorf("%w (%s)", ErrColumnIsReferenced, oldName)
+	}
+
 	col.colName = newName
 
 	delete(t.colsByName, oldName)
@@ -473,6 +815,214 @@ func (t *Table) renameColumn(oldName, newName string) (*Column, error) {
 	return col, nil
 }
 
+// dropColumn removes the column from the table, the column is only logically dropped
+// so values stored in previous rows are still preserved
+func (t *Table) dropColumn(colName string) (*Column, error) {
+	col, exists := t.colsByName[colName]
+	if !exists {
+		return nil, fmt.Errorf("%w (%s)", ErrColumnDoesNotExist, colName)
+	}
+
+	_, indexed := t.indexesByColID[col.id]
+	if indexed || t.isIndexPredicateCol(col) {
+		return nil, fmt.Errorf("%w (%s)", ErrColumnIsIndexed, colName)
+	}
+
+	if t.isCheckedCol(col) || t.isForeignKeyCol(col) {
+		return nil, fmt.Errorf("%w (%s)", ErrColumnIsReferenced, colName)
+	}
+
+	cols := make([]*Column, 0, len(t.cols)-1)
+
+	for _, c := range t.cols {
+		if c.id != col.id {
+			cols = append(cols, c)
+		}
+	}
+
+	t.cols = cols
+
+	delete(t.colsByID, col.id)
+	delete(t.colsByName, colName)
+
+	col.dropped = true
+	t.droppedColsByID[col.id] = col
+
+	return col, nil
+}
+
+// colDescriptors returns the descriptors of the columns of the table but the excluded one
+func (t *Table) colDescriptors(excludedColID uint32) map[string]ColDescriptor {
+	cols := make(map[string]ColDescriptor, len(t.cols))
+
+	for _, col := range t.cols {
+		if col.id == excludedColID {
+			continue
+		}
+
+		des := ColDescriptor{
+			Database: t.db.name,
+			Table:    t.name,
+			Column:   col.colName,
+			Type:     col.colType,
+		}
+
+		cols[des.Selector()] = des
+	}
+
+	return cols
+}
+
+// isCheckedCol returns true if the column is used by any check of the table
+func (t *Table) isCheckedCol(col *Column) bool {
+	if len(t.checks) == 0 {
+		return false
+	}
+
+	cols := t.colDescriptors(col.id)
+
+	for _, check := range t.checks {
+		err := check.exp.requiresType(BooleanType, cols, nil, t.db.name, t.name)
+		if err != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isIndexPredicateCol returns true if the column is used by the predicate of any partial index of the table
+func (t *Table) isIndexPredicateCol(col *Column) bool {
+	cols := t.colDescriptors(col.id)
+
+	for _, index := range t.indexes {
+		if index.where == nil {
+			continue
+		}
+
+		err := index.where.requiresType(BooleanType, cols, nil, t.db.name, t.name)
+		if err != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *Table) isForeignKeyCol(col *Column) bool {
+	for _, fk := range t.foreignKeys {
+		for _, c := range fk.cols {
+			if c.id == col.id {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (t *Table) newCheck(sql string, exp ValueExp) (*Check, error) {
+	params := make(map[string]SQLValueType)
+
+	err := exp.requiresType(BooleanType, t.colDescriptors(0), params, t.db.name, t.name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(params) > 0 {
+		return nil, ErrParameterizedCheck
+	}
+
+	check := &Check{
+		table: t,
+		id:    uint32(len(t.checks) + len(t.foreignKeys) + 1),
+		sql:   sql,
+		exp:   exp,
+	}
+
+	t.checks = append(t.checks, check)
+
+	return check, nil
+}
+
+// newForeignKey creates a foreign key over the columns, they must match the primary key of the referenced table
+func (t *Table) newForeignKey(colIDs []uint32, refTable *Table) (*ForeignKey, error) {
+	pkCols := refTable.primaryIndex.cols
+
+	if len(colIDs) != len(pkCols) {
+		return nil, ErrInvalidForeignKey
+	}
+
+	cols := make([]*Column, len(colIDs))
+
+	for i, colID := range colIDs {
+		col, err := t.GetColumnByID(colID)
+		if err != nil {
+			return nil, err
+		}
+
+		if col.colType != pkCols[i].colType {
+			return nil, fmt.Errorf("%w: %s and %s types differ", ErrInvalidForeignKey, col.colName, pkCols[i].colName)
+		}
+
+		cols[i] = col
+	}
+
+	fk := &ForeignKey{
+		table:    t,
+		id:       uint32(len(t.checks) + len(t.foreignKeys) + 1),
+		cols:     cols,
+		refTable: refTable,
+	}
+
+	t.foreignKeys = append(t.foreignKeys, fk)
+
+	return fk, nil
+}
+
+// alterColumn changes the column according to the spec, only changes keeping stored values valid
+// are supported i.e. increasing the max length of VARCHAR, BLOB and JSON columns, or the precision of DECIMAL columns
+func (t *Table) alterColumn(spec *ColSpec) (*Column, error) {
+	col, exists := t.colsByName[spec.colName]
+	if !exists {
+		return nil, fmt.Errorf("%w (%s)", ErrColumnDoesNotExist, spec.colName)
+	}
+
+	if spec.colType != col.colType || spec.autoIncrement || spec.notNull {
+		return nil, fmt.Errorf("%w (%s)", ErrLimitedColumnTypeChange, spec.colName)
+	}
+
+	if !validMaxLenForType(spec.maxLen, spec.colType) {
+		return nil, fmt.Errorf("%w (%s)", ErrLimitedMaxLen, spec.colName)
+	}
+
+	precision, scale, valid := precisionForType(spec.precision, spec.scale, spec.colType)
+	if !valid {
+		return nil, fmt.Errorf("%w (%s)", ErrLimitedPrecision, spec.colName)
+	}
+
+	if variableSized(col.colType) && spec.maxLen != col.maxLen {
+		if col.maxLen == 0 || (spec.maxLen > 0 && spec.maxLen < col.maxLen) {
+			return nil, fmt.Errorf("%w (%s)", ErrLimitedColumnTypeChange, spec.colName)
+		}
+
+		// indexed values are encoded using the max length of the column
+		_, indexed := t.indexesByColID[col.id]
+		if indexed {
+			return nil, fmt.Errorf("%w (%s)", ErrColumnIsIndexed, spec.colName)
+		}
+	}
+
+	if precision < col.precision || scale != col.scale {
+		return nil, fmt.Errorf("%w (%s)", ErrLimitedColumnTypeChange, spec.colName)
+	}
+
+	col.maxLen = spec.maxLen
+	col.precision = precision
+
+	return col, nil
+}
+
 func (c *Column) ID() uint32 {
 	return c.id
 }
@@ -493,10 +1043,22 @@ func (c *Column) MaxLen() int {
 		return 8
 	case TimestampType:
 		return 8
+	case DecimalType:
+		return 8
 	}
 	return c.maxLen
 }
 
+// Precision returns the max number of digits of DECIMAL columns
+func (c *Column) Precision() int {
+	return c.precision
+}
+
+// Scale returns the number of fractional digits of DECIMAL columns
+func (c *Column) Scale() int {
+	return c.scale
+}
+
 func (c *Column) IsNullable() bool {
 	return !c.notNull
 }
@@ -513,9 +1075,34 @@ func validMaxLenForType(maxLen int, sqlType SQLValueType) bool {
 		return maxLen == 0 || maxLen == 8
 	case TimestampType:
 		return maxLen == 0 || maxLen == 8
+	case DecimalType:
+		return maxLen == 0 || maxLen == 8
+	}
+
+	return maxLen >= 0
+}
+
+// precisionForType validates the precision and scale of the column,
+// DECIMAL columns get the max precision when it's not specified
+func precisionForType(precision, scale int, sqlType SQLValueType) (int, int, bool) {
+	if sqlType != DecimalType {
+		return 0, 0, precision == 0 && scale == 0
 	}
 
-	return maxLen >= 0
+	if precision == 0 {
+		precision = MaxDecimalPrecision
+	}
+
+	return precision, scale, precision <= MaxDecimalPrecision && scale <= precision
+}
+
+// the precision and scale of DECIMAL columns are persisted in place of their max length
+func encodeDecimalSpec(precision, scale int) int {
+	return precision<<8 | scale
+}
+
+func decodeDecimalSpec(v int) (precision, scale int) {
+	return v >> 8, v & 0xff
 }
 
 func (c *Catalog) load(sqlPrefix []byte, tx *store.OngoingTx) error {
@@ -558,6 +1145,17 @@ func (c *Catalog) load(sqlPrefix []byte, tx *store.OngoingTx) error {
 		if err != nil {
 			return err
 		}
+
+		// constraints are loaded once all the tables they may reference are loaded
+		err = db.loadConstraints(sqlPrefix, tx)
+		if err != nil {
+			return err
+		}
+
+		err = db.loadViews(sqlPrefix, tx)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -644,6 +1242,200 @@ func (db *Database) loadTables(sqlPrefix []byte, tx *store.OngoingTx) error {
 	return nil
 }
 
+func (db *Database) loadViews(sqlPrefix []byte, tx *store.OngoingTx) error {
+	viewReaderSpec := store.KeyReaderSpec{
+		Prefix:  mapKey(sqlPrefix, catalogViewPrefix, EncodeID(db.id)),
+		Filters: []store.FilterFn{store.IgnoreExpired, store.IgnoreDeleted},
+	}
+
+	viewReader, err := tx.NewKeyReader(viewReaderSpec)
+	if err != nil {
+		return err
+	}
+	defer viewReader.Close()
+
+	for {
+		mkey, vref, err := viewReader.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		dbID, viewID, err := unmapViewID(sqlPrefix, mkey)
+		if err != nil {
+			return err
+		}
+
+		if dbID != db.id {
+			return ErrCorruptedData
+		}
+
+		v, err := vref.Resolve()
+		if err != nil {
+			return err
+		}
+
+		name, sql, err := decodeView(v)
+		if err != nil {
+			return err
+		}
+
+		stmts, err := ParseString(sql)
+		if err != nil {
+			return ErrCorruptedData
+		}
+
+		if len(stmts) != 1 {
+			return ErrCorruptedData
+		}
+
+		query, ok := stmts[0].(DataSource)
+		if !ok {
+			return ErrCorruptedData
+		}
+
+		_, err = db.newView(viewID, name, sql, query)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *Database) loadConstraints(sqlPrefix []byte, tx *store.OngoingTx) error {
+	for _, table := range db.tables {
+		err := table.loadConstraints(sqlPrefix, tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (table *Table) loadConstraints(sqlPrefix []byte, tx *store.OngoingTx) error {
+	constraintReaderSpec := store.KeyReaderSpec{
+		Prefix:  mapKey(sqlPrefix, catalogConstraintPrefix, EncodeID(table.db.id), EncodeID(table.id)),
+		Filters: []store.FilterFn{store.IgnoreExpired, store.IgnoreDeleted},
+	}
+
+	constraintReader, err := tx.NewKeyReader(constraintReaderSpec)
+	if err != nil {
+		return err
+	}
+	defer constraintReader.Close()
+
+	for {
+		mkey, vref, err := constraintReader.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		dbID, tableID, constraintID, err := unmapConstraint(sqlPrefix, mkey)
+		if err != nil {
+			return err
+		}
+
+		if table.id != tableID || table.db.id != dbID {
+			return ErrCorruptedData
+		}
+
+		v, err := vref.Resolve()
+		if err != nil {
+			return err
+		}
+
+		if len(v) < 1 {
+			return ErrCorruptedData
+		}
+
+		var id uint32
+
+		switch v[0] {
+		case checkConstraint:
+			{
+				sql := string(v[1:])
+
+				exp, err := parseExp(sql)
+				if err != nil {
+					return ErrCorruptedData
+				}
+
+				check, err := table.newCheck(sql, exp)
+				if err != nil {
+					return err
+				}
+
+				id = check.id
+			}
+		case foreignKeyConstraint:
+			{
+				// v={FOREIGN_KEY}{refTableID}{colID1}...{colIDN}
+				if len(v) < 1+2*EncIDLen || (len(v)-1)%EncIDLen != 0 {
+					return ErrCorruptedData
+				}
+
+				refTable, err := table.db.GetTableByID(binary.BigEndian.Uint32(v[1:]))
+				if err != nil {
+					return err
+				}
+
+				var colIDs []uint32
+
+				for i := 1 + EncIDLen; i < len(v); i += EncIDLen {
+					colIDs = append(colIDs, binary.BigEndian.Uint32(v[i:]))
+				}
+
+				fk, err := table.newForeignKey(colIDs, refTable)
+				if err != nil {
+					return err
+				}
+
+				id = fk.id
+			}
+		default:
+			return ErrCorruptedData
+		}
+
+		if constraintID != id {
+			return ErrCorruptedData
+		}
+	}
+
+	return nil
+}
+
+// encodeView returns the value persisted for a view i.e. {nameLEN}{viewNAME}{viewSQL}
+func encodeView(name, sql string) []byte {
+	v := make([]byte, EncLenLen+len(name)+len(sql))
+
+	binary.BigEndian.PutUint32(v, uint32(len(name)))
+	copy(v[EncLenLen:], []byte(name))
+	copy(v[EncLenLen+len(name):], []byte(sql))
+
+	return v
+}
+
+func decodeView(v []byte) (name, sql string, err error) {
+	if len(v) < EncLenLen {
+		return "", "", ErrCorruptedData
+	}
+
+	nameLen := int(binary.BigEndian.Uint32(v))
+
+	if nameLen == 0 || len(v) < EncLenLen+nameLen {
+		return "", "", ErrCorruptedData
+	}
+
+	return string(v[EncLenLen : EncLenLen+nameLen]), string(v[EncLenLen+nameLen:]), nil
+}
+
 func loadMaxPK(sqlPrefix []byte, tx *store.OngoingTx, table *Table) ([]byte, error) {
 	pkReaderSpec := store.KeyReaderSpec{
 		Prefix:    mapKey(sqlPrefix, PIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(PKIndexID)),
@@ -664,6 +1456,25 @@ func loadMaxPK(sqlPrefix []byte, tx *store.OngoingTx, table *Table) ([]byte, err
 	return unmapIndexEntry(table.primaryIndex, sqlPrefix, mkey)
 }
 
+// decodeColSpec decodes the persisted value of a column spec i.e. {(auto_incremental | nullable | dropped)}{maxLen}{colNAME}
+func decodeColSpec(colType SQLValueType, v []byte) *ColSpec {
+	spec := &ColSpec{
+		colName:       string(v[5:]),
+		colType:       colType,
+		maxLen:        int(binary.BigEndian.Uint32(v[1:])),
+		autoIncrement: v[0]&autoIncrementFlag != 0,
+		notNull:       v[0]&nullableFlag != 0,
+		dropped:       v[0]&droppedFlag != 0,
+	}
+
+	if colType == DecimalType {
+		spec.precision, spec.scale = decodeDecimalSpec(spec.maxLen)
+		spec.maxLen = 0
+	}
+
+	return spec
+}
+
 func loadColSpecs(dbID, tableID uint32, tx *store.OngoingTx, sqlPrefix []byte) (specs []*ColSpec, err error) {
 	initialKey := mapKey(sqlPrefix, catalogColumnPrefix, EncodeID(dbID), EncodeID(tableID))
 
@@ -706,15 +1517,7 @@ func loadColSpecs(dbID, tableID uint32, tx *store.OngoingTx, sqlPrefix []byte) (
 			return nil, ErrCorruptedData
 		}
 
-		spec := &ColSpec{
-			colName:       string(v[5:]),
-			colType:       colType,
-			maxLen:        int(binary.BigEndian.Uint32(v[1:])),
-			autoIncrement: v[0]&autoIncrementFlag != 0,
-			notNull:       v[0]&nullableFlag != 0,
-		}
-
-		specs = append(specs, spec)
+		specs = append(specs, decodeColSpec(colType, v))
 
 		if int(colID) != len(specs) {
 			return nil, ErrCorruptedData
@@ -761,27 +1564,56 @@ func (table *Table) loadIndexes(sqlPrefix []byte, tx *store.OngoingTx) error {
 			return err
 		}
 
-		// v={unique {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)}
+		// v={flags ({predicateLen}{predicate})? {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)}
 		colSpecLen := EncIDLen + 1
 
-		if len(v) < 1+colSpecLen || len(v)%colSpecLen != 1 {
+		if len(v) < 1 {
+			return ErrCorruptedData
+		}
+
+		flags := v[0]
+		colSpecs := v[1:]
+
+		var whereSQL string
+		var where ValueExp
+
+		if flags&partialIndexFlag != 0 {
+			if len(colSpecs) < 4 {
+				return ErrCorruptedData
+			}
+
+			predicateLen := int(binary.BigEndian.Uint32(colSpecs))
+			if len(colSpecs) < 4+predicateLen {
+				return ErrCorruptedData
+			}
+
+			whereSQL = string(colSpecs[4 : 4+predicateLen])
+			colSpecs = colSpecs[4+predicateLen:]
+
+			where, err = parseExp(whereSQL)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(colSpecs) < colSpecLen || len(colSpecs)%colSpecLen != 0 {
 			return ErrCorruptedData
 		}
 
 		var colIDs []uint32
 
-		for i := 1; i < len(v); i += colSpecLen {
-			colID := binary.BigEndian.Uint32(v[i:])
+		for i := 0; i < len(colSpecs); i += colSpecLen {
+			colID := binary.BigEndian.Uint32(colSpecs[i:])
 
 			// TODO: currently only ASC order is supported
-			if v[i+EncIDLen] != 0 {
+			if colSpecs[i+EncIDLen] != 0 {
 				return ErrCorruptedData
 			}
 
 			colIDs = append(colIDs, colID)
 		}
 
-		index, err := table.newIndex(v[0] > 0, colIDs)
+		index, err := table.newIndex(flags&uniqueIndexFlag != 0, colIDs, whereSQL, where)
 		if err != nil {
 			return err
 		}
@@ -833,6 +1665,22 @@ func unmapTableID(prefix, mkey []byte) (dbID, tableID uint32, err error) {
 	return
 }
 
+func unmapViewID(prefix, mkey []byte) (dbID, viewID uint32, err error) {
+	encID, err := trimPrefix(prefix, mkey, []byte(catalogViewPrefix))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if len(encID) != EncIDLen*2 {
+		return 0, 0, ErrCorruptedData
+	}
+
+	dbID = binary.BigEndian.Uint32(encID)
+	viewID = binary.BigEndian.Uint32(encID[EncIDLen:])
+
+	return
+}
+
 func unmapColSpec(prefix, mkey []byte) (dbID, tableID, colID uint32, colType SQLValueType, err error) {
 	encID, err := trimPrefix(prefix, mkey, []byte(catalogColumnPrefix))
 	if err != nil {
@@ -860,7 +1708,9 @@ func asType(t string) (SQLValueType, error) {
 		t == BooleanType ||
 		t == VarcharType ||
 		t == BLOBType ||
-		t == TimestampType {
+		t == TimestampType ||
+		t == JSONType ||
+		t == DecimalType {
 		return t, nil
 	}
 
@@ -884,6 +1734,23 @@ func unmapIndex(sqlPrefix, mkey []byte) (dbID, tableID, indexID uint32, err erro
 	return
 }
 
+func unmapConstraint(sqlPrefix, mkey []byte) (dbID, tableID, constraintID uint32, err error) {
+	encID, err := trimPrefix(sqlPrefix, mkey, []byte(catalogConstraintPrefix))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	if len(encID) != EncIDLen*3 {
+		return 0, 0, 0, ErrCorruptedData
+	}
+
+	dbID = binary.BigEndian.Uint32(encID)
+	tableID = binary.BigEndian.Uint32(encID[EncIDLen:])
+	constraintID = binary.BigEndian.Uint32(encID[2*EncIDLen:])
+
+	return
+}
+
 func unmapIndexEntry(index *Index, sqlPrefix, mkey []byte) (encPKVals []byte, err error) {
 	if index == nil {
 		return nil, ErrIllegalArguments
@@ -946,7 +1813,7 @@ func unmapIndexEntry(index *Index, sqlPrefix, mkey []byte) (encPKVals []byte, er
 }
 
 func variableSized(sqlType SQLValueType) bool {
-	return sqlType == VarcharType || sqlType == BLOBType
+	return sqlType == VarcharType || sqlType == BLOBType || sqlType == JSONType
 }
 
 func mapKey(prefix []byte, mappingPrefix string, encValues ...[]byte) []byte {
@@ -1004,6 +1871,41 @@ func EncodeValue(val interface{}, colType SQLValueType, maxLen int) ([]byte, err
 			binary.BigEndian.PutUint32(encv[:], uint32(len(strVal)))
 			copy(encv[EncLenLen:], []byte(strVal))
 
+			return encv, nil
+		}
+	case DecimalType:
+		{
+			d, err := encodableDecimal(val)
+			if err != nil {
+				return nil, err
+			}
+
+			// len(v) + scale + unscaled v
+			encv := make([]byte, EncLenLen+9)
+			binary.BigEndian.PutUint32(encv[:], uint32(9))
+			encv[EncLenLen] = byte(d.scale)
+			binary.BigEndian.PutUint64(encv[EncLenLen+1:], uint64(d.val.Int64()))
+
+			return encv, nil
+		}
+	case JSONType:
+		{
+			jsonVal, ok := val.(string)
+			if !ok || !json.Valid([]byte(jsonVal)) {
+				return nil, fmt.Errorf(
+					"value is not a JSON document: %w", ErrInvalidValue,
+				)
+			}
+
+			if maxLen > 0 && len(jsonVal) > maxLen {
+				return nil, ErrMaxLengthExceeded
+			}
+
+			// len(v) + v
+			encv := make([]byte, EncLenLen+len(jsonVal))
+			binary.BigEndian.PutUint32(encv[:], uint32(len(jsonVal)))
+			copy(encv[EncLenLen:], []byte(jsonVal))
+
 			return encv, nil
 		}
 	case IntegerType:
@@ -1147,6 +2049,27 @@ func EncodeAsKey(val interface{}, colType SQLValueType, maxLen int) ([]byte, err
 			// map to unsigned integer space for lexical sorting order
 			encv[1] ^= 0x80
 
+			return encv[:], nil
+		}
+	case DecimalType:
+		{
+			if maxLen != 8 {
+				return nil, ErrCorruptedData
+			}
+
+			// values are expected to be of the scale of the column
+			d, err := encodableDecimal(val)
+			if err != nil {
+				return nil, err
+			}
+
+			// unscaled v
+			var encv [9]byte
+			encv[0] = KeyValPrefixNotNull
+			binary.BigEndian.PutUint64(encv[1:], uint64(d.val.Int64()))
+			// map to unsigned integer space for lexical sorting order
+			encv[1] ^= 0x80
+
 			return encv[:], nil
 		}
 	case BooleanType:
@@ -1220,6 +2143,27 @@ func EncodeAsKey(val interface{}, colType SQLValueType, maxLen int) ([]byte, err
 	return nil, ErrInvalidValue
 }
 
+// encodableDecimal parses the textual value of a DECIMAL, its unscaled value must fit into a 64-bit integer
+func encodableDecimal(val interface{}) (*Decimal, error) {
+	strVal, ok := val.(string)
+	if !ok {
+		return nil, fmt.Errorf(
+			"value is not a decimal: %w", ErrInvalidValue,
+		)
+	}
+
+	d, err := ParseDecimal(strVal)
+	if err != nil {
+		return nil, err
+	}
+
+	if !d.val.IsInt64() || d.scale > MaxDecimalPrecision {
+		return nil, ErrNumericValueOutOfRange
+	}
+
+	return d, nil
+}
+
 func DecodeValue(b []byte, colType SQLValueType) (TypedValue, int, error) {
 	if len(b) < EncLenLen {
 		return nil, 0, ErrCorruptedData
@@ -1269,6 +2213,16 @@ func DecodeValue(b []byte, colType SQLValueType) (TypedValue, int, error) {
 
 			return &Blob{val: v}, voff, nil
 		}
+	case JSONType:
+		{
+			v, err := ParseJSON(string(b[voff : voff+vlen]))
+			if err != nil {
+				return nil, 0, ErrCorruptedData
+			}
+			voff += vlen
+
+			return v, voff, nil
+		}
 	case TimestampType:
 		{
 			if vlen != 8 {
@@ -1280,6 +2234,22 @@ func DecodeValue(b []byte, colType SQLValueType) (TypedValue, int, error) {
 
 			return &Timestamp{val: TimeFromInt64(int64(v))}, voff, nil
 		}
+	case DecimalType:
+		{
+			if vlen != 9 {
+				return nil, 0, ErrCorruptedData
+			}
+
+			scale := int(b[voff])
+			if scale > MaxDecimalPrecision {
+				return nil, 0, ErrCorruptedData
+			}
+
+			v := binary.BigEndian.Uint64(b[voff+1:])
+			voff += vlen
+
+			return &Decimal{val: big.NewInt(int64(v)), scale: scale}, voff, nil
+		}
 	}
 
 	return nil, 0, ErrCorruptedData
@@ -87,7 +87,7 @@ func TestFromEmptyCatalog(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "table1", table.Name())
 
-	_, err = table.newIndex(true, []uint32{1})
+	_, err = table.newIndex(true, []uint32{1}, "", nil)
 	require.NoError(t, err)
 
 	tables := db.GetTables()
@@ -131,10 +131,10 @@ func TestFromEmptyCatalog(t *testing.T) {
 	_, err = table.GetColumnByID(3)
 	require.Equal(t, ErrColumnDoesNotExist, err)
 
-	_, err = table.newIndex(true, nil)
+	_, err = table.newIndex(true, nil, "", nil)
 	require.ErrorIs(t, err, ErrIllegalArguments)
 
-	_, err = table.newIndex(true, []uint32{1, 2, 1})
+	_, err = table.newIndex(true, []uint32{1, 2, 1}, "", nil)
 	require.ErrorIs(t, err, ErrDuplicatedColumn)
 
 }
@@ -0,0 +1,187 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const cursorVersion = 1
+
+const cursorHeaderLen = 1 + 8 + sha256.Size
+
+// CursorRowReader reads the rows of a query as they were at a given transaction, the position
+// reached by the rows already read is encoded into a cursor token, so reading can be resumed
+// from there by another query instead of skipping the rows read so far
+type CursorRowReader struct {
+	RowReader
+
+	scanReader *rawRowReader
+
+	snapshotTxID uint64
+	digest       [sha256.Size]byte
+	resumeAfter  []byte
+}
+
+// Cursor returns a token to resume reading right after the latest row read so far
+func (r *CursorRowReader) Cursor() []byte {
+	lastKey := r.scanReader.lastKey
+	if lastKey == nil {
+		lastKey = r.resumeAfter
+	}
+
+	cursor := make([]byte, cursorHeaderLen+len(lastKey))
+
+	cursor[0] = cursorVersion
+	binary.BigEndian.PutUint64(cursor[1:], r.snapshotTxID)
+	copy(cursor[9:], r.digest[:])
+	copy(cursor[cursorHeaderLen:], lastKey)
+
+	return cursor
+}
+
+// SnapshotTxID returns the transaction as of which rows are read
+func (r *CursorRowReader) SnapshotTxID() uint64 {
+	return r.snapshotTxID
+}
+
+// QueryWithCursor resolves a query which can be read in pages, each of them resuming from the
+// cursor returned by the previous one. A nil cursor starts reading as of the latest committed
+// transaction, tables without an explicit period are read as of that same transaction on every page.
+// The cursor is bound to the query and its parameters, only queries scanning a single table
+// with neither aggregations, grouping, distinct, limit nor offset are supported
+func (e *Engine) QueryWithCursor(ctx context.Context, tx *SQLTx, sql string, params map[string]interface{}, cursor []byte) (*CursorRowReader, error) {
+	stmts, err := Parse(strings.NewReader(sql))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrParsingError, err)
+	}
+	if len(stmts) != 1 {
+		return nil, ErrExpectingDQLStmt
+	}
+
+	stmt, ok := stmts[0].(*SelectStmt)
+	if !ok {
+		if _, ok := stmts[0].(DataSource); ok {
+			return nil, ErrCursorNotSupported
+		}
+		return nil, ErrExpectingDQLStmt
+	}
+
+	if len(stmt.joins) > 0 || stmt.limit > 0 || stmt.offset > 0 {
+		return nil, ErrCursorNotSupported
+	}
+
+	if _, ok := stmt.ds.(*tableRef); !ok {
+		return nil, ErrCursorNotSupported
+	}
+
+	nparams, err := normalizeParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := queryDigest(sql, nparams)
+
+	var snapshotTxID uint64
+	var resumeAfter []byte
+
+	if cursor == nil {
+		snapshotTxID = e.store.LastCommittedTxID()
+	} else {
+		if len(cursor) < cursorHeaderLen || cursor[0] != cursorVersion {
+			return nil, ErrInvalidCursor
+		}
+
+		snapshotTxID = binary.BigEndian.Uint64(cursor[1:])
+
+		if !bytes.Equal(cursor[9:cursorHeaderLen], digest[:]) {
+			return nil, fmt.Errorf("%w: cursor belongs to a different query", ErrInvalidCursor)
+		}
+
+		if len(cursor) > cursorHeaderLen {
+			resumeAfter = cursor[cursorHeaderLen:]
+		}
+	}
+
+	if snapshotTxID == 0 || snapshotTxID > e.store.LastCommittedTxID() {
+		return nil, ErrInvalidCursor
+	}
+
+	applyPeriod(stmt, period{asOf: &periodInstant{instantType: txInstant, exp: &Number{val: int64(snapshotTxID)}}})
+
+	stmt.resumeAfter = resumeAfter
+
+	r, err := e.QueryPreparedStmt(ctx, tx, stmt, nparams)
+	if err != nil {
+		return nil, err
+	}
+
+	scanReader, ok := scanRowReader(r)
+	if !ok || scanReader.tableAlias != stmt.ds.Alias() {
+		r.Close()
+		return nil, ErrCursorNotSupported
+	}
+
+	return &CursorRowReader{
+		RowReader:    r,
+		scanReader:   scanReader,
+		snapshotTxID: snapshotTxID,
+		digest:       digest,
+		resumeAfter:  resumeAfter,
+	}, nil
+}
+
+// scanRowReader returns the reader scanning the table when each row read from rowReader
+// corresponds to the latest one scanned
+func scanRowReader(rowReader RowReader) (*rawRowReader, bool) {
+	switch r := rowReader.(type) {
+	case *rawRowReader:
+		return r, true
+	case *conditionalRowReader:
+		return scanRowReader(r.rowReader)
+	case *projectedRowReader:
+		return scanRowReader(r.rowReader)
+	}
+
+	return nil, false
+}
+
+func queryDigest(sql string, params map[string]interface{}) [sha256.Size]byte {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	h.Write([]byte(sql))
+
+	for _, name := range names {
+		fmt.Fprintf(h, "\x00%s=%T:%v", name, params[name], params[name])
+	}
+
+	var digest [sha256.Size]byte
+	copy(digest[:], h.Sum(nil))
+
+	return digest
+}
@@ -0,0 +1,345 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// MaxDecimalPrecision is the max number of digits of DECIMAL values, as their unscaled value is stored as a 64-bit integer
+const MaxDecimalPrecision = 18
+
+// decimalDivScale is the min number of fractional digits of the result of a division
+const decimalDivScale = 6
+
+var bigOne = big.NewInt(1)
+var bigTen = big.NewInt(10)
+
+// bounds of the unscaled values which can be encoded as index keys
+var minEncodableDecimal = big.NewInt(math.MinInt64)
+var maxEncodableDecimal = big.NewInt(math.MaxInt64)
+
+// Decimal is an exact fixed-point number whose value is val * 10^(-scale).
+// Its value is exposed as its textual representation, including all of its fractional digits
+type Decimal struct {
+	val   *big.Int
+	scale int
+}
+
+func ParseDecimal(s string) (*Decimal, error) {
+	str := s
+
+	neg := strings.HasPrefix(str, "-")
+	if neg || strings.HasPrefix(str, "+") {
+		str = str[1:]
+	}
+
+	intPart, fracPart := str, ""
+
+	dotPos := strings.IndexByte(str, '.')
+	if dotPos >= 0 {
+		intPart, fracPart = str[:dotPos], str[dotPos+1:]
+	}
+
+	digits := intPart + fracPart
+
+	if len(digits) == 0 {
+		return nil, fmt.Errorf("%w: can not interpret '%s' as a DECIMAL", ErrInvalidValue, s)
+	}
+
+	for _, ch := range digits {
+		if ch < '0' || ch > '9' {
+			return nil, fmt.Errorf("%w: can not interpret '%s' as a DECIMAL", ErrInvalidValue, s)
+		}
+	}
+
+	val, _ := new(big.Int).SetString(digits, 10)
+	if neg {
+		val.Neg(val)
+	}
+
+	return &Decimal{val: val, scale: len(fracPart)}, nil
+}
+
+func decimalFromInt(n int64) *Decimal {
+	return &Decimal{val: big.NewInt(n)}
+}
+
+// asDecimal returns the decimal representation of INTEGER and DECIMAL values
+func asDecimal(val TypedValue) (*Decimal, bool) {
+	if val.IsNull() {
+		return nil, false
+	}
+
+	switch val.Type() {
+	case IntegerType:
+		{
+			return decimalFromInt(val.Value().(int64)), true
+		}
+	case DecimalType:
+		{
+			d, ok := val.(*Decimal)
+			if ok {
+				return d, true
+			}
+
+			// e.g. aggregated values
+			d, err := ParseDecimal(val.Value().(string))
+			return d, err == nil
+		}
+	}
+
+	return nil, false
+}
+
+func isNumericType(t SQLValueType) bool {
+	return t == IntegerType || t == DecimalType
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// quoRound returns n/d rounded half away from zero
+func quoRound(n, d *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+
+	r.Abs(r).Lsh(r, 1)
+
+	if r.CmpAbs(d) >= 0 {
+		if n.Sign() == d.Sign() {
+			q.Add(q, bigOne)
+		} else {
+			q.Sub(q, bigOne)
+		}
+	}
+
+	return q
+}
+
+// rescale returns the value with the given number of fractional digits, rounding half away from zero when digits are dropped
+func (v *Decimal) rescale(scale int) *Decimal {
+	if scale >= v.scale {
+		return &Decimal{val: new(big.Int).Mul(v.val, pow10(scale-v.scale)), scale: scale}
+	}
+
+	return &Decimal{val: quoRound(v.val, pow10(v.scale-scale)), scale: scale}
+}
+
+// digits returns the number of digits of the value, including its fractional digits
+func (v *Decimal) digits() int {
+	return len(new(big.Int).Abs(v.val).String())
+}
+
+func (v *Decimal) add(r *Decimal) *Decimal {
+	scale := maxInt(v.scale, r.scale)
+	return &Decimal{val: new(big.Int).Add(v.rescale(scale).val, r.rescale(scale).val), scale: scale}
+}
+
+func (v *Decimal) sub(r *Decimal) *Decimal {
+	scale := maxInt(v.scale, r.scale)
+	return &Decimal{val: new(big.Int).Sub(v.rescale(scale).val, r.rescale(scale).val), scale: scale}
+}
+
+func (v *Decimal) mul(r *Decimal) *Decimal {
+	return &Decimal{val: new(big.Int).Mul(v.val, r.val), scale: v.scale + r.scale}
+}
+
+// quo returns v/r rounded to the largest scale of the operands, with no less than decimalDivScale fractional digits.
+// The divisor must not be zero
+func (v *Decimal) quo(r *Decimal) *Decimal {
+	scale := maxInt(maxInt(v.scale, r.scale), decimalDivScale)
+
+	n := new(big.Int).Mul(v.val, pow10(scale+r.scale-v.scale))
+
+	return &Decimal{val: quoRound(n, r.val), scale: scale}
+}
+
+func (v *Decimal) cmp(r *Decimal) int {
+	scale := maxInt(v.scale, r.scale)
+	return v.rescale(scale).val.Cmp(r.rescale(scale).val)
+}
+
+func (v *Decimal) String() string {
+	digits := new(big.Int).Abs(v.val).String()
+
+	if v.scale > 0 {
+		if len(digits) <= v.scale {
+			digits = strings.Repeat("0", v.scale-len(digits)+1) + digits
+		}
+
+		digits = digits[:len(digits)-v.scale] + "." + digits[len(digits)-v.scale:]
+	}
+
+	if v.val.Sign() < 0 {
+		return "-" + digits
+	}
+
+	return digits
+}
+
+func (v *Decimal) Type() SQLValueType {
+	return DecimalType
+}
+
+func (v *Decimal) IsNull() bool {
+	return false
+}
+
+func (v *Decimal) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return DecimalType, nil
+}
+
+func (v *Decimal) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != DecimalType {
+		return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, DecimalType, t)
+	}
+
+	return nil
+}
+
+func (v *Decimal) substitute(params map[string]interface{}) (ValueExp, error) {
+	return v, nil
+}
+
+func (v *Decimal) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return v, nil
+}
+
+func (v *Decimal) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *Decimal) isConstant() bool {
+	return true
+}
+
+func (v *Decimal) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+func (v *Decimal) Value() interface{} {
+	return v.String()
+}
+
+func (v *Decimal) Compare(val TypedValue) (int, error) {
+	if val.IsNull() {
+		return 1, nil
+	}
+
+	rval, ok := asDecimal(val)
+	if !ok {
+		return 0, ErrNotComparableValues
+	}
+
+	return v.cmp(rval), nil
+}
+
+// reduceDecimalExp evaluates arithmetic operations involving DECIMAL values
+func reduceDecimalExp(op NumOperator, vl, vr TypedValue) (TypedValue, error) {
+	dl, ok := asDecimal(vl)
+	if !ok {
+		return nil, fmt.Errorf("%w (expecting numeric value)", ErrInvalidValue)
+	}
+
+	dr, ok := asDecimal(vr)
+	if !ok {
+		return nil, fmt.Errorf("%w (expecting numeric value)", ErrInvalidValue)
+	}
+
+	switch op {
+	case ADDOP:
+		{
+			return dl.add(dr), nil
+		}
+	case SUBSOP:
+		{
+			return dl.sub(dr), nil
+		}
+	case DIVOP:
+		{
+			if dr.val.Sign() == 0 {
+				return nil, ErrDivisionByZero
+			}
+
+			return dl.quo(dr), nil
+		}
+	case MULTOP:
+		{
+			return dl.mul(dr), nil
+		}
+	}
+
+	return nil, ErrUnexpected
+}
+
+// decimalForColumn rounds the value to the scale of the column,
+// values with more digits than the precision of the column are rejected
+func decimalForColumn(col *Column, val TypedValue) (*Decimal, error) {
+	d, ok := asDecimal(val)
+	if !ok {
+		return nil, fmt.Errorf("%w (expecting numeric value)", ErrInvalidValue)
+	}
+
+	d = d.rescale(col.scale)
+
+	if d.digits() > col.precision {
+		return nil, fmt.Errorf("%w (%s)", ErrNumericValueOutOfRange, col.colName)
+	}
+
+	return d, nil
+}
+
+// decimalKeyBound converts a range bound into a value of the scale of the column,
+// rounding outwards so all the values satisfying the condition are within the range.
+// Bounds beyond the encodable values are clamped to the min or max encodable value
+func decimalKeyBound(col *Column, val TypedValue, upper bool) TypedValue {
+	d, ok := asDecimal(val)
+	if !ok {
+		return val
+	}
+
+	bound := d.rescale(col.scale)
+
+	if upper && bound.cmp(d) < 0 {
+		bound.val.Add(bound.val, bigOne)
+	}
+
+	if !upper && bound.cmp(d) > 0 {
+		bound.val.Sub(bound.val, bigOne)
+	}
+
+	if bound.val.Cmp(minEncodableDecimal) < 0 {
+		bound.val.Set(minEncodableDecimal)
+	}
+
+	if bound.val.Cmp(maxEncodableDecimal) > 0 {
+		bound.val.Set(maxEncodableDecimal)
+	}
+
+	return bound
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func mustParseDecimal(t *testing.T, s string) *Decimal {
+	d, err := ParseDecimal(s)
+	require.NoError(t, err)
+	return d
+}
+
+func TestParseDecimal(t *testing.T) {
+	for _, d := range []struct {
+		s     string
+		str   string
+		scale int
+	}{
+		{"0", "0", 0},
+		{"10.10", "10.10", 2},
+		{"-0.05", "-0.05", 2},
+		{"+3.", "3", 0},
+		{".5", "0.5", 1},
+		{"12345678901234567890.1", "12345678901234567890.1", 1},
+	} {
+		t.Run(d.s, func(t *testing.T) {
+			v := mustParseDecimal(t, d.s)
+			require.Equal(t, d.str, v.Value())
+			require.Equal(t, d.scale, v.scale)
+		})
+	}
+
+	for _, s := range []string{"", "-", ".", "1.2.3", "1e5", "0x10", " 1"} {
+		_, err := ParseDecimal(s)
+		require.ErrorIs(t, err, ErrInvalidValue)
+	}
+}
+
+func TestDecimalRescale(t *testing.T) {
+	for _, d := range []struct {
+		s     string
+		scale int
+		res   string
+	}{
+		{"1.005", 2, "1.01"},
+		{"1.004", 2, "1.00"},
+		{"-1.005", 2, "-1.01"},
+		{"-1.004", 2, "-1.00"},
+		{"0.5", 0, "1"},
+		{"-0.5", 0, "-1"},
+		{"2", 3, "2.000"},
+	} {
+		require.Equal(t, d.res, mustParseDecimal(t, d.s).rescale(d.scale).Value())
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	for _, d := range []struct {
+		op  NumOperator
+		l   string
+		r   string
+		res string
+	}{
+		{ADDOP, "0.1", "0.2", "0.3"},
+		{ADDOP, "10.10", "-0.105", "9.995"},
+		{SUBSOP, "1", "0.01", "0.99"},
+		{MULTOP, "1.5", "-1.25", "-1.875"},
+		{DIVOP, "1", "3", "0.333333"},
+		{DIVOP, "2", "3", "0.666667"},
+		{DIVOP, "-10.00", "4", "-2.500000"},
+		{DIVOP, "1.0000000", "8", "0.1250000"},
+	} {
+		res, err := reduceDecimalExp(d.op, mustParseDecimal(t, d.l), mustParseDecimal(t, d.r))
+		require.NoError(t, err)
+		require.Equal(t, d.res, res.Value())
+	}
+
+	res, err := reduceDecimalExp(ADDOP, &Number{val: 1}, mustParseDecimal(t, "0.5"))
+	require.NoError(t, err)
+	require.Equal(t, "1.5", res.Value())
+
+	_, err = reduceDecimalExp(DIVOP, mustParseDecimal(t, "1.5"), &Number{val: 0})
+	require.ErrorIs(t, err, ErrDivisionByZero)
+
+	_, err = reduceDecimalExp(ADDOP, mustParseDecimal(t, "1.5"), &Varchar{val: "1"})
+	require.ErrorIs(t, err, ErrInvalidValue)
+}
+
+func TestDecimalCompare(t *testing.T) {
+	cmp, err := mustParseDecimal(t, "1.10").Compare(mustParseDecimal(t, "1.1"))
+	require.NoError(t, err)
+	require.Zero(t, cmp)
+
+	cmp, err = mustParseDecimal(t, "-1.5").Compare(&Number{val: -1})
+	require.NoError(t, err)
+	require.Equal(t, -1, cmp)
+
+	cmp, err = (&Number{val: 2}).Compare(mustParseDecimal(t, "1.99"))
+	require.NoError(t, err)
+	require.Equal(t, 1, cmp)
+
+	cmp, err = mustParseDecimal(t, "0").Compare(&NullValue{t: DecimalType})
+	require.NoError(t, err)
+	require.Equal(t, 1, cmp)
+
+	_, err = mustParseDecimal(t, "0").Compare(&Varchar{val: "0"})
+	require.ErrorIs(t, err, ErrNotComparableValues)
+}
+
+func TestDecimalKeyBound(t *testing.T) {
+	col := &Column{colName: "amount", colType: DecimalType, precision: 10, scale: 2}
+
+	require.Equal(t, "1.00", decimalKeyBound(col, mustParseDecimal(t, "1.004"), false).Value())
+	require.Equal(t, "1.01", decimalKeyBound(col, mustParseDecimal(t, "1.004"), true).Value())
+	require.Equal(t, "-1.01", decimalKeyBound(col, mustParseDecimal(t, "-1.005"), false).Value())
+	require.Equal(t, "-1.00", decimalKeyBound(col, mustParseDecimal(t, "-1.005"), true).Value())
+	require.Equal(t, "5.00", decimalKeyBound(col, &Number{val: 5}, true).Value())
+
+	// bounds beyond the encodable values are clamped
+	require.Equal(t, "92233720368547758.07", decimalKeyBound(col, mustParseDecimal(t, "100000000000000000000"), true).Value())
+	require.Equal(t, "-92233720368547758.08", decimalKeyBound(col, mustParseDecimal(t, "-100000000000000000000"), false).Value())
+
+	_, err := decimalForColumn(col, mustParseDecimal(t, "123456789.123"))
+	require.ErrorIs(t, err, ErrNumericValueOutOfRange)
+
+	d, err := decimalForColumn(col, mustParseDecimal(t, "12345678.123"))
+	require.NoError(t, err)
+	require.Equal(t, "12345678.12", d.Value())
+}
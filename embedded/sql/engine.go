@@ -18,7 +18,6 @@ package sql
 
 import (
 	"context"
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -59,7 +58,6 @@ var ErrInvalidValue = errors.New("invalid value provided")
 var ErrInferredMultipleTypes = errors.New("inferred multiple types")
 var ErrExpectingDQLStmt = errors.New("illegal statement. DQL statement expected")
 var ErrLimitedOrderBy = errors.New("order is limit to one indexed column")
-var ErrLimitedGroupBy = errors.New("group by requires ordering by the grouping column")
 var ErrIllegalMappedKey = errors.New("error illegal mapped key")
 var ErrCorruptedData = store.ErrCorruptedData
 var ErrNoMoreRows = store.ErrNoMoreEntries
@@ -87,6 +85,26 @@ var ErrAlreadyClosed = store.ErrAlreadyClosed
 var ErrAmbiguousSelector = errors.New("ambiguous selector")
 var ErrUnsupportedCast = errors.New("unsupported cast")
 var ErrColumnMismatchInUnionStmt = errors.New("column mismatch in union statement")
+var ErrColumnMismatchInSetOpStmt = errors.New("column mismatch in set operation")
+var ErrViewAlreadyExists = errors.New("view already exists")
+var ErrViewDoesNotExist = errors.New("view does not exist")
+var ErrRecursiveViewDefinition = errors.New("recursive view definition")
+var ErrParameterizedView = errors.New("views can not be parameterized")
+var ErrViewIsReferenced = errors.New("view is referenced by another view")
+var ErrLimitedPrecision = errors.New("only DECIMAL type supports precision and scale, with up to 18 digits")
+var ErrNumericValueOutOfRange = errors.New("numeric value out of range")
+var ErrColumnIsIndexed = errors.New("column is indexed")
+var ErrLimitedColumnTypeChange = errors.New("only increasing the max length or the precision of columns is supported")
+var ErrColumnIsReferenced = errors.New("column is referenced by a constraint")
+var ErrColumnIsUsedByView = errors.New("column is used by a view")
+var ErrCheckConstraintViolation = errors.New("check constraint violation")
+var ErrForeignKeyViolation = errors.New("foreign key constraint violation")
+var ErrInvalidForeignKey = errors.New("foreign key must reference the primary key of a table")
+var ErrParameterizedCheck = errors.New("check constraints can not be parameterized")
+var ErrParameterizedIndexPredicate = errors.New("index predicates can not be parameterized")
+var ErrInvalidConflictTarget = errors.New("conflict target must be the primary key or a unique index")
+var ErrInvalidCursor = errors.New("invalid cursor")
+var ErrCursorNotSupported = errors.New("cursors are only supported by queries scanning a single table without aggregations, distinct, limit or offset")
 
 var maxKeyLen = 256
 
@@ -622,6 +640,102 @@ func (d *Database) addTablesToTx(sqlPrefix []byte, tx *store.OngoingTx) error {
 	return nil
 }
 
+// addViewsToTx adds the view definitions of the database to the given transaction.
+func (d *Database) addViewsToTx(sqlPrefix []byte, tx *store.OngoingTx) error {
+	viewReaderSpec := store.KeyReaderSpec{
+		Prefix:  mapKey(sqlPrefix, catalogViewPrefix, EncodeID(d.id)),
+		Filters: []store.FilterFn{store.IgnoreExpired, store.IgnoreDeleted},
+	}
+
+	viewReader, err := tx.NewKeyReader(viewReaderSpec)
+	if err != nil {
+		return err
+	}
+	defer viewReader.Close()
+
+	for {
+		mkey, vref, err := viewReader.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		dbID, _, err := unmapViewID(sqlPrefix, mkey)
+		if err != nil {
+			return err
+		}
+
+		if dbID != d.id {
+			return ErrCorruptedData
+		}
+
+		v, err := vref.Resolve()
+		if err == io.EOF {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.Set(mkey, nil, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addConstraintsToTx adds the table constraints of the database to the given transaction.
+func (d *Database) addConstraintsToTx(sqlPrefix []byte, tx *store.OngoingTx) error {
+	constraintReaderSpec := store.KeyReaderSpec{
+		Prefix:  mapKey(sqlPrefix, catalogConstraintPrefix, EncodeID(d.id)),
+		Filters: []store.FilterFn{store.IgnoreExpired, store.IgnoreDeleted},
+	}
+
+	constraintReader, err := tx.NewKeyReader(constraintReaderSpec)
+	if err != nil {
+		return err
+	}
+	defer constraintReader.Close()
+
+	for {
+		mkey, vref, err := constraintReader.Read()
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		dbID, _, _, err := unmapConstraint(sqlPrefix, mkey)
+		if err != nil {
+			return err
+		}
+
+		if dbID != d.id {
+			return ErrCorruptedData
+		}
+
+		v, err := vref.Resolve()
+		if err == io.EOF {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.Set(mkey, nil, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // addSchemaToTx adds the schema of the catalog to the given transaction.
 func (c *Catalog) addSchemaToTx(sqlPrefix []byte, tx *store.OngoingTx) error {
 	dbReaderSpec := store.KeyReaderSpec{
@@ -674,6 +788,18 @@ func (c *Catalog) addSchemaToTx(sqlPrefix []byte, tx *store.OngoingTx) error {
 			return err
 		}
 
+		// read views into tx
+		err = db.addViewsToTx(sqlPrefix, tx)
+		if err != nil {
+			return err
+		}
+
+		// read constraints into tx
+		err = db.addConstraintsToTx(sqlPrefix, tx)
+		if err != nil {
+			return err
+		}
+
 	}
 
 	return nil
@@ -727,15 +853,7 @@ func addColSpecsToTx(dbID, tableID uint32, tx *store.OngoingTx, sqlPrefix []byte
 			return nil, err
 		}
 
-		spec := &ColSpec{
-			colName:       string(v[5:]),
-			colType:       colType,
-			maxLen:        int(binary.BigEndian.Uint32(v[1:])),
-			autoIncrement: v[0]&autoIncrementFlag != 0,
-			notNull:       v[0]&nullableFlag != 0,
-		}
-
-		specs = append(specs, spec)
+		specs = append(specs, decodeColSpec(colType, v))
 
 		if int(colID) != len(specs) {
 			return nil, ErrCorruptedData
@@ -19,9 +19,11 @@ package sql
 import (
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
+	"math/big"
 	"strings"
 	"sync"
 	"testing"
@@ -638,6 +640,675 @@ func TestRenameColumn(t *testing.T) {
 	})
 }
 
+func TestDropColumn(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("create-store", func(t *testing.T) {
+		st, err := store.Open(dir, store.DefaultOptions())
+		require.NoError(t, err)
+		defer closeStore(t, st)
+
+		engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 DROP COLUMN name", nil)
+		require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE DATABASE db1", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "USE DATABASE db1", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, name VARCHAR[50], age INTEGER, PRIMARY KEY id)", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON table1(age)", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1(name, age) VALUES('John', 30), ('Sylvia', 25)", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table2 DROP COLUMN name", nil)
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 DROP COLUMN surname", nil)
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 DROP COLUMN id", nil)
+		require.ErrorIs(t, err, ErrColumnIsIndexed)
+
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 DROP COLUMN age", nil)
+		require.ErrorIs(t, err, ErrColumnIsIndexed)
+
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 DROP COLUMN name", nil)
+		require.NoError(t, err)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT name FROM table1", nil)
+		require.NoError(t, err)
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1(name, age) VALUES('Robocop', 40)", nil)
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 ADD COLUMN name INTEGER", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1(name, age) VALUES(10, 40)", nil)
+		require.NoError(t, err)
+
+		assertRows := func(engine *Engine) {
+			r, err := engine.Query(context.Background(), nil, "SELECT * FROM table1", nil)
+			require.NoError(t, err)
+			defer r.Close()
+
+			cols, err := r.Columns(context.Background())
+			require.NoError(t, err)
+			require.Len(t, cols, 3)
+			require.Equal(t, "(db1.table1.name)", cols[2].Selector())
+
+			row, err := r.Read(context.Background())
+			require.NoError(t, err)
+			require.EqualValues(t, 1, row.ValuesByPosition[0].Value())
+			require.EqualValues(t, 30, row.ValuesByPosition[1].Value())
+			require.True(t, row.ValuesByPosition[2].IsNull())
+
+			row, err = r.Read(context.Background())
+			require.NoError(t, err)
+			require.EqualValues(t, 2, row.ValuesByPosition[0].Value())
+			require.True(t, row.ValuesByPosition[2].IsNull())
+
+			row, err = r.Read(context.Background())
+			require.NoError(t, err)
+			require.EqualValues(t, 3, row.ValuesByPosition[0].Value())
+			require.EqualValues(t, 40, row.ValuesByPosition[1].Value())
+			require.EqualValues(t, 10, row.ValuesByPosition[2].Value())
+
+			_, err = r.Read(context.Background())
+			require.ErrorIs(t, err, ErrNoMoreRows)
+
+			catalog, err := engine.Catalog(context.Background(), nil)
+			require.NoError(t, err)
+
+			db, err := catalog.GetDatabaseByName("db1")
+			require.NoError(t, err)
+
+			table, err := db.GetTableByName("table1")
+			require.NoError(t, err)
+			require.Len(t, table.DroppedColsByID(), 1)
+
+			col, err := table.GetColumnByName("name")
+			require.NoError(t, err)
+			require.Equal(t, uint32(4), col.ID())
+			require.Equal(t, IntegerType, col.Type())
+		}
+
+		assertRows(engine)
+
+		engine, err = NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "USE DATABASE db1", nil)
+		require.NoError(t, err)
+
+		assertRows(engine)
+	})
+}
+
+func TestAlterColumn(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+	defer closeStore(t, st)
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE DATABASE db1", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "USE DATABASE db1", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table1 (id INTEGER, name VARCHAR[5], code VARCHAR[10], rate DECIMAL(4,2), PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON table1(code)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1(id, name, rate) VALUES(1, 'John', 12.34)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1(id, name) VALUES(2, 'Robocop')", nil)
+	require.ErrorIs(t, err, ErrMaxLengthExceeded)
+
+	_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 ALTER COLUMN surname VARCHAR[10]", nil)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+	_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 ALTER COLUMN name INTEGER", nil)
+	require.ErrorIs(t, err, ErrLimitedColumnTypeChange)
+
+	_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 ALTER COLUMN name VARCHAR[3]", nil)
+	require.ErrorIs(t, err, ErrLimitedColumnTypeChange)
+
+	_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 ALTER COLUMN name VARCHAR[10] NOT NULL", nil)
+	require.ErrorIs(t, err, ErrLimitedColumnTypeChange)
+
+	_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 ALTER COLUMN code VARCHAR[20]", nil)
+	require.ErrorIs(t, err, ErrColumnIsIndexed)
+
+	_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 ALTER COLUMN rate DECIMAL(3,2)", nil)
+	require.ErrorIs(t, err, ErrLimitedColumnTypeChange)
+
+	_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 ALTER COLUMN rate DECIMAL(6,3)", nil)
+	require.ErrorIs(t, err, ErrLimitedColumnTypeChange)
+
+	_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 ALTER COLUMN name VARCHAR[10]", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 ALTER COLUMN rate DECIMAL(6,2)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1(id, name, rate) VALUES(2, 'Robocop', 1234.56)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 ALTER COLUMN name VARCHAR", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 ALTER COLUMN name VARCHAR[100]", nil)
+	require.ErrorIs(t, err, ErrLimitedColumnTypeChange)
+
+	r, err := engine.Query(context.Background(), nil, "SELECT name, rate FROM table1", nil)
+	require.NoError(t, err)
+	defer r.Close()
+
+	row, err := r.Read(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "John", row.ValuesByPosition[0].Value())
+	require.Equal(t, "12.34", row.ValuesByPosition[1].Value())
+
+	row, err = r.Read(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "Robocop", row.ValuesByPosition[0].Value())
+	require.Equal(t, "1234.56", row.ValuesByPosition[1].Value())
+
+	_, err = r.Read(context.Background())
+	require.ErrorIs(t, err, ErrNoMoreRows)
+}
+
+func TestConstraints(t *testing.T) {
+	dir := t.TempDir()
+
+	st, err := store.Open(dir, store.DefaultOptions())
+	require.NoError(t, err)
+	defer closeStore(t, st)
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE DATABASE db1; USE DATABASE db1;", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE customers (id INTEGER AUTO_INCREMENT, name VARCHAR, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE orders (id INTEGER, amount INTEGER, PRIMARY KEY id, CHECK (amount > @min))", nil)
+	require.ErrorIs(t, err, ErrParameterizedCheck)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE orders (id INTEGER, amount INTEGER, PRIMARY KEY id, CHECK (amount + 1))", nil)
+	require.ErrorIs(t, err, ErrInvalidTypes)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE orders (id INTEGER, amount INTEGER, PRIMARY KEY id, CHECK (total > 0))", nil)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE orders (id INTEGER, customer_id VARCHAR, PRIMARY KEY id, FOREIGN KEY (customer_id) REFERENCES customers)", nil)
+	require.ErrorIs(t, err, ErrInvalidForeignKey)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE orders (id INTEGER, customer_id INTEGER, PRIMARY KEY id, FOREIGN KEY (customer_id) REFERENCES customers(name))", nil)
+	require.ErrorIs(t, err, ErrInvalidForeignKey)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE orders (id INTEGER, customer_id INTEGER, PRIMARY KEY id, FOREIGN KEY (customer_id) REFERENCES suppliers)", nil)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE TABLE orders (
+			id INTEGER AUTO_INCREMENT,
+			customer_id INTEGER,
+			parent_id INTEGER,
+			amount INTEGER NOT NULL,
+			discount INTEGER,
+			PRIMARY KEY id,
+			CHECK (amount > 0),
+			CHECK (discount <= amount),
+			FOREIGN KEY (customer_id) REFERENCES customers(id),
+			FOREIGN KEY (parent_id) REFERENCES orders
+		)`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO customers (name) VALUES ('John'), ('Sylvia')", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO orders (customer_id, amount) VALUES (1, 0)", nil)
+	require.ErrorIs(t, err, ErrCheckConstraintViolation)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO orders (customer_id, amount, discount) VALUES (1, 10, 20)", nil)
+	require.ErrorIs(t, err, ErrCheckConstraintViolation)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO orders (customer_id, amount) VALUES (3, 10)", nil)
+	require.ErrorIs(t, err, ErrForeignKeyViolation)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO orders (customer_id, parent_id, amount) VALUES (1, 1000, 10)", nil)
+	require.ErrorIs(t, err, ErrForeignKeyViolation)
+
+	// checks evaluating to NULL and foreign keys with NULL values are satisfied
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO orders (amount) VALUES (10)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO orders (customer_id, amount, discount) VALUES (1, 20, 5)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO orders (customer_id, parent_id, amount) VALUES (2, 2, 30)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO orders (id, customer_id, parent_id, amount) VALUES (10, 2, 10, 30)", nil)
+	require.NoError(t, err)
+
+	t.Run("checks comparing NULL values are satisfied", func(t *testing.T) {
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE items (id INTEGER AUTO_INCREMENT, q INTEGER, p INTEGER, PRIMARY KEY id, CHECK (q > 0), CHECK (q > 0 AND p > 0))", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO items (q, p) VALUES (NULL, NULL)", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO items (p) VALUES (1)", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO items (q) VALUES (0)", nil)
+		require.ErrorIs(t, err, ErrCheckConstraintViolation)
+
+		// the known operand makes the conjunction false
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO items (p) VALUES (-1)", nil)
+		require.ErrorIs(t, err, ErrCheckConstraintViolation)
+
+		_, _, err = engine.Exec(context.Background(), nil, "UPDATE items SET q = NULL, p = 5 WHERE id = 1", nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("updates are validated", func(t *testing.T) {
+		_, _, err = engine.Exec(context.Background(), nil, "UPDATE orders SET discount = 50 WHERE id = 2", nil)
+		require.ErrorIs(t, err, ErrCheckConstraintViolation)
+
+		_, _, err = engine.Exec(context.Background(), nil, "UPDATE orders SET customer_id = 5 WHERE id = 2", nil)
+		require.ErrorIs(t, err, ErrForeignKeyViolation)
+
+		_, _, err = engine.Exec(context.Background(), nil, "UPDATE orders SET discount = 10 WHERE id = 2", nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("referenced rows can not be deleted", func(t *testing.T) {
+		_, _, err = engine.Exec(context.Background(), nil, "DELETE FROM customers WHERE id = 1", nil)
+		require.ErrorIs(t, err, ErrForeignKeyViolation)
+
+		_, _, err = engine.Exec(context.Background(), nil, "DELETE FROM orders WHERE id = 2", nil)
+		require.ErrorIs(t, err, ErrForeignKeyViolation)
+
+		// order 10 references itself
+		_, _, err = engine.Exec(context.Background(), nil, "DELETE FROM orders WHERE id = 10", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "BEGIN TRANSACTION; DELETE FROM orders WHERE id = 3; DELETE FROM orders WHERE id = 2; COMMIT;", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "DELETE FROM customers", nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("constrained columns can not be dropped nor renamed", func(t *testing.T) {
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE orders DROP COLUMN discount", nil)
+		require.ErrorIs(t, err, ErrColumnIsReferenced)
+
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE orders RENAME COLUMN amount TO total", nil)
+		require.ErrorIs(t, err, ErrColumnIsReferenced)
+
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE orders DROP COLUMN customer_id", nil)
+		require.ErrorIs(t, err, ErrColumnIsReferenced)
+
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE orders RENAME COLUMN customer_id TO client_id", nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("constraints are persisted", func(t *testing.T) {
+		engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		err = engine.SetCurrentDatabase(context.Background(), "db1")
+		require.NoError(t, err)
+
+		catalog, err := engine.Catalog(context.Background(), nil)
+		require.NoError(t, err)
+
+		table, err := catalog.GetTableByName("db1", "orders")
+		require.NoError(t, err)
+		require.Len(t, table.Checks(), 2)
+		require.Equal(t, "amount > 0", table.Checks()[0].SQL())
+		require.Equal(t, "discount <= amount", table.Checks()[1].SQL())
+		require.Len(t, table.ForeignKeys(), 2)
+		require.Equal(t, "customers", table.ForeignKeys()[0].ReferencedTable().Name())
+		require.Equal(t, "client_id", table.ForeignKeys()[0].Cols()[0].Name())
+		require.Equal(t, "orders", table.ForeignKeys()[1].ReferencedTable().Name())
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO customers (name) VALUES ('Robocop')", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO orders (client_id, amount) VALUES (1, 0)", nil)
+		require.ErrorIs(t, err, ErrCheckConstraintViolation)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO orders (client_id, amount) VALUES (1, 10)", nil)
+		require.ErrorIs(t, err, ErrForeignKeyViolation)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO orders (client_id, amount) VALUES (3, 10)", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "DELETE FROM customers WHERE id = 3", nil)
+		require.ErrorIs(t, err, ErrForeignKeyViolation)
+	})
+}
+
+func TestPartialIndexes(t *testing.T) {
+	dir := t.TempDir()
+
+	st, err := store.Open(dir, store.DefaultOptions())
+	require.NoError(t, err)
+	defer closeStore(t, st)
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE DATABASE db1;
+		USE DATABASE db1;
+		CREATE TABLE items (id INTEGER AUTO_INCREMENT, sku VARCHAR[20], deleted BOOLEAN, qty INTEGER, PRIMARY KEY id);
+	`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON items(sku) WHERE deleted = @deleted", nil)
+	require.ErrorIs(t, err, ErrParameterizedIndexPredicate)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON items(sku) WHERE qty + 1", nil)
+	require.ErrorIs(t, err, ErrInvalidTypes)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON items(sku) WHERE price > 0", nil)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE UNIQUE INDEX ON items(sku) WHERE deleted = false;
+		CREATE INDEX ON items(qty) WHERE deleted = false AND qty > 0;
+	`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON items(sku)", nil)
+	require.ErrorIs(t, err, ErrIndexAlreadyExists)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE INDEX IF NOT EXISTS ON items(sku) WHERE deleted = true", nil)
+	require.NoError(t, err)
+
+	explain := func(query string) []string {
+		r, err := engine.Query(context.Background(), nil, query, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var plan []string
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			plan = append(plan, row.ValuesByPosition[0].Value().(string))
+		}
+
+		return plan
+	}
+
+	readIDs := func(query string) []int64 {
+		r, err := engine.Query(context.Background(), nil, query, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var ids []int64
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			ids = append(ids, row.ValuesByPosition[0].Value().(int64))
+		}
+
+		return ids
+	}
+
+	t.Run("uniqueness should only be enforced among indexed rows", func(t *testing.T) {
+		_, _, err = engine.Exec(context.Background(), nil, `
+			INSERT INTO items (sku, deleted, qty) VALUES ('a', false, 1), ('a', true, 2), ('a', true, 3), ('b', NULL, 4), ('b', NULL, 5)
+		`, nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO items (sku, deleted, qty) VALUES ('a', false, 6)", nil)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+		_, _, err = engine.Exec(context.Background(), nil, "UPDATE items SET deleted = true WHERE sku = 'a' AND deleted = false", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO items (sku, deleted, qty) VALUES ('a', false, 6)", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "UPDATE items SET deleted = false WHERE id = 2", nil)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+		_, _, err = engine.Exec(context.Background(), nil, "UPDATE items SET deleted = false WHERE id = 4", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "UPDATE items SET deleted = false WHERE id = 5", nil)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+	})
+
+	t.Run("partial indexes should be used when the query implies their predicate", func(t *testing.T) {
+		require.Equal(t, []string{
+			"Project id",
+			"-> Filter ((sku = 'a') AND (deleted = FALSE))",
+			"  -> Range scan items using index items[sku] ASC (sku = 'a') on rows where deleted = false",
+		}, explain("EXPLAIN SELECT id FROM items WHERE sku = 'a' AND deleted = false"))
+
+		require.Equal(t, []int64{6}, readIDs("SELECT id FROM items WHERE sku = 'a' AND deleted = false"))
+		require.Equal(t, []int64{6, 4}, readIDs("SELECT id FROM items AS i WHERE i.deleted = false"))
+
+		require.Equal(t, []string{
+			"Project id",
+			"-> Filter (sku = 'a')",
+			"  -> Full scan items using index items[id] ASC",
+		}, explain("EXPLAIN SELECT id FROM items WHERE sku = 'a'"))
+
+		require.Equal(t, []int64{1, 2, 3, 6}, readIDs("SELECT id FROM items WHERE sku = 'a'"))
+
+		require.Equal(t, []string{
+			"Project id",
+			"-> Filter (((qty > 0) AND (deleted = FALSE)) AND (qty < 10))",
+			"  -> Range scan items using index items[qty] ASC (qty > 0 AND qty < 10) on rows where deleted = false AND qty > 0",
+		}, explain("EXPLAIN SELECT id FROM items WHERE qty > 0 AND deleted = false AND qty < 10"))
+
+		require.Equal(t, []int64{4, 6}, readIDs("SELECT id FROM items WHERE qty > 0 AND deleted = false AND qty < 10"))
+
+		_, err = engine.Query(context.Background(), nil, "SELECT id FROM items USE INDEX ON (qty) WHERE deleted = false", nil)
+		require.ErrorIs(t, err, ErrNoAvailableIndex)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT id FROM items USE INDEX ON (sku) WHERE deleted = false OR sku = 'b'", nil)
+		require.ErrorIs(t, err, ErrNoAvailableIndex)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM items WHERE deleted = false ORDER BY sku", nil)
+		require.NoError(t, err)
+		r.Close()
+
+		_, err = engine.Query(context.Background(), nil, "SELECT id FROM items ORDER BY qty", nil)
+		require.ErrorIs(t, err, ErrNoAvailableIndex)
+
+		require.Equal(t, []int64{6, 4}, readIDs("SELECT id FROM items WHERE deleted = false AND qty > 0 ORDER BY qty DESC"))
+	})
+
+	t.Run("index entries should follow updated and deleted rows", func(t *testing.T) {
+		_, _, err = engine.Exec(context.Background(), nil, "UPDATE items SET qty = qty + 10 WHERE deleted = false AND qty > 0", nil)
+		require.NoError(t, err)
+
+		require.Equal(t, []int64{6, 4}, readIDs("SELECT id FROM items WHERE deleted = false AND qty > 10"))
+		require.Equal(t, []int64{14, 16}, readIDs("SELECT qty FROM items WHERE id = 4 OR id = 6"))
+
+		_, _, err = engine.Exec(context.Background(), nil, "UPDATE items SET deleted = true WHERE id = 4", nil)
+		require.NoError(t, err)
+
+		require.Equal(t, []int64{6}, readIDs("SELECT id FROM items WHERE deleted = false AND qty > 0"))
+
+		_, _, err = engine.Exec(context.Background(), nil, "DELETE FROM items WHERE deleted = false AND sku = 'a'", nil)
+		require.NoError(t, err)
+
+		require.Empty(t, readIDs("SELECT id FROM items WHERE deleted = false AND qty > 0"))
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO items (sku, deleted, qty) VALUES ('a', false, 7)", nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("partial indexes should be loaded from the catalog", func(t *testing.T) {
+		engine, err = NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "USE DATABASE db1", nil)
+		require.NoError(t, err)
+
+		catalog, err := engine.Catalog(context.Background(), nil)
+		require.NoError(t, err)
+
+		table, err := catalog.GetTableByName("db1", "items")
+		require.NoError(t, err)
+
+		indexes := table.Indexes()
+		require.Len(t, indexes, 3)
+		require.False(t, indexes[0].IsPartial())
+		require.True(t, indexes[1].IsPartial())
+		require.True(t, indexes[1].IsUnique())
+		require.Equal(t, "deleted = false", indexes[1].Predicate())
+		require.True(t, indexes[2].IsPartial())
+		require.False(t, indexes[2].IsUnique())
+		require.Equal(t, "deleted = false AND qty > 0", indexes[2].Predicate())
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO items (sku, deleted, qty) VALUES ('a', false, 8)", nil)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+		require.Equal(t, []int64{7}, readIDs("SELECT qty FROM items WHERE sku = 'a' AND deleted = false"))
+	})
+
+	t.Run("columns used by index predicates can not be dropped", func(t *testing.T) {
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE items DROP COLUMN deleted", nil)
+		require.ErrorIs(t, err, ErrColumnIsIndexed)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO items (sku, deleted, qty) VALUES ('b', false, 1)", nil)
+		require.NoError(t, err)
+
+		require.Equal(t, []int64{1}, readIDs("SELECT qty FROM items WHERE sku = 'b' AND deleted = false"))
+	})
+}
+
+func TestMultiColumnIndexSelection(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+	defer closeStore(t, st)
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE DATABASE db1;
+		USE DATABASE db1;
+		CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, a INTEGER, b INTEGER, c INTEGER, PRIMARY KEY id);
+		CREATE INDEX ON table1(a, b);
+		CREATE INDEX ON table1(c);
+	`, nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1 (a, b, c) VALUES (@a, @b, @c)",
+			map[string]interface{}{"a": i % 2, "b": 20 - i, "c": i % 3})
+		require.NoError(t, err)
+	}
+
+	scanOf := func(query string) (string, []int64) {
+		r, err := engine.Query(context.Background(), nil, "EXPLAIN "+query, nil)
+		require.NoError(t, err)
+
+		var scan string
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			scan = strings.TrimLeft(row.ValuesByPosition[0].Value().(string), " ->")
+		}
+
+		r.Close()
+
+		r, err = engine.Query(context.Background(), nil, query, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var ids []int64
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			ids = append(ids, row.ValuesByPosition[0].Value().(int64))
+		}
+
+		return scan, ids
+	}
+
+	scan, ids := scanOf("SELECT id FROM table1 WHERE a = 1 AND b > 12")
+	require.Equal(t, "Range scan table1 using index table1[a,b] ASC (a = 1 AND b > 12)", scan)
+	require.Equal(t, []int64{8, 6, 4, 2}, ids)
+
+	scan, ids = scanOf("SELECT id FROM table1 WHERE b = 15 AND a = 1 AND c = 2")
+	require.Equal(t, "Range scan table1 using index table1[a,b] ASC (a = 1 AND b = 15)", scan)
+	require.Equal(t, []int64{6}, ids)
+
+	scan, ids = scanOf("SELECT id FROM table1 WHERE b >= 18 AND c = 0")
+	require.Equal(t, "Range scan table1 using index table1[c] ASC (c = 0)", scan)
+	require.Equal(t, []int64{1}, ids)
+
+	scan, ids = scanOf("SELECT id FROM table1 WHERE b = 18")
+	require.Equal(t, "Full scan table1 using index table1[id] ASC", scan)
+	require.Equal(t, []int64{3}, ids)
+
+	scan, ids = scanOf("SELECT id FROM table1 WHERE id = 5 AND c = 1")
+	require.Equal(t, "Range scan table1 using index table1[id] ASC (id = 5)", scan)
+	require.Equal(t, []int64{5}, ids)
+
+	scan, ids = scanOf("SELECT id FROM table1 WHERE a = 0 AND b < 16 ORDER BY b DESC")
+	require.Equal(t, "Range scan table1 using index table1[a,b] DESC (a = 0 AND b < 16)", scan)
+	require.Equal(t, []int64{7, 9, 11, 13, 15, 17, 19}, ids)
+}
+
 func TestCreateIndex(t *testing.T) {
 	engine := setupCommonTest(t)
 
@@ -893,6 +1564,131 @@ func TestInsertIntoEdgeCases(t *testing.T) {
 	})
 }
 
+func TestOnConflictDoUpdate(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE counters (
+			id INTEGER AUTO_INCREMENT,
+			name VARCHAR[20],
+			hits INTEGER,
+			note VARCHAR,
+			PRIMARY KEY id
+		);
+		CREATE UNIQUE INDEX ON counters(name);
+		CREATE INDEX ON counters(hits);
+	`, nil)
+	require.NoError(t, err)
+
+	readCounters := func() map[string]int64 {
+		r, err := engine.Query(context.Background(), nil, "SELECT name, hits FROM counters", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		hits := make(map[string]int64)
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			hits[row.ValuesByPosition[0].Value().(string)] = row.ValuesByPosition[1].Value().(int64)
+		}
+
+		return hits
+	}
+
+	t.Run("invalid conflict targets and updates", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "INSERT INTO counters(name, hits) VALUES ('page1', 1) ON CONFLICT (hits) DO NOTHING", nil)
+		require.ErrorIs(t, err, ErrInvalidConflictTarget)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO counters(name, hits) VALUES ('page1', 1) ON CONFLICT (total) DO NOTHING", nil)
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO counters(name, hits) VALUES ('page1', 1) ON CONFLICT (name) DO UPDATE SET id = 2", nil)
+		require.ErrorIs(t, err, ErrPKCanNotBeUpdated)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO counters(name, hits) VALUES ('page1', 1) ON CONFLICT (name) DO UPDATE SET hits = 1, hits = 2", nil)
+		require.ErrorIs(t, err, ErrDuplicatedColumn)
+	})
+
+	t.Run("conflicting rows should be updated", func(t *testing.T) {
+		_, ctxs, err := engine.Exec(context.Background(), nil, `
+			INSERT INTO counters(name, hits) VALUES ('page1', 1), ('page2', 1)
+			ON CONFLICT (name) DO UPDATE SET hits = hits + EXCLUDED.hits
+		`, nil)
+		require.NoError(t, err)
+		require.Equal(t, 2, ctxs[0].UpdatedRows())
+
+		_, ctxs, err = engine.Exec(context.Background(), nil, `
+			INSERT INTO counters(name, hits) VALUES ('page1', 2), ('page3', 1), ('page1', 3)
+			ON CONFLICT (name) DO UPDATE SET hits = counters.hits + EXCLUDED.hits, note = 'updated'
+		`, nil)
+		require.NoError(t, err)
+		require.Equal(t, 3, ctxs[0].UpdatedRows())
+
+		require.Equal(t, map[string]int64{"page1": 6, "page2": 1, "page3": 1}, readCounters())
+
+		r, err := engine.Query(context.Background(), nil, "SELECT id, note FROM counters WHERE hits = 6", nil)
+		require.NoError(t, err)
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(1), row.ValuesByPosition[0].Value())
+		require.Equal(t, "updated", row.ValuesByPosition[1].Value())
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		require.NoError(t, r.Close())
+	})
+
+	t.Run("conflicts on the primary key should be resolved by default", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "INSERT INTO counters(id, name, hits) VALUES (2, 'page2', 10) ON CONFLICT DO UPDATE SET hits = @hits", map[string]interface{}{"hits": 20})
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO counters(id, name, hits) VALUES (2, 'page2', 10) ON CONFLICT DO UPDATE SET hits = EXCLUDED.hits * hits", nil)
+		require.NoError(t, err)
+
+		require.Equal(t, map[string]int64{"page1": 6, "page2": 200, "page3": 1}, readCounters())
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO counters(id, name, hits) VALUES (2, 'page2', 10) ON CONFLICT (name) DO NOTHING", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO counters(id, name, hits) VALUES (2, 'page4', 10) ON CONFLICT (name) DO NOTHING", nil)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO counters(name, hits) VALUES ('page2', 1) ON CONFLICT DO UPDATE SET hits = 0", nil)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+	})
+
+	t.Run("updates should keep unique indexes consistent", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "INSERT INTO counters(name, hits) VALUES ('page3', 1) ON CONFLICT (name) DO UPDATE SET name = 'page1'", nil)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO counters(name, hits) VALUES ('page3', 1) ON CONFLICT (name) DO UPDATE SET name = 'page5', hits = 5", nil)
+		require.NoError(t, err)
+
+		require.Equal(t, map[string]int64{"page1": 6, "page2": 200, "page5": 5}, readCounters())
+	})
+
+	t.Run("remaining rows should be inserted when conflicts are ignored", func(t *testing.T) {
+		_, ctxs, err := engine.Exec(context.Background(), nil, "INSERT INTO counters(name, hits) VALUES ('page1', 1), ('page6', 1) ON CONFLICT (name) DO NOTHING", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, ctxs[0].UpdatedRows())
+
+		require.Equal(t, map[string]int64{"page1": 6, "page2": 200, "page5": 5, "page6": 1}, readCounters())
+	})
+
+	t.Run("parameters of update expressions should be inferred", func(t *testing.T) {
+		params, err := engine.InferParameters(context.Background(), nil, "INSERT INTO counters(name, hits) VALUES (@name, @hits) ON CONFLICT (name) DO UPDATE SET hits = counters.hits + @incr")
+		require.NoError(t, err)
+		require.Equal(t, map[string]SQLValueType{"name": VarcharType, "hits": IntegerType, "incr": IntegerType}, params)
+	})
+}
+
 func TestAutoIncrementPK(t *testing.T) {
 	engine := setupCommonTest(t)
 
@@ -1480,6 +2276,18 @@ func TestEncodeValue(t *testing.T) {
 	b, err = EncodeValue((&Number{val: 1}).Value(), TimestampType, 0)
 	require.ErrorIs(t, err, ErrInvalidValue)
 	require.Nil(t, b)
+
+	b, err = EncodeValue((&Decimal{val: big.NewInt(-1), scale: 2}).Value(), DecimalType, 0)
+	require.NoError(t, err)
+	require.EqualValues(t, []byte{0, 0, 0, 9, 2, 255, 255, 255, 255, 255, 255, 255, 255}, b)
+
+	b, err = EncodeValue((&Number{val: 1}).Value(), DecimalType, 0)
+	require.ErrorIs(t, err, ErrInvalidValue)
+	require.Nil(t, b)
+
+	b, err = EncodeValue("12345678901234567890", DecimalType, 0)
+	require.ErrorIs(t, err, ErrNumericValueOutOfRange)
+	require.Nil(t, b)
 }
 
 func TestQuery(t *testing.T) {
@@ -3245,23 +4053,25 @@ func TestCount(t *testing.T) {
 	err = r.Close()
 	require.NoError(t, err)
 
-	_, err = engine.Query(context.Background(), nil, "SELECT COUNT(*) as c FROM t1 GROUP BY val1", nil)
-	require.ErrorIs(t, err, ErrLimitedGroupBy)
+	for _, q := range []string{
+		"SELECT COUNT(*) as c FROM t1 GROUP BY val1",
+		"SELECT COUNT(*) as c FROM t1 GROUP BY val1 ORDER BY val1",
+	} {
+		r, err = engine.Query(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+
+		for j := 0; j < 3; j++ {
+			row, err = r.Read(context.Background())
+			require.NoError(t, err)
+			require.EqualValues(t, uint64(10), row.ValuesBySelector["(db1.t1.c)"].Value())
+		}
 
-	r, err = engine.Query(context.Background(), nil, "SELECT COUNT(*) as c FROM t1 GROUP BY val1 ORDER BY val1", nil)
-	require.NoError(t, err)
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
 
-	for j := 0; j < 3; j++ {
-		row, err = r.Read(context.Background())
+		err = r.Close()
 		require.NoError(t, err)
-		require.EqualValues(t, uint64(10), row.ValuesBySelector["(db1.t1.c)"].Value())
 	}
-
-	_, err = r.Read(context.Background())
-	require.ErrorIs(t, err, ErrNoMoreRows)
-
-	err = r.Close()
-	require.NoError(t, err)
 }
 
 func TestGroupByHaving(t *testing.T) {
@@ -3381,6 +4191,283 @@ func TestGroupByHaving(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestGroupByMultipleColumns(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE customers (id INTEGER, country VARCHAR, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE orders (id INTEGER AUTO_INCREMENT, customerid INTEGER, product VARCHAR, amount INTEGER, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO customers (id, country) VALUES (1, 'es'), (2, 'it'), (3, 'es')", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		INSERT INTO orders (customerid, product, amount)
+		VALUES
+			(1, 'book', 10),
+			(2, 'pen', 1),
+			(1, 'pen', 2),
+			(3, 'book', 15),
+			(1, 'book', 5),
+			(2, 'pen', 3)`, nil)
+	require.NoError(t, err)
+
+	type group struct {
+		key   []interface{}
+		count int64
+		sum   int64
+	}
+
+	assertGroups := func(t *testing.T, q string, keyCols []string, expected []group) {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		for _, g := range expected {
+			row, err := r.Read(context.Background())
+			require.NoError(t, err)
+
+			for i, col := range keyCols {
+				require.Equal(t, g.key[i], row.ValuesBySelector[col].Value())
+			}
+			require.Equal(t, g.count, row.ValuesBySelector["(db1.orders.c)"].Value())
+			require.Equal(t, g.sum, row.ValuesBySelector["(db1.orders.s)"].Value())
+		}
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
+	}
+
+	t.Run("grouping by multiple columns", func(t *testing.T) {
+		assertGroups(t,
+			"SELECT customerid, product, COUNT(*) AS c, SUM(amount) AS s FROM orders GROUP BY customerid, product",
+			[]string{"(db1.orders.customerid)", "(db1.orders.product)"},
+			[]group{
+				{key: []interface{}{int64(1), "book"}, count: 2, sum: 15},
+				{key: []interface{}{int64(2), "pen"}, count: 2, sum: 4},
+				{key: []interface{}{int64(1), "pen"}, count: 1, sum: 2},
+				{key: []interface{}{int64(3), "book"}, count: 1, sum: 15},
+			},
+		)
+	})
+
+	t.Run("having over aggregations not being selected", func(t *testing.T) {
+		assertGroups(t,
+			"SELECT product, COUNT(*) AS c, SUM(amount) AS s FROM orders GROUP BY product HAVING MAX(amount) > 5 AND MIN(amount) >= 5",
+			[]string{"(db1.orders.product)"},
+			[]group{
+				{key: []interface{}{"book"}, count: 3, sum: 30},
+			},
+		)
+	})
+
+	t.Run("having over grouping columns", func(t *testing.T) {
+		assertGroups(t,
+			"SELECT customerid, product, COUNT(*) AS c, SUM(amount) AS s FROM orders GROUP BY customerid, product HAVING product = 'pen' AND COUNT(*) > 1",
+			[]string{"(db1.orders.customerid)", "(db1.orders.product)"},
+			[]group{
+				{key: []interface{}{int64(2), "pen"}, count: 2, sum: 4},
+			},
+		)
+	})
+
+	t.Run("grouping over joined rows", func(t *testing.T) {
+		assertGroups(t, `
+			SELECT customers.country, orders.product, COUNT(*) AS c, SUM(orders.amount) AS s
+			FROM orders
+			INNER JOIN customers ON customers.id = orders.customerid
+			GROUP BY customers.country, orders.product
+			HAVING SUM(orders.amount) > 2`,
+			[]string{"(db1.customers.country)", "(db1.orders.product)"},
+			[]group{
+				{key: []interface{}{"es", "book"}, count: 3, sum: 30},
+				{key: []interface{}{"it", "pen"}, count: 2, sum: 4},
+			},
+		)
+	})
+
+	t.Run("grouping an empty set", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT customerid, product, COUNT(*) FROM orders WHERE amount > 100 GROUP BY customerid, product", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
+	})
+
+	t.Run("grouping by a non-existent column", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT product, COUNT(*) FROM orders GROUP BY product, price", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrInvalidColumn)
+	})
+
+	t.Run("grouping should fail when exceeding the limit of groups", func(t *testing.T) {
+		engine.distinctLimit = 2
+		defer func() { engine.distinctLimit = defaultDistinctLimit }()
+
+		r, err := engine.Query(context.Background(), nil, "SELECT customerid, product, COUNT(*) FROM orders GROUP BY customerid, product", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrTooManyRows)
+	})
+}
+
+func TestWindowFunctions(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE orders (id INTEGER AUTO_INCREMENT, customerid INTEGER, amount INTEGER, PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		INSERT INTO orders (customerid, amount)
+		VALUES (1, 10), (2, 5), (1, 20), (3, 7), (2, 5), (1, 20)`, nil)
+	require.NoError(t, err)
+
+	assertRows := func(t *testing.T, q string, cols []string, expected [][]interface{}) {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		for _, values := range expected {
+			row, err := r.Read(context.Background())
+			require.NoError(t, err)
+
+			for i, col := range cols {
+				require.Equal(t, values[i], row.ValuesBySelector[col].Value(), col)
+			}
+		}
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
+	}
+
+	t.Run("row number and rank", func(t *testing.T) {
+		assertRows(t, `
+			SELECT id,
+				ROW_NUMBER() OVER (PARTITION BY customerid ORDER BY id DESC) AS rn,
+				RANK() OVER (PARTITION BY customerid ORDER BY amount DESC) AS r,
+				ROW_NUMBER() OVER ()
+			FROM orders`,
+			[]string{"(db1.orders.id)", "(db1.orders.rn)", "(db1.orders.r)", "(db1.orders.col3)"},
+			[][]interface{}{
+				{int64(1), int64(3), int64(3), int64(1)},
+				{int64(2), int64(2), int64(1), int64(2)},
+				{int64(3), int64(2), int64(1), int64(3)},
+				{int64(4), int64(1), int64(1), int64(4)},
+				{int64(5), int64(1), int64(1), int64(5)},
+				{int64(6), int64(1), int64(1), int64(6)},
+			},
+		)
+	})
+
+	t.Run("lag and lead", func(t *testing.T) {
+		assertRows(t, `
+			SELECT id,
+				LAG(amount) OVER (PARTITION BY customerid ORDER BY id) AS prev,
+				LEAD(amount, 2, -1) OVER (PARTITION BY customerid ORDER BY id) AS next2
+			FROM orders`,
+			[]string{"(db1.orders.id)", "(db1.orders.prev)", "(db1.orders.next2)"},
+			[][]interface{}{
+				{int64(1), nil, int64(20)},
+				{int64(2), nil, int64(-1)},
+				{int64(3), int64(10), int64(-1)},
+				{int64(4), nil, int64(-1)},
+				{int64(5), int64(5), int64(-1)},
+				{int64(6), int64(20), int64(-1)},
+			},
+		)
+	})
+
+	t.Run("latest row per key", func(t *testing.T) {
+		assertRows(t, `
+			SELECT id, customerid
+			FROM (
+				SELECT id, customerid, ROW_NUMBER() OVER (PARTITION BY customerid ORDER BY id DESC) AS rn
+				FROM orders
+			) AS o
+			WHERE rn = 1`,
+			[]string{"(db1.o.id)", "(db1.o.customerid)"},
+			[][]interface{}{
+				{int64(4), int64(3)},
+				{int64(5), int64(2)},
+				{int64(6), int64(1)},
+			},
+		)
+	})
+
+	t.Run("window functions over grouped rows", func(t *testing.T) {
+		assertRows(t, `
+			SELECT customerid, COUNT(*) AS c, ROW_NUMBER() OVER (ORDER BY customerid DESC) AS pos
+			FROM orders
+			GROUP BY customerid`,
+			[]string{"(db1.orders.customerid)", "(db1.orders.c)", "(db1.orders.pos)"},
+			[][]interface{}{
+				{int64(1), int64(3), int64(3)},
+				{int64(2), int64(2), int64(2)},
+				{int64(3), int64(1), int64(1)},
+			},
+		)
+	})
+
+	t.Run("columns of window functions", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT ROW_NUMBER() OVER () AS rn, LAG(amount) OVER () FROM orders", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		cols, err := r.Columns(context.Background())
+		require.NoError(t, err)
+		require.Len(t, cols, 2)
+		require.Equal(t, "(db1.orders.rn)", cols[0].Selector())
+		require.Equal(t, IntegerType, cols[0].Type)
+		require.Equal(t, "(db1.orders.col1)", cols[1].Selector())
+		require.Equal(t, IntegerType, cols[1].Type)
+	})
+
+	t.Run("invalid window functions", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, "SELECT FIRST_VALUE(amount) OVER () FROM orders", nil)
+		require.ErrorIs(t, err, ErrFunctionDoesNotExist)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT ROW_NUMBER(id) OVER () FROM orders", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT LEAD() OVER () FROM orders", nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT LAG(amount, -1) OVER () FROM orders", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		r, err = engine.Query(context.Background(), nil, "SELECT LAG(amount, 1, 'none') OVER () FROM orders", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrInvalidTypes)
+	})
+
+	t.Run("window functions should fail when exceeding the limit of rows", func(t *testing.T) {
+		engine.distinctLimit = 2
+		defer func() { engine.distinctLimit = defaultDistinctLimit }()
+
+		r, err := engine.Query(context.Background(), nil, "SELECT ROW_NUMBER() OVER () FROM orders", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrTooManyRows)
+	})
+}
+
 func TestJoins(t *testing.T) {
 	engine := setupCommonTest(t)
 
@@ -3430,60 +4517,365 @@ func TestJoins(t *testing.T) {
 		INNER JOIN table3 AS t3 ON t1.fkid2 = t3.id AND t3.age > 30`, nil)
 		require.NoError(t, err)
 
-		row, err := r.Read(context.Background())
-		require.NoError(t, err)
-		require.Len(t, row.ValuesBySelector, 3)
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Len(t, row.ValuesBySelector, 3)
+
+		_, err = r.Read(context.Background())
+		require.Equal(t, ErrNoMoreRows, err)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should resolve every inserted row", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, `
+			SELECT id, title, table2.amount, table3.age
+			FROM table1 INNER JOIN table2 ON table1.fkid1 = table2.id
+			INNER JOIN table3 ON table1.fkid2 = table3.id
+			WHERE table1.id >= 0 AND table3.age >= 30
+			ORDER BY id DESC`, nil)
+		require.NoError(t, err)
+
+		r.SetParameters(nil)
+
+		cols, err := r.Columns(context.Background())
+		require.NoError(t, err)
+		require.Len(t, cols, 4)
+
+		for i := 0; i < rowCount; i++ {
+			row, err := r.Read(context.Background())
+			require.NoError(t, err)
+			require.NotNil(t, row)
+			require.Len(t, row.ValuesBySelector, 4)
+
+			require.Equal(t, int64(rowCount-1-i), row.ValuesBySelector[EncodeSelector("", "db1", "table1", "id")].Value())
+			require.Equal(t, fmt.Sprintf("title%d", rowCount-1-i), row.ValuesBySelector[EncodeSelector("", "db1", "table1", "title")].Value())
+			require.Equal(t, int64((rowCount-1-i)*(rowCount-1-i)), row.ValuesBySelector[EncodeSelector("", "db1", "table2", "amount")].Value())
+			require.Equal(t, int64(30+(rowCount-1-i)), row.ValuesBySelector[EncodeSelector("", "db1", "table3", "age")].Value())
+		}
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("should return error when joining nonexistent table", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, `
+		SELECT title
+		FROM table1
+		INNER JOIN table22 ON table1.id = table11.fkid1`, nil)
+		require.NoError(t, err)
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+}
+
+func TestOuterJoins(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE customers (id INTEGER, name VARCHAR, PRIMARY KEY id);
+		CREATE TABLE orders (id INTEGER, customer_id INTEGER, amount INTEGER, PRIMARY KEY id);
+		CREATE TABLE items (id INTEGER, order_id INTEGER, PRIMARY KEY id);
+
+		INSERT INTO customers (id, name) VALUES (1, 'c1'), (2, 'c2'), (3, 'c3'), (4, 'c4');
+		INSERT INTO orders (id, customer_id, amount) VALUES (1, 1, 100), (2, 1, 200), (3, 3, 300), (4, 9, 400);
+		INSERT INTO items (id, order_id) VALUES (1, 1), (2, 3), (3, 3);
+	`, nil)
+	require.NoError(t, err)
+
+	queryRows := func(t *testing.T, q string) [][]interface{} {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var rows [][]interface{}
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			values := make([]interface{}, len(row.ValuesByPosition))
+			for i, v := range row.ValuesByPosition {
+				values[i] = v.Value()
+			}
+			rows = append(rows, values)
+		}
+
+		return rows
+	}
+
+	t.Run("left join should extend rows without matches with nulls", func(t *testing.T) {
+		rows := queryRows(t, `
+			SELECT c.id, c.name, o.id, o.amount
+			FROM customers c
+			LEFT JOIN orders o ON c.id = o.customer_id`)
+
+		require.Equal(t, [][]interface{}{
+			{int64(1), "c1", int64(1), int64(100)},
+			{int64(1), "c1", int64(2), int64(200)},
+			{int64(2), "c2", nil, nil},
+			{int64(3), "c3", int64(3), int64(300)},
+			{int64(4), "c4", nil, nil},
+		}, rows)
+	})
+
+	t.Run("left join should keep rows not matching the join condition", func(t *testing.T) {
+		rows := queryRows(t, `
+			SELECT c.id, o.id
+			FROM customers c
+			LEFT JOIN orders o ON c.id = o.customer_id AND o.amount > 150`)
+
+		require.Equal(t, [][]interface{}{
+			{int64(1), int64(2)},
+			{int64(2), nil},
+			{int64(3), int64(3)},
+			{int64(4), nil},
+		}, rows)
+	})
+
+	t.Run("where clause should be evaluated over null extended rows", func(t *testing.T) {
+		rows := queryRows(t, `
+			SELECT c.id
+			FROM customers c
+			LEFT JOIN orders o ON c.id = o.customer_id
+			WHERE o.id IS NULL`)
+
+		require.Equal(t, [][]interface{}{{int64(2)}, {int64(4)}}, rows)
+
+		rows = queryRows(t, `
+			SELECT c.id, o.id
+			FROM customers c
+			LEFT JOIN orders o ON c.id = o.customer_id
+			WHERE o.amount > 150`)
+
+		require.Equal(t, [][]interface{}{
+			{int64(1), int64(2)},
+			{int64(3), int64(3)},
+		}, rows)
+	})
+
+	t.Run("left joins should be chained", func(t *testing.T) {
+		rows := queryRows(t, `
+			SELECT c.id, o.id, i.id
+			FROM customers c
+			LEFT JOIN orders o ON c.id = o.customer_id
+			LEFT JOIN items i ON o.id = i.order_id`)
+
+		require.Equal(t, [][]interface{}{
+			{int64(1), int64(1), int64(1)},
+			{int64(1), int64(2), nil},
+			{int64(2), nil, nil},
+			{int64(3), int64(3), int64(2)},
+			{int64(3), int64(3), int64(3)},
+			{int64(4), nil, nil},
+		}, rows)
+	})
+
+	t.Run("inner join over a null extended row should discard it", func(t *testing.T) {
+		rows := queryRows(t, `
+			SELECT c.id, o.id, i.id
+			FROM customers c
+			LEFT JOIN orders o ON c.id = o.customer_id
+			INNER JOIN items i ON o.id = i.order_id`)
+
+		require.Equal(t, [][]interface{}{
+			{int64(1), int64(1), int64(1)},
+			{int64(3), int64(3), int64(2)},
+			{int64(3), int64(3), int64(3)},
+		}, rows)
+	})
+
+	t.Run("right join should keep every row of the right table", func(t *testing.T) {
+		rows := queryRows(t, `
+			SELECT c.name, o.id
+			FROM customers c
+			RIGHT JOIN orders o ON c.id = o.customer_id`)
+
+		require.Equal(t, [][]interface{}{
+			{"c1", int64(1)},
+			{"c1", int64(2)},
+			{"c3", int64(3)},
+			{nil, int64(4)},
+		}, rows)
+	})
+
+	t.Run("right join should place columns of the left table first", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, `
+			SELECT *
+			FROM customers c
+			RIGHT JOIN orders o ON c.id = o.customer_id
+			WHERE o.amount >= 300`, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		cols, err := r.Columns(context.Background())
+		require.NoError(t, err)
+		require.Len(t, cols, 5)
+		require.Equal(t, "c", cols[0].Table)
+		require.Equal(t, "id", cols[0].Column)
+		require.Equal(t, "c", cols[1].Table)
+		require.Equal(t, "o", cols[2].Table)
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(3), row.ValuesByPosition[0].Value())
+		require.Equal(t, "c3", row.ValuesByPosition[1].Value())
+		require.Equal(t, int64(3), row.ValuesByPosition[2].Value())
+
+		row, err = r.Read(context.Background())
+		require.NoError(t, err)
+		require.True(t, row.ValuesByPosition[0].IsNull())
+		require.True(t, row.ValuesByPosition[1].IsNull())
+		require.Equal(t, int64(4), row.ValuesByPosition[2].Value())
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
+	})
+
+	t.Run("unqualified columns of a right join should refer to the left table", func(t *testing.T) {
+		rows := queryRows(t, `
+			SELECT id, o.id
+			FROM customers c
+			RIGHT JOIN orders o ON c.id = o.customer_id
+			WHERE o.id >= 3`)
+
+		require.Equal(t, [][]interface{}{
+			{int64(3), int64(3)},
+			{nil, int64(4)},
+		}, rows)
+	})
+
+	t.Run("right join should be followed by other joins", func(t *testing.T) {
+		rows := queryRows(t, `
+			SELECT c.id, o.id, i.id
+			FROM customers c
+			RIGHT JOIN orders o ON c.id = o.customer_id
+			LEFT JOIN items i ON o.id = i.order_id`)
+
+		require.Equal(t, [][]interface{}{
+			{int64(1), int64(1), int64(1)},
+			{int64(1), int64(2), nil},
+			{int64(3), int64(3), int64(2)},
+			{int64(3), int64(3), int64(3)},
+			{nil, int64(4), nil},
+		}, rows)
+	})
+
+	t.Run("right join should only be supported as the first join", func(t *testing.T) {
+		_, err := engine.Query(context.Background(), nil, `
+			SELECT c.id
+			FROM customers c
+			INNER JOIN orders o ON c.id = o.customer_id
+			RIGHT JOIN items i ON o.id = i.order_id`, nil)
+		require.ErrorIs(t, err, ErrUnsupportedJoinType)
+	})
+}
+
+func TestSubQueriesInWhere(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE customers (id INTEGER, name VARCHAR, PRIMARY KEY id);
+		CREATE TABLE orders (id INTEGER, customer_id INTEGER, amount INTEGER, PRIMARY KEY id);
+
+		INSERT INTO customers (id, name) VALUES (1, 'c1'), (2, 'c2'), (3, 'c3'), (4, 'c4');
+		INSERT INTO orders (id, customer_id, amount) VALUES (1, 1, 100), (2, 1, 200), (3, 3, 300), (4, 9, 400);
+	`, nil)
+	require.NoError(t, err)
+
+	queryIDs := func(t *testing.T, q string, params map[string]interface{}) []int64 {
+		r, err := engine.Query(context.Background(), nil, q, params)
+		require.NoError(t, err)
+		defer r.Close()
+
+		ids := []int64{}
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			ids = append(ids, row.ValuesByPosition[0].Value().(int64))
+		}
 
-		_, err = r.Read(context.Background())
-		require.Equal(t, ErrNoMoreRows, err)
+		return ids
+	}
 
-		err = r.Close()
-		require.NoError(t, err)
+	t.Run("in subquery", func(t *testing.T) {
+		ids := queryIDs(t, "SELECT id FROM customers WHERE id IN (SELECT customer_id FROM orders)", nil)
+		require.Equal(t, []int64{1, 3}, ids)
+
+		ids = queryIDs(t, "SELECT id FROM customers WHERE id NOT IN (SELECT customer_id FROM orders)", nil)
+		require.Equal(t, []int64{2, 4}, ids)
+
+		ids = queryIDs(t, "SELECT id FROM customers WHERE id IN (SELECT customer_id FROM orders WHERE amount > @amount)", map[string]interface{}{"amount": 150})
+		require.Equal(t, []int64{1, 3}, ids)
+
+		ids = queryIDs(t, "SELECT id FROM customers WHERE id IN (SELECT customer_id FROM orders WHERE amount > @amount)", map[string]interface{}{"amount": 250})
+		require.Equal(t, []int64{3}, ids)
 	})
 
-	t.Run("should resolve every inserted row", func(t *testing.T) {
-		r, err := engine.Query(context.Background(), nil, `
-			SELECT id, title, table2.amount, table3.age
-			FROM table1 INNER JOIN table2 ON table1.fkid1 = table2.id
-			INNER JOIN table3 ON table1.fkid2 = table3.id
-			WHERE table1.id >= 0 AND table3.age >= 30
-			ORDER BY id DESC`, nil)
-		require.NoError(t, err)
+	t.Run("exists subquery", func(t *testing.T) {
+		ids := queryIDs(t, "SELECT id FROM customers c WHERE EXISTS (SELECT id FROM orders WHERE customer_id = c.id)", nil)
+		require.Equal(t, []int64{1, 3}, ids)
 
-		r.SetParameters(nil)
+		ids = queryIDs(t, "SELECT id FROM customers c WHERE NOT EXISTS (SELECT id FROM orders WHERE customer_id = c.id)", nil)
+		require.Equal(t, []int64{2, 4}, ids)
 
-		cols, err := r.Columns(context.Background())
-		require.NoError(t, err)
-		require.Len(t, cols, 4)
+		ids = queryIDs(t, "SELECT id FROM orders o WHERE NOT EXISTS (SELECT id FROM customers WHERE id = o.customer_id)", nil)
+		require.Equal(t, []int64{4}, ids)
+	})
 
-		for i := 0; i < rowCount; i++ {
-			row, err := r.Read(context.Background())
-			require.NoError(t, err)
-			require.NotNil(t, row)
-			require.Len(t, row.ValuesBySelector, 4)
+	t.Run("scalar subquery", func(t *testing.T) {
+		ids := queryIDs(t, "SELECT id FROM orders WHERE amount > (SELECT amount FROM orders WHERE id = 2)", nil)
+		require.Equal(t, []int64{3, 4}, ids)
 
-			require.Equal(t, int64(rowCount-1-i), row.ValuesBySelector[EncodeSelector("", "db1", "table1", "id")].Value())
-			require.Equal(t, fmt.Sprintf("title%d", rowCount-1-i), row.ValuesBySelector[EncodeSelector("", "db1", "table1", "title")].Value())
-			require.Equal(t, int64((rowCount-1-i)*(rowCount-1-i)), row.ValuesBySelector[EncodeSelector("", "db1", "table2", "amount")].Value())
-			require.Equal(t, int64(30+(rowCount-1-i)), row.ValuesBySelector[EncodeSelector("", "db1", "table3", "age")].Value())
-		}
+		// correlated with the outer row
+		ids = queryIDs(t, "SELECT id FROM customers c WHERE (SELECT COUNT(*) FROM orders WHERE customer_id = c.id) > 1", nil)
+		require.Equal(t, []int64{1}, ids)
 
-		err = r.Close()
+		// no rows evaluate to null
+		ids = queryIDs(t, "SELECT id FROM orders WHERE amount = (SELECT amount FROM orders WHERE id = 99)", nil)
+		require.Empty(t, ids)
+
+		ids = queryIDs(t, "SELECT id FROM orders WHERE (SELECT amount FROM orders WHERE id = 99) IS NULL", nil)
+		require.Equal(t, []int64{1, 2, 3, 4}, ids)
+	})
+
+	t.Run("subqueries used in dml statements", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "DELETE FROM orders WHERE customer_id NOT IN (SELECT id FROM customers)", nil)
 		require.NoError(t, err)
+
+		ids := queryIDs(t, "SELECT id FROM orders", nil)
+		require.Equal(t, []int64{1, 2, 3}, ids)
 	})
 
-	t.Run("should return error when joining nonexistent table", func(t *testing.T) {
-		r, err := engine.Query(context.Background(), nil, `
-		SELECT title
-		FROM table1
-		INNER JOIN table22 ON table1.id = table11.fkid1`, nil)
+	t.Run("subquery returning more than one column", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM customers WHERE id IN (SELECT id, customer_id FROM orders)", nil)
 		require.NoError(t, err)
+		defer r.Close()
 
 		_, err = r.Read(context.Background())
-		require.ErrorIs(t, err, ErrTableDoesNotExist)
+		require.ErrorIs(t, err, ErrInvalidNumberOfValues)
+	})
 
-		err = r.Close()
+	t.Run("scalar subquery returning more than one row", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM customers WHERE id = (SELECT customer_id FROM orders)", nil)
 		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrTooManyRows)
 	})
 }
 
@@ -3711,23 +5103,349 @@ func TestSubQuery(t *testing.T) {
 	_, _, err = engine.Exec(context.Background(), nil, "UPSERT INTO table1 (id, title) VALUES (0, 'title0')", nil)
 	require.NoError(t, err)
 
-	r, err = engine.Query(context.Background(), nil, "SELECT id, title, active FROM (SELECT id, title, active FROM table1) WHERE active", nil)
-	require.NoError(t, err)
+	r, err = engine.Query(context.Background(), nil, "SELECT id, title, active FROM (SELECT id, title, active FROM table1) WHERE active", nil)
+	require.NoError(t, err)
+
+	_, err = r.Read(context.Background())
+	require.NoError(t, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.Query(context.Background(), nil, "SELECT id, title, active FROM (SELECT id, title, active FROM table1) WHERE title", nil)
+	require.NoError(t, err)
+
+	_, err = r.Read(context.Background())
+	require.ErrorIs(t, err, ErrInvalidCondition)
+
+	err = r.Close()
+	require.NoError(t, err)
+}
+
+func TestViews(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+	t.Cleanup(func() { closeStore(t, st) })
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE VIEW view1 AS SELECT id FROM table1", nil)
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE DATABASE db1; USE DATABASE db1;", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE VIEW view1 AS SELECT id FROM table1", nil)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR, active BOOLEAN, PRIMARY KEY id);
+		CREATE TABLE table2 (id INTEGER AUTO_INCREMENT, table1_id INTEGER, amount INTEGER, PRIMARY KEY id);
+	`, nil)
+	require.NoError(t, err)
+
+	rowCount := 10
+
+	for i := 0; i < rowCount; i++ {
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1 (title, active) VALUES (@title, @active)",
+			map[string]interface{}{"title": fmt.Sprintf("title%d", i), "active": i%2 == 0})
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table2 (table1_id, amount) VALUES (@id, @amount)",
+			map[string]interface{}{"id": i + 1, "amount": i * 10})
+		require.NoError(t, err)
+	}
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE VIEW view1 AS SELECT id, title FROM table1 WHERE id > @id", nil)
+	require.ErrorIs(t, err, ErrParameterizedView)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE VIEW view1 AS SELECT id, unknown FROM table1", nil)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE VIEW view1 AS SELECT t1.id, t2.id FROM table1 t1 INNER JOIN table2 t2 ON t1.id = t2.table1_id", nil)
+	require.ErrorIs(t, err, ErrDuplicatedColumn)
+
+	t.Run("views failing validation should not be added to the catalog", func(t *testing.T) {
+		tx, err := engine.NewTx(context.Background(), DefaultTxOptions())
+		require.NoError(t, err)
+		defer tx.Cancel()
+
+		stmts, err := ParseString("CREATE VIEW view1 AS SELECT t1.id, t2.id FROM table1 t1 INNER JOIN table2 t2 ON t1.id = t2.table1_id")
+		require.NoError(t, err)
+
+		_, err = stmts[0].execAt(context.Background(), tx, nil)
+		require.ErrorIs(t, err, ErrDuplicatedColumn)
+		require.False(t, tx.currentDB.ExistView("view1"))
+		require.Zero(t, tx.currentDB.maxViewID)
+	})
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE VIEW table1 AS SELECT id FROM table2", nil)
+	require.ErrorIs(t, err, ErrTableAlreadyExists)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE VIEW active_rows AS SELECT id, title AS name FROM table1 WHERE active", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE VIEW active_rows AS SELECT id FROM table1", nil)
+	require.ErrorIs(t, err, ErrViewAlreadyExists)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE VIEW IF NOT EXISTS active_rows AS SELECT id FROM table1", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE active_rows (id INTEGER, PRIMARY KEY id)", nil)
+	require.ErrorIs(t, err, ErrViewAlreadyExists)
+
+	t.Run("query through a view", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT id, name FROM active_rows WHERE id > 2", nil)
+		require.NoError(t, err)
+
+		cols, err := r.Columns(context.Background())
+		require.NoError(t, err)
+		require.Len(t, cols, 2)
+		require.Equal(t, EncodeSelector("", "db1", "active_rows", "id"), cols[0].Selector())
+		require.Equal(t, EncodeSelector("", "db1", "active_rows", "name"), cols[1].Selector())
+		require.Equal(t, IntegerType, cols[0].Type)
+		require.Equal(t, VarcharType, cols[1].Type)
+
+		for i := 3; i <= rowCount; i += 2 {
+			row, err := r.Read(context.Background())
+			require.NoError(t, err)
+
+			require.Equal(t, int64(i), row.ValuesBySelector[EncodeSelector("", "db1", "active_rows", "id")].Value())
+			require.Equal(t, fmt.Sprintf("title%d", i-1), row.ValuesBySelector[EncodeSelector("", "db1", "active_rows", "name")].Value())
+		}
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("query through a view with alias and join", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, `
+			SELECT v.name, t2.amount
+			FROM active_rows AS v
+			INNER JOIN table2 AS t2 ON v.id = t2.table1_id
+			WHERE t2.amount >= 40`, nil)
+		require.NoError(t, err)
+
+		for i := 4; i < rowCount; i += 2 {
+			row, err := r.Read(context.Background())
+			require.NoError(t, err)
+
+			require.Equal(t, fmt.Sprintf("title%d", i), row.ValuesBySelector[EncodeSelector("", "db1", "v", "name")].Value())
+			require.Equal(t, int64(i*10), row.ValuesBySelector[EncodeSelector("", "db1", "t2", "amount")].Value())
+		}
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("type-checking through a view", func(t *testing.T) {
+		params, err := engine.InferParameters(context.Background(), nil, "SELECT id FROM active_rows WHERE name = @name AND id > @id")
+		require.NoError(t, err)
+		require.Equal(t, map[string]SQLValueType{"name": VarcharType, "id": IntegerType}, params)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT title FROM active_rows", nil)
+		require.NoError(t, err)
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT id FROM active_rows ORDER BY id", nil)
+		require.ErrorIs(t, err, ErrLimitedOrderBy)
+	})
+
+	t.Run("rows can not be modified through a view", func(t *testing.T) {
+		_, _, err = engine.Exec(context.Background(), nil, "DELETE FROM active_rows WHERE id = 1", nil)
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+		_, _, err = engine.Exec(context.Background(), nil, "UPDATE active_rows SET name = 'title' WHERE id = 1", nil)
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO active_rows (id, name) VALUES (100, 'title')", nil)
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+	})
+
+	t.Run("views over views", func(t *testing.T) {
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE VIEW top_rows AS SELECT COUNT(*) AS c FROM active_rows WHERE id > 5", nil)
+		require.NoError(t, err)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT c FROM top_rows", nil)
+		require.NoError(t, err)
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(2), row.ValuesBySelector[EncodeSelector("", "db1", "top_rows", "c")].Value())
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("recursive views are rejected", func(t *testing.T) {
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE VIEW view1 AS SELECT id FROM view1", nil)
+		require.ErrorIs(t, err, ErrRecursiveViewDefinition)
+
+		// dropping a view used by other views would make it possible to re-create it on top of them
+		_, _, err = engine.Exec(context.Background(), nil, "DROP VIEW active_rows", nil)
+		require.ErrorIs(t, err, ErrViewIsReferenced)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT c FROM top_rows", nil)
+		require.NoError(t, err)
+
+		_, err = r.Read(context.Background())
+		require.NoError(t, err)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("recursive views through subqueries are rejected", func(t *testing.T) {
+		_, _, err = engine.Exec(context.Background(), nil, `
+			CREATE VIEW sq_a AS SELECT id FROM table1;
+			CREATE VIEW sq_b AS SELECT id FROM sq_a;
+		`, nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "DROP VIEW sq_a", nil)
+		require.ErrorIs(t, err, ErrViewIsReferenced)
+
+		for _, q := range []string{
+			"SELECT id FROM table1 WHERE id IN (SELECT id FROM sq_c)",
+			"SELECT id FROM table1 WHERE NOT EXISTS (SELECT id FROM sq_b WHERE id IN (SELECT id FROM sq_c))",
+			"SELECT active, COUNT(*) AS c FROM table1 GROUP BY active HAVING COUNT(*) > (SELECT COUNT(*) FROM sq_c)",
+			"SELECT t1.id FROM table1 AS t1 INNER JOIN table2 AS t2 ON t2.table1_id = t1.id AND t1.id IN (SELECT id FROM sq_c)",
+		} {
+			_, _, err = engine.Exec(context.Background(), nil, "CREATE VIEW sq_c AS "+q, nil)
+			require.ErrorIs(t, err, ErrRecursiveViewDefinition, q)
+		}
+
+		_, _, err = engine.Exec(context.Background(), nil, `
+			DROP VIEW sq_b;
+			DROP VIEW sq_a;
+		`, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("drop view", func(t *testing.T) {
+		_, _, err = engine.Exec(context.Background(), nil, "DROP VIEW view1", nil)
+		require.ErrorIs(t, err, ErrViewDoesNotExist)
+
+		_, _, err = engine.Exec(context.Background(), nil, "DROP VIEW IF EXISTS view1", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "DROP VIEW top_rows", nil)
+		require.NoError(t, err)
+
+		_, err = engine.Query(context.Background(), nil, "SELECT c FROM top_rows", nil)
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+	})
+
+	t.Run("views are persisted", func(t *testing.T) {
+		engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		err = engine.SetCurrentDatabase(context.Background(), "db1")
+		require.NoError(t, err)
+
+		catalog, err := engine.Catalog(context.Background(), nil)
+		require.NoError(t, err)
+
+		db, err := catalog.GetDatabaseByName("db1")
+		require.NoError(t, err)
+		require.Len(t, db.GetViews(), 1)
+
+		view, err := db.GetViewByName("active_rows")
+		require.NoError(t, err)
+		require.Equal(t, "SELECT id, title AS name FROM table1 WHERE active", view.SQL())
+
+		r, err := engine.Query(context.Background(), nil, "SELECT COUNT(*) AS c FROM active_rows", nil)
+		require.NoError(t, err)
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(rowCount/2), row.ValuesBySelector[EncodeSelector("", "db1", "active_rows", "c")].Value())
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("temporal queries over views", func(t *testing.T) {
+		_, txs, err := engine.Exec(context.Background(), nil, "INSERT INTO table1 (title, active) VALUES ('title', true)", nil)
+		require.NoError(t, err)
+		require.Len(t, txs, 1)
+
+		hdr := txs[0].TxHeader()
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE VIEW rows_before AS SELECT id FROM table1 BEFORE TX 5", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE VIEW active_count AS SELECT COUNT(*) AS c FROM active_rows", nil)
+		require.NoError(t, err)
+
+		countRows := func(query string, params map[string]interface{}) int64 {
+			r, err := engine.Query(context.Background(), nil, query, params)
+			require.NoError(t, err)
+			defer r.Close()
+
+			row, err := r.Read(context.Background())
+			require.NoError(t, err)
+
+			return row.ValuesByPosition[0].Value().(int64)
+		}
+
+		require.Equal(t, int64(rowCount/2+1), countRows("SELECT COUNT(*) FROM active_rows", nil))
+		require.Equal(t, int64(rowCount/2), countRows("SELECT COUNT(*) FROM active_rows BEFORE TX @tx", map[string]interface{}{"tx": hdr.ID}))
+		require.Equal(t, int64(1), countRows("SELECT COUNT(*) FROM active_rows SINCE TX @tx", map[string]interface{}{"tx": hdr.ID}))
+
+		// the period is propagated through views over views
+		require.Equal(t, int64(rowCount/2+1), countRows("SELECT c FROM active_count", nil))
+		require.Equal(t, int64(rowCount/2), countRows("SELECT c FROM active_count BEFORE TX @tx", map[string]interface{}{"tx": hdr.ID}))
+
+		// the period defined by the view takes precedence
+		rowsBefore := countRows("SELECT COUNT(*) FROM table1 BEFORE TX 5", nil)
+		require.Greater(t, rowsBefore, int64(0))
+		require.Equal(t, rowsBefore, countRows("SELECT COUNT(*) FROM rows_before SINCE TX @tx", map[string]interface{}{"tx": hdr.ID}))
+	})
+
+	t.Run("columns used by views can not be dropped nor altered", func(t *testing.T) {
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 DROP COLUMN active", nil)
+		require.ErrorIs(t, err, ErrColumnIsUsedByView)
+
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 DROP COLUMN title", nil)
+		require.ErrorIs(t, err, ErrColumnIsUsedByView)
+
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 RENAME COLUMN title TO name", nil)
+		require.ErrorIs(t, err, ErrColumnIsUsedByView)
+
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 ALTER COLUMN title VARCHAR[100]", nil)
+		require.ErrorIs(t, err, ErrColumnIsUsedByView)
 
-	_, err = r.Read(context.Background())
-	require.NoError(t, err)
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 DROP COLUMN unknown", nil)
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
 
-	err = r.Close()
-	require.NoError(t, err)
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 ADD COLUMN notes VARCHAR", nil)
+		require.NoError(t, err)
 
-	r, err = engine.Query(context.Background(), nil, "SELECT id, title, active FROM (SELECT id, title, active FROM table1) WHERE title", nil)
-	require.NoError(t, err)
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE table1 DROP COLUMN notes", nil)
+		require.NoError(t, err)
 
-	_, err = r.Read(context.Background())
-	require.ErrorIs(t, err, ErrInvalidCondition)
+		r, err := engine.Query(context.Background(), nil, "SELECT c FROM active_count", nil)
+		require.NoError(t, err)
 
-	err = r.Close()
-	require.NoError(t, err)
+		_, err = r.Read(context.Background())
+		require.NoError(t, err)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
 }
 
 func TestJoinsWithSubquery(t *testing.T) {
@@ -4091,6 +5809,15 @@ func TestDecodeValueFailures(t *testing.T) {
 		{
 			"Too large boolean", []byte{0, 0, 0, 2, 0, 0}, BooleanType,
 		},
+		{
+			"Invalid JSON document", []byte{0, 0, 0, 2, '{', ']'}, JSONType,
+		},
+		{
+			"Too short decimal", []byte{0, 0, 0, 8, 0, 0, 0, 0, 0, 0, 0, 0}, DecimalType,
+		},
+		{
+			"Invalid decimal scale", []byte{0, 0, 0, 9, 19, 0, 0, 0, 0, 0, 0, 0, 1}, DecimalType,
+		},
 		{
 			"Any type", []byte{0, 0, 0, 1, 1}, AnyType,
 		},
@@ -4132,6 +5859,20 @@ func TestDecodeValueSuccess(t *testing.T) {
 			&Varchar{val: ""},
 			4,
 		},
+		{
+			"json",
+			[]byte{0, 0, 0, 9, '{', '"', 'a', '"', ':', '[', '1', ']', '}'},
+			JSONType,
+			&JSON{val: map[string]interface{}{"a": []interface{}{json.Number("1")}}},
+			13,
+		},
+		{
+			"decimal",
+			[]byte{0, 0, 0, 9, 2, 0, 0, 0, 0, 0, 0, 4, 26},
+			DecimalType,
+			&Decimal{val: big.NewInt(1050), scale: 2},
+			13,
+		},
 		{
 			"zero integer",
 			[]byte{0, 0, 0, 8, 0, 0, 0, 0, 0, 0, 0, 0},
@@ -4481,6 +6222,17 @@ func TestEncodeAsKeyEdgeCases(t *testing.T) {
 		_, err = EncodeAsKey(int64(10), TimestampType, 4)
 		require.ErrorIs(t, err, ErrCorruptedData)
 	})
+
+	t.Run("decimal cases", func(t *testing.T) {
+		_, err = EncodeAsKey(int64(10), DecimalType, 8)
+		require.ErrorIs(t, err, ErrInvalidValue)
+
+		_, err = EncodeAsKey("10.5", DecimalType, 4)
+		require.ErrorIs(t, err, ErrCorruptedData)
+
+		_, err = EncodeAsKey("-12345678901234567890", DecimalType, 8)
+		require.ErrorIs(t, err, ErrNumericValueOutOfRange)
+	})
 }
 
 func TestIndexingNullableColumns(t *testing.T) {
@@ -4955,6 +6707,155 @@ func TestUnionOperator(t *testing.T) {
 	})
 }
 
+func TestSetOperators(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE table1(id INTEGER AUTO_INCREMENT, title VARCHAR[50], PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE table2(id INTEGER AUTO_INCREMENT, name VARCHAR[30], PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	_, err = engine.Query(context.Background(), nil, "SELECT id FROM table_unknown INTERSECT SELECT id FROM table1", nil)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, err = engine.Query(context.Background(), nil, "SELECT id FROM table1 EXCEPT SELECT id FROM table_unknown", nil)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, err = engine.Query(context.Background(), nil, "SELECT id FROM table1 INTERSECT SELECT id, name FROM table2", nil)
+	require.ErrorIs(t, err, ErrColumnMismatchInSetOpStmt)
+
+	_, err = engine.Query(context.Background(), nil, "SELECT id FROM table1 EXCEPT SELECT name FROM table2", nil)
+	require.ErrorIs(t, err, ErrColumnMismatchInSetOpStmt)
+
+	params, err := engine.InferParameters(context.Background(), nil, "SELECT id FROM table1 WHERE id > @id1 INTERSECT SELECT id FROM table2 WHERE name = @name")
+	require.NoError(t, err)
+	require.Equal(t, map[string]SQLValueType{"id1": IntegerType, "name": VarcharType}, params)
+
+	rowCount := 10
+	for i := 0; i < rowCount; i++ {
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1(title) VALUES (@title)", map[string]interface{}{"title": fmt.Sprintf("title%d", i%5)})
+		require.NoError(t, err)
+
+		// only even rows have a name, the rest are NULL
+		var name interface{}
+		if i%2 == 0 {
+			name = fmt.Sprintf("title%d", i)
+		}
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table2(name) VALUES (@name)", map[string]interface{}{"name": name})
+		require.NoError(t, err)
+	}
+
+	readTitles := func(t *testing.T, query string) []interface{} {
+		r, err := engine.Query(context.Background(), nil, query, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var titles []interface{}
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			titles = append(titles, row.ValuesByPosition[0].Value())
+		}
+
+		return titles
+	}
+
+	t.Run("intersect should return distinct rows present in both queries", func(t *testing.T) {
+		titles := readTitles(t, "SELECT title FROM table1 INTERSECT SELECT name FROM table2")
+		require.Equal(t, []interface{}{"title0", "title2", "title4"}, titles)
+	})
+
+	t.Run("except should return distinct rows not present in the second query", func(t *testing.T) {
+		titles := readTitles(t, "SELECT title FROM table1 EXCEPT SELECT name FROM table2")
+		require.Equal(t, []interface{}{"title1", "title3"}, titles)
+	})
+
+	t.Run("null values should be considered equal", func(t *testing.T) {
+		titles := readTitles(t, "SELECT name FROM table2 WHERE id > 5 INTERSECT SELECT name FROM table2 WHERE id < 5")
+		require.Equal(t, []interface{}{nil}, titles)
+
+		titles = readTitles(t, "SELECT name FROM table2 EXCEPT SELECT name FROM table2 WHERE name = NULL")
+		require.Equal(t, []interface{}{"title0", "title2", "title4", "title6", "title8"}, titles)
+	})
+
+	t.Run("intersect all should keep as many duplicates as present in both queries", func(t *testing.T) {
+		titles := readTitles(t, "SELECT title FROM table1 INTERSECT ALL SELECT name FROM table2")
+		require.Equal(t, []interface{}{"title0", "title2", "title4"}, titles)
+
+		titles = readTitles(t, "SELECT title FROM table1 INTERSECT ALL SELECT title FROM table1 WHERE id > 5")
+		require.Equal(t, []interface{}{"title0", "title1", "title2", "title3", "title4"}, titles)
+	})
+
+	t.Run("except all should discard a single row for each row in the second query", func(t *testing.T) {
+		titles := readTitles(t, "SELECT title FROM table1 EXCEPT ALL SELECT name FROM table2")
+		require.Equal(t, []interface{}{"title1", "title3", "title0", "title1", "title2", "title3", "title4"}, titles)
+
+		titles = readTitles(t, "SELECT title FROM table1 EXCEPT ALL SELECT title FROM table1 WHERE id > 5")
+		require.Equal(t, []interface{}{"title0", "title1", "title2", "title3", "title4"}, titles)
+	})
+
+	t.Run("set operations should be combined with union", func(t *testing.T) {
+		titles := readTitles(t, "SELECT title FROM table1 WHERE id = 1 UNION SELECT title FROM table1 EXCEPT SELECT name FROM table2")
+		require.Equal(t, []interface{}{"title1", "title3"}, titles)
+
+		titles = readTitles(t, "SELECT name FROM table2 EXCEPT SELECT title FROM table1 UNION SELECT title FROM table1 WHERE id = 1")
+		require.Equal(t, []interface{}{nil, "title6", "title8", "title0"}, titles)
+	})
+
+	t.Run("chained set operations should be evaluated from left to right", func(t *testing.T) {
+		titles := readTitles(t, "SELECT title FROM table1 EXCEPT SELECT name FROM table2 EXCEPT SELECT title FROM table1 WHERE id = 2")
+		require.Equal(t, []interface{}{"title3"}, titles)
+
+		titles = readTitles(t, "SELECT title FROM table1 EXCEPT ALL SELECT name FROM table2 EXCEPT ALL SELECT title FROM table1 WHERE id < 3")
+		require.Equal(t, []interface{}{"title3", "title1", "title2", "title3", "title4"}, titles)
+	})
+
+	t.Run("intersect should bind tighter than union and except", func(t *testing.T) {
+		titles := readTitles(t, "SELECT name FROM table2 WHERE id = 7 UNION SELECT title FROM table1 INTERSECT SELECT name FROM table2 WHERE id < 4")
+		require.Equal(t, []interface{}{"title6", "title0", "title2"}, titles)
+
+		titles = readTitles(t, "SELECT title FROM table1 EXCEPT SELECT title FROM table1 WHERE id < 4 INTERSECT SELECT name FROM table2")
+		require.Equal(t, []interface{}{"title1", "title3", "title4"}, titles)
+	})
+
+	t.Run("set operations should be bounded by the distinct limit", func(t *testing.T) {
+		distinctLimit := engine.distinctLimit
+		engine.distinctLimit = 3
+		defer func() { engine.distinctLimit = distinctLimit }()
+
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM table1 EXCEPT SELECT id FROM table2 WHERE id > 10", nil)
+		require.NoError(t, err)
+
+		for i := 0; i < engine.distinctLimit; i++ {
+			row, err := r.Read(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, int64(i+1), row.ValuesByPosition[0].Value())
+		}
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrTooManyRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		r, err = engine.Query(context.Background(), nil, "SELECT id FROM table1 INTERSECT SELECT id FROM table2", nil)
+		require.NoError(t, err)
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrTooManyRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+}
+
 func TestTemporalQueriesEdgeCases(t *testing.T) {
 	engine := setupCommonTest(t)
 
@@ -5124,15 +7025,285 @@ func TestTemporalQueriesDeletedRows(t *testing.T) {
 	require.NoError(t, err)
 	require.EqualValues(t, 2, row.ValuesByPosition[0].Value())
 
-	row, err = res.Read(context.Background())
-	require.NoError(t, err)
-	require.EqualValues(t, 8, row.ValuesByPosition[0].Value())
+	row, err = res.Read(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, 8, row.ValuesByPosition[0].Value())
+
+	_, err = res.Read(context.Background())
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = res.Close()
+	require.NoError(t, err)
+}
+
+func TestTemporalQueriesAsOf(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE table1 (id INTEGER, title VARCHAR[50], PRIMARY KEY id);
+		CREATE INDEX ON table1(title);
+		CREATE TABLE table2 (id INTEGER, table1_id INTEGER, amount INTEGER, PRIMARY KEY id);
+	`, nil)
+	require.NoError(t, err)
+
+	_, txs, err := engine.Exec(context.Background(), nil, `
+		INSERT INTO table1 (id, title) VALUES (1, 'title1'), (2, 'title2'), (3, 'title3');
+		INSERT INTO table2 (id, table1_id, amount) VALUES (1, 1, 10), (2, 2, 20);
+	`, nil)
+	require.NoError(t, err)
+	tx1 := txs[0].TxHeader().ID
+
+	_, txs, err = engine.Exec(context.Background(), nil, `
+		UPDATE table1 SET title = 'title22' WHERE id = 2;
+		DELETE FROM table1 WHERE id = 3;
+		UPDATE table2 SET amount = 21 WHERE id = 2;
+	`, nil)
+	require.NoError(t, err)
+	tx2 := txs[0].TxHeader().ID
+
+	_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO table1 (id, title) VALUES (4, 'title4')", nil)
+	require.NoError(t, err)
+
+	readRows := func(tx *SQLTx, query string, params map[string]interface{}) []string {
+		r, err := engine.Query(context.Background(), tx, query, params)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var rows []string
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			vals := make([]string, len(row.ValuesByPosition))
+			for i, v := range row.ValuesByPosition {
+				vals[i] = fmt.Sprintf("%v", v.Value())
+			}
+
+			rows = append(rows, strings.Join(vals, ","))
+		}
+
+		return rows
+	}
+
+	t.Run("tables should be read as they were right after the transaction", func(t *testing.T) {
+		params := map[string]interface{}{"tx1": tx1, "tx2": tx2}
+
+		require.Equal(t, []string{"1,title1", "2,title2", "3,title3"}, readRows(nil, "SELECT id, title FROM table1 AS OF TX @tx1", params))
+		require.Equal(t, []string{"1,title1", "2,title22"}, readRows(nil, "SELECT id, title FROM table1 AS OF TX @tx2", params))
+		require.Equal(t, []string{"1,title1", "2,title22", "4,title4"}, readRows(nil, "SELECT id, title FROM table1", nil))
+
+		require.Equal(t, []string{"2,title2"}, readRows(nil, "SELECT id, title FROM table1 AS OF TX @tx1 WHERE title = 'title2'", params))
+		require.Equal(t, []string{"1,title1", "2,title2", "3,title3"}, readRows(nil, "SELECT id, title FROM table1 AS OF TX @tx1 AS t USE INDEX ON (title)", params))
+		require.Empty(t, readRows(nil, "SELECT id FROM table1 AS OF TX @tx2 WHERE title = 'title2'", params))
+
+		require.Equal(t,
+			[]string{"Project id", "-> Full scan table1 using index table1[id] ASC as of tx @tx1"},
+			readRows(nil, "EXPLAIN SELECT id FROM table1 AS OF TX @tx1", nil),
+		)
+	})
+
+	t.Run("each table reference should be resolved at its own instant", func(t *testing.T) {
+		params := map[string]interface{}{"tx1": tx1, "tx2": tx2}
+
+		require.Equal(t,
+			[]string{"title1,10", "title2,21"},
+			readRows(nil, "SELECT t1.title, t2.amount FROM table1 AS OF TX @tx1 AS t1 INNER JOIN table2 AS t2 ON t1.id = t2.table1_id", params),
+		)
+
+		require.Equal(t,
+			[]string{"title1,10", "title22,20"},
+			readRows(nil, "SELECT t1.title, t2.amount FROM table1 AS t1 INNER JOIN table2 AS OF TX @tx1 AS t2 ON t1.id = t2.table1_id", params),
+		)
+
+		require.Equal(t,
+			[]string{"3"},
+			readRows(nil, "SELECT COUNT(*) FROM (SELECT id FROM table1) AS OF TX @tx1 AS q", params),
+		)
+
+		require.Equal(t,
+			[]string{"2,title2"},
+			readRows(nil, `
+				SELECT id, title
+				FROM table1
+				AS OF TX @tx1
+				WHERE id NOT IN (SELECT id FROM table1 AS OF TX @tx2 WHERE title = 'title1') AND id IN (SELECT table1_id FROM table2)
+			`, params),
+		)
+	})
+
+	t.Run("views should be read at the instant they are referenced at", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "CREATE VIEW titles AS SELECT id, title FROM table1", nil)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"1,title1", "2,title2", "3,title3"}, readRows(nil, "SELECT * FROM titles AS OF TX @tx", map[string]interface{}{"tx": tx1}))
+	})
+
+	t.Run("timestamps should be resolved to the latest transaction committed at that time", func(t *testing.T) {
+		require.Equal(t, []string{"1,title1", "2,title22", "4,title4"}, readRows(nil, "SELECT id, title FROM table1 AS OF TIMESTAMP NOW()", nil))
+		require.Empty(t, readRows(nil, "SELECT id, title FROM table1 AS OF TIMESTAMP '2000-01-01'", nil))
+	})
+
+	t.Run("pending changes should not be visible", func(t *testing.T) {
+		tx, _, err := engine.Exec(context.Background(), nil, "BEGIN TRANSACTION; INSERT INTO table1 (id, title) VALUES (5, 'title5')", nil)
+		require.NoError(t, err)
+		defer tx.Cancel()
+
+		require.Len(t, readRows(tx, "SELECT id FROM table1", nil), 4)
+		require.Len(t, readRows(tx, "SELECT id FROM table1 AS OF TX @tx", map[string]interface{}{"tx": tx2 + 1}), 3)
+	})
+
+	t.Run("invalid instants", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM table1 AS OF TX 1000", nil)
+		require.NoError(t, err)
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, store.ErrIllegalArguments)
+
+		require.NoError(t, r.Close())
+
+		r, err = engine.Query(context.Background(), nil, "SELECT id FROM table1 AS OF TX 'tx'", nil)
+		require.NoError(t, err)
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		require.NoError(t, r.Close())
+
+		params, err := engine.InferParameters(context.Background(), nil, "SELECT id FROM table1 AS OF TX @tx")
+		require.NoError(t, err)
+		require.Equal(t, map[string]SQLValueType{"tx": AnyType}, params)
+	})
+}
+
+func TestQueryWithCursor(t *testing.T) {
+	engine := setupCommonTest(t)
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR[50], active BOOLEAN, PRIMARY KEY id);
+		CREATE INDEX ON table1(title);
+	`, nil)
+	require.NoError(t, err)
+
+	for i := 1; i <= 10; i++ {
+		_, _, err = engine.Exec(context.Background(), nil,
+			"INSERT INTO table1 (title, active) VALUES (@title, @active)",
+			map[string]interface{}{"title": fmt.Sprintf("title%02d", i), "active": i%2 == 0},
+		)
+		require.NoError(t, err)
+	}
+
+	readPage := func(query string, params map[string]interface{}, cursor []byte, n int) ([]string, []byte) {
+		r, err := engine.QueryWithCursor(context.Background(), nil, query, params, cursor)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var rows []string
+
+		for len(rows) < n {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			rows = append(rows, fmt.Sprintf("%v", row.ValuesByPosition[0].Value()))
+		}
+
+		return rows, r.Cursor()
+	}
+
+	t.Run("pages should resume right after the latest row read", func(t *testing.T) {
+		query := "SELECT id FROM table1 WHERE active"
+
+		rows, cursor := readPage(query, nil, nil, 2)
+		require.Equal(t, []string{"2", "4"}, rows)
+
+		rows, cursor = readPage(query, nil, cursor, 2)
+		require.Equal(t, []string{"6", "8"}, rows)
+
+		rows, cursor = readPage(query, nil, cursor, 2)
+		require.Equal(t, []string{"10"}, rows)
+
+		rows, _ = readPage(query, nil, cursor, 2)
+		require.Empty(t, rows)
+	})
+
+	t.Run("pages should resume in the order of the index being scanned", func(t *testing.T) {
+		query := "SELECT title FROM table1 WHERE title < @title ORDER BY title DESC"
+		params := map[string]interface{}{"title": "title05"}
+
+		rows, cursor := readPage(query, params, nil, 3)
+		require.Equal(t, []string{"title04", "title03", "title02"}, rows)
+
+		rows, _ = readPage(query, params, cursor, 3)
+		require.Equal(t, []string{"title01"}, rows)
+	})
+
+	t.Run("pages should be read as of the transaction of the first one", func(t *testing.T) {
+		query := "SELECT id FROM table1"
+
+		rows, cursor := readPage(query, nil, nil, 5)
+		require.Equal(t, []string{"1", "2", "3", "4", "5"}, rows)
+
+		_, _, err = engine.Exec(context.Background(), nil, `
+			DELETE FROM table1 WHERE id = 6;
+			INSERT INTO table1 (title, active) VALUES ('title11', true);
+		`, nil)
+		require.NoError(t, err)
+
+		rows, _ = readPage(query, nil, cursor, 10)
+		require.Equal(t, []string{"6", "7", "8", "9", "10"}, rows)
+
+		rows, _ = readPage(query, nil, nil, 10)
+		require.Equal(t, []string{"1", "2", "3", "4", "5", "7", "8", "9", "10", "11"}, rows)
+	})
+
+	t.Run("cursors should be bound to the query and its parameters", func(t *testing.T) {
+		query := "SELECT title FROM table1 WHERE title > @title"
+
+		_, cursor := readPage(query, map[string]interface{}{"title": "title05"}, nil, 1)
+
+		_, err := engine.QueryWithCursor(context.Background(), nil, query, map[string]interface{}{"title": "title06"}, cursor)
+		require.ErrorIs(t, err, ErrInvalidCursor)
+
+		_, err = engine.QueryWithCursor(context.Background(), nil, "SELECT id FROM table1", nil, cursor)
+		require.ErrorIs(t, err, ErrInvalidCursor)
+
+		_, err = engine.QueryWithCursor(context.Background(), nil, query, nil, []byte{cursorVersion})
+		require.ErrorIs(t, err, ErrInvalidCursor)
+
+		tampered := append([]byte{}, cursor[:cursorHeaderLen]...)
+		tampered = append(tampered, []byte("unrelated key")...)
+
+		_, err = engine.QueryWithCursor(context.Background(), nil, query, map[string]interface{}{"title": "title05"}, tampered)
+		require.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("queries not resolved by a single scan should not support cursors", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "CREATE VIEW view1 AS SELECT id FROM table1 WHERE active", nil)
+		require.NoError(t, err)
 
-	_, err = res.Read(context.Background())
-	require.ErrorIs(t, err, ErrNoMoreRows)
+		for _, query := range []string{
+			"SELECT COUNT(*) FROM table1",
+			"SELECT DISTINCT active FROM table1",
+			"SELECT id FROM table1 LIMIT 2",
+			"SELECT id FROM table1 OFFSET 2",
+			"SELECT t1.id FROM table1 t1 INNER JOIN table1 t2 ON t1.id = t2.id",
+			"SELECT id FROM table1 UNION SELECT id FROM table1",
+			"SELECT id FROM (SELECT id FROM table1)",
+			"SELECT id FROM view1",
+		} {
+			_, err := engine.QueryWithCursor(context.Background(), nil, query, nil, nil)
+			require.ErrorIs(t, err, ErrCursorNotSupported, query)
+		}
 
-	err = res.Close()
-	require.NoError(t, err)
+		_, err = engine.QueryWithCursor(context.Background(), nil, "DELETE FROM table1", nil, nil)
+		require.ErrorIs(t, err, ErrExpectingDQLStmt)
+	})
 }
 
 func TestMultiDBCatalogQueries(t *testing.T) {
@@ -6111,3 +8282,457 @@ func TestCopyCatalogToTx(t *testing.T) {
 
 	})
 }
+
+func TestJSONType(t *testing.T) {
+	engine, st := setupCommonTestWithOptions(t, store.DefaultOptions())
+
+	_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE docs (id INTEGER AUTO_INCREMENT, doc JSON, small JSON[16], PRIMARY KEY id)", nil)
+	require.NoError(t, err)
+
+	t.Run("json columns can not be indexed", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "CREATE INDEX ON docs(doc)", nil)
+		require.ErrorIs(t, err, ErrLimitedKeyType)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE docs2 (doc JSON[64], PRIMARY KEY doc)", nil)
+		require.ErrorIs(t, err, ErrLimitedKeyType)
+	})
+
+	t.Run("json documents should be validated on insert", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "INSERT INTO docs (doc) VALUES ('{\"name\": ')", nil)
+		require.ErrorIs(t, err, ErrInvalidValue)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO docs (doc) VALUES ('{} []')", nil)
+		require.ErrorIs(t, err, ErrInvalidValue)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO docs (doc) VALUES (10)", nil)
+		require.ErrorIs(t, err, ErrUnsupportedCast)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO docs (small) VALUES ('{\"name\": \"a long name\"}')", nil)
+		require.ErrorIs(t, err, ErrMaxLengthExceeded)
+	})
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		INSERT INTO docs (doc)
+		VALUES
+			('{"name": "alice", "age": 30, "address": {"city": "rome"}, "tags": ["a", "b"]}'),
+			(CAST('{"name": "bob", "age": 25, "address": {"city": "madrid"}, "tags": []}' AS JSON)),
+			(@doc),
+			('[1, 2.5, null]')`,
+		map[string]interface{}{"doc": `{"name": "carol", "address": null, "big": 12345678901234567890}`})
+	require.NoError(t, err)
+
+	readIDs := func(t *testing.T, q string) []int64 {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var ids []int64
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			ids = append(ids, row.ValuesByPosition[0].Value().(int64))
+		}
+
+		return ids
+	}
+
+	t.Run("json documents should be stored in canonical form", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT id, doc FROM docs", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		cols, err := r.Columns(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, JSONType, cols[1].Type)
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, `{"address":{"city":"rome"},"age":30,"name":"alice","tags":["a","b"]}`, row.ValuesByPosition[1].Value())
+
+		_, err = r.Read(context.Background())
+		require.NoError(t, err)
+
+		row, err = r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, `{"address":null,"big":12345678901234567890,"name":"carol"}`, row.ValuesByPosition[1].Value())
+	})
+
+	t.Run("json values should be extracted", func(t *testing.T) {
+		require.Equal(t, []int64{1}, readIDs(t, "SELECT id FROM docs WHERE doc->>'name' = 'alice'"))
+		require.Equal(t, []int64{2}, readIDs(t, "SELECT id FROM docs WHERE doc->'address'->>'city' = 'madrid'"))
+		require.Equal(t, []int64{1}, readIDs(t, "SELECT id FROM docs WHERE doc->'tags'->>1 = 'b'"))
+		require.Equal(t, []int64{4}, readIDs(t, "SELECT id FROM docs WHERE doc->>1 = '2.5'"))
+		require.Equal(t, []int64{1}, readIDs(t, "SELECT id FROM docs WHERE doc->'age' = CAST('30' AS JSON)"))
+		require.Equal(t, []int64{3}, readIDs(t, "SELECT id FROM docs WHERE doc->>'big' = '12345678901234567890'"))
+		require.Equal(t, []int64{1, 2}, readIDs(t, "SELECT id FROM docs WHERE doc->>'name' LIKE '^[ab]'"))
+
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM docs WHERE doc->>@key = 'carol'", map[string]interface{}{"key": "name"})
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(3), row.ValuesByPosition[0].Value())
+	})
+
+	t.Run("missing json values should be null", func(t *testing.T) {
+		require.Equal(t, []int64{3, 4}, readIDs(t, "SELECT id FROM docs WHERE doc->>'age' IS NULL"))
+		require.Equal(t, []int64{3, 4}, readIDs(t, "SELECT id FROM docs WHERE doc->'address'->>'city' IS NULL"))
+		require.Equal(t, []int64{2, 3, 4}, readIDs(t, "SELECT id FROM docs WHERE doc->'tags'->>0 IS NULL"))
+		require.Equal(t, []int64{4}, readIDs(t, "SELECT id FROM docs WHERE doc->>2 IS NULL AND doc->2 IS NOT NULL"))
+	})
+
+	t.Run("json values should be extracted using text or integer values only", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM docs WHERE doc->true = 'x'", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		r, err = engine.Query(context.Background(), nil, "SELECT id FROM docs WHERE id->'name' = 'x'", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrInvalidTypes)
+	})
+
+	t.Run("json documents should be updated", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "UPDATE docs SET doc = '{\"name\": \"dave\"}' WHERE id = 4", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "UPDATE docs SET doc = 'dave' WHERE id = 4", nil)
+		require.ErrorIs(t, err, ErrInvalidValue)
+
+		require.Equal(t, []int64{4}, readIDs(t, "SELECT id FROM docs WHERE doc->>'name' = 'dave'"))
+	})
+
+	t.Run("json documents should be cast as text", func(t *testing.T) {
+		require.Equal(t, []int64{4}, readIDs(t, "SELECT id FROM docs WHERE CAST(doc AS VARCHAR) = '{\"name\":\"dave\"}'"))
+	})
+
+	t.Run("json columns should be loaded from the catalog", func(t *testing.T) {
+		engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "USE DATABASE db1", nil)
+		require.NoError(t, err)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM docs WHERE doc->'address'->>'city' = 'rome'", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(1), row.ValuesByPosition[0].Value())
+	})
+}
+
+func TestDecimalType(t *testing.T) {
+	engine, st := setupCommonTestWithOptions(t, store.DefaultOptions())
+
+	_, _, err := engine.Exec(context.Background(), nil, `
+		CREATE TABLE payments (
+			id INTEGER AUTO_INCREMENT,
+			account VARCHAR[16],
+			amount DECIMAL(10,2),
+			rate NUMERIC(5),
+			PRIMARY KEY id
+		)`, nil)
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, "CREATE INDEX ON payments(amount)", nil)
+	require.NoError(t, err)
+
+	t.Run("precision and scale should be validated", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "CREATE TABLE t1 (id INTEGER, amount DECIMAL(19,2), PRIMARY KEY id)", nil)
+		require.ErrorIs(t, err, ErrLimitedPrecision)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE t1 (id INTEGER, amount DECIMAL(2,3), PRIMARY KEY id)", nil)
+		require.ErrorIs(t, err, ErrLimitedPrecision)
+
+		_, _, err = engine.Exec(context.Background(), nil, "CREATE TABLE t1 (id INTEGER(10), PRIMARY KEY id)", nil)
+		require.ErrorIs(t, err, ErrLimitedPrecision)
+
+		_, _, err = engine.Exec(context.Background(), nil, "ALTER TABLE payments ADD COLUMN fee VARCHAR(10,2)", nil)
+		require.ErrorIs(t, err, ErrLimitedPrecision)
+	})
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		INSERT INTO payments (account, amount, rate)
+		VALUES
+			('acc1', 10.10, 1),
+			('acc1', 0.20, 2.5),
+			('acc2', '-3.005', 0),
+			('acc2', 1000, NULL),
+			('acc3', @amount, 3)`,
+		map[string]interface{}{"amount": "99999999.99"})
+	require.NoError(t, err)
+
+	t.Run("values exceeding the precision should be rejected", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "INSERT INTO payments (account, amount) VALUES ('acc4', 100000000)", nil)
+		require.ErrorIs(t, err, ErrNumericValueOutOfRange)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO payments (account, amount) VALUES ('acc4', 99999999.995)", nil)
+		require.ErrorIs(t, err, ErrNumericValueOutOfRange)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO payments (account, amount) VALUES ('acc4', 'ten')", nil)
+		require.ErrorIs(t, err, ErrInvalidValue)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO payments (account, amount) VALUES ('acc4', true)", nil)
+		require.ErrorIs(t, err, ErrUnsupportedCast)
+	})
+
+	readValues := func(t *testing.T, q string) []string {
+		r, err := engine.Query(context.Background(), nil, q, nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var vals []string
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			vals = append(vals, fmt.Sprintf("%v", row.ValuesByPosition[0].Value()))
+		}
+
+		return vals
+	}
+
+	t.Run("decimal values should be rounded to the scale of the column", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT amount, rate FROM payments", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		cols, err := r.Columns(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, DecimalType, cols[0].Type)
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "10.10", row.ValuesByPosition[0].Value())
+		require.Equal(t, "1", row.ValuesByPosition[1].Value())
+
+		row, err = r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "0.20", row.ValuesByPosition[0].Value())
+		require.Equal(t, "3", row.ValuesByPosition[1].Value())
+
+		row, err = r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "-3.01", row.ValuesByPosition[0].Value())
+	})
+
+	t.Run("decimal values should be ordered by the index", func(t *testing.T) {
+		require.Equal(t,
+			[]string{"-3.01", "0.20", "10.10", "1000.00", "99999999.99"},
+			readValues(t, "SELECT amount FROM payments USE INDEX ON (amount)"),
+		)
+
+		require.Equal(t,
+			[]string{"99999999.99", "1000.00", "10.10", "0.20", "-3.01"},
+			readValues(t, "SELECT amount FROM payments ORDER BY amount DESC"),
+		)
+	})
+
+	t.Run("index range bounds beyond the encodable values should be clamped", func(t *testing.T) {
+		require.Equal(t,
+			[]string{"-3.01", "0.20", "10.10", "1000.00", "99999999.99"},
+			readValues(t, "SELECT amount FROM payments USE INDEX ON (amount) WHERE amount > -100000000000000000.00 AND amount < 100000000000000000000.00"),
+		)
+
+		require.Equal(t,
+			[]string{"99999999.99", "1000.00"},
+			readValues(t, "SELECT amount FROM payments USE INDEX ON (amount) WHERE amount >= 1000 AND amount <= CAST('100000000000000000000' AS DECIMAL) ORDER BY amount DESC"),
+		)
+
+		require.Empty(t, readValues(t, "SELECT amount FROM payments USE INDEX ON (amount) WHERE amount > 100000000000000000000.00"))
+	})
+
+	t.Run("decimal values should be compared with numeric values", func(t *testing.T) {
+		require.Equal(t, []string{"10.10", "1000.00", "99999999.99"}, readValues(t, "SELECT amount FROM payments WHERE amount > 10"))
+		require.Equal(t, []string{"10.10"}, readValues(t, "SELECT amount FROM payments WHERE amount = 10.1"))
+		require.Equal(t, []string{"0.20", "10.10"}, readValues(t, "SELECT amount FROM payments WHERE amount > 0.195 AND amount <= 10.101"))
+		require.Equal(t, []string{"0.20", "10.10"}, readValues(t, "SELECT amount FROM payments USE INDEX ON (amount) WHERE amount > 0.195 AND amount <= 10.101"))
+		require.Equal(t, []string{"-3.01", "0.20"}, readValues(t, "SELECT amount FROM payments USE INDEX ON (amount) WHERE amount < 0.201"))
+		require.Equal(t, []string{"0.20"}, readValues(t, "SELECT amount FROM payments WHERE amount >= 0.2 AND amount < 10"))
+		require.Equal(t, []string{"2"}, readValues(t, "SELECT id FROM payments WHERE amount * 3 = 0.6"))
+	})
+
+	t.Run("arithmetic should be exact", func(t *testing.T) {
+		require.Equal(t, []string{"3"}, readValues(t, "SELECT id FROM payments WHERE amount + 3.01 = 0"))
+		require.Equal(t, []string{"1"}, readValues(t, "SELECT id FROM payments WHERE amount - 0.1 = 10"))
+		require.Equal(t, []string{"1"}, readValues(t, "SELECT id FROM payments WHERE amount / 3 = 3.366667"))
+		require.Equal(t, []string{"4"}, readValues(t, "SELECT id FROM payments WHERE amount * -1.5 = -1500"))
+
+		r, err := engine.Query(context.Background(), nil, "SELECT id FROM payments WHERE amount / 0.0 = 1", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read(context.Background())
+		require.ErrorIs(t, err, ErrDivisionByZero)
+	})
+
+	t.Run("decimal values should be aggregated", func(t *testing.T) {
+		r, err := engine.Query(context.Background(), nil, "SELECT SUM(amount), AVG(amount), MIN(amount), MAX(amount), SUM(rate) FROM payments", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		cols, err := r.Columns(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, DecimalType, cols[0].Type)
+		require.Equal(t, DecimalType, cols[1].Type)
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "100001007.28", row.ValuesByPosition[0].Value())
+		require.Equal(t, "20000201.456000", row.ValuesByPosition[1].Value())
+		require.Equal(t, "-3.01", row.ValuesByPosition[2].Value())
+		require.Equal(t, "99999999.99", row.ValuesByPosition[3].Value())
+		require.Equal(t, "7", row.ValuesByPosition[4].Value())
+
+		require.Equal(t,
+			[]string{"10.30", "996.99"},
+			readValues(t, "SELECT SUM(amount) FROM payments GROUP BY account HAVING SUM(amount) < 1000.5"),
+		)
+
+		require.Equal(t,
+			[]string{"0"},
+			readValues(t, "SELECT SUM(amount) FROM payments WHERE amount > 100000000"),
+		)
+	})
+
+	t.Run("decimal values should be updated", func(t *testing.T) {
+		_, _, err := engine.Exec(context.Background(), nil, "UPDATE payments SET amount = amount * 1.005 WHERE id = 1", nil)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"10.15"}, readValues(t, "SELECT amount FROM payments WHERE id = 1"))
+	})
+
+	t.Run("decimal values should be cast", func(t *testing.T) {
+		require.Equal(t, []string{"1"}, readValues(t, "SELECT id FROM payments WHERE CAST(amount AS VARCHAR) = '10.15'"))
+		require.Equal(t, []string{"3"}, readValues(t, "SELECT id FROM payments WHERE amount < CAST(' -3 ' AS DECIMAL)"))
+	})
+
+	t.Run("decimal columns should be loaded from the catalog", func(t *testing.T) {
+		engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "USE DATABASE db1", nil)
+		require.NoError(t, err)
+
+		_, _, err = engine.Exec(context.Background(), nil, "INSERT INTO payments (account, amount) VALUES ('acc5', 12.345)", nil)
+		require.NoError(t, err)
+
+		r, err := engine.Query(context.Background(), nil, "SELECT amount FROM payments WHERE account = 'acc5'", nil)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "12.35", row.ValuesByPosition[0].Value())
+
+		catalog, err := engine.Catalog(context.Background(), nil)
+		require.NoError(t, err)
+
+		table, err := catalog.GetTableByName("db1", "payments")
+		require.NoError(t, err)
+
+		col, err := table.GetColumnByName("amount")
+		require.NoError(t, err)
+		require.Equal(t, 10, col.Precision())
+		require.Equal(t, 2, col.Scale())
+	})
+}
+
+func TestExplain(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+	defer closeStore(t, st)
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, _, err = engine.Exec(context.Background(), nil, `
+		CREATE DATABASE db1;
+		USE DATABASE db1;
+		CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR[50], active BOOLEAN, PRIMARY KEY id);
+		CREATE INDEX ON table1(title);
+		CREATE TABLE table2 (id INTEGER AUTO_INCREMENT, table1_id INTEGER, amount INTEGER, PRIMARY KEY id);
+		INSERT INTO table1 (title, active) VALUES ('title1', true), ('title2', false);
+	`, nil)
+	require.NoError(t, err)
+
+	explain := func(query string, params map[string]interface{}) []string {
+		r, err := engine.Query(context.Background(), nil, query, params)
+		require.NoError(t, err)
+		defer r.Close()
+
+		cols, err := r.Columns(context.Background())
+		require.NoError(t, err)
+		require.Len(t, cols, 1)
+		require.Equal(t, "plan", cols[0].Column)
+		require.Equal(t, VarcharType, cols[0].Type)
+
+		var plan []string
+
+		for {
+			row, err := r.Read(context.Background())
+			if errors.Is(err, ErrNoMoreRows) {
+				break
+			}
+			require.NoError(t, err)
+
+			plan = append(plan, row.ValuesByPosition[0].Value().(string))
+		}
+
+		return plan
+	}
+
+	require.Equal(t, []string{
+		"Project id, title",
+		"-> Full scan table1 using index table1[id] ASC",
+	}, explain("EXPLAIN SELECT id, title FROM table1", nil))
+
+	require.Equal(t, []string{
+		"Limit 10",
+		"-> Offset 1",
+		"  -> Project id",
+		"    -> Filter ((title = @title) AND active)",
+		"      -> Range scan table1 using index table1[title] DESC (title = 'title1')",
+	}, explain("EXPLAIN SELECT id FROM table1 USE INDEX ON (title) WHERE title = @title AND active ORDER BY title DESC LIMIT 10 OFFSET 1", map[string]interface{}{"title": "title1"}))
+
+	require.Equal(t, []string{
+		"Project t1.title, SUM(t2.amount)",
+		"-> Group by t1.title (in memory)",
+		"  -> Filter ((t1.id > 0) AND (t1.id <= 10))",
+		"    -> Nested loop join INNER JOIN table2 AS t2 ON (t1.id = t2.table1_id)",
+		"      -> Range scan table1 AS t1 using index table1[id] ASC (id > 0 AND id <= 10)",
+	}, explain("EXPLAIN SELECT t1.title, SUM(t2.amount) FROM table1 t1 INNER JOIN table2 t2 ON t1.id = t2.table1_id WHERE t1.id > 0 AND t1.id <= 10 GROUP BY t1.title", nil))
+
+	require.Equal(t, []string{
+		"Distinct",
+		"-> Union all",
+		"  -> Project id",
+		"    -> Full scan table1 using index table1[id] ASC",
+		"  -> Project id",
+		"    -> Full scan table2 using index table2[id] ASC",
+	}, explain("EXPLAIN SELECT id FROM table1 UNION SELECT id FROM table2", nil))
+
+	_, err = engine.Query(context.Background(), nil, "EXPLAIN SELECT id FROM table3", nil)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	params, err := engine.InferParameters(context.Background(), nil, "EXPLAIN SELECT id FROM table1 WHERE title = @title")
+	require.NoError(t, err)
+	require.Equal(t, map[string]SQLValueType{"title": VarcharType}, params)
+}
@@ -0,0 +1,460 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ExplainStmt describes the plan a query would be resolved with, one row per row reader
+// from the outermost one. Joined data sources are resolved for each row being joined,
+// so they are described by the join instead of by their own row readers.
+type ExplainStmt struct {
+	query DataSource
+}
+
+func (stmt *ExplainStmt) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
+	return stmt.query.inferParameters(ctx, tx, params)
+}
+
+func (stmt *ExplainStmt) execAt(ctx context.Context, tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	return stmt.query.execAt(ctx, tx, params)
+}
+
+func (stmt *ExplainStmt) Resolve(ctx context.Context, tx *SQLTx, params map[string]interface{}, _ *ScanSpecs) (RowReader, error) {
+	rowReader, err := stmt.query.Resolve(ctx, tx, params, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []string
+	explainRowReader(rowReader, 0, &plan)
+
+	err = rowReader.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	cols := []ColDescriptor{
+		{
+			Column: "plan",
+			Type:   VarcharType,
+		},
+	}
+
+	values := make([][]ValueExp, len(plan))
+
+	for i, step := range plan {
+		values[i] = []ValueExp{&Varchar{val: step}}
+	}
+
+	return newValuesRowReader(ctx, tx, cols, "*", stmt.Alias(), values)
+}
+
+func (stmt *ExplainStmt) Alias() string {
+	return "explain"
+}
+
+func explainRowReader(rowReader RowReader, depth int, plan *[]string) {
+	step := func(format string, args ...interface{}) {
+		indent := ""
+		if depth > 0 {
+			indent = strings.Repeat("  ", depth-1) + "-> "
+		}
+
+		*plan = append(*plan, indent+fmt.Sprintf(format, args...))
+	}
+
+	switch r := rowReader.(type) {
+	case *rawRowReader:
+		{
+			step("%s", explainScan(r))
+		}
+	case *conditionalRowReader:
+		{
+			step("Filter %s", expString(r.condition))
+			explainRowReader(r.rowReader, depth+1, plan)
+		}
+	case *projectedRowReader:
+		{
+			sels := make([]string, len(r.selectors))
+			for i, sel := range r.selectors {
+				sels[i] = expString(sel)
+			}
+
+			if len(sels) == 0 {
+				sels = []string{"*"}
+			}
+
+			step("Project %s", strings.Join(sels, ", "))
+			explainRowReader(r.rowReader, depth+1, plan)
+		}
+	case *groupedRowReader:
+		{
+			groupBy := make([]string, len(r.groupBy))
+			for i, col := range r.groupBy {
+				groupBy[i] = expString(col)
+			}
+
+			strategy := "in memory"
+			if r.ordered {
+				strategy = "in index order"
+			}
+
+			if len(groupBy) == 0 {
+				step("Aggregate")
+			} else {
+				step("Group by %s (%s)", strings.Join(groupBy, ", "), strategy)
+			}
+
+			explainRowReader(r.rowReader, depth+1, plan)
+		}
+	case *windowRowReader:
+		{
+			fns := make([]string, len(r.windowFns))
+			for i, fn := range r.windowFns {
+				fns[i] = expString(fn)
+			}
+
+			step("Window %s", strings.Join(fns, ", "))
+			explainRowReader(r.rowReader, depth+1, plan)
+		}
+	case *jointRowReader:
+		{
+			joins := make([]string, len(r.joins))
+
+			for i, join := range r.joins {
+				joinType := join.joinType
+				if i == 0 && r.rightJoin {
+					joinType = RightJoin
+				}
+
+				joins[i] = fmt.Sprintf("%s %s ON %s", joinTypeString(joinType), explainDataSource(join.ds), expString(join.cond))
+			}
+
+			step("Nested loop join %s", strings.Join(joins, ", "))
+			explainRowReader(r.rowReader, depth+1, plan)
+		}
+	case *distinctRowReader:
+		{
+			step("Distinct")
+			explainRowReader(r.rowReader, depth+1, plan)
+		}
+	case *offsetRowReader:
+		{
+			step("Offset %d", r.offset)
+			explainRowReader(r.rowReader, depth+1, plan)
+		}
+	case *limitRowReader:
+		{
+			step("Limit %d", r.limit)
+			explainRowReader(r.rowReader, depth+1, plan)
+		}
+	case *unionRowReader:
+		{
+			step("Union all")
+
+			for _, rr := range r.rowReaders {
+				explainRowReader(rr, depth+1, plan)
+			}
+		}
+	case *setOpRowReader:
+		{
+			op := "Intersect"
+			if r.op == ExceptOp {
+				op = "Except"
+			}
+
+			if !r.distinct {
+				op += " all"
+			}
+
+			step("%s", op)
+			explainRowReader(r.leftReader, depth+1, plan)
+			explainRowReader(r.rightReader, depth+1, plan)
+		}
+	case *valuesRowReader:
+		{
+			step("Values %s (%d rows)", r.tableAlias, len(r.values))
+		}
+	default:
+		{
+			step("%T", rowReader)
+		}
+	}
+}
+
+func explainScan(r *rawRowReader) string {
+	var b strings.Builder
+
+	scanSpecs := r.scanSpecs
+
+	var ranges []string
+
+	for _, col := range scanSpecs.Index.cols {
+		colRange, ok := scanSpecs.rangesByColID[col.id]
+		if !ok {
+			continue
+		}
+
+		if colRange.lRange != nil && colRange.hRange != nil && colRange.lRange.inclusive && colRange.hRange.inclusive {
+			cmp, err := colRange.lRange.val.Compare(colRange.hRange.val)
+			if err == nil && cmp == 0 {
+				ranges = append(ranges, fmt.Sprintf("%s = %s", col.colName, expString(colRange.lRange.val)))
+				continue
+			}
+		}
+
+		if colRange.lRange != nil {
+			op := ">"
+			if colRange.lRange.inclusive {
+				op = ">="
+			}
+
+			ranges = append(ranges, fmt.Sprintf("%s %s %s", col.colName, op, expString(colRange.lRange.val)))
+		}
+
+		if colRange.hRange != nil {
+			op := "<"
+			if colRange.hRange.inclusive {
+				op = "<="
+			}
+
+			ranges = append(ranges, fmt.Sprintf("%s %s %s", col.colName, op, expString(colRange.hRange.val)))
+		}
+	}
+
+	if len(ranges) == 0 {
+		b.WriteString("Full scan ")
+	} else {
+		b.WriteString("Range scan ")
+	}
+
+	b.WriteString(r.table.name)
+
+	if r.tableAlias != r.table.name {
+		b.WriteString(" AS ")
+		b.WriteString(r.tableAlias)
+	}
+
+	b.WriteString(" using index ")
+	b.WriteString(scanSpecs.Index.Name())
+
+	if scanSpecs.DescOrder {
+		b.WriteString(" DESC")
+	} else {
+		b.WriteString(" ASC")
+	}
+
+	if len(ranges) > 0 {
+		b.WriteString(" (")
+		b.WriteString(strings.Join(ranges, " AND "))
+		b.WriteString(")")
+	}
+
+	if scanSpecs.Index.IsPartial() {
+		b.WriteString(" on rows where ")
+		b.WriteString(scanSpecs.Index.Predicate())
+	}
+
+	if r.period.start != nil || r.period.end != nil {
+		b.WriteString(" within period")
+	}
+
+	if r.period.asOf != nil {
+		if r.period.asOf.instantType == txInstant {
+			b.WriteString(" as of tx ")
+		} else {
+			b.WriteString(" as of timestamp ")
+		}
+
+		b.WriteString(expString(r.period.asOf.exp))
+	}
+
+	return b.String()
+}
+
+func explainDataSource(ds DataSource) string {
+	switch ds := ds.(type) {
+	case *tableRef:
+		{
+			if ds.as != "" && ds.as != ds.table {
+				return fmt.Sprintf("%s AS %s", ds.table, ds.as)
+			}
+
+			return ds.table
+		}
+	case *SelectStmt:
+		{
+			return fmt.Sprintf("(subquery) AS %s", ds.Alias())
+		}
+	}
+
+	return ds.Alias()
+}
+
+func joinTypeString(joinType JoinType) string {
+	switch joinType {
+	case LeftJoin:
+		return "LEFT JOIN"
+	case RightJoin:
+		return "RIGHT JOIN"
+	}
+
+	return "INNER JOIN"
+}
+
+var cmpOpStrings = map[CmpOperator]string{
+	EQ: "=",
+	NE: "!=",
+	LT: "<",
+	LE: "<=",
+	GT: ">",
+	GE: ">=",
+}
+
+var numOpStrings = map[NumOperator]string{
+	ADDOP:  "+",
+	SUBSOP: "-",
+	DIVOP:  "/",
+	MULTOP: "*",
+}
+
+// expString returns a textual representation of the expression, meant for describing query plans
+func expString(exp ValueExp) string {
+	switch e := exp.(type) {
+	case *NullValue:
+		return "NULL"
+	case *Number:
+		return strconv.FormatInt(e.val, 10)
+	case *Decimal:
+		return e.String()
+	case *Varchar:
+		return "'" + strings.ReplaceAll(e.val, "'", "''") + "'"
+	case *Bool:
+		if e.val {
+			return "TRUE"
+		}
+		return "FALSE"
+	case *Blob:
+		return "x'" + hex.EncodeToString(e.val) + "'"
+	case *Timestamp:
+		return "'" + e.val.Format("2006-01-02 15:04:05.999999") + "'"
+	case *JSON:
+		{
+			b, err := json.Marshal(e.val)
+			if err != nil {
+				return "?"
+			}
+			return "'" + string(b) + "'"
+		}
+	case *Param:
+		{
+			if e.pos > 0 {
+				return fmt.Sprintf("$%d", e.pos)
+			}
+			return "@" + e.id
+		}
+	case *ColSelector:
+		{
+			if e.table != "" {
+				return e.table + "." + e.col
+			}
+			return e.col
+		}
+	case *AggColSelector:
+		{
+			if e.table != "" {
+				return fmt.Sprintf("%s(%s.%s)", e.aggFn, e.table, e.col)
+			}
+			return fmt.Sprintf("%s(%s)", e.aggFn, e.col)
+		}
+	case *WindowFnSelector:
+		return fmt.Sprintf("%s(%s) OVER (...)", strings.ToUpper(e.fn), expsString(e.params))
+	case *FnCall:
+		return fmt.Sprintf("%s(%s)", strings.ToUpper(e.fn), expsString(e.params))
+	case *Cast:
+		return fmt.Sprintf("CAST(%s AS %s)", expString(e.val), e.t)
+	case *NumExp:
+		return fmt.Sprintf("(%s %s %s)", expString(e.left), numOpStrings[e.op], expString(e.right))
+	case *NotBoolExp:
+		return fmt.Sprintf("NOT %s", expString(e.exp))
+	case *LikeBoolExp:
+		{
+			op := "LIKE"
+			if e.notLike {
+				op = "NOT LIKE"
+			}
+			return fmt.Sprintf("(%s %s %s)", expString(e.val), op, expString(e.pattern))
+		}
+	case *CmpBoolExp:
+		return fmt.Sprintf("(%s %s %s)", expString(e.left), cmpOpStrings[e.op], expString(e.right))
+	case *BinBoolExp:
+		{
+			op := "AND"
+			if e.op == OR {
+				op = "OR"
+			}
+			return fmt.Sprintf("(%s %s %s)", expString(e.left), op, expString(e.right))
+		}
+	case *InListExp:
+		{
+			op := "IN"
+			if e.notIn {
+				op = "NOT IN"
+			}
+			return fmt.Sprintf("(%s %s (%s))", expString(e.val), op, expsString(e.values))
+		}
+	case *InSubQueryExp:
+		{
+			op := "IN"
+			if e.notIn {
+				op = "NOT IN"
+			}
+			return fmt.Sprintf("(%s %s (subquery))", expString(e.val), op)
+		}
+	case *ExistsBoolExp:
+		return "EXISTS (subquery)"
+	case *ScalarSubQueryExp:
+		return "(subquery)"
+	case *JSONExtractExp:
+		{
+			op := "->"
+			if e.asText {
+				op = "->>"
+			}
+			return fmt.Sprintf("%s%s%s", expString(e.val), op, expString(e.path))
+		}
+	}
+
+	return "?"
+}
+
+func expsString(exps []ValueExp) string {
+	strs := make([]string, len(exps))
+
+	for i, exp := range exps {
+		strs[i] = expString(exp)
+	}
+
+	return strings.Join(strs, ", ")
+}
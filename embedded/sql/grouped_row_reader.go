@@ -18,6 +18,7 @@ package sql
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 
 	"github.com/codenotary/immudb/embedded/store"
@@ -28,30 +29,136 @@ type groupedRowReader struct {
 
 	selectors []Selector
 
+	// aggregations referenced by the having clause but not selected
+	aggregations []*AggColSelector
+
 	groupBy []*ColSelector
 
+	// rows are grouped in memory when not read in grouping order
+	ordered bool
+	groups  []*Row
+
 	currRow  *Row
 	nonEmpty bool
 }
 
-func newGroupedRowReader(rowReader RowReader, selectors []Selector, groupBy []*ColSelector) (*groupedRowReader, error) {
-	if rowReader == nil || len(selectors) == 0 || len(groupBy) > 1 {
+func newGroupedRowReader(rowReader RowReader, selectors []Selector, groupBy []*ColSelector, having ValueExp) (*groupedRowReader, error) {
+	if rowReader == nil || len(selectors) == 0 {
 		return nil, ErrIllegalArguments
 	}
 
-	// TODO: leverage multi-column indexing
-	if len(groupBy) == 1 &&
-		rowReader.OrderBy()[0].Selector() != EncodeSelector(groupBy[0].resolve(rowReader.Database(), rowReader.TableAlias())) {
-		return nil, ErrLimitedGroupBy
+	selected := make(map[string]struct{}, len(selectors))
+	for _, sel := range selectors {
+		selected[EncodeSelector(sel.resolve(rowReader.Database(), rowReader.TableAlias()))] = struct{}{}
+	}
+
+	var aggregations []*AggColSelector
+
+	for _, aggSel := range aggregationsIn(having) {
+		encSel := EncodeSelector(aggSel.resolve(rowReader.Database(), rowReader.TableAlias()))
+
+		_, ok := selected[encSel]
+		if ok {
+			continue
+		}
+
+		selected[encSel] = struct{}{}
+		aggregations = append(aggregations, aggSel)
 	}
 
 	return &groupedRowReader{
-		rowReader: rowReader,
-		selectors: selectors,
-		groupBy:   groupBy,
+		rowReader:    rowReader,
+		selectors:    selectors,
+		aggregations: aggregations,
+		groupBy:      groupBy,
+		ordered:      readInGroupingOrder(rowReader, groupBy),
 	}, nil
 }
 
+// readInGroupingOrder returns true when rows belonging to the same group are read consecutively,
+// which is the case when the leading ordering columns are the grouping columns
+func readInGroupingOrder(rowReader RowReader, groupBy []*ColSelector) bool {
+	orderBy := rowReader.OrderBy()
+
+	if len(orderBy) < len(groupBy) {
+		return false
+	}
+
+	leadingCols := make(map[string]struct{}, len(groupBy))
+	for _, col := range orderBy[:len(groupBy)] {
+		leadingCols[col.Selector()] = struct{}{}
+	}
+
+	for _, sel := range groupBy {
+		_, ok := leadingCols[EncodeSelector(sel.resolve(rowReader.Database(), rowReader.TableAlias()))]
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// aggregationsIn returns the aggregations used in the expression, subqueries excluded
+func aggregationsIn(exp ValueExp) []*AggColSelector {
+	switch e := exp.(type) {
+	case *AggColSelector:
+		{
+			return []*AggColSelector{e}
+		}
+	case *NumExp:
+		{
+			return append(aggregationsIn(e.left), aggregationsIn(e.right)...)
+		}
+	case *CmpBoolExp:
+		{
+			return append(aggregationsIn(e.left), aggregationsIn(e.right)...)
+		}
+	case *BinBoolExp:
+		{
+			return append(aggregationsIn(e.left), aggregationsIn(e.right)...)
+		}
+	case *NotBoolExp:
+		{
+			return aggregationsIn(e.exp)
+		}
+	case *LikeBoolExp:
+		{
+			return aggregationsIn(e.val)
+		}
+	case *Cast:
+		{
+			return aggregationsIn(e.val)
+		}
+	case *JSONExtractExp:
+		{
+			return append(aggregationsIn(e.val), aggregationsIn(e.path)...)
+		}
+	case *InListExp:
+		{
+			aggregations := aggregationsIn(e.val)
+			for _, v := range e.values {
+				aggregations = append(aggregations, aggregationsIn(v)...)
+			}
+			return aggregations
+		}
+	case *InSubQueryExp:
+		{
+			return aggregationsIn(e.val)
+		}
+	case *FnCall:
+		{
+			var aggregations []*AggColSelector
+			for _, p := range e.params {
+				aggregations = append(aggregations, aggregationsIn(p)...)
+			}
+			return aggregations
+		}
+	}
+
+	return nil
+}
+
 func (gr *groupedRowReader) onClose(callback func()) {
 	gr.rowReader.onClose(callback)
 }
@@ -98,13 +205,14 @@ func (gr *groupedRowReader) colsBySelector(ctx context.Context) (map[string]ColD
 		return nil, err
 	}
 
-	for _, sel := range gr.selectors {
-		aggFn, db, table, col := sel.resolve(gr.rowReader.Database(), gr.rowReader.TableAlias())
-
-		if aggFn == "" {
+	for _, sel := range gr.aggregatedSelectors() {
+		_, isAggregation := sel.(*AggColSelector)
+		if !isAggregation {
 			continue
 		}
 
+		aggFn, db, table, col := sel.resolve(gr.rowReader.Database(), gr.rowReader.TableAlias())
+
 		des := ColDescriptor{
 			AggFn:    aggFn,
 			Database: db,
@@ -129,6 +237,10 @@ func (gr *groupedRowReader) colsBySelector(ctx context.Context) (map[string]ColD
 			colDescriptors[encSel] = colDesc
 		} else {
 			// SUM, AVG
+			if colDesc.Type == DecimalType {
+				des.Type = DecimalType
+			}
+
 			colDescriptors[encSel] = des
 		}
 	}
@@ -136,6 +248,18 @@ func (gr *groupedRowReader) colsBySelector(ctx context.Context) (map[string]ColD
 	return colDescriptors, nil
 }
 
+// aggregatedSelectors returns the selected columns followed by the aggregations only referenced by the having clause
+func (gr *groupedRowReader) aggregatedSelectors() []Selector {
+	selectors := make([]Selector, len(gr.selectors), len(gr.selectors)+len(gr.aggregations))
+	copy(selectors, gr.selectors)
+
+	for _, aggSel := range gr.aggregations {
+		selectors = append(selectors, aggSel)
+	}
+
+	return selectors
+}
+
 func allAgregations(selectors []Selector) bool {
 	for _, sel := range selectors {
 		_, isAggregation := sel.(*AggColSelector)
@@ -168,6 +292,14 @@ func zeroForType(t SQLValueType) TypedValue {
 		{
 			return &Timestamp{}
 		}
+	case JSONType:
+		{
+			return &JSON{}
+		}
+	case DecimalType:
+		{
+			return decimalFromInt(0)
+		}
 	}
 	return nil
 }
@@ -185,39 +317,15 @@ func (gr *groupedRowReader) SetParameters(params map[string]interface{}) error {
 }
 
 func (gr *groupedRowReader) Read(ctx context.Context) (*Row, error) {
+	if !gr.ordered {
+		return gr.readGroup(ctx)
+	}
+
 	for {
 		row, err := gr.rowReader.Read(ctx)
 		if err == store.ErrNoMoreEntries {
 			if !gr.nonEmpty && allAgregations(gr.selectors) {
-				// special case when all selectors are aggregations
-				zeroRow := &Row{
-					ValuesByPosition: make([]TypedValue, len(gr.selectors)),
-					ValuesBySelector: make(map[string]TypedValue, len(gr.selectors)),
-				}
-
-				colsBySelector, err := gr.colsBySelector(ctx)
-				if err != nil {
-					return nil, err
-				}
-
-				for i, sel := range gr.selectors {
-					aggFn, db, table, col := sel.resolve(gr.rowReader.Database(), gr.rowReader.TableAlias())
-					encSel := EncodeSelector(aggFn, db, table, col)
-
-					var zero TypedValue
-					if aggFn == COUNT || aggFn == SUM || aggFn == AVG {
-						zero = zeroForType(IntegerType)
-					} else {
-						zero = zeroForType(colsBySelector[encSel].Type)
-					}
-
-					zeroRow.ValuesByPosition[i] = zero
-					zeroRow.ValuesBySelector[encSel] = zero
-				}
-
-				gr.nonEmpty = true
-
-				return zeroRow, nil
+				return gr.zeroRow(ctx)
 			}
 
 			if gr.currRow == nil {
@@ -237,7 +345,7 @@ func (gr *groupedRowReader) Read(ctx context.Context) (*Row, error) {
 
 		if gr.currRow == nil {
 			gr.currRow = row
-			err = gr.initAggregations()
+			err = gr.initAggregations(row)
 			if err != nil {
 				return nil, err
 			}
@@ -253,7 +361,7 @@ func (gr *groupedRowReader) Read(ctx context.Context) (*Row, error) {
 			r := gr.currRow
 			gr.currRow = row
 
-			err = gr.initAggregations()
+			err = gr.initAggregations(row)
 			if err != nil {
 				return nil, err
 			}
@@ -262,36 +370,126 @@ func (gr *groupedRowReader) Read(ctx context.Context) (*Row, error) {
 		}
 
 		// Compatible rows get merged
-		for _, v := range gr.currRow.ValuesBySelector {
-			aggV, isAggregatedValue := v.(AggregatedValue)
-
-			if isAggregatedValue {
-				if aggV.ColBounded() {
-					val, exists := row.ValuesBySelector[aggV.Selector()]
-					if !exists {
-						return nil, ErrColumnDoesNotExist
-					}
-
-					err = aggV.updateWith(val)
-					if err != nil {
-						return nil, err
-					}
-				}
+		err = gr.updateAggregations(gr.currRow, row)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
 
-				if !aggV.ColBounded() {
-					err = aggV.updateWith(nil)
-					if err != nil {
-						return nil, err
-					}
-				}
+// readGroup returns the groups built in memory, in the order their first row was read
+func (gr *groupedRowReader) readGroup(ctx context.Context) (*Row, error) {
+	if !gr.nonEmpty {
+		err := gr.groupRows(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		gr.nonEmpty = true
+
+		if len(gr.groups) == 0 && allAgregations(gr.selectors) {
+			return gr.zeroRow(ctx)
+		}
+	}
+
+	if len(gr.groups) == 0 {
+		return nil, store.ErrNoMoreEntries
+	}
+
+	r := gr.groups[0]
+	gr.groups[0] = nil
+	gr.groups = gr.groups[1:]
+
+	return r, nil
+}
+
+func (gr *groupedRowReader) groupRows(ctx context.Context) error {
+	groupsByKey := make(map[[sha256.Size]byte]*Row)
+
+	for {
+		row, err := gr.rowReader.Read(ctx)
+		if err == store.ErrNoMoreEntries {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		key, err := gr.groupKey(row)
+		if err != nil {
+			return err
+		}
+
+		groupRow, ok := groupsByKey[key]
+		if ok {
+			err = gr.updateAggregations(groupRow, row)
+			if err != nil {
+				return err
 			}
+			continue
+		}
+
+		if len(gr.groups) == gr.rowReader.Tx().distinctLimit() {
+			return ErrTooManyRows
+		}
+
+		err = gr.initAggregations(row)
+		if err != nil {
+			return err
 		}
+
+		groupsByKey[key] = row
+		gr.groups = append(gr.groups, row)
 	}
 }
 
-func (gr *groupedRowReader) initAggregations() error {
-	// augment row with aggregated values
-	for _, sel := range gr.selectors {
+func (gr *groupedRowReader) groupKey(row *Row) ([sha256.Size]byte, error) {
+	groupValues := &Row{ValuesByPosition: make([]TypedValue, len(gr.groupBy))}
+
+	for i, sel := range gr.groupBy {
+		v, ok := row.ValuesBySelector[EncodeSelector(sel.resolve(gr.rowReader.Database(), gr.rowReader.TableAlias()))]
+		if !ok {
+			return [sha256.Size]byte{}, ErrInvalidColumn
+		}
+
+		groupValues.ValuesByPosition[i] = v
+	}
+
+	return groupValues.digest(nil)
+}
+
+// zeroRow is returned when all selectors are aggregations and there are no rows to aggregate
+func (gr *groupedRowReader) zeroRow(ctx context.Context) (*Row, error) {
+	selectors := gr.aggregatedSelectors()
+
+	zeroRow := &Row{
+		ValuesByPosition: make([]TypedValue, len(selectors)),
+		ValuesBySelector: make(map[string]TypedValue, len(selectors)),
+	}
+
+	colsBySelector, err := gr.colsBySelector(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, sel := range selectors {
+		aggFn, db, table, col := sel.resolve(gr.rowReader.Database(), gr.rowReader.TableAlias())
+		encSel := EncodeSelector(aggFn, db, table, col)
+
+		zero := zeroForType(colsBySelector[encSel].Type)
+
+		zeroRow.ValuesByPosition[i] = zero
+		zeroRow.ValuesBySelector[encSel] = zero
+	}
+
+	gr.nonEmpty = true
+
+	return zeroRow, nil
+}
+
+// initAggregations augments the first row of a group with the aggregated values
+func (gr *groupedRowReader) initAggregations(row *Row) error {
+	for _, sel := range gr.aggregatedSelectors() {
 		aggFn, db, table, col := sel.resolve(gr.rowReader.Database(), gr.rowReader.TableAlias())
 
 		encSel := EncodeSelector(aggFn, db, table, col)
@@ -309,7 +507,7 @@ func (gr *groupedRowReader) initAggregations() error {
 			}
 		case SUM:
 			{
-				v = &SumValue{sel: EncodeSelector("", db, table, col)}
+				v = newSumValue(EncodeSelector("", db, table, col), aggregatedType(row, db, table, col))
 			}
 		case MIN:
 			{
@@ -321,7 +519,7 @@ func (gr *groupedRowReader) initAggregations() error {
 			}
 		case AVG:
 			{
-				v = &AVGValue{sel: EncodeSelector("", db, table, col)}
+				v = newAVGValue(EncodeSelector("", db, table, col), aggregatedType(row, db, table, col))
 			}
 		default:
 			{
@@ -329,16 +527,31 @@ func (gr *groupedRowReader) initAggregations() error {
 			}
 		}
 
-		gr.currRow.ValuesByPosition = append(gr.currRow.ValuesByPosition, v)
-		gr.currRow.ValuesBySelector[encSel] = v
+		row.ValuesByPosition = append(row.ValuesByPosition, v)
+		row.ValuesBySelector[encSel] = v
 	}
 
-	for _, v := range gr.currRow.ValuesBySelector {
+	return gr.updateAggregations(row, row)
+}
+
+// aggregatedType returns the type of the aggregated column, INTEGER is assumed when not present in the row
+func aggregatedType(row *Row, db, table, col string) SQLValueType {
+	val, ok := row.ValuesBySelector[EncodeSelector("", db, table, col)]
+	if !ok {
+		return IntegerType
+	}
+
+	return val.Type()
+}
+
+// updateAggregations updates the aggregated values of the group with the values of the row
+func (gr *groupedRowReader) updateAggregations(groupRow, row *Row) error {
+	for _, v := range groupRow.ValuesBySelector {
 		aggV, isAggregatedValue := v.(AggregatedValue)
 
 		if isAggregatedValue {
 			if aggV.ColBounded() {
-				val, exists := gr.currRow.ValuesBySelector[aggV.Selector()]
+				val, exists := row.ValuesBySelector[aggV.Selector()]
 				if !exists {
 					return ErrColumnDoesNotExist
 				}
@@ -31,7 +31,7 @@ func TestGroupedRowReader(t *testing.T) {
 	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
 
-	_, err = newGroupedRowReader(nil, nil, nil)
+	_, err = newGroupedRowReader(nil, nil, nil, nil)
 	require.Equal(t, ErrIllegalArguments, err)
 
 	tx, err := engine.NewTx(context.Background(), DefaultTxOptions())
@@ -43,7 +43,7 @@ func TestGroupedRowReader(t *testing.T) {
 	table, err := db.newTable("table1", []*ColSpec{{colName: "id", colType: IntegerType}})
 	require.NoError(t, err)
 
-	index, err := table.newIndex(true, []uint32{1})
+	index, err := table.newIndex(true, []uint32{1}, "", nil)
 	require.NoError(t, err)
 	require.NotNil(t, index)
 	require.Equal(t, table.primaryIndex, index)
@@ -51,7 +51,7 @@ func TestGroupedRowReader(t *testing.T) {
 	r, err := newRawRowReader(tx, nil, table, period{}, "", &ScanSpecs{Index: table.primaryIndex})
 	require.NoError(t, err)
 
-	gr, err := newGroupedRowReader(r, []Selector{&ColSelector{col: "id"}}, []*ColSelector{{col: "id"}})
+	gr, err := newGroupedRowReader(r, []Selector{&ColSelector{col: "id"}}, []*ColSelector{{col: "id"}}, nil)
 	require.NoError(t, err)
 
 	orderBy := gr.OrderBy()
@@ -31,6 +31,11 @@ type jointRowReader struct {
 	rowReaders                 []RowReader
 	rowReadersValuesByPosition [][]TypedValue
 	rowReadersValuesBySelector []map[string]TypedValue
+
+	// set when resolving a right join, rowReader reads the right data source
+	// while the first join reads the left one
+	rightJoin  bool
+	tableAlias string
 }
 
 func newJointRowReader(rowReader RowReader, joins []*JoinSpec) (*jointRowReader, error) {
@@ -39,7 +44,7 @@ func newJointRowReader(rowReader RowReader, joins []*JoinSpec) (*jointRowReader,
 	}
 
 	for _, jspec := range joins {
-		if jspec.joinType != InnerJoin {
+		if jspec.joinType != InnerJoin && jspec.joinType != LeftJoin {
 			return nil, ErrUnsupportedJoinType
 		}
 	}
@@ -53,6 +58,32 @@ func newJointRowReader(rowReader RowReader, joins []*JoinSpec) (*jointRowReader,
 	}, nil
 }
 
+// newRightJointRowReader resolves `leftDs RIGHT JOIN rightDs ON cond` as `rightDs LEFT JOIN leftDs ON cond`,
+// rowReader reads the right data source and joins[0] is the right join. Columns and values of
+// the left data source are still placed before the ones of the right data source
+func newRightJointRowReader(rowReader RowReader, leftDs DataSource, leftIndexOn []string, joins []*JoinSpec) (*jointRowReader, error) {
+	if rowReader == nil || leftDs == nil || len(joins) == 0 || joins[0].joinType != RightJoin {
+		return nil, ErrIllegalArguments
+	}
+
+	leftJoin := &JoinSpec{
+		joinType: LeftJoin,
+		ds:       leftDs,
+		cond:     joins[0].cond,
+		indexOn:  leftIndexOn,
+	}
+
+	jointr, err := newJointRowReader(rowReader, append([]*JoinSpec{leftJoin}, joins[1:]...))
+	if err != nil {
+		return nil, err
+	}
+
+	jointr.rightJoin = true
+	jointr.tableAlias = leftDs.Alias()
+
+	return jointr, nil
+}
+
 func (jointr *jointRowReader) onClose(callback func()) {
 	jointr.rowReader.onClose(callback)
 }
@@ -66,6 +97,9 @@ func (jointr *jointRowReader) Database() string {
 }
 
 func (jointr *jointRowReader) TableAlias() string {
+	if jointr.rightJoin {
+		return jointr.tableAlias
+	}
 	return jointr.rowReader.TableAlias()
 }
 
@@ -127,7 +161,7 @@ func (jointr *jointRowReader) colsByPos(ctx context.Context) ([]ColDescriptor, e
 		return nil, err
 	}
 
-	for _, jspec := range jointr.joins {
+	for i, jspec := range jointr.joins {
 
 		// TODO (byo) optimize this by getting selector list only or opening all joint readers
 		//            on jointRowReader creation,
@@ -144,6 +178,12 @@ func (jointr *jointRowReader) colsByPos(ctx context.Context) ([]ColDescriptor, e
 			return nil, err
 		}
 
+		if i == 0 && jointr.rightJoin {
+			// columns of the left data source come first
+			colDescriptors = append(cd, colDescriptors...)
+			continue
+		}
+
 		colDescriptors = append(colDescriptors, cd...)
 	}
 
@@ -247,6 +287,11 @@ func (jointr *jointRowReader) Read(ctx context.Context) (row *Row, err error) {
 			}
 
 			r, err := reader.Read(ctx)
+			if err == ErrNoMoreRows && jspec.joinType == LeftJoin {
+				// no row matches the join condition, the row is extended with nulls.
+				// The exhausted reader is kept so the previous reader reads its next row afterwards
+				r, err = nullRow(ctx, reader)
+			}
 			if err == ErrNoMoreRows {
 				// previous reader will need to read next row
 				unsolvedFK = true
@@ -278,11 +323,50 @@ func (jointr *jointRowReader) Read(ctx context.Context) (row *Row, err error) {
 
 		// all readers have a valid read
 		if !unsolvedFK {
+			if jointr.rightJoin {
+				jointr.placeLeftValuesFirst(row)
+			}
 			return row, nil
 		}
 	}
 }
 
+// placeLeftValuesFirst reorders the values of a right join so that the ones read from
+// the left data source, i.e. the first join, come before the ones of the right data source
+func (jointr *jointRowReader) placeLeftValuesFirst(row *Row) {
+	rightLen := len(jointr.rowReadersValuesByPosition[0])
+	leftLen := len(jointr.rowReadersValuesByPosition[1])
+
+	values := make([]TypedValue, 0, len(row.ValuesByPosition))
+	values = append(values, row.ValuesByPosition[rightLen:rightLen+leftLen]...)
+	values = append(values, row.ValuesByPosition[:rightLen]...)
+	values = append(values, row.ValuesByPosition[rightLen+leftLen:]...)
+
+	row.ValuesByPosition = values
+}
+
+// nullRow returns a row made of null values for every column of the reader
+func nullRow(ctx context.Context, reader RowReader) (*Row, error) {
+	cols, err := reader.Columns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	row := &Row{
+		ValuesByPosition: make([]TypedValue, len(cols)),
+		ValuesBySelector: make(map[string]TypedValue, len(cols)),
+	}
+
+	for i, col := range cols {
+		v := &NullValue{t: col.Type}
+
+		row.ValuesByPosition[i] = v
+		row.ValuesBySelector[col.Selector()] = v
+	}
+
+	return row, nil
+}
+
 func (jointr *jointRowReader) Close() error {
 	merr := multierr.NewMultiErr()
 
@@ -47,7 +47,7 @@ func TestJointRowReader(t *testing.T) {
 	table, err := db.newTable("table1", []*ColSpec{{colName: "id", colType: IntegerType}, {colName: "number", colType: IntegerType}})
 	require.NoError(t, err)
 
-	index, err := table.newIndex(true, []uint32{1})
+	index, err := table.newIndex(true, []uint32{1}, "", nil)
 	require.NoError(t, err)
 	require.NotNil(t, index)
 	require.Equal(t, table.primaryIndex, index)
@@ -55,7 +55,7 @@ func TestJointRowReader(t *testing.T) {
 	r, err := newRawRowReader(tx, nil, table, period{}, "", &ScanSpecs{Index: table.primaryIndex})
 	require.NoError(t, err)
 
-	_, err = newJointRowReader(r, []*JoinSpec{{joinType: LeftJoin}})
+	_, err = newJointRowReader(r, []*JoinSpec{{joinType: RightJoin}})
 	require.Equal(t, ErrUnsupportedJoinType, err)
 
 	_, err = newJointRowReader(r, []*JoinSpec{{joinType: InnerJoin, ds: &SelectStmt{}}})
@@ -67,7 +67,10 @@ var reservedWords = map[string]int{
 	"FROM":           FROM,
 	"UNION":          UNION,
 	"ALL":            ALL,
+	"INTERSECT":      INTERSECT,
+	"EXCEPT":         EXCEPT,
 	"TX":             TX,
+	"OF":             OF,
 	"JOIN":           JOIN,
 	"HAVING":         HAVING,
 	"WHERE":          WHERE,
@@ -88,6 +91,14 @@ var reservedWords = map[string]int{
 	"IF":             IF,
 	"IS":             IS,
 	"CAST":           CAST,
+	"VIEW":           VIEW,
+	"DROP":           DROP,
+	"OVER":           OVER,
+	"PARTITION":      PARTITION,
+	"CHECK":          CHECK,
+	"FOREIGN":        FOREIGN,
+	"REFERENCES":     REFERENCES,
+	"EXPLAIN":        EXPLAIN,
 }
 
 var joinTypes = map[string]JoinType{
@@ -102,6 +113,9 @@ var types = map[string]SQLValueType{
 	"VARCHAR":   VarcharType,
 	"BLOB":      BLOBType,
 	"TIMESTAMP": TimestampType,
+	"JSON":      JSONType,
+	"DECIMAL":   DecimalType,
+	"NUMERIC":   DecimalType,
 }
 
 var aggregateFns = map[string]AggregateFn{
@@ -150,6 +164,15 @@ type lexer struct {
 	namedParamsType positionalParamType
 	paramsCount     int
 	result          []SQLStmt
+	tokens          []lexedToken
+}
+
+// lexedToken keeps track of the position of each token in the source,
+// making it possible to recover the original text of a statement i.e. view definitions
+type lexedToken struct {
+	tkn   int
+	start int
+	end   int
 }
 
 type aheadByteReader struct {
@@ -157,6 +180,7 @@ type aheadByteReader struct {
 	nextErr   error
 	r         io.ByteReader
 	readCount int
+	read      []byte
 }
 
 func newAheadByteReader(r io.ByteReader) *aheadByteReader {
@@ -174,6 +198,10 @@ func (ar *aheadByteReader) ReadByte() (byte, error) {
 
 	ar.readCount++
 
+	if ar.nextErr == nil {
+		ar.read = append(ar.read, ar.nextChar)
+	}
+
 	return ar.nextChar, ar.nextErr
 }
 
@@ -189,6 +217,25 @@ func ParseString(sql string) ([]SQLStmt, error) {
 	return Parse(strings.NewReader(sql))
 }
 
+// parseExp parses a standalone boolean expression such as the definition of a check constraint
+func parseExp(sql string) (ValueExp, error) {
+	stmts, err := ParseString(fmt.Sprintf("SELECT * FROM t WHERE (%s)", sql))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stmts) != 1 {
+		return nil, ErrIllegalArguments
+	}
+
+	stmt, ok := stmts[0].(*SelectStmt)
+	if !ok || stmt.where == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	return stmt.where, nil
+}
+
 func Parse(r io.ByteReader) ([]SQLStmt, error) {
 	lexer := newLexer(r)
 
@@ -205,6 +252,110 @@ func newLexer(r io.ByteReader) *lexer {
 }
 
 func (l *lexer) Lex(lval *yySymType) int {
+	start := l.r.ReadCount()
+
+	tkn := l.lex(lval)
+
+	end := l.r.ReadCount()
+	if tkn == 0 {
+		start = end
+	}
+
+	l.tokens = append(l.tokens, lexedToken{tkn: tkn, start: start, end: end})
+
+	return tkn
+}
+
+// viewDefinition returns the source text of the query of the last view being parsed.
+// It's meant to be called when reducing the view statement, at that point the token
+// following the query has already been lexed.
+func (l *lexer) viewDefinition() string {
+	i := len(l.tokens) - 1
+
+	for i >= 0 && l.tokens[i].tkn != VIEW {
+		i--
+	}
+
+	for i >= 0 && i < len(l.tokens) && l.tokens[i].tkn != AS {
+		i++
+	}
+
+	if i < 0 || i >= len(l.tokens)-1 {
+		return ""
+	}
+
+	start := l.tokens[i].end
+	end := l.tokens[len(l.tokens)-1].start
+	if end > len(l.r.read) {
+		end = len(l.r.read)
+	}
+
+	return strings.TrimSpace(string(l.r.read[start:end]))
+}
+
+// indexPredicate returns the source text of the WHERE clause of the last index being parsed.
+// As with views, it's meant to be called when reducing the statement, once the token following
+// the predicate has already been lexed.
+func (l *lexer) indexPredicate() string {
+	i := len(l.tokens) - 1
+
+	for i >= 0 && l.tokens[i].tkn != INDEX {
+		i--
+	}
+
+	for i >= 0 && i < len(l.tokens) && l.tokens[i].tkn != WHERE {
+		i++
+	}
+
+	if i < 0 || i >= len(l.tokens)-1 {
+		return ""
+	}
+
+	start := l.tokens[i].end
+	end := l.tokens[len(l.tokens)-1].start
+	if end > len(l.r.read) {
+		end = len(l.r.read)
+	}
+
+	return strings.TrimSpace(string(l.r.read[start:end]))
+}
+
+// checkDefinition returns the source text of the expression of the last check constraint
+// being parsed i.e. the text enclosed by the parentheses following the CHECK keyword.
+func (l *lexer) checkDefinition() string {
+	i := len(l.tokens) - 1
+
+	for i >= 0 && l.tokens[i].tkn != CHECK {
+		i--
+	}
+
+	// tokens[i+1] is the opening parenthesis
+	if i < 0 || i+2 >= len(l.tokens) {
+		return ""
+	}
+
+	depth := 0
+
+	for j := i + 1; j < len(l.tokens); j++ {
+		switch l.tokens[j].tkn {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+
+		if depth == 0 {
+			start := l.tokens[i+1].end
+			end := l.tokens[j].start
+
+			return strings.TrimSpace(string(l.r.read[start:end]))
+		}
+	}
+
+	return ""
+}
+
+func (l *lexer) lex(lval *yySymType) int {
 	var ch byte
 	var err error
 
@@ -347,6 +498,18 @@ func (l *lexer) Lex(lval *yySymType) int {
 		return IDENTIFIER
 	}
 
+	if ch == '-' && l.r.nextChar == '>' {
+		l.r.ReadByte() // consume '>'
+
+		// "->>" extracts values as text
+		lval.boolean = l.r.nextChar == '>'
+		if lval.boolean {
+			l.r.ReadByte()
+		}
+
+		return ARROW
+	}
+
 	if isNumber(ch) {
 		tail, err := l.readNumber()
 		if err != nil {
@@ -354,6 +517,25 @@ func (l *lexer) Lex(lval *yySymType) int {
 			return ERROR
 		}
 
+		if l.r.nextChar == '.' {
+			l.r.ReadByte() // consume '.'
+
+			fraction, err := l.readNumber()
+			if err != nil {
+				lval.err = err
+				return ERROR
+			}
+
+			val, err := ParseDecimal(fmt.Sprintf("%c%s.%s", ch, tail, fraction))
+			if err != nil {
+				lval.err = err
+				return ERROR
+			}
+
+			lval.value = val
+			return DECIMAL
+		}
+
 		val, err := strconv.ParseUint(fmt.Sprintf("%c%s", ch, tail), 10, 64)
 		if err != nil {
 			lval.err = err
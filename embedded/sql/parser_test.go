@@ -20,6 +20,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -48,7 +49,7 @@ func TestCreateDatabaseStmt(t *testing.T) {
 		{
 			input:          "CREATE db1",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected IDENTIFIER, expecting DATABASE or TABLE or UNIQUE or INDEX at position 10"),
+			expectedError:  errors.New("syntax error: unexpected IDENTIFIER at position 10"),
 		},
 	}
 
@@ -226,6 +227,40 @@ func TestCreateTableStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "CREATE TABLE orders (id INTEGER, customer_id INTEGER, amount INTEGER, PRIMARY KEY id, CHECK (amount > (0)), FOREIGN KEY (customer_id) REFERENCES customers(id))",
+			expectedOutput: []SQLStmt{
+				&CreateTableStmt{
+					table: "orders",
+					colsSpec: []*ColSpec{
+						{colName: "id", colType: IntegerType},
+						{colName: "customer_id", colType: IntegerType},
+						{colName: "amount", colType: IntegerType},
+					},
+					pkColNames: []string{"id"},
+					constraints: []constraintSpec{
+						&CheckSpec{
+							exp: &CmpBoolExp{
+								op:    GT,
+								left:  &ColSelector{col: "amount"},
+								right: &Number{val: 0},
+							},
+							sql: "amount > (0)",
+						},
+						&ForeignKeySpec{
+							cols:     []string{"customer_id"},
+							refTable: "customers",
+							refCols:  []string{"id"},
+						},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input:          "CREATE TABLE orders (id INTEGER, PRIMARY KEY id, FOREIGN KEY customer_id REFERENCES customers)",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected IDENTIFIER, expecting '(' at position 72"),
+		},
 		{
 			input: "CREATE TABLE xtable1 (xid INTEGER, PRIMARY KEY xid)",
 			expectedOutput: []SQLStmt{
@@ -265,10 +300,25 @@ func TestCreateTableStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "CREATE TABLE payments (id INTEGER, amount DECIMAL(10,2) NOT NULL, rate NUMERIC(5), total DECIMAL, PRIMARY KEY id)",
+			expectedOutput: []SQLStmt{
+				&CreateTableStmt{
+					table: "payments",
+					colsSpec: []*ColSpec{
+						{colName: "id", colType: IntegerType},
+						{colName: "amount", colType: DecimalType, precision: 10, scale: 2, notNull: true},
+						{colName: "rate", colType: DecimalType, precision: 5},
+						{colName: "total", colType: DecimalType},
+					},
+					pkColNames: []string{"id"},
+				}},
+			expectedError: nil,
+		},
 		{
 			input:          "CREATE table1",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected IDENTIFIER, expecting DATABASE or TABLE or UNIQUE or INDEX at position 13"),
+			expectedError:  errors.New("syntax error: unexpected IDENTIFIER at position 13"),
 		},
 		{
 			input:          "CREATE TABLE table1",
@@ -278,7 +328,7 @@ func TestCreateTableStmt(t *testing.T) {
 		{
 			input:          "CREATE TABLE table1()",
 			expectedOutput: []SQLStmt{&CreateTableStmt{table: "table1"}},
-			expectedError:  errors.New("syntax error: unexpected ')', expecting IDENTIFIER at position 21"),
+			expectedError:  errors.New("syntax error: unexpected ')', expecting VIEW or DROP or IDENTIFIER at position 21"),
 		},
 	}
 
@@ -311,7 +361,7 @@ func TestCreateIndexStmt(t *testing.T) {
 		{
 			input:          "CREATE INDEX ON \"table(\"primary\")",
 			expectedOutput: []SQLStmt{&CreateIndexStmt{table: "table", cols: []string{"primary"}}},
-			expectedError:  errors.New("syntax error: unexpected ERROR, expecting IDENTIFIER at position 22"),
+			expectedError:  errors.New("syntax error: unexpected ERROR, expecting VIEW or DROP or IDENTIFIER at position 22"),
 		},
 		{
 			input:          "CREATE INDEX IF NOT EXISTS ON table1(id)",
@@ -328,6 +378,146 @@ func TestCreateIndexStmt(t *testing.T) {
 			expectedOutput: []SQLStmt{&CreateIndexStmt{unique: true, table: "table1", cols: []string{"id", "title"}}},
 			expectedError:  nil,
 		},
+		{
+			input: "CREATE UNIQUE INDEX ON table1(title) WHERE deleted = false; SELECT id FROM table1",
+			expectedOutput: []SQLStmt{
+				&CreateIndexStmt{
+					unique: true,
+					table:  "table1",
+					cols:   []string{"title"},
+					where: &CmpBoolExp{
+						op:    EQ,
+						left:  &ColSelector{col: "deleted"},
+						right: &Bool{val: false},
+					},
+					whereSQL: "deleted = false",
+				},
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds:        &tableRef{table: "table1"},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "CREATE INDEX IF NOT EXISTS ON table1(active, title) WHERE NOT deleted",
+			expectedOutput: []SQLStmt{
+				&CreateIndexStmt{
+					ifNotExists: true,
+					table:       "table1",
+					cols:        []string{"active", "title"},
+					where:       &NotBoolExp{exp: &ColSelector{col: "deleted"}},
+					whereSQL:    "NOT deleted",
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input:          "CREATE INDEX ON table1(title) WHERE",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected $end at position 36"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
+func TestViewStmts(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input: "CREATE VIEW view1 AS SELECT id FROM table1",
+			expectedOutput: []SQLStmt{
+				&CreateViewStmt{
+					view: "view1",
+					query: &SelectStmt{
+						selectors: []Selector{&ColSelector{col: "id"}},
+						ds:        &tableRef{table: "table1"},
+					},
+					sql: "SELECT id FROM table1",
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "CREATE VIEW IF NOT EXISTS view1 AS SELECT id FROM table1 WHERE id > 10; DROP VIEW view1",
+			expectedOutput: []SQLStmt{
+				&CreateViewStmt{
+					view:        "view1",
+					ifNotExists: true,
+					query: &SelectStmt{
+						selectors: []Selector{&ColSelector{col: "id"}},
+						ds:        &tableRef{table: "table1"},
+						where: &CmpBoolExp{
+							op:    GT,
+							left:  &ColSelector{col: "id"},
+							right: &Number{val: 10},
+						},
+					},
+					sql: "SELECT id FROM table1 WHERE id > 10",
+				},
+				&DropViewStmt{view: "view1"},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "CREATE VIEW view1 AS SELECT title FROM table1 UNION SELECT name AS title FROM table2",
+			expectedOutput: []SQLStmt{
+				&CreateViewStmt{
+					view: "view1",
+					query: &UnionStmt{
+						distinct: true,
+						left: &SelectStmt{
+							selectors: []Selector{&ColSelector{col: "title"}},
+							ds:        &tableRef{table: "table1"},
+						},
+						right: &SelectStmt{
+							selectors: []Selector{&ColSelector{col: "name", as: "title"}},
+							ds:        &tableRef{table: "table2"},
+						},
+					},
+					sql: "SELECT title FROM table1 UNION SELECT name AS title FROM table2",
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input:          "DROP VIEW IF EXISTS view1",
+			expectedOutput: []SQLStmt{&DropViewStmt{view: "view1", ifExists: true}},
+			expectedError:  nil,
+		},
+		{
+			input:          "CREATE VIEW view1 SELECT id FROM table1",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected SELECT, expecting AS at position 24"),
+		},
+		{
+			input: "SELECT view, drop FROM drop WHERE view > 1; DROP VIEW view; ALTER TABLE view DROP COLUMN drop",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "view"}, &ColSelector{col: "drop"}},
+					ds:        &tableRef{table: "drop"},
+					where: &CmpBoolExp{
+						op:    GT,
+						left:  &ColSelector{col: "view"},
+						right: &Number{val: 1},
+					},
+				},
+				&DropViewStmt{view: "view"},
+				&DropColumnStmt{table: "view", colName: "drop"},
+			},
+			expectedError: nil,
+		},
 	}
 
 	for i, tc := range testCases {
@@ -367,7 +557,7 @@ func TestAlterTable(t *testing.T) {
 		{
 			input:          "ALTER TABLE table1 COLUMN title VARCHAR",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected COLUMN, expecting ADD or RENAME at position 25"),
+			expectedError:  errors.New("syntax error: unexpected COLUMN, expecting ALTER or ADD or RENAME or DROP at position 25"),
 		},
 		{
 			input: "ALTER TABLE table1 RENAME COLUMN title TO newtitle",
@@ -382,7 +572,34 @@ func TestAlterTable(t *testing.T) {
 		{
 			input:          "ALTER TABLE table1 RENAME COLUMN TO newtitle",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected TO, expecting IDENTIFIER at position 35"),
+			expectedError:  errors.New("syntax error: unexpected TO, expecting VIEW or DROP or IDENTIFIER at position 35"),
+		},
+		{
+			input: "ALTER TABLE table1 DROP COLUMN title",
+			expectedOutput: []SQLStmt{
+				&DropColumnStmt{
+					table:   "table1",
+					colName: "title",
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "ALTER TABLE table1 ALTER COLUMN title VARCHAR[100]",
+			expectedOutput: []SQLStmt{
+				&AlterColumnStmt{
+					table: "table1",
+					colSpec: &ColSpec{
+						colName: "title",
+						colType: VarcharType,
+						maxLen:  100,
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input:          "ALTER TABLE table1 DROP title",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected IDENTIFIER, expecting COLUMN at position 29"),
 		},
 	}
 
@@ -573,12 +790,57 @@ func TestInsertIntoStmt(t *testing.T) {
 		{
 			input:          "UPSERT INTO table1() VALUES (2, 'untitled')",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected ')', expecting IDENTIFIER at position 20"),
+			expectedError:  errors.New("syntax error: unexpected ')', expecting VIEW or DROP or IDENTIFIER at position 20"),
 		},
 		{
 			input:          "UPSERT INTO VALUES (2)",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected VALUES, expecting IDENTIFIER at position 18"),
+			expectedError:  errors.New("syntax error: unexpected VALUES, expecting VIEW or DROP or IDENTIFIER at position 18"),
+		},
+		{
+			input: "INSERT INTO table1(id, active) VALUES (1, false) ON CONFLICT DO NOTHING",
+			expectedOutput: []SQLStmt{
+				&UpsertIntoStmt{
+					isInsert:   true,
+					tableRef:   &tableRef{table: "table1"},
+					cols:       []string{"id", "active"},
+					rows:       []*RowSpec{{Values: []ValueExp{&Number{val: 1}, &Bool{val: false}}}},
+					onConflict: &OnConflictDo{},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "INSERT INTO counters(name, hits) VALUES ('page1', 1) ON CONFLICT (name) DO UPDATE SET hits = counters.hits + EXCLUDED.hits, active = true",
+			expectedOutput: []SQLStmt{
+				&UpsertIntoStmt{
+					isInsert: true,
+					tableRef: &tableRef{table: "counters"},
+					cols:     []string{"name", "hits"},
+					rows:     []*RowSpec{{Values: []ValueExp{&Varchar{val: "page1"}, &Number{val: 1}}}},
+					onConflict: &OnConflictDo{
+						target: []string{"name"},
+						updates: []*colUpdate{
+							{
+								col: "hits",
+								op:  EQ,
+								val: &NumExp{
+									op:    ADDOP,
+									left:  &ColSelector{table: "counters", col: "hits"},
+									right: &ColSelector{table: "excluded", col: "hits"},
+								},
+							},
+							{col: "active", op: EQ, val: &Bool{val: true}},
+						},
+					},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input:          "INSERT INTO counters(name, hits) VALUES ('page1', 1) ON CONFLICT (name) DO UPDATE hits = 1",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected IDENTIFIER, expecting SET at position 86"),
 		},
 	}
 
@@ -1175,6 +1437,111 @@ func TestSelectUnionStmt(t *testing.T) {
 	}
 }
 
+func TestSelectSetOpStmt(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input: "SELECT id FROM table1 INTERSECT SELECT id FROM table2",
+			expectedOutput: []SQLStmt{
+				&SetOpStmt{
+					op:       IntersectOp,
+					distinct: true,
+					left: &SelectStmt{
+						selectors: []Selector{&ColSelector{col: "id"}},
+						ds:        &tableRef{table: "table1"},
+					},
+					right: &SelectStmt{
+						selectors: []Selector{&ColSelector{col: "id"}},
+						ds:        &tableRef{table: "table2"},
+					}},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM table1 INTERSECT ALL SELECT id FROM table2",
+			expectedOutput: []SQLStmt{
+				&SetOpStmt{
+					op:       IntersectOp,
+					distinct: false,
+					left: &SelectStmt{
+						selectors: []Selector{&ColSelector{col: "id"}},
+						ds:        &tableRef{table: "table1"},
+					},
+					right: &SelectStmt{
+						selectors: []Selector{&ColSelector{col: "id"}},
+						ds:        &tableRef{table: "table2"},
+					}},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM table1 EXCEPT SELECT id FROM table2 UNION ALL SELECT id FROM table3",
+			expectedOutput: []SQLStmt{
+				&UnionStmt{
+					distinct: false,
+					left: &SetOpStmt{
+						op:       ExceptOp,
+						distinct: true,
+						left: &SelectStmt{
+							selectors: []Selector{&ColSelector{col: "id"}},
+							ds:        &tableRef{table: "table1"},
+						},
+						right: &SelectStmt{
+							selectors: []Selector{&ColSelector{col: "id"}},
+							ds:        &tableRef{table: "table2"},
+						},
+					},
+					right: &SelectStmt{
+						selectors: []Selector{&ColSelector{col: "id"}},
+						ds:        &tableRef{table: "table3"},
+					}},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM table1 UNION SELECT id FROM table2 INTERSECT SELECT id FROM table3",
+			expectedOutput: []SQLStmt{
+				&UnionStmt{
+					distinct: true,
+					left: &SelectStmt{
+						selectors: []Selector{&ColSelector{col: "id"}},
+						ds:        &tableRef{table: "table1"},
+					},
+					right: &SetOpStmt{
+						op:       IntersectOp,
+						distinct: true,
+						left: &SelectStmt{
+							selectors: []Selector{&ColSelector{col: "id"}},
+							ds:        &tableRef{table: "table2"},
+						},
+						right: &SelectStmt{
+							selectors: []Selector{&ColSelector{col: "id"}},
+							ds:        &tableRef{table: "table3"},
+						},
+					}},
+			},
+			expectedError: nil,
+		},
+		{
+			input:          "SELECT id FROM table1 INTERSECT",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected $end, expecting SELECT at position 32"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
 func TestAggFnStmt(t *testing.T) {
 	testCases := []struct {
 		input          string
@@ -1226,6 +1593,62 @@ func TestAggFnStmt(t *testing.T) {
 	}
 }
 
+func TestWindowFnStmt(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input: "SELECT id, ROW_NUMBER() OVER () FROM table1",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+						&WindowFnSelector{fn: "row_number"},
+					},
+					ds: &tableRef{table: "table1"},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT RANK() OVER (PARTITION BY country, city ORDER BY amount DESC) AS r, LAG(amount, 2, 0) OVER (ORDER BY t1.id) FROM table1 AS t1",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&WindowFnSelector{
+							fn:          "rank",
+							partitionBy: []*ColSelector{{col: "country"}, {col: "city"}},
+							orderBy:     []*OrdCol{{sel: &ColSelector{col: "amount"}, descOrder: true}},
+							as:          "r",
+						},
+						&WindowFnSelector{
+							fn:      "lag",
+							params:  []ValueExp{&ColSelector{col: "amount"}, &Number{val: 2}, &Number{val: 0}},
+							orderBy: []*OrdCol{{sel: &ColSelector{table: "t1", col: "id"}}},
+						},
+					},
+					ds: &tableRef{table: "table1", as: "t1"},
+				}},
+			expectedError: nil,
+		},
+		{
+			input:          "SELECT ROW_NUMBER() OVER (ORDER BY id PARTITION BY country) FROM table1",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected PARTITION, expecting ')' at position 47"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
 func TestExpressions(t *testing.T) {
 	testCases := []struct {
 		input          string
@@ -1474,6 +1897,47 @@ func TestExpressions(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT id FROM clients WHERE id NOT IN (SELECT id_client FROM orders) AND age > (SELECT age FROM clients WHERE id = 1)",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds: &tableRef{table: "clients"},
+					where: &BinBoolExp{
+						op: AND,
+						left: &InSubQueryExp{
+							val:   &ColSelector{col: "id"},
+							notIn: true,
+							q: &SelectStmt{
+								selectors: []Selector{
+									&ColSelector{col: "id_client"},
+								},
+								ds: &tableRef{table: "orders"},
+							},
+						},
+						right: &CmpBoolExp{
+							op:   GT,
+							left: &ColSelector{col: "age"},
+							right: &ScalarSubQueryExp{
+								q: &SelectStmt{
+									selectors: []Selector{
+										&ColSelector{col: "age"},
+									},
+									ds: &tableRef{table: "clients"},
+									where: &CmpBoolExp{
+										op:    EQ,
+										left:  &ColSelector{col: "id"},
+										right: &Number{val: 1},
+									},
+								},
+							},
+						},
+					},
+				}},
+			expectedError: nil,
+		},
 		{
 			input: "SELECT id FROM clients WHERE deleted_at IS NULL",
 			expectedOutput: []SQLStmt{
@@ -1514,6 +1978,57 @@ func TestExpressions(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT id FROM payments WHERE amount > 10.50 AND amount < -0.5",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds: &tableRef{table: "payments"},
+					where: &BinBoolExp{
+						op: AND,
+						left: &CmpBoolExp{
+							op:    GT,
+							left:  &ColSelector{col: "amount"},
+							right: &Decimal{val: big.NewInt(1050), scale: 2},
+						},
+						right: &CmpBoolExp{
+							op:   LT,
+							left: &ColSelector{col: "amount"},
+							right: &NumExp{
+								op:    SUBSOP,
+								left:  &Number{val: 0},
+								right: &Decimal{val: big.NewInt(5), scale: 1},
+							},
+						},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM docs WHERE doc->'tags'->>0 = 'a'",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds: &tableRef{table: "docs"},
+					where: &CmpBoolExp{
+						op: EQ,
+						left: &JSONExtractExp{
+							val: &JSONExtractExp{
+								val:  &ColSelector{col: "doc"},
+								path: &Varchar{val: "tags"},
+							},
+							path:   &Number{val: 0},
+							asText: true,
+						},
+						right: &Varchar{val: "a"},
+					},
+				}},
+			expectedError: nil,
+		},
 	}
 
 	for i, tc := range testCases {
@@ -1622,3 +2137,123 @@ func TestMultiLineStmts(t *testing.T) {
 		}
 	}
 }
+
+func TestExplainStmt(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input: "EXPLAIN SELECT id FROM table1",
+			expectedOutput: []SQLStmt{
+				&ExplainStmt{
+					query: &SelectStmt{
+						ds:        &tableRef{table: "table1"},
+						selectors: []Selector{&ColSelector{col: "id"}},
+					},
+				},
+			},
+		},
+		{
+			input:         "EXPLAIN DELETE FROM table1",
+			expectedError: errors.New("syntax error: unexpected DELETE, expecting SELECT at position 14"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
+func TestAsOfStmt(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input: "SELECT id FROM table1 AS OF TX 10 AS t1 INNER JOIN table2 AS OF TIMESTAMP @ts ON t1.id = table2.id",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds: &tableRef{
+						table:  "table1",
+						period: period{asOf: &periodInstant{instantType: txInstant, exp: &Number{val: 10}}},
+						as:     "t1",
+					},
+					joins: []*JoinSpec{
+						{
+							joinType: InnerJoin,
+							ds: &tableRef{
+								table:  "table2",
+								period: period{asOf: &periodInstant{instantType: timeInstant, exp: &Param{id: "ts"}}},
+							},
+							cond: &CmpBoolExp{
+								op:    EQ,
+								left:  &ColSelector{table: "t1", col: "id"},
+								right: &ColSelector{table: "table2", col: "id"},
+							},
+						},
+					},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM (SELECT id FROM table1 AS OF TX 3 INNER JOIN table2 ON table1.id = table2.id) AS OF TX @tx AS q",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds: &SelectStmt{
+						selectors: []Selector{&ColSelector{col: "id"}},
+						ds: &tableRef{
+							table:  "table1",
+							period: period{asOf: &periodInstant{instantType: txInstant, exp: &Number{val: 3}}},
+						},
+						joins: []*JoinSpec{
+							{
+								joinType: InnerJoin,
+								ds: &tableRef{
+									table:  "table2",
+									period: period{asOf: &periodInstant{instantType: txInstant, exp: &Param{id: "tx"}}},
+								},
+								cond: &CmpBoolExp{
+									op:    EQ,
+									left:  &ColSelector{table: "table1", col: "id"},
+									right: &ColSelector{table: "table2", col: "id"},
+								},
+							},
+						},
+						as: "q",
+					},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input:          "SELECT id FROM table1 AS OF INTEGER 10",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected INTEGER, expecting TX or TIMESTAMP at position 39"),
+		},
+		{
+			input:          "SELECT id FROM table1 AS OF TX 10 SINCE TX 1",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected SINCE at position 39"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
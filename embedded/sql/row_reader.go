@@ -17,6 +17,7 @@ limitations under the License.
 package sql
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/binary"
@@ -45,6 +46,9 @@ type ScanSpecs struct {
 	Index         *Index
 	rangesByColID map[uint32]*typedValueRange
 	DescOrder     bool
+
+	// when set, the scan starts right after this key
+	resumeAfter []byte
 }
 
 type Row struct {
@@ -125,7 +129,15 @@ type rawRowReader struct {
 
 	params map[string]interface{}
 
-	reader          store.KeyReader
+	rSpec  store.KeyReaderSpec
+	reader store.KeyReader
+
+	// snapshot used to resolve rows as of a past instant, it's taken once parameters are available
+	snap *store.Snapshot
+
+	// key of the latest entry read, it's the position reached by the scan
+	lastKey []byte
+
 	onCloseCallback func()
 }
 
@@ -156,9 +168,13 @@ func newRawRowReader(tx *SQLTx, params map[string]interface{}, table *Table, per
 		return nil, err
 	}
 
-	r, err := tx.newKeyReader(*rSpec)
-	if err != nil {
-		return nil, err
+	var r store.KeyReader
+
+	if period.asOf == nil {
+		r, err = tx.newKeyReader(*rSpec)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if tableAlias == "" {
@@ -189,6 +205,7 @@ func newRawRowReader(tx *SQLTx, params map[string]interface{}, table *Table, per
 		colsBySel:  colsBySel,
 		scanSpecs:  scanSpecs,
 		params:     params,
+		rSpec:      *rSpec,
 		reader:     r,
 	}, nil
 }
@@ -221,7 +238,12 @@ func keyReaderSpecFrom(sqlPrefix []byte, table *Table, scanSpecs *ScanSpecs) (sp
 			if colRange.hRange == nil {
 				hiKeyReady = true
 			} else {
-				encVal, err := EncodeAsKey(colRange.hRange.val.Value(), col.colType, col.MaxLen())
+				hVal := colRange.hRange.val
+				if col.colType == DecimalType {
+					hVal = decimalKeyBound(col, hVal, true)
+				}
+
+				encVal, err := EncodeAsKey(hVal.Value(), col.colType, col.MaxLen())
 				if err != nil {
 					return nil, err
 				}
@@ -233,7 +255,12 @@ func keyReaderSpecFrom(sqlPrefix []byte, table *Table, scanSpecs *ScanSpecs) (sp
 			if colRange.lRange == nil {
 				loKeyReady = true
 			} else {
-				encVal, err := EncodeAsKey(colRange.lRange.val.Value(), col.colType, col.MaxLen())
+				lVal := colRange.lRange.val
+				if col.colType == DecimalType {
+					lVal = decimalKeyBound(col, lVal, false)
+				}
+
+				encVal, err := EncodeAsKey(lVal.Value(), col.colType, col.MaxLen())
 				if err != nil {
 					return nil, err
 				}
@@ -252,9 +279,20 @@ func keyReaderSpecFrom(sqlPrefix []byte, table *Table, scanSpecs *ScanSpecs) (sp
 		seekKey, endKey = endKey, seekKey
 	}
 
+	inclusiveSeek := true
+
+	if scanSpecs.resumeAfter != nil {
+		if !bytes.HasPrefix(scanSpecs.resumeAfter, prefix) {
+			return nil, ErrInvalidCursor
+		}
+
+		seekKey = scanSpecs.resumeAfter
+		inclusiveSeek = false
+	}
+
 	return &store.KeyReaderSpec{
 		SeekKey:       seekKey,
-		InclusiveSeek: true,
+		InclusiveSeek: inclusiveSeek,
 		EndKey:        endKey,
 		InclusiveEnd:  true,
 		Prefix:        prefix,
@@ -334,6 +372,13 @@ func (r *rawRowReader) InferParameters(ctx context.Context, params map[string]SQ
 		}
 	}
 
+	if r.period.asOf != nil {
+		_, err = r.period.asOf.exp.inferType(cols, params, r.Database(), r.TableAlias())
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -381,6 +426,39 @@ func (r *rawRowReader) reduceTxRange() (err error) {
 	return nil
 }
 
+// openAsOfSnapshot takes the snapshot holding the rows as they were right after the instant the table is referenced at,
+// as with the tx range, it's postponed until parameters are provided
+func (r *rawRowReader) openAsOfSnapshot(ctx context.Context) error {
+	if r.reader != nil {
+		return nil
+	}
+
+	txID, err := r.period.asOf.resolve(r.tx, r.params, false, true)
+	if err == store.ErrTxNotFound {
+		// there were no rows at that time
+		return ErrNoMoreRows
+	}
+	if err != nil {
+		return err
+	}
+
+	snap, err := r.tx.engine.store.SnapshotAsOfTx(ctx, txID)
+	if err != nil {
+		return err
+	}
+
+	reader, err := snap.NewKeyReader(r.rSpec)
+	if err != nil {
+		snap.Close()
+		return err
+	}
+
+	r.snap = snap
+	r.reader = reader
+
+	return nil
+}
+
 func (r *rawRowReader) Read(ctx context.Context) (row *Row, err error) {
 	if ctx.Err() != nil {
 		return nil, err
@@ -395,6 +473,11 @@ func (r *rawRowReader) Read(ctx context.Context) (row *Row, err error) {
 		return nil, err
 	}
 
+	err = r.openAsOfSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	if r.txRange == nil {
 		mkey, vref, err = r.reader.Read()
 	} else {
@@ -404,6 +487,8 @@ func (r *rawRowReader) Read(ctx context.Context) (row *Row, err error) {
 		return nil, err
 	}
 
+	r.lastKey = append(r.lastKey[:0], mkey...)
+
 	var v []byte
 
 	//decompose key, determine if it's pk, when it's pk, the value holds the actual row data
@@ -429,7 +514,13 @@ func (r *rawRowReader) Read(ctx context.Context) (row *Row, err error) {
 			}
 		}
 
-		vref, err = r.tx.get(mapKey(r.tx.engine.prefix, PIndexPrefix, EncodeID(r.table.db.id), EncodeID(r.table.id), EncodeID(PKIndexID), encPKVals))
+		pkKey := mapKey(r.tx.engine.prefix, PIndexPrefix, EncodeID(r.table.db.id), EncodeID(r.table.id), EncodeID(PKIndexID), encPKVals)
+
+		if r.snap == nil {
+			vref, err = r.tx.get(pkKey)
+		} else {
+			vref, err = r.snap.Get(pkKey)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -442,12 +533,14 @@ func (r *rawRowReader) Read(ctx context.Context) (row *Row, err error) {
 
 	valuesByPosition := make([]TypedValue, len(r.table.Cols()))
 	valuesBySelector := make(map[string]TypedValue, len(r.table.Cols()))
+	posByColID := make(map[uint32]int, len(r.table.Cols()))
 
 	for i, col := range r.table.Cols() {
 		v := &NullValue{t: col.colType}
 
 		valuesByPosition[i] = v
 		valuesBySelector[EncodeSelector("", r.table.db.name, r.tableAlias, col.colName)] = v
+		posByColID[col.id] = i
 	}
 
 	if len(v) < EncLenLen {
@@ -469,6 +562,10 @@ func (r *rawRowReader) Read(ctx context.Context) (row *Row, err error) {
 
 		col, err := r.table.GetColumnByID(colID)
 		if err != nil {
+			// values of dropped columns are skipped
+			col = r.table.droppedColsByID[colID]
+		}
+		if col == nil {
 			return nil, ErrCorruptedData
 		}
 
@@ -479,7 +576,11 @@ func (r *rawRowReader) Read(ctx context.Context) (row *Row, err error) {
 
 		voff += n
 
-		valuesByPosition[i] = val
+		if col.dropped {
+			continue
+		}
+
+		valuesByPosition[posByColID[col.id]] = val
 		valuesBySelector[EncodeSelector("", r.table.db.name, r.tableAlias, col.colName)] = val
 	}
 
@@ -495,5 +596,13 @@ func (r *rawRowReader) Close() error {
 		defer r.onCloseCallback()
 	}
 
+	if r.snap != nil {
+		defer r.snap.Close()
+	}
+
+	if r.reader == nil {
+		return nil
+	}
+
 	return r.reader.Close()
 }
@@ -0,0 +1,226 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/multierr"
+)
+
+// setOpRowReader streams the rows of the left reader which are (INTERSECT) or are not (EXCEPT)
+// returned by the right reader. Rows from the right reader are fully loaded the first time
+// a row is read, both the number of loaded and returned rows are limited by the distinct limit.
+// When duplicates are kept (ALL), each row of the right reader matches a single row of the left one
+// and returned rows are not limited.
+// As the comparison is done on row digests, NULL values are considered equal
+type setOpRowReader struct {
+	op       SetOperator
+	distinct bool
+
+	leftReader  RowReader
+	rightReader RowReader
+
+	cols []ColDescriptor
+
+	rightRows map[[sha256.Size]byte]int // number of occurrences of each row
+	readRows  map[[sha256.Size]byte]struct{}
+}
+
+func newSetOpRowReader(ctx context.Context, op SetOperator, distinct bool, leftReader, rightReader RowReader) (*setOpRowReader, error) {
+	if leftReader == nil || rightReader == nil || (op != IntersectOp && op != ExceptOp) {
+		return nil, ErrIllegalArguments
+	}
+
+	cols, err := leftReader.Columns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := rightReader.Columns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cols) != len(cs) {
+		return nil, fmt.Errorf("%w: each subquery must have same number of columns", ErrColumnMismatchInSetOpStmt)
+	}
+
+	for c := 0; c < len(cols); c++ {
+		if cols[c].Type != cs[c].Type {
+			return nil, fmt.Errorf("%w: expecting type '%v' for column '%s'", ErrColumnMismatchInSetOpStmt, cols[c].Type, cs[c].Column)
+		}
+	}
+
+	return &setOpRowReader{
+		op:          op,
+		distinct:    distinct,
+		leftReader:  leftReader,
+		rightReader: rightReader,
+		cols:        cols,
+		readRows:    make(map[[sha256.Size]byte]struct{}),
+	}, nil
+}
+
+func (sr *setOpRowReader) onClose(callback func()) {
+	sr.leftReader.onClose(callback)
+}
+
+func (sr *setOpRowReader) Tx() *SQLTx {
+	return sr.leftReader.Tx()
+}
+
+func (sr *setOpRowReader) Database() string {
+	return sr.leftReader.Database()
+}
+
+func (sr *setOpRowReader) TableAlias() string {
+	return ""
+}
+
+func (sr *setOpRowReader) SetParameters(params map[string]interface{}) error {
+	err := sr.leftReader.SetParameters(params)
+	if err != nil {
+		return err
+	}
+
+	return sr.rightReader.SetParameters(params)
+}
+
+func (sr *setOpRowReader) Parameters() map[string]interface{} {
+	return sr.leftReader.Parameters()
+}
+
+func (sr *setOpRowReader) OrderBy() []ColDescriptor {
+	return nil
+}
+
+func (sr *setOpRowReader) ScanSpecs() *ScanSpecs {
+	return nil
+}
+
+func (sr *setOpRowReader) Columns(ctx context.Context) ([]ColDescriptor, error) {
+	return sr.leftReader.Columns(ctx)
+}
+
+func (sr *setOpRowReader) colsBySelector(ctx context.Context) (map[string]ColDescriptor, error) {
+	return sr.leftReader.colsBySelector(ctx)
+}
+
+func (sr *setOpRowReader) InferParameters(ctx context.Context, params map[string]SQLValueType) error {
+	err := sr.leftReader.InferParameters(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	return sr.rightReader.InferParameters(ctx, params)
+}
+
+func (sr *setOpRowReader) loadRightRows(ctx context.Context) error {
+	sr.rightRows = make(map[[sha256.Size]byte]int)
+
+	for {
+		row, err := sr.rightReader.Read(ctx)
+		if err == ErrNoMoreRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		digest, err := row.digest(sr.cols)
+		if err != nil {
+			return err
+		}
+
+		n, ok := sr.rightRows[digest]
+		if ok {
+			sr.rightRows[digest] = n + 1
+			continue
+		}
+
+		if len(sr.rightRows) == sr.Tx().distinctLimit() {
+			return ErrTooManyRows
+		}
+
+		sr.rightRows[digest] = 1
+	}
+}
+
+func (sr *setOpRowReader) Read(ctx context.Context) (*Row, error) {
+	if sr.rightRows == nil {
+		err := sr.loadRightRows(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		if sr.distinct && len(sr.readRows) == sr.Tx().distinctLimit() {
+			return nil, ErrTooManyRows
+		}
+
+		row, err := sr.leftReader.Read(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		digest, err := row.digest(sr.cols)
+		if err != nil {
+			return nil, err
+		}
+
+		if !sr.distinct {
+			n := sr.rightRows[digest]
+			if n > 0 {
+				sr.rightRows[digest] = n - 1
+			}
+
+			if (n > 0) != (sr.op == IntersectOp) {
+				continue
+			}
+
+			return row, nil
+		}
+
+		_, ok := sr.readRows[digest]
+		if ok {
+			continue
+		}
+
+		_, inRight := sr.rightRows[digest]
+		if inRight != (sr.op == IntersectOp) {
+			continue
+		}
+
+		sr.readRows[digest] = struct{}{}
+
+		return row, nil
+	}
+}
+
+func (sr *setOpRowReader) Close() error {
+	merr := multierr.NewMultiErr()
+
+	// the left reader executes the onClose callback thus it must be closed at the end
+	merr.Append(sr.rightReader.Close())
+	merr.Append(sr.leftReader.Close())
+
+	return merr.Reduce()
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetOpRowReader(t *testing.T) {
+	_, err := newSetOpRowReader(context.Background(), IntersectOp, true, nil, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	dummyr := &dummyRowReader{
+		database:             "db1",
+		failReturningColumns: true,
+	}
+
+	_, err = newSetOpRowReader(context.Background(), -1, true, dummyr, dummyr)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = newSetOpRowReader(context.Background(), ExceptOp, true, dummyr, dummyr)
+	require.ErrorIs(t, err, errDummy)
+
+	dummyr.failReturningColumns = false
+
+	rowReader, err := newSetOpRowReader(context.Background(), ExceptOp, true, dummyr, dummyr)
+	require.NoError(t, err)
+	require.NotNil(t, rowReader)
+
+	require.Equal(t, "db1", rowReader.Database())
+
+	require.Equal(t, "", rowReader.TableAlias())
+
+	require.Nil(t, rowReader.OrderBy())
+
+	require.Nil(t, rowReader.ScanSpecs())
+
+	params := map[string]interface{}{
+		"param1": 1,
+	}
+
+	err = rowReader.SetParameters(params)
+	require.NoError(t, err)
+
+	require.Equal(t, params, rowReader.Parameters())
+
+	paramTypes := make(map[string]string)
+	err = rowReader.InferParameters(context.Background(), paramTypes)
+	require.NoError(t, err)
+
+	_, err = rowReader.Read(context.Background())
+	require.ErrorIs(t, err, errDummy)
+
+	dummyr.failInferringParams = true
+	err = rowReader.InferParameters(context.Background(), paramTypes)
+	require.ErrorIs(t, err, errDummy)
+}
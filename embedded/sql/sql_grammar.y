@@ -66,13 +66,19 @@ func setResult(l yyLexer, stmts []SQLStmt) {
     update *colUpdate
     updates []*colUpdate
     onConflict *OnConflictDo
+    constraints []constraintSpec
+    constraint constraintSpec
 }
 
 %token CREATE USE DATABASE SNAPSHOT SINCE AFTER BEFORE UNTIL TX OF TIMESTAMP TABLE UNIQUE INDEX ON ALTER ADD RENAME TO COLUMN PRIMARY KEY
 %token BEGIN TRANSACTION COMMIT ROLLBACK
 %token INSERT UPSERT INTO VALUES DELETE UPDATE SET CONFLICT DO NOTHING
-%token SELECT DISTINCT FROM JOIN HAVING WHERE GROUP BY LIMIT OFFSET ORDER ASC DESC AS UNION ALL
+%token SELECT DISTINCT FROM JOIN HAVING WHERE GROUP BY LIMIT OFFSET ORDER ASC DESC AS UNION ALL INTERSECT EXCEPT
+%token OVER PARTITION
 %token NOT LIKE IF EXISTS IN IS
+%token VIEW DROP
+%token CHECK FOREIGN REFERENCES
+%token EXPLAIN
 %token AUTO_INCREMENT NULL CAST
 %token <id> NPARAM
 %token <pparam> PPARAM
@@ -82,8 +88,10 @@ func setResult(l yyLexer, stmts []SQLStmt) {
 %token <id> IDENTIFIER
 %token <sqlType> TYPE
 %token <number> NUMBER
+%token <value> DECIMAL
 %token <str> VARCHAR
 %token <boolean> BOOLEAN
+%token <boolean> ARROW
 %token <blob> BLOB
 %token <aggFn> AGGREGATE_FUNC
 %token <err> ERROR
@@ -101,10 +109,12 @@ func setResult(l yyLexer, stmts []SQLStmt) {
 %left IS
 
 %type <stmts> sql sqlstmts
-%type <stmt> sqlstmt ddlstmt dmlstmt dqlstmt select_stmt
+%type <stmt> sqlstmt ddlstmt dmlstmt dqlstmt intersect_stmt select_stmt
 %type <colsSpec> colsSpec
 %type <colSpec> colSpec
-%type <ids> ids one_or_more_ids opt_ids
+%type <constraints> opt_constraints
+%type <constraint> constraint
+%type <ids> ids one_or_more_ids opt_ids opt_conflict_target
 %type <cols> cols
 %type <rows> rows
 %type <row> row
@@ -116,22 +126,21 @@ func setResult(l yyLexer, stmts []SQLStmt) {
 %type <distinct> opt_distinct opt_all
 %type <ds> ds
 %type <tableRef> tableRef
-%type <period> opt_period
-%type <openPeriod> opt_period_start
-%type <openPeriod> opt_period_end
-%type <periodInstant> period_instant
+%type <period> opt_period period
+%type <openPeriod> period_start period_end opt_period_end
+%type <periodInstant> period_instant as_of_instant
 %type <joins> opt_joins joins
 %type <join> join
 %type <joinType> opt_join_type
 %type <exp> exp opt_where opt_having boundexp
 %type <binExp> binExp
-%type <cols> opt_groupby
-%type <number> opt_limit opt_offset opt_max_len
-%type <id> opt_as
+%type <cols> opt_groupby opt_partitionby
+%type <number> opt_limit opt_offset opt_max_len opt_scale
+%type <id> opt_as identifier
 %type <ordcols> ordcols opt_orderby
 %type <opt_ord> opt_ord
 %type <ids> opt_indexon
-%type <boolean> opt_if_not_exists opt_auto_increment opt_not_null opt_not
+%type <boolean> opt_if_not_exists opt_if_exists opt_auto_increment opt_not_null opt_not
 %type <update> update
 %type <updates> updates
 %type <onConflict> opt_on_conflict
@@ -160,6 +169,11 @@ sqlstmts:
 opt_separator: {} | STMT_SEPARATOR
 
 sqlstmt: ddlstmt | dmlstmt | dqlstmt
+|
+    EXPLAIN dqlstmt
+    {
+        $$ = &ExplainStmt{query: $2.(DataSource)}
+    }
 
 ddlstmt:
     BEGIN TRANSACTION
@@ -182,17 +196,17 @@ ddlstmt:
         $$ = &RollbackStmt{}
     }
 |
-    CREATE DATABASE opt_if_not_exists IDENTIFIER
+    CREATE DATABASE opt_if_not_exists identifier
     {
         $$ = &CreateDatabaseStmt{ifNotExists: $3, DB: $4}
     }
 |
-    USE IDENTIFIER
+    USE identifier
     {
         $$ = &UseDatabaseStmt{DB: $2}
     }
 |
-    USE DATABASE IDENTIFIER
+    USE DATABASE identifier
     {
         $$ = &UseDatabaseStmt{DB: $3}
     }
@@ -202,30 +216,50 @@ ddlstmt:
         $$ = &UseSnapshotStmt{period: $3}
     }
 |
-    CREATE TABLE opt_if_not_exists IDENTIFIER '(' colsSpec ',' PRIMARY KEY one_or_more_ids ')'
+    CREATE TABLE opt_if_not_exists identifier '(' colsSpec ',' PRIMARY KEY one_or_more_ids opt_constraints ')'
     {
-        $$ = &CreateTableStmt{ifNotExists: $3, table: $4, colsSpec: $6, pkColNames: $10}
+        $$ = &CreateTableStmt{ifNotExists: $3, table: $4, colsSpec: $6, pkColNames: $10, constraints: $11}
     }
 |
-    CREATE INDEX opt_if_not_exists ON IDENTIFIER '(' ids ')'
+    CREATE INDEX opt_if_not_exists ON identifier '(' ids ')' opt_where
     {
-        $$ = &CreateIndexStmt{ifNotExists: $3, table: $5, cols: $7}
+        $$ = &CreateIndexStmt{ifNotExists: $3, table: $5, cols: $7, where: $9, whereSQL: yylex.(*lexer).indexPredicate()}
     }
 |
-    CREATE UNIQUE INDEX opt_if_not_exists ON IDENTIFIER '(' ids ')'
+    CREATE UNIQUE INDEX opt_if_not_exists ON identifier '(' ids ')' opt_where
     {
-        $$ = &CreateIndexStmt{unique: true, ifNotExists: $4, table: $6, cols: $8}
+        $$ = &CreateIndexStmt{unique: true, ifNotExists: $4, table: $6, cols: $8, where: $10, whereSQL: yylex.(*lexer).indexPredicate()}
     }
 |
-    ALTER TABLE IDENTIFIER ADD COLUMN colSpec
+    ALTER TABLE identifier ADD COLUMN colSpec
     {
         $$ = &AddColumnStmt{table: $3, colSpec: $6}
     }
 |
-    ALTER TABLE IDENTIFIER RENAME COLUMN IDENTIFIER TO IDENTIFIER
+    ALTER TABLE identifier RENAME COLUMN identifier TO identifier
     {
         $$ = &RenameColumnStmt{table: $3, oldName: $6, newName: $8}
     }
+|
+    ALTER TABLE identifier DROP COLUMN identifier
+    {
+        $$ = &DropColumnStmt{table: $3, colName: $6}
+    }
+|
+    ALTER TABLE identifier ALTER COLUMN colSpec
+    {
+        $$ = &AlterColumnStmt{table: $3, colSpec: $6}
+    }
+|
+    CREATE VIEW opt_if_not_exists identifier AS dqlstmt
+    {
+        $$ = &CreateViewStmt{ifNotExists: $3, view: $4, query: $6.(DataSource), sql: yylex.(*lexer).viewDefinition()}
+    }
+|
+    DROP VIEW opt_if_exists identifier
+    {
+        $$ = &DropViewStmt{ifExists: $3, view: $4}
+    }
 
 opt_if_not_exists:
     {
@@ -237,8 +271,18 @@ opt_if_not_exists:
         $$ = true
     }
 
+opt_if_exists:
+    {
+        $$ = false
+    }
+|
+    IF EXISTS
+    {
+        $$ = true
+    }
+
 one_or_more_ids:
-    IDENTIFIER
+    identifier
     {
         $$ = []string{$1}
     }
@@ -274,9 +318,24 @@ opt_on_conflict:
         $$ = nil
     }
 |
-    ON CONFLICT DO NOTHING
+    ON CONFLICT opt_conflict_target DO NOTHING
+    {
+        $$ = &OnConflictDo{target: $3}
+    }
+|
+    ON CONFLICT opt_conflict_target DO UPDATE SET updates
     {
-        $$ = &OnConflictDo{}
+        $$ = &OnConflictDo{target: $3, updates: $7}
+    }
+
+opt_conflict_target:
+    {
+        $$ = nil
+    }
+|
+    '(' ids ')'
+    {
+        $$ = $2
     }
 
 updates:
@@ -291,7 +350,7 @@ updates:
     }
 
 update:
-    IDENTIFIER CMPOP exp
+    identifier CMPOP exp
     {
         $$ = &colUpdate{col: $1, op: $2, val: $3}
     }
@@ -324,12 +383,12 @@ row:
     }
 
 ids:
-    IDENTIFIER
+    identifier
     {
         $$ = []string{$1}
     }
 |
-    ids ',' IDENTIFIER
+    ids ',' identifier
     {
         $$ = append($1, $3)
     }
@@ -371,6 +430,11 @@ val:
     {
         $$ = &Number{val: int64($1)}
     }
+|
+    DECIMAL
+    {
+        $$ = $1
+    }
 |
     VARCHAR
     {
@@ -413,11 +477,37 @@ val:
     }
 
 fnCall:
-    IDENTIFIER '(' opt_values ')'
+    identifier '(' opt_values ')'
     {
         $$ = &FnCall{fn: $1, params: $3}
     }
 
+opt_constraints:
+    {
+        $$ = nil
+    }
+|
+    opt_constraints ',' constraint
+    {
+        $$ = append($1, $3)
+    }
+
+constraint:
+    CHECK '(' exp ')'
+    {
+        $$ = &CheckSpec{exp: $3, sql: yylex.(*lexer).checkDefinition()}
+    }
+|
+    FOREIGN KEY '(' ids ')' REFERENCES identifier
+    {
+        $$ = &ForeignKeySpec{cols: $4, refTable: $7}
+    }
+|
+    FOREIGN KEY '(' ids ')' REFERENCES identifier '(' ids ')'
+    {
+        $$ = &ForeignKeySpec{cols: $4, refTable: $7, refCols: $9}
+    }
+
 colsSpec:
     colSpec
     {
@@ -430,10 +520,25 @@ colsSpec:
     }
 
 colSpec:
-    IDENTIFIER TYPE opt_max_len opt_not_null opt_auto_increment
+    identifier TYPE opt_max_len opt_not_null opt_auto_increment
     {
         $$ = &ColSpec{colName: $1, colType: $2, maxLen: int($3), notNull: $4, autoIncrement: $5}
     }
+|
+    identifier TYPE '(' NUMBER opt_scale ')' opt_not_null opt_auto_increment
+    {
+        $$ = &ColSpec{colName: $1, colType: $2, precision: int($4), scale: int($5), notNull: $7, autoIncrement: $8}
+    }
+
+opt_scale:
+    {
+        $$ = 0
+    }
+|
+    ',' NUMBER
+    {
+        $$ = $2
+    }
 
 opt_max_len:
     {
@@ -471,12 +576,12 @@ opt_not_null:
     }
 
 dqlstmt:
-    select_stmt
+    intersect_stmt
     {
         $$ = $1
     }
 |
-    select_stmt UNION opt_all dqlstmt
+    dqlstmt UNION opt_all intersect_stmt
     {
         $$ = &UnionStmt{
             distinct: $3,
@@ -484,6 +589,32 @@ dqlstmt:
             right: $4.(DataSource),
         }
     }
+|
+    dqlstmt EXCEPT opt_all intersect_stmt
+    {
+        $$ = &SetOpStmt{
+            op: ExceptOp,
+            distinct: $3,
+            left: $1.(DataSource),
+            right: $4.(DataSource),
+        }
+    }
+
+intersect_stmt:
+    select_stmt
+    {
+        $$ = $1
+    }
+|
+    intersect_stmt INTERSECT opt_all select_stmt
+    {
+        $$ = &SetOpStmt{
+            op: IntersectOp,
+            distinct: $3,
+            left: $1.(DataSource),
+            right: $4.(DataSource),
+        }
+    }
 
 select_stmt: SELECT opt_distinct opt_selectors FROM ds opt_indexon opt_joins opt_where opt_groupby opt_having opt_orderby opt_limit opt_offset
     {
@@ -561,31 +692,58 @@ selector:
     {
         $$ = &AggColSelector{aggFn: $1, db: $3.db, table: $3.table, col: $3.col}
     }
+|
+    identifier '(' opt_values ')' OVER '(' opt_partitionby opt_orderby ')'
+    {
+        $$ = &WindowFnSelector{fn: $1, params: $3, partitionBy: $7, orderBy: $8}
+    }
 
 col:
-    IDENTIFIER
+    identifier
     {
         $$ = &ColSelector{col: $1}
     }
 |
-    IDENTIFIER '.' IDENTIFIER
+    identifier '.' identifier
     {
         $$ = &ColSelector{table: $1, col: $3}
     }
 
 ds:
-    tableRef opt_period opt_as
+    tableRef opt_as
+    {
+        $1.as = $2
+        $$ = $1
+    }
+|
+    tableRef period opt_as
     {
         $1.period = $2
         $1.as = $3
         $$ = $1
     }
+|
+    tableRef AS OF as_of_instant opt_as
+    {
+        asOf := $4
+        $1.period = period{asOf: &asOf}
+        $1.as = $5
+        $$ = $1
+    }
 |
     '(' dqlstmt ')' opt_as
     {
         $2.(*SelectStmt).as = $4
         $$ = $2.(DataSource)
     }
+|
+    '(' dqlstmt ')' AS OF as_of_instant opt_as
+    {
+        asOf := $6
+        applyPeriod($2.(DataSource), period{asOf: &asOf})
+        $2.(*SelectStmt).as = $7
+        $$ = $2.(DataSource)
+    }
 |
     fnCall opt_as
     {
@@ -593,22 +751,33 @@ ds:
     }
 
 tableRef:
-    IDENTIFIER
+    identifier
     {
         $$ = &tableRef{table: $1}
     }
 
 opt_period:
-    opt_period_start opt_period_end
     {
-        $$ = period{start: $1, end: $2}
+        $$ = period{}
+    }
+|
+    period
+    {
+        $$ = $1
     }
 
-opt_period_start:
+period:
+    period_start opt_period_end
     {
-        $$ = nil
+        $$ = period{start: $1, end: $2}
     }
 |
+    period_end
+    {
+        $$ = period{end: $1}
+    }
+
+period_start:
     SINCE period_instant
     {
         $$ = &openPeriod{inclusive: true, instant: $2}
@@ -624,6 +793,12 @@ opt_period_end:
         $$ = nil
     }
 |
+    period_end
+    {
+        $$ = $1
+    }
+
+period_end:
     UNTIL period_instant
     {
         $$ = &openPeriod{inclusive: true, instant: $2}
@@ -634,6 +809,22 @@ opt_period_end:
         $$ = &openPeriod{instant: $2}
     }
 
+as_of_instant:
+    TX exp
+    {
+        $$ = periodInstant{instantType: txInstant, exp: $2}
+    }
+|
+    TYPE exp
+    {
+        if $1 != TimestampType {
+            yylex.Error(fmt.Sprintf("syntax error: unexpected %s, expecting TX or TIMESTAMP", $1))
+            return 1
+        }
+
+        $$ = periodInstant{instantType: timeInstant, exp: $2}
+    }
+
 period_instant:
     TX exp
     {
@@ -702,6 +893,16 @@ opt_groupby:
         $$ = $3
     }
 
+opt_partitionby:
+    {
+        $$ = nil
+    }
+|
+    PARTITION BY cols
+    {
+        $$ = $3
+    }
+
 opt_having:
     {
         $$ = nil
@@ -783,12 +984,12 @@ opt_as:
         $$ = ""
     }
 |
-    IDENTIFIER
+    identifier
     {
         $$ = $1
     }
 |
-    AS IDENTIFIER
+    AS identifier
     {
         $$ = $2
     }
@@ -821,12 +1022,12 @@ exp:
 |
     EXISTS '(' dqlstmt ')'
     {
-        $$ = &ExistsBoolExp{q: ($3).(*SelectStmt)}
+        $$ = &ExistsBoolExp{q: $3.(DataSource)}
     }
 |
     boundexp opt_not IN '(' dqlstmt ')'
     {
-        $$ = &InSubQueryExp{val: $1, notIn: $2, q: $5.(*SelectStmt)}
+        $$ = &InSubQueryExp{val: $1, notIn: $2, q: $5.(DataSource)}
     }
 |
     boundexp opt_not IN '(' values ')'
@@ -849,6 +1050,16 @@ boundexp:
     {
         $$ = $2
     }
+|
+    '(' dqlstmt ')'
+    {
+        $$ = &ScalarSubQueryExp{q: $2.(DataSource)}
+    }
+|
+    boundexp ARROW val
+    {
+        $$ = &JSONExtractExp{val: $1, path: $3, asText: $2}
+    }
 
 opt_not:
     {
@@ -900,3 +1111,16 @@ binExp:
     {
         $$ = &CmpBoolExp{left: $1, op: NE, right: &NullValue{t: AnyType}}
     }
+
+identifier:
+    IDENTIFIER
+|
+    VIEW
+    {
+        $$ = "view"
+    }
+|
+    DROP
+    {
+        $$ = "drop"
+    }
@@ -52,6 +52,8 @@ type yySymType struct {
 	update        *colUpdate
 	updates       []*colUpdate
 	onConflict    *OnConflictDo
+	constraints   []constraintSpec
+	constraint    constraintSpec
 }
 
 const CREATE = 57346
@@ -106,29 +108,41 @@ const DESC = 57394
 const AS = 57395
 const UNION = 57396
 const ALL = 57397
-const NOT = 57398
-const LIKE = 57399
-const IF = 57400
-const EXISTS = 57401
-const IN = 57402
-const IS = 57403
-const AUTO_INCREMENT = 57404
-const NULL = 57405
-const CAST = 57406
-const NPARAM = 57407
-const PPARAM = 57408
-const JOINTYPE = 57409
-const LOP = 57410
-const CMPOP = 57411
-const IDENTIFIER = 57412
-const TYPE = 57413
-const NUMBER = 57414
-const VARCHAR = 57415
-const BOOLEAN = 57416
-const BLOB = 57417
-const AGGREGATE_FUNC = 57418
-const ERROR = 57419
-const STMT_SEPARATOR = 57420
+const INTERSECT = 57398
+const EXCEPT = 57399
+const OVER = 57400
+const PARTITION = 57401
+const NOT = 57402
+const LIKE = 57403
+const IF = 57404
+const EXISTS = 57405
+const IN = 57406
+const IS = 57407
+const VIEW = 57408
+const DROP = 57409
+const CHECK = 57410
+const FOREIGN = 57411
+const REFERENCES = 57412
+const EXPLAIN = 57413
+const AUTO_INCREMENT = 57414
+const NULL = 57415
+const CAST = 57416
+const NPARAM = 57417
+const PPARAM = 57418
+const JOINTYPE = 57419
+const LOP = 57420
+const CMPOP = 57421
+const IDENTIFIER = 57422
+const TYPE = 57423
+const NUMBER = 57424
+const DECIMAL = 57425
+const VARCHAR = 57426
+const BOOLEAN = 57427
+const ARROW = 57428
+const BLOB = 57429
+const AGGREGATE_FUNC = 57430
+const ERROR = 57431
+const STMT_SEPARATOR = 57432
 
 var yyToknames = [...]string{
 	"$end",
@@ -186,12 +200,22 @@ var yyToknames = [...]string{
 	"AS",
 	"UNION",
 	"ALL",
+	"INTERSECT",
+	"EXCEPT",
+	"OVER",
+	"PARTITION",
 	"NOT",
 	"LIKE",
 	"IF",
 	"EXISTS",
 	"IN",
 	"IS",
+	"VIEW",
+	"DROP",
+	"CHECK",
+	"FOREIGN",
+	"REFERENCES",
+	"EXPLAIN",
 	"AUTO_INCREMENT",
 	"NULL",
 	"CAST",
@@ -203,8 +227,10 @@ var yyToknames = [...]string{
 	"IDENTIFIER",
 	"TYPE",
 	"NUMBER",
+	"DECIMAL",
 	"VARCHAR",
 	"BOOLEAN",
+	"ARROW",
 	"BLOB",
 	"AGGREGATE_FUNC",
 	"ERROR",
@@ -227,225 +253,285 @@ const yyEofCode = 1
 const yyErrCode = 2
 const yyInitialStackSize = 16
 
-var yyExca = [...]int{
+var yyExca = [...]int16{
 	-1, 1,
 	1, -1,
 	-2, 0,
-	-1, 74,
-	57, 137,
-	60, 137,
-	-2, 126,
-	-1, 186,
-	43, 102,
-	-2, 97,
-	-1, 215,
-	43, 102,
-	-2, 99,
+	-1, 96,
+	61, 172,
+	64, 172,
+	-2, 159,
+	-1, 227,
+	43, 133,
+	-2, 128,
+	-1, 260,
+	43, 133,
+	-2, 130,
 }
 
 const yyPrivate = 57344
 
-const yyLast = 373
-
-var yyAct = [...]int{
-	73, 292, 60, 180, 138, 208, 232, 236, 87, 135,
-	144, 172, 214, 105, 231, 97, 173, 155, 45, 6,
-	79, 265, 100, 178, 223, 203, 18, 178, 178, 178,
-	274, 269, 72, 251, 268, 249, 224, 179, 237, 148,
-	252, 250, 76, 219, 202, 78, 200, 192, 191, 90,
-	86, 88, 89, 238, 146, 233, 91, 59, 82, 83,
-	84, 85, 61, 177, 199, 109, 77, 131, 196, 131,
-	157, 81, 130, 116, 102, 128, 76, 126, 127, 78,
-	111, 108, 129, 90, 86, 88, 89, 96, 95, 20,
-	91, 109, 82, 83, 84, 85, 61, 62, 248, 140,
-	77, 291, 62, 61, 98, 81, 137, 285, 57, 123,
-	235, 152, 147, 151, 254, 141, 121, 122, 159, 160,
-	161, 162, 163, 164, 203, 149, 255, 117, 118, 120,
-	119, 171, 174, 193, 170, 123, 178, 142, 104, 62,
-	210, 228, 194, 201, 185, 61, 183, 62, 169, 186,
-	175, 123, 136, 117, 118, 120, 119, 230, 121, 122,
-	143, 189, 107, 190, 187, 184, 188, 195, 198, 117,
-	118, 120, 119, 206, 76, 27, 28, 78, 217, 106,
-	101, 90, 86, 88, 89, 220, 254, 212, 91, 123,
-	82, 83, 84, 85, 61, 176, 121, 122, 77, 156,
-	174, 218, 158, 81, 229, 123, 225, 117, 118, 120,
-	119, 221, 153, 122, 71, 123, 227, 150, 239, 226,
-	112, 65, 234, 117, 118, 120, 119, 63, 241, 240,
-	34, 156, 49, 243, 174, 120, 119, 44, 264, 26,
-	247, 197, 166, 263, 123, 256, 110, 246, 257, 165,
-	40, 147, 261, 260, 167, 125, 64, 168, 55, 35,
-	277, 266, 209, 92, 181, 273, 293, 294, 284, 272,
-	259, 39, 278, 98, 271, 280, 10, 11, 242, 103,
-	283, 32, 286, 37, 114, 115, 18, 289, 290, 287,
-	282, 12, 275, 267, 295, 41, 42, 296, 7, 53,
-	8, 9, 13, 14, 145, 31, 15, 16, 207, 205,
-	30, 21, 18, 244, 133, 67, 132, 204, 93, 94,
-	2, 33, 22, 281, 211, 113, 66, 182, 43, 29,
-	139, 23, 25, 24, 19, 50, 51, 52, 70, 69,
-	253, 38, 47, 48, 99, 124, 245, 262, 276, 288,
-	222, 258, 75, 74, 270, 216, 215, 213, 68, 46,
-	54, 36, 58, 56, 80, 279, 134, 154, 17, 5,
-	4, 3, 1,
+const yyLast = 585
+
+var yyAct = [...]int16{
+	104, 168, 128, 253, 378, 325, 341, 300, 221, 292,
+	351, 134, 281, 33, 285, 111, 80, 177, 280, 45,
+	126, 259, 187, 188, 192, 101, 6, 102, 61, 330,
+	171, 355, 345, 129, 26, 59, 152, 272, 24, 273,
+	68, 25, 45, 45, 45, 219, 334, 219, 82, 150,
+	151, 21, 189, 396, 85, 391, 87, 309, 24, 90,
+	219, 25, 146, 147, 149, 148, 297, 247, 374, 389,
+	122, 98, 237, 79, 100, 130, 236, 37, 38, 235,
+	135, 218, 307, 394, 114, 110, 112, 113, 139, 142,
+	161, 36, 386, 105, 106, 107, 108, 359, 109, 81,
+	37, 38, 265, 99, 219, 358, 24, 238, 103, 25,
+	37, 38, 335, 24, 36, 308, 25, 95, 95, 95,
+	95, 219, 381, 348, 36, 169, 169, 170, 219, 306,
+	160, 287, 282, 181, 82, 219, 274, 183, 186, 298,
+	190, 179, 193, 220, 195, 233, 152, 145, 180, 174,
+	246, 156, 157, 245, 185, 208, 159, 211, 242, 182,
+	151, 139, 194, 138, 193, 215, 216, 193, 162, 158,
+	141, 196, 146, 147, 149, 148, 130, 137, 23, 135,
+	173, 135, 207, 135, 212, 209, 248, 125, 214, 124,
+	228, 217, 232, 152, 234, 169, 241, 139, 152, 197,
+	198, 199, 200, 201, 202, 224, 231, 229, 227, 225,
+	152, 150, 151, 312, 127, 213, 175, 377, 37, 38,
+	251, 149, 148, 346, 146, 147, 149, 148, 364, 226,
+	135, 183, 36, 257, 329, 304, 146, 147, 149, 148,
+	193, 263, 303, 169, 275, 184, 37, 38, 37, 38,
+	279, 311, 239, 238, 219, 133, 266, 286, 244, 175,
+	36, 288, 36, 321, 270, 284, 135, 136, 81, 277,
+	81, 283, 276, 77, 255, 155, 293, 295, 278, 289,
+	37, 38, 290, 240, 264, 311, 269, 176, 169, 314,
+	262, 268, 181, 135, 36, 305, 20, 183, 296, 327,
+	286, 154, 34, 35, 318, 313, 152, 180, 302, 317,
+	243, 37, 38, 204, 324, 326, 37, 38, 40, 150,
+	151, 301, 37, 38, 332, 36, 203, 331, 37, 38,
+	36, 340, 146, 147, 149, 148, 36, 37, 38, 186,
+	392, 135, 36, 349, 186, 294, 319, 320, 339, 169,
+	362, 36, 354, 360, 357, 352, 94, 186, 363, 152,
+	352, 140, 37, 38, 123, 186, 371, 375, 369, 370,
+	63, 205, 131, 367, 206, 54, 36, 70, 186, 86,
+	93, 376, 323, 267, 130, 46, 387, 169, 390, 388,
+	350, 51, 144, 393, 384, 169, 395, 121, 118, 119,
+	379, 380, 24, 28, 98, 25, 365, 100, 342, 254,
+	37, 38, 29, 31, 30, 222, 356, 114, 110, 112,
+	113, 343, 338, 291, 36, 316, 105, 106, 107, 108,
+	50, 109, 81, 92, 385, 19, 99, 98, 127, 337,
+	100, 103, 132, 37, 38, 120, 115, 116, 117, 43,
+	114, 110, 112, 113, 152, 48, 52, 36, 361, 105,
+	106, 107, 108, 32, 109, 81, 21, 150, 151, 99,
+	64, 65, 67, 66, 103, 373, 53, 75, 333, 372,
+	146, 147, 149, 148, 37, 38, 83, 210, 84, 383,
+	74, 114, 110, 112, 113, 11, 12, 178, 36, 252,
+	105, 106, 107, 108, 250, 109, 55, 56, 42, 58,
+	13, 41, 27, 382, 299, 230, 44, 8, 166, 9,
+	10, 15, 16, 2, 165, 17, 18, 164, 37, 38,
+	163, 21, 249, 353, 89, 256, 143, 88, 223, 71,
+	72, 73, 36, 57, 39, 67, 66, 49, 64, 65,
+	67, 66, 172, 22, 310, 153, 69, 366, 14, 328,
+	271, 322, 7, 315, 97, 96, 336, 261, 260, 258,
+	91, 62, 60, 47, 78, 76, 347, 167, 368, 344,
+	191, 5, 4, 3, 1,
 }
 
-var yyPact = [...]int{
-	272, -1000, -1000, 5, -1000, -1000, -1000, 284, -1000, -1000,
-	316, 169, 314, 278, 273, 239, 160, 205, 242, -1000,
-	272, -1000, 192, 192, 192, 311, -1000, 167, 334, 162,
-	160, 160, 160, 263, -1000, 203, 27, -1000, -1000, 157,
-	200, 151, 308, 192, -1000, -1000, 328, 20, 20, 298,
-	3, 2, 228, 110, 246, -1000, 237, -1000, 60, 109,
-	-1000, -4, 8, -1000, 187, -5, 150, 307, -1000, 20,
-	20, -1000, 118, 128, 199, -1000, 118, 118, -10, -1000,
-	-1000, 118, -1000, -1000, -1000, -1000, -13, -1000, -1000, -1000,
-	-1000, -18, -1000, 293, 291, 82, 82, 325, 118, 59,
-	-1000, 91, -1000, -31, 69, -1000, -1000, 147, 32, 142,
-	-1000, 129, -15, 132, -1000, -1000, 128, 118, 118, 118,
-	118, 118, 118, 186, 197, -1000, 144, 154, 246, 48,
-	118, 118, 129, 125, -23, 58, -1000, -49, 216, 310,
-	128, 325, 110, 118, 325, 334, 246, 109, -16, 109,
-	-1000, -38, -39, -1000, 55, -1000, 71, 82, -17, 154,
-	154, 183, 183, 144, 74, -1000, 178, 118, -21, -40,
-	-1000, 90, -42, 46, 128, -1000, 295, 276, 103, 275,
-	213, 68, 306, 216, -1000, 128, 111, 109, -43, -1000,
-	-1000, -1000, -1000, 161, -63, -50, 82, -1000, 144, -14,
-	-1000, 70, -1000, 118, 87, -30, -1000, -30, -1000, 38,
-	-1000, -32, 213, 228, -1000, 111, 235, -1000, -1000, 109,
-	288, -1000, 184, 26, -1000, -51, -45, -53, -46, 128,
-	-1000, 108, -1000, 118, 36, -1000, -1000, -1000, 82, -1000,
-	224, -1000, -31, -1000, -32, 181, -1000, 175, -67, -1000,
-	-1000, -1000, -1000, -1000, -30, 256, -52, -55, 230, 222,
-	325, -56, -1000, -1000, -1000, -1000, -1000, 254, -1000, -1000,
-	210, 118, 77, 305, -1000, 251, 216, 221, 128, 29,
-	-1000, 118, -1000, 213, 77, 77, 128, -1000, 23, 215,
-	-1000, 77, -1000, -1000, -1000, 215, -1000,
+var yyPact = [...]int16{
+	491, -1000, -1000, 82, -1000, -1000, 348, 426, 485, -1000,
+	-1000, 397, 296, 529, 252, 479, 476, 407, 256, 329,
+	-1000, 414, -1000, 491, 336, 336, 348, -1000, 313, 313,
+	313, 526, 313, -1000, 256, 540, -1000, -1000, -1000, 256,
+	315, 256, 256, 256, 454, -1000, 336, 180, -1000, -1000,
+	426, -1000, 426, 256, 319, 256, 519, 313, 256, -1000,
+	-1000, -1000, 535, -1000, 344, 344, 344, 344, 378, 256,
+	301, 92, 90, 393, 256, 426, 400, -1000, 165, 214,
+	-1000, 80, 66, 329, 329, -1000, 298, 73, 256, 518,
+	339, -1000, -1000, -1000, 377, 241, 215, -1000, 377, 377,
+	72, -1000, -1000, 11, -7, -1000, -1000, -1000, -1000, -1000,
+	71, -1000, -1000, -1000, -1000, -1000, -1000, -1000, 507, 504,
+	501, 495, -1000, -1000, 256, 256, 547, 377, 169, -1000,
+	208, -1000, 44, 182, -1000, -1000, 256, 152, 377, 256,
+	-1000, 256, 65, 256, 426, 241, 377, 377, 377, 377,
+	377, 377, 253, 310, 418, -1000, 81, 128, 426, 389,
+	59, 377, 377, 256, 256, 256, 256, -17, 164, -1000,
+	45, 367, 521, 241, 547, 256, 377, 547, 462, 426,
+	214, 48, 214, -1000, -19, -22, 102, -26, 163, 241,
+	-1000, 162, -1000, 202, 256, 61, 348, 128, 128, 294,
+	294, 81, 145, -1000, 237, 377, 56, -1000, 48, 52,
+	-1000, -1000, -31, 133, -1000, 510, -1000, -1000, 471, 256,
+	466, 360, 192, 517, 367, -1000, 241, 213, -1000, 214,
+	271, 4, -1000, 377, -1000, -1000, -1000, 325, 377, 262,
+	-60, 38, 256, -1000, 81, 11, -1000, 325, 197, 256,
+	35, -1000, 35, -1000, 183, -1000, 34, 360, 393, -1000,
+	213, 380, -1000, -1000, 264, 245, -32, 42, 241, 489,
+	-1000, 248, 160, 153, 393, 31, -16, 17, -41, -1000,
+	195, -1000, 377, 161, -1000, -1000, -1000, 256, -1000, 379,
+	-1000, 44, 214, 377, 377, -1000, 250, -1000, 323, 34,
+	243, -1000, 226, 144, -71, -1000, 393, -1000, -1000, -1000,
+	-1000, 35, 441, -52, 14, 395, 375, 547, -1000, 241,
+	241, 264, 358, 374, -1000, -1000, -1000, -1000, -66, 141,
+	-1000, -1000, -1000, 26, -1000, -1000, 358, 377, 256, 515,
+	214, -67, 369, 256, 7, 248, -1000, 420, 256, 367,
+	241, 138, -1000, 377, -1000, -1000, 256, 138, -1000, 300,
+	243, 440, -30, 360, 256, 241, 127, 349, -1000, 25,
+	488, -1000, -1000, 453, -1000, -1000, -1000, 256, -1000, -1000,
+	-1000, 377, -5, 256, 349, -29, 256, 126, -1000, -1000,
+	-43, 270, 256, -14, 256, -45, -1000,
 }
 
-var yyPgo = [...]int{
-	0, 372, 320, 371, 370, 369, 19, 368, 367, 17,
-	9, 7, 366, 365, 14, 6, 16, 11, 364, 8,
-	20, 363, 362, 2, 361, 360, 10, 304, 18, 359,
-	358, 214, 357, 12, 356, 355, 0, 15, 354, 353,
-	352, 351, 3, 5, 350, 13, 349, 348, 1, 4,
-	271, 347, 346, 345, 22, 344, 340, 334,
+var yyPgo = [...]int16{
+	0, 584, 523, 583, 582, 581, 26, 435, 296, 580,
+	24, 579, 578, 1, 14, 577, 576, 10, 18, 12,
+	23, 22, 27, 15, 25, 575, 574, 16, 573, 430,
+	17, 497, 572, 28, 571, 370, 570, 380, 9, 569,
+	21, 568, 567, 52, 20, 566, 565, 564, 563, 561,
+	8, 3, 560, 559, 11, 0, 557, 6, 4, 30,
+	476, 556, 5, 7, 555, 33, 2, 554, 553,
 }
 
-var yyR1 = [...]int{
-	0, 1, 2, 2, 57, 57, 3, 3, 3, 4,
+var yyR1 = [...]int8{
+	0, 1, 2, 2, 68, 68, 3, 3, 3, 3,
 	4, 4, 4, 4, 4, 4, 4, 4, 4, 4,
-	4, 4, 50, 50, 11, 11, 5, 5, 5, 5,
-	56, 56, 55, 55, 54, 12, 12, 14, 14, 15,
-	10, 10, 13, 13, 17, 17, 16, 16, 18, 18,
-	18, 18, 18, 18, 18, 18, 18, 19, 8, 8,
-	9, 44, 44, 51, 51, 52, 52, 52, 6, 6,
-	7, 25, 25, 24, 24, 21, 21, 22, 22, 20,
-	20, 20, 23, 23, 26, 26, 26, 27, 28, 29,
-	29, 29, 30, 30, 30, 31, 31, 32, 32, 33,
-	33, 34, 35, 35, 37, 37, 41, 41, 38, 38,
-	42, 42, 43, 43, 47, 47, 49, 49, 46, 46,
-	48, 48, 48, 45, 45, 45, 36, 36, 36, 36,
-	36, 36, 36, 36, 39, 39, 39, 53, 53, 40,
-	40, 40, 40, 40, 40, 40, 40,
+	4, 4, 4, 4, 4, 4, 4, 60, 60, 61,
+	61, 14, 14, 5, 5, 5, 5, 67, 67, 67,
+	16, 16, 66, 66, 65, 15, 15, 18, 18, 19,
+	13, 13, 17, 17, 21, 21, 20, 20, 22, 22,
+	22, 22, 22, 22, 22, 22, 22, 22, 23, 11,
+	11, 12, 12, 12, 9, 9, 10, 10, 53, 53,
+	52, 52, 62, 62, 63, 63, 63, 6, 6, 6,
+	7, 7, 8, 29, 29, 28, 28, 25, 25, 26,
+	26, 24, 24, 24, 24, 27, 27, 30, 30, 30,
+	30, 30, 30, 31, 32, 32, 33, 33, 34, 34,
+	36, 36, 35, 35, 38, 38, 37, 37, 39, 39,
+	40, 40, 41, 42, 42, 44, 44, 48, 48, 49,
+	49, 45, 45, 50, 50, 51, 51, 57, 57, 59,
+	59, 56, 56, 58, 58, 58, 54, 54, 54, 43,
+	43, 43, 43, 43, 43, 43, 43, 46, 46, 46,
+	46, 46, 64, 64, 47, 47, 47, 47, 47, 47,
+	47, 47, 55, 55, 55,
 }
 
-var yyR2 = [...]int{
+var yyR2 = [...]int8{
 	0, 1, 2, 3, 0, 1, 1, 1, 1, 2,
-	1, 1, 1, 4, 2, 3, 3, 11, 8, 9,
-	6, 8, 0, 3, 1, 3, 9, 8, 7, 8,
-	0, 4, 1, 3, 3, 0, 1, 1, 3, 3,
+	2, 1, 1, 1, 4, 2, 3, 3, 12, 9,
+	10, 6, 8, 6, 6, 6, 4, 0, 3, 0,
+	2, 1, 3, 9, 8, 7, 8, 0, 5, 7,
+	0, 3, 1, 3, 3, 0, 1, 1, 3, 3,
 	1, 3, 1, 3, 0, 1, 1, 3, 1, 1,
-	1, 1, 6, 1, 1, 1, 1, 4, 1, 3,
-	5, 0, 3, 0, 1, 0, 1, 2, 1, 4,
-	13, 0, 1, 0, 1, 1, 1, 2, 4, 1,
-	4, 4, 1, 3, 3, 4, 2, 1, 2, 0,
-	2, 2, 0, 2, 2, 2, 1, 0, 1, 1,
-	2, 6, 0, 1, 0, 2, 0, 3, 0, 2,
-	0, 2, 0, 2, 0, 3, 0, 4, 2, 4,
-	0, 1, 1, 0, 1, 2, 1, 1, 2, 2,
-	4, 4, 6, 6, 1, 1, 3, 0, 1, 3,
-	3, 3, 3, 3, 3, 3, 4,
+	1, 1, 1, 6, 1, 1, 1, 1, 4, 0,
+	3, 4, 7, 10, 1, 3, 5, 8, 0, 2,
+	0, 3, 0, 1, 0, 1, 2, 1, 4, 4,
+	1, 4, 13, 0, 1, 0, 1, 1, 1, 2,
+	4, 1, 4, 4, 9, 1, 3, 2, 3, 5,
+	4, 7, 2, 1, 0, 1, 2, 1, 2, 2,
+	0, 1, 2, 2, 2, 2, 2, 1, 0, 1,
+	1, 2, 6, 0, 1, 0, 2, 0, 3, 0,
+	3, 0, 2, 0, 2, 0, 2, 0, 3, 0,
+	4, 2, 4, 0, 1, 1, 0, 1, 2, 1,
+	1, 2, 2, 4, 4, 6, 6, 1, 1, 3,
+	3, 3, 0, 1, 3, 3, 3, 3, 3, 3,
+	3, 4, 1, 1, 1,
 }
 
-var yyChk = [...]int{
-	-1000, -1, -2, -3, -4, -5, -6, 26, 28, 29,
-	4, 5, 19, 30, 31, 34, 35, -7, 40, -57,
-	84, 27, 6, 15, 17, 16, 70, 6, 7, 15,
-	32, 32, 42, -27, 70, 54, -24, 41, -2, -50,
-	58, -50, -50, 17, 70, -28, -29, 8, 9, 70,
-	-27, -27, -27, 36, -25, 55, -21, 81, -22, -20,
-	-23, 76, 70, 70, 56, 70, 18, -50, -30, 11,
-	10, -31, 12, -36, -39, -40, 56, 80, 59, -20,
-	-18, 85, 72, 73, 74, 75, 64, -19, 65, 66,
-	63, 70, -31, 20, 21, 85, 85, -37, 45, -55,
-	-54, 70, -6, 42, 78, -45, 70, 53, 85, 83,
-	59, 85, 70, 18, -31, -31, -36, 79, 80, 82,
-	81, 68, 69, 61, -53, 56, -36, -36, 85, -36,
-	85, 85, 23, 23, -12, -10, 70, -10, -49, 5,
-	-36, -37, 78, 69, -26, -27, 85, -19, 70, -20,
-	70, 81, -23, 70, -8, -9, 70, 85, 70, -36,
-	-36, -36, -36, -36, -36, 63, 56, 57, 60, -6,
-	86, -36, -17, -16, -36, -9, 70, 86, 78, 86,
-	-42, 48, 17, -49, -54, -36, -49, -28, -6, -45,
-	-45, 86, 86, 78, 71, -10, 85, 63, -36, 85,
-	86, 53, 86, 78, 22, 33, 70, 33, -43, 49,
-	72, 18, -42, -32, -33, -34, -35, 67, -45, 86,
-	24, -9, -44, 87, 86, -10, -6, -16, 71, -36,
-	70, -14, -15, 85, -14, 72, -11, 70, 85, -43,
-	-37, -33, 43, -45, 25, -52, 63, 56, 72, 86,
-	86, 86, 86, -56, 78, 18, -17, -10, -41, 46,
-	-26, -11, -51, 62, 63, 88, -15, 37, 86, 86,
-	-38, 44, 47, -49, 86, 38, -47, 50, -36, -13,
-	-23, 18, 39, -42, 47, 78, -36, -43, -46, -23,
-	-23, 78, -48, 51, 52, -23, -48,
+var yyChk = [...]int16{
+	-1000, -1, -2, -3, -4, -5, -6, 71, 26, 28,
+	29, 4, 5, 19, 67, 30, 31, 34, 35, -7,
+	-8, 40, -68, 96, 54, 57, -6, 27, 6, 15,
+	17, 16, 66, -55, 6, 7, 80, 66, 67, 15,
+	66, 32, 32, 42, -31, -55, 56, -28, 41, -2,
+	-29, 55, -29, -60, 62, -60, -60, 17, -60, -55,
+	-32, -33, -34, -35, 8, 9, 11, 10, -55, -61,
+	62, -31, -31, -31, 36, -29, -25, 93, -26, -24,
+	-27, 88, -55, -7, -7, -55, 60, -55, 18, -60,
+	-55, -36, -35, -37, 12, -43, -46, -47, 60, 92,
+	63, -24, -22, 97, -55, 82, 83, 84, 85, 87,
+	74, -23, 75, 76, 73, -37, -37, -37, 20, 21,
+	67, 19, -55, 63, 97, 97, -44, 45, -66, -65,
+	-55, -8, 42, 90, -54, -55, 53, 97, 97, 95,
+	63, 97, -55, 18, 53, -43, 91, 92, 94, 93,
+	78, 79, 65, -64, 86, 60, -43, -43, 97, -43,
+	-6, 97, 97, 23, 23, 23, 23, -15, -13, -55,
+	-13, -59, 5, -43, -44, 90, 79, -30, -31, 97,
+	-23, -55, -24, -55, 93, -27, -55, -21, -20, -43,
+	-55, -9, -10, -55, 97, -55, -6, -43, -43, -43,
+	-43, -43, -43, 73, 60, 61, 64, -22, -55, -6,
+	98, 98, -21, -43, -10, -55, -55, -10, 98, 90,
+	98, -50, 48, 17, -59, -65, -43, -59, -54, -33,
+	53, -6, -54, 97, -54, 98, 98, 98, 90, 90,
+	81, -13, 97, 73, -43, 97, 98, 98, 53, 22,
+	33, -55, 33, -51, 49, 82, 18, -50, -39, -40,
+	-41, -42, 77, -54, 13, 98, -21, 58, -43, 24,
+	-10, -52, 97, 99, 98, -13, -6, -20, 81, -55,
+	-18, -19, 97, -18, 82, -14, -55, 97, -51, -44,
+	-40, 43, -38, 12, 81, -54, 53, 98, 97, 25,
+	-63, 73, 60, 82, 82, -44, 98, 98, 98, 98,
+	-67, 90, 18, -21, -13, -48, 46, -30, -54, -43,
+	-43, 13, -49, 59, -14, -62, 72, 73, -53, 90,
+	100, -44, -19, 37, 98, 98, -45, 44, 47, -59,
+	-38, -57, 50, 47, -11, 98, 82, -16, 97, -57,
+	-43, -17, -27, 18, -54, 98, 47, -17, 98, 90,
+	-63, 38, -13, -50, 90, -43, -56, -27, -12, 68,
+	69, -62, 39, 35, 98, -51, -27, 90, -58, 51,
+	52, 97, 25, 36, -27, -43, 97, -66, -58, 98,
+	-13, 98, 70, -55, 97, -13, 98,
 }
 
-var yyDef = [...]int{
-	0, -2, 1, 4, 6, 7, 8, 10, 11, 12,
-	0, 0, 0, 0, 0, 0, 0, 68, 73, 2,
-	5, 9, 22, 22, 22, 0, 14, 0, 89, 0,
-	0, 0, 0, 0, 87, 71, 0, 74, 3, 0,
-	0, 0, 0, 22, 15, 16, 92, 0, 0, 0,
-	0, 0, 104, 0, 0, 72, 0, 75, 76, 123,
-	79, 0, 82, 13, 0, 0, 0, 0, 88, 0,
-	0, 90, 0, 96, -2, 127, 0, 0, 0, 134,
-	135, 0, 48, 49, 50, 51, 0, 53, 54, 55,
-	56, 82, 91, 0, 0, 35, 0, 116, 0, 104,
-	32, 0, 69, 0, 0, 77, 124, 0, 0, 0,
-	23, 0, 0, 0, 93, 94, 95, 0, 0, 0,
-	0, 0, 0, 0, 0, 138, 128, 129, 0, 0,
-	0, 44, 0, 0, 0, 36, 40, 0, 110, 0,
-	105, 116, 0, 0, 116, 89, 0, 123, 87, 123,
-	125, 0, 0, 83, 0, 58, 0, 0, 0, 139,
-	140, 141, 142, 143, 144, 145, 0, 0, 0, 0,
-	136, 0, 0, 45, 46, 20, 0, 0, 0, 0,
-	112, 0, 0, 110, 33, 34, -2, 123, 0, 86,
-	78, 80, 81, 0, 61, 0, 0, 146, 130, 0,
-	131, 0, 57, 0, 0, 0, 41, 0, 28, 0,
-	111, 0, 112, 104, 98, -2, 0, 103, 84, 123,
-	0, 59, 65, 0, 18, 0, 0, 0, 0, 47,
-	21, 30, 37, 44, 27, 113, 117, 24, 0, 29,
-	106, 100, 0, 85, 0, 63, 66, 0, 0, 19,
-	132, 133, 52, 26, 0, 0, 0, 0, 108, 0,
-	116, 0, 60, 64, 67, 62, 38, 0, 39, 25,
-	114, 0, 0, 0, 17, 0, 110, 0, 109, 107,
-	42, 0, 31, 112, 0, 0, 101, 70, 115, 120,
-	43, 0, 118, 121, 122, 120, 119,
+var yyDef = [...]int16{
+	0, -2, 1, 4, 6, 7, 8, 0, 11, 12,
+	13, 0, 0, 0, 0, 0, 0, 0, 0, 87,
+	90, 95, 2, 5, 93, 93, 9, 10, 27, 27,
+	27, 0, 27, 15, 0, 114, 182, 183, 184, 0,
+	29, 0, 0, 0, 0, 113, 93, 0, 96, 3,
+	0, 94, 0, 0, 0, 0, 0, 27, 0, 16,
+	17, 115, 120, 117, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 135, 0, 0, 0, 97, 98, 156,
+	101, 0, 105, 88, 89, 14, 0, 0, 0, 0,
+	0, 116, 121, 118, 0, 127, -2, 160, 0, 0,
+	0, 167, 168, 0, 105, 58, 59, 60, 61, 62,
+	0, 64, 65, 66, 67, 119, 122, 123, 0, 0,
+	0, 0, 26, 30, 45, 0, 149, 0, 135, 42,
+	0, 91, 0, 0, 99, 157, 0, 0, 54, 0,
+	28, 0, 0, 0, 0, 126, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 173, 161, 162, 0, 0,
+	0, 54, 0, 0, 0, 0, 0, 0, 46, 50,
+	0, 143, 0, 136, 149, 0, 0, 149, 156, 0,
+	156, 113, 156, 158, 0, 0, 105, 0, 55, 56,
+	106, 0, 74, 0, 0, 0, 25, 174, 175, 176,
+	177, 178, 179, 180, 0, 0, 0, 171, 0, 0,
+	169, 170, 0, 0, 21, 0, 23, 24, 0, 0,
+	0, 145, 0, 0, 143, 43, 44, -2, 107, 156,
+	0, 0, 112, 54, 100, 102, 103, 0, 0, 0,
+	80, 0, 0, 181, 163, 0, 164, 68, 0, 0,
+	0, 51, 0, 35, 0, 144, 0, 145, 135, 129,
+	-2, 0, 134, 108, 0, 156, 0, 0, 57, 0,
+	75, 84, 0, 0, 135, 0, 0, 0, 0, 22,
+	37, 47, 54, 34, 146, 150, 31, 0, 36, 137,
+	131, 0, 156, 0, 0, 110, 0, 68, 139, 0,
+	82, 85, 0, 78, 0, 19, 135, 165, 166, 63,
+	33, 0, 0, 0, 0, 141, 0, 149, 109, 124,
+	125, 0, 147, 0, 69, 76, 83, 86, 0, 0,
+	81, 20, 48, 40, 49, 32, 147, 0, 0, 0,
+	156, 0, 0, 0, 0, 84, 79, 0, 0, 143,
+	142, 138, 52, 0, 111, 104, 0, 140, 18, 0,
+	82, 0, 0, 145, 0, 132, 148, 153, 70, 0,
+	0, 77, 38, 0, 41, 92, 53, 0, 151, 154,
+	155, 0, 0, 0, 153, 0, 0, 39, 152, 71,
+	0, 0, 0, 72, 0, 0, 73,
 }
 
-var yyTok1 = [...]int{
+var yyTok1 = [...]int8{
 	1, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	85, 86, 81, 79, 78, 80, 83, 82, 3, 3,
+	97, 98, 93, 91, 90, 92, 95, 94, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 87, 3, 88,
+	3, 99, 3, 100,
 }
 
-var yyTok2 = [...]int{
+var yyTok2 = [...]int8{
 	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
 	12, 13, 14, 15, 16, 17, 18, 19, 20, 21,
 	22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
@@ -453,10 +539,11 @@ var yyTok2 = [...]int{
 	42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
 	52, 53, 54, 55, 56, 57, 58, 59, 60, 61,
 	62, 63, 64, 65, 66, 67, 68, 69, 70, 71,
-	72, 73, 74, 75, 76, 77, 84,
+	72, 73, 74, 75, 76, 77, 78, 79, 80, 81,
+	82, 83, 84, 85, 86, 87, 88, 89, 96,
 }
 
-var yyTok3 = [...]int{
+var yyTok3 = [...]int8{
 	0,
 }
 
@@ -536,9 +623,9 @@ func yyErrorMessage(state, lookAhead int) string {
 	expected := make([]int, 0, 4)
 
 	// Look for shiftable tokens.
-	base := yyPact[state]
+	base := int(yyPact[state])
 	for tok := TOKSTART; tok-1 < len(yyToknames); tok++ {
-		if n := base + tok; n >= 0 && n < yyLast && yyChk[yyAct[n]] == tok {
+		if n := base + tok; n >= 0 && n < yyLast && int(yyChk[int(yyAct[n])]) == tok {
 			if len(expected) == cap(expected) {
 				return res
 			}
@@ -548,13 +635,13 @@ func yyErrorMessage(state, lookAhead int) string {
 
 	if yyDef[state] == -2 {
 		i := 0
-		for yyExca[i] != -1 || yyExca[i+1] != state {
+		for yyExca[i] != -1 || int(yyExca[i+1]) != state {
 			i += 2
 		}
 
 		// Look for tokens that we accept or reduce.
 		for i += 2; yyExca[i] >= 0; i += 2 {
-			tok := yyExca[i]
+			tok := int(yyExca[i])
 			if tok < TOKSTART || yyExca[i+1] == 0 {
 				continue
 			}
@@ -585,30 +672,30 @@ func yylex1(lex yyLexer, lval *yySymType) (char, token int) {
 	token = 0
 	char = lex.Lex(lval)
 	if char <= 0 {
-		token = yyTok1[0]
+		token = int(yyTok1[0])
 		goto out
 	}
 	if char < len(yyTok1) {
-		token = yyTok1[char]
+		token = int(yyTok1[char])
 		goto out
 	}
 	if char >= yyPrivate {
 		if char < yyPrivate+len(yyTok2) {
-			token = yyTok2[char-yyPrivate]
+			token = int(yyTok2[char-yyPrivate])
 			goto out
 		}
 	}
 	for i := 0; i < len(yyTok3); i += 2 {
-		token = yyTok3[i+0]
+		token = int(yyTok3[i+0])
 		if token == char {
-			token = yyTok3[i+1]
+			token = int(yyTok3[i+1])
 			goto out
 		}
 	}
 
 out:
 	if token == 0 {
-		token = yyTok2[1] /* unknown char */
+		token = int(yyTok2[1]) /* unknown char */
 	}
 	if yyDebug >= 3 {
 		__yyfmt__.Printf("lex %s(%d)\n", yyTokname(token), uint(char))
@@ -663,7 +750,7 @@ yystack:
 	yyS[yyp].yys = yystate
 
 yynewstate:
-	yyn = yyPact[yystate]
+	yyn = int(yyPact[yystate])
 	if yyn <= yyFlag {
 		goto yydefault /* simple state */
 	}
@@ -674,8 +761,8 @@ yynewstate:
 	if yyn < 0 || yyn >= yyLast {
 		goto yydefault
 	}
-	yyn = yyAct[yyn]
-	if yyChk[yyn] == yytoken { /* valid shift */
+	yyn = int(yyAct[yyn])
+	if int(yyChk[yyn]) == yytoken { /* valid shift */
 		yyrcvr.char = -1
 		yytoken = -1
 		yyVAL = yyrcvr.lval
@@ -688,7 +775,7 @@ yynewstate:
 
 yydefault:
 	/* default state action */
-	yyn = yyDef[yystate]
+	yyn = int(yyDef[yystate])
 	if yyn == -2 {
 		if yyrcvr.char < 0 {
 			yyrcvr.char, yytoken = yylex1(yylex, &yyrcvr.lval)
@@ -697,18 +784,18 @@ yydefault:
 		/* look through exception table */
 		xi := 0
 		for {
-			if yyExca[xi+0] == -1 && yyExca[xi+1] == yystate {
+			if yyExca[xi+0] == -1 && int(yyExca[xi+1]) == yystate {
 				break
 			}
 			xi += 2
 		}
 		for xi += 2; ; xi += 2 {
-			yyn = yyExca[xi+0]
+			yyn = int(yyExca[xi+0])
 			if yyn < 0 || yyn == yytoken {
 				break
 			}
 		}
-		yyn = yyExca[xi+1]
+		yyn = int(yyExca[xi+1])
 		if yyn < 0 {
 			goto ret0
 		}
@@ -730,10 +817,10 @@ yydefault:
 
 			/* find a state where "error" is a legal shift action */
 			for yyp >= 0 {
-				yyn = yyPact[yyS[yyp].yys] + yyErrCode
+				yyn = int(yyPact[yyS[yyp].yys]) + yyErrCode
 				if yyn >= 0 && yyn < yyLast {
-					yystate = yyAct[yyn] /* simulate a shift of "error" */
-					if yyChk[yystate] == yyErrCode {
+					yystate = int(yyAct[yyn]) /* simulate a shift of "error" */
+					if int(yyChk[yystate]) == yyErrCode {
 						goto yystack
 					}
 				}
@@ -769,7 +856,7 @@ yydefault:
 	yypt := yyp
 	_ = yypt // guard against "declared and not used"
 
-	yyp -= yyR2[yyn]
+	yyp -= int(yyR2[yyn])
 	// yyp is now the index of $0. Perform the default action. Iff the
 	// reduced production is ε, $1 is possibly out of range.
 	if yyp+1 >= len(yyS) {
@@ -780,16 +867,16 @@ yydefault:
 	yyVAL = yyS[yyp+1]
 
 	/* consult goto table to find next state */
-	yyn = yyR1[yyn]
-	yyg := yyPgo[yyn]
+	yyn = int(yyR1[yyn])
+	yyg := int(yyPgo[yyn])
 	yyj := yyg + yyS[yyp].yys + 1
 
 	if yyj >= yyLast {
-		yystate = yyAct[yyg]
+		yystate = int(yyAct[yyg])
 	} else {
-		yystate = yyAct[yyj]
-		if yyChk[yystate] != -yyn {
-			yystate = yyAct[yyg]
+		yystate = int(yyAct[yyj])
+		if int(yyChk[yystate]) != -yyn {
+			yystate = int(yyAct[yyg])
 		}
 	}
 	// dummy call; replaced with literal code
@@ -818,304 +905,399 @@ yydefault:
 	case 9:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
-			yyVAL.stmt = &BeginTransactionStmt{}
+			yyVAL.stmt = &ExplainStmt{query: yyDollar[2].stmt.(DataSource)}
 		}
 	case 10:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.stmt = &BeginTransactionStmt{}
 		}
 	case 11:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.stmt = &CommitStmt{}
+			yyVAL.stmt = &BeginTransactionStmt{}
 		}
 	case 12:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.stmt = &RollbackStmt{}
+			yyVAL.stmt = &CommitStmt{}
 		}
 	case 13:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.stmt = &RollbackStmt{}
+		}
+	case 14:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.stmt = &CreateDatabaseStmt{ifNotExists: yyDollar[3].boolean, DB: yyDollar[4].id}
 		}
-	case 14:
+	case 15:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.stmt = &UseDatabaseStmt{DB: yyDollar[2].id}
 		}
-	case 15:
+	case 16:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.stmt = &UseDatabaseStmt{DB: yyDollar[3].id}
 		}
-	case 16:
+	case 17:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.stmt = &UseSnapshotStmt{period: yyDollar[3].period}
 		}
-	case 17:
-		yyDollar = yyS[yypt-11 : yypt+1]
-		{
-			yyVAL.stmt = &CreateTableStmt{ifNotExists: yyDollar[3].boolean, table: yyDollar[4].id, colsSpec: yyDollar[6].colsSpec, pkColNames: yyDollar[10].ids}
-		}
 	case 18:
-		yyDollar = yyS[yypt-8 : yypt+1]
+		yyDollar = yyS[yypt-12 : yypt+1]
 		{
-			yyVAL.stmt = &CreateIndexStmt{ifNotExists: yyDollar[3].boolean, table: yyDollar[5].id, cols: yyDollar[7].ids}
+			yyVAL.stmt = &CreateTableStmt{ifNotExists: yyDollar[3].boolean, table: yyDollar[4].id, colsSpec: yyDollar[6].colsSpec, pkColNames: yyDollar[10].ids, constraints: yyDollar[11].constraints}
 		}
 	case 19:
 		yyDollar = yyS[yypt-9 : yypt+1]
 		{
-			yyVAL.stmt = &CreateIndexStmt{unique: true, ifNotExists: yyDollar[4].boolean, table: yyDollar[6].id, cols: yyDollar[8].ids}
+			yyVAL.stmt = &CreateIndexStmt{ifNotExists: yyDollar[3].boolean, table: yyDollar[5].id, cols: yyDollar[7].ids, where: yyDollar[9].exp, whereSQL: yylex.(*lexer).indexPredicate()}
 		}
 	case 20:
+		yyDollar = yyS[yypt-10 : yypt+1]
+		{
+			yyVAL.stmt = &CreateIndexStmt{unique: true, ifNotExists: yyDollar[4].boolean, table: yyDollar[6].id, cols: yyDollar[8].ids, where: yyDollar[10].exp, whereSQL: yylex.(*lexer).indexPredicate()}
+		}
+	case 21:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.stmt = &AddColumnStmt{table: yyDollar[3].id, colSpec: yyDollar[6].colSpec}
 		}
-	case 21:
+	case 22:
 		yyDollar = yyS[yypt-8 : yypt+1]
 		{
 			yyVAL.stmt = &RenameColumnStmt{table: yyDollar[3].id, oldName: yyDollar[6].id, newName: yyDollar[8].id}
 		}
-	case 22:
+	case 23:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.stmt = &DropColumnStmt{table: yyDollar[3].id, colName: yyDollar[6].id}
+		}
+	case 24:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.stmt = &AlterColumnStmt{table: yyDollar[3].id, colSpec: yyDollar[6].colSpec}
+		}
+	case 25:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.stmt = &CreateViewStmt{ifNotExists: yyDollar[3].boolean, view: yyDollar[4].id, query: yyDollar[6].stmt.(DataSource), sql: yylex.(*lexer).viewDefinition()}
+		}
+	case 26:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.stmt = &DropViewStmt{ifExists: yyDollar[3].boolean, view: yyDollar[4].id}
+		}
+	case 27:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 23:
+	case 28:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 24:
+	case 29:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.boolean = false
+		}
+	case 30:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.boolean = true
+		}
+	case 31:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.ids = []string{yyDollar[1].id}
 		}
-	case 25:
+	case 32:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.ids = yyDollar[2].ids
 		}
-	case 26:
+	case 33:
 		yyDollar = yyS[yypt-9 : yypt+1]
 		{
 			yyVAL.stmt = &UpsertIntoStmt{isInsert: true, tableRef: yyDollar[3].tableRef, cols: yyDollar[5].ids, rows: yyDollar[8].rows, onConflict: yyDollar[9].onConflict}
 		}
-	case 27:
+	case 34:
 		yyDollar = yyS[yypt-8 : yypt+1]
 		{
 			yyVAL.stmt = &UpsertIntoStmt{tableRef: yyDollar[3].tableRef, cols: yyDollar[5].ids, rows: yyDollar[8].rows}
 		}
-	case 28:
+	case 35:
 		yyDollar = yyS[yypt-7 : yypt+1]
 		{
 			yyVAL.stmt = &DeleteFromStmt{tableRef: yyDollar[3].tableRef, where: yyDollar[4].exp, indexOn: yyDollar[5].ids, limit: int(yyDollar[6].number), offset: int(yyDollar[7].number)}
 		}
-	case 29:
+	case 36:
 		yyDollar = yyS[yypt-8 : yypt+1]
 		{
 			yyVAL.stmt = &UpdateStmt{tableRef: yyDollar[2].tableRef, updates: yyDollar[4].updates, where: yyDollar[5].exp, indexOn: yyDollar[6].ids, limit: int(yyDollar[7].number), offset: int(yyDollar[8].number)}
 		}
-	case 30:
+	case 37:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.onConflict = nil
 		}
-	case 31:
-		yyDollar = yyS[yypt-4 : yypt+1]
+	case 38:
+		yyDollar = yyS[yypt-5 : yypt+1]
 		{
-			yyVAL.onConflict = &OnConflictDo{}
+			yyVAL.onConflict = &OnConflictDo{target: yyDollar[3].ids}
 		}
-	case 32:
+	case 39:
+		yyDollar = yyS[yypt-7 : yypt+1]
+		{
+			yyVAL.onConflict = &OnConflictDo{target: yyDollar[3].ids, updates: yyDollar[7].updates}
+		}
+	case 40:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.ids = nil
+		}
+	case 41:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.ids = yyDollar[2].ids
+		}
+	case 42:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.updates = []*colUpdate{yyDollar[1].update}
 		}
-	case 33:
+	case 43:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.updates = append(yyDollar[1].updates, yyDollar[3].update)
 		}
-	case 34:
+	case 44:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.update = &colUpdate{col: yyDollar[1].id, op: yyDollar[2].cmpOp, val: yyDollar[3].exp}
 		}
-	case 35:
+	case 45:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.ids = nil
 		}
-	case 36:
+	case 46:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.ids = yyDollar[1].ids
 		}
-	case 37:
+	case 47:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.rows = []*RowSpec{yyDollar[1].row}
 		}
-	case 38:
+	case 48:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.rows = append(yyDollar[1].rows, yyDollar[3].row)
 		}
-	case 39:
+	case 49:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.row = &RowSpec{Values: yyDollar[2].values}
 		}
-	case 40:
+	case 50:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.ids = []string{yyDollar[1].id}
 		}
-	case 41:
+	case 51:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.ids = append(yyDollar[1].ids, yyDollar[3].id)
 		}
-	case 42:
+	case 52:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.cols = []*ColSelector{yyDollar[1].col}
 		}
-	case 43:
+	case 53:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.cols = append(yyDollar[1].cols, yyDollar[3].col)
 		}
-	case 44:
+	case 54:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.values = nil
 		}
-	case 45:
+	case 55:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.values = yyDollar[1].values
 		}
-	case 46:
+	case 56:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.values = []ValueExp{yyDollar[1].exp}
 		}
-	case 47:
+	case 57:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.values = append(yyDollar[1].values, yyDollar[3].exp)
 		}
-	case 48:
+	case 58:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Number{val: int64(yyDollar[1].number)}
 		}
-	case 49:
+	case 59:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.value = yyDollar[1].value
+		}
+	case 60:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Varchar{val: yyDollar[1].str}
 		}
-	case 50:
+	case 61:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Bool{val: yyDollar[1].boolean}
 		}
-	case 51:
+	case 62:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Blob{val: yyDollar[1].blob}
 		}
-	case 52:
+	case 63:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.value = &Cast{val: yyDollar[3].exp, t: yyDollar[5].sqlType}
 		}
-	case 53:
+	case 64:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = yyDollar[1].value
 		}
-	case 54:
+	case 65:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Param{id: yyDollar[1].id}
 		}
-	case 55:
+	case 66:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Param{id: fmt.Sprintf("param%d", yyDollar[1].pparam), pos: yyDollar[1].pparam}
 		}
-	case 56:
+	case 67:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &NullValue{t: AnyType}
 		}
-	case 57:
+	case 68:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.value = &FnCall{fn: yyDollar[1].id, params: yyDollar[3].values}
 		}
-	case 58:
+	case 69:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.constraints = nil
+		}
+	case 70:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.constraints = append(yyDollar[1].constraints, yyDollar[3].constraint)
+		}
+	case 71:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.constraint = &CheckSpec{exp: yyDollar[3].exp, sql: yylex.(*lexer).checkDefinition()}
+		}
+	case 72:
+		yyDollar = yyS[yypt-7 : yypt+1]
+		{
+			yyVAL.constraint = &ForeignKeySpec{cols: yyDollar[4].ids, refTable: yyDollar[7].id}
+		}
+	case 73:
+		yyDollar = yyS[yypt-10 : yypt+1]
+		{
+			yyVAL.constraint = &ForeignKeySpec{cols: yyDollar[4].ids, refTable: yyDollar[7].id, refCols: yyDollar[9].ids}
+		}
+	case 74:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.colsSpec = []*ColSpec{yyDollar[1].colSpec}
 		}
-	case 59:
+	case 75:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.colsSpec = append(yyDollar[1].colsSpec, yyDollar[3].colSpec)
 		}
-	case 60:
+	case 76:
 		yyDollar = yyS[yypt-5 : yypt+1]
 		{
 			yyVAL.colSpec = &ColSpec{colName: yyDollar[1].id, colType: yyDollar[2].sqlType, maxLen: int(yyDollar[3].number), notNull: yyDollar[4].boolean, autoIncrement: yyDollar[5].boolean}
 		}
-	case 61:
+	case 77:
+		yyDollar = yyS[yypt-8 : yypt+1]
+		{
+			yyVAL.colSpec = &ColSpec{colName: yyDollar[1].id, colType: yyDollar[2].sqlType, precision: int(yyDollar[4].number), scale: int(yyDollar[5].number), notNull: yyDollar[7].boolean, autoIncrement: yyDollar[8].boolean}
+		}
+	case 78:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.number = 0
 		}
-	case 62:
+	case 79:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.number = yyDollar[2].number
+		}
+	case 80:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.number = 0
+		}
+	case 81:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.number = yyDollar[2].number
 		}
-	case 63:
+	case 82:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 64:
+	case 83:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 65:
+	case 84:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 66:
+	case 85:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 67:
+	case 86:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 68:
+	case 87:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.stmt = yyDollar[1].stmt
 		}
-	case 69:
+	case 88:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.stmt = &UnionStmt{
@@ -1124,7 +1306,32 @@ yydefault:
 				right:    yyDollar[4].stmt.(DataSource),
 			}
 		}
-	case 70:
+	case 89:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.stmt = &SetOpStmt{
+				op:       ExceptOp,
+				distinct: yyDollar[3].distinct,
+				left:     yyDollar[1].stmt.(DataSource),
+				right:    yyDollar[4].stmt.(DataSource),
+			}
+		}
+	case 90:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.stmt = yyDollar[1].stmt
+		}
+	case 91:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.stmt = &SetOpStmt{
+				op:       IntersectOp,
+				distinct: yyDollar[3].distinct,
+				left:     yyDollar[1].stmt.(DataSource),
+				right:    yyDollar[4].stmt.(DataSource),
+			}
+		}
+	case 92:
 		yyDollar = yyS[yypt-13 : yypt+1]
 		{
 			yyVAL.stmt = &SelectStmt{
@@ -1141,391 +1348,478 @@ yydefault:
 				offset:    int(yyDollar[13].number),
 			}
 		}
-	case 71:
+	case 93:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.distinct = true
 		}
-	case 72:
+	case 94:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.distinct = false
 		}
-	case 73:
+	case 95:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.distinct = false
 		}
-	case 74:
+	case 96:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.distinct = true
 		}
-	case 75:
+	case 97:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.sels = nil
 		}
-	case 76:
+	case 98:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.sels = yyDollar[1].sels
 		}
-	case 77:
+	case 99:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyDollar[1].sel.setAlias(yyDollar[2].id)
 			yyVAL.sels = []Selector{yyDollar[1].sel}
 		}
-	case 78:
+	case 100:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyDollar[3].sel.setAlias(yyDollar[4].id)
 			yyVAL.sels = append(yyDollar[1].sels, yyDollar[3].sel)
 		}
-	case 79:
+	case 101:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.sel = yyDollar[1].col
 		}
-	case 80:
+	case 102:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.sel = &AggColSelector{aggFn: yyDollar[1].aggFn, col: "*"}
 		}
-	case 81:
+	case 103:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.sel = &AggColSelector{aggFn: yyDollar[1].aggFn, db: yyDollar[3].col.db, table: yyDollar[3].col.table, col: yyDollar[3].col.col}
 		}
-	case 82:
+	case 104:
+		yyDollar = yyS[yypt-9 : yypt+1]
+		{
+			yyVAL.sel = &WindowFnSelector{fn: yyDollar[1].id, params: yyDollar[3].values, partitionBy: yyDollar[7].cols, orderBy: yyDollar[8].ordcols}
+		}
+	case 105:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.col = &ColSelector{col: yyDollar[1].id}
 		}
-	case 83:
+	case 106:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.col = &ColSelector{table: yyDollar[1].id, col: yyDollar[3].id}
 		}
-	case 84:
+	case 107:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyDollar[1].tableRef.as = yyDollar[2].id
+			yyVAL.ds = yyDollar[1].tableRef
+		}
+	case 108:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyDollar[1].tableRef.period = yyDollar[2].period
 			yyDollar[1].tableRef.as = yyDollar[3].id
 			yyVAL.ds = yyDollar[1].tableRef
 		}
-	case 85:
+	case 109:
+		yyDollar = yyS[yypt-5 : yypt+1]
+		{
+			asOf := yyDollar[4].periodInstant
+			yyDollar[1].tableRef.period = period{asOf: &asOf}
+			yyDollar[1].tableRef.as = yyDollar[5].id
+			yyVAL.ds = yyDollar[1].tableRef
+		}
+	case 110:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyDollar[2].stmt.(*SelectStmt).as = yyDollar[4].id
 			yyVAL.ds = yyDollar[2].stmt.(DataSource)
 		}
-	case 86:
+	case 111:
+		yyDollar = yyS[yypt-7 : yypt+1]
+		{
+			asOf := yyDollar[6].periodInstant
+			applyPeriod(yyDollar[2].stmt.(DataSource), period{asOf: &asOf})
+			yyDollar[2].stmt.(*SelectStmt).as = yyDollar[7].id
+			yyVAL.ds = yyDollar[2].stmt.(DataSource)
+		}
+	case 112:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.ds = &FnDataSourceStmt{fnCall: yyDollar[1].value.(*FnCall), as: yyDollar[2].id}
 		}
-	case 87:
+	case 113:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.tableRef = &tableRef{table: yyDollar[1].id}
 		}
-	case 88:
+	case 114:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.period = period{}
+		}
+	case 115:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.period = yyDollar[1].period
+		}
+	case 116:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.period = period{start: yyDollar[1].openPeriod, end: yyDollar[2].openPeriod}
 		}
-	case 89:
-		yyDollar = yyS[yypt-0 : yypt+1]
+	case 117:
+		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.openPeriod = nil
+			yyVAL.period = period{end: yyDollar[1].openPeriod}
 		}
-	case 90:
+	case 118:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.openPeriod = &openPeriod{inclusive: true, instant: yyDollar[2].periodInstant}
 		}
-	case 91:
+	case 119:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.openPeriod = &openPeriod{instant: yyDollar[2].periodInstant}
 		}
-	case 92:
+	case 120:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.openPeriod = nil
 		}
-	case 93:
+	case 121:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.openPeriod = yyDollar[1].openPeriod
+		}
+	case 122:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.openPeriod = &openPeriod{inclusive: true, instant: yyDollar[2].periodInstant}
 		}
-	case 94:
+	case 123:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.openPeriod = &openPeriod{instant: yyDollar[2].periodInstant}
 		}
-	case 95:
+	case 124:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.periodInstant = periodInstant{instantType: txInstant, exp: yyDollar[2].exp}
 		}
-	case 96:
+	case 125:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			if yyDollar[1].sqlType != TimestampType {
+				yylex.Error(fmt.Sprintf("syntax error: unexpected %s, expecting TX or TIMESTAMP", yyDollar[1].sqlType))
+				return 1
+			}
+
+			yyVAL.periodInstant = periodInstant{instantType: timeInstant, exp: yyDollar[2].exp}
+		}
+	case 126:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.periodInstant = periodInstant{instantType: txInstant, exp: yyDollar[2].exp}
+		}
+	case 127:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.periodInstant = periodInstant{instantType: timeInstant, exp: yyDollar[1].exp}
 		}
-	case 97:
+	case 128:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.joins = nil
 		}
-	case 98:
+	case 129:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.joins = yyDollar[1].joins
 		}
-	case 99:
+	case 130:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.joins = []*JoinSpec{yyDollar[1].join}
 		}
-	case 100:
+	case 131:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.joins = append([]*JoinSpec{yyDollar[1].join}, yyDollar[2].joins...)
 		}
-	case 101:
+	case 132:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.join = &JoinSpec{joinType: yyDollar[1].joinType, ds: yyDollar[3].ds, indexOn: yyDollar[4].ids, cond: yyDollar[6].exp}
 		}
-	case 102:
+	case 133:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.joinType = InnerJoin
 		}
-	case 103:
+	case 134:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.joinType = yyDollar[1].joinType
 		}
-	case 104:
+	case 135:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.exp = nil
 		}
-	case 105:
+	case 136:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[2].exp
 		}
-	case 106:
+	case 137:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.cols = nil
 		}
-	case 107:
+	case 138:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.cols = yyDollar[3].cols
 		}
-	case 108:
+	case 139:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.cols = nil
+		}
+	case 140:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.cols = yyDollar[3].cols
+		}
+	case 141:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.exp = nil
 		}
-	case 109:
+	case 142:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[2].exp
 		}
-	case 110:
+	case 143:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.number = 0
 		}
-	case 111:
+	case 144:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.number = yyDollar[2].number
 		}
-	case 112:
+	case 145:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.number = 0
 		}
-	case 113:
+	case 146:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.number = yyDollar[2].number
 		}
-	case 114:
+	case 147:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.ordcols = nil
 		}
-	case 115:
+	case 148:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.ordcols = yyDollar[3].ordcols
 		}
-	case 116:
+	case 149:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.ids = nil
 		}
-	case 117:
+	case 150:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.ids = yyDollar[4].ids
 		}
-	case 118:
+	case 151:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.ordcols = []*OrdCol{{sel: yyDollar[1].col, descOrder: yyDollar[2].opt_ord}}
 		}
-	case 119:
+	case 152:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.ordcols = append(yyDollar[1].ordcols, &OrdCol{sel: yyDollar[3].col, descOrder: yyDollar[4].opt_ord})
 		}
-	case 120:
+	case 153:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.opt_ord = false
 		}
-	case 121:
+	case 154:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.opt_ord = false
 		}
-	case 122:
+	case 155:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.opt_ord = true
 		}
-	case 123:
+	case 156:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.id = ""
 		}
-	case 124:
+	case 157:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.id = yyDollar[1].id
 		}
-	case 125:
+	case 158:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.id = yyDollar[2].id
 		}
-	case 126:
+	case 159:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].exp
 		}
-	case 127:
+	case 160:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].binExp
 		}
-	case 128:
+	case 161:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = &NotBoolExp{exp: yyDollar[2].exp}
 		}
-	case 129:
+	case 162:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = &NumExp{left: &Number{val: 0}, op: SUBSOP, right: yyDollar[2].exp}
 		}
-	case 130:
+	case 163:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.exp = &LikeBoolExp{val: yyDollar[1].exp, notLike: yyDollar[2].boolean, pattern: yyDollar[4].exp}
 		}
-	case 131:
+	case 164:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
-			yyVAL.exp = &ExistsBoolExp{q: (yyDollar[3].stmt).(*SelectStmt)}
+			yyVAL.exp = &ExistsBoolExp{q: yyDollar[3].stmt.(DataSource)}
 		}
-	case 132:
+	case 165:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
-			yyVAL.exp = &InSubQueryExp{val: yyDollar[1].exp, notIn: yyDollar[2].boolean, q: yyDollar[5].stmt.(*SelectStmt)}
+			yyVAL.exp = &InSubQueryExp{val: yyDollar[1].exp, notIn: yyDollar[2].boolean, q: yyDollar[5].stmt.(DataSource)}
 		}
-	case 133:
+	case 166:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.exp = &InListExp{val: yyDollar[1].exp, notIn: yyDollar[2].boolean, values: yyDollar[5].values}
 		}
-	case 134:
+	case 167:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].sel
 		}
-	case 135:
+	case 168:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].value
 		}
-	case 136:
+	case 169:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[2].exp
 		}
-	case 137:
+	case 170:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.exp = &ScalarSubQueryExp{q: yyDollar[2].stmt.(DataSource)}
+		}
+	case 171:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.exp = &JSONExtractExp{val: yyDollar[1].exp, path: yyDollar[3].value, asText: yyDollar[2].boolean}
+		}
+	case 172:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 138:
+	case 173:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 139:
+	case 174:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: ADDOP, right: yyDollar[3].exp}
 		}
-	case 140:
+	case 175:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: SUBSOP, right: yyDollar[3].exp}
 		}
-	case 141:
+	case 176:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: DIVOP, right: yyDollar[3].exp}
 		}
-	case 142:
+	case 177:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: MULTOP, right: yyDollar[3].exp}
 		}
-	case 143:
+	case 178:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &BinBoolExp{left: yyDollar[1].exp, op: yyDollar[2].logicOp, right: yyDollar[3].exp}
 		}
-	case 144:
+	case 179:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &CmpBoolExp{left: yyDollar[1].exp, op: yyDollar[2].cmpOp, right: yyDollar[3].exp}
 		}
-	case 145:
+	case 180:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &CmpBoolExp{left: yyDollar[1].exp, op: EQ, right: &NullValue{t: AnyType}}
 		}
-	case 146:
+	case 181:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.binExp = &CmpBoolExp{left: yyDollar[1].exp, op: NE, right: &NullValue{t: AnyType}}
 		}
+	case 183:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.id = "view"
+		}
+	case 184:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.id = "drop"
+		}
 	}
 	goto yystack /* stack new state and value */
 }
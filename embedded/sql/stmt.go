@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
@@ -30,13 +31,15 @@ import (
 )
 
 const (
-	catalogDatabasePrefix = "CTL.DATABASE." // (key=CTL.DATABASE.{dbID}, value={dbNAME})
-	catalogTablePrefix    = "CTL.TABLE."    // (key=CTL.TABLE.{dbID}{tableID}, value={tableNAME})
-	catalogColumnPrefix   = "CTL.COLUMN."   // (key=CTL.COLUMN.{dbID}{tableID}{colID}{colTYPE}, value={(auto_incremental | nullable){maxLen}{colNAME}})
-	catalogIndexPrefix    = "CTL.INDEX."    // (key=CTL.INDEX.{dbID}{tableID}{indexID}, value={unique {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)})
-	PIndexPrefix          = "R."            // (key=R.{dbID}{tableID}{0}({null}({pkVal}{padding}{pkValLen})?)+, value={count (colID valLen val)+})
-	SIndexPrefix          = "E."            // (key=E.{dbID}{tableID}{indexID}({null}({val}{padding}{valLen})?)+({pkVal}{padding}{pkValLen})+, value={})
-	UIndexPrefix          = "N."            // (key=N.{dbID}{tableID}{indexID}({null}({val}{padding}{valLen})?)+, value={({pkVal}{padding}{pkValLen})+})
+	catalogDatabasePrefix   = "CTL.DATABASE."   // (key=CTL.DATABASE.{dbID}, value={dbNAME})
+	catalogTablePrefix      = "CTL.TABLE."      // (key=CTL.TABLE.{dbID}{tableID}, value={tableNAME})
+	catalogColumnPrefix     = "CTL.COLUMN."     // (key=CTL.COLUMN.{dbID}{tableID}{colID}{colTYPE}, value={(auto_incremental | nullable | dropped){maxLen}{colNAME}})
+	catalogIndexPrefix      = "CTL.INDEX."      // (key=CTL.INDEX.{dbID}{tableID}{indexID}, value={flags ({predicateLen}{predicate})? {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)})
+	catalogViewPrefix       = "CTL.VIEW."       // (key=CTL.VIEW.{dbID}{viewID}, value={nameLEN}{viewNAME}{viewSQL})
+	catalogConstraintPrefix = "CTL.CONSTRAINT." // (key=CTL.CONSTRAINT.{dbID}{tableID}{constraintID}, value={CHECK}{checkSQL} | {FOREIGN_KEY}{refTableID}{colID1}...{colIDN})
+	PIndexPrefix            = "R."              // (key=R.{dbID}{tableID}{0}({null}({pkVal}{padding}{pkValLen})?)+, value={count (colID valLen val)+})
+	SIndexPrefix            = "E."              // (key=E.{dbID}{tableID}{indexID}({null}({val}{padding}{valLen})?)+({pkVal}{padding}{pkValLen})+, value={})
+	UIndexPrefix            = "N."              // (key=N.{dbID}{tableID}{indexID}({null}({val}{padding}{valLen})?)+, value={({pkVal}{padding}{pkValLen})+})
 
 	// Old prefixes that must not be reused:
 	//  `CATALOG.DATABASE.`
@@ -56,6 +59,17 @@ const PKIndexID = uint32(0)
 const (
 	nullableFlag      byte = 1 << iota
 	autoIncrementFlag byte = 1 << iota
+	droppedFlag       byte = 1 << iota
+)
+
+const (
+	uniqueIndexFlag  byte = 1 << iota
+	partialIndexFlag byte = 1 << iota
+)
+
+const (
+	checkConstraint      byte = 1
+	foreignKeyConstraint byte = 2
 )
 
 type SQLValueType = string
@@ -66,6 +80,8 @@ const (
 	VarcharType   SQLValueType = "VARCHAR"
 	BLOBType      SQLValueType = "BLOB"
 	TimestampType SQLValueType = "TIMESTAMP"
+	JSONType      SQLValueType = "JSON"
+	DecimalType   SQLValueType = "DECIMAL"
 	AnyType       SQLValueType = "ANY"
 )
 
@@ -122,6 +138,13 @@ const (
 	IndexesFnCall   string = "INDEXES"
 )
 
+const (
+	RowNumberFnCall string = "ROW_NUMBER"
+	RankFnCall      string = "RANK"
+	LagFnCall       string = "LAG"
+	LeadFnCall      string = "LEAD"
+)
+
 type SQLStmt interface {
 	execAt(ctx context.Context, tx *SQLTx, params map[string]interface{}) (*SQLTx, error)
 	inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error
@@ -272,7 +295,7 @@ func (stmt *UseSnapshotStmt) execAt(ctx context.Context, tx *SQLTx, params map[s
 }
 
 func persistColumn(col *Column, tx *SQLTx) error {
-	//{auto_incremental | nullable}{maxLen}{colNAME})
+	//{auto_incremental | nullable | dropped}{maxLen}{colNAME})
 	v := make([]byte, 1+4+len(col.colName))
 
 	if col.autoIncrement {
@@ -283,7 +306,16 @@ func persistColumn(col *Column, tx *SQLTx) error {
 		v[0] = v[0] | nullableFlag
 	}
 
-	binary.BigEndian.PutUint32(v[1:], uint32(col.MaxLen()))
+	if col.dropped {
+		v[0] = v[0] | droppedFlag
+	}
+
+	maxLen := col.MaxLen()
+	if col.colType == DecimalType {
+		maxLen = encodeDecimalSpec(col.precision, col.scale)
+	}
+
+	binary.BigEndian.PutUint32(v[1:], uint32(maxLen))
 
 	copy(v[5:], []byte(col.Name()))
 
@@ -304,6 +336,21 @@ type CreateTableStmt struct {
 	ifNotExists bool
 	colsSpec    []*ColSpec
 	pkColNames  []string
+	constraints []constraintSpec
+}
+
+// constraintSpec is either a *CheckSpec or a *ForeignKeySpec
+type constraintSpec interface{}
+
+type CheckSpec struct {
+	exp ValueExp
+	sql string
+}
+
+type ForeignKeySpec struct {
+	cols     []string
+	refTable string
+	refCols  []string // primary key columns of the referenced table when not specified
 }
 
 func (stmt *CreateTableStmt) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
@@ -343,6 +390,33 @@ func (stmt *CreateTableStmt) execAt(ctx context.Context, tx *SQLTx, params map[s
 		}
 	}
 
+	for _, constraint := range stmt.constraints {
+		switch c := constraint.(type) {
+		case *CheckSpec:
+			check, err := table.newCheck(c.sql, c.exp)
+			if err != nil {
+				return nil, err
+			}
+
+			err = persistCheck(check, tx)
+			if err != nil {
+				return nil, err
+			}
+		case *ForeignKeySpec:
+			fk, err := newForeignKeyFromSpec(table, c)
+			if err != nil {
+				return nil, err
+			}
+
+			err = persistForeignKey(fk, tx)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, ErrIllegalArguments
+		}
+	}
+
 	mappedKey := mapKey(tx.sqlPrefix(), catalogTablePrefix, EncodeID(tx.currentDB.id), EncodeID(table.id))
 
 	err = tx.set(mappedKey, nil, []byte(table.name))
@@ -353,12 +427,72 @@ func (stmt *CreateTableStmt) execAt(ctx context.Context, tx *SQLTx, params map[s
 	return tx, nil
 }
 
+func newForeignKeyFromSpec(table *Table, spec *ForeignKeySpec) (*ForeignKey, error) {
+	refTable, err := table.db.GetTableByName(spec.refTable)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(spec.refCols) > 0 {
+		if len(spec.refCols) != len(refTable.primaryIndex.cols) {
+			return nil, ErrInvalidForeignKey
+		}
+
+		for i, pkCol := range refTable.primaryIndex.cols {
+			if spec.refCols[i] != pkCol.colName {
+				return nil, ErrInvalidForeignKey
+			}
+		}
+	}
+
+	colIDs := make([]uint32, len(spec.cols))
+
+	for i, colName := range spec.cols {
+		col, err := table.GetColumnByName(colName)
+		if err != nil {
+			return nil, err
+		}
+
+		colIDs[i] = col.id
+	}
+
+	return table.newForeignKey(colIDs, refTable)
+}
+
+func persistCheck(check *Check, tx *SQLTx) error {
+	v := make([]byte, 1+len(check.sql))
+	v[0] = checkConstraint
+	copy(v[1:], []byte(check.sql))
+
+	mappedKey := mapKey(tx.sqlPrefix(), catalogConstraintPrefix, EncodeID(check.table.db.id), EncodeID(check.table.id), EncodeID(check.id))
+
+	return tx.set(mappedKey, nil, v)
+}
+
+func persistForeignKey(fk *ForeignKey, tx *SQLTx) error {
+	v := make([]byte, 1+EncIDLen+EncIDLen*len(fk.cols))
+	v[0] = foreignKeyConstraint
+
+	binary.BigEndian.PutUint32(v[1:], fk.refTable.id)
+
+	for i, col := range fk.cols {
+		binary.BigEndian.PutUint32(v[1+EncIDLen+i*EncIDLen:], col.id)
+	}
+
+	mappedKey := mapKey(tx.sqlPrefix(), catalogConstraintPrefix, EncodeID(fk.table.db.id), EncodeID(fk.table.id), EncodeID(fk.id))
+
+	return tx.set(mappedKey, nil, v)
+}
+
 type ColSpec struct {
 	colName       string
 	colType       SQLValueType
 	maxLen        int
+	precision     int
+	scale         int
 	autoIncrement bool
 	notNull       bool
+	dropped       bool // only set when loading the catalog
 }
 
 type CreateIndexStmt struct {
@@ -366,6 +500,8 @@ type CreateIndexStmt struct {
 	ifNotExists bool
 	table       string
 	cols        []string
+	where       ValueExp // only rows satisfying the condition are indexed
+	whereSQL    string
 }
 
 func (stmt *CreateIndexStmt) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
@@ -405,7 +541,7 @@ func (stmt *CreateIndexStmt) execAt(ctx context.Context, tx *SQLTx, params map[s
 		colIDs[i] = col.id
 	}
 
-	index, err := table.newIndex(stmt.unique, colIDs)
+	index, err := table.newIndex(stmt.unique, colIDs, stmt.whereSQL, stmt.where)
 	if err == ErrIndexAlreadyExists && stmt.ifNotExists {
 		return tx, nil
 	}
@@ -425,18 +561,30 @@ func (stmt *CreateIndexStmt) execAt(ctx context.Context, tx *SQLTx, params map[s
 		}
 	}
 
-	// v={unique {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)}
+	// v={flags ({predicateLen}{predicate})? {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)}
 	// TODO: currently only ASC order is supported
 	colSpecLen := EncIDLen + 1
 
-	encodedValues := make([]byte, 1+len(index.cols)*colSpecLen)
+	var predicateSpecLen int
+	if index.IsPartial() {
+		predicateSpecLen = 4 + len(index.whereSQL)
+	}
+
+	encodedValues := make([]byte, 1+predicateSpecLen+len(index.cols)*colSpecLen)
 
 	if index.IsUnique() {
-		encodedValues[0] = 1
+		encodedValues[0] |= uniqueIndexFlag
+	}
+
+	if index.IsPartial() {
+		encodedValues[0] |= partialIndexFlag
+
+		binary.BigEndian.PutUint32(encodedValues[1:], uint32(len(index.whereSQL)))
+		copy(encodedValues[5:], index.whereSQL)
 	}
 
 	for i, col := range index.cols {
-		copy(encodedValues[1+i*colSpecLen:], EncodeID(col.id))
+		copy(encodedValues[1+predicateSpecLen+i*colSpecLen:], EncodeID(col.id))
 	}
 
 	mappedKey := mapKey(tx.sqlPrefix(), catalogIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(index.id))
@@ -501,6 +649,11 @@ func (stmt *RenameColumnStmt) execAt(ctx context.Context, tx *SQLTx, params map[
 		return nil, err
 	}
 
+	err = tx.checkColNotUsedByViews(ctx, table, stmt.oldName)
+	if err != nil {
+		return nil, err
+	}
+
 	col, err := table.renameColumn(stmt.oldName, stmt.newName)
 	if err != nil {
 		return nil, err
@@ -514,6 +667,309 @@ func (stmt *RenameColumnStmt) execAt(ctx context.Context, tx *SQLTx, params map[
 	return tx, nil
 }
 
+type DropColumnStmt struct {
+	table   string
+	colName string
+}
+
+func (stmt *DropColumnStmt) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *DropColumnStmt) execAt(ctx context.Context, tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	if tx.currentDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	table, err := tx.currentDB.GetTableByName(stmt.table)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.checkColNotUsedByViews(ctx, table, stmt.colName)
+	if err != nil {
+		return nil, err
+	}
+
+	col, err := table.dropColumn(stmt.colName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = persistColumn(col, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+type AlterColumnStmt struct {
+	table   string
+	colSpec *ColSpec
+}
+
+func (stmt *AlterColumnStmt) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *AlterColumnStmt) execAt(ctx context.Context, tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	if tx.currentDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	table, err := tx.currentDB.GetTableByName(stmt.table)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.checkColNotUsedByViews(ctx, table, stmt.colSpec.colName)
+	if err != nil {
+		return nil, err
+	}
+
+	col, err := table.alterColumn(stmt.colSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	err = persistColumn(col, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// checkColNotUsedByViews validates the views of the database can be resolved without the column,
+// views selecting every column of the table are not considered to use it
+func (tx *SQLTx) checkColNotUsedByViews(ctx context.Context, table *Table, colName string) error {
+	col, err := table.GetColumnByName(colName)
+	if err != nil {
+		return err
+	}
+
+	if len(table.db.views) == 0 {
+		return nil
+	}
+
+	cols, colsByID, colsByName := table.cols, table.colsByID, table.colsByName
+
+	defer func() {
+		table.cols, table.colsByID, table.colsByName = cols, colsByID, colsByName
+	}()
+
+	// the column is hidden while views are resolved
+	table.cols = make([]*Column, 0, len(cols)-1)
+	table.colsByID = make(map[uint32]*Column, len(cols)-1)
+	table.colsByName = make(map[string]*Column, len(cols)-1)
+
+	for _, c := range cols {
+		if c.id != col.id {
+			table.cols = append(table.cols, c)
+			table.colsByID[c.id] = c
+			table.colsByName[c.colName] = c
+		}
+	}
+
+	for _, view := range table.db.views {
+		if !table.db.dependsOn(view.query, table.name) {
+			continue
+		}
+
+		err := view.query.inferParameters(ctx, tx, make(map[string]SQLValueType))
+		if err != nil {
+			return fmt.Errorf("%w: %s is used by %s", ErrColumnIsUsedByView, colName, view.name)
+		}
+	}
+
+	return nil
+}
+
+type CreateViewStmt struct {
+	view        string
+	ifNotExists bool
+	query       DataSource
+	sql         string
+}
+
+func (stmt *CreateViewStmt) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *CreateViewStmt) execAt(ctx context.Context, tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	if tx.currentDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	if stmt.ifNotExists && tx.currentDB.ExistView(stmt.view) {
+		return tx, nil
+	}
+
+	if stmt.query == nil || stmt.sql == "" {
+		return nil, ErrIllegalArguments
+	}
+
+	if tx.currentDB.ExistView(stmt.view) {
+		return nil, fmt.Errorf("%w (%s)", ErrViewAlreadyExists, stmt.view)
+	}
+
+	if tx.currentDB.dependsOn(stmt.query, stmt.view) {
+		return nil, fmt.Errorf("%w (%s)", ErrRecursiveViewDefinition, stmt.view)
+	}
+
+	queryParams := make(map[string]SQLValueType)
+
+	err := stmt.query.inferParameters(ctx, tx, queryParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(queryParams) > 0 {
+		return nil, fmt.Errorf("%w (%s)", ErrParameterizedView, stmt.view)
+	}
+
+	// column names and types must be resolvable through the view,
+	// it's only added to the catalog once validated
+	cols, err := (&View{db: tx.currentDB, name: stmt.view, sql: stmt.sql, query: stmt.query}).columns(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	colNames := make(map[string]struct{}, len(cols))
+
+	for _, col := range cols {
+		_, duplicated := colNames[col.Column]
+		if duplicated {
+			return nil, fmt.Errorf("%w (%s)", ErrDuplicatedColumn, col.Column)
+		}
+
+		colNames[col.Column] = struct{}{}
+	}
+
+	view, err := tx.currentDB.newView(tx.currentDB.maxViewID+1, stmt.view, stmt.sql, stmt.query)
+	if err != nil {
+		return nil, err
+	}
+
+	mappedKey := mapKey(tx.sqlPrefix(), catalogViewPrefix, EncodeID(tx.currentDB.id), EncodeID(view.id))
+
+	err = tx.set(mappedKey, nil, encodeView(view.name, view.sql))
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+type DropViewStmt struct {
+	view     string
+	ifExists bool
+}
+
+func (stmt *DropViewStmt) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *DropViewStmt) execAt(ctx context.Context, tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	if tx.currentDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	if stmt.ifExists && !tx.currentDB.ExistView(stmt.view) {
+		return tx, nil
+	}
+
+	view, err := tx.currentDB.dropView(stmt.view)
+	if err != nil {
+		return nil, err
+	}
+
+	md := store.NewKVMetadata()
+
+	md.AsDeleted(true)
+
+	mappedKey := mapKey(tx.sqlPrefix(), catalogViewPrefix, EncodeID(tx.currentDB.id), EncodeID(view.id))
+
+	err = tx.set(mappedKey, md, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// resolve expands the view definition into the plan of the query referencing it
+func (v *View) resolve(ctx context.Context, tx *SQLTx, params map[string]interface{}, alias string) (RowReader, error) {
+	_, err := v.query.execAt(ctx, tx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &SelectStmt{
+		ds: v.query,
+		as: alias,
+	}
+
+	return stmt.Resolve(ctx, tx, params, nil)
+}
+
+// resolveAt expands the view definition as it would have been evaluated during the given period,
+// the period applies to every table referenced by the view which does not specify its own one
+func (v *View) resolveAt(ctx context.Context, tx *SQLTx, params map[string]interface{}, alias string, p period) (RowReader, error) {
+	// the view definition is parsed again so the shared query is not altered
+	stmts, err := ParseString(v.sql)
+	if err != nil {
+		return nil, err
+	}
+
+	query, ok := stmts[0].(DataSource)
+	if !ok {
+		return nil, ErrCorruptedData
+	}
+
+	applyPeriod(query, p)
+
+	tv := &View{
+		db:    v.db,
+		id:    v.id,
+		name:  v.name,
+		sql:   v.sql,
+		query: query,
+	}
+
+	return tv.resolve(ctx, tx, params, alias)
+}
+
+// applyPeriod sets the period to the table references of the data source lacking one
+func applyPeriod(ds DataSource, p period) {
+	switch ds := ds.(type) {
+	case *tableRef:
+		if !ds.period.isSet() {
+			ds.period = p
+		}
+	case *SelectStmt:
+		applyPeriod(ds.ds, p)
+
+		for _, join := range ds.joins {
+			applyPeriod(join.ds, p)
+		}
+	case *SetOpStmt:
+		applyPeriod(ds.left, p)
+		applyPeriod(ds.right, p)
+	}
+}
+
+func (v *View) columns(ctx context.Context, tx *SQLTx) ([]ColDescriptor, error) {
+	rowReader, err := v.resolve(ctx, tx, nil, v.name)
+	if err != nil {
+		return nil, err
+	}
+	defer rowReader.Close()
+
+	return rowReader.Columns(ctx)
+}
+
 type UpsertIntoStmt struct {
 	isInsert   bool
 	tableRef   *tableRef
@@ -526,7 +982,169 @@ type RowSpec struct {
 	Values []ValueExp
 }
 
-type OnConflictDo struct {
+// OnConflictDo specifies how rows conflicting with existent ones are handled, the conflict target
+// are the columns of either the primary key (when not specified) or a unique index
+type OnConflictDo struct {
+	target  []string
+	updates []*colUpdate // when not specified conflicting rows are left as they are
+}
+
+// excludedRowAlias is used to refer to the values of the row which was not inserted due to a conflict
+const excludedRowAlias = "excluded"
+
+func (oc *OnConflictDo) conflictIndex(table *Table) (*Index, error) {
+	if len(oc.target) == 0 {
+		return table.primaryIndex, nil
+	}
+
+	colIDs := make(map[uint32]struct{}, len(oc.target))
+
+	for _, colName := range oc.target {
+		col, err := table.GetColumnByName(colName)
+		if err != nil {
+			return nil, err
+		}
+
+		colIDs[col.id] = struct{}{}
+	}
+
+	for _, index := range table.indexes {
+		if !index.IsUnique() || len(index.cols) != len(colIDs) {
+			continue
+		}
+
+		matches := true
+
+		for _, col := range index.cols {
+			_, ok := colIDs[col.id]
+			matches = matches && ok
+		}
+
+		if matches {
+			return index, nil
+		}
+	}
+
+	return nil, ErrInvalidConflictTarget
+}
+
+// excludedColDescriptors returns the columns of the table together with the ones of the excluded row
+func (oc *OnConflictDo) excludedColDescriptors(table *Table) map[string]ColDescriptor {
+	cols := table.colDescriptors(0)
+
+	for _, col := range table.cols {
+		des := ColDescriptor{
+			Database: table.db.name,
+			Table:    excludedRowAlias,
+			Column:   col.colName,
+			Type:     col.colType,
+		}
+
+		cols[des.Selector()] = des
+	}
+
+	return cols
+}
+
+// updatedValues returns the values of the conflicting row once updated, update expressions
+// may refer to the values of the conflicting row and to the ones of the excluded row
+func (oc *OnConflictDo) updatedValues(
+	tx *SQLTx,
+	table *Table,
+	conflictingRow *Row,
+	excludedValuesByColID map[uint32]TypedValue,
+	params map[string]interface{}) (map[uint32]TypedValue, error) {
+
+	row := &Row{
+		ValuesByPosition: conflictingRow.ValuesByPosition,
+		ValuesBySelector: make(map[string]TypedValue, 2*len(table.cols)),
+	}
+
+	valuesByColID := make(map[uint32]TypedValue, len(table.cols))
+
+	for _, col := range table.cols {
+		encSel := EncodeSelector("", table.db.name, table.name, col.colName)
+
+		val := conflictingRow.ValuesBySelector[encSel]
+
+		row.ValuesBySelector[encSel] = val
+		valuesByColID[col.id] = val
+
+		excludedVal, specified := excludedValuesByColID[col.id]
+		if !specified {
+			excludedVal = &NullValue{t: col.colType}
+		}
+
+		row.ValuesBySelector[EncodeSelector("", table.db.name, excludedRowAlias, col.colName)] = excludedVal
+	}
+
+	cols := oc.excludedColDescriptors(table)
+
+	for _, update := range oc.updates {
+		col, err := table.GetColumnByName(update.col)
+		if err != nil {
+			return nil, err
+		}
+
+		sval, err := update.val.substitute(params)
+		if err != nil {
+			return nil, err
+		}
+
+		rval, err := sval.reduce(tx, row, table.db.name, table.name)
+		if err != nil {
+			return nil, err
+		}
+
+		rval, err = coerceToColumnType(col, rval)
+		if err != nil {
+			return nil, err
+		}
+
+		err = rval.requiresType(col.colType, cols, nil, table.db.name, table.name)
+		if err != nil {
+			return nil, err
+		}
+
+		valuesByColID[col.id] = rval
+	}
+
+	return valuesByColID, nil
+}
+
+// conflictingRow returns the existent row holding the same values for the columns of the index,
+// nil is returned when there is no such row
+func (tx *SQLTx) conflictingRow(ctx context.Context, index *Index, valuesByColID map[uint32]TypedValue, pkExists bool) (*Row, error) {
+	if index.IsPrimary() {
+		if !pkExists {
+			return nil, nil
+		}
+
+		return tx.fetchPKRow(ctx, index.table, valuesByColID)
+	}
+
+	for _, col := range index.cols {
+		val, specified := valuesByColID[col.id]
+		if !specified || val.IsNull() {
+			return nil, nil
+		}
+	}
+
+	included, err := index.includes(tx, tableRow(index.table, valuesByColID))
+	if err != nil {
+		return nil, err
+	}
+
+	if !included {
+		return nil, nil
+	}
+
+	row, err := tx.fetchIndexedRow(ctx, index, valuesByColID)
+	if err == ErrNoMoreRows {
+		return nil, nil
+	}
+
+	return row, err
 }
 
 func (stmt *UpsertIntoStmt) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
@@ -551,6 +1169,37 @@ func (stmt *UpsertIntoStmt) inferParameters(ctx context.Context, tx *SQLTx, para
 			}
 
 			err = val.requiresType(col.colType, make(map[string]ColDescriptor), params, tx.currentDB.name, table.name)
+			if err != nil && (col.colType == JSONType || col.colType == DecimalType) {
+				// textual values are converted into JSON documents, numeric and textual values into DECIMAL values
+				t, terr := val.inferType(make(map[string]ColDescriptor), params, tx.currentDB.name, table.name)
+				if terr == nil && col.colType == JSONType && (t == VarcharType || t == BLOBType) {
+					err = nil
+				}
+				if terr == nil && col.colType == DecimalType && (t == IntegerType || t == VarcharType) {
+					err = nil
+				}
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if stmt.onConflict != nil && len(stmt.onConflict.updates) > 0 {
+		table, err := stmt.tableRef.referencedTable(tx)
+		if err != nil {
+			return err
+		}
+
+		cols := stmt.onConflict.excludedColDescriptors(table)
+
+		for _, update := range stmt.onConflict.updates {
+			col, err := table.GetColumnByName(update.col)
+			if err != nil {
+				return err
+			}
+
+			err = update.val.requiresType(col.colType, cols, params, tx.currentDB.name, table.name)
 			if err != nil {
 				return err
 			}
@@ -577,6 +1226,13 @@ func (stmt *UpsertIntoStmt) validate(table *Table) (map[uint32]int, error) {
 		selPosByColID[col.id] = i
 	}
 
+	if stmt.onConflict != nil {
+		err := validateUpdates(table, stmt.onConflict.updates)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return selPosByColID, nil
 }
 
@@ -595,6 +1251,15 @@ func (stmt *UpsertIntoStmt) execAt(ctx context.Context, tx *SQLTx, params map[st
 		return nil, err
 	}
 
+	var conflictIndex *Index
+
+	if stmt.onConflict != nil {
+		conflictIndex, err = stmt.onConflict.conflictIndex(table)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	for _, row := range stmt.rows {
 		if len(row.Values) != len(stmt.cols) {
 			return nil, ErrInvalidNumberOfValues
@@ -650,6 +1315,11 @@ func (stmt *UpsertIntoStmt) execAt(ctx context.Context, tx *SQLTx, params map[st
 				continue
 			}
 
+			rval, err = coerceToColumnType(col, rval)
+			if err != nil {
+				return nil, err
+			}
+
 			if col.autoIncrement {
 				// validate specified value
 				nl, isNumber := rval.Value().(int64)
@@ -685,17 +1355,42 @@ func (stmt *UpsertIntoStmt) execAt(ctx context.Context, tx *SQLTx, params map[st
 			return nil, fmt.Errorf("%w: specified value must be greater than current one", ErrInvalidValue)
 		}
 
-		if stmt.isInsert {
-			if err == nil && stmt.onConflict == nil {
-				return nil, store.ErrKeyAlreadyExists
+		pkExists := err == nil
+
+		if stmt.isInsert && stmt.onConflict != nil {
+			conflictingRow, err := tx.conflictingRow(ctx, conflictIndex, valuesByColID, pkExists)
+			if err != nil {
+				return nil, err
+			}
+
+			if conflictingRow != nil && len(stmt.onConflict.updates) == 0 {
+				continue
 			}
 
-			if err == nil && stmt.onConflict != nil {
-				// TODO: conflict resolution may be extended. Currently only supports "ON CONFLICT DO NOTHING"
-				return tx, nil
+			if conflictingRow != nil {
+				updatedValuesByColID, err := stmt.onConflict.updatedValues(tx, table, conflictingRow, valuesByColID, params)
+				if err != nil {
+					return nil, err
+				}
+
+				conflictingPKEncVals, err := encodedPK(table, updatedValuesByColID)
+				if err != nil {
+					return nil, err
+				}
+
+				err = tx.doUpsert(ctx, conflictingPKEncVals, updatedValuesByColID, table, true)
+				if err != nil {
+					return nil, err
+				}
+
+				continue
 			}
 		}
 
+		if stmt.isInsert && pkExists {
+			return nil, store.ErrKeyAlreadyExists
+		}
+
 		err = tx.doUpsert(ctx, pkEncVals, valuesByColID, table, !stmt.isInsert)
 		if err != nil {
 			return nil, err
@@ -706,6 +1401,11 @@ func (stmt *UpsertIntoStmt) execAt(ctx context.Context, tx *SQLTx, params map[st
 }
 
 func (tx *SQLTx) doUpsert(ctx context.Context, pkEncVals []byte, valuesByColID map[uint32]TypedValue, table *Table, reuseIndex bool) error {
+	err := tx.checkConstraints(table, pkEncVals, valuesByColID)
+	if err != nil {
+		return err
+	}
+
 	var reusableIndexEntries map[uint32]struct{}
 
 	if reuseIndex && len(table.indexes) > 1 {
@@ -745,7 +1445,7 @@ func (tx *SQLTx) doUpsert(ctx context.Context, pkEncVals []byte, valuesByColID m
 	b := make([]byte, EncLenLen)
 	binary.BigEndian.PutUint32(b, uint32(encodedVals))
 
-	_, err := valbuf.Write(b)
+	_, err = valbuf.Write(b)
 	if err != nil {
 		return err
 	}
@@ -780,6 +1480,8 @@ func (tx *SQLTx) doUpsert(ctx context.Context, pkEncVals []byte, valuesByColID m
 		return err
 	}
 
+	var row *Row
+
 	// create entries for secondary indexes
 	for _, index := range table.indexes {
 		if index.IsPrimary() {
@@ -793,6 +1495,21 @@ func (tx *SQLTx) doUpsert(ctx context.Context, pkEncVals []byte, valuesByColID m
 			}
 		}
 
+		if index.IsPartial() {
+			if row == nil {
+				row = tableRow(table, valuesByColID)
+			}
+
+			included, err := index.includes(tx, row)
+			if err != nil {
+				return err
+			}
+
+			if !included {
+				continue
+			}
+		}
+
 		var prefix string
 		var encodedValues [][]byte
 		var val []byte
@@ -849,6 +1566,40 @@ func (tx *SQLTx) doUpsert(ctx context.Context, pkEncVals []byte, valuesByColID m
 	return nil
 }
 
+// coerceToColumnType validates and converts values provided as text into JSON documents
+// when stored into JSON columns, and numeric or textual values into DECIMAL values of the
+// column scale when stored into DECIMAL columns, any other value is returned as is
+func coerceToColumnType(col *Column, val TypedValue) (TypedValue, error) {
+	if val.IsNull() {
+		return val, nil
+	}
+
+	if col.colType == JSONType && val.Type() != JSONType {
+		conv, err := getConverter(val.Type(), JSONType)
+		if err != nil {
+			return nil, fmt.Errorf("%w (%s)", err, col.colName)
+		}
+
+		return conv(val)
+	}
+
+	if col.colType == DecimalType {
+		conv, err := getConverter(val.Type(), DecimalType)
+		if err != nil {
+			return nil, fmt.Errorf("%w (%s)", err, col.colName)
+		}
+
+		dval, err := conv(val)
+		if err != nil {
+			return nil, err
+		}
+
+		return decimalForColumn(col, dval)
+	}
+
+	return val, nil
+}
+
 func encodedPK(table *Table, valuesByColID map[uint32]TypedValue) ([]byte, error) {
 	valbuf := bytes.Buffer{}
 
@@ -872,24 +1623,280 @@ func encodedPK(table *Table, valuesByColID map[uint32]TypedValue) ([]byte, error
 	return valbuf.Bytes(), nil
 }
 
+// tableRow builds a row of the table holding the given values, unspecified columns are NULL
+func tableRow(table *Table, valuesByColID map[uint32]TypedValue) *Row {
+	row := &Row{
+		ValuesByPosition: make([]TypedValue, len(table.cols)),
+		ValuesBySelector: make(map[string]TypedValue, len(table.cols)),
+	}
+
+	for i, col := range table.cols {
+		val, specified := valuesByColID[col.id]
+		if !specified {
+			val = &NullValue{t: col.colType}
+		}
+
+		row.ValuesByPosition[i] = val
+		row.ValuesBySelector[EncodeSelector("", table.db.name, table.name, col.colName)] = val
+	}
+
+	return row
+}
+
+// checkConstraints validates the row against the checks and foreign keys of the table,
+// checks evaluating to NULL and foreign keys including NULL values are satisfied
+func (tx *SQLTx) checkConstraints(table *Table, pkEncVals []byte, valuesByColID map[uint32]TypedValue) error {
+	if len(table.checks) > 0 {
+		row := tableRow(table, valuesByColID)
+
+		for _, check := range table.checks {
+			satisfied, err := checkSatisfied(tx, check.exp, row, table.db.name, table.name)
+			if err != nil {
+				return err
+			}
+
+			if !satisfied {
+				return fmt.Errorf("%w (%s)", ErrCheckConstraintViolation, check.sql)
+			}
+		}
+	}
+
+	for _, fk := range table.foreignKeys {
+		pkValuesByColID := make(map[uint32]TypedValue, len(fk.cols))
+
+		for i, col := range fk.cols {
+			val, specified := valuesByColID[col.id]
+			if !specified || val.IsNull() {
+				break
+			}
+
+			pkValuesByColID[fk.refTable.primaryIndex.cols[i].id] = val
+		}
+
+		if len(pkValuesByColID) < len(fk.cols) {
+			continue
+		}
+
+		refPKEncVals, err := encodedPK(fk.refTable, pkValuesByColID)
+		if err != nil {
+			return err
+		}
+
+		if fk.refTable == table && bytes.Equal(refPKEncVals, pkEncVals) {
+			// the row references itself
+			continue
+		}
+
+		mkey := mapKey(tx.sqlPrefix(), PIndexPrefix, EncodeID(fk.refTable.db.id), EncodeID(fk.refTable.id), EncodeID(fk.refTable.primaryIndex.id), refPKEncVals)
+
+		_, err = tx.get(mkey)
+		if errors.Is(err, store.ErrKeyNotFound) {
+			return fmt.Errorf("%w: no matching row in table %s", ErrForeignKeyViolation, fk.refTable.name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkSatisfied evaluates a check with three-valued logic, where comparisons involving NULL
+// are unknown instead of comparing NULL as the lowest value. Only false results violate the check
+func checkSatisfied(tx *SQLTx, exp ValueExp, row *Row, implicitDB, implicitTable string) (bool, error) {
+	val, err := reduceUnknown(tx, exp, row, implicitDB, implicitTable)
+	if err != nil {
+		return false, err
+	}
+
+	if val.IsNull() {
+		return true, nil
+	}
+
+	satisfied, ok := val.Value().(bool)
+	if !ok {
+		return false, ErrInvalidCondition
+	}
+
+	return satisfied, nil
+}
+
+// reduceUnknown reduces exp as reduce does but propagating NULL through comparisons and logical operators
+func reduceUnknown(tx *SQLTx, exp ValueExp, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	unknown := &NullValue{t: BooleanType}
+
+	switch e := exp.(type) {
+	case *CmpBoolExp:
+		vl, err := e.left.reduce(tx, row, implicitDB, implicitTable)
+		if err != nil {
+			return nil, err
+		}
+
+		vr, err := e.right.reduce(tx, row, implicitDB, implicitTable)
+		if err != nil {
+			return nil, err
+		}
+
+		if vl.IsNull() || vr.IsNull() {
+			return unknown, nil
+		}
+
+		r, err := vl.Compare(vr)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Bool{val: cmpSatisfiesOp(r, e.op)}, nil
+	case *NotBoolExp:
+		v, err := reduceUnknown(tx, e.exp, row, implicitDB, implicitTable)
+		if err != nil {
+			return nil, err
+		}
+
+		b, isBool := v.(*Bool)
+		if !isBool {
+			return v, nil
+		}
+
+		return &Bool{val: !b.val}, nil
+	case *BinBoolExp:
+		vl, err := reduceUnknown(tx, e.left, row, implicitDB, implicitTable)
+		if err != nil {
+			return nil, err
+		}
+
+		vr, err := reduceUnknown(tx, e.right, row, implicitDB, implicitTable)
+		if err != nil {
+			return nil, err
+		}
+
+		bl, leftIsBool := vl.(*Bool)
+		br, rightIsBool := vr.(*Bool)
+
+		if (!leftIsBool && !vl.IsNull()) || (!rightIsBool && !vr.IsNull()) {
+			return nil, fmt.Errorf("%w (expecting boolean value)", ErrInvalidValue)
+		}
+
+		// a known operand may determine the result regardless of the unknown one
+		switch e.op {
+		case AND:
+			if (leftIsBool && !bl.val) || (rightIsBool && !br.val) {
+				return &Bool{val: false}, nil
+			}
+			if leftIsBool && rightIsBool {
+				return &Bool{val: true}, nil
+			}
+		case OR:
+			if (leftIsBool && bl.val) || (rightIsBool && br.val) {
+				return &Bool{val: true}, nil
+			}
+			if leftIsBool && rightIsBool {
+				return &Bool{val: false}, nil
+			}
+		default:
+			return nil, ErrUnexpected
+		}
+
+		return unknown, nil
+	}
+
+	return exp.reduce(tx, row, implicitDB, implicitTable)
+}
+
+// checkNotReferenced validates there are no rows referencing the row through a foreign key
+func (tx *SQLTx) checkNotReferenced(ctx context.Context, table *Table, valuesByColID map[uint32]TypedValue) error {
+	for _, t := range table.db.tables {
+		for _, fk := range t.foreignKeys {
+			if fk.refTable != table {
+				continue
+			}
+
+			var cond ValueExp
+
+			for i, col := range fk.cols {
+				var exp ValueExp = &CmpBoolExp{
+					op:    EQ,
+					left:  &ColSelector{table: t.name, col: col.colName},
+					right: valuesByColID[table.primaryIndex.cols[i].id],
+				}
+
+				if cond != nil {
+					exp = &BinBoolExp{op: AND, left: cond, right: exp}
+				}
+
+				cond = exp
+			}
+
+			if t == table {
+				// a row referencing itself does not prevent its deletion
+				var samePK ValueExp
+
+				for _, pkCol := range table.primaryIndex.cols {
+					var exp ValueExp = &CmpBoolExp{
+						op:    EQ,
+						left:  &ColSelector{table: t.name, col: pkCol.colName},
+						right: valuesByColID[pkCol.id],
+					}
+
+					if samePK != nil {
+						exp = &BinBoolExp{op: AND, left: samePK, right: exp}
+					}
+
+					samePK = exp
+				}
+
+				cond = &BinBoolExp{op: AND, left: cond, right: &NotBoolExp{exp: samePK}}
+			}
+
+			selectStmt := &SelectStmt{
+				ds:    &tableRef{table: t.name},
+				where: cond,
+				limit: 1,
+			}
+
+			r, err := selectStmt.Resolve(ctx, tx, nil, nil)
+			if err != nil {
+				return err
+			}
+
+			_, err = r.Read(ctx)
+			r.Close()
+
+			if err == nil {
+				return fmt.Errorf("%w: row is referenced by table %s", ErrForeignKeyViolation, t.name)
+			}
+			if err != ErrNoMoreRows {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func (tx *SQLTx) fetchPKRow(ctx context.Context, table *Table, valuesByColID map[uint32]TypedValue) (*Row, error) {
-	pkRanges := make(map[uint32]*typedValueRange, len(table.primaryIndex.cols))
+	return tx.fetchIndexedRow(ctx, table.primaryIndex, valuesByColID)
+}
+
+// fetchIndexedRow returns the first row holding the given values for the columns of the index
+func (tx *SQLTx) fetchIndexedRow(ctx context.Context, index *Index, valuesByColID map[uint32]TypedValue) (*Row, error) {
+	ranges := make(map[uint32]*typedValueRange, len(index.cols))
 
-	for _, pkCol := range table.primaryIndex.cols {
-		pkVal := valuesByColID[pkCol.id]
+	for _, col := range index.cols {
+		val := valuesByColID[col.id]
 
-		pkRanges[pkCol.id] = &typedValueRange{
-			lRange: &typedValueSemiRange{val: pkVal, inclusive: true},
-			hRange: &typedValueSemiRange{val: pkVal, inclusive: true},
+		ranges[col.id] = &typedValueRange{
+			lRange: &typedValueSemiRange{val: val, inclusive: true},
+			hRange: &typedValueSemiRange{val: val, inclusive: true},
 		}
 	}
 
 	scanSpecs := &ScanSpecs{
-		Index:         table.primaryIndex,
-		rangesByColID: pkRanges,
+		Index:         index,
+		rangesByColID: ranges,
 	}
 
-	r, err := newRawRowReader(tx, nil, table, period{}, table.name, scanSpecs)
+	r, err := newRawRowReader(tx, nil, index.table, period{}, index.table.name, scanSpecs)
 	if err != nil {
 		return nil, err
 	}
@@ -909,11 +1916,45 @@ func (tx *SQLTx) deprecateIndexEntries(
 
 	reusableIndexEntries = make(map[uint32]struct{})
 
+	var currRow, newRow *Row
+
 	for _, index := range table.indexes {
 		if index.IsPrimary() {
 			continue
 		}
 
+		if index.IsPartial() {
+			if currRow == nil {
+				currRow = tableRow(table, currValuesByColID)
+				newRow = tableRow(table, newValuesByColID)
+			}
+
+			currIncluded, err := index.includes(tx, currRow)
+			if err != nil {
+				return nil, err
+			}
+
+			if !currIncluded {
+				// there is no existent index entry
+				continue
+			}
+
+			newIncluded, err := index.includes(tx, newRow)
+			if err != nil {
+				return nil, err
+			}
+
+			if !newIncluded {
+				// existent index entry must be deleted
+				err = tx.deleteIndexEntry(index, pkEncVals, currValuesByColID)
+				if err != nil {
+					return nil, err
+				}
+
+				continue
+			}
+		}
+
 		var prefix string
 		var encodedValues [][]byte
 
@@ -1025,9 +2066,13 @@ func (stmt *UpdateStmt) inferParameters(ctx context.Context, tx *SQLTx, params m
 }
 
 func (stmt *UpdateStmt) validate(table *Table) error {
-	colIDs := make(map[uint32]struct{}, len(stmt.updates))
+	return validateUpdates(table, stmt.updates)
+}
 
-	for _, update := range stmt.updates {
+func validateUpdates(table *Table, updates []*colUpdate) error {
+	colIDs := make(map[uint32]struct{}, len(updates))
+
+	for _, update := range updates {
 		if update.op != EQ {
 			return ErrIllegalArguments
 		}
@@ -1057,6 +2102,12 @@ func (stmt *UpdateStmt) execAt(ctx context.Context, tx *SQLTx, params map[string
 		return nil, ErrNoDatabaseSelected
 	}
 
+	// rows can only be updated through tables
+	_, err := stmt.tableRef.referencedTable(tx)
+	if err != nil {
+		return nil, err
+	}
+
 	selectStmt := &SelectStmt{
 		ds:      stmt.tableRef,
 		where:   stmt.where,
@@ -1107,7 +2158,12 @@ func (stmt *UpdateStmt) execAt(ctx context.Context, tx *SQLTx, params map[string
 				return nil, err
 			}
 
-			rval, err := sval.reduce(tx, row, table.db.name, table.name)
+			rval, err := sval.reduce(tx, row, table.db.name, table.name)
+			if err != nil {
+				return nil, err
+			}
+
+			rval, err = coerceToColumnType(col, rval)
 			if err != nil {
 				return nil, err
 			}
@@ -1164,6 +2220,12 @@ func (stmt *DeleteFromStmt) execAt(ctx context.Context, tx *SQLTx, params map[st
 		return nil, ErrNoDatabaseSelected
 	}
 
+	// rows can only be deleted through tables
+	_, err := stmt.tableRef.referencedTable(tx)
+	if err != nil {
+		return nil, err
+	}
+
 	selectStmt := &SelectStmt{
 		ds:      stmt.tableRef,
 		where:   stmt.where,
@@ -1201,6 +2263,11 @@ func (stmt *DeleteFromStmt) execAt(ctx context.Context, tx *SQLTx, params map[st
 			return nil, err
 		}
 
+		err = tx.checkNotReferenced(ctx, table, valuesByColID)
+		if err != nil {
+			return nil, err
+		}
+
 		err = tx.deleteIndexEntries(pkEncVals, valuesByColID, table)
 		if err != nil {
 			return nil, err
@@ -1213,50 +2280,71 @@ func (stmt *DeleteFromStmt) execAt(ctx context.Context, tx *SQLTx, params map[st
 }
 
 func (tx *SQLTx) deleteIndexEntries(pkEncVals []byte, valuesByColID map[uint32]TypedValue, table *Table) error {
+	var row *Row
+
 	for _, index := range table.indexes {
-		var prefix string
-		var encodedValues [][]byte
+		if index.IsPartial() {
+			if row == nil {
+				row = tableRow(table, valuesByColID)
+			}
 
-		if index.IsUnique() {
-			if index.IsPrimary() {
-				prefix = PIndexPrefix
-			} else {
-				prefix = UIndexPrefix
+			included, err := index.includes(tx, row)
+			if err != nil {
+				return err
 			}
 
-			encodedValues = make([][]byte, 3+len(index.cols))
-		} else {
-			prefix = SIndexPrefix
-			encodedValues = make([][]byte, 4+len(index.cols))
-			encodedValues[len(encodedValues)-1] = pkEncVals
+			if !included {
+				continue
+			}
 		}
 
-		encodedValues[0] = EncodeID(table.db.id)
-		encodedValues[1] = EncodeID(table.id)
-		encodedValues[2] = EncodeID(index.id)
+		err := tx.deleteIndexEntry(index, pkEncVals, valuesByColID)
+		if err != nil {
+			return err
+		}
+	}
 
-		for i, col := range index.cols {
-			val, specified := valuesByColID[col.id]
-			if !specified {
-				val = &NullValue{t: col.colType}
-			}
+	return nil
+}
 
-			encVal, _ := EncodeAsKey(val.Value(), col.colType, col.MaxLen())
+func (tx *SQLTx) deleteIndexEntry(index *Index, pkEncVals []byte, valuesByColID map[uint32]TypedValue) error {
+	var prefix string
+	var encodedValues [][]byte
 
-			encodedValues[i+3] = encVal
+	if index.IsUnique() {
+		if index.IsPrimary() {
+			prefix = PIndexPrefix
+		} else {
+			prefix = UIndexPrefix
 		}
 
-		md := store.NewKVMetadata()
+		encodedValues = make([][]byte, 3+len(index.cols))
+	} else {
+		prefix = SIndexPrefix
+		encodedValues = make([][]byte, 4+len(index.cols))
+		encodedValues[len(encodedValues)-1] = pkEncVals
+	}
 
-		md.AsDeleted(true)
+	encodedValues[0] = EncodeID(index.table.db.id)
+	encodedValues[1] = EncodeID(index.table.id)
+	encodedValues[2] = EncodeID(index.id)
 
-		err := tx.set(mapKey(tx.sqlPrefix(), prefix, encodedValues...), md, nil)
-		if err != nil {
-			return err
+	for i, col := range index.cols {
+		val, specified := valuesByColID[col.id]
+		if !specified {
+			val = &NullValue{t: col.colType}
 		}
+
+		encVal, _ := EncodeAsKey(val.Value(), col.colType, col.MaxLen())
+
+		encodedValues[i+3] = encVal
 	}
 
-	return nil
+	md := store.NewKVMetadata()
+
+	md.AsDeleted(true)
+
+	return tx.set(mapKey(tx.sqlPrefix(), prefix, encodedValues...), md, nil)
 }
 
 type ValueExp interface {
@@ -1498,6 +2586,10 @@ func (v *Number) Compare(val TypedValue) (int, error) {
 		return 1, nil
 	}
 
+	if val.Type() == DecimalType {
+		return decimalFromInt(v.val).Compare(val)
+	}
+
 	if val.Type() != IntegerType {
 		return 0, ErrNotComparableValues
 	}
@@ -1779,6 +2871,236 @@ func (v *Blob) Compare(val TypedValue) (int, error) {
 	return bytes.Compare(v.val, rval), nil
 }
 
+// JSON holds a decoded JSON document, numbers are kept as json.Number so to preserve their precision.
+// Its value is the canonical encoding of the document, both when exposed and when stored
+type JSON struct {
+	val interface{}
+}
+
+func ParseJSON(s string) (*JSON, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+
+	var val interface{}
+
+	err := dec.Decode(&val)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid JSON document (%v)", ErrInvalidValue, err)
+	}
+
+	if dec.More() {
+		return nil, fmt.Errorf("%w: invalid JSON document (unexpected data after the document)", ErrInvalidValue)
+	}
+
+	return &JSON{val: val}, nil
+}
+
+func (v *JSON) Type() SQLValueType {
+	return JSONType
+}
+
+func (v *JSON) IsNull() bool {
+	return false
+}
+
+func (v *JSON) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return JSONType, nil
+}
+
+func (v *JSON) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != JSONType {
+		return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, JSONType, t)
+	}
+
+	return nil
+}
+
+func (v *JSON) substitute(params map[string]interface{}) (ValueExp, error) {
+	return v, nil
+}
+
+func (v *JSON) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return v, nil
+}
+
+func (v *JSON) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *JSON) isConstant() bool {
+	return true
+}
+
+func (v *JSON) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+func (v *JSON) Value() interface{} {
+	return v.String()
+}
+
+func (v *JSON) String() string {
+	// decoded documents can always be encoded
+	b, _ := json.Marshal(v.val)
+	return string(b)
+}
+
+func (v *JSON) Compare(val TypedValue) (int, error) {
+	if val.IsNull() {
+		return 1, nil
+	}
+
+	if val.Type() != JSONType {
+		return 0, ErrNotComparableValues
+	}
+
+	rval := val.Value().(string)
+
+	return strings.Compare(v.String(), rval), nil
+}
+
+// JSONExtractExp extracts the value of an object member or an array element from a JSON document,
+// either as a JSON document (->) or as text (->>). NULL is returned when the document has no such value
+type JSONExtractExp struct {
+	val    ValueExp
+	path   ValueExp
+	asText bool
+}
+
+func (bexp *JSONExtractExp) resultType() SQLValueType {
+	if bexp.asText {
+		return VarcharType
+	}
+	return JSONType
+}
+
+func (bexp *JSONExtractExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	err := bexp.val.requiresType(JSONType, cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, err
+	}
+
+	t, err := bexp.path.inferType(cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, err
+	}
+
+	if t != VarcharType && t != IntegerType && t != AnyType {
+		return AnyType, fmt.Errorf("%w: JSON members and elements can only be extracted using %v or %v values", ErrInvalidTypes, VarcharType, IntegerType)
+	}
+
+	return bexp.resultType(), nil
+}
+
+func (bexp *JSONExtractExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	it, err := bexp.inferType(cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return err
+	}
+
+	if it != t {
+		return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, it, t)
+	}
+
+	return nil
+}
+
+func (bexp *JSONExtractExp) substitute(params map[string]interface{}) (ValueExp, error) {
+	val, err := bexp.val.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := bexp.path.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSONExtractExp{val: val, path: path, asText: bexp.asText}, nil
+}
+
+func (bexp *JSONExtractExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	v, err := bexp.val.reduce(tx, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := bexp.path.reduce(tx, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.IsNull() || p.IsNull() {
+		return &NullValue{t: bexp.resultType()}, nil
+	}
+
+	doc, ok := v.(*JSON)
+	if !ok {
+		return nil, fmt.Errorf("%w: expecting %v value but %v was provided", ErrInvalidTypes, JSONType, v.Type())
+	}
+
+	var extracted interface{}
+	var found bool
+
+	switch path := p.Value().(type) {
+	case string:
+		{
+			obj, isObject := doc.val.(map[string]interface{})
+			if isObject {
+				extracted, found = obj[path]
+			}
+		}
+	case int64:
+		{
+			arr, isArray := doc.val.([]interface{})
+			if isArray && path >= 0 && path < int64(len(arr)) {
+				extracted, found = arr[path], true
+			}
+		}
+	default:
+		{
+			return nil, fmt.Errorf("%w: JSON members and elements can only be extracted using %v or %v values", ErrInvalidTypes, VarcharType, IntegerType)
+		}
+	}
+
+	if !found {
+		return &NullValue{t: bexp.resultType()}, nil
+	}
+
+	if !bexp.asText {
+		return &JSON{val: extracted}, nil
+	}
+
+	switch e := extracted.(type) {
+	case nil:
+		{
+			return &NullValue{t: VarcharType}, nil
+		}
+	case string:
+		{
+			return &Varchar{val: e}, nil
+		}
+	}
+
+	return &Varchar{val: (&JSON{val: extracted}).String()}, nil
+}
+
+func (bexp *JSONExtractExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return &JSONExtractExp{
+		val:    bexp.val.reduceSelectors(row, implicitDB, implicitTable),
+		path:   bexp.path.reduceSelectors(row, implicitDB, implicitTable),
+		asText: bexp.asText,
+	}
+}
+
+func (bexp *JSONExtractExp) isConstant() bool {
+	return bexp.val.isConstant() && bexp.path.isConstant()
+}
+
+func (bexp *JSONExtractExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
 type FnCall struct {
 	fn     string
 	params []ValueExp
@@ -1858,46 +3180,112 @@ func getConverter(src, dst SQLValueType) (converterFunc, error) {
 				if val.Value() == nil {
 					return &NullValue{t: TimestampType}, nil
 				}
-				return &Timestamp{val: time.Unix(val.Value().(int64), 0).Truncate(time.Microsecond).UTC()}, nil
+				return &Timestamp{val: time.Unix(val.Value().(int64), 0).Truncate(time.Microsecond).UTC()}, nil
+			}, nil
+		}
+
+		if src == VarcharType {
+			return func(val TypedValue) (TypedValue, error) {
+				if val.Value() == nil {
+					return &NullValue{t: TimestampType}, nil
+				}
+
+				str := val.Value().(string)
+				for _, layout := range []string{
+					"2006-01-02 15:04:05.999999",
+					"2006-01-02 15:04",
+					"2006-01-02",
+				} {
+					t, err := time.ParseInLocation(layout, str, time.UTC)
+					if err == nil {
+						return &Timestamp{val: t.Truncate(time.Microsecond).UTC()}, nil
+					}
+				}
+
+				if len(str) > 30 {
+					str = str[:30] + "..."
+				}
+
+				return nil, fmt.Errorf(
+					"%w: can not cast string '%s' as a TIMESTAMP",
+					ErrIllegalArguments,
+					str,
+				)
+			}, nil
+		}
+
+		return nil, fmt.Errorf(
+			"%w: only INTEGER and VARCHAR types can be cast as TIMESTAMP",
+			ErrUnsupportedCast,
+		)
+	}
+
+	if dst == JSONType {
+
+		if src == JSONType {
+			return func(val TypedValue) (TypedValue, error) {
+				return val, nil
+			}, nil
+		}
+
+		if src == VarcharType || src == BLOBType {
+			return func(val TypedValue) (TypedValue, error) {
+				if val.Value() == nil {
+					return &NullValue{t: JSONType}, nil
+				}
+
+				if src == BLOBType {
+					return ParseJSON(string(val.Value().([]byte)))
+				}
+
+				return ParseJSON(val.Value().(string))
+			}, nil
+		}
+
+		return nil, fmt.Errorf(
+			"%w: only VARCHAR and BLOB types can be cast as JSON",
+			ErrUnsupportedCast,
+		)
+	}
+
+	if dst == DecimalType {
+
+		if src == IntegerType || src == DecimalType {
+			return func(val TypedValue) (TypedValue, error) {
+				if val.Value() == nil {
+					return &NullValue{t: DecimalType}, nil
+				}
+
+				d, _ := asDecimal(val)
+				return d, nil
 			}, nil
 		}
 
 		if src == VarcharType {
 			return func(val TypedValue) (TypedValue, error) {
 				if val.Value() == nil {
-					return &NullValue{t: TimestampType}, nil
-				}
-
-				str := val.Value().(string)
-				for _, layout := range []string{
-					"2006-01-02 15:04:05.999999",
-					"2006-01-02 15:04",
-					"2006-01-02",
-				} {
-					t, err := time.ParseInLocation(layout, str, time.UTC)
-					if err == nil {
-						return &Timestamp{val: t.Truncate(time.Microsecond).UTC()}, nil
-					}
-				}
-
-				if len(str) > 30 {
-					str = str[:30] + "..."
+					return &NullValue{t: DecimalType}, nil
 				}
 
-				return nil, fmt.Errorf(
-					"%w: can not cast string '%s' as a TIMESTAMP",
-					ErrIllegalArguments,
-					str,
-				)
+				return ParseDecimal(strings.TrimSpace(val.Value().(string)))
 			}, nil
 		}
 
 		return nil, fmt.Errorf(
-			"%w: only INTEGER and VARCHAR types can be cast as TIMESTAMP",
+			"%w: only INTEGER, VARCHAR and DECIMAL types can be cast as DECIMAL",
 			ErrUnsupportedCast,
 		)
 	}
 
+	if dst == VarcharType && (src == JSONType || src == DecimalType) {
+		return func(val TypedValue) (TypedValue, error) {
+			if val.Value() == nil {
+				return &NullValue{t: VarcharType}, nil
+			}
+			return &Varchar{val: val.Value().(string)}, nil
+		}, nil
+	}
+
 	return nil, fmt.Errorf(
 		"%w: can not cast %s value as %s",
 		ErrUnsupportedCast,
@@ -2086,6 +3474,9 @@ type SelectStmt struct {
 	offset    int
 	orderBy   []*OrdCol
 	as        string
+
+	// key of the latest row read by a previous query, the scan is resumed right after it
+	resumeAfter []byte
 }
 
 func (stmt *SelectStmt) Limit() int {
@@ -2121,17 +3512,13 @@ func (stmt *SelectStmt) execAt(ctx context.Context, tx *SQLTx, params map[string
 		return nil, ErrHavingClauseRequiresGroupClause
 	}
 
-	if len(stmt.groupBy) > 1 {
-		return nil, ErrLimitedGroupBy
-	}
-
 	if len(stmt.orderBy) > 1 {
 		return nil, ErrLimitedOrderBy
 	}
 
 	if len(stmt.orderBy) > 0 {
 		tableRef, ok := stmt.ds.(*tableRef)
-		if !ok {
+		if !ok || tableRef.referencesView(tx) {
 			return nil, ErrLimitedOrderBy
 		}
 
@@ -2155,11 +3542,19 @@ func (stmt *SelectStmt) execAt(ctx context.Context, tx *SQLTx, params map[string
 }
 
 func (stmt *SelectStmt) Resolve(ctx context.Context, tx *SQLTx, params map[string]interface{}, _ *ScanSpecs) (ret RowReader, err error) {
+	if len(stmt.joins) > 0 && stmt.joins[0].joinType == RightJoin {
+		return stmt.resolveRightJoin(ctx, tx, params)
+	}
+
 	scanSpecs, err := stmt.genScanSpecs(tx, params)
 	if err != nil {
 		return nil, err
 	}
 
+	if scanSpecs != nil {
+		scanSpecs.resumeAfter = stmt.resumeAfter
+	}
+
 	rowReader, err := stmt.ds.Resolve(ctx, tx, params, scanSpecs)
 	if err != nil {
 		return nil, err
@@ -2178,6 +3573,49 @@ func (stmt *SelectStmt) Resolve(ctx context.Context, tx *SQLTx, params map[strin
 		rowReader = jointRowReader
 	}
 
+	return stmt.resolveRows(ctx, rowReader)
+}
+
+// resolveRightJoin reads the rows of the right data source of the first join,
+// rows of the left data source not matching any of them are not returned
+func (stmt *SelectStmt) resolveRightJoin(ctx context.Context, tx *SQLTx, params map[string]interface{}) (ret RowReader, err error) {
+	rjoin := stmt.joins[0]
+
+	// filtering conditions and ordering apply to the right data source as they
+	// would do to the left data source in any other join
+	rightStmt := &SelectStmt{
+		ds:      rjoin.ds,
+		indexOn: rjoin.indexOn,
+		where:   stmt.where,
+		orderBy: stmt.orderBy,
+	}
+
+	scanSpecs, err := rightStmt.genScanSpecs(tx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	rowReader, err := rjoin.ds.Resolve(ctx, tx, params, scanSpecs)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			rowReader.Close()
+		}
+	}()
+
+	jointRowReader, err := newRightJointRowReader(rowReader, stmt.ds, stmt.indexOn, stmt.joins)
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt.resolveRows(ctx, jointRowReader)
+}
+
+// resolveRows applies filtering, aggregations, projection and limits to the rows of the data sources
+func (stmt *SelectStmt) resolveRows(ctx context.Context, rowReader RowReader) (ret RowReader, err error) {
+
 	if stmt.where != nil {
 		rowReader = newConditionalRowReader(rowReader, stmt.where)
 	}
@@ -2196,7 +3634,7 @@ func (stmt *SelectStmt) Resolve(ctx context.Context, tx *SQLTx, params map[strin
 			groupBy = stmt.groupBy
 		}
 
-		groupedRowReader, err := newGroupedRowReader(rowReader, stmt.selectors, groupBy)
+		groupedRowReader, err := newGroupedRowReader(rowReader, stmt.selectors, groupBy, stmt.having)
 		if err != nil {
 			return nil, err
 		}
@@ -2207,6 +3645,23 @@ func (stmt *SelectStmt) Resolve(ctx context.Context, tx *SQLTx, params map[strin
 		}
 	}
 
+	var windowFns []*WindowFnSelector
+	for i, sel := range stmt.selectors {
+		wfn, isWindowFn := sel.(*WindowFnSelector)
+		if isWindowFn {
+			wfn.pos = i
+			windowFns = append(windowFns, wfn)
+		}
+	}
+
+	if len(windowFns) > 0 {
+		windowRowReader, err := newWindowRowReader(rowReader, windowFns)
+		if err != nil {
+			return nil, err
+		}
+		rowReader = windowRowReader
+	}
+
 	projectedRowReader, err := newProjectedRowReader(ctx, rowReader, stmt.as, stmt.selectors)
 	if err != nil {
 		return nil, err
@@ -2242,7 +3697,7 @@ func (stmt *SelectStmt) Alias() string {
 
 func (stmt *SelectStmt) genScanSpecs(tx *SQLTx, params map[string]interface{}) (*ScanSpecs, error) {
 	tableRef, isTableRef := stmt.ds.(*tableRef)
-	if !isTableRef {
+	if !isTableRef || tableRef.referencesView(tx) {
 		return nil, nil
 	}
 
@@ -2274,7 +3729,7 @@ func (stmt *SelectStmt) genScanSpecs(tx *SQLTx, params map[string]interface{}) (
 		}
 
 		index, ok := table.indexesByName[indexName(table.name, cols)]
-		if !ok {
+		if !ok || !index.usableWith(stmt.where, tableRef.Alias()) {
 			return nil, ErrNoAvailableIndex
 		}
 
@@ -2287,6 +3742,22 @@ func (stmt *SelectStmt) genScanSpecs(tx *SQLTx, params map[string]interface{}) (
 	if stmt.orderBy == nil {
 		if preferredIndex == nil {
 			sortingIndex = table.primaryIndex
+			prefixLen := table.primaryIndex.usablePrefixLen(rangesByColID)
+
+			// an index is preferred over the primary one when a longer prefix of its columns is ranged,
+			// or when it's a partial index and the query only selects rows included in it
+			for _, idx := range table.indexes[1:] {
+				if !idx.usableWith(stmt.where, tableRef.Alias()) {
+					continue
+				}
+
+				idxPrefixLen := idx.usablePrefixLen(rangesByColID)
+
+				if idxPrefixLen > prefixLen || (idxPrefixLen == prefixLen && idx.IsPartial() && !sortingIndex.IsPartial()) {
+					sortingIndex = idx
+					prefixLen = idxPrefixLen
+				}
+			}
 		} else {
 			sortingIndex = preferredIndex
 		}
@@ -2299,7 +3770,7 @@ func (stmt *SelectStmt) genScanSpecs(tx *SQLTx, params map[string]interface{}) (
 		}
 
 		for _, idx := range table.indexesByColID[col.id] {
-			if idx.sortableUsing(col.id, rangesByColID) {
+			if idx.sortableUsing(col.id, rangesByColID) && idx.usableWith(stmt.where, tableRef.Alias()) {
 				if preferredIndex == nil || idx.id == preferredIndex.id {
 					sortingIndex = idx
 					break
@@ -2321,6 +3792,76 @@ func (stmt *SelectStmt) genScanSpecs(tx *SQLTx, params map[string]interface{}) (
 	}, nil
 }
 
+// usableWith returns true if every row satisfying the condition is included in the index,
+// it's the case for partial indexes when each conjunct of the predicate is also a conjunct of the condition
+func (i *Index) usableWith(where ValueExp, alias string) bool {
+	if !i.IsPartial() {
+		return true
+	}
+
+	conds := conjuncts(where, nil)
+
+	for _, p := range conjuncts(i.where, nil) {
+		implied := false
+
+		for _, cond := range conds {
+			if sameExp(p, cond, alias) {
+				implied = true
+				break
+			}
+		}
+
+		if !implied {
+			return false
+		}
+	}
+
+	return true
+}
+
+func conjuncts(exp ValueExp, acc []ValueExp) []ValueExp {
+	if exp == nil {
+		return acc
+	}
+
+	bexp, ok := exp.(*BinBoolExp)
+	if ok && bexp.op == AND {
+		acc = conjuncts(bexp.left, acc)
+		return conjuncts(bexp.right, acc)
+	}
+
+	return append(acc, exp)
+}
+
+// sameExp compares an expression over the columns of a table with an expression of a query
+// where the table is referenced by the given alias
+func sameExp(e1, e2 ValueExp, alias string) bool {
+	switch e := e1.(type) {
+	case *ColSelector:
+		{
+			sel, ok := e2.(*ColSelector)
+			return ok && e.col == sel.col && (sel.table == "" || sel.table == alias)
+		}
+	case *CmpBoolExp:
+		{
+			cmp, ok := e2.(*CmpBoolExp)
+			return ok && e.op == cmp.op && sameExp(e.left, cmp.left, alias) && sameExp(e.right, cmp.right, alias)
+		}
+	case *BinBoolExp:
+		{
+			bin, ok := e2.(*BinBoolExp)
+			return ok && e.op == bin.op && sameExp(e.left, bin.left, alias) && sameExp(e.right, bin.right, alias)
+		}
+	case *NotBoolExp:
+		{
+			not, ok := e2.(*NotBoolExp)
+			return ok && sameExp(e.exp, not.exp, alias)
+		}
+	}
+
+	return expString(e1) == expString(e2)
+}
+
 type UnionStmt struct {
 	distinct    bool
 	left, right DataSource
@@ -2399,6 +3940,67 @@ func (stmt *UnionStmt) Alias() string {
 	return ""
 }
 
+type SetOperator = int
+
+const (
+	IntersectOp SetOperator = iota
+	ExceptOp
+)
+
+// SetOpStmt combines the rows of two queries using INTERSECT or EXCEPT semantics,
+// duplicated rows are removed from the result unless ALL is specified, as in UNION
+type SetOpStmt struct {
+	op          SetOperator
+	distinct    bool
+	left, right DataSource
+}
+
+func (stmt *SetOpStmt) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
+	err := stmt.left.inferParameters(ctx, tx, params)
+	if err != nil {
+		return err
+	}
+
+	return stmt.right.inferParameters(ctx, tx, params)
+}
+
+func (stmt *SetOpStmt) execAt(ctx context.Context, tx *SQLTx, params map[string]interface{}) (*SQLTx, error) {
+	_, err := stmt.left.execAt(ctx, tx, params)
+	if err != nil {
+		return tx, err
+	}
+
+	return stmt.right.execAt(ctx, tx, params)
+}
+
+func (stmt *SetOpStmt) Resolve(ctx context.Context, tx *SQLTx, params map[string]interface{}, _ *ScanSpecs) (ret RowReader, err error) {
+	leftRowReader, err := stmt.left.Resolve(ctx, tx, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			leftRowReader.Close()
+		}
+	}()
+
+	rightRowReader, err := stmt.right.Resolve(ctx, tx, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			rightRowReader.Close()
+		}
+	}()
+
+	return newSetOpRowReader(ctx, stmt.op, stmt.distinct, leftRowReader, rightRowReader)
+}
+
+func (stmt *SetOpStmt) Alias() string {
+	return ""
+}
+
 type tableRef struct {
 	db     string
 	table  string
@@ -2409,6 +4011,11 @@ type tableRef struct {
 type period struct {
 	start *openPeriod
 	end   *openPeriod
+	asOf  *periodInstant // rows are resolved as they were right after the instant
+}
+
+func (p period) isSet() bool {
+	return p.start != nil || p.end != nil || p.asOf != nil
 }
 
 type openPeriod struct {
@@ -2531,6 +4138,25 @@ func (stmt *tableRef) referencedTable(tx *SQLTx) (*Table, error) {
 	return table, nil
 }
 
+func (stmt *tableRef) referencedView(tx *SQLTx) (*View, bool) {
+	if tx.currentDB == nil {
+		return nil, false
+	}
+
+	if stmt.db != "" && stmt.db != tx.currentDB.name {
+		return nil, false
+	}
+
+	view, exists := tx.currentDB.viewsByName[stmt.table]
+
+	return view, exists
+}
+
+func (stmt *tableRef) referencesView(tx *SQLTx) bool {
+	_, isView := stmt.referencedView(tx)
+	return isView
+}
+
 func (stmt *tableRef) inferParameters(ctx context.Context, tx *SQLTx, params map[string]SQLValueType) error {
 	return nil
 }
@@ -2544,6 +4170,15 @@ func (stmt *tableRef) Resolve(ctx context.Context, tx *SQLTx, params map[string]
 		return nil, ErrIllegalArguments
 	}
 
+	view, isView := stmt.referencedView(tx)
+	if isView {
+		if stmt.period.isSet() {
+			return view.resolveAt(ctx, tx, params, stmt.Alias(), stmt.period)
+		}
+
+		return view.resolve(ctx, tx, params, stmt.Alias())
+	}
+
 	table, err := stmt.referencedTable(tx)
 	if err != nil {
 		return nil, err
@@ -2700,78 +4335,212 @@ func (sel *AggColSelector) resolve(implicitDB, implicitTable string) (aggFn, db,
 		table = sel.table
 	}
 
-	return sel.aggFn, db, table, sel.col
+	return sel.aggFn, db, table, sel.col
+}
+
+func (sel *AggColSelector) alias() string {
+	return sel.as
+}
+
+func (sel *AggColSelector) setAlias(alias string) {
+	sel.as = alias
+}
+
+func (sel *AggColSelector) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	if sel.aggFn == COUNT {
+		return IntegerType, nil
+	}
+
+	colSelector := &ColSelector{db: sel.db, table: sel.table, col: sel.col}
+
+	if sel.aggFn == SUM || sel.aggFn == AVG {
+		t, err := colSelector.inferType(cols, params, implicitDB, implicitTable)
+		if err == nil && t == DecimalType {
+			return DecimalType, nil
+		}
+
+		err = colSelector.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return AnyType, err
+		}
+
+		return IntegerType, nil
+	}
+
+	return colSelector.inferType(cols, params, implicitDB, implicitTable)
+}
+
+func (sel *AggColSelector) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if sel.aggFn == COUNT {
+		if t != IntegerType {
+			return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, IntegerType, t)
+		}
+		return nil
+	}
+
+	colSelector := &ColSelector{db: sel.db, table: sel.table, col: sel.col}
+
+	if sel.aggFn == SUM || sel.aggFn == AVG {
+		if t == DecimalType {
+			return colSelector.requiresType(DecimalType, cols, params, implicitDB, implicitTable)
+		}
+
+		return colSelector.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
+	}
+
+	return colSelector.requiresType(t, cols, params, implicitDB, implicitTable)
+}
+
+func (sel *AggColSelector) substitute(params map[string]interface{}) (ValueExp, error) {
+	return sel, nil
+}
+
+func (sel *AggColSelector) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	if row == nil {
+		return nil, fmt.Errorf("%w: no row to evaluate aggregation (%s) in current context", ErrInvalidValue, sel.aggFn)
+	}
+
+	v, ok := row.ValuesBySelector[EncodeSelector(sel.resolve(implicitDB, implicitTable))]
+	if !ok {
+		return nil, fmt.Errorf("%w (%s)", ErrColumnDoesNotExist, sel.col)
+	}
+	return v, nil
+}
+
+func (sel *AggColSelector) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return sel
+}
+
+func (sel *AggColSelector) isConstant() bool {
+	return false
+}
+
+func (sel *AggColSelector) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+// WindowFnSelector selects the value of a window function evaluated over the partition
+// the row belongs to, e.g. ROW_NUMBER() OVER (PARTITION BY col1 ORDER BY col2 DESC)
+type WindowFnSelector struct {
+	fn          string
+	params      []ValueExp
+	partitionBy []*ColSelector
+	orderBy     []*OrdCol
+	as          string
+
+	// position in the list of selectors, identifies the evaluated values
+	pos int
+}
+
+func (sel *WindowFnSelector) resolve(implicitDB, implicitTable string) (aggFn, db, table, col string) {
+	return strings.ToUpper(sel.fn), implicitDB, implicitTable, fmt.Sprintf("col%d", sel.pos)
 }
 
-func (sel *AggColSelector) alias() string {
+func (sel *WindowFnSelector) alias() string {
 	return sel.as
 }
 
-func (sel *AggColSelector) setAlias(alias string) {
+func (sel *WindowFnSelector) setAlias(alias string) {
 	sel.as = alias
 }
 
-func (sel *AggColSelector) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	if sel.aggFn == COUNT {
-		return IntegerType, nil
+func (sel *WindowFnSelector) validate() error {
+	fn := strings.ToUpper(sel.fn)
+
+	switch fn {
+	case RowNumberFnCall, RankFnCall:
+		{
+			if len(sel.params) > 0 {
+				return fmt.Errorf("%w: '%s' function does not expect any argument but %d were provided", ErrIllegalArguments, fn, len(sel.params))
+			}
+		}
+	case LagFnCall, LeadFnCall:
+		{
+			if len(sel.params) == 0 || len(sel.params) > 3 {
+				return fmt.Errorf("%w: '%s' function expects from one to three arguments but %d were provided", ErrIllegalArguments, fn, len(sel.params))
+			}
+		}
+	default:
+		{
+			return fmt.Errorf("%w (%s)", ErrFunctionDoesNotExist, sel.fn)
+		}
 	}
 
-	colSelector := &ColSelector{db: sel.db, table: sel.table, col: sel.col}
+	return nil
+}
 
-	if sel.aggFn == SUM || sel.aggFn == AVG {
-		err := colSelector.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
+func (sel *WindowFnSelector) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	err := sel.validate()
+	if err != nil {
+		return AnyType, err
+	}
+
+	fn := strings.ToUpper(sel.fn)
+
+	if fn == RowNumberFnCall || fn == RankFnCall {
+		return IntegerType, nil
+	}
+
+	if len(sel.params) > 1 {
+		err = sel.params[1].requiresType(IntegerType, cols, params, implicitDB, implicitTable)
 		if err != nil {
 			return AnyType, err
 		}
-
-		return IntegerType, nil
 	}
 
-	return colSelector.inferType(cols, params, implicitDB, implicitTable)
-}
+	t, err := sel.params[0].inferType(cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, err
+	}
 
-func (sel *AggColSelector) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if sel.aggFn == COUNT {
-		if t != IntegerType {
-			return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, IntegerType, t)
+	if len(sel.params) > 2 {
+		err = sel.params[2].requiresType(t, cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return AnyType, err
 		}
-		return nil
 	}
 
-	colSelector := &ColSelector{db: sel.db, table: sel.table, col: sel.col}
+	return t, nil
+}
 
-	if sel.aggFn == SUM || sel.aggFn == AVG {
-		return colSelector.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
+func (sel *WindowFnSelector) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	it, err := sel.inferType(cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return err
 	}
 
-	return colSelector.requiresType(t, cols, params, implicitDB, implicitTable)
+	if it != t {
+		return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, it, t)
+	}
+
+	return nil
 }
 
-func (sel *AggColSelector) substitute(params map[string]interface{}) (ValueExp, error) {
+func (sel *WindowFnSelector) substitute(params map[string]interface{}) (ValueExp, error) {
 	return sel, nil
 }
 
-func (sel *AggColSelector) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+func (sel *WindowFnSelector) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
 	if row == nil {
-		return nil, fmt.Errorf("%w: no row to evaluate aggregation (%s) in current context", ErrInvalidValue, sel.aggFn)
+		return nil, fmt.Errorf("%w: no row to evaluate window function (%s) in current context", ErrInvalidValue, sel.fn)
 	}
 
 	v, ok := row.ValuesBySelector[EncodeSelector(sel.resolve(implicitDB, implicitTable))]
 	if !ok {
-		return nil, fmt.Errorf("%w (%s)", ErrColumnDoesNotExist, sel.col)
+		return nil, fmt.Errorf("%w (%s)", ErrColumnDoesNotExist, sel.fn)
 	}
 	return v, nil
 }
 
-func (sel *AggColSelector) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+func (sel *WindowFnSelector) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
 	return sel
 }
 
-func (sel *AggColSelector) isConstant() bool {
+func (sel *WindowFnSelector) isConstant() bool {
 	return false
 }
 
-func (sel *AggColSelector) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+func (sel *WindowFnSelector) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
 	return nil
 }
 
@@ -2781,7 +4550,27 @@ type NumExp struct {
 }
 
 func (bexp *NumExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	err := bexp.left.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
+	tleft, err := bexp.left.inferType(cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, err
+	}
+
+	tright, err := bexp.right.inferType(cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, err
+	}
+
+	// operations involving DECIMAL values are evaluated as DECIMAL
+	if tleft == DecimalType || tright == DecimalType {
+		err = bexp.requiresType(DecimalType, cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return AnyType, err
+		}
+
+		return DecimalType, nil
+	}
+
+	err = bexp.left.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
 	if err != nil {
 		return AnyType, err
 	}
@@ -2795,6 +4584,27 @@ func (bexp *NumExp) inferType(cols map[string]ColDescriptor, params map[string]S
 }
 
 func (bexp *NumExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t == DecimalType {
+		// INTEGER operands are interpreted as DECIMAL values
+		for _, exp := range []ValueExp{bexp.left, bexp.right} {
+			texp, err := exp.inferType(cols, params, implicitDB, implicitTable)
+			if err != nil {
+				return err
+			}
+
+			if isNumericType(texp) {
+				continue
+			}
+
+			err = exp.requiresType(DecimalType, cols, params, implicitDB, implicitTable)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
 	if t != IntegerType {
 		return fmt.Errorf("%w: %v can not be interpreted as type %v", ErrInvalidTypes, IntegerType, t)
 	}
@@ -2842,12 +4652,12 @@ func (bexp *NumExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string
 
 	nl, isNumber := vl.Value().(int64)
 	if !isNumber {
-		return nil, fmt.Errorf("%w (expecting numeric value)", ErrInvalidValue)
+		return reduceDecimalExp(bexp.op, vl, vr)
 	}
 
 	nr, isNumber := vr.Value().(int64)
 	if !isNumber {
-		return nil, fmt.Errorf("%w (expecting numeric value)", ErrInvalidValue)
+		return reduceDecimalExp(bexp.op, vl, vr)
 	}
 
 	switch bexp.op {
@@ -3024,6 +4834,10 @@ func (bexp *LikeBoolExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable s
 		return nil, fmt.Errorf("error in 'LIKE' clause: %w (expecting %s)", ErrInvalidTypes, VarcharType)
 	}
 
+	if rval.IsNull() {
+		return &NullValue{t: BooleanType}, nil
+	}
+
 	rpattern, err := bexp.pattern.reduce(tx, row, implicitDB, implicitTable)
 	if err != nil {
 		return nil, fmt.Errorf("error in 'LIKE' clause: %w", err)
@@ -3071,7 +4885,7 @@ func (bexp *CmpBoolExp) inferType(cols map[string]ColDescriptor, params map[stri
 
 	// unification step
 
-	if tleft == tright {
+	if tleft == tright || (isNumericType(tleft) && isNumericType(tright)) {
 		return BooleanType, nil
 	}
 
@@ -3424,28 +5238,173 @@ func (bexp *BinBoolExp) selectorRanges(table *Table, asTable string, params map[
 	return nil
 }
 
+// resolveSubQuery resolves a subquery evaluated for an outer row. Selectors in the where clause
+// of the subquery which refer to the outer row are replaced by its values, while unqualified
+// selectors refer to the data source of the subquery
+func resolveSubQuery(tx *SQLTx, q DataSource, params map[string]interface{}, row *Row, implicitDB string) (RowReader, error) {
+	if tx == nil {
+		return nil, fmt.Errorf("%w: subqueries are resolved within a transaction", ErrIllegalArguments)
+	}
+
+	return correlatedSubQuery(q, row, implicitDB).Resolve(context.Background(), tx, params, nil)
+}
+
+func correlatedSubQuery(q DataSource, row *Row, implicitDB string) DataSource {
+	stmt, ok := q.(*SelectStmt)
+	if !ok || row == nil || stmt.where == nil {
+		return q
+	}
+
+	alias := stmt.ds.Alias()
+
+	// values of the outer row are hidden by the ones of the subquery data source,
+	// e.g. when the outer query reads from the same table
+	innerPrefix := "(" + implicitDB + "." + alias + "."
+
+	outerRow := &Row{ValuesBySelector: make(map[string]TypedValue, len(row.ValuesBySelector))}
+
+	for sel, v := range row.ValuesBySelector {
+		if !strings.HasPrefix(sel, innerPrefix) {
+			outerRow.ValuesBySelector[sel] = v
+		}
+	}
+
+	correlated := *stmt
+	correlated.where = stmt.where.reduceSelectors(outerRow, implicitDB, alias)
+
+	return &correlated
+}
+
+// subQueryColumn returns the single column projected by a subquery used as a value
+func subQueryColumn(reader RowReader) (ColDescriptor, error) {
+	cols, err := reader.Columns(context.Background())
+	if err != nil {
+		return ColDescriptor{}, err
+	}
+
+	if len(cols) != 1 {
+		return ColDescriptor{}, fmt.Errorf("%w: subquery must return a single column", ErrInvalidNumberOfValues)
+	}
+
+	return cols[0], nil
+}
+
+// subQueriesIn returns the subqueries used in the expression, nested subqueries excluded
+func subQueriesIn(exp ValueExp) []DataSource {
+	switch e := exp.(type) {
+	case *ExistsBoolExp:
+		{
+			return []DataSource{e.q}
+		}
+	case *ScalarSubQueryExp:
+		{
+			return []DataSource{e.q}
+		}
+	case *InSubQueryExp:
+		{
+			return append(subQueriesIn(e.val), e.q)
+		}
+	case *NumExp:
+		{
+			return append(subQueriesIn(e.left), subQueriesIn(e.right)...)
+		}
+	case *CmpBoolExp:
+		{
+			return append(subQueriesIn(e.left), subQueriesIn(e.right)...)
+		}
+	case *BinBoolExp:
+		{
+			return append(subQueriesIn(e.left), subQueriesIn(e.right)...)
+		}
+	case *NotBoolExp:
+		{
+			return subQueriesIn(e.exp)
+		}
+	case *LikeBoolExp:
+		{
+			return append(subQueriesIn(e.val), subQueriesIn(e.pattern)...)
+		}
+	case *Cast:
+		{
+			return subQueriesIn(e.val)
+		}
+	case *JSONExtractExp:
+		{
+			return append(subQueriesIn(e.val), subQueriesIn(e.path)...)
+		}
+	case *InListExp:
+		{
+			subQueries := subQueriesIn(e.val)
+			for _, v := range e.values {
+				subQueries = append(subQueries, subQueriesIn(v)...)
+			}
+			return subQueries
+		}
+	case *FnCall:
+		{
+			var subQueries []DataSource
+			for _, p := range e.params {
+				subQueries = append(subQueries, subQueriesIn(p)...)
+			}
+			return subQueries
+		}
+	case *WindowFnSelector:
+		{
+			var subQueries []DataSource
+			for _, p := range e.params {
+				subQueries = append(subQueries, subQueriesIn(p)...)
+			}
+			return subQueries
+		}
+	}
+
+	return nil
+}
+
 type ExistsBoolExp struct {
-	q *SelectStmt
+	q      DataSource
+	params map[string]interface{}
 }
 
 func (bexp *ExistsBoolExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	return AnyType, errors.New("not yet supported")
+	return BooleanType, nil
 }
 
 func (bexp *ExistsBoolExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	return errors.New("not yet supported")
+	if t != BooleanType {
+		return fmt.Errorf("error inferring type in 'EXISTS' clause: %w", ErrInvalidTypes)
+	}
+
+	return nil
 }
 
 func (bexp *ExistsBoolExp) substitute(params map[string]interface{}) (ValueExp, error) {
-	return bexp, nil
+	return &ExistsBoolExp{q: bexp.q, params: params}, nil
 }
 
 func (bexp *ExistsBoolExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	return nil, errors.New("not yet supported")
+	reader, err := resolveSubQuery(tx, bexp.q, bexp.params, row, implicitDB)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating 'EXISTS' clause: %w", err)
+	}
+	defer reader.Close()
+
+	_, err = reader.Read(context.Background())
+	if errors.Is(err, ErrNoMoreRows) {
+		return &Bool{val: false}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating 'EXISTS' clause: %w", err)
+	}
+
+	return &Bool{val: true}, nil
 }
 
 func (bexp *ExistsBoolExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return bexp
+	return &ExistsBoolExp{
+		q:      correlatedSubQuery(bexp.q, row, implicitDB),
+		params: bexp.params,
+	}
 }
 
 func (bexp *ExistsBoolExp) isConstant() bool {
@@ -3457,29 +5416,92 @@ func (bexp *ExistsBoolExp) selectorRanges(table *Table, asTable string, params m
 }
 
 type InSubQueryExp struct {
-	val   ValueExp
-	notIn bool
-	q     *SelectStmt
+	val    ValueExp
+	notIn  bool
+	q      DataSource
+	params map[string]interface{}
 }
 
 func (bexp *InSubQueryExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	return AnyType, fmt.Errorf("error inferring type in 'IN' clause: %w", ErrNoSupported)
+	_, err := bexp.val.inferType(cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, fmt.Errorf("error inferring type in 'IN' clause: %w", err)
+	}
+
+	return BooleanType, nil
 }
 
 func (bexp *InSubQueryExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	return fmt.Errorf("error inferring type in 'IN' clause: %w", ErrNoSupported)
+	_, err := bexp.inferType(cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return err
+	}
+
+	if t != BooleanType {
+		return fmt.Errorf("error inferring type in 'IN' clause: %w", ErrInvalidTypes)
+	}
+
+	return nil
 }
 
 func (bexp *InSubQueryExp) substitute(params map[string]interface{}) (ValueExp, error) {
-	return bexp, nil
+	val, err := bexp.val.substitute(params)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
+	}
+
+	return &InSubQueryExp{
+		val:    val,
+		notIn:  bexp.notIn,
+		q:      bexp.q,
+		params: params,
+	}, nil
 }
 
 func (bexp *InSubQueryExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	return nil, fmt.Errorf("error inferring type in 'IN' clause: %w", ErrNoSupported)
+	rval, err := bexp.val.reduce(tx, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
+	}
+
+	reader, err := resolveSubQuery(tx, bexp.q, bexp.params, row, implicitDB)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
+	}
+	defer reader.Close()
+
+	_, err = subQueryColumn(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
+	}
+
+	for {
+		r, err := reader.Read(context.Background())
+		if errors.Is(err, ErrNoMoreRows) {
+			return &Bool{val: bexp.notIn}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
+		}
+
+		cmp, err := rval.Compare(r.ValuesByPosition[0])
+		if err != nil {
+			return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
+		}
+
+		if cmp == 0 {
+			return &Bool{val: !bexp.notIn}, nil
+		}
+	}
 }
 
 func (bexp *InSubQueryExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return bexp
+	return &InSubQueryExp{
+		val:    bexp.val.reduceSelectors(row, implicitDB, implicitTable),
+		notIn:  bexp.notIn,
+		q:      correlatedSubQuery(bexp.q, row, implicitDB),
+		params: bexp.params,
+	}
 }
 
 func (bexp *InSubQueryExp) isConstant() bool {
@@ -3490,7 +5512,72 @@ func (bexp *InSubQueryExp) selectorRanges(table *Table, asTable string, params m
 	return nil
 }
 
-// TODO: once InSubQueryExp is supported, this struct may become obsolete by creating a ListDataSource struct
+// ScalarSubQueryExp is a subquery used as a value, it must return a single column and at most one row.
+// Its value is null when no row is returned
+type ScalarSubQueryExp struct {
+	q      DataSource
+	params map[string]interface{}
+}
+
+func (exp *ScalarSubQueryExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	// the type of the returned column is only known once the subquery is resolved
+	return AnyType, nil
+}
+
+func (exp *ScalarSubQueryExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	return nil
+}
+
+func (exp *ScalarSubQueryExp) substitute(params map[string]interface{}) (ValueExp, error) {
+	return &ScalarSubQueryExp{q: exp.q, params: params}, nil
+}
+
+func (exp *ScalarSubQueryExp) reduce(tx *SQLTx, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	reader, err := resolveSubQuery(tx, exp.q, exp.params, row, implicitDB)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating subquery: %w", err)
+	}
+	defer reader.Close()
+
+	col, err := subQueryColumn(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating subquery: %w", err)
+	}
+
+	r, err := reader.Read(context.Background())
+	if errors.Is(err, ErrNoMoreRows) {
+		return &NullValue{t: col.Type}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error evaluating subquery: %w", err)
+	}
+
+	_, err = reader.Read(context.Background())
+	if err == nil {
+		return nil, fmt.Errorf("error evaluating subquery: %w: more than one row returned", ErrTooManyRows)
+	}
+	if !errors.Is(err, ErrNoMoreRows) {
+		return nil, fmt.Errorf("error evaluating subquery: %w", err)
+	}
+
+	return r.ValuesByPosition[0], nil
+}
+
+func (exp *ScalarSubQueryExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return &ScalarSubQueryExp{
+		q:      correlatedSubQuery(exp.q, row, implicitDB),
+		params: exp.params,
+	}
+}
+
+func (exp *ScalarSubQueryExp) isConstant() bool {
+	return false
+}
+
+func (exp *ScalarSubQueryExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
 type InListExp struct {
 	val    ValueExp
 	notIn  bool
@@ -3585,6 +5672,7 @@ func (bexp *InListExp) reduceSelectors(row *Row, implicitDB, implicitTable strin
 
 	return &InListExp{
 		val:    bexp.val.reduceSelectors(row, implicitDB, implicitTable),
+		notIn:  bexp.notIn,
 		values: values,
 	}
 }
@@ -578,21 +578,27 @@ func TestRequiresTypeBinValueExp(t *testing.T) {
 	}
 }
 
-func TestYetUnsupportedExistsBoolExp(t *testing.T) {
+func TestExistsBoolExpEdgeCases(t *testing.T) {
 	exp := &ExistsBoolExp{}
 
-	_, err := exp.inferType(nil, nil, "", "")
-	require.Error(t, err)
+	it, err := exp.inferType(nil, nil, "", "")
+	require.NoError(t, err)
+	require.Equal(t, BooleanType, it)
 
 	err = exp.requiresType(BooleanType, nil, nil, "", "")
-	require.Error(t, err)
+	require.NoError(t, err)
+
+	err = exp.requiresType(IntegerType, nil, nil, "", "")
+	require.ErrorIs(t, err, ErrInvalidTypes)
 
-	rexp, err := exp.substitute(nil)
+	params := map[string]interface{}{"p": 1}
+
+	rexp, err := exp.substitute(params)
 	require.NoError(t, err)
-	require.Equal(t, exp, rexp)
+	require.Equal(t, &ExistsBoolExp{params: params}, rexp)
 
 	_, err = exp.reduce(nil, nil, "", "")
-	require.Error(t, err)
+	require.ErrorIs(t, err, ErrIllegalArguments)
 
 	require.Equal(t, exp, exp.reduceSelectors(nil, "", ""))
 
@@ -601,21 +607,56 @@ func TestYetUnsupportedExistsBoolExp(t *testing.T) {
 	require.Nil(t, exp.selectorRanges(nil, "", nil, nil))
 }
 
-func TestYetUnsupportedInSubQueryExp(t *testing.T) {
-	exp := &InSubQueryExp{}
+func TestInSubQueryExpEdgeCases(t *testing.T) {
+	exp := &InSubQueryExp{val: &Number{val: 1}}
 
-	_, err := exp.inferType(nil, nil, "", "")
-	require.ErrorIs(t, err, ErrNoSupported)
+	it, err := exp.inferType(nil, nil, "", "")
+	require.NoError(t, err)
+	require.Equal(t, BooleanType, it)
 
 	err = exp.requiresType(BooleanType, nil, nil, "", "")
-	require.ErrorIs(t, err, ErrNoSupported)
+	require.NoError(t, err)
+
+	err = exp.requiresType(IntegerType, nil, nil, "", "")
+	require.ErrorIs(t, err, ErrInvalidTypes)
+
+	_, err = (&InSubQueryExp{val: &Param{id: "p"}}).substitute(nil)
+	require.ErrorIs(t, err, ErrMissingParameter)
 
-	rexp, err := exp.substitute(nil)
+	params := map[string]interface{}{"p": 1}
+
+	rexp, err := exp.substitute(params)
 	require.NoError(t, err)
-	require.Equal(t, exp, rexp)
+	require.Equal(t, &InSubQueryExp{val: &Number{val: 1}, params: params}, rexp)
 
 	_, err = exp.reduce(nil, nil, "", "")
-	require.ErrorIs(t, err, ErrNoSupported)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	require.Equal(t, exp, exp.reduceSelectors(nil, "", ""))
+
+	require.False(t, exp.isConstant())
+
+	require.Nil(t, exp.selectorRanges(nil, "", nil, nil))
+}
+
+func TestScalarSubQueryExpEdgeCases(t *testing.T) {
+	exp := &ScalarSubQueryExp{}
+
+	it, err := exp.inferType(nil, nil, "", "")
+	require.NoError(t, err)
+	require.Equal(t, AnyType, it)
+
+	err = exp.requiresType(IntegerType, nil, nil, "", "")
+	require.NoError(t, err)
+
+	params := map[string]interface{}{"p": 1}
+
+	rexp, err := exp.substitute(params)
+	require.NoError(t, err)
+	require.Equal(t, &ScalarSubQueryExp{params: params}, rexp)
+
+	_, err = exp.reduce(nil, nil, "", "")
+	require.ErrorIs(t, err, ErrIllegalArguments)
 
 	require.Equal(t, exp, exp.reduceSelectors(nil, "", ""))
 
@@ -0,0 +1,380 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+// windowRowReader evaluates window functions over the rows of the underlying reader.
+// All the rows are read in advance, then returned in the same order they were read
+type windowRowReader struct {
+	rowReader RowReader
+
+	windowFns []*WindowFnSelector
+
+	evaluated bool
+	rows      []*Row
+}
+
+type windowEntry struct {
+	row         *Row
+	partitionBy []TypedValue
+	orderBy     []TypedValue
+}
+
+func newWindowRowReader(rowReader RowReader, windowFns []*WindowFnSelector) (*windowRowReader, error) {
+	if rowReader == nil || len(windowFns) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	for _, wfn := range windowFns {
+		err := wfn.validate()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &windowRowReader{
+		rowReader: rowReader,
+		windowFns: windowFns,
+	}, nil
+}
+
+func (wr *windowRowReader) onClose(callback func()) {
+	wr.rowReader.onClose(callback)
+}
+
+func (wr *windowRowReader) Tx() *SQLTx {
+	return wr.rowReader.Tx()
+}
+
+func (wr *windowRowReader) Database() string {
+	return wr.rowReader.Database()
+}
+
+func (wr *windowRowReader) TableAlias() string {
+	return wr.rowReader.TableAlias()
+}
+
+func (wr *windowRowReader) Parameters() map[string]interface{} {
+	return wr.rowReader.Parameters()
+}
+
+func (wr *windowRowReader) SetParameters(params map[string]interface{}) error {
+	return wr.rowReader.SetParameters(params)
+}
+
+func (wr *windowRowReader) OrderBy() []ColDescriptor {
+	return wr.rowReader.OrderBy()
+}
+
+func (wr *windowRowReader) ScanSpecs() *ScanSpecs {
+	return wr.rowReader.ScanSpecs()
+}
+
+func (wr *windowRowReader) Columns(ctx context.Context) ([]ColDescriptor, error) {
+	return wr.rowReader.Columns(ctx)
+}
+
+func (wr *windowRowReader) colsBySelector(ctx context.Context) (map[string]ColDescriptor, error) {
+	colDescriptors, err := wr.rowReader.colsBySelector(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := make(map[string]SQLValueType)
+
+	for _, wfn := range wr.windowFns {
+		t, err := wfn.inferType(colDescriptors, params, wr.rowReader.Database(), wr.rowReader.TableAlias())
+		if err != nil {
+			return nil, err
+		}
+
+		aggFn, db, table, col := wfn.resolve(wr.rowReader.Database(), wr.rowReader.TableAlias())
+
+		des := ColDescriptor{
+			AggFn:    aggFn,
+			Database: db,
+			Table:    table,
+			Column:   col,
+			Type:     t,
+		}
+
+		colDescriptors[des.Selector()] = des
+	}
+
+	return colDescriptors, nil
+}
+
+func (wr *windowRowReader) InferParameters(ctx context.Context, params map[string]SQLValueType) error {
+	err := wr.rowReader.InferParameters(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	cols, err := wr.rowReader.colsBySelector(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, wfn := range wr.windowFns {
+		_, err = wfn.inferType(cols, params, wr.rowReader.Database(), wr.rowReader.TableAlias())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (wr *windowRowReader) Read(ctx context.Context) (*Row, error) {
+	if !wr.evaluated {
+		err := wr.evalWindowFns(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		wr.evaluated = true
+	}
+
+	if len(wr.rows) == 0 {
+		return nil, store.ErrNoMoreEntries
+	}
+
+	row := wr.rows[0]
+	wr.rows[0] = nil
+	wr.rows = wr.rows[1:]
+
+	return row, nil
+}
+
+func (wr *windowRowReader) evalWindowFns(ctx context.Context) error {
+	for {
+		row, err := wr.rowReader.Read(ctx)
+		if err == store.ErrNoMoreEntries {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(wr.rows) == wr.Tx().distinctLimit() {
+			return ErrTooManyRows
+		}
+
+		wr.rows = append(wr.rows, row)
+	}
+
+	cols, err := wr.colsBySelector(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, wfn := range wr.windowFns {
+		err := wr.evalWindowFn(wfn, cols)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// evalWindowFn sorts the rows by partition and ordering values,
+// then evaluates the window function over each partition
+func (wr *windowRowReader) evalWindowFn(wfn *WindowFnSelector, cols map[string]ColDescriptor) error {
+	entries := make([]*windowEntry, len(wr.rows))
+
+	for i, row := range wr.rows {
+		entry := &windowEntry{
+			row:         row,
+			partitionBy: make([]TypedValue, len(wfn.partitionBy)),
+			orderBy:     make([]TypedValue, len(wfn.orderBy)),
+		}
+
+		for j, sel := range wfn.partitionBy {
+			v, err := sel.reduce(wr.Tx(), row, wr.rowReader.Database(), wr.rowReader.TableAlias())
+			if err != nil {
+				return err
+			}
+			entry.partitionBy[j] = v
+		}
+
+		for j, col := range wfn.orderBy {
+			v, err := col.sel.reduce(wr.Tx(), row, wr.rowReader.Database(), wr.rowReader.TableAlias())
+			if err != nil {
+				return err
+			}
+			entry.orderBy[j] = v
+		}
+
+		entries[i] = entry
+	}
+
+	var cmpErr error
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		cmp, err := compareValues(entries[i].partitionBy, entries[j].partitionBy, nil)
+		if err == nil && cmp == 0 {
+			cmp, err = compareValues(entries[i].orderBy, entries[j].orderBy, wfn.orderBy)
+		}
+		if err != nil && cmpErr == nil {
+			cmpErr = err
+		}
+		return cmp < 0
+	})
+	if cmpErr != nil {
+		return cmpErr
+	}
+
+	aggFn, db, table, col := wfn.resolve(wr.rowReader.Database(), wr.rowReader.TableAlias())
+	encSel := EncodeSelector(aggFn, db, table, col)
+
+	for start := 0; start < len(entries); {
+		end := start + 1
+
+		for ; end < len(entries); end++ {
+			cmp, err := compareValues(entries[start].partitionBy, entries[end].partitionBy, nil)
+			if err != nil {
+				return err
+			}
+			if cmp != 0 {
+				break
+			}
+		}
+
+		partition := entries[start:end]
+
+		for i, entry := range partition {
+			v, err := wr.evalWindowFnAt(wfn, partition, i, cols[encSel].Type)
+			if err != nil {
+				return err
+			}
+
+			entry.row.ValuesByPosition = append(entry.row.ValuesByPosition, v)
+			entry.row.ValuesBySelector[encSel] = v
+		}
+
+		start = end
+	}
+
+	return nil
+}
+
+func (wr *windowRowReader) evalWindowFnAt(wfn *WindowFnSelector, partition []*windowEntry, i int, t SQLValueType) (TypedValue, error) {
+	fn := strings.ToUpper(wfn.fn)
+
+	switch fn {
+	case RowNumberFnCall:
+		{
+			return &Number{val: int64(i + 1)}, nil
+		}
+	case RankFnCall:
+		{
+			// rows with the same ordering values share the rank of the first of them
+			rank := i
+			for ; rank > 0; rank-- {
+				cmp, err := compareValues(partition[rank-1].orderBy, partition[i].orderBy, nil)
+				if err != nil {
+					return nil, err
+				}
+				if cmp != 0 {
+					break
+				}
+			}
+
+			return &Number{val: int64(rank + 1)}, nil
+		}
+	}
+
+	// LAG and LEAD
+	params := make([]ValueExp, len(wfn.params))
+
+	for j, p := range wfn.params {
+		sp, err := p.substitute(wr.Parameters())
+		if err != nil {
+			return nil, err
+		}
+		params[j] = sp
+	}
+
+	row := partition[i].row
+
+	offset := int64(1)
+
+	if len(params) > 1 {
+		v, err := params[1].reduce(wr.Tx(), row, wr.rowReader.Database(), wr.rowReader.TableAlias())
+		if err != nil {
+			return nil, err
+		}
+
+		n, ok := v.(*Number)
+		if !ok || n.val < 0 {
+			return nil, fmt.Errorf("%w: '%s' function expects a non-negative offset", ErrIllegalArguments, fn)
+		}
+
+		offset = n.val
+	}
+
+	if fn == LagFnCall {
+		offset = -offset
+	}
+
+	j := int64(i) + offset
+
+	if j < 0 || j >= int64(len(partition)) {
+		if len(params) > 2 {
+			return params[2].reduce(wr.Tx(), row, wr.rowReader.Database(), wr.rowReader.TableAlias())
+		}
+
+		return &NullValue{t: t}, nil
+	}
+
+	return params[0].reduce(wr.Tx(), partition[j].row, wr.rowReader.Database(), wr.rowReader.TableAlias())
+}
+
+// compareValues compares values in order, ordCols may be provided to reverse descending comparisons
+func compareValues(vals1, vals2 []TypedValue, ordCols []*OrdCol) (int, error) {
+	for i := range vals1 {
+		cmp, err := vals1[i].Compare(vals2[i])
+		if err != nil {
+			return 0, err
+		}
+
+		if cmp == 0 {
+			continue
+		}
+
+		if ordCols != nil && ordCols[i].descOrder {
+			return -cmp, nil
+		}
+
+		return cmp, nil
+	}
+
+	return 0, nil
+}
+
+func (wr *windowRowReader) Close() error {
+	return wr.rowReader.Close()
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindowRowReader(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.DefaultOptions())
+	require.NoError(t, err)
+
+	engine, err := NewEngine(st, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = newWindowRowReader(nil, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	tx, err := engine.NewTx(context.Background(), DefaultTxOptions())
+	require.NoError(t, err)
+
+	db, err := tx.catalog.newDatabase(1, "db1")
+	require.NoError(t, err)
+
+	table, err := db.newTable("table1", []*ColSpec{{colName: "id", colType: IntegerType}})
+	require.NoError(t, err)
+
+	_, err = table.newIndex(true, []uint32{1}, "", nil)
+	require.NoError(t, err)
+
+	r, err := newRawRowReader(tx, nil, table, period{}, "", &ScanSpecs{Index: table.primaryIndex})
+	require.NoError(t, err)
+
+	_, err = newWindowRowReader(r, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = newWindowRowReader(r, []*WindowFnSelector{{fn: "ntile"}})
+	require.ErrorIs(t, err, ErrFunctionDoesNotExist)
+
+	wr, err := newWindowRowReader(r, []*WindowFnSelector{{fn: "rank", orderBy: []*OrdCol{{sel: &ColSelector{col: "id"}}}}})
+	require.NoError(t, err)
+
+	require.Equal(t, "db1", wr.Database())
+	require.Equal(t, "table1", wr.TableAlias())
+	require.Len(t, wr.OrderBy(), 1)
+	require.Equal(t, table.primaryIndex, wr.ScanSpecs().Index)
+
+	colsBySel, err := wr.colsBySelector(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, IntegerType, colsBySel["RANK(db1.table1.col0)"].Type)
+
+	_, err = wr.Read(context.Background())
+	require.ErrorIs(t, err, store.ErrNoMoreEntries)
+
+	err = wr.Close()
+	require.NoError(t, err)
+}
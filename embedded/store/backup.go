@@ -0,0 +1,290 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/codenotary/immudb/embedded/hashing"
+)
+
+const txRangeStreamMagic = "IMMUTXRS"
+const txRangeStreamVersion = 2
+
+// ExportTxRange writes transactions fromTx to toTx (both included) into w.
+// Every chunk of the stream is followed by a checksum of the whole content written
+// up to it, so each one is verified together with everything preceding it before
+// being used on import. Transactions are replicated on import, thus the resulting
+// state is verified against the headers included in the stream.
+//
+// Stream layout:
+//
+//	magic | version(1) | hashAlg(1) | fromTx(8) | toTx(8) | sha256(stream)
+//	{ len(4) | exportedTx | sha256(stream) }*
+//	0(4) | sha256(stream)
+func (s *ImmuStore) ExportTxRange(fromTx, toTx uint64, w io.Writer) error {
+	if fromTx == 0 || fromTx > toTx || w == nil {
+		return ErrIllegalArguments
+	}
+
+	if toTx > s.LastCommittedTxID() {
+		return fmt.Errorf("%w: tx %d is not yet committed", ErrTxNotFound, toTx)
+	}
+
+	tx, err := s.fetchAllocTx()
+	if err != nil {
+		return err
+	}
+	defer s.releaseAllocTx(tx)
+
+	digest := sha256.New()
+	dw := io.MultiWriter(w, digest)
+
+	writeChecksum := func() error {
+		_, err := w.Write(digest.Sum(nil))
+		return err
+	}
+
+	var hdr [len(txRangeStreamMagic) + 2 + 2*txIDSize]byte
+
+	i := copy(hdr[:], txRangeStreamMagic)
+	hdr[i] = txRangeStreamVersion
+	hdr[i+1] = byte(s.txHashAlg)
+	binary.BigEndian.PutUint64(hdr[i+2:], fromTx)
+	binary.BigEndian.PutUint64(hdr[i+2+txIDSize:], toTx)
+
+	_, err = dw.Write(hdr[:])
+	if err != nil {
+		return err
+	}
+
+	err = writeChecksum()
+	if err != nil {
+		return err
+	}
+
+	var lenBs [lszSize]byte
+
+	for txID := fromTx; txID <= toTx; txID++ {
+		etx, err := s.ExportTx(txID, false, tx)
+		if err != nil {
+			return err
+		}
+
+		binary.BigEndian.PutUint32(lenBs[:], uint32(len(etx)))
+
+		_, err = dw.Write(lenBs[:])
+		if err != nil {
+			return err
+		}
+
+		_, err = dw.Write(etx)
+		if err != nil {
+			return err
+		}
+
+		err = writeChecksum()
+		if err != nil {
+			return err
+		}
+	}
+
+	binary.BigEndian.PutUint32(lenBs[:], 0)
+
+	_, err = dw.Write(lenBs[:])
+	if err != nil {
+		return err
+	}
+
+	return writeChecksum()
+}
+
+// ImportTxRange replicates the transactions of a stream produced by ExportTxRange.
+// The stream must start right after the last committed transaction. As transactions
+// are replicated while the stream is read, a stream found corrupted after some of
+// them were imported leaves those transactions in place, importing can be resumed
+// with a stream starting after the last one.
+func (s *ImmuStore) ImportTxRange(ctx context.Context, r io.Reader) (*TxHeader, error) {
+	return s.ImportTxRangeUntil(ctx, r, nil)
+}
+
+// ImportTxRangeUntil works as ImportTxRange but stops right before the first transaction
+// for which stop returns true, e.g. to restore the state as of a given point in time.
+// The rest of the stream is not read
+func (s *ImmuStore) ImportTxRangeUntil(ctx context.Context, r io.Reader, stop func(hdr *TxHeader) bool) (*TxHeader, error) {
+	if r == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	digest := sha256.New()
+	dr := io.TeeReader(r, digest)
+
+	// verifyChecksum checks the content read so far against the checksum following it
+	verifyChecksum := func() error {
+		var checksum [sha256.Size]byte
+
+		_, err := io.ReadFull(r, checksum[:])
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrCorruptedTxRangeStream, err)
+		}
+
+		if !bytes.Equal(checksum[:], digest.Sum(nil)) {
+			return fmt.Errorf("%w: checksum mismatch", ErrCorruptedTxRangeStream)
+		}
+
+		return nil
+	}
+
+	var hdr [len(txRangeStreamMagic) + 2 + 2*txIDSize]byte
+
+	_, err := io.ReadFull(dr, hdr[:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptedTxRangeStream, err)
+	}
+
+	i := len(txRangeStreamMagic)
+
+	if string(hdr[:i]) != txRangeStreamMagic || hdr[i] != txRangeStreamVersion {
+		return nil, fmt.Errorf("%w: unknown format", ErrCorruptedTxRangeStream)
+	}
+
+	err = verifyChecksum()
+	if err != nil {
+		return nil, err
+	}
+
+	txHashAlg := hashing.Algorithm(hdr[i+1])
+	if txHashAlg != s.txHashAlg {
+		return nil, fmt.Errorf("%w: transactions built with '%s' can not be imported into a store using '%s'",
+			ErrTxHashAlgorithmMismatch, txHashAlg, s.txHashAlg)
+	}
+
+	fromTx := binary.BigEndian.Uint64(hdr[i+2:])
+	toTx := binary.BigEndian.Uint64(hdr[i+2+txIDSize:])
+
+	if fromTx == 0 || fromTx > toTx {
+		return nil, fmt.Errorf("%w: invalid tx range", ErrCorruptedTxRangeStream)
+	}
+
+	lastTxID := s.LastCommittedTxID()
+	if fromTx != lastTxID+1 {
+		return nil, fmt.Errorf("%w: stream starts at tx %d while the last committed tx is %d", ErrIllegalState, fromTx, lastTxID)
+	}
+
+	var lastHdr *TxHeader
+	var lenBs [lszSize]byte
+
+	maxTxSize := s.maxExportedTxSize()
+
+	for txID := fromTx; ; txID++ {
+		_, err = io.ReadFull(dr, lenBs[:])
+		if err != nil {
+			return lastHdr, fmt.Errorf("%w: %v", ErrCorruptedTxRangeStream, err)
+		}
+
+		etxLen := binary.BigEndian.Uint32(lenBs[:])
+
+		if etxLen == 0 {
+			err = verifyChecksum()
+			if err != nil {
+				return lastHdr, err
+			}
+
+			if txID != toTx+1 {
+				return lastHdr, fmt.Errorf("%w: missing transactions", ErrCorruptedTxRangeStream)
+			}
+
+			break
+		}
+
+		if txID > toTx || uint64(etxLen) > uint64(maxTxSize) {
+			return lastHdr, fmt.Errorf("%w: unexpected transaction data", ErrCorruptedTxRangeStream)
+		}
+
+		// the buffer grows as data is read, the declared length is not trusted
+		// until the checksum following the transaction is verified
+		var etxBuf bytes.Buffer
+
+		_, err = io.CopyN(&etxBuf, dr, int64(etxLen))
+		if err != nil {
+			return lastHdr, fmt.Errorf("%w: %v", ErrCorruptedTxRangeStream, err)
+		}
+
+		err = verifyChecksum()
+		if err != nil {
+			return lastHdr, fmt.Errorf("%w at tx %d", err, txID)
+		}
+
+		etx := etxBuf.Bytes()
+
+		if stop != nil {
+			hdr, err := exportedTxHeader(etx)
+			if err != nil {
+				return lastHdr, fmt.Errorf("%w: %v", ErrCorruptedTxRangeStream, err)
+			}
+
+			if stop(hdr) {
+				return lastHdr, nil
+			}
+		}
+
+		hdr, err := s.ReplicateTx(ctx, etx, false)
+		if err != nil {
+			return lastHdr, err
+		}
+
+		lastHdr = hdr
+	}
+
+	return lastHdr, nil
+}
+
+// exportedTxHeader decodes the header of a transaction produced by ExportTx
+func exportedTxHeader(etx []byte) (*TxHeader, error) {
+	if len(etx) < lszSize {
+		return nil, ErrIllegalArguments
+	}
+
+	hdrLen := int(binary.BigEndian.Uint32(etx))
+
+	if len(etx) < lszSize+hdrLen {
+		return nil, ErrIllegalArguments
+	}
+
+	hdr := &TxHeader{}
+
+	err := hdr.ReadFrom(etx[lszSize : lszSize+hdrLen])
+	if err != nil {
+		return nil, err
+	}
+
+	return hdr, nil
+}
+
+// maxExportedTxSize is an upper bound of the size of transactions produced by ExportTx
+func (s *ImmuStore) maxExportedTxSize() int {
+	return lszSize /*hdrLen*/ +
+		maxTxSize(s.maxTxEntries, s.maxKeyLen, maxTxMetadataLen, maxKVMetadataLen) +
+		s.maxTxEntries*s.maxValueLen +
+		sszSize + 1 /*truncation*/ +
+		sszSize + 1 /*hashAlg*/
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportAndImportTxRange(t *testing.T) {
+	primaryStore, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, primaryStore)
+
+	for i := 0; i < 10; i++ {
+		tx, err := primaryStore.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	replicaStore, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, replicaStore)
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		err := primaryStore.ExportTxRange(0, 1, &buf)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		err = primaryStore.ExportTxRange(2, 1, &buf)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		err = primaryStore.ExportTxRange(1, 1, nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		err = primaryStore.ExportTxRange(1, 11, &buf)
+		require.ErrorIs(t, err, ErrTxNotFound)
+
+		_, err = replicaStore.ImportTxRange(context.Background(), nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = replicaStore.ImportTxRange(context.Background(), bytes.NewReader([]byte("invalid")))
+		require.ErrorIs(t, err, ErrCorruptedTxRangeStream)
+	})
+
+	var buf bytes.Buffer
+
+	err = primaryStore.ExportTxRange(1, 5, &buf)
+	require.NoError(t, err)
+
+	t.Run("corrupted streams", func(t *testing.T) {
+		st, err := Open(t.TempDir(), DefaultOptions())
+		require.NoError(t, err)
+		defer immustoreClose(t, st)
+
+		bs := buf.Bytes()
+
+		_, err = st.ImportTxRange(context.Background(), bytes.NewReader(bs[:len(bs)-1]))
+		require.ErrorIs(t, err, ErrCorruptedTxRangeStream)
+
+		err = st.Close()
+		require.NoError(t, err)
+
+		st, err = Open(t.TempDir(), DefaultOptions())
+		require.NoError(t, err)
+		defer immustoreClose(t, st)
+
+		// last byte of the last exported tx
+		corrupted := make([]byte, len(bs))
+		copy(corrupted, bs)
+		corrupted[len(corrupted)-sha256.Size-lszSize-sha256.Size-1] ^= 1
+
+		// transactions preceding the corrupted one are imported
+		_, err = st.ImportTxRange(context.Background(), bytes.NewReader(corrupted))
+		require.ErrorIs(t, err, ErrCorruptedTxRangeStream)
+		require.Equal(t, uint64(4), st.LastCommittedTxID())
+	})
+
+	t.Run("corrupted stream headers should be rejected before importing", func(t *testing.T) {
+		st, err := Open(t.TempDir(), DefaultOptions())
+		require.NoError(t, err)
+		defer immustoreClose(t, st)
+
+		bs := buf.Bytes()
+		hdrLen := len(txRangeStreamMagic) + 2 + 2*txIDSize
+
+		// toTx is the last field of the header
+		corrupted := make([]byte, len(bs))
+		copy(corrupted, bs)
+		corrupted[hdrLen-1] ^= 1
+
+		_, err = st.ImportTxRange(context.Background(), bytes.NewReader(corrupted))
+		require.ErrorIs(t, err, ErrCorruptedTxRangeStream)
+		require.Zero(t, st.LastCommittedTxID())
+
+		// declared lengths are bounded before reading the transaction
+		corrupted = make([]byte, hdrLen+sha256.Size+lszSize)
+		copy(corrupted, bs)
+		binary.BigEndian.PutUint32(corrupted[hdrLen+sha256.Size:], math.MaxUint32)
+
+		_, err = st.ImportTxRange(context.Background(), bytes.NewReader(corrupted))
+		require.ErrorIs(t, err, ErrCorruptedTxRangeStream)
+
+		// streams shorter than the declared length
+		binary.BigEndian.PutUint32(corrupted[hdrLen+sha256.Size:], uint32(st.maxExportedTxSize()))
+
+		_, err = st.ImportTxRange(context.Background(), bytes.NewReader(corrupted))
+		require.ErrorIs(t, err, ErrCorruptedTxRangeStream)
+		require.Zero(t, st.LastCommittedTxID())
+	})
+
+	hdr, err := replicaStore.ImportTxRange(context.Background(), bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), hdr.ID)
+
+	// the same range can not be imported twice
+	_, err = replicaStore.ImportTxRange(context.Background(), bytes.NewReader(buf.Bytes()))
+	require.ErrorIs(t, err, ErrIllegalState)
+
+	// incremental backup
+	buf.Reset()
+
+	err = primaryStore.ExportTxRange(6, 10, &buf)
+	require.NoError(t, err)
+
+	hdr, err = replicaStore.ImportTxRange(context.Background(), &buf)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), hdr.ID)
+
+	t.Run("importing should stop right before the requested transaction", func(t *testing.T) {
+		st, err := Open(t.TempDir(), DefaultOptions())
+		require.NoError(t, err)
+		defer immustoreClose(t, st)
+
+		var buf bytes.Buffer
+
+		err = primaryStore.ExportTxRange(1, 10, &buf)
+		require.NoError(t, err)
+
+		hdr, err := st.ImportTxRangeUntil(context.Background(), &buf, func(hdr *TxHeader) bool {
+			return hdr.ID > 7
+		})
+		require.NoError(t, err)
+		require.Equal(t, uint64(7), hdr.ID)
+		require.Equal(t, uint64(7), st.LastCommittedTxID())
+
+		primaryHdr, err := primaryStore.ReadTxHeader(7, false)
+		require.NoError(t, err)
+		require.Equal(t, primaryHdr.Alh(), hdr.Alh())
+
+		buf.Reset()
+
+		err = primaryStore.ExportTxRange(1, 10, &buf)
+		require.NoError(t, err)
+
+		// nothing is imported when stopping at the first transaction
+		st1, err := Open(t.TempDir(), DefaultOptions())
+		require.NoError(t, err)
+		defer immustoreClose(t, st1)
+
+		hdr, err = st1.ImportTxRangeUntil(context.Background(), &buf, func(hdr *TxHeader) bool { return true })
+		require.NoError(t, err)
+		require.Nil(t, hdr)
+		require.Zero(t, st1.LastCommittedTxID())
+	})
+
+	primaryTxID, primaryAlh := primaryStore.CommittedAlh()
+	replicaTxID, replicaAlh := replicaStore.CommittedAlh()
+	require.Equal(t, primaryTxID, replicaTxID)
+	require.Equal(t, primaryAlh, replicaAlh)
+
+	err = replicaStore.WaitForIndexingUpto(context.Background(), 10)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		valRef, err := replicaStore.Get([]byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type expiredKey struct {
+	key  []byte
+	txID uint64
+}
+
+// ReapExpiredKeys logically deletes the keys whose current value has expired,
+// so they are handled as any other deleted key from then on.
+// Keys updated after being found expired are kept, as their deletion would be
+// rejected by a KeyNotModifiedAfterTx precondition.
+func (s *ImmuStore) ReapExpiredKeys(ctx context.Context) (reaped int, err error) {
+	var seekKey []byte
+
+	for {
+		expired, done, err := s.expiredKeys(seekKey, s.maxTxEntries)
+		if err != nil {
+			return reaped, err
+		}
+
+		if len(expired) > 0 {
+			err = s.deleteExpiredKeys(ctx, expired)
+			if errors.Is(err, ErrPreconditionFailed) {
+				s.logger.Infof("Expired keys were updated while being reaped at '%s', they'll be evaluated in a later run", s.path)
+			} else if err != nil {
+				return reaped, err
+			} else {
+				reaped += len(expired)
+			}
+
+			seekKey = expired[len(expired)-1].key
+		}
+
+		if done {
+			return reaped, nil
+		}
+	}
+}
+
+// expiredKeys returns up to limit expired keys located after seekKey. done is set when
+// there are no more keys to be scanned
+func (s *ImmuStore) expiredKeys(seekKey []byte, limit int) (expired []expiredKey, done bool, err error) {
+	snap, err := s.Snapshot()
+	if err != nil {
+		return nil, false, err
+	}
+	defer snap.Close()
+
+	r, err := snap.NewKeyReader(KeyReaderSpec{
+		SeekKey: seekKey,
+		Filters: []FilterFn{IgnoreDeleted},
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	defer r.Close()
+
+	now := s.timeFunc()
+
+	for len(expired) < limit {
+		key, valRef, err := r.Read()
+		if errors.Is(err, ErrNoMoreEntries) {
+			return expired, true, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		md := valRef.KVMetadata()
+		if md != nil && md.ExpiredAt(now) {
+			expired = append(expired, expiredKey{key: cp(key), txID: valRef.Tx()})
+		}
+	}
+
+	return expired, false, nil
+}
+
+func (s *ImmuStore) deleteExpiredKeys(ctx context.Context, expired []expiredKey) error {
+	tx, err := s.NewWriteOnlyTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Cancel()
+
+	for _, e := range expired {
+		md := NewKVMetadata()
+		md.AsDeleted(true)
+
+		err = tx.Set(e.key, md, nil)
+		if err != nil {
+			return err
+		}
+
+		err = tx.AddPrecondition(&PreconditionKeyNotModifiedAfterTx{Key: e.key, TxID: e.txID})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Commit(ctx)
+	return err
+}
+
+func (s *ImmuStore) reapExpiredKeysPeriodically(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		reaped, err := s.ReapExpiredKeys(context.Background())
+		if errors.Is(err, ErrAlreadyClosed) {
+			return
+		}
+		if err != nil {
+			s.notify(Error, true, "%s: while reaping expired keys at '%s'", err, s.path)
+			continue
+		}
+
+		if reaped > 0 {
+			s.logger.Infof("%d expired keys were deleted at '%s'", reaped, s.path)
+		}
+	}
+}
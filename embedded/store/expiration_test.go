@@ -0,0 +1,130 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func setWithExpiration(t *testing.T, st *ImmuStore, key []byte, expiresAt time.Time) {
+	tx, err := st.NewWriteOnlyTx(context.Background())
+	require.NoError(t, err)
+
+	md := NewKVMetadata()
+
+	err = md.ExpiresAt(expiresAt)
+	require.NoError(t, err)
+
+	err = tx.Set(key, md, []byte("value"))
+	require.NoError(t, err)
+
+	_, err = tx.Commit(context.Background())
+	require.NoError(t, err)
+}
+
+func TestReapExpiredKeys(t *testing.T) {
+	st, err := Open(t.TempDir(), DefaultOptions().WithMaxTxEntries(2))
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	for i := 0; i < 5; i++ {
+		setWithExpiration(t, st, []byte(fmt.Sprintf("expired%d", i)), past)
+	}
+
+	setWithExpiration(t, st, []byte("alive"), future)
+
+	tx, err := st.NewWriteOnlyTx(context.Background())
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("non-expirable"), nil, []byte("value"))
+	require.NoError(t, err)
+
+	_, err = tx.Commit(context.Background())
+	require.NoError(t, err)
+
+	reaped, err := st.ReapExpiredKeys(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 5, reaped)
+
+	for i := 0; i < 5; i++ {
+		valRef, err := st.GetWithFilters([]byte(fmt.Sprintf("expired%d", i)))
+		require.NoError(t, err)
+		require.True(t, valRef.KVMetadata().Deleted())
+
+		_, err = st.Get([]byte(fmt.Sprintf("expired%d", i)))
+		require.ErrorIs(t, err, ErrKeyNotFound)
+	}
+
+	_, err = st.Get([]byte("alive"))
+	require.NoError(t, err)
+
+	_, err = st.Get([]byte("non-expirable"))
+	require.NoError(t, err)
+
+	reaped, err = st.ReapExpiredKeys(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, reaped)
+}
+
+func TestReapExpiredKeysWithTimeFunc(t *testing.T) {
+	st, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	setWithExpiration(t, st, []byte("key1"), expiresAt)
+
+	reaped, err := st.ReapExpiredKeys(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, reaped)
+
+	// expiration is evaluated using the time function of the store
+	err = st.UseTimeFunc(func() time.Time { return expiresAt.Add(time.Second) })
+	require.NoError(t, err)
+
+	reaped, err = st.ReapExpiredKeys(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, reaped)
+
+	valRef, err := st.GetWithFilters([]byte("key1"))
+	require.NoError(t, err)
+	require.True(t, valRef.KVMetadata().Deleted())
+}
+
+func TestReapExpiredKeysInBackground(t *testing.T) {
+	st, err := Open(t.TempDir(), DefaultOptions().WithExpiredKeysReaperInterval(10*time.Millisecond))
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	setWithExpiration(t, st, []byte("key1"), time.Now().Add(-time.Hour))
+
+	require.Eventually(t, func() bool {
+		valRef, err := st.GetWithFilters([]byte("key1"))
+		require.NoError(t, err)
+
+		return valRef.KVMetadata().Deleted()
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+// number of revisions fetched from the index on each round-trip
+const historyReaderBatchSize = 100
+
+// HistoryReader lazily iterates over the revisions of a key,
+// values are only read from the logs when they're resolved
+type HistoryReader struct {
+	snap     *Snapshot
+	ownsSnap bool // the snapshot is closed together with the reader
+
+	key    []byte
+	offset uint64
+	desc   bool
+
+	hCount uint64
+	txs    []uint64 // prefetched revisions
+
+	closed bool
+}
+
+// NewHistoryReader returns a reader over the revisions of key, skipping the first offset ones.
+// Revisions are read from the oldest to the latest one or the other way around when desc is set
+func (s *Snapshot) NewHistoryReader(key []byte, offset uint64, desc bool) (*HistoryReader, error) {
+	if len(key) == 0 {
+		return nil, ErrNullKey
+	}
+
+	return &HistoryReader{
+		snap:   s,
+		key:    key,
+		offset: offset,
+		desc:   desc,
+	}, nil
+}
+
+// NewHistoryReader returns a reader over the revisions of key based on a fresh snapshot,
+// see Snapshot.NewHistoryReader
+func (s *ImmuStore) NewHistoryReader(key []byte, offset uint64, desc bool) (*HistoryReader, error) {
+	snap, err := s.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := snap.NewHistoryReader(key, offset, desc)
+	if err != nil {
+		snap.Close()
+		return nil, err
+	}
+
+	r.ownsSnap = true
+
+	return r, nil
+}
+
+// Read returns the next revision of the key, ErrNoMoreEntries is returned once all of them were read.
+// The revision number and the transaction in which it was set are available through the returned ValueRef
+func (r *HistoryReader) Read() (ValueRef, error) {
+	if r.closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	if len(r.txs) == 0 {
+		txs, hCount, err := r.snap.History(r.key, r.offset, r.desc, historyReaderBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		r.txs = txs
+		r.hCount = hCount
+	}
+
+	txID := r.txs[0]
+
+	revision := r.offset + 1
+	if r.desc {
+		revision = r.hCount - r.offset
+	}
+
+	e, hdr, err := r.snap.st.ReadTxEntry(txID, r.key)
+	if err != nil {
+		return nil, err
+	}
+
+	r.txs = r.txs[1:]
+	r.offset++
+
+	return &valueRef{
+		tx:     hdr.ID,
+		hc:     revision,
+		hVal:   e.hVal,
+		vOff:   int64(e.vOff),
+		valLen: uint32(e.vLen),
+		txmd:   hdr.Metadata,
+		kvmd:   e.md,
+		st:     r.snap.st,
+	}, nil
+}
+
+func (r *HistoryReader) Close() error {
+	if r.closed {
+		return ErrAlreadyClosed
+	}
+
+	r.closed = true
+
+	if r.ownsSnap {
+		return r.snap.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryReader(t *testing.T) {
+	st, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	revisions := historyReaderBatchSize*2 + 10
+
+	expiration := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	for i := 1; i <= revisions; i++ {
+		tx, err := st.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		var md *KVMetadata
+		if i == revisions {
+			md = NewKVMetadata()
+			err = md.ExpiresAt(expiration)
+			require.NoError(t, err)
+		}
+
+		err = tx.Set([]byte("key"), md, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	err = st.WaitForIndexingUpto(context.Background(), uint64(revisions))
+	require.NoError(t, err)
+
+	t.Run("ascending order", func(t *testing.T) {
+		r, err := st.NewHistoryReader([]byte("key"), 0, false)
+		require.NoError(t, err)
+		defer r.Close()
+
+		for i := 1; i <= revisions; i++ {
+			valRef, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, uint64(i), valRef.Tx())
+			require.Equal(t, uint64(i), valRef.HC())
+
+			val, err := valRef.Resolve()
+			require.NoError(t, err)
+			require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreEntries)
+	})
+
+	t.Run("descending order with offset", func(t *testing.T) {
+		offset := uint64(5)
+
+		r, err := st.NewHistoryReader([]byte("key"), offset, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		for i := revisions - int(offset); i >= 1; i-- {
+			valRef, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, uint64(i), valRef.Tx())
+			require.Equal(t, uint64(i), valRef.HC())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreEntries)
+	})
+
+	t.Run("entry metadata is kept", func(t *testing.T) {
+		r, err := st.NewHistoryReader([]byte("key"), 0, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		valRef, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, uint64(revisions), valRef.HC())
+		require.NotNil(t, valRef.KVMetadata())
+
+		expiresAt, err := valRef.KVMetadata().ExpirationTime()
+		require.NoError(t, err)
+		require.Equal(t, expiration, expiresAt)
+
+		valRef, err = r.Read()
+		require.NoError(t, err)
+		require.Nil(t, valRef.KVMetadata())
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		snap, err := st.Snapshot()
+		require.NoError(t, err)
+		defer snap.Close()
+
+		pageSize := 7
+		var offset uint64
+
+		for offset < uint64(revisions) {
+			r, err := snap.NewHistoryReader([]byte("key"), offset, false)
+			require.NoError(t, err)
+
+			for i := 0; i < pageSize && offset < uint64(revisions); i++ {
+				valRef, err := r.Read()
+				require.NoError(t, err)
+				require.Equal(t, offset+1, valRef.HC())
+				offset++
+			}
+
+			err = r.Close()
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("invalid cases", func(t *testing.T) {
+		_, err := st.NewHistoryReader(nil, 0, false)
+		require.ErrorIs(t, err, ErrNullKey)
+
+		r, err := st.NewHistoryReader([]byte("unknown"), 0, false)
+		require.NoError(t, err)
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrKeyNotFound)
+
+		r2, err := st.NewHistoryReader([]byte("key"), uint64(revisions)+1, false)
+		require.NoError(t, err)
+
+		_, err = r2.Read()
+		require.ErrorIs(t, err, ErrOffsetOutOfRange)
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		err = r.Close()
+		require.ErrorIs(t, err, ErrAlreadyClosed)
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrAlreadyClosed)
+
+		err = r2.Close()
+		require.NoError(t, err)
+	})
+}
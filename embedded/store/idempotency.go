@@ -0,0 +1,146 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"fmt"
+)
+
+type idempotentTx struct {
+	txID uint64
+	key  string
+}
+
+// idempotencyIndex keeps the idempotency keys of the latest
+// precommitted transactions in memory.
+// It is protected by the commit state mutex of the store.
+type idempotencyIndex struct {
+	window uint64
+
+	txIDs map[string]uint64
+	txs   []idempotentTx // sorted by txID
+}
+
+func newIdempotencyIndex(window int) *idempotencyIndex {
+	return &idempotencyIndex{
+		window: uint64(window),
+		txIDs:  make(map[string]uint64),
+	}
+}
+
+func (idx *idempotencyIndex) get(key []byte) (uint64, bool) {
+	txID, ok := idx.txIDs[string(key)]
+	return txID, ok
+}
+
+// put registers the key of the transaction and evicts the keys
+// of transactions falling out of the window.
+func (idx *idempotencyIndex) put(txID uint64, key []byte) {
+	if idx.window == 0 {
+		return
+	}
+
+	if len(key) > 0 {
+		idx.txIDs[string(key)] = txID
+		idx.txs = append(idx.txs, idempotentTx{txID: txID, key: string(key)})
+	}
+
+	if txID <= idx.window {
+		return
+	}
+
+	i := 0
+	for ; i < len(idx.txs) && idx.txs[i].txID <= txID-idx.window; i++ {
+		delete(idx.txIDs, idx.txs[i].key)
+	}
+
+	idx.txs = idx.txs[i:]
+}
+
+// discardSince removes the keys of transactions with an ID
+// greater than or equal to the given one.
+func (idx *idempotencyIndex) discardSince(txID uint64) {
+	i := len(idx.txs)
+	for ; i > 0 && idx.txs[i-1].txID >= txID; i-- {
+		delete(idx.txIDs, idx.txs[i-1].key)
+	}
+
+	idx.txs = idx.txs[:i]
+}
+
+func (s *ImmuStore) loadIdempotencyKeys() error {
+	if s.idempotency.window == 0 || s.inmemPrecommittedTxID == 0 {
+		return nil
+	}
+
+	initialTxID := uint64(1)
+	if s.inmemPrecommittedTxID > s.idempotency.window {
+		initialTxID = s.inmemPrecommittedTxID - s.idempotency.window + 1
+	}
+
+	for txID := initialTxID; txID <= s.inmemPrecommittedTxID; txID++ {
+		hdr, err := s.ReadTxHeader(txID, true)
+		if err != nil {
+			return fmt.Errorf("%w: while reading idempotency key of tx %d", err, txID)
+		}
+
+		var key []byte
+		if hdr.Metadata != nil {
+			key = hdr.Metadata.IdempotencyKey()
+		}
+
+		s.idempotency.put(txID, key)
+	}
+
+	return nil
+}
+
+func (s *ImmuStore) checkIdempotencyKey(md *TxMetadata) error {
+	if md == nil || !md.HasIdempotencyKey() {
+		return nil
+	}
+
+	s.commitStateRWMutex.RLock()
+	defer s.commitStateRWMutex.RUnlock()
+
+	txID, ok := s.idempotency.get(md.IdempotencyKey())
+	if ok {
+		return fmt.Errorf("%w: by tx %d", ErrIdempotencyKeyAlreadyUsed, txID)
+	}
+
+	return nil
+}
+
+// TxIDByIdempotencyKey returns the ID of the transaction carrying the given
+// idempotency key. Only the keys of the latest IdempotencyWindow transactions
+// are remembered. The returned transaction may be precommitted but not yet
+// committed, WaitForTx can be used to wait for it.
+func (s *ImmuStore) TxIDByIdempotencyKey(key []byte) (uint64, error) {
+	if len(key) == 0 || len(key) > MaxIdempotencyKeyLen {
+		return 0, fmt.Errorf("%w: invalid idempotency key", ErrIllegalArguments)
+	}
+
+	s.commitStateRWMutex.RLock()
+	defer s.commitStateRWMutex.RUnlock()
+
+	txID, ok := s.idempotency.get(key)
+	if !ok {
+		return 0, ErrIdempotencyKeyNotFound
+	}
+
+	return txID, nil
+}
@@ -26,9 +26,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/codenotary/immudb/embedded"
@@ -36,7 +39,9 @@ import (
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
 	"github.com/codenotary/immudb/embedded/appendable/singleapp"
+	"github.com/codenotary/immudb/embedded/appendable/singlefile"
 	"github.com/codenotary/immudb/embedded/cache"
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/htree"
 	"github.com/codenotary/immudb/embedded/multierr"
 	"github.com/codenotary/immudb/embedded/tbtree"
@@ -85,6 +90,8 @@ var ErrUnexpectedError = errors.New("unexpected error")
 var ErrUnsupportedTxVersion = errors.New("unsupported tx version")
 var ErrNewerVersionOrCorruptedData = errors.New("tx created with a newer version or data is corrupted")
 var ErrTxPoolExhausted = errors.New("transaction pool exhausted")
+var ErrIdempotencyKeyAlreadyUsed = errors.New("idempotency key already used")
+var ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
 
 var ErrInvalidPrecondition = errors.New("invalid precondition")
 var ErrInvalidPreconditionTooMany = fmt.Errorf("%w: too many preconditions", ErrInvalidPrecondition)
@@ -105,12 +112,18 @@ var ErrMetadataUnsupported = errors.New(
 var ErrUnsupportedTxHeaderVersion = errors.New("missing tx header serialization method")
 var ErrIllegalTruncationArgument = fmt.Errorf("%w: invalid truncation info", ErrIllegalArguments)
 var ErrTxNotPresentInMetadata = errors.New("tx not present in metadata")
+var ErrSnapshotExpired = errors.New("snapshot expired: referenced value was reclaimed by truncation")
+var ErrTxHashAlgorithmMismatch = errors.New("tx hash algorithm mismatch")
+var ErrMaxStoreSizeExceeded = errors.New("max store size exceeded")
+var ErrCorruptedTxRangeStream = fmt.Errorf("%w: invalid tx range stream", ErrCorruptedData)
 
 const MaxKeyLen = 1024 // assumed to be not lower than hash size
 const MaxParallelIO = 127
 
 const cLogEntrySize = offsetSize + lszSize // tx offset & size
 
+const parallelValueHashingThld = 64 // minimum number of entries hashed by each goroutine
+
 const txIDSize = 8
 const tsSize = 8
 const lszSize = 4
@@ -126,6 +139,7 @@ const (
 	metaMaxKeyLen    = "MAX_KEY_LEN"
 	metaMaxValueLen  = "MAX_VALUE_LEN"
 	metaFileSize     = "FILE_SIZE"
+	metaTxHashAlg    = "TX_HASH_ALGORITHM"
 )
 
 const indexDirname = "index"
@@ -135,6 +149,8 @@ type ImmuStore struct {
 	path string
 
 	logger           logger.Logger
+	tracer           Tracer
+	metrics          MetricsCollector
 	lastNotification time.Time
 	notifyMutex      sync.Mutex
 
@@ -142,10 +158,10 @@ type ImmuStore struct {
 	vLogUnlockedList *list.List
 	vLogsCond        *sync.Cond
 
-	vLogCache *cache.LRUCache
+	vLogCache cache.Cache
 
 	txLog      appendable.Appendable
-	txLogCache *cache.LRUCache
+	txLogCache cache.Cache
 
 	cLog appendable.Appendable
 
@@ -159,22 +175,42 @@ type ImmuStore struct {
 
 	precommittedTxLogSize int64
 
+	// incremented whenever precommitted transactions are discarded
+	discardedPrecommits uint64
+
 	commitStateRWMutex sync.RWMutex
 
+	// serializes syncs, which only hold the commit state while committing
+	syncMutex sync.Mutex
+
+	idempotency *idempotencyIndex
+
+	cacheHits   uint64
+	cacheMisses uint64
+
 	readOnly              bool
 	synced                bool
 	syncFrequency         time.Duration
+	syncMaxBatchSize      int
 	maxActiveTransactions int
 	mvccReadSetLimit      int
 	maxWaitees            int
 	maxConcurrency        int
 	maxIOConcurrency      int
 	maxTxEntries          int
+	readAheadWindowSize   int
 	maxKeyLen             int
 	maxValueLen           int
 
+	maxStoreSize       int64
+	storeSizeSoftLimit int64
+	onStoreSizeLimit   StoreSizeHandler
+	softLimitReached   int32 // set once onStoreSizeLimit has been called
+
 	writeTxHeaderVersion int
 
+	txHashAlg hashing.Algorithm
+
 	timeFunc TimeFunc
 
 	useExternalCommitAllowance bool
@@ -201,11 +237,29 @@ type ImmuStore struct {
 	mutex sync.Mutex
 
 	compactionDisabled bool
+
+	// closed to stop the expired keys reaper, nil when it's not running
+	reaperDone chan struct{}
+
+	// closed to stop the retention-based truncation, nil when it's not running
+	truncatorDone chan struct{}
+
+	// closed to stop the periodic fsync of non-synced stores, nil when it's not running
+	asyncSyncerDone chan struct{}
+
+	// closed to stop the scrubber, nil when it's not running
+	scrubberDone chan struct{}
+
+	singleFile *singlefile.SingleFile // set when all the data is kept within a single file
 }
 
 type refVLog struct {
 	vLog        appendable.Appendable
 	unlockedRef *list.Element // unlockedRef == nil <-> vLog is locked
+
+	// discardedUpTo holds the offset below which values were reclaimed by truncation.
+	// It's only updated while the vLog is locked but may be read atomically at any time
+	discardedUpTo int64
 }
 
 func Open(path string, opts *Options) (*ImmuStore, error) {
@@ -214,6 +268,10 @@ func Open(path string, opts *Options) (*ImmuStore, error) {
 		return nil, fmt.Errorf("%w: %v", ErrIllegalArguments, err)
 	}
 
+	if opts.SingleFile {
+		return openSingleFile(path, opts)
+	}
+
 	finfo, err := os.Stat(path)
 	if err != nil {
 		if !os.IsNotExist(err) {
@@ -228,12 +286,17 @@ func Open(path string, opts *Options) (*ImmuStore, error) {
 		return nil, ErrorPathIsNotADirectory
 	}
 
+	return open(path, opts)
+}
+
+func open(path string, opts *Options) (*ImmuStore, error) {
 	metadata := appendable.NewMetadata(nil)
 	metadata.PutInt(metaVersion, Version)
 	metadata.PutInt(metaMaxTxEntries, opts.MaxTxEntries)
 	metadata.PutInt(metaMaxKeyLen, opts.MaxKeyLen)
 	metadata.PutInt(metaMaxValueLen, opts.MaxValueLen)
 	metadata.PutInt(metaFileSize, opts.FileSize)
+	metadata.PutInt(metaTxHashAlg, int(opts.TxHashAlgorithm))
 
 	appendableOpts := multiapp.DefaultOptions().
 		WithReadOnly(opts.ReadOnly).
@@ -242,6 +305,10 @@ func Open(path string, opts *Options) (*ImmuStore, error) {
 		WithAutoSync(true).
 		WithFileSize(opts.FileSize).
 		WithFileMode(opts.FileMode).
+		WithEncryption(opts.EncryptionKeyID, opts.KeyProvider).
+		WithChecksums(opts.ChecksumBlockSize).
+		WithVerifyChecksums(opts.VerifyChecksums).
+		WithMmap(opts.Mmap).
 		WithMetadata(metadata.Bytes())
 
 	appFactory := opts.appFactory
@@ -319,6 +386,18 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 
 	}
 
+	// stores created before the algorithm was configurable do not record it
+	txHashAlg := hashing.SHA256
+
+	alg, ok := metadata.GetInt(metaTxHashAlg)
+	if ok {
+		txHashAlg = hashing.Algorithm(alg)
+
+		if int(txHashAlg) != alg || txHashAlg.Validate() != nil {
+			return nil, fmt.Errorf("corrupted commit log metadata (tx hash algorithm): %w", ErrCorruptedCLog)
+		}
+	}
+
 	cLogSize, err := cLog.Size()
 	if err != nil {
 		return nil, fmt.Errorf("corrupted commit log: could not get size: %w", err)
@@ -326,6 +405,8 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 
 	rem := cLogSize % cLogEntrySize
 	if rem > 0 {
+		opts.logger.Warningf("Discarding partially written commit log entry at '%s' {size=%d}", path, rem)
+
 		cLogSize -= rem
 		err = cLog.SetOffset(cLogSize)
 		if err != nil {
@@ -333,63 +414,91 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		}
 	}
 
-	var committedTxLogSize int64
-	var committedTxOffset int64
-	var committedTxSize int
+	txPool, err := newTxPool(txPoolOptions{
+		poolSize:     opts.MaxConcurrency + 1, // one extra tx pre-allocation for indexing thread
+		maxTxEntries: maxTxEntries,
+		maxKeyLen:    maxKeyLen,
+		hashAlg:      txHashAlg,
+		preallocated: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration, couldn't initialize transaction holder pool")
+	}
 
+	maxTxSize := maxTxSize(maxTxEntries, maxKeyLen, maxTxMetadataLen, maxKVMetadataLen)
+	txbs := make([]byte, maxTxSize)
+
+	var committedTxLogSize int64
 	var committedTxID uint64
 
-	if cLogSize > 0 {
+	committedAlh := txHashAlg.Sum(nil)
+
+	txLogFileSize := int64(-1)
+
+	// commits whose transaction was not completely written into the transaction log
+	// (e.g. the process was killed before the log was synced) are discarded.
+	// Transactions fully contained in the transaction log must be readable, otherwise
+	// data is corrupted and committed transactions are not discarded
+	for cLogSize > 0 {
 		b := make([]byte, cLogEntrySize)
 		_, err := cLog.ReadAt(b, cLogSize-cLogEntrySize)
 		if err != nil {
 			return nil, fmt.Errorf("corrupted commit log: could not read the last commit: %w", err)
 		}
 
-		committedTxOffset = int64(binary.BigEndian.Uint64(b))
-		committedTxSize = int(binary.BigEndian.Uint32(b[txIDSize:]))
+		committedTxOffset := int64(binary.BigEndian.Uint64(b))
+		committedTxSize := int(binary.BigEndian.Uint32(b[txIDSize:]))
+
 		committedTxLogSize = committedTxOffset + int64(committedTxSize)
 		committedTxID = uint64(cLogSize) / cLogEntrySize
 
-		txLogFileSize, err := txLog.Size()
-		if err != nil {
-			return nil, fmt.Errorf("corrupted transaction log: could not get size: %w", err)
+		if txLogFileSize < 0 {
+			txLogFileSize, err = txLog.Size()
+			if err != nil {
+				return nil, fmt.Errorf("corrupted transaction log: could not get size: %w", err)
+			}
 		}
 
-		if txLogFileSize < committedTxLogSize {
-			return nil, fmt.Errorf("corrupted transaction log: size is too small: %w", ErrorCorruptedTxData)
-		}
-	}
+		if committedTxLogSize <= txLogFileSize {
+			txReader := appendable.NewReaderFrom(txLog, committedTxOffset, committedTxSize)
 
-	txPool, err := newTxPool(txPoolOptions{
-		poolSize:     opts.MaxConcurrency + 1, // one extra tx pre-allocation for indexing thread
-		maxTxEntries: maxTxEntries,
-		maxKeyLen:    maxKeyLen,
-		preallocated: true,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("invalid configuration, couldn't initialize transaction holder pool")
-	}
+			tx, _ := txPool.Alloc()
 
-	maxTxSize := maxTxSize(maxTxEntries, maxKeyLen, maxTxMetadataLen, maxKVMetadataLen)
-	txbs := make([]byte, maxTxSize)
+			err = tx.readFrom(txReader, txHashAlg)
+			if err == nil && tx.header.ID != committedTxID {
+				err = fmt.Errorf("%w: unexpected transaction id %d", ErrorCorruptedTxData, tx.header.ID)
+			}
+			if err == nil {
+				committedAlh = tx.header.Alh()
+			}
 
-	committedAlh := sha256.Sum256(nil)
+			txPool.Release(tx)
 
-	if cLogSize > 0 {
-		txReader := appendable.NewReaderFrom(txLog, committedTxOffset, committedTxSize)
+			if err != nil {
+				return nil, fmt.Errorf("corrupted transaction log: could not read the last transaction: %w", err)
+			}
 
-		tx, _ := txPool.Alloc()
+			break
+		}
 
-		err = tx.readFrom(txReader)
-		if err != nil {
-			txPool.Release(tx)
+		err = fmt.Errorf("%w: transaction log is too small", ErrorCorruptedTxData)
+
+		if opts.ReadOnly {
 			return nil, fmt.Errorf("corrupted transaction log: could not read the last transaction: %w", err)
 		}
 
-		txPool.Release(tx)
+		logger.With(opts.logger, logger.FieldComponent, "store", logger.FieldTxID, committedTxID).
+			Warningf("%v: discarding incomplete commit of transaction %d at '%s'", err, committedTxID, path)
+
+		cLogSize -= cLogEntrySize
+
+		err = cLog.SetOffset(cLogSize)
+		if err != nil {
+			return nil, fmt.Errorf("corrupted commit log: could not set offset: %w", err)
+		}
 
-		committedAlh = tx.header.Alh()
+		committedTxLogSize = 0
+		committedTxID = 0
 	}
 
 	cLogBuf := newPrecommitBuffer(opts.MaxActiveTransactions)
@@ -405,17 +514,19 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 	tx, _ := txPool.Alloc()
 
 	for {
-		err = tx.readFrom(txReader)
+		err = tx.readFrom(txReader, txHashAlg)
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			opts.logger.Warningf("%w: while reading pre-committed transaction: %d", err, precommittedTxID+1)
+			logger.With(opts.logger, logger.FieldComponent, "store", logger.FieldTxID, precommittedTxID+1).
+				Warningf("%w: while reading pre-committed transaction: %d", err, precommittedTxID+1)
 			break
 		}
 
 		if tx.header.ID != precommittedTxID+1 || tx.header.PrevAlh != precommittedAlh {
-			opts.logger.Warningf("%w: while reading pre-committed transaction: %d", ErrCorruptedData, precommittedTxID+1)
+			logger.With(opts.logger, logger.FieldComponent, "store", logger.FieldTxID, precommittedTxID+1).
+				Warningf("%w: while reading pre-committed transaction: %d", ErrCorruptedData, precommittedTxID+1)
 			break
 		}
 
@@ -443,10 +554,10 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		vLogsMap[byte(i)] = &refVLog{vLog: vLog, unlockedRef: e}
 	}
 
-	var vLogCache *cache.LRUCache
+	var vLogCache cache.Cache
 
 	if opts.VLogCacheSize > 0 {
-		vLogCache, err = cache.NewLRUCache(opts.VLogCacheSize)
+		vLogCache, err = cache.New(opts.VLogCacheSize, opts.CacheShards, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -461,7 +572,9 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		WithRetryableSync(opts.Synced).
 		WithAutoSync(true).
 		WithWriteBufferSize(opts.AHTOpts.WriteBufferSize).
-		WithSyncThld(opts.AHTOpts.SyncThld)
+		WithSyncThld(opts.AHTOpts.SyncThld).
+		WithHashAlgorithm(txHashAlg).
+		WithEncryption(opts.EncryptionKeyID, opts.KeyProvider)
 
 	if opts.appFactory != nil {
 		ahtOpts.WithAppFactory(func(rootPath, subPath string, appOpts *multiapp.Options) (appendable.Appendable, error) {
@@ -469,19 +582,44 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		})
 	}
 
-	aht, err := ahtree.Open(ahtPath, ahtOpts)
+	var aht *ahtree.AHtree
+
+	if opts.SingleFile {
+		aht, err = openSingleFileAHT(path, ahtOpts, opts)
+	} else {
+		aht, err = ahtree.Open(ahtPath, ahtOpts)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("could not open aht: %w", err)
 	}
 
-	txLogCache, err := cache.NewLRUCache(opts.TxLogCacheSize) // TODO: optionally it could include up to opts.MaxActiveTransactions upon start
+	txLogCache, err := cache.New(opts.TxLogCacheSize, opts.CacheShards, nil) // TODO: optionally it could include up to opts.MaxActiveTransactions upon start
+	if err != nil {
+		return nil, err
+	}
+
+	// syncer (TODO: indexer may wait here instead)
+	inmemPrecommitWHub, err := watchers.New(0, watchers.DefaultOptions().WithMaxWaiting(opts.MaxActiveTransactions+1))
+	if err != nil {
+		return nil, err
+	}
+
+	durablePrecommitWHub, err := watchers.New(0, watchers.DefaultOptions().WithMaxWaiting(opts.MaxActiveTransactions+opts.MaxWaitees))
+	if err != nil {
+		return nil, err
+	}
+
+	// including indexer
+	commitWHub, err := watchers.New(0, watchers.DefaultOptions().WithMaxWaiting(1+opts.MaxActiveTransactions+opts.MaxWaitees))
 	if err != nil {
 		return nil, err
 	}
 
 	store := &ImmuStore{
 		path:             path,
-		logger:           opts.logger,
+		logger:           logger.With(opts.logger, logger.FieldComponent, "store"),
+		tracer:           opts.tracer,
+		metrics:          opts.metrics,
 		txLog:            txLog,
 		txLogCache:       txLogCache,
 		vLogs:            vLogsMap,
@@ -503,17 +641,25 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		readOnly:              opts.ReadOnly,
 		synced:                opts.Synced,
 		syncFrequency:         opts.SyncFrequency,
+		syncMaxBatchSize:      opts.SyncMaxBatchSize,
 		maxActiveTransactions: opts.MaxActiveTransactions,
 		mvccReadSetLimit:      opts.MVCCReadSetLimit,
 		maxWaitees:            opts.MaxWaitees,
 		maxConcurrency:        opts.MaxConcurrency,
 		maxIOConcurrency:      opts.MaxIOConcurrency,
 		maxTxEntries:          maxTxEntries,
+		readAheadWindowSize:   opts.ReadAheadWindowSize,
 		maxKeyLen:             maxKeyLen,
 		maxValueLen:           maxInt(maxValueLen, opts.MaxValueLen),
 
+		maxStoreSize:       opts.MaxStoreSize,
+		storeSizeSoftLimit: opts.StoreSizeSoftLimit,
+		onStoreSizeLimit:   opts.StoreSizeHandler,
+
 		writeTxHeaderVersion: opts.WriteTxHeaderVersion,
 
+		txHashAlg: txHashAlg,
+
 		timeFunc: opts.TimeFunc,
 
 		useExternalCommitAllowance: opts.UseExternalCommitAllowance,
@@ -521,15 +667,17 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 
 		aht: aht,
 
-		inmemPrecommitWHub:   watchers.New(0, opts.MaxActiveTransactions+1), // syncer (TODO: indexer may wait here instead)
-		durablePrecommitWHub: watchers.New(0, opts.MaxActiveTransactions+opts.MaxWaitees),
-		commitWHub:           watchers.New(0, 1+opts.MaxActiveTransactions+opts.MaxWaitees), // including indexer
+		inmemPrecommitWHub:   inmemPrecommitWHub,
+		durablePrecommitWHub: durablePrecommitWHub,
+		commitWHub:           commitWHub,
 
 		txPool: txPool,
 		_txbs:  txbs,
 		_valBs: make([]byte, maxValueLen),
 
-		compactionDisabled: opts.CompactionDisabled,
+		compactionDisabled: opts.CompactionDisabled || opts.SingleFile,
+
+		idempotency: newIdempotencyIndex(opts.IdempotencyWindow),
 	}
 
 	if store.aht.Size() > precommittedTxID {
@@ -550,6 +698,12 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		}
 	}
 
+	err = store.loadIdempotencyKeys()
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("could not load idempotency keys: %w", err)
+	}
+
 	err = store.inmemPrecommitWHub.DoneUpto(precommittedTxID)
 	if err != nil {
 		return nil, err
@@ -581,6 +735,26 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 		// NOTE: compaction should preserve snapshot which are not synced... so to ensure rollback can be achieved
 	}
 
+	if opts.ExpiredKeysReaperInterval > 0 && !opts.ReadOnly {
+		store.reaperDone = make(chan struct{})
+		go store.reapExpiredKeysPeriodically(opts.ExpiredKeysReaperInterval, store.reaperDone)
+	}
+
+	if opts.RetentionPeriod > 0 && !opts.ReadOnly {
+		store.truncatorDone = make(chan struct{})
+		go store.truncatePeriodically(opts.RetentionPeriod, opts.TruncationFrequency, store.truncatorDone)
+	}
+
+	if opts.ScrubberRate > 0 {
+		store.scrubberDone = make(chan struct{})
+		go store.scrubPeriodically(opts.ScrubberRate, opts.CorruptionHandler, store.scrubberDone)
+	}
+
+	if !store.synced && opts.AsyncSyncFrequency > 0 && !opts.ReadOnly {
+		store.asyncSyncerDone = make(chan struct{})
+		go store.syncPeriodically(opts.AsyncSyncFrequency, store.asyncSyncerDone)
+	}
+
 	if store.synced {
 		go func() {
 			for {
@@ -594,6 +768,12 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 
 				// TODO: parametrize concurrency evaluation
 				for i := 0; i < 4; i++ {
+					if store.syncMaxBatchSize > 0 &&
+						store.lastPrecommittedTxID()-committedTxID >= uint64(store.syncMaxBatchSize) {
+						// avoid waiting if the batch is already complete
+						break
+					}
+
 					// give some time for more transactions to be precommitted
 					time.Sleep(store.syncFrequency / 4)
 
@@ -608,7 +788,7 @@ func OpenWith(path string, vLogs []appendable.Appendable, txLog, cLog appendable
 				}
 
 				// ensure durability
-				err := store.sync()
+				err := store.sync(store.syncMaxBatchSize)
 				if errors.Is(err, ErrAlreadyClosed) ||
 					errors.Is(err, multiapp.ErrAlreadyClosed) ||
 					errors.Is(err, singleapp.ErrAlreadyClosed) ||
@@ -676,6 +856,8 @@ func (s *ImmuStore) GetWithFilters(key []byte, filters ...FilterFn) (valRef Valu
 		return nil, err
 	}
 
+	valRef = s.withTombstones(key, valRef, math.MaxUint64)
+
 	now := time.Now()
 
 	for _, filter := range filters {
@@ -707,6 +889,8 @@ func (s *ImmuStore) GetWithPrefixAndFilters(prefix []byte, neq []byte, filters .
 		return nil, nil, err
 	}
 
+	valRef = s.withTombstones(key, valRef, math.MaxUint64)
+
 	now := time.Now()
 
 	for _, filter := range filters {
@@ -723,6 +907,22 @@ func (s *ImmuStore) GetWithPrefixAndFilters(prefix []byte, neq []byte, filters .
 	return key, valRef, nil
 }
 
+// GetBatch resolves all the keys against a single snapshot of the index,
+// see Snapshot.GetBatchWithFilters
+func (s *ImmuStore) GetBatch(keys [][]byte) ([]ValueRef, error) {
+	return s.GetBatchWithFilters(keys, IgnoreExpired, IgnoreDeleted)
+}
+
+func (s *ImmuStore) GetBatchWithFilters(keys [][]byte, filters ...FilterFn) ([]ValueRef, error) {
+	snap, err := s.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	defer snap.Close()
+
+	return snap.GetBatchWithFilters(keys, filters...)
+}
+
 func (s *ImmuStore) History(key []byte, offset uint64, descOrder bool, limit int) (txs []uint64, hCount uint64, err error) {
 	return s.indexer.History(key, offset, descOrder, limit)
 }
@@ -745,6 +945,7 @@ func (s *ImmuStore) NewTxHolderPool(poolSize int, preallocated bool) (TxPool, er
 		poolSize:     poolSize,
 		maxTxEntries: s.maxTxEntries,
 		maxKeyLen:    s.maxKeyLen,
+		hashAlg:      s.txHashAlg,
 		preallocated: preallocated,
 	})
 }
@@ -808,6 +1009,44 @@ func (s *ImmuStore) SnapshotMustIncludeTxIDWithRenewalPeriod(ctx context.Context
 	}, nil
 }
 
+// SnapshotAsOfTx returns a snapshot where keys are resolved as they were right after
+// the specified transaction was committed, later changes are not visible through it
+func (s *ImmuStore) SnapshotAsOfTx(ctx context.Context, txID uint64) (*Snapshot, error) {
+	if txID == 0 || txID > s.LastCommittedTxID() {
+		return nil, fmt.Errorf("%w: invalid transaction ID", ErrIllegalArguments)
+	}
+
+	hdr, err := s.ReadTxHeader(txID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.snapshotAsOf(ctx, txID, time.Unix(hdr.Ts, 0))
+}
+
+// SnapshotAsOfTime returns a snapshot where keys are resolved as they were right after
+// the latest transaction committed at or before ts. Expiration is also evaluated at ts
+func (s *ImmuStore) SnapshotAsOfTime(ctx context.Context, ts time.Time) (*Snapshot, error) {
+	hdr, err := s.LastTxUntil(ts)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.snapshotAsOf(ctx, hdr.ID, ts)
+}
+
+func (s *ImmuStore) snapshotAsOf(ctx context.Context, txID uint64, ts time.Time) (*Snapshot, error) {
+	snap, err := s.SnapshotMustIncludeTxID(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+
+	snap.asOfTx = txID
+	snap.ts = ts
+
+	return snap, nil
+}
+
 func (s *ImmuStore) CommittedAlh() (uint64, [sha256.Size]byte) {
 	s.commitStateRWMutex.RLock()
 	defer s.commitStateRWMutex.RUnlock()
@@ -930,14 +1169,25 @@ func (s *ImmuStore) WaitForIndexingUpto(ctx context.Context, txID uint64) error
 	return s.indexer.WaitForIndexingUpto(ctx, txID)
 }
 
-func (s *ImmuStore) CompactIndex() error {
+func (s *ImmuStore) CompactIndex() (err error) {
+	_, span := s.startSpan(context.Background(), SpanCompactIndex, nil)
+	defer func() { span.End(err) }()
+
 	if s.compactionDisabled {
 		return ErrCompactionUnsupported
 	}
 	return s.indexer.CompactIndex()
 }
 
-func (s *ImmuStore) FlushIndex(cleanupPercentage float32, synced bool) error {
+func (s *ImmuStore) FlushIndex(cleanupPercentage float32, synced bool) (err error) {
+	_, span := s.startSpan(context.Background(), SpanFlushIndex, func() SpanAttrs {
+		return SpanAttrs{
+			SpanAttrCleanup: cleanupPercentage,
+			SpanAttrSynced:  synced,
+		}
+	})
+	defer func() { span.End(err) }()
+
 	return s.indexer.FlushIndex(cleanupPercentage, synced)
 }
 
@@ -998,6 +1248,11 @@ func (s *ImmuStore) MaxValueLen() int {
 	return s.maxValueLen
 }
 
+// TxHashAlgorithm returns the algorithm recorded in the store metadata, used to build the whole chain
+func (s *ImmuStore) TxHashAlgorithm() hashing.Algorithm {
+	return s.txHashAlg
+}
+
 func (s *ImmuStore) TxCount() uint64 {
 	s.commitStateRWMutex.RLock()
 	defer s.commitStateRWMutex.RUnlock()
@@ -1065,19 +1320,32 @@ type appendableResult struct {
 	err     error
 }
 
-func (s *ImmuStore) appendData(entries []*EntrySpec, donec chan<- appendableResult) {
+func (s *ImmuStore) appendData(ctx context.Context, entries []*EntrySpec, donec chan<- appendableResult) {
 	offsets := make([]int64, len(entries))
 
+	_, span := s.startSpan(ctx, SpanValueLogWrite, func() SpanAttrs {
+		return SpanAttrs{
+			SpanAttrEntries: len(entries),
+		}
+	})
+
 	vLogID, vLog := s.fetchAnyVLog()
 	defer s.releaseVLog(vLogID)
 
+	var written int
+	defer func() {
+		s.metrics.AddValueLogBytes(written)
+	}()
+
 	for i := 0; i < len(offsets); i++ {
 		if len(entries[i].Value) == 0 {
 			continue
 		}
 
-		voff, _, err := vLog.Append(entries[i].Value)
+		voff, n, err := vLog.Append(entries[i].Value)
+		written += n
 		if err != nil {
+			span.End(err)
 			donec <- appendableResult{nil, err}
 			return
 		}
@@ -1086,15 +1354,54 @@ func (s *ImmuStore) appendData(entries []*EntrySpec, donec chan<- appendableResu
 		if s.vLogCache != nil {
 			_, _, err = s.vLogCache.Put(offsets[i], entries[i].Value)
 			if err != nil {
+				span.End(err)
 				donec <- appendableResult{nil, err}
 				return
 			}
 		}
 	}
 
+	span.SetAttr(SpanAttrBytes, written)
+	span.End(nil)
+
 	donec <- appendableResult{offsets, nil}
 }
 
+// hashValues sets the value digests of the tx entries, values of large transactions
+// are hashed concurrently while they are being written into the value log
+func (s *ImmuStore) hashValues(txEntries []*TxEntry, entries []*EntrySpec) {
+	hashRange := func(from, to int) {
+		for i := from; i < to; i++ {
+			if entries[i].isValueTruncated {
+				txEntries[i].hVal = entries[i].hashValue
+			} else {
+				txEntries[i].hVal = s.txHashAlg.Sum(entries[i].Value)
+			}
+		}
+	}
+
+	workers := minInt(runtime.GOMAXPROCS(0), len(entries)/parallelValueHashingThld)
+	if workers <= 1 {
+		hashRange(0, len(entries))
+		return
+	}
+
+	rangeSize := (len(entries) + workers - 1) / workers
+
+	var wg sync.WaitGroup
+
+	for from := 0; from < len(entries); from += rangeSize {
+		wg.Add(1)
+
+		go func(from, to int) {
+			defer wg.Done()
+			hashRange(from, to)
+		}(from, minInt(from+rangeSize, len(entries)))
+	}
+
+	wg.Wait()
+}
+
 func (s *ImmuStore) NewWriteOnlyTx(ctx context.Context) (*OngoingTx, error) {
 	return newOngoingTx(ctx, s, &TxOptions{Mode: WriteOnlyTx})
 }
@@ -1103,8 +1410,31 @@ func (s *ImmuStore) NewTx(ctx context.Context, opts *TxOptions) (*OngoingTx, err
 	return newOngoingTx(ctx, s, opts)
 }
 
-func (s *ImmuStore) commit(ctx context.Context, otx *OngoingTx, expectedHeader *TxHeader, waitForIndexing bool) (*TxHeader, error) {
-	hdr, err := s.precommit(ctx, otx, expectedHeader)
+func (s *ImmuStore) commit(ctx context.Context, otx *OngoingTx, expectedHeader *TxHeader, waitForIndexing bool) (hdr *TxHeader, err error) {
+	ctx, span := s.startSpan(ctx, SpanCommit, func() SpanAttrs {
+		var nentries, nbytes int
+		if otx != nil {
+			nentries = len(otx.entries)
+			for _, e := range otx.entries {
+				nbytes += len(e.Key) + len(e.Value)
+			}
+		}
+
+		return SpanAttrs{
+			SpanAttrEntries: nentries,
+			SpanAttrBytes:   nbytes,
+		}
+	})
+	defer func() {
+		if hdr != nil {
+			span.SetAttr(SpanAttrTxID, hdr.ID)
+		}
+		span.End(err)
+	}()
+
+	start := time.Now()
+
+	hdr, err = s.precommit(ctx, otx, expectedHeader)
 	if err != nil {
 		return nil, err
 	}
@@ -1118,6 +1448,8 @@ func (s *ImmuStore) commit(ctx context.Context, otx *OngoingTx, expectedHeader *
 		return nil, err
 	}
 
+	s.metrics.ObserveCommit(time.Since(start), hdr.NEntries)
+
 	if waitForIndexing {
 		err = s.WaitForIndexingUpto(ctx, hdr.ID)
 		if err != nil {
@@ -1129,6 +1461,26 @@ func (s *ImmuStore) commit(ctx context.Context, otx *OngoingTx, expectedHeader *
 	return hdr, nil
 }
 
+func (s *ImmuStore) validateTx(ctx context.Context, otx *OngoingTx) (err error) {
+	_, span := s.startSpan(ctx, SpanValidate, func() SpanAttrs {
+		return SpanAttrs{
+			SpanAttrEntries: len(otx.entries),
+		}
+	})
+	defer func() { span.End(err) }()
+
+	err = s.validateEntries(otx.entries)
+	if err != nil {
+		return err
+	}
+
+	if otx.metadata != nil && len(otx.metadata.IdempotencyKey()) > MaxIdempotencyKeyLen {
+		return fmt.Errorf("%w: idempotency key too long", ErrIllegalArguments)
+	}
+
+	return s.validatePreconditions(otx.preconditions)
+}
+
 func (s *ImmuStore) precommit(ctx context.Context, otx *OngoingTx, hdr *TxHeader) (*TxHeader, error) {
 	if otx == nil {
 		return nil, fmt.Errorf("%w: no transaction", ErrIllegalArguments)
@@ -1139,12 +1491,12 @@ func (s *ImmuStore) precommit(ctx context.Context, otx *OngoingTx, hdr *TxHeader
 		return nil, fmt.Errorf("%w: transaction does not validate against header", err)
 	}
 
-	err = s.validateEntries(otx.entries)
+	err = s.validateTx(ctx, otx)
 	if err != nil {
 		return nil, err
 	}
 
-	err = s.validatePreconditions(otx.preconditions)
+	err = s.checkStoreSize(otx.entries)
 	if err != nil {
 		return nil, err
 	}
@@ -1156,7 +1508,7 @@ func (s *ImmuStore) precommit(ctx context.Context, otx *OngoingTx, hdr *TxHeader
 	defer s.releaseAllocTx(tx)
 
 	appendableCh := make(chan appendableResult)
-	go s.appendData(otx.entries, appendableCh)
+	go s.appendData(ctx, otx.entries, appendableCh)
 
 	if hdr == nil {
 		tx.header.Version = s.writeTxHeaderVersion
@@ -1173,13 +1525,10 @@ func (s *ImmuStore) precommit(ctx context.Context, otx *OngoingTx, hdr *TxHeader
 		txe.setKey(e.Key)
 		txe.md = e.Metadata
 		txe.vLen = len(e.Value)
-		if e.isValueTruncated {
-			txe.hVal = e.hashValue
-		} else {
-			txe.hVal = sha256.Sum256(e.Value)
-		}
 	}
 
+	s.hashValues(tx.entries, otx.entries)
+
 	err = tx.BuildHashTree()
 	if err != nil {
 		<-appendableCh // wait for data to be written
@@ -1265,6 +1614,14 @@ func (s *ImmuStore) precommit(ctx context.Context, otx *OngoingTx, hdr *TxHeader
 		}
 	}
 
+	if hdr == nil {
+		// replicated transactions are accepted as they were committed by the primary
+		err = s.checkIdempotencyKey(otx.metadata)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if otx.hasPreconditions() {
 		// Preconditions must be executed with up-to-date tree
 		err = s.WaitForIndexingUpto(ctx, currPrecomittedTxID)
@@ -1282,7 +1639,7 @@ func (s *ImmuStore) precommit(ctx context.Context, otx *OngoingTx, hdr *TxHeader
 		tx.entries[i].vOff = r.offsets[i]
 	}
 
-	err = s.performPrecommit(tx, ts, blTxID)
+	err = s.performPrecommit(ctx, tx, ts, blTxID)
 	if err != nil {
 		return nil, err
 	}
@@ -1304,7 +1661,7 @@ func (s *ImmuStore) lastPrecommittedTxID() uint64 {
 	return s.inmemPrecommittedTxID
 }
 
-func (s *ImmuStore) performPrecommit(tx *Tx, ts int64, blTxID uint64) error {
+func (s *ImmuStore) performPrecommit(ctx context.Context, tx *Tx, ts int64, blTxID uint64) error {
 	s.commitStateRWMutex.Lock()
 	defer s.commitStateRWMutex.Unlock()
 
@@ -1430,11 +1787,7 @@ func (s *ImmuStore) performPrecommit(tx *Tx, ts int64, blTxID uint64) error {
 		return err
 	}
 
-	err = s.aht.ResetSize(s.inmemPrecommittedTxID)
-	if err != nil {
-		return err
-	}
-	_, _, err = s.aht.Append(alh[:])
+	err = s.appendToAHT(ctx, alh)
 	if err != nil {
 		return err
 	}
@@ -1443,6 +1796,12 @@ func (s *ImmuStore) performPrecommit(tx *Tx, ts int64, blTxID uint64) error {
 	s.inmemPrecommittedAlh = alh
 	s.precommittedTxLogSize += int64(txSize)
 
+	var idempotencyKey []byte
+	if tx.header.Metadata != nil {
+		idempotencyKey = tx.header.Metadata.IdempotencyKey()
+	}
+	s.idempotency.put(s.inmemPrecommittedTxID, idempotencyKey)
+
 	s.inmemPrecommitWHub.DoneUpto(s.inmemPrecommittedTxID)
 
 	err = s.cLogBuf.put(s.inmemPrecommittedTxID, alh, txOff, txSize)
@@ -1499,6 +1858,10 @@ func (s *ImmuStore) DiscardPrecommittedTxsSince(txID uint64) (int, error) {
 
 	txsToDiscard := int(s.inmemPrecommittedTxID + 1 - txID)
 
+	s.discardedPrecommits++
+
+	s.idempotency.discardSince(txID)
+
 	err := s.aht.ResetSize(s.aht.Size() - uint64(txsToDiscard))
 	if err != nil {
 		return 0, err
@@ -1715,12 +2078,7 @@ func (s *ImmuStore) preCommitWith(ctx context.Context, callback func(txID uint64
 		return nil, err
 	}
 
-	err = s.validateEntries(otx.entries)
-	if err != nil {
-		return nil, err
-	}
-
-	err = s.validatePreconditions(otx.preconditions)
+	err = s.validateTx(ctx, otx)
 	if err != nil {
 		return nil, err
 	}
@@ -1749,7 +2107,7 @@ func (s *ImmuStore) preCommitWith(ctx context.Context, callback func(txID uint64
 	defer s.releaseAllocTx(tx)
 
 	appendableCh := make(chan appendableResult)
-	go s.appendData(otx.entries, appendableCh)
+	go s.appendData(ctx, otx.entries, appendableCh)
 
 	tx.header.Version = s.writeTxHeaderVersion
 	tx.header.NEntries = len(otx.entries)
@@ -1759,7 +2117,7 @@ func (s *ImmuStore) preCommitWith(ctx context.Context, callback func(txID uint64
 		txe.setKey(e.Key)
 		txe.md = e.Metadata
 		txe.vLen = len(e.Value)
-		txe.hVal = sha256.Sum256(e.Value)
+		txe.hVal = s.txHashAlg.Sum(e.Value)
 	}
 
 	err = tx.BuildHashTree()
@@ -1778,7 +2136,7 @@ func (s *ImmuStore) preCommitWith(ctx context.Context, callback func(txID uint64
 		tx.entries[i].vOff = r.offsets[i]
 	}
 
-	err = s.performPrecommit(tx, s.timeFunc().Unix(), s.aht.Size())
+	err = s.performPrecommit(ctx, tx, s.timeFunc().Unix(), s.aht.Size())
 	if err != nil {
 		return nil, err
 	}
@@ -1978,7 +2336,7 @@ type LinearAdvanceProof struct {
 	InclusionProofs  [][][sha256.Size]byte
 }
 
-func (s *ImmuStore) txOffsetAndSize(txID uint64) (int64, int, error) {
+func (s *ImmuStore) txOffsetAndSize(txID uint64, cLog io.ReaderAt) (int64, int, error) {
 	if txID == 0 {
 		return 0, 0, ErrIllegalArguments
 	}
@@ -1987,7 +2345,7 @@ func (s *ImmuStore) txOffsetAndSize(txID uint64) (int64, int, error) {
 
 	var cb [cLogEntrySize]byte
 
-	_, err := s.cLog.ReadAt(cb[:], int64(off))
+	_, err := cLog.ReadAt(cb[:], int64(off))
 	if err == multiapp.ErrAlreadyClosed || err == singleapp.ErrAlreadyClosed {
 		return 0, 0, ErrAlreadyClosed
 	}
@@ -2030,7 +2388,7 @@ func (r *slicedReaderAt) ReadAt(bs []byte, off int64) (n int, err error) {
 }
 
 func (s *ImmuStore) ExportTx(txID uint64, allowPrecommitted bool, tx *Tx) ([]byte, error) {
-	err := s.readTx(txID, allowPrecommitted, tx)
+	err := s.readTx(txID, allowPrecommitted, tx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -2094,6 +2452,9 @@ func (s *ImmuStore) ExportTx(txID uint64, allowPrecommitted bool, tx *Tx) ([]byt
 		// TODO: improve value reading implementation, get rid of _valBs
 		s._valBsMux.Lock()
 		_, err = s.readValueAt(s._valBs[:e.vLen], e.vOff, e.hVal)
+		if err == ErrSnapshotExpired {
+			err = io.EOF
+		}
 		if err != nil && err != io.EOF {
 			s._valBsMux.Unlock()
 			return nil, err
@@ -2154,6 +2515,21 @@ func (s *ImmuStore) ExportTx(txID uint64, allowPrecommitted bool, tx *Tx) ([]byt
 		return nil, err
 	}
 
+	// the tx hash algorithm is only included when it's not the default one,
+	// so that exported transactions remain readable by previous versions
+	if s.txHashAlg != hashing.SHA256 {
+		binary.BigEndian.PutUint16(b[:], 1)
+		_, err = buf.Write(b[:sszSize])
+		if err != nil {
+			return nil, err
+		}
+
+		err = buf.WriteByte(byte(s.txHashAlg))
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return buf.Bytes(), nil
 }
 
@@ -2263,10 +2639,36 @@ func (s *ImmuStore) ReplicateTx(ctx context.Context, exportedTx []byte, waitForI
 		i += tLen
 	}
 
+	txHashAlg := hashing.SHA256
+
+	// check if the transaction was exported from a store using a non-default tx hash algorithm
+	if i < len(exportedTx) {
+		if len(exportedTx) < i+sszSize {
+			return nil, ErrIllegalArguments
+		}
+
+		aLen := int(binary.BigEndian.Uint16(exportedTx[i:]))
+		i += sszSize
+
+		if aLen != 1 || len(exportedTx) < i+aLen {
+			return nil, ErrIllegalArguments
+		}
+
+		txHashAlg = hashing.Algorithm(exportedTx[i])
+		i += aLen
+	}
+
 	if i != len(exportedTx) {
 		return nil, ErrIllegalArguments
 	}
 
+	if txHashAlg != s.txHashAlg {
+		return nil, fmt.Errorf("%w: transaction built with '%s' can not be replicated into a store using '%s'",
+			ErrTxHashAlgorithmMismatch, txHashAlg, s.txHashAlg)
+	}
+
+	hdr.hashAlg = s.txHashAlg
+
 	// add entries to tx
 	for _, e := range entries {
 		var err error
@@ -2376,7 +2778,8 @@ func (s *ImmuStore) LastTxUntil(ts time.Time) (*TxHeader, error) {
 	return header, nil
 }
 
-func (s *ImmuStore) appendableReaderForTx(txID uint64, allowPrecommitted bool) (*appendable.Reader, error) {
+// appendableReaderForTx returns a reader of the tx data, pf is used to read the logs when provided
+func (s *ImmuStore) appendableReaderForTx(txID uint64, allowPrecommitted bool, pf *txPrefetcher) (*appendable.Reader, error) {
 	s.commitStateRWMutex.Lock()
 	defer s.commitStateRWMutex.Unlock()
 
@@ -2384,6 +2787,13 @@ func (s *ImmuStore) appendableReaderForTx(txID uint64, allowPrecommitted bool) (
 		return nil, ErrTxNotFound
 	}
 
+	var txLog, cLog io.ReaderAt = s.txLog, s.cLog
+
+	if pf != nil {
+		pf.setLimits(s)
+		txLog, cLog = pf.txLog, pf.cLog
+	}
+
 	cacheMiss := false
 
 	txbs, err := s.txLogCache.Get(txID)
@@ -2395,11 +2805,13 @@ func (s *ImmuStore) appendableReaderForTx(txID uint64, allowPrecommitted bool) (
 		}
 	}
 
+	s.observeCacheAccess(CacheTxs, !cacheMiss)
+
 	var txOff int64
 	var txSize int
 
 	if txID <= s.committedTxID {
-		txOff, txSize, err = s.txOffsetAndSize(txID)
+		txOff, txSize, err = s.txOffsetAndSize(txID, cLog)
 	} else {
 		_, _, txOff, txSize, err = s.cLogBuf.readAhead(int(txID - s.committedTxID - 1))
 	}
@@ -2410,7 +2822,7 @@ func (s *ImmuStore) appendableReaderForTx(txID uint64, allowPrecommitted bool) (
 	var txr io.ReaderAt
 
 	if cacheMiss {
-		txr = s.txLog
+		txr = txLog
 	} else {
 		txr = &slicedReaderAt{bs: txbs.([]byte), off: txOff}
 	}
@@ -2426,16 +2838,16 @@ func (s *ImmuStore) ReadTx(txID uint64, tx *Tx) error {
 		return ErrAlreadyClosed
 	}
 
-	return s.readTx(txID, false, tx)
+	return s.readTx(txID, false, tx, nil)
 }
 
-func (s *ImmuStore) readTx(txID uint64, allowPrecommitted bool, tx *Tx) error {
-	r, err := s.appendableReaderForTx(txID, allowPrecommitted)
+func (s *ImmuStore) readTx(txID uint64, allowPrecommitted bool, tx *Tx, pf *txPrefetcher) error {
+	r, err := s.appendableReaderForTx(txID, allowPrecommitted, pf)
 	if err != nil {
 		return err
 	}
 
-	err = tx.readFrom(r)
+	err = tx.readFrom(r, s.txHashAlg)
 	if err == io.EOF {
 		return fmt.Errorf("%w: unexpected EOF while reading tx %d", ErrorCorruptedTxData, txID)
 	}
@@ -2451,12 +2863,17 @@ func (s *ImmuStore) ReadTxHeader(txID uint64, allowPrecommitted bool) (*TxHeader
 		return nil, ErrAlreadyClosed
 	}
 
-	r, err := s.appendableReaderForTx(txID, allowPrecommitted)
+	r, err := s.appendableReaderForTx(txID, allowPrecommitted, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	builder, err := htree.NewBuilderWith(s.txHashAlg)
 	if err != nil {
 		return nil, err
 	}
 
-	tdr := &txDataReader{r: r}
+	tdr := &txDataReader{r: r, hashAlg: s.txHashAlg, builder: builder}
 
 	header, err := tdr.readHeader(s.maxTxEntries)
 	if err != nil {
@@ -2472,12 +2889,7 @@ func (s *ImmuStore) ReadTxHeader(txID uint64, allowPrecommitted bool) (*TxHeader
 		}
 	}
 
-	htree, err := htree.New(header.NEntries)
-	if err != nil {
-		return nil, err
-	}
-
-	err = tdr.buildAndValidateHtree(htree)
+	err = tdr.validateRoot()
 	if err != nil {
 		return nil, err
 	}
@@ -2488,12 +2900,17 @@ func (s *ImmuStore) ReadTxHeader(txID uint64, allowPrecommitted bool) (*TxHeader
 func (s *ImmuStore) ReadTxEntry(txID uint64, key []byte) (*TxEntry, *TxHeader, error) {
 	var ret *TxEntry
 
-	r, err := s.appendableReaderForTx(txID, false)
+	r, err := s.appendableReaderForTx(txID, false, nil)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	tdr := &txDataReader{r: r}
+	builder, err := htree.NewBuilderWith(s.txHashAlg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tdr := &txDataReader{r: r, hashAlg: s.txHashAlg, builder: builder}
 
 	header, err := tdr.readHeader(s.maxTxEntries)
 	if err != nil {
@@ -2522,12 +2939,7 @@ func (s *ImmuStore) ReadTxEntry(txID uint64, key []byte) (*TxEntry, *TxHeader, e
 		return nil, nil, ErrKeyNotFound
 	}
 
-	htree, err := htree.New(header.NEntries)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	err = tdr.buildAndValidateHtree(htree)
+	err = tdr.validateRoot()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -2552,7 +2964,7 @@ func (s *ImmuStore) ReadValue(entry *TxEntry) ([]byte, error) {
 
 	b := make([]byte, entry.vLen)
 
-	bval, err := s.readValueAt(b, entry.vOff, entry.hVal)
+	bval, err := s.resolveValueAt(b, entry.vOff, entry.hVal)
 	if err != nil {
 		return nil, err
 	}
@@ -2565,13 +2977,40 @@ func (s *ImmuStore) ReadValue(entry *TxEntry) ([]byte, error) {
 	return b, nil
 }
 
+// resolveValueAt reads the value stored at the given offset within a tracing span
+func (s *ImmuStore) resolveValueAt(b []byte, off int64, hvalue [sha256.Size]byte) (n int, err error) {
+	_, span := s.startSpan(context.Background(), SpanResolveValue, func() SpanAttrs {
+		return SpanAttrs{
+			SpanAttrValueSize: len(b),
+		}
+	})
+	defer func() { span.End(err) }()
+
+	return s.readValueAt(b, off, hvalue)
+}
+
+// isDiscarded returns true if the value stored at the given offset was already reclaimed by truncation
+func (s *ImmuStore) isDiscarded(vLogID byte, offset int64) bool {
+	return vLogID > 0 && offset < atomic.LoadInt64(&s.vLogs[vLogID-1].discardedUpTo)
+}
+
 func (s *ImmuStore) readValueAt(b []byte, off int64, hvalue [sha256.Size]byte) (n int, err error) {
+	vLogID, offset := decodeOffset(off)
+
+	// readers may outlive the data they reference e.g. a long running scan
+	// while the value logs get truncated, reclaimed regions must never be read
+	if s.isDiscarded(vLogID, offset) {
+		return 0, ErrSnapshotExpired
+	}
+
 	if s.vLogCache != nil {
 		val, err := s.vLogCache.Get(off)
+		s.observeCacheAccess(CacheValues, err == nil)
+
 		if err == nil {
 			// the requested value was found in the value cache
 			copy(b, val.([]byte))
-			if hvalue != sha256.Sum256(b) {
+			if hvalue != s.txHashAlg.Sum(b) {
 				return len(b), ErrCorruptedData
 			}
 			return len(b), nil
@@ -2582,20 +3021,24 @@ func (s *ImmuStore) readValueAt(b []byte, off int64, hvalue [sha256.Size]byte) (
 		}
 	}
 
-	vLogID, offset := decodeOffset(off)
 	if vLogID > 0 {
 		vLog := s.fetchVLog(vLogID)
 		defer s.releaseVLog(vLogID)
 
+		// truncation may have taken place while waiting for the vLog
+		if s.isDiscarded(vLogID, offset) {
+			return 0, ErrSnapshotExpired
+		}
+
 		n, err := vLog.ReadAt(b, offset)
-		if err == multiapp.ErrAlreadyClosed || err == singleapp.ErrAlreadyClosed {
+		if err == multiapp.ErrAlreadyClosed || err == singleapp.ErrAlreadyClosed || err == singlefile.ErrAlreadyClosed {
 			return n, ErrAlreadyClosed
 		}
 		if err != nil {
 			return n, err
 		}
 
-		if hvalue != sha256.Sum256(b) {
+		if hvalue != s.txHashAlg.Sum(b) {
 			return len(b), ErrCorruptedData
 		}
 
@@ -2662,18 +3105,126 @@ func (s *ImmuStore) Sync() error {
 		return ErrAlreadyClosed
 	}
 
-	return s.sync()
+	return s.sync(0)
 }
 
-func (s *ImmuStore) sync() error {
+// syncLogs fsyncs all the logs regardless of the commit state, so transactions
+// committed without waiting for fsync are made durable
+func (s *ImmuStore) syncLogs() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return ErrAlreadyClosed
+	}
+
 	s.commitStateRWMutex.Lock()
 	defer s.commitStateRWMutex.Unlock()
 
-	if s.inmemPrecommittedTxID == s.committedTxID {
+	for i := range s.vLogs {
+		vLog := s.fetchVLog(i + 1)
+		defer s.releaseVLog(i + 1)
+
+		err := vLog.Flush()
+		if err != nil {
+			return err
+		}
+
+		err = vLog.Sync()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := s.txLog.Flush()
+	if err != nil {
+		return err
+	}
+
+	err = s.txLog.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = s.cLog.Flush()
+	if err != nil {
+		return err
+	}
+
+	return s.cLog.Sync()
+}
+
+func (s *ImmuStore) syncPeriodically(frequency time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(frequency)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		err := s.syncLogs()
+		if errors.Is(err, ErrAlreadyClosed) {
+			return
+		}
+		if err != nil {
+			s.notify(Error, true, "%s: while syncing logs at '%s'", err, s.path)
+		}
+	}
+}
+
+// appendToAHT links the pre-committed transaction into the binary linking,
+// discarding any leaf left by a transaction which was not pre-committed
+func (s *ImmuStore) appendToAHT(ctx context.Context, alh [sha256.Size]byte) (err error) {
+	_, span := s.startSpan(ctx, SpanAHTAppend, func() SpanAttrs {
+		return SpanAttrs{
+			SpanAttrTxID: s.inmemPrecommittedTxID + 1,
+		}
+	})
+	defer func() { span.End(err) }()
+
+	err = s.aht.ResetSize(s.inmemPrecommittedTxID)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = s.aht.Append(alh[:])
+	return err
+}
+
+// sync makes the precommitted transactions durable and commits them,
+// at most maxBatchSize of them unless it's zero.
+// Logs are fsynced without holding the commit state, thus transactions keep being
+// precommitted meanwhile and are made durable together by the following sync
+func (s *ImmuStore) sync(maxBatchSize int) (err error) {
+	s.syncMutex.Lock()
+	defer s.syncMutex.Unlock()
+
+	s.commitStateRWMutex.RLock()
+	syncUpToTxID := s.inmemPrecommittedTxID
+	committedTxID := s.committedTxID
+	discardedPrecommits := s.discardedPrecommits
+	s.commitStateRWMutex.RUnlock()
+
+	if maxBatchSize > 0 && syncUpToTxID-committedTxID > uint64(maxBatchSize) {
+		// remaining transactions are committed by the following sync
+		syncUpToTxID = committedTxID + uint64(maxBatchSize)
+	}
+
+	if syncUpToTxID == committedTxID {
 		// everything already synced
 		return nil
 	}
 
+	_, span := s.startSpan(context.Background(), SpanSync, func() SpanAttrs {
+		return SpanAttrs{
+			SpanAttrTxID: syncUpToTxID,
+		}
+	})
+	defer func() { span.End(err) }()
+
 	for i := range s.vLogs {
 		vLog := s.fetchVLog(i + 1)
 		defer s.releaseVLog(i + 1)
@@ -2689,7 +3240,7 @@ func (s *ImmuStore) sync() error {
 		}
 	}
 
-	err := s.txLog.Flush()
+	err = s.txLog.Flush()
 	if err != nil {
 		return err
 	}
@@ -2699,12 +3250,21 @@ func (s *ImmuStore) sync() error {
 		return err
 	}
 
-	err = s.durablePrecommitWHub.DoneUpto(s.inmemPrecommittedTxID)
+	s.commitStateRWMutex.Lock()
+	defer s.commitStateRWMutex.Unlock()
+
+	if discardedPrecommits != s.discardedPrecommits {
+		// transactions precommitted after discarding may not be synced yet, they
+		// are committed by the following sync
+		return nil
+	}
+
+	err = s.durablePrecommitWHub.DoneUpto(syncUpToTxID)
 	if err != nil {
 		return err
 	}
 
-	commitAllowedUpToTxID := s.commitAllowedUpTo()
+	commitAllowedUpToTxID := minUint64(s.commitAllowedUpTo(), syncUpToTxID)
 	txsCountToBeCommitted := int(commitAllowedUpToTxID - s.committedTxID)
 
 	if txsCountToBeCommitted == 0 {
@@ -2785,6 +3345,22 @@ func (s *ImmuStore) Close() error {
 
 	s.closed = true
 
+	if s.reaperDone != nil {
+		close(s.reaperDone)
+	}
+
+	if s.truncatorDone != nil {
+		close(s.truncatorDone)
+	}
+
+	if s.asyncSyncerDone != nil {
+		close(s.asyncSyncerDone)
+	}
+
+	if s.scrubberDone != nil {
+		close(s.scrubberDone)
+	}
+
 	merr := multierr.NewMultiErr()
 
 	for i := range s.vLogs {
@@ -2824,11 +3400,16 @@ func (s *ImmuStore) Close() error {
 		merr.Append(errors.New("not all tx holders were released"))
 	}
 
+	if s.singleFile != nil {
+		err = s.singleFile.Close()
+		merr.Append(err)
+	}
+
 	return merr.Reduce()
 }
 
 func (s *ImmuStore) wrapAppendableErr(err error, action string) error {
-	if err == singleapp.ErrAlreadyClosed || err == multiapp.ErrAlreadyClosed {
+	if err == singleapp.ErrAlreadyClosed || err == multiapp.ErrAlreadyClosed || err == singlefile.ErrAlreadyClosed {
 		s.logger.Warningf("Got '%v' while '%s'", err, action)
 		return ErrAlreadyClosed
 	}
@@ -2876,12 +3457,12 @@ func (s *ImmuStore) readTxOffsetAt(txID uint64, allowPrecommitted bool, index in
 		return nil, ErrAlreadyClosed
 	}
 
-	r, err := s.appendableReaderForTx(txID, allowPrecommitted)
+	r, err := s.appendableReaderForTx(txID, allowPrecommitted, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	tdr := &txDataReader{r: r}
+	tdr := &txDataReader{r: r, hashAlg: s.txHashAlg}
 
 	hdr, err := tdr.readHeader(s.maxTxEntries)
 	if err != nil {
@@ -2934,7 +3515,7 @@ func (s *ImmuStore) TruncateUptoTx(minTxID uint64) error {
 		of values for any future transaction.
 	*/
 
-	s.logger.Infof("running truncation up to transaction '%d'", minTxID)
+	logger.With(s.logger, logger.FieldTxID, minTxID).Infof("running truncation up to transaction '%d'", minTxID)
 
 	var err error
 	// tombstones maintain the minimum offset for each value log file that can be safely deleted.
@@ -3014,11 +3595,21 @@ func (s *ImmuStore) TruncateUptoTx(minTxID uint64) error {
 			defer s.releaseVLog(vLogID)
 			s.logger.Infof("truncating vlog '%d' at offset '%d'", vLogID, offset)
 			err := vlog.DiscardUpto(offset)
+			if err == nil && offset > s.vLogs[vLogID-1].discardedUpTo {
+				atomic.StoreInt64(&s.vLogs[vLogID-1].discardedUpTo, offset)
+			}
 			merr.Append(err)
 		}
 	}
 
-	return merr.Reduce()
+	err = merr.Reduce()
+	if err != nil {
+		return err
+	}
+
+	s.indexer.tombstones.prune(minTxID)
+
+	return nil
 }
 
 func byte32(s []byte) [32]byte {
@@ -24,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -37,6 +38,9 @@ import (
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/mocked"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/appendable/singleapp"
+	"github.com/codenotary/immudb/embedded/cache"
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/htree"
 	"github.com/codenotary/immudb/embedded/tbtree"
 
@@ -190,6 +194,154 @@ func TestImmudbStoreConcurrentCommits(t *testing.T) {
 	wg.Wait()
 }
 
+func TestImmudbStoreSyncedConcurrentCommits(t *testing.T) {
+	opts := DefaultOptions().
+		WithSyncFrequency(5 * time.Millisecond).
+		WithMaxTxEntries(1024)
+
+	immuStore, err := Open(t.TempDir(), opts)
+	require.NoError(t, err)
+
+	defer immustoreClose(t, immuStore)
+
+	workers := 4
+	txCount := 10
+	// large enough for values to be hashed concurrently
+	eCount := 8 * parallelValueHashingThld
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			defer wg.Done()
+
+			txHolder := tempTxHolder(t, immuStore)
+
+			for c := 0; c < txCount; c++ {
+				tx, err := immuStore.NewWriteOnlyTx(context.Background())
+				require.NoError(t, err)
+
+				for j := 0; j < eCount; j++ {
+					err = tx.Set([]byte(fmt.Sprintf("key_%d_%d", w, j)), nil, []byte(fmt.Sprintf("value_%d_%d", c, j)))
+					require.NoError(t, err)
+				}
+
+				hdr, err := tx.Commit(context.Background())
+				require.NoError(t, err)
+
+				// syncs requested meanwhile don't interfere with group commits
+				err = immuStore.Sync()
+				require.NoError(t, err)
+
+				err = immuStore.ReadTx(hdr.ID, txHolder)
+				require.NoError(t, err)
+				require.Len(t, txHolder.Entries(), eCount)
+
+				for _, e := range txHolder.Entries() {
+					_, err := immuStore.ReadValue(e)
+					require.NoError(t, err)
+				}
+			}
+		}(w)
+	}
+
+	wg.Wait()
+
+	require.Equal(t, uint64(workers*txCount), immuStore.LastCommittedTxID())
+}
+
+func TestImmudbStoreIdempotencyKeys(t *testing.T) {
+	dir := t.TempDir()
+
+	opts := DefaultOptions().WithIdempotencyWindow(3)
+
+	immuStore, err := Open(dir, opts)
+	require.NoError(t, err)
+
+	commit := func(key string, idempotencyKey []byte) (*TxHeader, error) {
+		tx, err := immuStore.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		tx.WithMetadata(NewTxMetadata().WithIdempotencyKey(idempotencyKey))
+
+		err = tx.Set([]byte(key), nil, []byte("value"))
+		require.NoError(t, err)
+
+		return tx.Commit(context.Background())
+	}
+
+	_, err = immuStore.TxIDByIdempotencyKey(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = immuStore.TxIDByIdempotencyKey([]byte("token1"))
+	require.ErrorIs(t, err, ErrIdempotencyKeyNotFound)
+
+	_, err = commit("key1", make([]byte, MaxIdempotencyKeyLen+1))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	hdr, err := commit("key1", []byte("token1"))
+	require.NoError(t, err)
+
+	txID, err := immuStore.TxIDByIdempotencyKey([]byte("token1"))
+	require.NoError(t, err)
+	require.Equal(t, hdr.ID, txID)
+
+	// a retried transaction is rejected
+	_, err = commit("key1", []byte("token1"))
+	require.ErrorIs(t, err, ErrIdempotencyKeyAlreadyUsed)
+	require.Equal(t, hdr.ID, immuStore.LastCommittedTxID())
+
+	// idempotency keys are kept in the tx header but not in the index
+	err = immuStore.WaitForIndexingUpto(context.Background(), hdr.ID)
+	require.NoError(t, err)
+
+	valRef, err := immuStore.Get([]byte("key1"))
+	require.NoError(t, err)
+	require.Nil(t, valRef.TxMetadata())
+
+	txHdr, err := immuStore.ReadTxHeader(hdr.ID, false)
+	require.NoError(t, err)
+	require.Equal(t, []byte("token1"), txHdr.Metadata.IdempotencyKey())
+
+	hdr2, err := commit("key2", []byte("token2"))
+	require.NoError(t, err)
+
+	err = immuStore.Close()
+	require.NoError(t, err)
+
+	immuStore, err = Open(dir, opts)
+	require.NoError(t, err)
+
+	defer immustoreClose(t, immuStore)
+
+	txID, err = immuStore.TxIDByIdempotencyKey([]byte("token2"))
+	require.NoError(t, err)
+	require.Equal(t, hdr2.ID, txID)
+
+	_, err = commit("key2", []byte("token2"))
+	require.ErrorIs(t, err, ErrIdempotencyKeyAlreadyUsed)
+
+	for i := 0; i < 2; i++ {
+		_, err = commit("key3", nil)
+		require.NoError(t, err)
+	}
+
+	// token1 fell out of the window
+	_, err = immuStore.TxIDByIdempotencyKey([]byte("token1"))
+	require.ErrorIs(t, err, ErrIdempotencyKeyNotFound)
+
+	_, err = immuStore.TxIDByIdempotencyKey([]byte("token2"))
+	require.NoError(t, err)
+
+	hdr, err = commit("key1", []byte("token1"))
+	require.NoError(t, err)
+
+	txID, err = immuStore.TxIDByIdempotencyKey([]byte("token1"))
+	require.NoError(t, err)
+	require.Equal(t, hdr.ID, txID)
+}
+
 func TestImmudbStoreOpenWithInvalidPath(t *testing.T) {
 	_, err := Open("immustore_test.go", DefaultOptions())
 	require.ErrorIs(t, err, ErrorPathIsNotADirectory)
@@ -490,7 +642,8 @@ func TestImmudbStoreEdgeCases(t *testing.T) {
 			return 0, nil
 		}
 
-		_, err := OpenWith(t.TempDir(), vLogs, txLog, cLog, DefaultOptions())
+		// incomplete commits are only discarded when the store is writable
+		_, err := OpenWith(t.TempDir(), vLogs, txLog, cLog, DefaultOptions().WithReadOnly(true))
 		require.ErrorIs(t, err, ErrorCorruptedTxData)
 	})
 
@@ -849,6 +1002,24 @@ func TestImmudbStoreEdgeCases(t *testing.T) {
 		})
 		require.ErrorIs(t, err, ErrInvalidPrecondition)
 		require.ErrorIs(t, err, ErrInvalidPreconditionInvalidTxID)
+
+		for _, c := range []Precondition{
+			&PreconditionKeyValueHashMustEqual{},
+			&PreconditionKeyRevisionMustEqual{},
+			&PreconditionKeyMetadataMustMatch{},
+		} {
+			err = immuStore.validatePreconditions([]Precondition{c})
+			require.ErrorIs(t, err, ErrInvalidPreconditionNullKey)
+		}
+
+		for _, c := range []Precondition{
+			&PreconditionKeyValueHashMustEqual{Key: make([]byte, immuStore.maxKeyLen+1)},
+			&PreconditionKeyRevisionMustEqual{Key: make([]byte, immuStore.maxKeyLen+1)},
+			&PreconditionKeyMetadataMustMatch{Key: make([]byte, immuStore.maxKeyLen+1)},
+		} {
+			err = immuStore.validatePreconditions([]Precondition{c})
+			require.ErrorIs(t, err, ErrInvalidPreconditionMaxKeyLenExceeded)
+		}
 	})
 }
 
@@ -862,7 +1033,7 @@ func TestImmudbTxOffsetAndSize(t *testing.T) {
 	immuStore.mutex.Lock()
 	defer immuStore.mutex.Unlock()
 
-	_, _, err = immuStore.txOffsetAndSize(0)
+	_, _, err = immuStore.txOffsetAndSize(0, immuStore.cLog)
 	require.ErrorIs(t, err, ErrIllegalArguments)
 }
 
@@ -2687,6 +2858,93 @@ func TestImmudbStoreCommitWithPreconditions(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("value hash constraint should only pass when the current value has the expected hash", func(t *testing.T) {
+		for _, c := range []struct {
+			precondition Precondition
+			err          error
+		}{
+			{&PreconditionKeyValueHashMustEqual{Key: []byte("key2"), Hash: sha256.Sum256([]byte("value2"))}, nil},
+			{&PreconditionKeyValueHashMustEqual{Key: []byte("key2"), Hash: sha256.Sum256([]byte("value1"))}, ErrPreconditionFailed},
+			{&PreconditionKeyValueHashMustEqual{Key: []byte("key1"), Hash: sha256.Sum256(nil)}, ErrPreconditionFailed},
+		} {
+			otx, err := immuStore.NewTx(context.Background(), DefaultTxOptions())
+			require.NoError(t, err)
+
+			err = otx.Set([]byte("key6"), nil, []byte("value6"))
+			require.NoError(t, err)
+
+			err = otx.AddPrecondition(c.precondition)
+			require.NoError(t, err)
+
+			_, err = otx.Commit(context.Background())
+			if c.err == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, c.err)
+			}
+		}
+	})
+
+	t.Run("revision constraint should only pass when the latest entry is the expected revision", func(t *testing.T) {
+		for _, c := range []struct {
+			precondition Precondition
+			err          error
+		}{
+			{&PreconditionKeyRevisionMustEqual{Key: []byte("key1"), Revision: 2}, nil},
+			{&PreconditionKeyRevisionMustEqual{Key: []byte("key1"), Revision: 1}, ErrPreconditionFailed},
+			{&PreconditionKeyRevisionMustEqual{Key: []byte("nonExistentKey"), Revision: 0}, nil},
+			{&PreconditionKeyRevisionMustEqual{Key: []byte("nonExistentKey"), Revision: 1}, ErrPreconditionFailed},
+		} {
+			otx, err := immuStore.NewTx(context.Background(), DefaultTxOptions())
+			require.NoError(t, err)
+
+			err = otx.Set([]byte("key7"), nil, []byte("value7"))
+			require.NoError(t, err)
+
+			err = otx.AddPrecondition(c.precondition)
+			require.NoError(t, err)
+
+			_, err = otx.Commit(context.Background())
+			if c.err == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, c.err)
+			}
+		}
+	})
+
+	t.Run("metadata constraint should only pass when the latest entry holds the expected metadata", func(t *testing.T) {
+		deletedMD := NewKVMetadata()
+		deletedMD.AsDeleted(true)
+
+		for _, c := range []struct {
+			precondition Precondition
+			err          error
+		}{
+			{&PreconditionKeyMetadataMustMatch{Key: []byte("key1"), Metadata: deletedMD}, nil},
+			{&PreconditionKeyMetadataMustMatch{Key: []byte("key1")}, ErrPreconditionFailed},
+			{&PreconditionKeyMetadataMustMatch{Key: []byte("key2")}, nil},
+			{&PreconditionKeyMetadataMustMatch{Key: []byte("key2"), Metadata: deletedMD}, ErrPreconditionFailed},
+			{&PreconditionKeyMetadataMustMatch{Key: []byte("nonExistentKey")}, ErrPreconditionFailed},
+		} {
+			otx, err := immuStore.NewTx(context.Background(), DefaultTxOptions())
+			require.NoError(t, err)
+
+			err = otx.Set([]byte("key8"), nil, []byte("value8"))
+			require.NoError(t, err)
+
+			err = otx.AddPrecondition(c.precondition)
+			require.NoError(t, err)
+
+			_, err = otx.Commit(context.Background())
+			if c.err == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, c.err)
+			}
+		}
+	})
+
 	// insert an expirable entry
 	otx, err = immuStore.NewTx(context.Background(), DefaultTxOptions())
 	require.NoError(t, err)
@@ -4249,6 +4507,45 @@ func TestImmudbStoreWithoutVLogCache(t *testing.T) {
 	require.Equal(t, []byte("value1"), val)
 }
 
+func TestImmudbStoreWithShardedCaches(t *testing.T) {
+	opts := DefaultOptions().
+		WithVLogCacheSize(10).
+		WithCacheShards(4).
+		WithIndexOptions(DefaultIndexOptions().WithCacheShards(4))
+
+	immuStore, err := Open(t.TempDir(), opts)
+	require.NoError(t, err)
+
+	defer immuStore.Close()
+
+	require.IsType(t, &cache.ShardedCache{}, immuStore.txLogCache)
+	require.IsType(t, &cache.ShardedCache{}, immuStore.vLogCache)
+
+	for i := 0; i < 20; i++ {
+		tx, err := immuStore.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < 20; i++ {
+		valRef, err := immuStore.Get([]byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+
+		hdr, err := immuStore.ReadTxHeader(valRef.Tx(), false)
+		require.NoError(t, err)
+		require.Equal(t, valRef.Tx(), hdr.ID)
+	}
+}
+
 func TestImmudbStoreTruncateUptoTx_WithMultipleIOConcurrency(t *testing.T) {
 	opts := DefaultOptions().
 		WithFileSize(6).
@@ -4720,3 +5017,1120 @@ func TestImmudbStoreTruncateUptoTx_WithDataPostTruncationPoint(t *testing.T) {
 		}
 	}
 }
+
+func TestImmudbStoreTruncateUptoTx_WithConcurrentReader(t *testing.T) {
+	opts := DefaultOptions().
+		WithFileSize(6).
+		WithMaxIOConcurrency(1)
+
+	st, err := Open(t.TempDir(), opts)
+	require.NoError(t, err)
+	require.NotNil(t, st)
+	defer immustoreClose(t, st)
+
+	ctx := context.Background()
+
+	txCount := 100
+
+	for i := 1; i <= txCount; i++ {
+		tx, err := st.NewWriteOnlyTx(ctx)
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key_%03d", i)), nil, []byte(fmt.Sprintf("val_%03d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit(ctx)
+		require.NoError(t, err)
+	}
+
+	snap, err := st.SnapshotMustIncludeTxID(ctx, uint64(txCount))
+	require.NoError(t, err)
+	defer snap.Close()
+
+	r, err := snap.NewKeyReader(KeyReaderSpec{Prefix: []byte("key_")})
+	require.NoError(t, err)
+	defer r.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	truncationErrs := make(chan error, txCount)
+
+	go func() {
+		defer wg.Done()
+
+		for txID := 2; txID <= txCount-10; txID += 4 {
+			err := st.TruncateUptoTx(uint64(txID))
+			if err != nil {
+				truncationErrs <- err
+				return
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	readCount := 0
+
+	for {
+		key, valRef, err := r.Read()
+		if errors.Is(err, ErrNoMoreEntries) {
+			break
+		}
+		require.NoError(t, err)
+
+		readCount++
+
+		val, err := valRef.Resolve()
+		if errors.Is(err, ErrSnapshotExpired) {
+			// the value was reclaimed while scanning, it must never be read
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, strings.Replace(string(key), "key_", "val_", 1), string(val))
+
+		time.Sleep(time.Millisecond)
+	}
+
+	wg.Wait()
+	close(truncationErrs)
+
+	for err := range truncationErrs {
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, txCount, readCount)
+
+	t.Run("values reclaimed by truncation should not be readable", func(t *testing.T) {
+		valRef, err := snap.Get([]byte("key_001"))
+		require.NoError(t, err)
+
+		_, err = valRef.Resolve()
+		require.ErrorIs(t, err, ErrSnapshotExpired)
+
+		valRef, err = snap.Get([]byte(fmt.Sprintf("key_%03d", txCount)))
+		require.NoError(t, err)
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("val_%03d", txCount)), val)
+	})
+}
+
+type recordedSpan struct {
+	name  string
+	attrs SpanAttrs
+	ended bool
+	err   error
+}
+
+func (s *recordedSpan) SetAttr(key string, value interface{}) {
+	s.attrs[key] = value
+}
+
+func (s *recordedSpan) End(err error) {
+	s.ended = true
+	s.err = err
+}
+
+type spanCtxKey struct{}
+
+type recordingTracer struct {
+	mutex sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) StartSpan(ctx context.Context, name string, attrs SpanAttrs) (context.Context, Span) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	span := &recordedSpan{name: name, attrs: SpanAttrs{}}
+	for k, v := range attrs {
+		span.attrs[k] = v
+	}
+
+	if parent, ok := ctx.Value(spanCtxKey{}).(*recordedSpan); ok {
+		span.attrs["parent"] = parent.name
+	}
+
+	t.spans = append(t.spans, span)
+
+	return context.WithValue(ctx, spanCtxKey{}, span), span
+}
+
+func (t *recordingTracer) spansNamed(name string) []*recordedSpan {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var spans []*recordedSpan
+	for _, s := range t.spans {
+		if s.name == name {
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
+func TestImmudbStoreTracing(t *testing.T) {
+	tracer := &recordingTracer{}
+
+	st, err := Open(t.TempDir(), DefaultOptions().WithTracer(tracer))
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	parentCtx, parentSpan := tracer.StartSpan(context.Background(), "caller", nil)
+
+	tx, err := st.NewWriteOnlyTx(parentCtx)
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key1"), nil, []byte("value1"))
+	require.NoError(t, err)
+
+	hdr, err := tx.Commit(parentCtx)
+	require.NoError(t, err)
+
+	parentSpan.End(nil)
+
+	commitSpans := tracer.spansNamed(SpanCommit)
+	require.Len(t, commitSpans, 1)
+	require.True(t, commitSpans[0].ended)
+	require.NoError(t, commitSpans[0].err)
+	require.Equal(t, "caller", commitSpans[0].attrs["parent"])
+	require.Equal(t, hdr.ID, commitSpans[0].attrs[SpanAttrTxID])
+	require.Equal(t, 1, commitSpans[0].attrs[SpanAttrEntries])
+	require.Equal(t, len("key1")+len("value1"), commitSpans[0].attrs[SpanAttrBytes])
+
+	for _, phase := range []string{SpanValidate, SpanValueLogWrite, SpanAHTAppend} {
+		phaseSpans := tracer.spansNamed(phase)
+		require.Len(t, phaseSpans, 1, phase)
+		require.True(t, phaseSpans[0].ended, phase)
+		require.NoError(t, phaseSpans[0].err, phase)
+		require.Equal(t, SpanCommit, phaseSpans[0].attrs["parent"], phase)
+	}
+
+	require.Equal(t, len("value1"), tracer.spansNamed(SpanValueLogWrite)[0].attrs[SpanAttrBytes])
+	require.Equal(t, hdr.ID, tracer.spansNamed(SpanAHTAppend)[0].attrs[SpanAttrTxID])
+
+	syncSpans := tracer.spansNamed(SpanSync)
+	require.NotEmpty(t, syncSpans)
+	require.Equal(t, hdr.ID, syncSpans[0].attrs[SpanAttrTxID])
+
+	err = st.WaitForIndexingUpto(context.Background(), hdr.ID)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		for _, span := range tracer.spansNamed(SpanIndex) {
+			if span.ended && span.attrs[SpanAttrEntries] == 1 {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+
+	valRef, err := st.Get([]byte("key1"))
+	require.NoError(t, err)
+
+	_, err = valRef.Resolve()
+	require.NoError(t, err)
+
+	resolveSpans := tracer.spansNamed(SpanResolveValue)
+	require.Len(t, resolveSpans, 1)
+	require.True(t, resolveSpans[0].ended)
+	require.Equal(t, len("value1"), resolveSpans[0].attrs[SpanAttrValueSize])
+
+	err = st.FlushIndex(0, true)
+	require.NoError(t, err)
+
+	flushSpans := tracer.spansNamed(SpanFlushIndex)
+	require.Len(t, flushSpans, 1)
+	require.True(t, flushSpans[0].ended)
+	require.Equal(t, true, flushSpans[0].attrs[SpanAttrSynced])
+
+	err = st.CompactIndex()
+
+	compactionSpans := tracer.spansNamed(SpanCompactIndex)
+	require.Len(t, compactionSpans, 1)
+	require.True(t, compactionSpans[0].ended)
+	require.Equal(t, err, compactionSpans[0].err)
+}
+
+func TestImmudbStoreWithoutTracer(t *testing.T) {
+	st, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	valueSize := 10
+
+	// attributes are not built when no tracer is configured
+	allocs := testing.AllocsPerRun(100, func() {
+		_, span := st.startSpan(context.Background(), SpanResolveValue, func() SpanAttrs {
+			return SpanAttrs{SpanAttrValueSize: valueSize}
+		})
+		span.End(nil)
+	})
+	require.Zero(t, allocs)
+}
+
+type recordingMetrics struct {
+	mutex           sync.Mutex
+	commits         int
+	entries         int
+	valueLogBytes   int
+	indexerLag      uint64
+	cacheHits       map[string]int
+	cacheMisses     map[string]int
+	commitLatencies []time.Duration
+}
+
+func (m *recordingMetrics) ObserveCommit(latency time.Duration, entries int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.commits++
+	m.entries += entries
+	m.commitLatencies = append(m.commitLatencies, latency)
+}
+
+func (m *recordingMetrics) AddValueLogBytes(n int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.valueLogBytes += n
+}
+
+func (m *recordingMetrics) SetIndexerLag(txs uint64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.indexerLag = txs
+}
+
+func (m *recordingMetrics) ObserveCacheAccess(cache string, hit bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if hit {
+		m.cacheHits[cache]++
+	} else {
+		m.cacheMisses[cache]++
+	}
+}
+
+func TestImmudbStoreMetrics(t *testing.T) {
+	metrics := &recordingMetrics{
+		cacheHits:   map[string]int{},
+		cacheMisses: map[string]int{},
+	}
+
+	st, err := Open(t.TempDir(), DefaultOptions().WithMetricsCollector(metrics).WithVLogCacheSize(10))
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	for i := 0; i < 3; i++ {
+		tx, err := st.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte("value"))
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("other-key%d", i)), nil, []byte("other-value"))
+		require.NoError(t, err)
+
+		_, err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	err = st.WaitForIndexingUpto(context.Background(), 3)
+	require.NoError(t, err)
+
+	valRef, err := st.Get([]byte("key0"))
+	require.NoError(t, err)
+
+	_, err = valRef.Resolve()
+	require.NoError(t, err)
+
+	txHolder := tempTxHolder(t, st)
+
+	err = st.ReadTx(1, txHolder)
+	require.NoError(t, err)
+
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	require.Equal(t, 3, metrics.commits)
+	require.Equal(t, 6, metrics.entries)
+	require.Len(t, metrics.commitLatencies, 3)
+	require.Equal(t, 3*(len("value")+len("other-value")), metrics.valueLogBytes)
+	require.Equal(t, 1, metrics.cacheHits[CacheValues])
+	require.Equal(t, 0, metrics.cacheMisses[CacheValues])
+	require.Greater(t, metrics.cacheHits[CacheTxs]+metrics.cacheMisses[CacheTxs], 0)
+	require.Zero(t, metrics.indexerLag)
+}
+
+func TestImmudbStoreStats(t *testing.T) {
+	st, err := Open(t.TempDir(), DefaultOptions().WithVLogCacheSize(10))
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	stats, err := st.Stats()
+	require.NoError(t, err)
+	require.Zero(t, stats.ValueLogSize)
+	require.Zero(t, stats.IndexerLag)
+
+	for i := 0; i < 3; i++ {
+		tx, err := st.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte("value"))
+		require.NoError(t, err)
+
+		_, err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	err = st.WaitForIndexingUpto(context.Background(), 3)
+	require.NoError(t, err)
+
+	valRef, err := st.Get([]byte("key0"))
+	require.NoError(t, err)
+
+	_, err = valRef.Resolve()
+	require.NoError(t, err)
+
+	err = st.FlushIndex(0, true)
+	require.NoError(t, err)
+
+	stats, err = st.Stats()
+	require.NoError(t, err)
+	require.Equal(t, int64(3*len("value")), stats.ValueLogSize)
+	require.Zero(t, stats.IndexerLag)
+	require.Greater(t, stats.CacheHits, uint64(0))
+	require.False(t, stats.IndexWriteProgress.Flushing)
+	require.Equal(t, 3, stats.IndexWriteProgress.FlushedEntries)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go st.WaitForTx(ctx, 10, false)
+
+	require.Eventually(t, func() bool {
+		s, err := st.Stats()
+		require.NoError(t, err)
+		return s.WatchersWaiting == stats.WatchersWaiting+1
+	}, time.Second, time.Millisecond)
+}
+
+func TestImmudbStoreSingleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+
+	opts := DefaultOptions().WithSingleFile(true)
+
+	st, err := Open(path, opts)
+	require.NoError(t, err)
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	require.True(t, fi.Mode().IsRegular())
+
+	for i := 0; i < 10; i++ {
+		tx, err := st.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	err = st.CompactIndex()
+	require.ErrorIs(t, err, ErrCompactionUnsupported)
+
+	err = st.FlushIndex(0, true)
+	require.NoError(t, err)
+
+	err = st.Close()
+	require.NoError(t, err)
+
+	checkStore := func(st *ImmuStore) {
+		err = st.WaitForIndexingUpto(context.Background(), 10)
+		require.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			valRef, err := st.Get([]byte(fmt.Sprintf("key%d", i)))
+			require.NoError(t, err)
+
+			val, err := valRef.Resolve()
+			require.NoError(t, err)
+			require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+
+			tx, err := st.fetchAllocTx()
+			require.NoError(t, err)
+
+			err = st.readTx(uint64(i+1), false, tx, nil)
+			require.NoError(t, err)
+
+			_, err = st.DualProof(tx.Header(), tx.Header())
+			require.NoError(t, err)
+
+			st.releaseAllocTx(tx)
+		}
+	}
+
+	st, err = Open(path, opts)
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	checkStore(st)
+
+	tx, err := st.NewWriteOnlyTx(context.Background())
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key10"), nil, []byte("value10"))
+	require.NoError(t, err)
+
+	hdr, err := tx.Commit(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, 11, hdr.ID)
+}
+
+func TestImmudbStoreEncryption(t *testing.T) {
+	dir := t.TempDir()
+
+	keys := map[string][]byte{
+		"key1": []byte("0123456789abcdef0123456789abcdef"),
+		"key2": []byte("fedcba9876543210fedcba9876543210"),
+	}
+
+	keyProvider := func(keyID string) ([]byte, error) {
+		key, ok := keys[keyID]
+		if !ok {
+			return nil, fmt.Errorf("unknown key '%s'", keyID)
+		}
+		return key, nil
+	}
+
+	commit := func(st *ImmuStore, i int) {
+		tx, err := st.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("secret-key%d", i)), nil, []byte(fmt.Sprintf("secret-value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	opts := DefaultOptions().WithEncryption("key1", keyProvider)
+	opts.WithIndexOptions(opts.IndexOpts.WithFlushThld(1))
+
+	st, err := Open(dir, opts)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		commit(st, i)
+	}
+
+	err = st.CompactIndex()
+	require.NoError(t, err)
+
+	err = st.Close()
+	require.NoError(t, err)
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		bs, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		require.False(t, bytes.Contains(bs, []byte("secret")), "plain text found in '%s'", path)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, err = Open(dir, DefaultOptions())
+	require.ErrorIs(t, err, singleapp.ErrMissingEncryptionKey)
+
+	// key rotation, data written with key1 must remain readable
+	st, err = Open(dir, DefaultOptions().WithEncryption("key2", keyProvider))
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	commit(st, 10)
+
+	err = st.WaitForIndexingUpto(context.Background(), 11)
+	require.NoError(t, err)
+
+	for i := 0; i <= 10; i++ {
+		valRef, err := st.Get([]byte(fmt.Sprintf("secret-key%d", i)))
+		require.NoError(t, err)
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("secret-value%d", i)), val)
+	}
+}
+
+func TestImmudbStoreWithReadAhead(t *testing.T) {
+	st, err := Open(t.TempDir(), DefaultOptions().WithReadAheadWindowSize(1024))
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	txCount := 200
+
+	hdrs := make([]*TxHeader, txCount)
+
+	commit := func(i int) {
+		tx, err := st.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		hdrs[i], err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < txCount/2; i++ {
+		commit(i)
+	}
+
+	txHolder, err := st.fetchAllocTx()
+	require.NoError(t, err)
+	defer st.releaseAllocTx(txHolder)
+
+	r, err := st.NewTxReader(1, false, txHolder)
+	require.NoError(t, err)
+	require.NotNil(t, r.prefetcher)
+
+	readTxs := func(n int) {
+		for i := 0; i < n; i++ {
+			tx, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, hdrs[tx.header.ID-1].Alh(), tx.header.Alh())
+		}
+	}
+
+	readTxs(txCount / 2)
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreEntries)
+
+	// transactions committed after data was read ahead
+	for i := txCount / 2; i < txCount; i++ {
+		commit(i)
+	}
+
+	readTxs(txCount / 2)
+
+	err = st.WaitForIndexingUpto(context.Background(), uint64(txCount))
+	require.NoError(t, err)
+
+	for i := 0; i < txCount; i++ {
+		valRef, err := st.Get([]byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+		require.Equal(t, uint64(i+1), valRef.Tx())
+	}
+}
+
+func TestImmudbStoreWithChecksums(t *testing.T) {
+	dir := t.TempDir()
+
+	opts := DefaultOptions().
+		WithChecksums(appendable.DefaultChecksumBlockSize).
+		WithVerifyChecksums(true)
+
+	st, err := Open(dir, opts)
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		tx, err := st.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	err = st.Close()
+	require.NoError(t, err)
+
+	for _, subPath := range []string{"tx", "commit", "val_0"} {
+		require.NoError(t, appendable.Verify(filepath.Join(dir, subPath)))
+	}
+
+	st, err = Open(dir, opts)
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	err = st.WaitForIndexingUpto(context.Background(), 100)
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		valRef, err := st.Get([]byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+
+		_, err = st.ReadTxHeader(uint64(i+1), false)
+		require.NoError(t, err)
+	}
+}
+
+func TestImmudbStoreWithMmap(t *testing.T) {
+	dir := t.TempDir()
+
+	opts := DefaultOptions().
+		WithFileSize(1024).
+		WithMmap(true)
+
+	st, err := Open(dir, opts)
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		tx, err := st.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	check := func(st *ImmuStore) {
+		err = st.WaitForIndexingUpto(context.Background(), 100)
+		require.NoError(t, err)
+
+		for i := 0; i < 100; i++ {
+			valRef, err := st.Get([]byte(fmt.Sprintf("key%d", i)))
+			require.NoError(t, err)
+
+			val, err := valRef.Resolve()
+			require.NoError(t, err)
+			require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+
+			_, err = st.ReadTxHeader(uint64(i+1), false)
+			require.NoError(t, err)
+		}
+	}
+
+	check(st)
+
+	err = st.Close()
+	require.NoError(t, err)
+
+	st, err = Open(dir, opts)
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	check(st)
+}
+
+func TestImmudbStoreTxHashAlgorithm(t *testing.T) {
+	for _, alg := range []hashing.Algorithm{hashing.BLAKE2b256, hashing.SHA3_256} {
+		t.Run(alg.String(), func(t *testing.T) {
+			dir := t.TempDir()
+
+			st, err := Open(dir, DefaultOptions().WithTxHashAlgorithm(alg))
+			require.NoError(t, err)
+			require.Equal(t, alg, st.TxHashAlgorithm())
+
+			txCount := 5
+
+			hdrs := make([]*TxHeader, txCount)
+
+			for i := 0; i < txCount; i++ {
+				tx, err := st.NewWriteOnlyTx(context.Background())
+				require.NoError(t, err)
+
+				err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+				require.NoError(t, err)
+
+				hdrs[i], err = tx.Commit(context.Background())
+				require.NoError(t, err)
+				require.Equal(t, alg, hdrs[i].HashAlgorithm())
+			}
+
+			txHolder := tempTxHolder(t, st)
+
+			for i := 0; i < txCount; i++ {
+				err = st.readTx(uint64(i+1), false, txHolder, nil)
+				require.NoError(t, err)
+
+				key := []byte(fmt.Sprintf("key%d", i))
+				value := []byte(fmt.Sprintf("value%d", i))
+
+				proof, err := txHolder.Proof(key)
+				require.NoError(t, err)
+
+				entrySpecDigest, err := EntrySpecDigestWith(alg, txHolder.header.Version)
+				require.NoError(t, err)
+
+				digest := entrySpecDigest(&EntrySpec{Key: key, Value: value})
+
+				require.True(t, VerifyInclusionWith(alg, proof, digest, txHolder.header.Eh))
+				require.False(t, VerifyInclusion(proof, digest, txHolder.header.Eh))
+
+				valRef, err := st.Get(key)
+				require.NoError(t, err)
+				require.Equal(t, alg.Sum(value), valRef.HVal())
+
+				for j := i; j < txCount; j++ {
+					dproof, err := st.DualProof(hdrs[i], hdrs[j])
+					require.NoError(t, err)
+
+					require.True(t, VerifyDualProofWith(alg, dproof, hdrs[i].ID, hdrs[j].ID, hdrs[i].Alh(), hdrs[j].Alh()))
+					require.False(t, VerifyDualProof(dproof, hdrs[i].ID, hdrs[j].ID, hdrs[i].Alh(), hdrs[j].Alh()))
+				}
+			}
+
+			err = st.Close()
+			require.NoError(t, err)
+
+			// the algorithm recorded in the metadata takes precedence over the provided one
+			st, err = Open(dir, DefaultOptions())
+			require.NoError(t, err)
+			defer immustoreClose(t, st)
+
+			require.Equal(t, alg, st.TxHashAlgorithm())
+
+			tx, err := st.NewWriteOnlyTx(context.Background())
+			require.NoError(t, err)
+
+			err = tx.Set([]byte("key"), nil, []byte("value"))
+			require.NoError(t, err)
+
+			hdr, err := tx.Commit(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, hdrs[txCount-1].Alh(), hdr.PrevAlh)
+
+			dproof, err := st.DualProof(hdrs[0], hdr)
+			require.NoError(t, err)
+			require.True(t, VerifyDualProofWith(alg, dproof, hdrs[0].ID, hdr.ID, hdrs[0].Alh(), hdr.Alh()))
+		})
+	}
+}
+
+func TestExportAndReplicateTxWithTxHashAlgorithm(t *testing.T) {
+	primaryStore, err := Open(t.TempDir(), DefaultOptions().WithTxHashAlgorithm(hashing.BLAKE2b256))
+	require.NoError(t, err)
+	defer immustoreClose(t, primaryStore)
+
+	replicaStore, err := Open(t.TempDir(), DefaultOptions().WithTxHashAlgorithm(hashing.BLAKE2b256))
+	require.NoError(t, err)
+	defer immustoreClose(t, replicaStore)
+
+	sha256Store, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, sha256Store)
+
+	tx, err := primaryStore.NewWriteOnlyTx(context.Background())
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key1"), nil, []byte("value1"))
+	require.NoError(t, err)
+
+	hdr, err := tx.Commit(context.Background())
+	require.NoError(t, err)
+
+	etx, err := primaryStore.ExportTx(1, false, tempTxHolder(t, primaryStore))
+	require.NoError(t, err)
+
+	_, err = sha256Store.ReplicateTx(context.Background(), etx, false)
+	require.ErrorIs(t, err, ErrTxHashAlgorithmMismatch)
+
+	rhdr, err := replicaStore.ReplicateTx(context.Background(), etx, false)
+	require.NoError(t, err)
+	require.Equal(t, hdr.ID, rhdr.ID)
+	require.Equal(t, hdr.Alh(), rhdr.Alh())
+
+	tx, err = sha256Store.NewWriteOnlyTx(context.Background())
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key1"), nil, []byte("value1"))
+	require.NoError(t, err)
+
+	_, err = tx.Commit(context.Background())
+	require.NoError(t, err)
+
+	etx, err = sha256Store.ExportTx(1, false, tempTxHolder(t, sha256Store))
+	require.NoError(t, err)
+
+	_, err = replicaStore.ReplicateTx(context.Background(), etx, false)
+	require.ErrorIs(t, err, ErrTxHashAlgorithmMismatch)
+}
+
+func TestImmudbStoreDurabilityModes(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		opts *Options
+	}{
+		{"sync-per-tx", DefaultOptions().WithDurability(SyncPerTxDurability)},
+		{"group-commit", DefaultOptions().WithDurability(GroupCommitDurability).WithSyncMaxBatchSize(2)},
+		{"async", DefaultOptions().WithAsyncSyncFrequency(time.Millisecond).WithDurability(AsyncDurability)},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			st, err := Open(dir, c.opts)
+			require.NoError(t, err)
+
+			var wg sync.WaitGroup
+
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+
+				go func(i int) {
+					defer wg.Done()
+
+					tx, err := st.NewWriteOnlyTx(context.Background())
+					require.NoError(t, err)
+
+					err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte("value"))
+					require.NoError(t, err)
+
+					_, err = tx.Commit(context.Background())
+					require.NoError(t, err)
+				}(i)
+			}
+
+			wg.Wait()
+
+			err = st.syncLogs()
+			require.NoError(t, err)
+
+			require.NoError(t, st.Close())
+
+			st, err = Open(dir, c.opts)
+			require.NoError(t, err)
+			defer immustoreClose(t, st)
+
+			require.Equal(t, uint64(10), st.LastCommittedTxID())
+
+			err = st.syncLogs()
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestImmudbStoreSyncMaxBatchSize(t *testing.T) {
+	tracer := &recordingTracer{}
+
+	opts := DefaultOptions().
+		WithSyncFrequency(100 * time.Millisecond).
+		WithSyncMaxBatchSize(2).
+		WithMaxConcurrency(10).
+		WithTracer(tracer)
+
+	st, err := Open(t.TempDir(), opts)
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			tx, err := st.NewWriteOnlyTx(context.Background())
+			require.NoError(t, err)
+
+			err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte("value"))
+			require.NoError(t, err)
+
+			_, err = tx.Commit(context.Background())
+			require.NoError(t, err)
+		}(i)
+	}
+
+	wg.Wait()
+
+	require.Equal(t, uint64(10), st.LastCommittedTxID())
+
+	var syncedUpToTxID uint64
+
+	for _, span := range tracer.spansNamed(SpanSync) {
+		txID := span.attrs[SpanAttrTxID].(uint64)
+		require.LessOrEqual(t, txID-syncedUpToTxID, uint64(2))
+
+		syncedUpToTxID = txID
+	}
+
+	require.Equal(t, uint64(10), syncedUpToTxID)
+}
+
+func TestImmudbStoreRecoveryFromTornWrites(t *testing.T) {
+	dir := t.TempDir()
+
+	st, err := Open(dir, DefaultOptions())
+	require.NoError(t, err)
+
+	for i := 1; i <= 3; i++ {
+		tx, err := st.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	err = st.Close()
+	require.NoError(t, err)
+
+	truncateTail := func(pattern string, n int64) {
+		files, err := filepath.Glob(filepath.Join(dir, pattern))
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+
+		fi, err := os.Stat(files[0])
+		require.NoError(t, err)
+
+		err = os.Truncate(files[0], fi.Size()-n)
+		require.NoError(t, err)
+	}
+
+	// the last transaction is partially written and the commit log
+	// holds a partially written entry as well
+	truncateTail("tx/*.tx", 5)
+	truncateTail("commit/*.txi", 3)
+
+	// the index was flushed on close, it's removed to emulate a crash
+	// happening before the index got synced
+	err = os.RemoveAll(filepath.Join(dir, "index"))
+	require.NoError(t, err)
+
+	st, err = Open(dir, DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	require.Equal(t, uint64(2), st.LastCommittedTxID())
+
+	_, err = st.Get([]byte("key3"))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	tx, err := st.NewWriteOnlyTx(context.Background())
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key3"), nil, []byte("value3"))
+	require.NoError(t, err)
+
+	hdr, err := tx.Commit(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), hdr.ID)
+
+	for i := uint64(1); i <= 3; i++ {
+		require.NoError(t, st.VerifyTx(i))
+	}
+}
+
+func TestImmudbStoreCorruptedCommittedTxIsNotDiscarded(t *testing.T) {
+	dir := t.TempDir()
+
+	st, err := Open(dir, DefaultOptions())
+	require.NoError(t, err)
+
+	for i := 1; i <= 3; i++ {
+		tx, err := st.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	err = st.Close()
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "tx/*.tx"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	f, err := os.OpenFile(files[0], os.O_RDWR, 0)
+	require.NoError(t, err)
+
+	fi, err := f.Stat()
+	require.NoError(t, err)
+
+	// the last committed transaction is fully written but its content is corrupted
+	b := make([]byte, 1)
+	_, err = f.ReadAt(b, fi.Size()-10)
+	require.NoError(t, err)
+
+	original := b[0]
+
+	_, err = f.WriteAt([]byte{original + 1}, fi.Size()-10)
+	require.NoError(t, err)
+
+	_, err = Open(dir, DefaultOptions())
+	require.ErrorIs(t, err, ErrorCorruptedTxData)
+
+	// committed transactions are kept, so the store can be opened once data gets repaired
+	_, err = f.WriteAt([]byte{original}, fi.Size()-10)
+	require.NoError(t, err)
+
+	err = f.Close()
+	require.NoError(t, err)
+
+	st, err = Open(dir, DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	require.Equal(t, uint64(3), st.LastCommittedTxID())
+}
+
+func TestImmudbStoreWithSharedIndexCache(t *testing.T) {
+	indexCache, err := tbtree.NewSharedCache(1<<20, tbtree.DefaultSharedCacheShards)
+	require.NoError(t, err)
+
+	opts := DefaultOptions().WithIndexOptions(DefaultIndexOptions().WithSharedCache(indexCache))
+
+	stores := make([]*ImmuStore, 3)
+
+	for i := range stores {
+		stores[i], err = Open(t.TempDir(), opts)
+		require.NoError(t, err)
+	}
+
+	for i, st := range stores {
+		tx, err := st.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key"), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	for i, st := range stores {
+		err = st.WaitForIndexingUpto(context.Background(), 1)
+		require.NoError(t, err)
+
+		valRef, err := st.Get([]byte("key"))
+		require.NoError(t, err)
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+	}
+
+	for _, st := range stores {
+		err = st.Close()
+		require.NoError(t, err)
+	}
+
+	require.Zero(t, indexCache.Size())
+}
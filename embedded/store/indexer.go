@@ -30,6 +30,7 @@ import (
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
 	"github.com/codenotary/immudb/embedded/tbtree"
 	"github.com/codenotary/immudb/embedded/watchers"
+	"github.com/codenotary/immudb/pkg/logger"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -40,6 +41,9 @@ type indexer struct {
 	store *ImmuStore
 	tx    *Tx
 
+	// nil when read-ahead is disabled
+	prefetcher *txPrefetcher
+
 	maxBulkSize            int
 	bulkPreparationTimeout time.Duration
 
@@ -47,6 +51,8 @@ type indexer struct {
 
 	index *tbtree.TBtree
 
+	tombstones *tombstones
+
 	ctx        context.Context
 	cancelFunc context.CancelFunc
 	wHub       *watchers.WatchersHub
@@ -94,23 +100,30 @@ func newIndexer(path string, store *ImmuStore, opts *Options) (*indexer, error)
 	indexOpts := tbtree.DefaultOptions().
 		WithReadOnly(opts.ReadOnly).
 		WithFileMode(opts.FileMode).
-		WithLogger(opts.logger).
+		WithLogger(logger.With(opts.logger, logger.FieldComponent, "index")).
 		WithFileSize(opts.FileSize).
 		WithCacheSize(opts.IndexOpts.CacheSize).
+		WithCacheShards(opts.IndexOpts.CacheShards).
+		WithSharedCache(opts.IndexOpts.SharedCache).
 		WithFlushThld(opts.IndexOpts.FlushThld).
 		WithSyncThld(opts.IndexOpts.SyncThld).
 		WithFlushBufferSize(opts.IndexOpts.FlushBufferSize).
 		WithCleanupPercentage(opts.IndexOpts.CleanupPercentage).
 		WithMaxActiveSnapshots(opts.IndexOpts.MaxActiveSnapshots).
 		WithMaxNodeSize(opts.IndexOpts.MaxNodeSize).
+		WithBloomFilterBitsPerKey(opts.IndexOpts.BloomFilterBitsPerKey).
+		WithPrefixCompression(opts.IndexOpts.PrefixCompression).
 		WithMaxKeySize(opts.MaxKeyLen).
-		WithMaxValueSize(lszSize + offsetSize + sha256.Size + sszSize + maxTxMetadataLen + sszSize + maxKVMetadataLen). // indexed values
+		WithMaxValueSize(lszSize+offsetSize+sha256.Size+sszSize+maxIndexedTxMetadataLen+sszSize+maxKVMetadataLen). // indexed values
 		WithNodesLogMaxOpenedFiles(opts.IndexOpts.NodesLogMaxOpenedFiles).
 		WithHistoryLogMaxOpenedFiles(opts.IndexOpts.HistoryLogMaxOpenedFiles).
 		WithCommitLogMaxOpenedFiles(opts.IndexOpts.CommitLogMaxOpenedFiles).
 		WithRenewSnapRootAfter(opts.IndexOpts.RenewSnapRootAfter).
 		WithCompactionThld(opts.IndexOpts.CompactionThld).
-		WithDelayDuringCompaction(opts.IndexOpts.DelayDuringCompaction)
+		WithDelayDuringCompaction(opts.IndexOpts.DelayDuringCompaction).
+		WithCompactionRateLimit(opts.IndexOpts.CompactionRateLimit).
+		WithEncryption(opts.EncryptionKeyID, opts.KeyProvider).
+		WithMmap(opts.Mmap)
 
 	if opts.appFactory != nil {
 		indexOpts.WithAppFactory(func(rootPath, subPath string, appOpts *multiapp.Options) (appendable.Appendable, error) {
@@ -118,14 +131,30 @@ func newIndexer(path string, store *ImmuStore, opts *Options) (*indexer, error)
 		})
 	}
 
-	index, err := tbtree.Open(path, indexOpts)
+	var index *tbtree.TBtree
+	var err error
+
+	if opts.SingleFile {
+		index, err = openSingleFileIndex(store, path, indexOpts, opts)
+	} else {
+		index, err = tbtree.Open(path, indexOpts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tombstones, err := openTombstones(store, opts, index.Ts())
 	if err != nil {
+		index.Close()
 		return nil, err
 	}
 
 	var wHub *watchers.WatchersHub
 	if opts.MaxWaitees > 0 {
-		wHub = watchers.New(0, opts.MaxWaitees)
+		wHub, err = watchers.New(0, watchers.DefaultOptions().WithMaxWaiting(opts.MaxWaitees))
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	tx, err := store.fetchAllocTx()
@@ -136,18 +165,20 @@ func newIndexer(path string, store *ImmuStore, opts *Options) (*indexer, error)
 	kvs := make([]*tbtree.KVT, store.maxTxEntries*opts.IndexOpts.MaxBulkSize)
 	for i := range kvs {
 		// vLen + vOff + vHash + txmdLen + txmd + kvmdLen + kvmd
-		elen := lszSize + offsetSize + sha256.Size + sszSize + maxTxMetadataLen + sszSize + maxKVMetadataLen
+		elen := lszSize + offsetSize + sha256.Size + sszSize + maxIndexedTxMetadataLen + sszSize + maxKVMetadataLen
 		kvs[i] = &tbtree.KVT{K: make([]byte, store.maxKeyLen), V: make([]byte, elen)}
 	}
 
 	indexer := &indexer{
 		store:                  store,
 		tx:                     tx,
+		prefetcher:             store.newTxPrefetcher(),
 		maxBulkSize:            opts.IndexOpts.MaxBulkSize,
 		bulkPreparationTimeout: opts.IndexOpts.BulkPreparationTimeout,
 		_kvs:                   kvs,
 		path:                   path,
 		index:                  index,
+		tombstones:             tombstones,
 		wHub:                   wHub,
 		state:                  stopped,
 		stateCond:              sync.NewCond(&sync.Mutex{}),
@@ -169,6 +200,13 @@ func (idx *indexer) Ts() uint64 {
 	return idx.index.Ts()
 }
 
+func (idx *indexer) stats() (lastIndexedTxID uint64, progress tbtree.WriteProgress) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	return idx.index.Ts(), idx.index.WriteProgress()
+}
+
 func (idx *indexer) Get(key []byte) (value []byte, tx uint64, hc uint64, err error) {
 	idx.mutex.Lock()
 	defer idx.mutex.Unlock()
@@ -252,6 +290,12 @@ func (idx *indexer) Close() error {
 
 	idx.closed = true
 
+	err := idx.tombstones.close()
+	if err != nil {
+		idx.index.Close()
+		return err
+	}
+
 	return idx.index.Close()
 }
 
@@ -377,6 +421,7 @@ func (idx *indexer) doIndexing() {
 	for {
 		lastIndexedTx := idx.index.Ts()
 		idx.metricsLastIndexedTrx.Set(float64(lastIndexedTx))
+		idx.store.metrics.SetIndexerLag(idx.store.LastCommittedTxID() - lastIndexedTx)
 
 		if idx.wHub != nil {
 			idx.wHub.DoneUpto(lastIndexedTx)
@@ -421,7 +466,14 @@ func (idx *indexer) doIndexing() {
 	}
 }
 
-func (idx *indexer) indexSince(txID uint64) error {
+func (idx *indexer) indexSince(txID uint64) (err error) {
+	_, span := idx.store.startSpan(context.Background(), SpanIndex, func() SpanAttrs {
+		return SpanAttrs{
+			SpanAttrTxID: txID,
+		}
+	})
+	defer func() { span.End(err) }()
+
 	ctx, cancel := context.WithTimeout(context.Background(), idx.bulkPreparationTimeout)
 	defer cancel()
 
@@ -429,7 +481,7 @@ func (idx *indexer) indexSince(txID uint64) error {
 	indexableEntries := 0
 
 	for i := 0; i < idx.maxBulkSize; i++ {
-		err := idx.store.readTx(txID+uint64(i), false, idx.tx)
+		err := idx.store.readTx(txID+uint64(i), false, idx.tx, idx.prefetcher)
 		if err != nil {
 			return err
 		}
@@ -439,18 +491,26 @@ func (idx *indexer) indexSince(txID uint64) error {
 		var txmd []byte
 
 		if idx.tx.header.Metadata != nil {
-			txmd = idx.tx.header.Metadata.Bytes()
+			txmd = idx.tx.header.Metadata.indexedBytes()
 		}
 
 		txmdLen := len(txmd)
 
 		for _, e := range txEntries {
+			if e.md != nil && e.md.DeletedPrefix() {
+				// tombstones must be registered before the transaction is marked as indexed
+				err = idx.tombstones.add(txID+uint64(i), e.key())
+				if err != nil {
+					return err
+				}
+			}
+
 			if e.md != nil && e.md.NonIndexable() {
 				continue
 			}
 
 			// vLen + vOff + vHash + txmdLen + txmd + kvmdLen + kvmd
-			var b [lszSize + offsetSize + sha256.Size + sszSize + maxTxMetadataLen + sszSize + maxKVMetadataLen]byte
+			var b [lszSize + offsetSize + sha256.Size + sszSize + maxIndexedTxMetadataLen + sszSize + maxKVMetadataLen]byte
 			o := 0
 
 			binary.BigEndian.PutUint32(b[o:], uint32(e.vLen))
@@ -503,7 +563,7 @@ func (idx *indexer) indexSince(txID uint64) error {
 		}
 	}
 
-	var err error
+	span.SetAttr(SpanAttrEntries, indexableEntries)
 
 	if indexableEntries == 0 {
 		// if there are no entries to be indexed, the logical time in the tree
@@ -17,8 +17,10 @@ limitations under the License.
 package store
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"time"
 
@@ -30,6 +32,7 @@ type Snapshot struct {
 	snap           *tbtree.Snapshot
 	ts             time.Time
 	refInterceptor valueRefInterceptor
+	asOfTx         uint64 // when set, entries are resolved as they were right after this transaction
 }
 
 type valueRefInterceptor func(key []byte, valRef ValueRef) ValueRef
@@ -82,12 +85,7 @@ func (s *Snapshot) Get(key []byte) (valRef ValueRef, err error) {
 }
 
 func (s *Snapshot) GetWithFilters(key []byte, filters ...FilterFn) (valRef ValueRef, err error) {
-	indexedVal, tx, hc, err := s.snap.Get(key)
-	if err != nil {
-		return nil, err
-	}
-
-	valRef, err = s.st.valueRefFrom(tx, hc, indexedVal)
+	valRef, err = s.get(key)
 	if err != nil {
 		return nil, err
 	}
@@ -115,12 +113,7 @@ func (s *Snapshot) GetWithPrefix(prefix []byte, neq []byte) (key []byte, valRef
 }
 
 func (s *Snapshot) GetWithPrefixAndFilters(prefix []byte, neq []byte, filters ...FilterFn) (key []byte, valRef ValueRef, err error) {
-	key, indexedVal, tx, hc, err := s.snap.GetWithPrefix(prefix, neq)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	valRef, err = s.st.valueRefFrom(tx, hc, indexedVal)
+	key, valRef, err = s.getWithPrefix(prefix, neq)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -143,11 +136,119 @@ func (s *Snapshot) GetWithPrefixAndFilters(prefix []byte, neq []byte, filters ..
 	return key, valRef, nil
 }
 
+func (s *Snapshot) get(key []byte) (ValueRef, error) {
+	if s.asOfTx > 0 {
+		_, valRef, err := s.getAsOf(tbtree.ReaderSpec{
+			SeekKey:       key,
+			EndKey:        key,
+			InclusiveSeek: true,
+			InclusiveEnd:  true,
+		}, nil)
+		return valRef, err
+	}
+
+	indexedVal, tx, hc, err := s.snap.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	valRef, err := s.st.valueRefFrom(tx, hc, indexedVal)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.st.withTombstones(key, valRef, s.snap.Ts()), nil
+}
+
+func (s *Snapshot) getWithPrefix(prefix []byte, neq []byte) ([]byte, ValueRef, error) {
+	if s.asOfTx > 0 {
+		return s.getAsOf(tbtree.ReaderSpec{
+			SeekKey:       prefix,
+			Prefix:        prefix,
+			InclusiveSeek: true,
+		}, neq)
+	}
+
+	key, indexedVal, tx, hc, err := s.snap.GetWithPrefix(prefix, neq)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	valRef, err := s.st.valueRefFrom(tx, hc, indexedVal)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, s.st.withTombstones(key, valRef, s.snap.Ts()), nil
+}
+
+// getAsOf returns the first key matching the spec, other than neq, which was already set
+// when the transaction asOfTx got committed, together with the value it had at that time
+func (s *Snapshot) getAsOf(spec tbtree.ReaderSpec, neq []byte) ([]byte, ValueRef, error) {
+	r, err := s.snap.NewReader(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+
+	kr := &storeKeyReader{
+		snap:           s,
+		reader:         r,
+		refInterceptor: func(key []byte, valRef ValueRef) ValueRef { return valRef },
+	}
+
+	for {
+		key, valRef, err := kr.ReadBetween(0, s.asOfTx)
+		if errors.Is(err, ErrNoMoreEntries) {
+			return nil, nil, ErrKeyNotFound
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if neq != nil && bytes.Equal(key, neq) {
+			continue
+		}
+
+		return key, valRef, nil
+	}
+}
+
+func (s *Snapshot) GetBatch(keys [][]byte) (valRefs []ValueRef, err error) {
+	return s.GetBatchWithFilters(keys, IgnoreExpired, IgnoreDeleted)
+}
+
+// GetBatchWithFilters resolves all the keys against the snapshot. Values are returned
+// in the same order as the keys, nil is returned for keys which are not found or
+// which are filtered out
+func (s *Snapshot) GetBatchWithFilters(keys [][]byte, filters ...FilterFn) (valRefs []ValueRef, err error) {
+	valRefs = make([]ValueRef, len(keys))
+
+	for i, key := range keys {
+		valRef, err := s.GetWithFilters(key, filters...)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		valRefs[i] = valRef
+	}
+
+	return valRefs, nil
+}
+
 func (s *Snapshot) History(key []byte, offset uint64, descOrder bool, limit int) (tss []uint64, hCount uint64, err error) {
 	return s.snap.History(key, offset, descOrder, limit)
 }
 
+// Ts returns the last transaction visible through the snapshot
 func (s *Snapshot) Ts() uint64 {
+	if s.asOfTx > 0 {
+		return s.asOfTx
+	}
+
 	return s.snap.Ts()
 }
 
@@ -247,7 +348,7 @@ func (st *ImmuStore) valueRefFrom(tx, hc uint64, indexedVal []byte) (ValueRef, e
 		txmdLen := int(binary.BigEndian.Uint16(indexedVal[i:]))
 		i += sszSize
 
-		if txmdLen > maxTxMetadataLen || len(indexedVal) < i+txmdLen+sszSize {
+		if txmdLen > maxIndexedTxMetadataLen || len(indexedVal) < i+txmdLen+sszSize {
 			return nil, ErrCorruptedIndex
 		}
 
@@ -303,7 +404,7 @@ func (v *valueRef) Resolve() (val []byte, err error) {
 		return nil, ErrExpiredEntry
 	}
 
-	_, err = v.st.readValueAt(refVal, v.vOff, v.hVal)
+	_, err = v.st.resolveValueAt(refVal, v.vOff, v.hVal)
 	if err != nil {
 		return nil, err
 	}
@@ -368,6 +469,8 @@ func (r *storeKeyReader) ReadBetween(initialTxID, finalTxID uint64) (key []byte,
 			st:     r.snap.st,
 		}
 
+		val = r.snap.st.withTombstones(key, val, finalTxID)
+
 		valRef := r.refInterceptor(key, val)
 
 		filterEntry := false
@@ -394,6 +497,10 @@ func (r *storeKeyReader) ReadBetween(initialTxID, finalTxID uint64) (key []byte,
 }
 
 func (r *storeKeyReader) Read() (key []byte, val ValueRef, err error) {
+	if r.snap.asOfTx > 0 {
+		return r.ReadBetween(0, r.snap.asOfTx)
+	}
+
 	for {
 		key, indexedVal, tx, hc, err := r.reader.Read()
 		if err != nil {
@@ -405,6 +512,8 @@ func (r *storeKeyReader) Read() (key []byte, val ValueRef, err error) {
 			return nil, nil, err
 		}
 
+		val = r.snap.st.withTombstones(key, val, r.snap.snap.Ts())
+
 		valRef := r.refInterceptor(key, val)
 
 		filterEntry := false
@@ -19,7 +19,9 @@ package store
 import (
 	"context"
 	"encoding/binary"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -277,3 +279,169 @@ func TestImmudbStoreReaderAsBeforeWithOffset(t *testing.T) {
 		require.NoError(t, err)
 	}
 }
+
+func TestImmudbStoreGetBatch(t *testing.T) {
+	immuStore, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+
+	defer immuStore.Close()
+
+	tx, err := immuStore.NewWriteOnlyTx(context.Background())
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key1"), nil, []byte("value1"))
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key2"), nil, []byte("value2"))
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("key3"), nil, []byte("value3"))
+	require.NoError(t, err)
+
+	_, err = tx.Commit(context.Background())
+	require.NoError(t, err)
+
+	tx, err = immuStore.NewWriteOnlyTx(context.Background())
+	require.NoError(t, err)
+
+	md := NewKVMetadata()
+	md.AsDeleted(true)
+
+	err = tx.Set([]byte("key2"), md, nil)
+	require.NoError(t, err)
+
+	_, err = tx.Commit(context.Background())
+	require.NoError(t, err)
+
+	valRefs, err := immuStore.GetBatch([][]byte{[]byte("key3"), []byte("key2"), []byte("key4"), []byte("key1")})
+	require.NoError(t, err)
+	require.Len(t, valRefs, 4)
+	require.Nil(t, valRefs[1])
+	require.Nil(t, valRefs[2])
+
+	val, err := valRefs[0].Resolve()
+	require.NoError(t, err)
+	require.Equal(t, []byte("value3"), val)
+
+	val, err = valRefs[3].Resolve()
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), val)
+
+	valRefs, err = immuStore.GetBatchWithFilters([][]byte{[]byte("key2")})
+	require.NoError(t, err)
+	require.True(t, valRefs[0].KVMetadata().Deleted())
+
+	_, err = immuStore.GetBatchWithFilters([][]byte{[]byte("key1")}, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
+
+func TestImmudbStoreSnapshotAsOf(t *testing.T) {
+	st, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	_, err = st.SnapshotAsOfTime(context.Background(), time.Now())
+	require.ErrorIs(t, err, ErrTxNotFound)
+
+	_, err = st.SnapshotAsOfTx(context.Background(), 1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	t0 := time.Now().Add(-10 * 24 * time.Hour).Truncate(time.Second)
+
+	// one transaction per day, the first one only sets key1
+	for i := 1; i <= 5; i++ {
+		txTime := t0.Add(time.Duration(i) * 24 * time.Hour)
+
+		err = st.UseTimeFunc(func() time.Time { return txTime })
+		require.NoError(t, err)
+
+		tx, err := st.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte("key1"), nil, []byte(fmt.Sprintf("value1_%d", i)))
+		require.NoError(t, err)
+
+		if i > 1 {
+			err = tx.Set([]byte("key2"), nil, []byte(fmt.Sprintf("value2_%d", i)))
+			require.NoError(t, err)
+		}
+
+		_, err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	t.Run("before the first transaction", func(t *testing.T) {
+		_, err := st.SnapshotAsOfTime(context.Background(), t0)
+		require.ErrorIs(t, err, ErrTxNotFound)
+	})
+
+	t.Run("in between transactions", func(t *testing.T) {
+		// half a day after the third transaction
+		snap, err := st.SnapshotAsOfTime(context.Background(), t0.Add(3*24*time.Hour+12*time.Hour))
+		require.NoError(t, err)
+		defer snap.Close()
+
+		require.Equal(t, uint64(3), snap.Ts())
+
+		valRef, err := snap.Get([]byte("key1"))
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), valRef.Tx())
+		require.Equal(t, uint64(3), valRef.HC())
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, []byte("value1_3"), val)
+
+		key, valRef, err := snap.GetWithPrefix([]byte("key"), []byte("key1"))
+		require.NoError(t, err)
+		require.Equal(t, []byte("key2"), key)
+		require.Equal(t, uint64(3), valRef.Tx())
+
+		valRefs, err := snap.GetBatch([][]byte{[]byte("key1"), []byte("key2"), []byte("key3")})
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), valRefs[0].Tx())
+		require.Equal(t, uint64(3), valRefs[1].Tx())
+		require.Nil(t, valRefs[2])
+
+		r, err := snap.NewKeyReader(KeyReaderSpec{Prefix: []byte("key")})
+		require.NoError(t, err)
+		defer r.Close()
+
+		for _, expectedKey := range []string{"key1", "key2"} {
+			key, valRef, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, []byte(expectedKey), key)
+			require.Equal(t, uint64(3), valRef.Tx())
+		}
+
+		_, _, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreEntries)
+	})
+
+	t.Run("keys set later are not visible", func(t *testing.T) {
+		snap, err := st.SnapshotAsOfTx(context.Background(), 1)
+		require.NoError(t, err)
+		defer snap.Close()
+
+		valRef, err := snap.Get([]byte("key1"))
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), valRef.Tx())
+
+		_, err = snap.Get([]byte("key2"))
+		require.ErrorIs(t, err, ErrKeyNotFound)
+
+		_, _, err = snap.GetWithPrefix([]byte("key"), []byte("key1"))
+		require.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("latest transaction", func(t *testing.T) {
+		snap, err := st.SnapshotAsOfTime(context.Background(), time.Now())
+		require.NoError(t, err)
+		defer snap.Close()
+
+		valRef, err := snap.Get([]byte("key2"))
+		require.NoError(t, err)
+		require.Equal(t, uint64(5), valRef.Tx())
+		require.Equal(t, uint64(4), valRef.HC())
+	})
+}
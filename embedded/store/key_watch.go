@@ -0,0 +1,124 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"bytes"
+	"context"
+)
+
+type KeyOp int
+
+const (
+	KeyOpSet KeyOp = iota
+	KeyOpDelete
+	// the key is a prefix tombstone, it may cover keys matching the watched prefix
+	KeyOpDeletePrefix
+)
+
+func (op KeyOp) String() string {
+	switch op {
+	case KeyOpSet:
+		return "set"
+	case KeyOpDelete:
+		return "delete"
+	case KeyOpDeletePrefix:
+		return "delete-prefix"
+	}
+	return "unknown"
+}
+
+// KeyNotification describes a write of a watched key
+type KeyNotification struct {
+	Key  []byte
+	TxID uint64
+	Op   KeyOp
+}
+
+// KeyWatcher streams notifications about committed writes of the keys
+// matching a prefix
+type KeyWatcher interface {
+	// Next blocks until a matching key is written in a committed transaction or ctx is done
+	Next(ctx context.Context) (*KeyNotification, error)
+	Close() error
+}
+
+type keyWatcher struct {
+	prefix []byte
+
+	sub TxIterator
+
+	pending []*KeyNotification
+}
+
+// Watch returns a watcher notified about the keys matching the prefix written
+// by the transactions committed after the watcher is created.
+// An empty prefix matches every key.
+func (s *ImmuStore) Watch(prefix []byte) (KeyWatcher, error) {
+	sub, err := s.SubscribeTx(s.LastCommittedTxID() + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	p := make([]byte, len(prefix))
+	copy(p, prefix)
+
+	return &keyWatcher{
+		prefix: p,
+		sub:    sub,
+	}, nil
+}
+
+func (w *keyWatcher) Next(ctx context.Context) (*KeyNotification, error) {
+	for len(w.pending) == 0 {
+		tx, err := w.sub.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range tx.Entries() {
+			op := KeyOpSet
+			if e.md != nil && e.md.DeletedPrefix() {
+				op = KeyOpDeletePrefix
+			} else if e.md != nil && e.md.Deleted() {
+				op = KeyOpDelete
+			}
+
+			matches := bytes.HasPrefix(e.key(), w.prefix) ||
+				(op == KeyOpDeletePrefix && bytes.HasPrefix(w.prefix, e.key()))
+
+			if !matches {
+				continue
+			}
+
+			w.pending = append(w.pending, &KeyNotification{
+				Key:  e.Key(),
+				TxID: tx.header.ID,
+				Op:   op,
+			})
+		}
+	}
+
+	n := w.pending[0]
+	w.pending = w.pending[1:]
+
+	return n, nil
+}
+
+func (w *keyWatcher) Close() error {
+	return w.sub.Close()
+}
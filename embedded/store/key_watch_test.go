@@ -0,0 +1,105 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	st, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	commit := func(key string, op KeyOp) uint64 {
+		tx, err := st.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		var md *KVMetadata
+		switch op {
+		case KeyOpDelete:
+			md = NewKVMetadata()
+			err = md.AsDeleted(true)
+			require.NoError(t, err)
+		case KeyOpDeletePrefix:
+			md = NewKVMetadata()
+			err = md.AsDeletedPrefix(true)
+			require.NoError(t, err)
+		}
+
+		err = tx.Set([]byte(key), md, []byte("value"))
+		require.NoError(t, err)
+
+		hdr, err := tx.Commit(context.Background())
+		require.NoError(t, err)
+
+		return hdr.ID
+	}
+
+	// writes committed before watching are not notified
+	commit("user:1", KeyOpSet)
+
+	w, err := st.Watch([]byte("user:"))
+	require.NoError(t, err)
+
+	commit("order:1", KeyOpSet)
+	txID := commit("user:2", KeyOpSet)
+
+	n, err := w.Next(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []byte("user:2"), n.Key)
+	require.Equal(t, txID, n.TxID)
+	require.Equal(t, KeyOpSet, n.Op)
+
+	t.Run("Next should wait for matching writes", func(t *testing.T) {
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			commit("order:2", KeyOpSet)
+			commit("user:2", KeyOpDelete)
+			commit("user", KeyOpDeletePrefix)
+		}()
+
+		n, err := w.Next(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []byte("user:2"), n.Key)
+		require.Equal(t, KeyOpDelete, n.Op)
+		require.Equal(t, "delete", n.Op.String())
+
+		n, err = w.Next(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []byte("user"), n.Key)
+		require.Equal(t, KeyOpDeletePrefix, n.Op)
+	})
+
+	t.Run("Next should honor the context", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := w.Next(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	err = w.Close()
+	require.NoError(t, err)
+
+	_, err = w.Next(context.Background())
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
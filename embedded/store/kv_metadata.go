@@ -28,16 +28,18 @@ var ErrNonExpirable = errors.New("non expirable")
 var ErrReadOnly = errors.New("read-only")
 
 const (
-	deletedAttrCode      attributeCode = 0
-	expiresAtAttrCode    attributeCode = 1
-	nonIndexableAttrCode attributeCode = 2
+	deletedAttrCode       attributeCode = 0
+	expiresAtAttrCode     attributeCode = 1
+	nonIndexableAttrCode  attributeCode = 2
+	deletedPrefixAttrCode attributeCode = 3
 )
 
 const deletedAttrSize = 0
 const expiresAtAttrSize = tsSize
 const nonIndexableAttrSize = 0
+const deletedPrefixAttrSize = 0
 
-const maxKVMetadataLen = (attrCodeSize + deletedAttrSize) + (attrCodeSize + expiresAtAttrSize) + (attrCodeSize + nonIndexableAttrSize)
+const maxKVMetadataLen = (attrCodeSize + deletedAttrSize) + (attrCodeSize + expiresAtAttrSize) + (attrCodeSize + nonIndexableAttrSize) + (attrCodeSize + deletedPrefixAttrSize)
 
 type KVMetadata struct {
 	attributes map[attributeCode]attribute
@@ -98,6 +100,21 @@ func (a *nonIndexableAttribute) deserialize(b []byte) (int, error) {
 	return 0, nil
 }
 
+type deletedPrefixAttribute struct {
+}
+
+func (a *deletedPrefixAttribute) code() attributeCode {
+	return deletedPrefixAttrCode
+}
+
+func (a *deletedPrefixAttribute) serialize() []byte {
+	return nil
+}
+
+func (a *deletedPrefixAttribute) deserialize(b []byte) (int, error) {
+	return 0, nil
+}
+
 func NewKVMetadata() *KVMetadata {
 	return &KVMetadata{
 		attributes: make(map[attributeCode]attribute),
@@ -201,10 +218,35 @@ func (md *KVMetadata) NonIndexable() bool {
 	return ok
 }
 
+// AsDeletedPrefix marks the entry as a tombstone of every key prefixed by the key of the entry,
+// including the key itself. Only versions written before the tombstone are deleted
+func (md *KVMetadata) AsDeletedPrefix(deletedPrefix bool) error {
+	if md.readonly {
+		return ErrReadOnly
+	}
+
+	if !deletedPrefix {
+		delete(md.attributes, deletedPrefixAttrCode)
+		return nil
+	}
+
+	_, ok := md.attributes[deletedPrefixAttrCode]
+	if !ok {
+		md.attributes[deletedPrefixAttrCode] = &deletedPrefixAttribute{}
+	}
+
+	return md.AsDeleted(true)
+}
+
+func (md *KVMetadata) DeletedPrefix() bool {
+	_, ok := md.attributes[deletedPrefixAttrCode]
+	return ok
+}
+
 func (md *KVMetadata) Bytes() []byte {
 	var b bytes.Buffer
 
-	for _, attrCode := range []attributeCode{deletedAttrCode, expiresAtAttrCode, nonIndexableAttrCode} {
+	for _, attrCode := range []attributeCode{deletedAttrCode, expiresAtAttrCode, nonIndexableAttrCode, deletedPrefixAttrCode} {
 		attr, ok := md.attributes[attrCode]
 		if ok {
 			b.WriteByte(byte(attr.code()))
@@ -266,6 +308,10 @@ func newAttribute(attrCode attributeCode) (attribute, error) {
 		{
 			return &nonIndexableAttribute{}, nil
 		}
+	case deletedPrefixAttrCode:
+		{
+			return &deletedPrefixAttribute{}, nil
+		}
 	default:
 		{
 			return nil, fmt.Errorf("error reading metadata attributes: %w", ErrCorruptedData)
@@ -36,6 +36,7 @@ func TestKVMetadata(t *testing.T) {
 	require.False(t, md.Deleted())
 	require.False(t, md.IsExpirable())
 	require.False(t, md.NonIndexable())
+	require.False(t, md.DeletedPrefix())
 
 	_, err = md.ExpirationTime()
 	require.ErrorIs(t, err, ErrNonExpirable)
@@ -61,6 +62,9 @@ func TestKVMetadata(t *testing.T) {
 
 		err = desmd.AsNonIndexable(true)
 		require.ErrorIs(t, err, ErrReadOnly)
+
+		err = desmd.AsDeletedPrefix(true)
+		require.ErrorIs(t, err, ErrReadOnly)
 	})
 
 	desmd := NewKVMetadata()
@@ -95,6 +99,12 @@ func TestKVMetadata(t *testing.T) {
 	desmd.AsNonIndexable(true)
 	require.True(t, desmd.NonIndexable())
 
+	desmd.AsDeletedPrefix(false)
+	require.False(t, desmd.DeletedPrefix())
+
+	desmd.AsDeletedPrefix(true)
+	require.True(t, desmd.DeletedPrefix())
+
 	bs = desmd.Bytes()
 	require.NotNil(t, bs)
 	require.Len(t, bs, maxKVMetadataLen)
@@ -105,4 +115,5 @@ func TestKVMetadata(t *testing.T) {
 	require.True(t, desmd.IsExpirable())
 	require.True(t, desmd.ExpiredAt(now))
 	require.True(t, desmd.NonIndexable())
+	require.True(t, desmd.DeletedPrefix())
 }
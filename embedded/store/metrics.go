@@ -0,0 +1,119 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/tbtree"
+	"github.com/codenotary/immudb/embedded/watchers"
+)
+
+const (
+	CacheValues = "values"
+	CacheTxs    = "txs"
+)
+
+// MetricsCollector is a generic hook used to observe the engine.
+// It's meant to be implemented by adapters of metrics libraries e.g. Prometheus,
+// methods are called from the commit and indexing paths thus they must not block
+type MetricsCollector interface {
+	// ObserveCommit is called once a transaction is durably committed
+	ObserveCommit(latency time.Duration, entries int)
+
+	// AddValueLogBytes is called with the number of value bytes appended to the value logs
+	AddValueLogBytes(n int)
+
+	// SetIndexerLag is called with the number of committed transactions which are not yet indexed
+	SetIndexerLag(txs uint64)
+
+	// ObserveCacheAccess is called on every lookup into the named cache (CacheValues or CacheTxs)
+	ObserveCacheAccess(cache string, hit bool)
+}
+
+type noopMetricsCollector struct{}
+
+func (noopMetricsCollector) ObserveCommit(latency time.Duration, entries int) {}
+
+func (noopMetricsCollector) AddValueLogBytes(n int) {}
+
+func (noopMetricsCollector) SetIndexerLag(txs uint64) {}
+
+func (noopMetricsCollector) ObserveCacheAccess(cache string, hit bool) {}
+
+// Stats is a point-in-time view of the internal state of the store,
+// meant to be polled e.g. to feed gauges
+type Stats struct {
+	// number of committed transactions which are not yet indexed
+	IndexerLag uint64
+
+	// number of goroutines waiting for transactions to be precommitted, committed or indexed
+	WatchersWaiting int
+
+	// number of bytes written into the value logs
+	ValueLogSize int64
+
+	// lookups into the transaction and value caches since the store was opened
+	CacheHits   uint64
+	CacheMisses uint64
+
+	// progress of the ongoing or last flush and compaction of the index
+	IndexWriteProgress tbtree.WriteProgress
+}
+
+// Stats doesn't wait for ongoing commits
+func (s *ImmuStore) Stats() (*Stats, error) {
+	stats := &Stats{
+		CacheHits:   atomic.LoadUint64(&s.cacheHits),
+		CacheMisses: atomic.LoadUint64(&s.cacheMisses),
+	}
+
+	for _, vLog := range s.vLogs {
+		vLogSize, err := vLog.vLog.Size()
+		if err != nil {
+			return nil, err
+		}
+		stats.ValueLogSize += vLogSize
+	}
+
+	for _, wHub := range []*watchers.WatchersHub{s.inmemPrecommitWHub, s.durablePrecommitWHub, s.commitWHub, s.indexer.wHub} {
+		if wHub != nil {
+			stats.WatchersWaiting += wHub.Metrics().Waiting
+		}
+	}
+
+	lastIndexedTxID, progress := s.indexer.stats()
+
+	if committedTxID := s.LastCommittedTxID(); committedTxID > lastIndexedTxID {
+		stats.IndexerLag = committedTxID - lastIndexedTxID
+	}
+
+	stats.IndexWriteProgress = progress
+
+	return stats, nil
+}
+
+func (s *ImmuStore) observeCacheAccess(cache string, hit bool) {
+	if hit {
+		atomic.AddUint64(&s.cacheHits, 1)
+	} else {
+		atomic.AddUint64(&s.cacheMisses, 1)
+	}
+
+	s.metrics.ObserveCacheAccess(cache, hit)
+}
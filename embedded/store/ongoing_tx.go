@@ -23,6 +23,8 @@ import (
 	"errors"
 	"fmt"
 	"time"
+
+	"github.com/codenotary/immudb/embedded/hashing"
 )
 
 // OngoingTx (no-thread safe) represents an interactive or incremental transaction with support of RYOW.
@@ -124,10 +126,11 @@ func newOngoingTx(ctx context.Context, s *ImmuStore, opts *TxOptions) (*OngoingT
 		entrySpec := tx.entries[keyRef]
 
 		return &ongoingValRef{
-			hc:    valRef.HC(),
-			value: entrySpec.Value,
-			txmd:  tx.metadata,
-			kvmd:  entrySpec.Metadata,
+			hc:      valRef.HC(),
+			value:   entrySpec.Value,
+			txmd:    tx.metadata,
+			kvmd:    entrySpec.Metadata,
+			hashAlg: s.txHashAlg,
 		}
 	}
 
@@ -135,10 +138,11 @@ func newOngoingTx(ctx context.Context, s *ImmuStore, opts *TxOptions) (*OngoingT
 }
 
 type ongoingValRef struct {
-	value []byte
-	hc    uint64
-	txmd  *TxMetadata
-	kvmd  *KVMetadata
+	value   []byte
+	hc      uint64
+	txmd    *TxMetadata
+	kvmd    *KVMetadata
+	hashAlg hashing.Algorithm
 }
 
 func (oref *ongoingValRef) Resolve() (val []byte, err error) {
@@ -162,7 +166,7 @@ func (oref *ongoingValRef) KVMetadata() *KVMetadata {
 }
 
 func (oref *ongoingValRef) HVal() [sha256.Size]byte {
-	return sha256.Sum256(oref.value)
+	return oref.hashAlg.Sum(oref.value)
 }
 
 func (oref *ongoingValRef) Len() uint32 {
@@ -299,6 +303,16 @@ func (tx *OngoingTx) Delete(key []byte) error {
 	return tx.Set(key, md, nil)
 }
 
+// DeletePrefix deletes every key with the given prefix by writing a single tombstone entry.
+// Keys set in later transactions, or within the same transaction, are not affected.
+func (tx *OngoingTx) DeletePrefix(prefix []byte) error {
+	md := NewKVMetadata()
+
+	md.AsDeletedPrefix(true)
+
+	return tx.Set(prefix, md, nil)
+}
+
 func (tx *OngoingTx) Get(key []byte) (ValueRef, error) {
 	return tx.GetWithFilters(key, IgnoreExpired, IgnoreDeleted)
 }
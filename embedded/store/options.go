@@ -24,29 +24,35 @@ import (
 	"github.com/codenotary/immudb/embedded/ahtree"
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/tbtree"
 	"github.com/codenotary/immudb/pkg/logger"
 )
 
 const DefaultMaxActiveTransactions = 1000
 const DefaultMVCCReadSetLimit = 100_000
+const DefaultIdempotencyWindow = 1000
 const DefaultMaxConcurrency = 30
 const DefaultMaxIOConcurrency = 1
 const DefaultMaxTxEntries = 1 << 10 // 1024
 const DefaultMaxKeyLen = 1024
 const DefaultMaxValueLen = 4096 // 4Kb
 const DefaultSyncFrequency = 20 * time.Millisecond
+const DefaultAsyncSyncFrequency = 1 * time.Second
 const DefaultFileMode = os.FileMode(0755)
 const DefaultFileSize = multiapp.DefaultFileSize
 const DefaultCompressionFormat = appendable.DefaultCompressionFormat
 const DefaultCompressionLevel = appendable.DefaultCompressionLevel
 const DefaultTxLogCacheSize = 1000
 const DefaultVLogCacheSize = 0
+const DefaultCacheShards = 1
+const DefaultReadAheadWindowSize = 1 << 16 // 64Kb
 const DefaultMaxWaitees = 1000
 const DefaultVLogMaxOpenedFiles = 10
 const DefaultTxLogMaxOpenedFiles = 10
 const DefaultCommitLogMaxOpenedFiles = 10
 const DefaultWriteTxHeaderVersion = MaxTxHeaderVersion
+const DefaultTxHashAlgorithm = hashing.SHA256
 const DefaultWriteBufferSize = 1 << 22 //4Mb
 const DefaultIndexingMaxBulkSize = 1
 const DefaultBulkPreparationTimeout = DefaultSyncFrequency
@@ -56,6 +62,9 @@ const MinimumTruncationFrequency = 1 * time.Hour
 
 const MaxFileSize = (1 << 31) - 1 // 2Gb
 
+// MaxScrubberRate is the highest number of transactions the scrubber can verify per second
+const MaxScrubberRate = 1_000_000
+
 type AppFactoryFunc func(
 	rootPath string,
 	subPath string,
@@ -64,6 +73,22 @@ type AppFactoryFunc func(
 
 type TimeFunc func() time.Time
 
+// Durability selects how commits are made durable, see Options.WithDurability
+type Durability int
+
+const (
+	// GroupCommitDurability fsyncs the transactions precommitted within SyncFrequency
+	// (up to SyncMaxBatchSize of them) together, before they are committed
+	GroupCommitDurability Durability = iota
+
+	// SyncPerTxDurability fsyncs as soon as a transaction is precommitted
+	SyncPerTxDurability
+
+	// AsyncDurability commits without waiting for fsync, data is periodically
+	// synced every AsyncSyncFrequency. Recently committed transactions may be lost on crash
+	AsyncDurability
+)
+
 type Options struct {
 	ReadOnly bool
 
@@ -73,6 +98,13 @@ type Options struct {
 	// Fsync frequency during commit process
 	SyncFrequency time.Duration
 
+	// Maximum number of transactions committed by the same fsync during commit process, zero means no limit.
+	// Waiting for more transactions to be precommitted stops as soon as the batch is complete
+	SyncMaxBatchSize int
+
+	// Fsync frequency when Synced is disabled, zero disables periodic fsync
+	AsyncSyncFrequency time.Duration
+
 	// Size of the in-memory buffer for write operations
 	WriteBufferSize int
 
@@ -80,16 +112,27 @@ type Options struct {
 
 	logger logger.Logger
 
+	tracer Tracer
+
+	metrics MetricsCollector
+
 	appFactory AppFactoryFunc
 
 	CompactionDisabled bool
 
+	// Keep all the data (logs, binary linking and index) within a single file.
+	// Index compaction is not supported in this mode
+	SingleFile bool
+
 	// Maximum number of pre-committed transactions
 	MaxActiveTransactions int
 
 	// Limit the number of read entries per transaction
 	MVCCReadSetLimit int
 
+	// Number of latest transactions whose idempotency keys are remembered
+	IdempotencyWindow int
+
 	// Maximum number of simultaneous commits prepared for write
 	MaxConcurrency int
 
@@ -105,6 +148,13 @@ type Options struct {
 	// Size of the LRU cache for value logs
 	VLogCacheSize int
 
+	// Number of shards the transaction and value log caches are split into, each one with its own lock
+	CacheShards int
+
+	// Amount of bytes read ahead from the transaction and commit logs when they are read
+	// sequentially, e.g. while indexing or replicating, zero disables read-ahead
+	ReadAheadWindowSize int
+
 	// Maximum number of simultaneous transaction log files opened
 	TxLogMaxOpenedFiles int
 
@@ -117,6 +167,30 @@ type Options struct {
 	// Maximum number of go-routines waiting for specific transactions to be in a committed or indexed state
 	MaxWaitees int
 
+	// Interval between background runs deleting expired keys, zero disables it
+	ExpiredKeysReaperInterval time.Duration
+
+	// Values of transactions committed before the retention period are discarded from the value logs, zero disables it
+	RetentionPeriod time.Duration
+
+	// Interval between background runs truncating the value logs according to the retention period
+	TruncationFrequency time.Duration
+
+	// Number of transactions verified per second by the background scrubber, zero disables it
+	ScrubberRate int
+
+	// Called by the scrubber with every transaction which failed verification
+	CorruptionHandler CorruptionHandler
+
+	// Commits are rejected once the values, transaction and commit logs would exceed this size in bytes, zero disables it
+	MaxStoreSize int64
+
+	// Store size in bytes at which StoreSizeHandler is called, zero disables it
+	StoreSizeSoftLimit int64
+
+	// Called once the soft limit is reached so callers can react before commits get rejected
+	StoreSizeHandler StoreSizeHandler
+
 	TimeFunc TimeFunc
 
 	UseExternalCommitAllowance bool
@@ -129,6 +203,24 @@ type Options struct {
 	CompressionFormat int
 	CompressionLevel  int
 
+	// Algorithm used by the whole chain: entry digests, value hashes, tx linking and binary linking.
+	// It's only set when the store is created, the one recorded in the store metadata is used afterwards
+	TxHashAlgorithm hashing.Algorithm
+
+	// Key used to encrypt newly created files, rotating it affects new files only.
+	// KeyProvider resolves every key recorded in existing files, including the current one
+	EncryptionKeyID string
+	KeyProvider     appendable.KeyProvider
+
+	// Amount of data bytes covered by each checksum in newly created files, zero disables them.
+	// VerifyChecksums makes reads fail on corrupted blocks instead of relying on digest validation
+	ChecksumBlockSize int
+	VerifyChecksums   bool
+
+	// Serve reads of files no longer written, i.e. rotated chunks of the logs and index files,
+	// from memory mappings instead of read syscalls. Ignored where mapping is not supported
+	Mmap bool
+
 	// options below affect indexing
 	IndexOpts *IndexOptions
 
@@ -140,6 +232,12 @@ type IndexOptions struct {
 	// Size of the Btree node LRU cache
 	CacheSize int
 
+	// Number of shards the Btree node LRU cache is split into, each one with its own lock
+	CacheShards int
+
+	// Byte-bounded node cache shared with other indexes, CacheSize is ignored when set
+	SharedCache *tbtree.SharedCache
+
 	// Number of new index entries between disk flushes
 	FlushThld int
 
@@ -158,6 +256,12 @@ type IndexOptions struct {
 	// Max size of a single Btree node in bytes
 	MaxNodeSize int
 
+	// Bits per key of the bloom filters kept for each leaf node (0 disables them)
+	BloomFilterBitsPerKey int
+
+	// Front-coding of keys within leaf nodes
+	PrefixCompression bool
+
 	// Time between the most recent DB snapshot is automatically renewed
 	RenewSnapRootAfter time.Duration
 
@@ -167,6 +271,9 @@ type IndexOptions struct {
 	// Additional delay added during indexing when full compaction is in progress
 	DelayDuringCompaction time.Duration
 
+	// Maximum bytes per second written while the index is dumped during compaction (0 means unlimited)
+	CompactionRateLimit int
+
 	// Maximum number of simultaneously opened nodes files
 	NodesLogMaxOpenedFiles int
 
@@ -199,15 +306,21 @@ func DefaultOptions() *Options {
 		SyncFrequency:   DefaultSyncFrequency,
 		FileMode:        DefaultFileMode,
 		logger:          logger.NewSimpleLogger("immudb ", os.Stderr),
+		tracer:          noopTracer{},
+		metrics:         noopMetricsCollector{},
 
 		MaxActiveTransactions: DefaultMaxActiveTransactions,
 		MVCCReadSetLimit:      DefaultMVCCReadSetLimit,
+		IdempotencyWindow:     DefaultIdempotencyWindow,
 
 		MaxConcurrency:   DefaultMaxConcurrency,
 		MaxIOConcurrency: DefaultMaxIOConcurrency,
 
 		TxLogCacheSize: DefaultTxLogCacheSize,
 		VLogCacheSize:  DefaultVLogCacheSize,
+		CacheShards:    DefaultCacheShards,
+
+		ReadAheadWindowSize: DefaultReadAheadWindowSize,
 
 		VLogMaxOpenedFiles:      DefaultVLogMaxOpenedFiles,
 		TxLogMaxOpenedFiles:     DefaultTxLogMaxOpenedFiles,
@@ -215,6 +328,8 @@ func DefaultOptions() *Options {
 
 		MaxWaitees: DefaultMaxWaitees,
 
+		TruncationFrequency: DefaultTruncationFrequency,
+
 		TimeFunc: func() time.Time {
 			return time.Now()
 		},
@@ -228,6 +343,7 @@ func DefaultOptions() *Options {
 		FileSize:          DefaultFileSize,
 		CompressionFormat: DefaultCompressionFormat,
 		CompressionLevel:  DefaultCompressionLevel,
+		TxHashAlgorithm:   DefaultTxHashAlgorithm,
 
 		IndexOpts: DefaultIndexOptions(),
 
@@ -238,6 +354,7 @@ func DefaultOptions() *Options {
 func DefaultIndexOptions() *IndexOptions {
 	return &IndexOptions{
 		CacheSize:                tbtree.DefaultCacheSize,
+		CacheShards:              tbtree.DefaultCacheShards,
 		FlushThld:                tbtree.DefaultFlushThld,
 		SyncThld:                 tbtree.DefaultSyncThld,
 		FlushBufferSize:          tbtree.DefaultFlushBufferSize,
@@ -275,6 +392,14 @@ func (opts *Options) Validate() error {
 		return fmt.Errorf("%w: invalid SyncFrequency", ErrInvalidOptions)
 	}
 
+	if opts.SyncMaxBatchSize < 0 {
+		return fmt.Errorf("%w: invalid SyncMaxBatchSize", ErrInvalidOptions)
+	}
+
+	if opts.AsyncSyncFrequency < 0 {
+		return fmt.Errorf("%w: invalid AsyncSyncFrequency", ErrInvalidOptions)
+	}
+
 	if opts.MaxActiveTransactions <= 0 {
 		return fmt.Errorf("%w: invalid MaxActiveTransactions", ErrInvalidOptions)
 	}
@@ -283,6 +408,10 @@ func (opts *Options) Validate() error {
 		return fmt.Errorf("%w: invalid MVCCReadSetLimit", ErrInvalidOptions)
 	}
 
+	if opts.IdempotencyWindow < 0 {
+		return fmt.Errorf("%w: invalid IdempotencyWindow", ErrInvalidOptions)
+	}
+
 	if opts.MaxConcurrency <= 0 {
 		return fmt.Errorf("%w: invalid MaxConcurrency", ErrInvalidOptions)
 	}
@@ -309,10 +438,42 @@ func (opts *Options) Validate() error {
 		return fmt.Errorf("%w: invalid VLogCacheSize", ErrInvalidOptions)
 	}
 
+	if opts.CacheShards <= 0 {
+		return fmt.Errorf("%w: invalid CacheShards", ErrInvalidOptions)
+	}
+
+	if opts.ReadAheadWindowSize < 0 {
+		return fmt.Errorf("%w: invalid ReadAheadWindowSize", ErrInvalidOptions)
+	}
+
 	if opts.MaxWaitees < 0 {
 		return fmt.Errorf("%w: invalid MaxWaitees", ErrInvalidOptions)
 	}
 
+	if opts.ExpiredKeysReaperInterval < 0 {
+		return fmt.Errorf("%w: invalid ExpiredKeysReaperInterval", ErrInvalidOptions)
+	}
+
+	if opts.ScrubberRate < 0 || opts.ScrubberRate > MaxScrubberRate {
+		return fmt.Errorf("%w: invalid ScrubberRate", ErrInvalidOptions)
+	}
+
+	if opts.MaxStoreSize < 0 {
+		return fmt.Errorf("%w: invalid MaxStoreSize", ErrInvalidOptions)
+	}
+
+	if opts.StoreSizeSoftLimit < 0 || (opts.MaxStoreSize > 0 && opts.StoreSizeSoftLimit > opts.MaxStoreSize) {
+		return fmt.Errorf("%w: invalid StoreSizeSoftLimit", ErrInvalidOptions)
+	}
+
+	if opts.RetentionPeriod < 0 || (opts.RetentionPeriod > 0 && opts.RetentionPeriod < MinimumRetentionPeriod) {
+		return fmt.Errorf("%w: invalid RetentionPeriod", ErrInvalidOptions)
+	}
+
+	if opts.RetentionPeriod > 0 && opts.TruncationFrequency < MinimumTruncationFrequency {
+		return fmt.Errorf("%w: invalid TruncationFrequency", ErrInvalidOptions)
+	}
+
 	if opts.TimeFunc == nil {
 		return fmt.Errorf("%w: invalid TimeFunc", ErrInvalidOptions)
 	}
@@ -337,9 +498,38 @@ func (opts *Options) Validate() error {
 	if opts.FileSize <= 0 || opts.FileSize >= MaxFileSize {
 		return fmt.Errorf("%w: invalid FileSize", ErrInvalidOptions)
 	}
+	if opts.TxHashAlgorithm.Validate() != nil {
+		return fmt.Errorf("%w: invalid TxHashAlgorithm", ErrInvalidOptions)
+	}
 	if opts.logger == nil {
 		return fmt.Errorf("%w: invalid log", ErrInvalidOptions)
 	}
+	if opts.tracer == nil {
+		return fmt.Errorf("%w: invalid tracer", ErrInvalidOptions)
+	}
+	if opts.metrics == nil {
+		return fmt.Errorf("%w: invalid metrics collector", ErrInvalidOptions)
+	}
+
+	if opts.EncryptionKeyID != "" && opts.KeyProvider == nil {
+		return fmt.Errorf("%w: invalid KeyProvider", ErrInvalidOptions)
+	}
+
+	if opts.SingleFile && opts.CompressionFormat != appendable.NoCompression {
+		return fmt.Errorf("%w: compression is not supported in single-file mode", ErrInvalidOptions)
+	}
+
+	if opts.SingleFile && opts.EncryptionKeyID != "" {
+		return fmt.Errorf("%w: encryption is not supported in single-file mode", ErrInvalidOptions)
+	}
+
+	if opts.ChecksumBlockSize < 0 {
+		return fmt.Errorf("%w: invalid ChecksumBlockSize", ErrInvalidOptions)
+	}
+
+	if opts.SingleFile && opts.ChecksumBlockSize > 0 {
+		return fmt.Errorf("%w: checksums are not supported in single-file mode", ErrInvalidOptions)
+	}
 
 	err := opts.IndexOpts.Validate()
 	if err != nil {
@@ -356,6 +546,9 @@ func (opts *IndexOptions) Validate() error {
 	if opts.CacheSize <= 0 {
 		return fmt.Errorf("%w: invalid index option CacheSize", ErrInvalidOptions)
 	}
+	if opts.CacheShards <= 0 {
+		return fmt.Errorf("%w: invalid index option CacheShards", ErrInvalidOptions)
+	}
 	if opts.FlushThld <= 0 {
 		return fmt.Errorf("%w: invalid index option FlushThld", ErrInvalidOptions)
 	}
@@ -398,6 +591,12 @@ func (opts *IndexOptions) Validate() error {
 	if opts.CommitLogMaxOpenedFiles <= 0 {
 		return fmt.Errorf("%w: invalid index option CommitLogMaxOpenedFiles", ErrInvalidOptions)
 	}
+	if opts.CompactionRateLimit < 0 {
+		return fmt.Errorf("%w: invalid index option CompactionRateLimit", ErrInvalidOptions)
+	}
+	if opts.BloomFilterBitsPerKey < 0 || opts.BloomFilterBitsPerKey > tbtree.MaxBloomFilterBitsPerKey {
+		return fmt.Errorf("%w: invalid index option BloomFilterBitsPerKey", ErrInvalidOptions)
+	}
 
 	return nil
 }
@@ -436,6 +635,37 @@ func (opts *Options) WithSyncFrequency(frequency time.Duration) *Options {
 	return opts
 }
 
+func (opts *Options) WithSyncMaxBatchSize(maxBatchSize int) *Options {
+	opts.SyncMaxBatchSize = maxBatchSize
+	return opts
+}
+
+func (opts *Options) WithAsyncSyncFrequency(frequency time.Duration) *Options {
+	opts.AsyncSyncFrequency = frequency
+	return opts
+}
+
+// WithDurability sets Synced, SyncFrequency and AsyncSyncFrequency according to the durability mode.
+// Group commit and async fsync frequencies are preserved when already set
+func (opts *Options) WithDurability(durability Durability) *Options {
+	switch durability {
+	case SyncPerTxDurability:
+		opts.Synced = true
+		opts.SyncFrequency = 0
+	case AsyncDurability:
+		opts.Synced = false
+		if opts.AsyncSyncFrequency == 0 {
+			opts.AsyncSyncFrequency = DefaultAsyncSyncFrequency
+		}
+	default:
+		opts.Synced = true
+		if opts.SyncFrequency == 0 {
+			opts.SyncFrequency = DefaultSyncFrequency
+		}
+	}
+	return opts
+}
+
 func (opts *Options) WithFileMode(fileMode os.FileMode) *Options {
 	opts.FileMode = fileMode
 	return opts
@@ -446,11 +676,26 @@ func (opts *Options) WithLogger(logger logger.Logger) *Options {
 	return opts
 }
 
+func (opts *Options) WithTracer(tracer Tracer) *Options {
+	opts.tracer = tracer
+	return opts
+}
+
+func (opts *Options) WithMetricsCollector(metrics MetricsCollector) *Options {
+	opts.metrics = metrics
+	return opts
+}
+
 func (opts *Options) WithAppFactory(appFactory AppFactoryFunc) *Options {
 	opts.appFactory = appFactory
 	return opts
 }
 
+func (opts *Options) WithSingleFile(singleFile bool) *Options {
+	opts.SingleFile = singleFile
+	return opts
+}
+
 func (opts *Options) WithCompactionDisabled(disabled bool) *Options {
 	opts.CompactionDisabled = disabled
 	return opts
@@ -466,6 +711,11 @@ func (opts *Options) WithMVCCReadSetLimit(mvccReadSetLimit int) *Options {
 	return opts
 }
 
+func (opts *Options) WithIdempotencyWindow(idempotencyWindow int) *Options {
+	opts.IdempotencyWindow = idempotencyWindow
+	return opts
+}
+
 func (opts *Options) WithMaxConcurrency(maxConcurrency int) *Options {
 	opts.MaxConcurrency = maxConcurrency
 	return opts
@@ -501,6 +751,16 @@ func (opts *Options) WithVLogCacheSize(vLogCacheSize int) *Options {
 	return opts
 }
 
+func (opts *Options) WithCacheShards(cacheShards int) *Options {
+	opts.CacheShards = cacheShards
+	return opts
+}
+
+func (opts *Options) WithReadAheadWindowSize(size int) *Options {
+	opts.ReadAheadWindowSize = size
+	return opts
+}
+
 func (opts *Options) WithFileSize(fileSize int) *Options {
 	opts.FileSize = fileSize
 	return opts
@@ -521,6 +781,42 @@ func (opts *Options) WithCommitLogMaxOpenedFiles(commitLogMaxOpenedFiles int) *O
 	return opts
 }
 
+func (opts *Options) WithExpiredKeysReaperInterval(interval time.Duration) *Options {
+	opts.ExpiredKeysReaperInterval = interval
+	return opts
+}
+
+func (opts *Options) WithRetentionPeriod(retentionPeriod time.Duration) *Options {
+	opts.RetentionPeriod = retentionPeriod
+	return opts
+}
+
+func (opts *Options) WithTruncationFrequency(truncationFrequency time.Duration) *Options {
+	opts.TruncationFrequency = truncationFrequency
+	return opts
+}
+
+// WithScrubber enables the background verification of rate transactions per second,
+// onCorruption may be nil when logging the failures is enough
+func (opts *Options) WithScrubber(rate int, onCorruption CorruptionHandler) *Options {
+	opts.ScrubberRate = rate
+	opts.CorruptionHandler = onCorruption
+	return opts
+}
+
+func (opts *Options) WithMaxStoreSize(maxStoreSize int64) *Options {
+	opts.MaxStoreSize = maxStoreSize
+	return opts
+}
+
+// WithStoreSizeSoftLimit sets the store size at which onSoftLimit is called,
+// it must not be greater than MaxStoreSize when a quota is set
+func (opts *Options) WithStoreSizeSoftLimit(softLimit int64, onSoftLimit StoreSizeHandler) *Options {
+	opts.StoreSizeSoftLimit = softLimit
+	opts.StoreSizeHandler = onSoftLimit
+	return opts
+}
+
 func (opts *Options) WithMaxWaitees(maxWaitees int) *Options {
 	opts.MaxWaitees = maxWaitees
 	return opts
@@ -546,6 +842,32 @@ func (opts *Options) WithCompressionFormat(compressionFormat int) *Options {
 	return opts
 }
 
+func (opts *Options) WithTxHashAlgorithm(hashAlg hashing.Algorithm) *Options {
+	opts.TxHashAlgorithm = hashAlg
+	return opts
+}
+
+func (opts *Options) WithEncryption(keyID string, keyProvider appendable.KeyProvider) *Options {
+	opts.EncryptionKeyID = keyID
+	opts.KeyProvider = keyProvider
+	return opts
+}
+
+func (opts *Options) WithChecksums(blockSize int) *Options {
+	opts.ChecksumBlockSize = blockSize
+	return opts
+}
+
+func (opts *Options) WithVerifyChecksums(verify bool) *Options {
+	opts.VerifyChecksums = verify
+	return opts
+}
+
+func (opts *Options) WithMmap(mmap bool) *Options {
+	opts.Mmap = mmap
+	return opts
+}
+
 func (opts *Options) WithCompresionLevel(compressionLevel int) *Options {
 	opts.CompressionLevel = compressionLevel
 	return opts
@@ -568,6 +890,11 @@ func (opts *IndexOptions) WithCacheSize(cacheSize int) *IndexOptions {
 	return opts
 }
 
+func (opts *IndexOptions) WithCacheShards(cacheShards int) *IndexOptions {
+	opts.CacheShards = cacheShards
+	return opts
+}
+
 func (opts *IndexOptions) WithFlushThld(flushThld int) *IndexOptions {
 	opts.FlushThld = flushThld
 	return opts
@@ -638,6 +965,26 @@ func (opts *IndexOptions) WithCommitLogMaxOpenedFiles(commitLogMaxOpenedFiles in
 	return opts
 }
 
+func (opts *IndexOptions) WithSharedCache(c *tbtree.SharedCache) *IndexOptions {
+	opts.SharedCache = c
+	return opts
+}
+
+func (opts *IndexOptions) WithCompactionRateLimit(bytesPerSecond int) *IndexOptions {
+	opts.CompactionRateLimit = bytesPerSecond
+	return opts
+}
+
+func (opts *IndexOptions) WithBloomFilterBitsPerKey(bits int) *IndexOptions {
+	opts.BloomFilterBitsPerKey = bits
+	return opts
+}
+
+func (opts *IndexOptions) WithPrefixCompression(enabled bool) *IndexOptions {
+	opts.PrefixCompression = enabled
+	return opts
+}
+
 // AHTOptions
 
 func (opts *AHTOptions) WithWriteBufferSize(writeBufferSize int) *AHTOptions {
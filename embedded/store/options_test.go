@@ -22,6 +22,7 @@ import (
 
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/stretchr/testify/require"
 )
 
@@ -33,15 +34,20 @@ func TestInvalidOptions(t *testing.T) {
 		{"nil", nil},
 		{"empty", &Options{}},
 		{"logger", DefaultOptions().WithLogger(nil)},
+		{"tracer", DefaultOptions().WithTracer(nil)},
+		{"metrics", DefaultOptions().WithMetricsCollector(nil)},
 		{"MaxConcurrency", DefaultOptions().WithMaxConcurrency(0)},
 		{"WriteBufferSize", DefaultOptions().WithWriteBufferSize(0)},
 		{"SyncFrequency", DefaultOptions().WithSyncFrequency(-1)},
 		{"MaxActiveTransactions", DefaultOptions().WithMaxActiveTransactions(0)},
 		{"MVCCReadSetLimit", DefaultOptions().WithMVCCReadSetLimit(0)},
+		{"IdempotencyWindow", DefaultOptions().WithIdempotencyWindow(-1)},
 		{"MaxIOConcurrency", DefaultOptions().WithMaxIOConcurrency(0)},
 		{"MaxIOConcurrency-max", DefaultOptions().WithMaxIOConcurrency(MaxParallelIO + 1)},
 		{"TxLogCacheSize", DefaultOptions().WithTxLogCacheSize(-1)},
+		{"CacheShards", DefaultOptions().WithCacheShards(0)},
 		{"VLogCacheSize", DefaultOptions().WithVLogCacheSize(-1)},
+		{"ReadAheadWindowSize", DefaultOptions().WithReadAheadWindowSize(-1)},
 		{"VLogMaxOpenedFiles", DefaultOptions().WithVLogMaxOpenedFiles(0)},
 		{"TxLogMaxOpenedFiles", DefaultOptions().WithTxLogMaxOpenedFiles(0)},
 		{"CommitLogMaxOpenedFiles", DefaultOptions().WithCommitLogMaxOpenedFiles(0)},
@@ -55,6 +61,23 @@ func TestInvalidOptions(t *testing.T) {
 		{"MaxValueLen", DefaultOptions().WithMaxValueLen(0)},
 		{"FileSize", DefaultOptions().WithFileSize(0)},
 		{"FileSize-max", DefaultOptions().WithFileSize(MaxFileSize)},
+		{"TxHashAlgorithm", DefaultOptions().WithTxHashAlgorithm(hashing.Algorithm(255))},
+		{"SingleFile-compression", DefaultOptions().WithSingleFile(true).WithCompressionFormat(appendable.GZipCompression)},
+		{"KeyProvider", DefaultOptions().WithEncryption("key1", nil)},
+		{"ExpiredKeysReaperInterval", DefaultOptions().WithExpiredKeysReaperInterval(-1)},
+		{"RetentionPeriod", DefaultOptions().WithRetentionPeriod(-1)},
+		{"SyncMaxBatchSize", DefaultOptions().WithSyncMaxBatchSize(-1)},
+		{"ScrubberRate", DefaultOptions().WithScrubber(-1, nil)},
+		{"ScrubberRate-max", DefaultOptions().WithScrubber(MaxScrubberRate+1, nil)},
+		{"AsyncSyncFrequency", DefaultOptions().WithAsyncSyncFrequency(-1)},
+		{"MaxStoreSize", DefaultOptions().WithMaxStoreSize(-1)},
+		{"StoreSizeSoftLimit", DefaultOptions().WithStoreSizeSoftLimit(-1, nil)},
+		{"StoreSizeSoftLimit-above-max", DefaultOptions().WithMaxStoreSize(1<<20).WithStoreSizeSoftLimit(1<<20+1, nil)},
+		{"RetentionPeriod-too-short", DefaultOptions().WithRetentionPeriod(time.Hour)},
+		{"TruncationFrequency", DefaultOptions().WithRetentionPeriod(MinimumRetentionPeriod).WithTruncationFrequency(time.Minute)},
+		{"SingleFile-encryption", DefaultOptions().WithSingleFile(true).WithEncryption("key1", func(string) ([]byte, error) { return nil, nil })},
+		{"ChecksumBlockSize", DefaultOptions().WithChecksums(-1)},
+		{"SingleFile-checksums", DefaultOptions().WithSingleFile(true).WithChecksums(appendable.DefaultChecksumBlockSize)},
 	} {
 		t.Run(d.n, func(t *testing.T) {
 			require.ErrorIs(t, d.opts.Validate(), ErrInvalidOptions)
@@ -70,6 +93,7 @@ func TestInvalidIndexOptions(t *testing.T) {
 		{"nil", nil},
 		{"empty", &IndexOptions{}},
 		{"CacheSize", DefaultIndexOptions().WithCacheSize(0)},
+		{"CacheShards", DefaultIndexOptions().WithCacheShards(0)},
 		{"FlushThld", DefaultIndexOptions().WithFlushThld(0)},
 		{"SyncThld", DefaultIndexOptions().WithSyncThld(0)},
 		{"FlushBufferSize", DefaultIndexOptions().WithFlushBufferSize(0)},
@@ -77,6 +101,8 @@ func TestInvalidIndexOptions(t *testing.T) {
 		{"CleanupPercentage", DefaultIndexOptions().WithCleanupPercentage(101)},
 		{"MaxActiveSnapshots", DefaultIndexOptions().WithMaxActiveSnapshots(0)},
 		{"MaxNodeSize", DefaultIndexOptions().WithMaxNodeSize(0)},
+		{"BloomFilterBitsPerKey", DefaultIndexOptions().WithBloomFilterBitsPerKey(-1)},
+		{"CompactionRateLimit", DefaultIndexOptions().WithCompactionRateLimit(-1)},
 		{"RenewSnapRootAfter", DefaultIndexOptions().WithRenewSnapRootAfter(-1)},
 		{"MaxBulkSize", DefaultIndexOptions().WithMaxBulkSize(0)},
 		{"BulkPreparationTimeout", DefaultIndexOptions().WithBulkPreparationTimeout(-1)},
@@ -125,15 +151,25 @@ func TestValidOptions(t *testing.T) {
 	require.Equal(t, DefaultSyncFrequency, opts.WithSyncFrequency(DefaultSyncFrequency).SyncFrequency)
 	require.Equal(t, DefaultMaxActiveTransactions, opts.WithMaxActiveTransactions(DefaultMaxActiveTransactions).MaxActiveTransactions)
 	require.Equal(t, DefaultMVCCReadSetLimit, opts.WithMVCCReadSetLimit(DefaultMVCCReadSetLimit).MVCCReadSetLimit)
+	require.Equal(t, DefaultIdempotencyWindow, opts.WithIdempotencyWindow(DefaultIdempotencyWindow).IdempotencyWindow)
 	require.Equal(t, DefaultMaxIOConcurrency, opts.WithMaxIOConcurrency(DefaultMaxIOConcurrency).MaxIOConcurrency)
 	require.Equal(t, DefaultMaxKeyLen, opts.WithMaxKeyLen(DefaultMaxKeyLen).MaxKeyLen)
 	require.Equal(t, DefaultMaxTxEntries, opts.WithMaxTxEntries(DefaultMaxTxEntries).MaxTxEntries)
 	require.Equal(t, DefaultMaxValueLen, opts.WithMaxValueLen(DefaultMaxValueLen).MaxValueLen)
 	require.Equal(t, DefaultTxLogCacheSize, opts.WithTxLogCacheSize(DefaultOptions().TxLogCacheSize).TxLogCacheSize)
 	require.Equal(t, DefaultVLogCacheSize, opts.WithVLogCacheSize(DefaultOptions().VLogCacheSize).VLogCacheSize)
+	require.Equal(t, DefaultCacheShards, opts.WithCacheShards(DefaultCacheShards).CacheShards)
 	require.Equal(t, 2, opts.WithTxLogMaxOpenedFiles(2).TxLogMaxOpenedFiles)
 	require.Equal(t, 3, opts.WithVLogMaxOpenedFiles(3).VLogMaxOpenedFiles)
 	require.Equal(t, DefaultMaxWaitees, opts.WithMaxWaitees(DefaultMaxWaitees).MaxWaitees)
+	require.Equal(t, time.Minute, opts.WithExpiredKeysReaperInterval(time.Minute).ExpiredKeysReaperInterval)
+	require.Equal(t, MinimumRetentionPeriod, opts.WithRetentionPeriod(MinimumRetentionPeriod).RetentionPeriod)
+	require.Equal(t, 10, opts.WithSyncMaxBatchSize(10).SyncMaxBatchSize)
+	require.Equal(t, 100, opts.WithScrubber(100, nil).ScrubberRate)
+	require.Equal(t, time.Second, opts.WithAsyncSyncFrequency(time.Second).AsyncSyncFrequency)
+	require.Equal(t, int64(1<<30), opts.WithMaxStoreSize(1<<30).MaxStoreSize)
+	require.Equal(t, int64(1<<29), opts.WithStoreSizeSoftLimit(1<<29, nil).StoreSizeSoftLimit)
+	require.Equal(t, MinimumTruncationFrequency, opts.WithTruncationFrequency(MinimumTruncationFrequency).TruncationFrequency)
 
 	timeFun := func() time.Time {
 		return time.Now()
@@ -150,6 +186,10 @@ func TestValidOptions(t *testing.T) {
 
 	require.NotNil(t, opts.WithLogger(DefaultOptions().logger))
 
+	require.NotNil(t, opts.WithTracer(DefaultOptions().tracer).tracer)
+
+	require.NotNil(t, opts.WithMetricsCollector(DefaultOptions().metrics).metrics)
+
 	require.NoError(t, opts.Validate())
 
 	require.True(t, opts.WithReadOnly(true).ReadOnly)
@@ -178,6 +218,7 @@ func TestValidOptions(t *testing.T) {
 	require.ErrorIs(t, opts.Validate(), ErrInvalidOptions)
 
 	require.Equal(t, 100, indexOpts.WithCacheSize(100).CacheSize)
+	require.Equal(t, 4, indexOpts.WithCacheShards(4).CacheShards)
 	require.Equal(t, 1000, indexOpts.WithFlushThld(1000).FlushThld)
 	require.Equal(t, 10_000, indexOpts.WithSyncThld(10_000).SyncThld)
 	require.Equal(t, 10, indexOpts.WithMaxActiveSnapshots(10).MaxActiveSnapshots)
@@ -190,6 +231,10 @@ func TestValidOptions(t *testing.T) {
 	require.Equal(t, 10, indexOpts.WithNodesLogMaxOpenedFiles(10).NodesLogMaxOpenedFiles)
 	require.Equal(t, 11, indexOpts.WithHistoryLogMaxOpenedFiles(11).HistoryLogMaxOpenedFiles)
 	require.Equal(t, 12, indexOpts.WithCommitLogMaxOpenedFiles(12).CommitLogMaxOpenedFiles)
+	require.Equal(t, 10, indexOpts.WithBloomFilterBitsPerKey(10).BloomFilterBitsPerKey)
+	require.True(t, indexOpts.WithPrefixCompression(true).PrefixCompression)
+	require.Equal(t, 1<<20, indexOpts.WithCompactionRateLimit(1<<20).CompactionRateLimit)
+	require.Nil(t, indexOpts.WithSharedCache(nil).SharedCache)
 	require.Equal(t, 3, indexOpts.WithCompactionThld(3).CompactionThld)
 	require.Equal(t, 1*time.Millisecond, indexOpts.WithDelayDuringCompaction(1*time.Millisecond).DelayDuringCompaction)
 	require.Equal(t, 4096*2, indexOpts.WithFlushBufferSize(4096*2).FlushBufferSize)
@@ -207,3 +252,22 @@ func TestValidOptions(t *testing.T) {
 
 	require.NoError(t, opts.Validate())
 }
+
+func TestDurabilityOptions(t *testing.T) {
+	opts := DefaultOptions().WithDurability(SyncPerTxDurability)
+	require.True(t, opts.Synced)
+	require.Zero(t, opts.SyncFrequency)
+
+	opts.WithDurability(GroupCommitDurability)
+	require.True(t, opts.Synced)
+	require.Equal(t, DefaultSyncFrequency, opts.SyncFrequency)
+
+	opts.WithDurability(AsyncDurability)
+	require.False(t, opts.Synced)
+	require.Equal(t, DefaultAsyncSyncFrequency, opts.AsyncSyncFrequency)
+
+	opts = DefaultOptions().
+		WithAsyncSyncFrequency(time.Minute).
+		WithDurability(AsyncDurability)
+	require.Equal(t, time.Minute, opts.AsyncSyncFrequency)
+}
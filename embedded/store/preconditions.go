@@ -17,6 +17,8 @@ limitations under the License.
 package store
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"errors"
 
 	"github.com/codenotary/immudb/embedded/tbtree"
@@ -122,3 +124,111 @@ func (cs *PreconditionKeyNotModifiedAfterTx) Check(idx KeyIndex) (bool, error) {
 
 	return valRef.Tx() <= cs.TxID, nil
 }
+
+// PreconditionKeyValueHashMustEqual is satisfied when the key exists and
+// the hash of its current value is the expected one
+type PreconditionKeyValueHashMustEqual struct {
+	Key  []byte
+	Hash [sha256.Size]byte
+}
+
+func (cs *PreconditionKeyValueHashMustEqual) String() string { return "KeyValueHashMustEqual" }
+
+func (cs *PreconditionKeyValueHashMustEqual) Validate(st *ImmuStore) error {
+	if len(cs.Key) == 0 {
+		return ErrInvalidPreconditionNullKey
+	}
+
+	if len(cs.Key) > st.maxKeyLen {
+		return ErrInvalidPreconditionMaxKeyLenExceeded
+	}
+
+	return nil
+}
+
+func (cs *PreconditionKeyValueHashMustEqual) Check(idx KeyIndex) (bool, error) {
+	valRef, err := idx.Get(cs.Key)
+	if err != nil && errors.Is(err, ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return valRef.HVal() == cs.Hash, nil
+}
+
+// PreconditionKeyRevisionMustEqual is satisfied when the latest entry of the key
+// (it could be deleted or even expired) is the expected revision.
+// Revision zero is only satisfied by keys which were never set
+type PreconditionKeyRevisionMustEqual struct {
+	Key      []byte
+	Revision uint64
+}
+
+func (cs *PreconditionKeyRevisionMustEqual) String() string { return "KeyRevisionMustEqual" }
+
+func (cs *PreconditionKeyRevisionMustEqual) Validate(st *ImmuStore) error {
+	if len(cs.Key) == 0 {
+		return ErrInvalidPreconditionNullKey
+	}
+
+	if len(cs.Key) > st.maxKeyLen {
+		return ErrInvalidPreconditionMaxKeyLenExceeded
+	}
+
+	return nil
+}
+
+func (cs *PreconditionKeyRevisionMustEqual) Check(idx KeyIndex) (bool, error) {
+	valRef, err := idx.GetWithFilters(cs.Key)
+	if err != nil && errors.Is(err, ErrKeyNotFound) {
+		return cs.Revision == 0, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return valRef.HC() == cs.Revision, nil
+}
+
+// PreconditionKeyMetadataMustMatch is satisfied when the latest entry of the key
+// (it could be deleted or even expired) holds exactly the expected metadata.
+// A nil Metadata matches entries without metadata
+type PreconditionKeyMetadataMustMatch struct {
+	Key      []byte
+	Metadata *KVMetadata
+}
+
+func (cs *PreconditionKeyMetadataMustMatch) String() string { return "KeyMetadataMustMatch" }
+
+func (cs *PreconditionKeyMetadataMustMatch) Validate(st *ImmuStore) error {
+	if len(cs.Key) == 0 {
+		return ErrInvalidPreconditionNullKey
+	}
+
+	if len(cs.Key) > st.maxKeyLen {
+		return ErrInvalidPreconditionMaxKeyLenExceeded
+	}
+
+	return nil
+}
+
+func (cs *PreconditionKeyMetadataMustMatch) Check(idx KeyIndex) (bool, error) {
+	valRef, err := idx.GetWithFilters(cs.Key)
+	if err != nil && errors.Is(err, ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(kvMetadataBytes(valRef.KVMetadata()), kvMetadataBytes(cs.Metadata)), nil
+}
+
+func kvMetadataBytes(md *KVMetadata) []byte {
+	if md == nil {
+		return nil
+	}
+	return md.Bytes()
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// StoreSizeHandler is called once the store size reaches the configured soft limit
+type StoreSizeHandler func(size, maxSize int64)
+
+// Size returns the number of bytes written into the value, transaction and commit logs
+func (s *ImmuStore) Size() (int64, error) {
+	var size int64
+
+	for _, vLog := range s.vLogs {
+		vLogSize, err := vLog.vLog.Size()
+		if err != nil {
+			return 0, err
+		}
+		size += vLogSize
+	}
+
+	txLogSize, err := s.txLog.Size()
+	if err != nil {
+		return 0, err
+	}
+
+	cLogSize, err := s.cLog.Size()
+	if err != nil {
+		return 0, err
+	}
+
+	return size + txLogSize + cLogSize, nil
+}
+
+// checkStoreSize estimates the size of the store once the entries are committed,
+// the commit is rejected when it would exceed the quota.
+// Concurrent commits are checked independently thus the quota may be slightly surpassed
+func (s *ImmuStore) checkStoreSize(entries []*EntrySpec) error {
+	if s.maxStoreSize == 0 && s.storeSizeSoftLimit == 0 {
+		return nil
+	}
+
+	size, err := s.Size()
+	if err != nil {
+		return err
+	}
+
+	var maxKeyLen int
+
+	for _, e := range entries {
+		size += int64(len(e.Value))
+
+		if len(e.Key) > maxKeyLen {
+			maxKeyLen = len(e.Key)
+		}
+	}
+
+	// upper bound of the space taken by the transaction and its commit log entry
+	size += int64(maxTxSize(len(entries), maxKeyLen, maxTxMetadataLen, maxKVMetadataLen)) + cLogEntrySize
+
+	if s.storeSizeSoftLimit > 0 && size >= s.storeSizeSoftLimit &&
+		atomic.CompareAndSwapInt32(&s.softLimitReached, 0, 1) {
+
+		s.logger.Warningf("store size soft limit reached at '%s' {size=%d, softLimit=%d}", s.path, size, s.storeSizeSoftLimit)
+
+		if s.onStoreSizeLimit != nil {
+			s.onStoreSizeLimit(size, s.maxStoreSize)
+		}
+	}
+
+	if s.maxStoreSize > 0 && size > s.maxStoreSize {
+		return fmt.Errorf("%w: store size would reach %d bytes, limit is %d bytes", ErrMaxStoreSizeExceeded, size, s.maxStoreSize)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmudbStoreSizeQuota(t *testing.T) {
+	dir := t.TempDir()
+
+	var softLimitCalls int
+
+	opts := DefaultOptions().
+		WithMaxStoreSize(64*1024).
+		WithStoreSizeSoftLimit(32*1024, func(size, maxSize int64) {
+			require.GreaterOrEqual(t, size, int64(32*1024))
+			require.Equal(t, int64(64*1024), maxSize)
+			softLimitCalls++
+		})
+
+	st, err := Open(dir, opts)
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	size, err := st.Size()
+	require.NoError(t, err)
+	require.Zero(t, size)
+
+	value := make([]byte, 1024)
+
+	var committed int
+
+	for {
+		tx, err := st.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", committed)), nil, value)
+		require.NoError(t, err)
+
+		_, err = tx.Commit(context.Background())
+		if err != nil {
+			require.ErrorIs(t, err, ErrMaxStoreSizeExceeded)
+			break
+		}
+
+		committed++
+	}
+
+	require.Greater(t, committed, 0)
+	require.Equal(t, uint64(committed), st.LastCommittedTxID())
+	require.Equal(t, 1, softLimitCalls)
+
+	size, err = st.Size()
+	require.NoError(t, err)
+	require.LessOrEqual(t, size, int64(64*1024))
+
+	t.Run("reads are not affected by the quota", func(t *testing.T) {
+		valRef, err := st.Get([]byte("key0"))
+		require.NoError(t, err)
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Equal(t, value, val)
+	})
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/logger"
+)
+
+// TruncateBefore discards from the value logs the values of the transactions
+// committed before the specified time. Transaction headers, the commit log and
+// the binary linking are kept, so inclusion and consistency proofs can still be built.
+// The most recent transaction is always kept, and the id of the oldest transaction
+// whose values are preserved is returned, zero meaning nothing was truncated.
+func (s *ImmuStore) TruncateBefore(ts time.Time) (uint64, error) {
+	lastTxID := s.LastCommittedTxID()
+	if lastTxID == 0 {
+		return 0, nil
+	}
+
+	minTxID := lastTxID
+
+	hdr, err := s.FirstTxSince(ts)
+	if err == nil {
+		minTxID = hdr.ID
+	} else if !errors.Is(err, ErrTxNotFound) {
+		return 0, err
+	}
+
+	if minTxID <= 1 {
+		return 0, nil
+	}
+
+	err = s.TruncateUptoTx(minTxID)
+	if err != nil {
+		return 0, err
+	}
+
+	return minTxID, nil
+}
+
+func (s *ImmuStore) truncatePeriodically(retentionPeriod, frequency time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(frequency)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		s.mutex.Lock()
+		now := s.timeFunc()
+		s.mutex.Unlock()
+
+		minTxID, err := s.TruncateBefore(now.Add(-retentionPeriod))
+		if errors.Is(err, ErrAlreadyClosed) {
+			return
+		}
+		if err != nil {
+			s.notify(Error, true, "%s: while truncating value logs at '%s'", err, s.path)
+			continue
+		}
+
+		if minTxID > 0 {
+			logger.With(s.logger, logger.FieldTxID, minTxID).
+				Infof("Value logs truncated up to transaction %d at '%s'", minTxID, s.path)
+		}
+	}
+}
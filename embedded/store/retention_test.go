@@ -0,0 +1,103 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncateBefore(t *testing.T) {
+	st, err := Open(t.TempDir(), DefaultOptions().WithFileSize(6).WithMaxIOConcurrency(1))
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	minTxID, err := st.TruncateBefore(time.Now())
+	require.NoError(t, err)
+	require.Zero(t, minTxID)
+
+	t0 := time.Now().Add(-72 * time.Hour)
+
+	for i := 1; i <= 10; i++ {
+		txTime := t0
+		if i > 5 {
+			txTime = t0.Add(48 * time.Hour)
+		}
+
+		err = st.UseTimeFunc(func() time.Time { return txTime })
+		require.NoError(t, err)
+
+		tx, err := st.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key_%d", i)), nil, []byte(fmt.Sprintf("val_%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	minTxID, err = st.TruncateBefore(t0.Add(24 * time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), minTxID)
+
+	tx := NewTx(st.MaxTxEntries(), st.MaxKeyLen())
+
+	for i := uint64(1); i <= 10; i++ {
+		err = st.ReadTx(i, tx)
+		require.NoError(t, err)
+
+		for _, e := range tx.Entries() {
+			_, err := st.ReadValue(e)
+			if i < minTxID {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		}
+	}
+
+	t.Run("binary linking should be preserved", func(t *testing.T) {
+		sourceTx := NewTx(st.MaxTxEntries(), st.MaxKeyLen())
+
+		err := st.ReadTx(1, sourceTx)
+		require.NoError(t, err)
+
+		err = st.ReadTx(10, tx)
+		require.NoError(t, err)
+
+		proof, err := st.DualProof(sourceTx.Header(), tx.Header())
+		require.NoError(t, err)
+		require.True(t, VerifyDualProof(proof, 1, 10, sourceTx.Header().Alh(), tx.Header().Alh()))
+	})
+
+	t.Run("the most recent transaction should be kept", func(t *testing.T) {
+		minTxID, err := st.TruncateBefore(time.Now())
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), minTxID)
+
+		err = st.ReadTx(10, tx)
+		require.NoError(t, err)
+
+		_, err = st.ReadValue(tx.Entries()[0])
+		require.NoError(t, err)
+	})
+}
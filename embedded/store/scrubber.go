@@ -0,0 +1,132 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/ahtree"
+)
+
+// CorruptionHandler is called by the scrubber with every transaction which failed verification
+type CorruptionHandler func(txID uint64, err error)
+
+// VerifyTx re-reads the specified transaction and checks its integrity:
+// entry digests against the header, values against their hashes and
+// the alh linkage with the previous transaction and the binary linking.
+// Values already discarded by truncation are not verified
+func (s *ImmuStore) VerifyTx(txID uint64) error {
+	tx, err := s.fetchAllocTx()
+	if err != nil {
+		return err
+	}
+	defer s.releaseAllocTx(tx)
+
+	return s.verifyTx(txID, tx)
+}
+
+func (s *ImmuStore) verifyTx(txID uint64, tx *Tx) error {
+	// entries are validated against the header while the transaction is read
+	err := s.ReadTx(txID, tx)
+	if err != nil {
+		return err
+	}
+
+	if txID > 1 {
+		prevHdr, err := s.ReadTxHeader(txID-1, false)
+		if err != nil {
+			return err
+		}
+
+		if prevHdr.Alh() != tx.header.PrevAlh {
+			return fmt.Errorf("%w: ALH mismatch at tx %d", ErrorCorruptedTxData, txID)
+		}
+	}
+
+	alh := tx.header.Alh()
+
+	lalh, err := s.aht.DataAt(txID)
+	if errors.Is(err, ahtree.ErrAlreadyClosed) {
+		return ErrAlreadyClosed
+	}
+	if err != nil && !errors.Is(err, ahtree.ErrUnexistentData) {
+		return err
+	}
+	if err == nil && !bytes.Equal(lalh, alh[:]) {
+		return fmt.Errorf("%w: binary linking mismatch at tx %d", ErrorCorruptedTxData, txID)
+	}
+
+	for _, e := range tx.Entries() {
+		b := make([]byte, e.vLen)
+
+		_, err = s.readValueAt(b, e.vOff, e.hVal)
+		if errors.Is(err, ErrSnapshotExpired) {
+			// value already discarded by truncation
+			continue
+		}
+		if errors.Is(err, ErrCorruptedData) {
+			return fmt.Errorf("%w: invalid value of key '%s' at tx %d", ErrCorruptedData, e.key(), txID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scrubPeriodically verifies rate transactions per second, cycling over the
+// whole transaction log
+func (s *ImmuStore) scrubPeriodically(rate int, onCorruption CorruptionHandler, done <-chan struct{}) {
+	ticker := time.NewTicker(time.Second / time.Duration(rate))
+	defer ticker.Stop()
+
+	tx := NewTx(s.maxTxEntries, s.maxKeyLen)
+
+	nextTxID := uint64(1)
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		if nextTxID > s.LastCommittedTxID() {
+			// start over, including the transactions committed since the last run
+			nextTxID = 1
+			continue
+		}
+
+		err := s.verifyTx(nextTxID, tx)
+		if errors.Is(err, ErrAlreadyClosed) {
+			return
+		}
+		if err != nil {
+			s.notify(Error, true, "%s: while verifying tx %d at '%s'", err, nextTxID, s.path)
+
+			if onCorruption != nil {
+				onCorruption(nextTxID, err)
+			}
+		}
+
+		nextTxID++
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyTx(t *testing.T) {
+	dir := t.TempDir()
+
+	st, err := Open(dir, DefaultOptions().WithMaxIOConcurrency(1))
+	require.NoError(t, err)
+
+	for i := 1; i <= 3; i++ {
+		tx, err := st.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	for i := uint64(1); i <= 3; i++ {
+		require.NoError(t, st.VerifyTx(i))
+	}
+
+	err = st.VerifyTx(4)
+	require.ErrorIs(t, err, ErrTxNotFound)
+
+	err = st.Close()
+	require.NoError(t, err)
+
+	// flip a byte of the value written by the second transaction
+	files, err := filepath.Glob(filepath.Join(dir, "val_0", "*.val"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	content, err := ioutil.ReadFile(files[0])
+	require.NoError(t, err)
+
+	i := bytes.Index(content, []byte("value2"))
+	require.Greater(t, i, 0)
+	content[i] ^= 0xff
+
+	err = ioutil.WriteFile(files[0], content, 0644)
+	require.NoError(t, err)
+
+	type corruption struct {
+		txID uint64
+		err  error
+	}
+
+	corrupted := make(chan corruption, 10)
+
+	st, err = Open(dir, DefaultOptions().
+		WithMaxIOConcurrency(1).
+		WithScrubber(1000, func(txID uint64, err error) {
+			corrupted <- corruption{txID: txID, err: err}
+		}),
+	)
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	err = st.VerifyTx(2)
+	require.ErrorIs(t, err, ErrCorruptedData)
+
+	require.NoError(t, st.VerifyTx(1))
+	require.NoError(t, st.VerifyTx(3))
+
+	select {
+	case c := <-corrupted:
+		require.Equal(t, uint64(2), c.txID)
+		require.ErrorIs(t, c.err, ErrCorruptedData)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "corruption not reported by the scrubber")
+	}
+}
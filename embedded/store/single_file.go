@@ -0,0 +1,121 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+
+	"github.com/codenotary/immudb/embedded/ahtree"
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/appendable/singlefile"
+	"github.com/codenotary/immudb/embedded/tbtree"
+)
+
+// openSingleFile opens a store whose data is kept within the file at the given path.
+// Every appendable, including the ones used by the binary linking and the index,
+// is mapped into a section of the file
+func openSingleFile(path string, opts *Options) (*ImmuStore, error) {
+	sf, err := singlefile.Open(path, singlefile.DefaultOptions().
+		WithReadOnly(opts.ReadOnly).
+		WithFileMode(opts.FileMode),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sfOpts := *opts
+	sfOpts.appFactory = func(rootPath, subPath string, appOpts *multiapp.Options) (appendable.Appendable, error) {
+		return sf.OpenSection(filepath.ToSlash(subPath), appOpts.GetMetadata())
+	}
+
+	st, err := open(path, &sfOpts)
+	if err != nil {
+		sf.Close()
+		return nil, err
+	}
+
+	st.singleFile = sf
+
+	return st, nil
+}
+
+func openSingleFileAHT(path string, ahtOpts *ahtree.Options, opts *Options) (*ahtree.AHtree, error) {
+	if opts.appFactory == nil {
+		return nil, fmt.Errorf("%w: single-file mode requires the store to be opened with Open", ErrIllegalArguments)
+	}
+
+	metadata := appendable.NewMetadata(nil)
+	metadata.PutInt(ahtree.MetaVersion, ahtree.Version)
+
+	appendableOpts := multiapp.DefaultOptions().
+		WithReadOnly(opts.ReadOnly).
+		WithMetadata(metadata.Bytes())
+
+	pLog, err := opts.appFactory(path, filepath.Join(ahtDirname, "data"), appendableOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	dLog, err := opts.appFactory(path, filepath.Join(ahtDirname, "tree"), appendableOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	cLog, err := opts.appFactory(path, filepath.Join(ahtDirname, "commit"), appendableOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return ahtree.OpenWith(pLog, dLog, cLog, ahtOpts)
+}
+
+func openSingleFileIndex(store *ImmuStore, path string, indexOpts *tbtree.Options, opts *Options) (*tbtree.TBtree, error) {
+	if opts.appFactory == nil {
+		return nil, fmt.Errorf("%w: single-file mode requires the store to be opened with Open", ErrIllegalArguments)
+	}
+
+	metadata := appendable.NewMetadata(nil)
+	metadata.PutInt(tbtree.MetaVersion, tbtree.RequiredVersion(indexOpts))
+	metadata.PutInt(tbtree.MetaMaxNodeSize, opts.IndexOpts.MaxNodeSize)
+	metadata.PutInt(tbtree.MetaMaxKeySize, opts.MaxKeyLen)
+	metadata.PutInt(tbtree.MetaMaxValueSize, lszSize+offsetSize+sha256.Size+sszSize+maxIndexedTxMetadataLen+sszSize+maxKVMetadataLen)
+
+	appendableOpts := multiapp.DefaultOptions().
+		WithReadOnly(opts.ReadOnly).
+		WithMetadata(metadata.Bytes())
+
+	// compaction is disabled in single-file mode, thus there is only one snapshot of the index
+	nLog, err := opts.appFactory(store.path, filepath.Join(indexDirname, "nodes"), appendableOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	hLog, err := opts.appFactory(store.path, filepath.Join(indexDirname, "history"), appendableOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	cLog, err := opts.appFactory(store.path, filepath.Join(indexDirname, "commit"), appendableOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return tbtree.OpenWith(path, nLog, hLog, cLog, indexOpts)
+}
@@ -0,0 +1,326 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/codenotary/immudb/embedded/appendable/singlefile"
+)
+
+const tombstonesDirname = "tombstones"
+
+// tombstones keeps the prefix tombstones processed by the indexer, indexed by prefix so
+// looking up the tombstones of a key only takes the distinct prefix lengths into account.
+// Tombstones are persisted into their own log, so they don't need to be
+// rebuilt from the transaction log when the store is reopened.
+// Each record is written as: txID + prefixLen + prefix
+type tombstones struct {
+	log appendable.Appendable
+
+	// txIDs of the tombstones of every prefix, in ascending order
+	byPrefix map[string][]uint64
+	// distinct lengths of the tombstoned prefixes, in ascending order
+	prefixLens []int
+
+	mutex sync.RWMutex
+}
+
+func newTombstones(log appendable.Appendable) *tombstones {
+	return &tombstones{
+		log:      log,
+		byPrefix: make(map[string][]uint64),
+	}
+}
+
+func openTombstonesLog(store *ImmuStore, opts *Options) (appendable.Appendable, error) {
+	appendableOpts := multiapp.DefaultOptions().
+		WithReadOnly(opts.ReadOnly).
+		WithRetryableSync(opts.Synced).
+		WithFileSize(opts.FileSize).
+		WithFileMode(opts.FileMode).
+		WithFileExt("ts").
+		WithCompressionFormat(appendable.NoCompression).
+		WithEncryption(opts.EncryptionKeyID, opts.KeyProvider)
+
+	if opts.appFactory != nil {
+		return opts.appFactory(store.path, tombstonesDirname, appendableOpts)
+	}
+
+	return multiapp.Open(filepath.Join(store.path, tombstonesDirname), appendableOpts)
+}
+
+func openTombstones(store *ImmuStore, opts *Options, upToTx uint64) (*tombstones, error) {
+	log, err := openTombstonesLog(store, opts)
+	if opts.ReadOnly && (os.IsNotExist(err) || errors.Is(err, singlefile.ErrReadOnly)) {
+		// stores created before prefix tombstones were introduced
+		return newTombstones(nil), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to open tombstones log: %w", err)
+	}
+
+	ts := newTombstones(log)
+
+	err = ts.load(upToTx, !opts.ReadOnly)
+	if err != nil {
+		log.Close()
+		return nil, err
+	}
+
+	return ts, nil
+}
+
+// load reads the tombstones stored in the log. Tombstones recorded by
+// transactions that were not yet indexed are discarded, they'll be
+// added again once those transactions get indexed. An incomplete
+// trailing record, left by an interrupted write, is discarded as well
+func (ts *tombstones) load(upToTx uint64, truncate bool) error {
+	size, err := ts.log.Size()
+	if err != nil {
+		return err
+	}
+
+	off := int64(0)
+
+	for off < size {
+		var hdr [txIDSize + sszSize]byte
+
+		if off+int64(len(hdr)) > size {
+			break
+		}
+
+		_, err := ts.log.ReadAt(hdr[:], off)
+		if err != nil {
+			return fmt.Errorf("%w: tombstones log: %v", ErrCorruptedData, err)
+		}
+
+		txID := binary.BigEndian.Uint64(hdr[:])
+		prefixLen := int(binary.BigEndian.Uint16(hdr[txIDSize:]))
+
+		if txID > upToTx || off+int64(len(hdr)+prefixLen) > size {
+			break
+		}
+
+		prefix := make([]byte, prefixLen)
+
+		_, err = ts.log.ReadAt(prefix, off+int64(len(hdr)))
+		if err != nil {
+			return fmt.Errorf("%w: tombstones log: %v", ErrCorruptedData, err)
+		}
+
+		ts.insert(txID, prefix)
+
+		off += int64(len(hdr) + prefixLen)
+	}
+
+	if off < size && truncate {
+		return ts.log.SetOffset(off)
+	}
+
+	return nil
+}
+
+// insert indexes the tombstone, unless it was already indexed
+func (ts *tombstones) insert(txID uint64, prefix []byte) {
+	txIDs, exists := ts.byPrefix[string(prefix)]
+
+	if !exists {
+		i := sort.SearchInts(ts.prefixLens, len(prefix))
+
+		if i == len(ts.prefixLens) || ts.prefixLens[i] != len(prefix) {
+			ts.prefixLens = append(ts.prefixLens, 0)
+			copy(ts.prefixLens[i+1:], ts.prefixLens[i:])
+			ts.prefixLens[i] = len(prefix)
+		}
+	}
+
+	i := sort.Search(len(txIDs), func(i int) bool { return txIDs[i] >= txID })
+
+	if i < len(txIDs) && txIDs[i] == txID {
+		return
+	}
+
+	txIDs = append(txIDs, 0)
+	copy(txIDs[i+1:], txIDs[i:])
+	txIDs[i] = txID
+
+	ts.byPrefix[string(prefix)] = txIDs
+}
+
+func (ts *tombstones) add(txID uint64, prefix []byte) error {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	txIDs := ts.byPrefix[string(prefix)]
+
+	i := sort.Search(len(txIDs), func(i int) bool { return txIDs[i] >= txID })
+	if i < len(txIDs) && txIDs[i] == txID {
+		// tombstones may be re-processed when indexing is resumed
+		return nil
+	}
+
+	if ts.log == nil {
+		return ErrReadOnly
+	}
+
+	rec := make([]byte, txIDSize+sszSize+len(prefix))
+	binary.BigEndian.PutUint64(rec, txID)
+	binary.BigEndian.PutUint16(rec[txIDSize:], uint16(len(prefix)))
+	copy(rec[txIDSize+sszSize:], prefix)
+
+	_, _, err := ts.log.Append(rec)
+	if err != nil {
+		return err
+	}
+
+	err = ts.log.Flush()
+	if err != nil {
+		return err
+	}
+
+	err = ts.log.Sync()
+	if err != nil {
+		return err
+	}
+
+	ts.insert(txID, prefix)
+
+	return nil
+}
+
+// deletedBy returns the id of the most recent transaction, up to ts,
+// which deleted the key through a prefix tombstone after the value
+// written at valTx. Zero is returned when no such tombstone exists
+func (ts *tombstones) deletedBy(key []byte, valTx, upToTx uint64) uint64 {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+
+	var deletedAt uint64
+
+	for _, l := range ts.prefixLens {
+		if l > len(key) {
+			break
+		}
+
+		txIDs, exists := ts.byPrefix[string(key[:l])]
+		if !exists {
+			continue
+		}
+
+		// most recent tombstone up to upToTx
+		i := sort.Search(len(txIDs), func(i int) bool { return txIDs[i] > upToTx }) - 1
+
+		if i >= 0 && txIDs[i] > valTx && txIDs[i] > deletedAt {
+			deletedAt = txIDs[i]
+		}
+	}
+
+	return deletedAt
+}
+
+// prune discards the tombstones superseded by a more recent tombstone of the same prefix,
+// when both were committed before minTxID. Values written before them are not readable
+// once the store is truncated up to minTxID, so only the latest one is needed to report
+// them as deleted. Pruned tombstones are kept in the log and loaded again on reopening
+func (ts *tombstones) prune(minTxID uint64) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	for prefix, txIDs := range ts.byPrefix {
+		i := sort.Search(len(txIDs), func(i int) bool { return txIDs[i] >= minTxID })
+		if i > 1 {
+			ts.byPrefix[prefix] = append(txIDs[:0:0], txIDs[i-1:]...)
+		}
+	}
+}
+
+func (ts *tombstones) close() error {
+	if ts.log == nil {
+		return nil
+	}
+
+	return ts.log.Close()
+}
+
+// prefixDeletedValueRef is the value reference of a key deleted by a prefix tombstone
+type prefixDeletedValueRef struct {
+	tx   uint64
+	hc   uint64
+	hVal [sha256.Size]byte
+	kvmd *KVMetadata
+}
+
+// withTombstones returns the reference to the deleted value when the key
+// was deleted by a prefix tombstone committed after valRef and up to upToTx
+func (st *ImmuStore) withTombstones(key []byte, valRef ValueRef, upToTx uint64) ValueRef {
+	deletedAt := st.indexer.tombstones.deletedBy(key, valRef.Tx(), upToTx)
+	if deletedAt == 0 {
+		return valRef
+	}
+
+	return st.prefixDeletedValueRefFrom(valRef, deletedAt)
+}
+
+func (st *ImmuStore) prefixDeletedValueRefFrom(valRef ValueRef, txID uint64) *prefixDeletedValueRef {
+	kvmd := NewKVMetadata()
+	kvmd.AsDeleted(true)
+
+	return &prefixDeletedValueRef{
+		tx:   txID,
+		hc:   valRef.HC(),
+		hVal: st.txHashAlg.Sum(nil),
+		kvmd: kvmd,
+	}
+}
+
+func (v *prefixDeletedValueRef) Resolve() ([]byte, error) {
+	return nil, nil
+}
+
+func (v *prefixDeletedValueRef) Tx() uint64 {
+	return v.tx
+}
+
+func (v *prefixDeletedValueRef) HC() uint64 {
+	return v.hc
+}
+
+func (v *prefixDeletedValueRef) TxMetadata() *TxMetadata {
+	return nil
+}
+
+func (v *prefixDeletedValueRef) KVMetadata() *KVMetadata {
+	return v.kvmd
+}
+
+func (v *prefixDeletedValueRef) HVal() [sha256.Size]byte {
+	return v.hVal
+}
+
+func (v *prefixDeletedValueRef) Len() uint32 {
+	return 0
+}
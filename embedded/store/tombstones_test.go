@@ -0,0 +1,241 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/appendable/multiapp"
+	"github.com/stretchr/testify/require"
+)
+
+func scanKeys(t *testing.T, st *ImmuStore, txID uint64, prefix []byte) []string {
+	snap, err := st.SnapshotMustIncludeTxID(context.Background(), txID)
+	require.NoError(t, err)
+	defer snap.Close()
+
+	reader, err := snap.NewKeyReader(KeyReaderSpec{Prefix: prefix, Filters: []FilterFn{IgnoreExpired, IgnoreDeleted}})
+	require.NoError(t, err)
+	defer reader.Close()
+
+	var keys []string
+
+	for {
+		key, _, err := reader.Read()
+		if err == ErrNoMoreEntries {
+			break
+		}
+		require.NoError(t, err)
+
+		keys = append(keys, string(key))
+	}
+
+	return keys
+}
+
+func TestDeletePrefix(t *testing.T) {
+	dir := t.TempDir()
+
+	st, err := Open(dir, DefaultOptions())
+	require.NoError(t, err)
+
+	tx, err := st.NewWriteOnlyTx(context.Background())
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		err = tx.Set([]byte(fmt.Sprintf("users/%d", i)), nil, []byte("value"))
+		require.NoError(t, err)
+	}
+
+	err = tx.Set([]byte("groups/0"), nil, []byte("value"))
+	require.NoError(t, err)
+
+	hdr, err := tx.Commit(context.Background())
+	require.NoError(t, err)
+
+	snapBefore, err := st.SnapshotMustIncludeTxID(context.Background(), hdr.ID)
+	require.NoError(t, err)
+	defer snapBefore.Close()
+
+	tx, err = st.NewWriteOnlyTx(context.Background())
+	require.NoError(t, err)
+
+	err = tx.DeletePrefix([]byte("users/"))
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("users/10"), nil, []byte("value"))
+	require.NoError(t, err)
+
+	hdr, err = tx.Commit(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, hdr.NEntries)
+
+	tx, err = st.NewWriteOnlyTx(context.Background())
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("users/1"), nil, []byte("value"))
+	require.NoError(t, err)
+
+	hdr, err = tx.Commit(context.Background())
+	require.NoError(t, err)
+
+	err = st.WaitForIndexingUpto(context.Background(), hdr.ID)
+	require.NoError(t, err)
+
+	checkDeleted := func(t *testing.T, st *ImmuStore) {
+		_, err := st.Get([]byte("users/0"))
+		require.ErrorIs(t, err, ErrKeyNotFound)
+
+		valRef, err := st.GetWithFilters([]byte("users/0"))
+		require.NoError(t, err)
+		require.Equal(t, hdr.ID-1, valRef.Tx())
+		require.True(t, valRef.KVMetadata().Deleted())
+
+		val, err := valRef.Resolve()
+		require.NoError(t, err)
+		require.Empty(t, val)
+
+		_, err = st.Get([]byte("users/1"))
+		require.NoError(t, err)
+
+		_, err = st.Get([]byte("users/10"))
+		require.NoError(t, err)
+
+		_, err = st.Get([]byte("groups/0"))
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"users/1", "users/10"}, scanKeys(t, st, hdr.ID, []byte("users/")))
+		require.Equal(t, []string{"groups/0"}, scanKeys(t, st, hdr.ID, []byte("groups/")))
+	}
+
+	t.Run("deleted keys should not be visible", func(t *testing.T) {
+		checkDeleted(t, st)
+	})
+
+	t.Run("snapshots taken before the tombstone should not be affected", func(t *testing.T) {
+		_, err := snapBefore.Get([]byte("users/0"))
+		require.NoError(t, err)
+	})
+
+	snapBefore.Close()
+
+	err = st.Close()
+	require.NoError(t, err)
+
+	t.Run("tombstones should be kept after reopening the store", func(t *testing.T) {
+		st, err := Open(dir, DefaultOptions())
+		require.NoError(t, err)
+		defer immustoreClose(t, st)
+
+		err = st.WaitForIndexingUpto(context.Background(), hdr.ID)
+		require.NoError(t, err)
+
+		checkDeleted(t, st)
+	})
+}
+
+func TestTombstonesTornRecord(t *testing.T) {
+	dir := t.TempDir()
+
+	st, err := Open(dir, DefaultOptions())
+	require.NoError(t, err)
+
+	tx, err := st.NewWriteOnlyTx(context.Background())
+	require.NoError(t, err)
+
+	err = tx.Set([]byte("users/0"), nil, []byte("value"))
+	require.NoError(t, err)
+
+	_, err = tx.Commit(context.Background())
+	require.NoError(t, err)
+
+	tx, err = st.NewWriteOnlyTx(context.Background())
+	require.NoError(t, err)
+
+	err = tx.DeletePrefix([]byte("users/"))
+	require.NoError(t, err)
+
+	hdr, err := tx.Commit(context.Background())
+	require.NoError(t, err)
+
+	err = st.WaitForIndexingUpto(context.Background(), hdr.ID)
+	require.NoError(t, err)
+
+	err = st.Close()
+	require.NoError(t, err)
+
+	// simulate an interrupted write of a tombstone record
+	log, err := multiapp.Open(filepath.Join(dir, tombstonesDirname), multiapp.DefaultOptions().WithFileExt("ts"))
+	require.NoError(t, err)
+
+	_, _, err = log.Append([]byte{0, 0, 0, 0, 0, 0, 0, 1, 0})
+	require.NoError(t, err)
+
+	err = log.Close()
+	require.NoError(t, err)
+
+	st, err = Open(dir, DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	err = st.WaitForIndexingUpto(context.Background(), hdr.ID)
+	require.NoError(t, err)
+
+	_, err = st.Get([]byte("users/0"))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	size, err := st.indexer.tombstones.log.Size()
+	require.NoError(t, err)
+	require.EqualValues(t, txIDSize+sszSize+len("users/"), size)
+}
+
+func TestTombstonesIndex(t *testing.T) {
+	ts := newTombstones(nil)
+
+	ts.insert(10, []byte("a/"))
+	ts.insert(20, []byte("a/b/"))
+	ts.insert(30, []byte("a/"))
+	ts.insert(30, []byte("a/"))
+	ts.insert(40, []byte("c/"))
+
+	require.Equal(t, []int{2, 4}, ts.prefixLens)
+	require.Equal(t, []uint64{10, 30}, ts.byPrefix["a/"])
+
+	require.Zero(t, ts.deletedBy([]byte("b/0"), 0, 100))
+	require.Zero(t, ts.deletedBy([]byte("a"), 0, 100))
+	require.Zero(t, ts.deletedBy([]byte("a/0"), 30, 100))
+	require.Zero(t, ts.deletedBy([]byte("a/0"), 0, 5))
+
+	require.Equal(t, uint64(10), ts.deletedBy([]byte("a/0"), 0, 15))
+	require.Equal(t, uint64(20), ts.deletedBy([]byte("a/b/0"), 0, 25))
+	require.Equal(t, uint64(30), ts.deletedBy([]byte("a/b/0"), 0, 100))
+	require.Equal(t, uint64(30), ts.deletedBy([]byte("a/0"), 15, 100))
+
+	t.Run("pruning should keep the latest tombstone before the truncation point", func(t *testing.T) {
+		ts.prune(35)
+
+		require.Equal(t, []uint64{30}, ts.byPrefix["a/"])
+		require.Equal(t, []uint64{20}, ts.byPrefix["a/b/"])
+		require.Equal(t, []uint64{40}, ts.byPrefix["c/"])
+
+		require.Equal(t, uint64(30), ts.deletedBy([]byte("a/0"), 0, 100))
+		require.Equal(t, uint64(40), ts.deletedBy([]byte("c/0"), 0, 100))
+	})
+}
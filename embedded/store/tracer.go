@@ -0,0 +1,81 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import "context"
+
+const (
+	SpanCommit        = "store.commit"
+	SpanValidate      = "store.commit.validate"
+	SpanValueLogWrite = "store.commit.vlogWrite"
+	SpanAHTAppend     = "store.commit.ahtAppend"
+	SpanSync          = "store.sync"
+	SpanIndex         = "store.index"
+	SpanFlushIndex    = "store.flushIndex"
+	SpanCompactIndex  = "store.compactIndex"
+	SpanResolveValue  = "store.resolveValue"
+	SpanAttrTxID      = "tx.id"
+	SpanAttrEntries   = "tx.entries"
+	SpanAttrBytes     = "bytes"
+	SpanAttrCleanup   = "index.cleanupPercentage"
+	SpanAttrSynced    = "index.synced"
+	SpanAttrValueSize = "value.size"
+)
+
+// SpanAttrs holds the attributes attached to a span when it's started
+type SpanAttrs map[string]interface{}
+
+// Tracer is a generic hook used to trace key store operations.
+// It's meant to be implemented by adapters of tracing libraries e.g. OpenTelemetry,
+// the returned context must carry the span so nested spans get properly linked
+type Tracer interface {
+	StartSpan(ctx context.Context, name string, attrs SpanAttrs) (context.Context, Span)
+}
+
+// Span is the unit of work started by a Tracer, End is called once the operation is completed.
+// Attributes only known at the end of the operation (e.g. tx ID on commit) are set before ending it
+type Span interface {
+	SetAttr(key string, value interface{})
+	End(err error)
+}
+
+type noopTracer struct{}
+
+type noopSpan struct{}
+
+func (noopTracer) StartSpan(ctx context.Context, name string, attrs SpanAttrs) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+func (noopSpan) SetAttr(key string, value interface{}) {}
+
+func (noopSpan) End(err error) {}
+
+// startSpan starts a span with the configured tracer. Attributes are only built when
+// a tracer is configured, so untraced operations don't allocate them
+func (s *ImmuStore) startSpan(ctx context.Context, name string, attrs func() SpanAttrs) (context.Context, Span) {
+	if _, ok := s.tracer.(noopTracer); ok {
+		return ctx, noopSpan{}
+	}
+
+	var spanAttrs SpanAttrs
+	if attrs != nil {
+		spanAttrs = attrs()
+	}
+
+	return s.tracer.StartSpan(ctx, name, spanAttrs)
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 
 	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/htree"
 )
 
@@ -32,6 +33,8 @@ type Tx struct {
 	entries []*TxEntry
 
 	htree *htree.HTree
+
+	hashAlg hashing.Algorithm
 }
 
 type TxHeader struct {
@@ -47,9 +50,16 @@ type TxHeader struct {
 
 	NEntries int
 	Eh       [sha256.Size]byte
+
+	// hashAlg is not part of the serialized header, it's set by the store the header belongs to
+	hashAlg hashing.Algorithm
 }
 
 func NewTx(nentries int, maxKeyLen int) *Tx {
+	return newTx(nentries, maxKeyLen, hashing.SHA256)
+}
+
+func newTx(nentries int, maxKeyLen int, hashAlg hashing.Algorithm) *Tx {
 	entries := make([]*TxEntry, nentries)
 
 	keyBuffer := make([]byte, maxKeyLen*nentries)
@@ -60,18 +70,20 @@ func NewTx(nentries int, maxKeyLen int) *Tx {
 		keyBuffer = keyBuffer[maxKeyLen:]
 	}
 
-	header := &TxHeader{NEntries: len(entries)}
+	header := &TxHeader{NEntries: len(entries), hashAlg: hashAlg}
 
 	return NewTxWithEntries(header, entries)
 }
 
+// NewTxWithEntries creates a transaction whose digests are calculated with the hash algorithm of the header
 func NewTxWithEntries(header *TxHeader, entries []*TxEntry) *Tx {
-	htree, _ := htree.New(len(entries))
+	htree, _ := htree.NewWith(len(entries), header.hashAlg)
 
 	return &Tx{
 		header:  header,
 		entries: entries,
 		htree:   htree,
+		hashAlg: header.hashAlg,
 	}
 }
 
@@ -96,9 +108,16 @@ func (tx *Tx) Header() *TxHeader {
 
 		NEntries: tx.header.NEntries,
 		Eh:       tx.header.Eh,
+
+		hashAlg: tx.header.hashAlg,
 	}
 }
 
+// HashAlgorithm returns the algorithm used to calculate the digests of the transaction
+func (hdr *TxHeader) HashAlgorithm() hashing.Algorithm {
+	return hdr.hashAlg
+}
+
 func (hdr *TxHeader) Bytes() ([]byte, error) {
 	// ID + PrevAlh + Ts + Version + MDLen + MD + NEntries + Eh + BlTxID + BlRoot
 	var b [txIDSize + sha256.Size + tsSize + sszSize + (sszSize + maxTxMetadataLen) + lszSize + sha256.Size + txIDSize + sha256.Size]byte
@@ -246,6 +265,10 @@ func (hdr *TxHeader) ReadFrom(b []byte) error {
 }
 
 func (hdr *TxHeader) innerHash() [sha256.Size]byte {
+	return hdr.innerHashWith(hdr.hashAlg)
+}
+
+func (hdr *TxHeader) innerHashWith(hashAlg hashing.Algorithm) [sha256.Size]byte {
 	// ts + version + (mdLen + md)? + nentries + eH + blTxID + blRoot
 	var b [tsSize + sszSize + (sszSize + maxTxMetadataLen) + lszSize + sha256.Size + txIDSize + sha256.Size]byte
 	i := 0
@@ -297,32 +320,38 @@ func (hdr *TxHeader) innerHash() [sha256.Size]byte {
 	i += sha256.Size
 
 	// hash(ts + version + (mdLen + md) + nentries + eH + blTxID + blRoot)
-	return sha256.Sum256(b[:i])
+	return hashAlg.Sum(b[:i])
 }
 
 // Alh calculates the Accumulative Linear Hash up to this transaction
 // Alh is calculated as hash(txID + prevAlh + hash(ts + nentries + eH + blTxID + blRoot))
 // Inner hash is calculated so to reduce the length of linear proofs
 func (hdr *TxHeader) Alh() [sha256.Size]byte {
+	return hdr.alhWith(hdr.hashAlg)
+}
+
+func (hdr *TxHeader) alhWith(hashAlg hashing.Algorithm) [sha256.Size]byte {
 	// txID + prevAlh + innerHash
 	var bi [txIDSize + 2*sha256.Size]byte
 	binary.BigEndian.PutUint64(bi[:], hdr.ID)
 	copy(bi[txIDSize:], hdr.PrevAlh[:])
 
 	// hash(ts + version + (mdLen + md)? + nentries + eH + blTxID + blRoot)
-	innerHash := hdr.innerHash()
+	innerHash := hdr.innerHashWith(hashAlg)
 	copy(bi[txIDSize+sha256.Size:], innerHash[:])
 
 	// hash(txID + prevAlh + innerHash)
-	return sha256.Sum256(bi[:])
+	return hashAlg.Sum(bi[:])
 }
 
 func (hdr *TxHeader) TxEntryDigest() (TxEntryDigest, error) {
+	hashAlg := hdr.hashAlg
+
 	switch hdr.Version {
 	case 0:
-		return TxEntryDigest_v1_1, nil
+		return func(e *TxEntry) ([sha256.Size]byte, error) { return txEntryDigest_v1_1(hashAlg, e) }, nil
 	case 1:
-		return TxEntryDigest_v1_2, nil
+		return func(e *TxEntry) ([sha256.Size]byte, error) { return txEntryDigest_v1_2(hashAlg, e) }, nil
 	}
 
 	return nil, ErrCorruptedTxDataUnknownHeaderVersion
@@ -389,8 +418,19 @@ func (tx *Tx) Proof(key []byte) (*htree.InclusionProof, error) {
 	return tx.htree.InclusionProof(kindex)
 }
 
-func (tx *Tx) readFrom(r *appendable.Reader) error {
-	tdr := &txDataReader{r: r}
+func (tx *Tx) readFrom(r *appendable.Reader, hashAlg hashing.Algorithm) error {
+	// tx holders may be created without knowing the store they will be used with
+	if tx.hashAlg != hashAlg {
+		htree, err := htree.NewWith(len(tx.entries), hashAlg)
+		if err != nil {
+			return err
+		}
+
+		tx.htree = htree
+		tx.hashAlg = hashAlg
+	}
+
+	tdr := &txDataReader{r: r, hashAlg: hashAlg}
 
 	header, err := tdr.readHeader(len(tx.entries))
 	if err != nil {
@@ -419,10 +459,15 @@ type txDataReader struct {
 	h          *TxHeader
 	digests    [][sha256.Size]byte
 	digestFunc TxEntryDigest
+	hashAlg    hashing.Algorithm
+
+	// when set, entry digests are not kept but only used to calculate the root of the tx,
+	// which is enough when no inclusion proof is needed (see validateRoot)
+	builder *htree.Builder
 }
 
 func (t *txDataReader) readHeader(maxEntries int) (*TxHeader, error) {
-	header := &TxHeader{}
+	header := &TxHeader{hashAlg: t.hashAlg}
 
 	id, err := t.r.ReadUint64()
 	if err != nil {
@@ -520,7 +565,11 @@ func (t *txDataReader) readHeader(maxEntries int) (*TxHeader, error) {
 		return nil, err
 	}
 
-	t.digests = make([][sha256.Size]byte, 0, header.NEntries)
+	if t.builder != nil {
+		t.builder.Reset()
+	} else {
+		t.digests = make([][sha256.Size]byte, 0, header.NEntries)
+	}
 
 	return header, nil
 }
@@ -591,24 +640,43 @@ func (t *txDataReader) readEntry(entry *TxEntry) error {
 		return err
 	}
 
-	t.digests = append(t.digests, digest)
+	if t.builder != nil {
+		t.builder.Add(digest)
+	} else {
+		t.digests = append(t.digests, digest)
+	}
 
 	return nil
 }
 
 func (t *txDataReader) buildAndValidateHtree(htree *htree.HTree) error {
-	var alh [sha256.Size]byte
-	_, err := t.r.Read(alh[:])
+	err := htree.BuildWith(t.digests)
 	if err != nil {
 		return err
 	}
 
-	err = htree.BuildWith(t.digests)
+	root, err := htree.Root()
 	if err != nil {
 		return err
 	}
 
-	root, err := htree.Root()
+	return t.validateAlh(root)
+}
+
+// validateRoot checks the tx data against the root calculated while reading its entries,
+// the reader must have been created with a builder
+func (t *txDataReader) validateRoot() error {
+	root, err := t.builder.Finalize()
+	if err != nil {
+		return err
+	}
+
+	return t.validateAlh(root)
+}
+
+func (t *txDataReader) validateAlh(root [sha256.Size]byte) error {
+	var alh [sha256.Size]byte
+	_, err := t.r.Read(alh[:])
 	if err != nil {
 		return err
 	}
@@ -681,6 +749,10 @@ func (e *TxEntry) VLen() int {
 type TxEntryDigest func(e *TxEntry) ([sha256.Size]byte, error)
 
 func TxEntryDigest_v1_1(e *TxEntry) ([sha256.Size]byte, error) {
+	return txEntryDigest_v1_1(hashing.SHA256, e)
+}
+
+func txEntryDigest_v1_1(hashAlg hashing.Algorithm, e *TxEntry) ([sha256.Size]byte, error) {
 	if e.md != nil && len(e.md.Bytes()) > 0 {
 		return [sha256.Size]byte{}, ErrMetadataUnsupported
 	}
@@ -690,10 +762,14 @@ func TxEntryDigest_v1_1(e *TxEntry) ([sha256.Size]byte, error) {
 	copy(b[:], e.k[:e.kLen])
 	copy(b[e.kLen:], e.hVal[:])
 
-	return sha256.Sum256(b), nil
+	return hashAlg.Sum(b), nil
 }
 
 func TxEntryDigest_v1_2(e *TxEntry) ([sha256.Size]byte, error) {
+	return txEntryDigest_v1_2(hashing.SHA256, e)
+}
+
+func txEntryDigest_v1_2(hashAlg hashing.Algorithm, e *TxEntry) ([sha256.Size]byte, error) {
 	var mdbs []byte
 
 	if e.md != nil {
@@ -720,5 +796,5 @@ func TxEntryDigest_v1_2(e *TxEntry) ([sha256.Size]byte, error) {
 	copy(b[i:], e.hVal[:])
 	i += sha256.Size
 
-	return sha256.Sum256(b[:i]), nil
+	return hashAlg.Sum(b[:i]), nil
 }
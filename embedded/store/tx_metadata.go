@@ -25,14 +25,23 @@ import (
 // attributeCode is used to identify the attribute.
 const (
 	truncatedUptoTxAttrCode attributeCode = 0
+	idempotencyKeyAttrCode  attributeCode = 1
 )
 
 // attribute size is the size of the attribute in bytes.
 const (
 	truncatedUptoTxAttrSize = txIDSize
+	idempotencyKeyLenSize   = 1
 )
 
-const maxTxMetadataLen = (attrCodeSize + truncatedUptoTxAttrSize)
+// MaxIdempotencyKeyLen is the maximum length of a transaction idempotency key.
+const MaxIdempotencyKeyLen = 64
+
+// idempotency keys are not copied into indexed values
+const maxIndexedTxMetadataLen = (attrCodeSize + truncatedUptoTxAttrSize)
+
+const maxTxMetadataLen = maxIndexedTxMetadataLen +
+	(attrCodeSize + idempotencyKeyLenSize + MaxIdempotencyKeyLen)
 
 // truncatedUptoTxAttribute is used to identify that the transaction
 // stores the information up to which given transaction ID the
@@ -63,12 +72,53 @@ func (a *truncatedUptoTxAttribute) deserialize(b []byte) (int, error) {
 	return txIDSize, nil
 }
 
+// idempotencyKeyAttribute holds a client-supplied token used to
+// detect retried transactions.
+type idempotencyKeyAttribute struct {
+	key []byte
+}
+
+// code returns the attribute code.
+func (a *idempotencyKeyAttribute) code() attributeCode {
+	return idempotencyKeyAttrCode
+}
+
+// serialize returns the serialized attribute.
+func (a *idempotencyKeyAttribute) serialize() []byte {
+	b := make([]byte, idempotencyKeyLenSize+len(a.key))
+	b[0] = byte(len(a.key))
+	copy(b[idempotencyKeyLenSize:], a.key)
+	return b
+}
+
+// deserialize deserializes the attribute.
+func (a *idempotencyKeyAttribute) deserialize(b []byte) (int, error) {
+	if len(b) < idempotencyKeyLenSize {
+		return 0, ErrCorruptedData
+	}
+
+	keyLen := int(b[0])
+
+	if keyLen == 0 || keyLen > MaxIdempotencyKeyLen || len(b) < idempotencyKeyLenSize+keyLen {
+		return 0, ErrCorruptedData
+	}
+
+	a.key = make([]byte, keyLen)
+	copy(a.key, b[idempotencyKeyLenSize:])
+
+	return idempotencyKeyLenSize + keyLen, nil
+}
+
 func getAttributeFrom(attrCode attributeCode) (attribute, error) {
 	switch attrCode {
 	case truncatedUptoTxAttrCode:
 		{
 			return &truncatedUptoTxAttribute{}, nil
 		}
+	case idempotencyKeyAttrCode:
+		{
+			return &idempotencyKeyAttribute{}, nil
+		}
 	default:
 		{
 			return nil, fmt.Errorf("error reading tx metadata attributes: %w", ErrCorruptedData)
@@ -96,9 +146,19 @@ func (md *TxMetadata) Equal(amd *TxMetadata) bool {
 }
 
 func (md *TxMetadata) Bytes() []byte {
+	return md.bytes(truncatedUptoTxAttrCode, idempotencyKeyAttrCode)
+}
+
+// indexedBytes returns the serialized attributes which are copied into
+// indexed values.
+func (md *TxMetadata) indexedBytes() []byte {
+	return md.bytes(truncatedUptoTxAttrCode)
+}
+
+func (md *TxMetadata) bytes(attrCodes ...attributeCode) []byte {
 	var b bytes.Buffer
 
-	for _, attrCode := range []attributeCode{truncatedUptoTxAttrCode} {
+	for _, attrCode := range attrCodes {
 		attr, ok := md.attributes[attrCode]
 		if ok {
 			b.WriteByte(byte(attr.code()))
@@ -110,7 +170,7 @@ func (md *TxMetadata) Bytes() []byte {
 }
 
 func (md *TxMetadata) ReadFrom(b []byte) error {
-	if len(b) > maxKVMetadataLen {
+	if len(b) > maxTxMetadataLen {
 		return ErrCorruptedData
 	}
 
@@ -178,3 +238,35 @@ func (md *TxMetadata) WithTruncatedTxID(txID uint64) *TxMetadata {
 	attr.(*truncatedUptoTxAttribute).txID = txID
 	return md
 }
+
+// HasIdempotencyKey returns true if the transaction carries an idempotency key.
+func (md *TxMetadata) HasIdempotencyKey() bool {
+	_, ok := md.attributes[idempotencyKeyAttrCode]
+	return ok
+}
+
+// IdempotencyKey returns the idempotency key of the transaction, if any.
+func (md *TxMetadata) IdempotencyKey() []byte {
+	attr, ok := md.attributes[idempotencyKeyAttrCode]
+	if !ok {
+		return nil
+	}
+
+	return attr.(*idempotencyKeyAttribute).key
+}
+
+// WithIdempotencyKey sets a client-supplied token which can later be used
+// to find out whether the transaction was already committed.
+// An empty key removes the attribute.
+func (md *TxMetadata) WithIdempotencyKey(key []byte) *TxMetadata {
+	if len(key) == 0 {
+		delete(md.attributes, idempotencyKeyAttrCode)
+		return md
+	}
+
+	k := make([]byte, len(key))
+	copy(k, key)
+
+	md.attributes[idempotencyKeyAttrCode] = &idempotencyKeyAttribute{key: k}
+	return md
+}
@@ -69,9 +69,50 @@ func TestTxMetadataWithAttributes(t *testing.T) {
 
 	bs = desmd.Bytes()
 	require.NotNil(t, bs)
-	require.Len(t, bs, maxTxMetadataLen)
+	require.Len(t, bs, maxIndexedTxMetadataLen)
 
 	err = desmd.ReadFrom(bs)
 	require.NoError(t, err)
 	require.True(t, desmd.HasTruncatedTxID())
 }
+
+func TestTxMetadataWithIdempotencyKey(t *testing.T) {
+	md := NewTxMetadata()
+	require.False(t, md.HasIdempotencyKey())
+	require.Nil(t, md.IdempotencyKey())
+
+	key := make([]byte, MaxIdempotencyKeyLen)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	md.WithTruncatedTxID(10).WithIdempotencyKey(key)
+	require.True(t, md.HasIdempotencyKey())
+	require.Equal(t, key, md.IdempotencyKey())
+
+	bs := md.Bytes()
+	require.Len(t, bs, maxTxMetadataLen)
+	require.Len(t, md.indexedBytes(), maxIndexedTxMetadataLen)
+
+	desmd := NewTxMetadata()
+	err := desmd.ReadFrom(bs)
+	require.NoError(t, err)
+	require.True(t, desmd.HasIdempotencyKey())
+	require.Equal(t, key, desmd.IdempotencyKey())
+	require.True(t, md.Equal(desmd))
+
+	md.WithIdempotencyKey(nil)
+	require.False(t, md.HasIdempotencyKey())
+	require.Len(t, md.Bytes(), maxIndexedTxMetadataLen)
+
+	t.Run("corrupted idempotency key", func(t *testing.T) {
+		err := NewTxMetadata().ReadFrom([]byte{byte(idempotencyKeyAttrCode)})
+		require.ErrorIs(t, err, ErrCorruptedData)
+
+		err = NewTxMetadata().ReadFrom([]byte{byte(idempotencyKeyAttrCode), 0})
+		require.ErrorIs(t, err, ErrCorruptedData)
+
+		err = NewTxMetadata().ReadFrom([]byte{byte(idempotencyKeyAttrCode), 2, 1})
+		require.ErrorIs(t, err, ErrCorruptedData)
+	})
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"github.com/codenotary/immudb/embedded/appendable"
+)
+
+// txPrefetcher reads ahead the tx and commit logs on behalf of a consumer reading
+// transactions sequentially, such as the indexer or a tx reader
+type txPrefetcher struct {
+	txLog *appendable.Prefetcher
+	cLog  *appendable.Prefetcher
+}
+
+// newTxPrefetcher returns nil when read-ahead is disabled
+func (s *ImmuStore) newTxPrefetcher() *txPrefetcher {
+	if s.readAheadWindowSize == 0 {
+		return nil
+	}
+
+	return &txPrefetcher{
+		txLog: appendable.NewPrefetcher(s.txLog, s.readAheadWindowSize),
+		cLog:  appendable.NewPrefetcher(s.cLog, s.readAheadWindowSize),
+	}
+}
+
+// setLimits prevents data not yet precommitted, resp. committed, to be read ahead
+// as it may be overwritten. The caller must hold the commitStateRWMutex lock
+func (p *txPrefetcher) setLimits(s *ImmuStore) {
+	p.txLog.SetLimit(s.precommittedTxLogSize)
+	p.cLog.SetLimit(int64(s.committedTxID * cLogEntrySize))
+}
@@ -32,6 +32,9 @@ type TxReader struct {
 
 	st  *ImmuStore
 	_tx *Tx
+
+	// nil when read-ahead is disabled or transactions are read in descending order
+	prefetcher *txPrefetcher
 }
 
 func (s *ImmuStore) NewTxReader(initialTxID uint64, desc bool, tx *Tx) (*TxReader, error) {
@@ -54,14 +57,20 @@ func (s *ImmuStore) newTxReader(initialTxID uint64, desc, allowPrecommitted bool
 		return nil, ErrIllegalArguments
 	}
 
-	return &TxReader{
+	txr := &TxReader{
 		InitialTxID:       initialTxID,
 		Desc:              desc,
 		CurrTxID:          initialTxID,
 		allowPrecommitted: allowPrecommitted,
 		st:                s,
 		_tx:               tx,
-	}, nil
+	}
+
+	if !desc {
+		txr.prefetcher = s.newTxPrefetcher()
+	}
+
+	return txr, nil
 }
 
 func (txr *TxReader) Read() (*Tx, error) {
@@ -69,7 +78,7 @@ func (txr *TxReader) Read() (*Tx, error) {
 		return nil, ErrNoMoreEntries
 	}
 
-	err := txr.st.readTx(txr.CurrTxID, txr.allowPrecommitted, txr._tx)
+	err := txr.st.readTx(txr.CurrTxID, txr.allowPrecommitted, txr._tx, txr.prefetcher)
 	if err == ErrTxNotFound {
 		return nil, ErrNoMoreEntries
 	}
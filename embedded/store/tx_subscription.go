@@ -0,0 +1,130 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+)
+
+// TxIterator streams committed transactions in ascending order
+type TxIterator interface {
+	// Next blocks until the next transaction is committed or ctx is done.
+	// The returned transaction is only valid until the following call to Next
+	Next(ctx context.Context) (*Tx, error)
+	Close() error
+}
+
+type txSubscription struct {
+	st *ImmuStore
+
+	nextTxID uint64
+	prevAlh  [sha256.Size]byte
+
+	tx *Tx
+
+	// cancels the ongoing Next call, so Close doesn't need to wait for new commits
+	cancel context.CancelFunc
+
+	readMutex sync.Mutex // serializes Next calls
+
+	closed bool
+	mutex  sync.Mutex
+}
+
+// SubscribeTx returns an iterator over the transactions committed from fromTx onwards,
+// including the ones committed after the subscription is created.
+// Each Next call waits on the commit watchers, thus it counts towards MaxWaitees.
+func (s *ImmuStore) SubscribeTx(fromTx uint64) (TxIterator, error) {
+	if fromTx == 0 {
+		return nil, fmt.Errorf("%w: invalid initial transaction", ErrIllegalArguments)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	return &txSubscription{
+		st:       s,
+		nextTxID: fromTx,
+		tx:       NewTx(s.maxTxEntries, s.maxKeyLen),
+	}, nil
+}
+
+func (sub *txSubscription) Next(ctx context.Context) (*Tx, error) {
+	sub.readMutex.Lock()
+	defer sub.readMutex.Unlock()
+
+	sub.mutex.Lock()
+
+	if sub.closed {
+		sub.mutex.Unlock()
+		return nil, ErrAlreadyClosed
+	}
+
+	ctx, sub.cancel = context.WithCancel(ctx)
+	defer sub.cancel()
+
+	sub.mutex.Unlock()
+
+	err := sub.st.WaitForTx(ctx, sub.nextTxID, false)
+	if err != nil {
+		sub.mutex.Lock()
+		defer sub.mutex.Unlock()
+
+		if sub.closed {
+			return nil, ErrAlreadyClosed
+		}
+		return nil, err
+	}
+
+	err = sub.st.ReadTx(sub.nextTxID, sub.tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if sub.prevAlh != [sha256.Size]byte{} && sub.prevAlh != sub.tx.header.PrevAlh {
+		return nil, fmt.Errorf("%w: ALH mismatch at tx %d", ErrorCorruptedTxData, sub.tx.header.ID)
+	}
+
+	sub.nextTxID++
+	sub.prevAlh = sub.tx.header.Alh()
+
+	return sub.tx, nil
+}
+
+func (sub *txSubscription) Close() error {
+	sub.mutex.Lock()
+	defer sub.mutex.Unlock()
+
+	if sub.closed {
+		return ErrAlreadyClosed
+	}
+
+	sub.closed = true
+
+	if sub.cancel != nil {
+		sub.cancel()
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribeTx(t *testing.T) {
+	st, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer immustoreClose(t, st)
+
+	commit := func(i int) {
+		tx, err := st.NewWriteOnlyTx(context.Background())
+		require.NoError(t, err)
+
+		err = tx.Set([]byte(fmt.Sprintf("key%d", i)), nil, []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+
+		_, err = tx.Commit(context.Background())
+		require.NoError(t, err)
+	}
+
+	_, err = st.SubscribeTx(0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	for i := 1; i <= 3; i++ {
+		commit(i)
+	}
+
+	it, err := st.SubscribeTx(2)
+	require.NoError(t, err)
+
+	for i := 2; i <= 3; i++ {
+		tx, err := it.Next(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, uint64(i), tx.Header().ID)
+		require.Equal(t, []byte(fmt.Sprintf("key%d", i)), tx.Entries()[0].Key())
+	}
+
+	t.Run("Next should wait for new commits", func(t *testing.T) {
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			commit(4)
+		}()
+
+		tx, err := it.Next(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, uint64(4), tx.Header().ID)
+	})
+
+	t.Run("Next should honor the context", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := it.Next(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Close should interrupt an ongoing Next", func(t *testing.T) {
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			it.Close()
+		}()
+
+		_, err := it.Next(context.Background())
+		require.ErrorIs(t, err, ErrAlreadyClosed)
+
+		err = it.Close()
+		require.ErrorIs(t, err, ErrAlreadyClosed)
+	})
+}
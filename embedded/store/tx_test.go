@@ -23,6 +23,7 @@ import (
 
 	"github.com/codenotary/immudb/embedded/appendable"
 	"github.com/codenotary/immudb/embedded/appendable/mocked"
+	"github.com/codenotary/immudb/embedded/hashing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -41,7 +42,7 @@ func TestReadTxFromCorruptedData(t *testing.T) {
 	a.ReadAtFn = func(bs []byte, off int64) (int, error) {
 		return 0, errors.New("error")
 	}
-	err := tx.readFrom(r)
+	err := tx.readFrom(r, hashing.SHA256)
 	require.Error(t, err)
 
 	// Should fail while reading Ts
@@ -52,7 +53,7 @@ func TestReadTxFromCorruptedData(t *testing.T) {
 		}
 		return 0, errors.New("error")
 	}
-	err = tx.readFrom(r)
+	err = tx.readFrom(r, hashing.SHA256)
 	require.Error(t, err)
 
 	// Should fail while reading BlTxID
@@ -63,7 +64,7 @@ func TestReadTxFromCorruptedData(t *testing.T) {
 		}
 		return 0, errors.New("error")
 	}
-	err = tx.readFrom(r)
+	err = tx.readFrom(r, hashing.SHA256)
 	require.Error(t, err)
 
 	// Should fail while reading BlRoot
@@ -74,7 +75,7 @@ func TestReadTxFromCorruptedData(t *testing.T) {
 		}
 		return 0, errors.New("error")
 	}
-	err = tx.readFrom(r)
+	err = tx.readFrom(r, hashing.SHA256)
 	require.Error(t, err)
 
 	// Should fail while reading PrevAlh
@@ -85,7 +86,7 @@ func TestReadTxFromCorruptedData(t *testing.T) {
 		}
 		return 0, errors.New("error")
 	}
-	err = tx.readFrom(r)
+	err = tx.readFrom(r, hashing.SHA256)
 	require.Error(t, err)
 
 	// Should fail while reading nentries
@@ -96,7 +97,7 @@ func TestReadTxFromCorruptedData(t *testing.T) {
 		}
 		return 0, errors.New("error")
 	}
-	err = tx.readFrom(r)
+	err = tx.readFrom(r, hashing.SHA256)
 	require.Error(t, err)
 }
 
@@ -18,12 +18,15 @@ package store
 
 import (
 	"sync"
+
+	"github.com/codenotary/immudb/embedded/hashing"
 )
 
 type txPoolOptions struct {
 	poolSize     int
 	maxTxEntries int
 	maxKeyLen    int
+	hashAlg      hashing.Algorithm
 	preallocated bool
 }
 
@@ -53,7 +56,7 @@ func newTxPool(opts txPoolOptions) (TxPool, error) {
 
 	if opts.preallocated {
 		for i := 0; i < opts.poolSize; i++ {
-			ret.pool = append(ret.pool, NewTx(opts.maxTxEntries, opts.maxKeyLen))
+			ret.pool = append(ret.pool, newTx(opts.maxTxEntries, opts.maxKeyLen, opts.hashAlg))
 		}
 	}
 
@@ -69,7 +72,7 @@ func (p *txPool) Alloc() (*Tx, error) {
 			return nil, ErrTxPoolExhausted
 		}
 
-		p.pool = append(p.pool, NewTx(p.opts.maxTxEntries, p.opts.maxKeyLen))
+		p.pool = append(p.pool, newTx(p.opts.maxTxEntries, p.opts.maxKeyLen, p.opts.hashAlg))
 	}
 
 	tx := p.pool[p.used]
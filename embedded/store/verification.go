@@ -21,23 +21,35 @@ import (
 	"encoding/binary"
 
 	"github.com/codenotary/immudb/embedded/ahtree"
+	"github.com/codenotary/immudb/embedded/hashing"
 	"github.com/codenotary/immudb/embedded/htree"
 )
 
+// Verification functions assume SHA-256 is used as tx hash algorithm,
+// the ones suffixed with With must be used to verify proofs produced by stores using a different algorithm
+
 func VerifyInclusion(proof *htree.InclusionProof, entryDigest, root [sha256.Size]byte) bool {
-	return htree.VerifyInclusion(proof, entryDigest, root)
+	return VerifyInclusionWith(hashing.SHA256, proof, entryDigest, root)
+}
+
+func VerifyInclusionWith(hashAlg hashing.Algorithm, proof *htree.InclusionProof, entryDigest, root [sha256.Size]byte) bool {
+	return htree.VerifyInclusionWith(hashAlg, proof, entryDigest, root)
 }
 
-func advanceLinearHash(alh [sha256.Size]byte, txID uint64, term [sha256.Size]byte) [sha256.Size]byte {
+func advanceLinearHash(hashAlg hashing.Algorithm, alh [sha256.Size]byte, txID uint64, term [sha256.Size]byte) [sha256.Size]byte {
 	var bs [txIDSize + 2*sha256.Size]byte
 	binary.BigEndian.PutUint64(bs[:], txID)
 	copy(bs[txIDSize:], alh[:])
 	copy(bs[txIDSize+sha256.Size:], term[:]) // innerHash = hash(ts + mdLen + md + nentries + eH + blTxID + blRoot)
-	return sha256.Sum256(bs[:])              // hash(txID + prevAlh + innerHash)
+	return hashAlg.Sum(bs[:])                // hash(txID + prevAlh + innerHash)
 }
 
 func VerifyLinearProof(proof *LinearProof, sourceTxID, targetTxID uint64, sourceAlh, targetAlh [sha256.Size]byte) bool {
-	if proof == nil || proof.SourceTxID != sourceTxID || proof.TargetTxID != targetTxID {
+	return VerifyLinearProofWith(hashing.SHA256, proof, sourceTxID, targetTxID, sourceAlh, targetAlh)
+}
+
+func VerifyLinearProofWith(hashAlg hashing.Algorithm, proof *LinearProof, sourceTxID, targetTxID uint64, sourceAlh, targetAlh [sha256.Size]byte) bool {
+	if hashAlg.Validate() != nil || proof == nil || proof.SourceTxID != sourceTxID || proof.TargetTxID != targetTxID {
 		return false
 	}
 
@@ -53,7 +65,7 @@ func VerifyLinearProof(proof *LinearProof, sourceTxID, targetTxID uint64, source
 	calculatedAlh := proof.Terms[0]
 
 	for i := 1; i < len(proof.Terms); i++ {
-		calculatedAlh = advanceLinearHash(calculatedAlh, proof.SourceTxID+uint64(i), proof.Terms[i])
+		calculatedAlh = advanceLinearHash(hashAlg, calculatedAlh, proof.SourceTxID+uint64(i), proof.Terms[i])
 	}
 
 	return targetAlh == calculatedAlh
@@ -66,6 +78,18 @@ func VerifyLinearAdvanceProof(
 	endAlh [sha256.Size]byte,
 	treeRoot [sha256.Size]byte,
 	treeSize uint64,
+) bool {
+	return VerifyLinearAdvanceProofWith(hashing.SHA256, proof, startTxID, endTxID, endAlh, treeRoot, treeSize)
+}
+
+func VerifyLinearAdvanceProofWith(
+	hashAlg hashing.Algorithm,
+	proof *LinearAdvanceProof,
+	startTxID uint64,
+	endTxID uint64,
+	endAlh [sha256.Size]byte,
+	treeRoot [sha256.Size]byte,
+	treeSize uint64,
 ) bool {
 	//
 	//       Old
@@ -85,7 +109,7 @@ func VerifyLinearAdvanceProof(
 	//         startTxID                            endTxID
 	//
 
-	if endTxID < startTxID {
+	if endTxID < startTxID || hashAlg.Validate() != nil {
 		// This must not happen - that's an invalid proof
 		return false
 	}
@@ -106,18 +130,19 @@ func VerifyLinearAdvanceProof(
 	for txID := startTxID + 1; txID < endTxID; txID++ {
 
 		// Ensure the node in the chain is included in the target Merkle Tree
-		if !ahtree.VerifyInclusion(
+		if !ahtree.VerifyInclusionWith(
+			hashAlg,
 			proof.InclusionProofs[txID-startTxID-1],
 			txID,
 			treeSize,
-			leafFor(calculatedAlh),
+			leafFor(hashAlg, calculatedAlh),
 			treeRoot,
 		) {
 			return false
 		}
 
 		// Get the Alh for the next transaction
-		calculatedAlh = advanceLinearHash(calculatedAlh, txID+1, proof.LinearProofTerms[txID-startTxID])
+		calculatedAlh = advanceLinearHash(hashAlg, calculatedAlh, txID+1, proof.LinearProofTerms[txID-startTxID])
 	}
 
 	// We must end up with the final Alh - that one is also checked for inclusion but in different part of the proof
@@ -125,7 +150,12 @@ func VerifyLinearAdvanceProof(
 }
 
 func VerifyDualProof(proof *DualProof, sourceTxID, targetTxID uint64, sourceAlh, targetAlh [sha256.Size]byte) bool {
-	if proof == nil ||
+	return VerifyDualProofWith(hashing.SHA256, proof, sourceTxID, targetTxID, sourceAlh, targetAlh)
+}
+
+func VerifyDualProofWith(hashAlg hashing.Algorithm, proof *DualProof, sourceTxID, targetTxID uint64, sourceAlh, targetAlh [sha256.Size]byte) bool {
+	if hashAlg.Validate() != nil ||
+		proof == nil ||
 		proof.SourceTxHeader == nil ||
 		proof.TargetTxHeader == nil ||
 		proof.SourceTxHeader.ID != sourceTxID ||
@@ -137,22 +167,23 @@ func VerifyDualProof(proof *DualProof, sourceTxID, targetTxID uint64, sourceAlh,
 		return false
 	}
 
-	cSourceAlh := proof.SourceTxHeader.Alh()
+	cSourceAlh := proof.SourceTxHeader.alhWith(hashAlg)
 	if sourceAlh != cSourceAlh {
 		return false
 	}
 
-	cTargetAlh := proof.TargetTxHeader.Alh()
+	cTargetAlh := proof.TargetTxHeader.alhWith(hashAlg)
 	if targetAlh != cTargetAlh {
 		return false
 	}
 
 	if sourceTxID < proof.TargetTxHeader.BlTxID {
-		verifies := ahtree.VerifyInclusion(
+		verifies := ahtree.VerifyInclusionWith(
+			hashAlg,
 			proof.InclusionProof,
 			sourceTxID,
 			proof.TargetTxHeader.BlTxID,
-			leafFor(sourceAlh),
+			leafFor(hashAlg, sourceAlh),
 			proof.TargetTxHeader.BlRoot,
 		)
 
@@ -162,7 +193,8 @@ func VerifyDualProof(proof *DualProof, sourceTxID, targetTxID uint64, sourceAlh,
 	}
 
 	if proof.SourceTxHeader.BlTxID > 0 {
-		verifies := ahtree.VerifyConsistency(
+		verifies := ahtree.VerifyConsistencyWith(
+			hashAlg,
 			proof.ConsistencyProof,
 			proof.SourceTxHeader.BlTxID,
 			proof.TargetTxHeader.BlTxID,
@@ -176,10 +208,11 @@ func VerifyDualProof(proof *DualProof, sourceTxID, targetTxID uint64, sourceAlh,
 	}
 
 	if proof.TargetTxHeader.BlTxID > 0 {
-		verifies := ahtree.VerifyLastInclusion(
+		verifies := ahtree.VerifyLastInclusionWith(
+			hashAlg,
 			proof.LastInclusionProof,
 			proof.TargetTxHeader.BlTxID,
-			leafFor(proof.TargetBlTxAlh),
+			leafFor(hashAlg, proof.TargetBlTxAlh),
 			proof.TargetTxHeader.BlRoot,
 		)
 
@@ -189,7 +222,7 @@ func VerifyDualProof(proof *DualProof, sourceTxID, targetTxID uint64, sourceAlh,
 	}
 
 	if sourceTxID < proof.TargetTxHeader.BlTxID {
-		verifies := VerifyLinearProof(proof.LinearProof, proof.TargetTxHeader.BlTxID, targetTxID, proof.TargetBlTxAlh, targetAlh)
+		verifies := VerifyLinearProofWith(hashAlg, proof.LinearProof, proof.TargetTxHeader.BlTxID, targetTxID, proof.TargetBlTxAlh, targetAlh)
 		if !verifies {
 			return false
 		}
@@ -197,7 +230,8 @@ func VerifyDualProof(proof *DualProof, sourceTxID, targetTxID uint64, sourceAlh,
 		// Verify that the part of the linear proof consumed by the new merkle tree is consistent with that Merkle Tree
 		// In this case, this is the whole chain to the SourceTxID from the previous Merkle Tree.
 		// The sourceTxID consistency is already proven using proof.InclusionProof
-		if !VerifyLinearAdvanceProof(
+		if !VerifyLinearAdvanceProofWith(
+			hashAlg,
 			proof.LinearAdvanceProof,
 			proof.SourceTxHeader.BlTxID,
 			sourceTxID,
@@ -210,7 +244,7 @@ func VerifyDualProof(proof *DualProof, sourceTxID, targetTxID uint64, sourceAlh,
 
 	} else {
 
-		verifies := VerifyLinearProof(proof.LinearProof, sourceTxID, targetTxID, sourceAlh, targetAlh)
+		verifies := VerifyLinearProofWith(hashAlg, proof.LinearProof, sourceTxID, targetTxID, sourceAlh, targetAlh)
 		if !verifies {
 			return false
 		}
@@ -219,7 +253,8 @@ func VerifyDualProof(proof *DualProof, sourceTxID, targetTxID uint64, sourceAlh,
 		// In this case, this is the whole linear chain between the old Merkle Tree and the new Merkle Tree. The last entry
 		// in the new Merkle Tree is already proven through the LastInclusionProof, the remaining part of the liner proof
 		// that goes outside of the target Merkle Tree will be validated in future DualProof validations
-		if !VerifyLinearAdvanceProof(
+		if !VerifyLinearAdvanceProofWith(
+			hashAlg,
 			proof.LinearAdvanceProof,
 			proof.SourceTxHeader.BlTxID,
 			proof.TargetTxHeader.BlTxID,
@@ -234,38 +269,55 @@ func VerifyDualProof(proof *DualProof, sourceTxID, targetTxID uint64, sourceAlh,
 	return true
 }
 
-func leafFor(d [sha256.Size]byte) [sha256.Size]byte {
+func leafFor(hashAlg hashing.Algorithm, d [sha256.Size]byte) [sha256.Size]byte {
 	var b [1 + sha256.Size]byte
 	b[0] = ahtree.LeafPrefix
 	copy(b[1:], d[:])
-	return sha256.Sum256(b[:])
+	return hashAlg.Sum(b[:])
 }
 
 type EntrySpecDigest func(kv *EntrySpec) [sha256.Size]byte
 
 func EntrySpecDigestFor(version int) (EntrySpecDigest, error) {
+	return EntrySpecDigestWith(hashing.SHA256, version)
+}
+
+func EntrySpecDigestWith(hashAlg hashing.Algorithm, version int) (EntrySpecDigest, error) {
+	err := hashAlg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
 	switch version {
 	case 0:
-		return EntrySpecDigest_v0, nil
+		return func(kv *EntrySpec) [sha256.Size]byte { return entrySpecDigest_v0(hashAlg, kv) }, nil
 	case 1:
-		return EntrySpecDigest_v1, nil
+		return func(kv *EntrySpec) [sha256.Size]byte { return entrySpecDigest_v1(hashAlg, kv) }, nil
 	}
 
 	return nil, ErrUnsupportedTxVersion
 }
 
 func EntrySpecDigest_v0(kv *EntrySpec) [sha256.Size]byte {
+	return entrySpecDigest_v0(hashing.SHA256, kv)
+}
+
+func entrySpecDigest_v0(hashAlg hashing.Algorithm, kv *EntrySpec) [sha256.Size]byte {
 	b := make([]byte, len(kv.Key)+sha256.Size)
 
 	copy(b[:], kv.Key)
 
-	hvalue := sha256.Sum256(kv.Value)
+	hvalue := hashAlg.Sum(kv.Value)
 	copy(b[len(kv.Key):], hvalue[:])
 
-	return sha256.Sum256(b)
+	return hashAlg.Sum(b)
 }
 
 func EntrySpecDigest_v1(kv *EntrySpec) [sha256.Size]byte {
+	return entrySpecDigest_v1(hashing.SHA256, kv)
+}
+
+func entrySpecDigest_v1(hashAlg hashing.Algorithm, kv *EntrySpec) [sha256.Size]byte {
 	var mdbs []byte
 
 	if kv.Metadata != nil {
@@ -290,9 +342,9 @@ func EntrySpecDigest_v1(kv *EntrySpec) [sha256.Size]byte {
 	copy(b[i:], kv.Key)
 	i += len(kv.Key)
 
-	hvalue := sha256.Sum256(kv.Value)
+	hvalue := hashAlg.Sum(kv.Value)
 	copy(b[i:], hvalue[:])
 	i += sha256.Size
 
-	return sha256.Sum256(b[:i])
+	return hashAlg.Sum(b[:i])
 }
@@ -0,0 +1,98 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tbtree
+
+import (
+	"hash/fnv"
+)
+
+const MaxBloomFilterBitsPerKey = 32
+
+// bloomFilter summarizes the keys stored in a leaf node,
+// it's kept within the reference held by the parent node so
+// lookups of absent keys can be resolved without reading the leaf
+type bloomFilter struct {
+	k    uint8 // number of hash functions
+	bits []byte
+}
+
+func bloomFilterHashes(bitsPerKey int) uint8 {
+	// k = ln(2) * (m/n) minimizes the false positive rate
+	k := int(float64(bitsPerKey) * 0.69)
+	if k < 1 {
+		k = 1
+	}
+	return uint8(k)
+}
+
+// bloomFilterSize returns the number of bytes taken by a filter of nkeys
+func bloomFilterSize(nkeys, bitsPerKey int) int {
+	return (nkeys*bitsPerKey + 7) / 8
+}
+
+func newBloomFilter(values []*leafValue, bitsPerKey int) *bloomFilter {
+	f := &bloomFilter{
+		k:    bloomFilterHashes(bitsPerKey),
+		bits: make([]byte, bloomFilterSize(len(values), bitsPerKey)),
+	}
+
+	for _, v := range values {
+		f.add(v.key)
+	}
+
+	return f
+}
+
+func bloomFilterKeyHash(key []byte) (uint32, uint32) {
+	h := fnv.New64a()
+	h.Write(key)
+	sum := h.Sum64()
+
+	// an odd delta ensures all the bit positions can be reached
+	return uint32(sum), uint32(sum>>32) | 1
+}
+
+func (f *bloomFilter) add(key []byte) {
+	m := uint32(len(f.bits) * 8)
+	h, delta := bloomFilterKeyHash(key)
+
+	for i := uint8(0); i < f.k; i++ {
+		pos := h % m
+		f.bits[pos/8] |= 1 << (pos % 8)
+		h += delta
+	}
+}
+
+// mayContain returns false only when the key was not added into the filter
+func (f *bloomFilter) mayContain(key []byte) bool {
+	m := uint32(len(f.bits) * 8)
+	if m == 0 {
+		return false
+	}
+
+	h, delta := bloomFilterKeyHash(key)
+
+	for i := uint8(0); i < f.k; i++ {
+		pos := h % m
+		if f.bits[pos/8]&(1<<(pos%8)) == 0 {
+			return false
+		}
+		h += delta
+	}
+
+	return true
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tbtree
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/appendable"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBloomFilter(t *testing.T) {
+	values := make([]*leafValue, 1000)
+	for i := range values {
+		values[i] = &leafValue{key: []byte(fmt.Sprintf("key%d", i))}
+	}
+
+	f := newBloomFilter(values, 10)
+	require.Len(t, f.bits, bloomFilterSize(len(values), 10))
+
+	for _, v := range values {
+		require.True(t, f.mayContain(v.key))
+	}
+
+	falsePositives := 0
+	for i := 0; i < 10_000; i++ {
+		if f.mayContain([]byte(fmt.Sprintf("absent%d", i))) {
+			falsePositives++
+		}
+	}
+
+	// ~1% is expected with 10 bits per key
+	require.Less(t, falsePositives, 300)
+
+	empty := newBloomFilter(nil, 10)
+	require.False(t, empty.mayContain([]byte("key0")))
+}
+
+func TestTBTreeWithBloomFilters(t *testing.T) {
+	dir := t.TempDir()
+
+	opts := DefaultOptions().WithBloomFilterBitsPerKey(10)
+
+	tree, err := Open(dir, opts)
+	require.NoError(t, err)
+
+	keyCount := 10_000
+
+	for i := 0; i < keyCount; i++ {
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], uint64(i*2))
+
+		err = tree.Insert(k[:], []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	err = tree.Close()
+	require.NoError(t, err)
+
+	checkTree := func(t *testing.T, tree *TBtree) {
+		for i := 0; i < keyCount; i++ {
+			var k [8]byte
+			binary.BigEndian.PutUint64(k[:], uint64(i*2))
+
+			v, _, hc, err := tree.Get(k[:])
+			require.NoError(t, err)
+			require.Equal(t, []byte(fmt.Sprintf("value%d", i)), v)
+			require.Equal(t, uint64(1), hc)
+
+			binary.BigEndian.PutUint64(k[:], uint64(i*2+1))
+
+			_, _, _, err = tree.Get(k[:])
+			require.ErrorIs(t, err, ErrKeyNotFound)
+
+			_, _, err = tree.History(k[:], 0, false, 1)
+			require.ErrorIs(t, err, ErrKeyNotFound)
+		}
+	}
+
+	t.Run("absent keys are resolved by the filters", func(t *testing.T) {
+		tree, err := Open(dir, opts)
+		require.NoError(t, err)
+		defer tree.Close()
+
+		negatives := testutil.ToFloat64(metricsBloomFilterNegatives.WithLabelValues(dir))
+
+		checkTree(t, tree)
+
+		require.Greater(t, testutil.ToFloat64(metricsBloomFilterNegatives.WithLabelValues(dir))-negatives, float64(keyCount))
+	})
+
+	t.Run("filters are kept on updates", func(t *testing.T) {
+		tree, err := Open(dir, opts)
+		require.NoError(t, err)
+
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], uint64(0))
+
+		err = tree.Insert(k[:], []byte("value0"))
+		require.NoError(t, err)
+
+		err = tree.Close()
+		require.NoError(t, err)
+
+		tree, err = Open(dir, opts)
+		require.NoError(t, err)
+		defer tree.Close()
+
+		_, _, hc, err := tree.Get(k[:])
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), hc)
+	})
+
+	t.Run("trees with filters can be opened with filters disabled", func(t *testing.T) {
+		tree, err := Open(dir, DefaultOptions())
+		require.NoError(t, err)
+
+		// insertion rewrites the path to the leaf without filters
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], uint64(keyCount*2))
+
+		err = tree.Insert(k[:], []byte(fmt.Sprintf("value%d", keyCount)))
+		require.NoError(t, err)
+
+		err = tree.Close()
+		require.NoError(t, err)
+
+		tree, err = Open(dir, DefaultOptions())
+		require.NoError(t, err)
+		defer tree.Close()
+
+		v, _, _, err := tree.Get(k[:])
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", keyCount)), v)
+
+		_, _, hc, err := tree.Get(make([]byte, 8))
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), hc)
+	})
+
+	t.Run("only trees with filters should require the new version", func(t *testing.T) {
+		tree, err := Open(dir, DefaultOptions())
+		require.NoError(t, err)
+
+		version, ok := appendable.NewMetadata(tree.cLog.Metadata()).GetInt(MetaVersion)
+		require.True(t, ok)
+		require.Equal(t, BloomFilterVersion, version)
+
+		err = tree.Close()
+		require.NoError(t, err)
+
+		plainDir := t.TempDir()
+
+		tree, err = Open(plainDir, DefaultOptions())
+		require.NoError(t, err)
+
+		err = tree.Close()
+		require.NoError(t, err)
+
+		tree, err = Open(plainDir, opts)
+		require.NoError(t, err)
+		defer tree.Close()
+
+		version, ok = appendable.NewMetadata(tree.cLog.Metadata()).GetInt(MetaVersion)
+		require.True(t, ok)
+		require.Equal(t, MinCompatibleVersion, version)
+		require.Zero(t, tree.bloomFilterBitsPerKey)
+	})
+}
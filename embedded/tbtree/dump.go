@@ -0,0 +1,351 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tbtree
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// DumpVersion is the version of the portable format produced by Snapshot.DumpTo
+const DumpVersion = 1
+
+// dumpMagic identifies streams produced by Snapshot.DumpTo
+const dumpMagic = "TBTREEDUMP"
+
+const (
+	dumpEndTag   = 0
+	dumpEntryTag = 1
+)
+
+// history timestamps are read in batches while dumping a key
+const dumpHistoryBatchSize = 1_000
+
+// entries are inserted in batches while loading a dump
+const loadBatchSize = 1_000
+
+var ErrInvalidDump = errors.New("tbtree: invalid dump")
+
+// DumpTo writes every key in the snapshot, with its current value and the timestamps of
+// all its updates, into a self-contained stream which doesn't depend on the offsets of the
+// nodes and history logs, it can be restored as a new tree with Load.
+//
+// Stream layout: magic | version (uint16) | snapshot ts (uint64) |
+// entries: tag=1 (byte) | key len (uint16) | key | value len (uint16) | value | hCount (uint64) | hCount tss (uint64, ascending) |
+// tag=0 (byte) | sha256 digest of all the preceding bytes
+func (s *Snapshot) DumpTo(w io.Writer) (err error) {
+	bw := bufio.NewWriter(w)
+
+	digest := sha256.New()
+	dw := io.MultiWriter(bw, digest)
+
+	var buf [8]byte
+
+	_, err = dw.Write([]byte(dumpMagic))
+	if err != nil {
+		return err
+	}
+
+	binary.BigEndian.PutUint16(buf[:], DumpVersion)
+	_, err = dw.Write(buf[:2])
+	if err != nil {
+		return err
+	}
+
+	binary.BigEndian.PutUint64(buf[:], s.Ts())
+	_, err = dw.Write(buf[:])
+	if err != nil {
+		return err
+	}
+
+	r, err := s.NewReader(ReaderSpec{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := r.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	for {
+		key, value, _, hc, err := r.Read()
+		if errors.Is(err, ErrNoMoreEntries) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		entry := make([]byte, 1+2+len(key)+2+len(value)+8)
+		i := 0
+
+		entry[i] = dumpEntryTag
+		i++
+
+		binary.BigEndian.PutUint16(entry[i:], uint16(len(key)))
+		i += 2
+
+		i += copy(entry[i:], key)
+
+		binary.BigEndian.PutUint16(entry[i:], uint16(len(value)))
+		i += 2
+
+		i += copy(entry[i:], value)
+
+		binary.BigEndian.PutUint64(entry[i:], hc)
+
+		_, err = dw.Write(entry)
+		if err != nil {
+			return err
+		}
+
+		for offset := uint64(0); offset < hc; offset += dumpHistoryBatchSize {
+			tss, _, err := s.History(key, offset, false, dumpHistoryBatchSize)
+			if err != nil {
+				return err
+			}
+
+			for _, ts := range tss {
+				binary.BigEndian.PutUint64(buf[:], ts)
+
+				_, err = dw.Write(buf[:])
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	_, err = dw.Write([]byte{dumpEndTag})
+	if err != nil {
+		return err
+	}
+
+	_, err = bw.Write(digest.Sum(nil))
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// Load creates a new tree at the given path, which must not exist, with the content of a
+// stream produced by Snapshot.DumpTo. The tree is left open and fully flushed.
+// The path is removed if the stream can not be fully loaded
+func Load(r io.Reader, path string, opts *Options) (*TBtree, error) {
+	if r == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	_, err := os.Stat(path)
+	if err == nil {
+		return nil, fmt.Errorf("%w: while loading index into '%s'", ErrTargetPathAlreadyExists, path)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	t, err := Open(path, opts)
+	if err != nil {
+		os.RemoveAll(path)
+		return nil, err
+	}
+
+	err = t.loadAndFlush(r)
+	if err != nil {
+		t.Close()
+		os.RemoveAll(path)
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *TBtree) loadAndFlush(r io.Reader) error {
+	t.lock()
+	defer t.unlock()
+
+	err := t.load(r)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = t.flushTree(0, true, false, "Load")
+	return err
+}
+
+func (t *TBtree) load(r io.Reader) error {
+	br := bufio.NewReader(r)
+
+	digest := sha256.New()
+	dr := io.TeeReader(br, digest)
+
+	var buf [8]byte
+
+	magic := make([]byte, len(dumpMagic))
+
+	_, err := io.ReadFull(dr, magic)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDump, err)
+	}
+	if !bytes.Equal(magic, []byte(dumpMagic)) {
+		return fmt.Errorf("%w: unknown format", ErrInvalidDump)
+	}
+
+	_, err = io.ReadFull(dr, buf[:2])
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDump, err)
+	}
+	if binary.BigEndian.Uint16(buf[:]) != DumpVersion {
+		return fmt.Errorf("%w: unsupported version", ErrIncompatibleDataFormat)
+	}
+
+	_, err = io.ReadFull(dr, buf[:])
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDump, err)
+	}
+	snapTs := binary.BigEndian.Uint64(buf[:])
+
+	var kvts []*KVT
+	var batchTs uint64
+
+	readUint16 := func() (int, error) {
+		_, err := io.ReadFull(dr, buf[:2])
+		return int(binary.BigEndian.Uint16(buf[:])), err
+	}
+
+	readUint64 := func() (uint64, error) {
+		_, err := io.ReadFull(dr, buf[:])
+		return binary.BigEndian.Uint64(buf[:]), err
+	}
+
+	for {
+		_, err = io.ReadFull(dr, buf[:1])
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidDump, err)
+		}
+
+		if buf[0] == dumpEndTag {
+			break
+		}
+		if buf[0] != dumpEntryTag {
+			return fmt.Errorf("%w: unexpected entry tag", ErrInvalidDump)
+		}
+
+		ksize, err := readUint16()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidDump, err)
+		}
+		if ksize > t.maxKeySize {
+			return ErrorMaxKeySizeExceeded
+		}
+
+		key := make([]byte, ksize)
+		_, err = io.ReadFull(dr, key)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidDump, err)
+		}
+
+		vsize, err := readUint16()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidDump, err)
+		}
+		if vsize > t.maxValueSize {
+			return ErrorMaxValueSizeExceeded
+		}
+
+		value := make([]byte, vsize)
+		_, err = io.ReadFull(dr, value)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidDump, err)
+		}
+
+		hc, err := readUint64()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidDump, err)
+		}
+		if hc == 0 {
+			return fmt.Errorf("%w: key without updates", ErrInvalidDump)
+		}
+
+		var prevTs uint64
+
+		for i := uint64(0); i < hc; i++ {
+			ts, err := readUint64()
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidDump, err)
+			}
+			if ts <= prevTs || ts > snapTs {
+				return fmt.Errorf("%w: invalid timestamp", ErrInvalidDump)
+			}
+			prevTs = ts
+
+			// only the latest value of a key is kept by the tree
+			kvts = append(kvts, &KVT{K: key, V: value, T: ts})
+
+			if ts > batchTs {
+				batchTs = ts
+			}
+
+			if len(kvts) == loadBatchSize {
+				err = t.insert(kvts, batchTs, "Load")
+				if err != nil {
+					return err
+				}
+
+				kvts = nil
+			}
+		}
+	}
+
+	checksum := make([]byte, sha256.Size)
+
+	_, err = io.ReadFull(br, checksum)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDump, err)
+	}
+	if !bytes.Equal(checksum, digest.Sum(nil)) {
+		return fmt.Errorf("%w: checksum mismatch", ErrInvalidDump)
+	}
+
+	if len(kvts) > 0 {
+		err = t.insert(kvts, batchTs, "Load")
+		if err != nil {
+			return err
+		}
+	}
+
+	// the snapshot ts may be above the ts of every entry when it was increased with IncreaseTs
+	if t.root.ts() < snapTs {
+		root, err := t.root.setTs(snapTs)
+		if err != nil {
+			return err
+		}
+
+		t.root = root
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tbtree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotDumpAndLoad(t *testing.T) {
+	tree, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer tree.Close()
+
+	keyCount := 1_000
+
+	for i := 0; i < keyCount; i++ {
+		err = tree.Insert([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+		require.NoError(t, err)
+	}
+
+	// some keys get updated, a few of them more times than the history batch size
+	for u := 0; u < dumpHistoryBatchSize+10; u++ {
+		for i := 0; i < 5; i++ {
+			err = tree.Insert([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d-%d", i, u)))
+			require.NoError(t, err)
+		}
+	}
+
+	err = tree.IncreaseTs(tree.Ts() + 10)
+	require.NoError(t, err)
+
+	snap, err := tree.Snapshot()
+	require.NoError(t, err)
+	defer snap.Close()
+
+	var dump bytes.Buffer
+
+	err = snap.DumpTo(&dump)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+
+	t.Run("loaded tree matches the snapshot", func(t *testing.T) {
+		path := filepath.Join(dir, "loaded")
+
+		loaded, err := Load(bytes.NewReader(dump.Bytes()), path, DefaultOptions())
+		require.NoError(t, err)
+
+		checkTree := func(loaded *TBtree) {
+			for i := 0; i < keyCount; i++ {
+				key := []byte(fmt.Sprintf("key%d", i))
+
+				expectedValue, expectedTs, expectedHc, err := snap.Get(key)
+				require.NoError(t, err)
+
+				value, ts, hc, err := loaded.Get(key)
+				require.NoError(t, err)
+				require.Equal(t, expectedValue, value)
+				require.Equal(t, expectedTs, ts)
+				require.Equal(t, expectedHc, hc)
+
+				expectedTss, _, err := snap.History(key, 0, true, int(expectedHc))
+				require.NoError(t, err)
+
+				tss, _, err := loaded.History(key, 0, true, int(hc))
+				require.NoError(t, err)
+				require.Equal(t, expectedTss, tss)
+			}
+		}
+
+		checkTree(loaded)
+
+		err = loaded.Close()
+		require.NoError(t, err)
+
+		loaded, err = Open(path, DefaultOptions())
+		require.NoError(t, err)
+		defer loaded.Close()
+
+		checkTree(loaded)
+	})
+
+	t.Run("loading into an existing path should fail", func(t *testing.T) {
+		_, err := Load(bytes.NewReader(dump.Bytes()), dir, DefaultOptions())
+		require.ErrorIs(t, err, ErrTargetPathAlreadyExists)
+
+		_, err = Load(nil, filepath.Join(dir, "nil"), DefaultOptions())
+		require.ErrorIs(t, err, ErrIllegalArguments)
+	})
+
+	t.Run("invalid dumps should be rejected", func(t *testing.T) {
+		corrupted := make([]byte, dump.Len())
+		copy(corrupted, dump.Bytes())
+		corrupted[len(corrupted)/2]++
+
+		unknownVersion := make([]byte, dump.Len())
+		copy(unknownVersion, dump.Bytes())
+		binary.BigEndian.PutUint16(unknownVersion[len(dumpMagic):], DumpVersion+1)
+
+		for i, c := range []struct {
+			dump []byte
+			err  error
+		}{
+			{[]byte("unknown"), ErrInvalidDump},
+			{[]byte("UNKNOWNFORMAT"), ErrInvalidDump},
+			{dump.Bytes()[:dump.Len()/2], ErrInvalidDump},
+			{dump.Bytes()[:dump.Len()-1], ErrInvalidDump},
+			{corrupted, ErrInvalidDump},
+			{unknownVersion, ErrIncompatibleDataFormat},
+		} {
+			path := filepath.Join(dir, fmt.Sprintf("invalid%d", i))
+
+			_, err := Load(bytes.NewReader(c.dump), path, DefaultOptions())
+			require.ErrorIs(t, err, c.err)
+			require.NoDirExists(t, path)
+		}
+	})
+}
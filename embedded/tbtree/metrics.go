@@ -63,6 +63,11 @@ var metricsCompactedEntriesTotal = promauto.NewCounterVec(prometheus.CounterOpts
 	Help: "Number of btree entries written to disk during compaction since the immudb process was started",
 }, []string{"id"})
 
+var metricsCompactionThrottledSeconds = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "immudb_btree_compaction_throttled_seconds_total",
+	Help: "Time compaction has been paused to stay within the configured IO rate limit",
+}, []string{"id"})
+
 var metricsCacheSizeStats = promauto.NewGaugeVec(prometheus.GaugeOpts{
 	Name: "immudb_btree_cache_size",
 	Help: "number of entries in btree cache",
@@ -83,6 +88,11 @@ var metricsCacheEvict = promauto.NewCounterVec(prometheus.CounterOpts{
 	Help: "Number of btree nodes evicted from cache",
 }, []string{"id"})
 
+var metricsBloomFilterNegatives = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "immudb_btree_bloom_filter_negatives",
+	Help: "Number of lookups of absent keys resolved by bloom filters without reading leaf nodes",
+}, []string{"id"})
+
 var metricsBtreeDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
 	Name: "immudb_btree_depth",
 	Help: "Btree depth",
@@ -0,0 +1,253 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tbtree
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/codenotary/immudb/embedded/cache"
+)
+
+// nodeCache holds deserialized nodes keyed by their offset in the nodes log of a tree
+type nodeCache interface {
+	get(t *TBtree, off int64) (node, error)
+	put(t *TBtree, n node) (evicted int)
+	entriesCount() int
+	release(t *TBtree)
+}
+
+// lruNodeCache is the cache owned by a single tree, bounded by its number of nodes
+type lruNodeCache struct {
+	cache.Cache
+}
+
+func newLRUNodeCache(size int, shards int) (*lruNodeCache, error) {
+	c, err := cache.New(size, shards, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lruNodeCache{Cache: c}, nil
+}
+
+func (c *lruNodeCache) get(t *TBtree, off int64) (node, error) {
+	v, err := c.Get(off)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.(node), nil
+}
+
+func (c *lruNodeCache) put(t *TBtree, n node) int {
+	r, _, _ := c.Put(n.offset(), n)
+	if r != nil {
+		return 1
+	}
+
+	return 0
+}
+
+func (c *lruNodeCache) entriesCount() int {
+	return c.EntriesCount()
+}
+
+func (c *lruNodeCache) release(t *TBtree) {
+}
+
+// SharedCache is a cache of btree nodes bounded by the amount of bytes taken by them,
+// a single instance may be shared by multiple trees, e.g. the indexes of every database
+// in the same server, so the memory devoted to caching doesn't grow with the number of trees.
+// Nodes are weighted by their serialized size and evicted in LRU order regardless of the tree
+// they belong to. Nodes are spread over shards, each guarded by its own lock and given an equal
+// part of the size, thus with several shards nodes are evicted in LRU order only approximately
+type SharedCache struct {
+	maxSize int
+	shards  []*sharedCacheShard
+}
+
+type sharedCacheShard struct {
+	maxSize int
+	size    int
+
+	entries map[sharedCacheKey]*list.Element
+	lruList *list.List
+
+	mutex sync.Mutex
+}
+
+type sharedCacheKey struct {
+	t   *TBtree
+	off int64
+}
+
+type sharedCacheEntry struct {
+	key  sharedCacheKey
+	n    node
+	size int
+}
+
+// NewSharedCache creates a node cache holding up to maxSize bytes split into shards,
+// the number of shards is lowered if needed so that every shard holds at least 1 byte
+func NewSharedCache(maxSize int, shards int) (*SharedCache, error) {
+	if maxSize < 1 || shards < 1 {
+		return nil, ErrIllegalArguments
+	}
+
+	if shards > maxSize {
+		shards = maxSize
+	}
+
+	c := &SharedCache{
+		maxSize: maxSize,
+		shards:  make([]*sharedCacheShard, shards),
+	}
+
+	for i := range c.shards {
+		shardSize := maxSize / shards
+		if i < maxSize%shards {
+			shardSize++
+		}
+
+		c.shards[i] = &sharedCacheShard{
+			maxSize: shardSize,
+			entries: make(map[sharedCacheKey]*list.Element),
+			lruList: list.New(),
+		}
+	}
+
+	return c, nil
+}
+
+// shard returns the shard holding the node at offset off,
+// offsets are mixed so that aligned ones are spread evenly
+func (c *SharedCache) shard(off int64) *sharedCacheShard {
+	x := uint64(off)
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+
+	return c.shards[x%uint64(len(c.shards))]
+}
+
+// Size returns the amount of bytes taken by the cached nodes
+func (c *SharedCache) Size() int {
+	size := 0
+
+	for _, shard := range c.shards {
+		shard.mutex.Lock()
+		size += shard.size
+		shard.mutex.Unlock()
+	}
+
+	return size
+}
+
+// MaxSize returns the amount of bytes the cache may hold
+func (c *SharedCache) MaxSize() int {
+	return c.maxSize
+}
+
+func (c *SharedCache) get(t *TBtree, off int64) (node, error) {
+	shard := c.shard(off)
+
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	e, ok := shard.entries[sharedCacheKey{t: t, off: off}]
+	if !ok {
+		return nil, cache.ErrKeyNotFound
+	}
+
+	shard.lruList.MoveToBack(e)
+
+	return e.Value.(*sharedCacheEntry).n, nil
+}
+
+func (c *SharedCache) put(t *TBtree, n node) (evicted int) {
+	size, err := n.size()
+	if err != nil {
+		size = t.maxNodeSize
+	}
+
+	shard := c.shard(n.offset())
+
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	key := sharedCacheKey{t: t, off: n.offset()}
+
+	e, ok := shard.entries[key]
+	if ok {
+		entry := e.Value.(*sharedCacheEntry)
+
+		shard.size += size - entry.size
+
+		entry.n = n
+		entry.size = size
+
+		shard.lruList.MoveToBack(e)
+	} else {
+		shard.entries[key] = shard.lruList.PushBack(&sharedCacheEntry{key: key, n: n, size: size})
+		shard.size += size
+	}
+
+	// the most recently added node is kept even if it doesn't fit on its own
+	for shard.size > shard.maxSize && shard.lruList.Len() > 1 {
+		shard.remove(shard.lruList.Front())
+		evicted++
+	}
+
+	return evicted
+}
+
+func (shard *sharedCacheShard) remove(e *list.Element) {
+	entry := e.Value.(*sharedCacheEntry)
+
+	delete(shard.entries, entry.key)
+	shard.lruList.Remove(e)
+
+	shard.size -= entry.size
+}
+
+func (c *SharedCache) entriesCount() int {
+	count := 0
+
+	for _, shard := range c.shards {
+		shard.mutex.Lock()
+		count += shard.lruList.Len()
+		shard.mutex.Unlock()
+	}
+
+	return count
+}
+
+// release removes the nodes of a tree being closed, shards are locked one at a time
+func (c *SharedCache) release(t *TBtree) {
+	for _, shard := range c.shards {
+		shard.mutex.Lock()
+
+		for key, e := range shard.entries {
+			if key.t == t {
+				shard.remove(e)
+			}
+		}
+
+		shard.mutex.Unlock()
+	}
+}
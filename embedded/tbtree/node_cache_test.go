@@ -0,0 +1,221 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tbtree
+
+import (
+	"encoding/binary"
+	"sync"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/cache"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedCache(t *testing.T) {
+	_, err := NewSharedCache(0, 1)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = NewSharedCache(100, 0)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	c, err := NewSharedCache(100, 1)
+	require.NoError(t, err)
+	require.Equal(t, 100, c.MaxSize())
+
+	tree := &TBtree{maxNodeSize: DefaultMaxNodeSize}
+
+	leafOfSize := func(off int64, size int) *leafNode {
+		// an empty leaf takes 3 bytes and each entry with an empty value 28 bytes plus the key
+		return &leafNode{
+			t:      tree,
+			off:    off,
+			values: []*leafValue{{key: make([]byte, size-3-28)}},
+		}
+	}
+
+	require.Zero(t, c.put(tree, leafOfSize(0, 40)))
+	require.Zero(t, c.put(tree, leafOfSize(1, 40)))
+	require.Equal(t, 80, c.Size())
+
+	// node 0 is the least recently used one after reading node 1
+	_, err = c.get(tree, 1)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, c.put(tree, leafOfSize(2, 40)))
+	require.Equal(t, 80, c.Size())
+	require.Equal(t, 2, c.entriesCount())
+
+	_, err = c.get(tree, 0)
+	require.ErrorIs(t, err, cache.ErrKeyNotFound)
+
+	// replacing a node updates its weight
+	require.Equal(t, 0, c.put(tree, leafOfSize(2, 50)))
+	require.Equal(t, 90, c.Size())
+
+	// a node bigger than the cache evicts every other one but it's still cached
+	require.Equal(t, 2, c.put(tree, leafOfSize(3, 200)))
+	require.Equal(t, 200, c.Size())
+
+	n, err := c.get(tree, 3)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), n.offset())
+
+	otherTree := &TBtree{maxNodeSize: DefaultMaxNodeSize}
+
+	// nodes of different trees at the same offset don't collide
+	require.Equal(t, 1, c.put(otherTree, leafOfSize(3, 40)))
+
+	_, err = c.get(tree, 3)
+	require.ErrorIs(t, err, cache.ErrKeyNotFound)
+
+	c.release(otherTree)
+	require.Zero(t, c.Size())
+	require.Zero(t, c.entriesCount())
+}
+
+func TestShardedSharedCache(t *testing.T) {
+	c, err := NewSharedCache(3, 10)
+	require.NoError(t, err)
+	require.Len(t, c.shards, 3)
+
+	c, err = NewSharedCache(1000, 4)
+	require.NoError(t, err)
+	require.Len(t, c.shards, 4)
+	require.Equal(t, 1000, c.MaxSize())
+
+	tree := &TBtree{maxNodeSize: DefaultMaxNodeSize}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for off := int64(i * 100); off < int64(i*100+100); off++ {
+				c.put(tree, &leafNode{t: tree, off: off})
+
+				n, err := c.get(tree, off)
+				if err == nil {
+					require.Equal(t, off, n.offset())
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	// every shard is bounded by its own part of the size
+	for _, shard := range c.shards {
+		require.LessOrEqual(t, shard.size, 250)
+	}
+	require.LessOrEqual(t, c.Size(), c.MaxSize())
+	require.Greater(t, c.entriesCount(), 0)
+
+	c.release(tree)
+	require.Zero(t, c.Size())
+	require.Zero(t, c.entriesCount())
+}
+
+func TestTBTreeWithSharedCache(t *testing.T) {
+	c, err := NewSharedCache(1<<20, DefaultSharedCacheShards)
+	require.NoError(t, err)
+
+	opts := DefaultOptions().WithSharedCache(c)
+
+	trees := make([]*TBtree, 5)
+
+	for i := range trees {
+		trees[i], err = Open(t.TempDir(), opts)
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < 10_000; i++ {
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], uint64(i))
+
+		for _, tree := range trees {
+			err = tree.Insert(k[:], k[:])
+			require.NoError(t, err)
+		}
+	}
+
+	for _, tree := range trees {
+		_, _, err = tree.Flush()
+		require.NoError(t, err)
+
+		require.Same(t, c, tree.GetOptions().sharedCache)
+	}
+
+	require.LessOrEqual(t, c.Size(), c.MaxSize())
+	require.Greater(t, c.entriesCount(), 0)
+
+	for i := 0; i < 10_000; i++ {
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], uint64(i))
+
+		for _, tree := range trees {
+			v, _, _, err := tree.Get(k[:])
+			require.NoError(t, err)
+			require.Equal(t, k[:], v)
+		}
+	}
+
+	require.LessOrEqual(t, c.Size(), c.MaxSize())
+
+	for _, tree := range trees {
+		err = tree.Close()
+		require.NoError(t, err)
+	}
+
+	require.Zero(t, c.Size())
+	require.Zero(t, c.entriesCount())
+}
+
+func TestTBTreeWithShardedCache(t *testing.T) {
+	tree, err := Open(t.TempDir(), DefaultOptions().WithCacheSize(100).WithCacheShards(4))
+	require.NoError(t, err)
+
+	require.IsType(t, &cache.ShardedCache{}, tree.cache.(*lruNodeCache).Cache)
+	require.Equal(t, 4, tree.GetOptions().cacheShards)
+
+	for i := 0; i < 10_000; i++ {
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], uint64(i))
+
+		err = tree.Insert(k[:], k[:])
+		require.NoError(t, err)
+	}
+
+	_, _, err = tree.Flush()
+	require.NoError(t, err)
+
+	require.LessOrEqual(t, tree.cache.entriesCount(), 100)
+
+	for i := 0; i < 10_000; i++ {
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], uint64(i))
+
+		v, _, _, err := tree.Get(k[:])
+		require.NoError(t, err)
+		require.Equal(t, k[:], v)
+	}
+
+	err = tree.Close()
+	require.NoError(t, err)
+}
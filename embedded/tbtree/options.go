@@ -34,6 +34,8 @@ const DefaultCleanUpPercentage float32 = 0
 const DefaultMaxActiveSnapshots = 100
 const DefaultRenewSnapRootAfter = time.Duration(1000) * time.Millisecond
 const DefaultCacheSize = 100_000
+const DefaultCacheShards = 1
+const DefaultSharedCacheShards = 16
 const DefaultFileMode = os.FileMode(0755)
 const DefaultFileSize = 1 << 26 // 64Mb
 const DefaultMaxKeySize = 1024
@@ -63,9 +65,16 @@ type Options struct {
 	maxActiveSnapshots int
 	renewSnapRootAfter time.Duration
 	cacheSize          int
+	cacheShards        int          // the node cache is split into shards with their own lock when greater than 1
+	sharedCache        *SharedCache // when set, nodes are cached in it instead of a cache of cacheSize nodes
 	readOnly           bool
 	fileMode           os.FileMode
 
+	encryptionKeyID string
+	keyProvider     appendable.KeyProvider
+
+	mmap bool // if mmap is enabled, log chunks no longer written are memory-mapped
+
 	nodesLogMaxOpenedFiles   int
 	historyLogMaxOpenedFiles int
 	commitLogMaxOpenedFiles  int
@@ -73,6 +82,15 @@ type Options struct {
 	compactionThld        int
 	delayDuringCompaction time.Duration
 
+	// bytes per second written while dumping the index during compaction, zero means unlimited
+	compactionRateLimit int
+
+	// leaf nodes are summarized with a bloom filter of this many bits per key, zero disables them
+	bloomFilterBitsPerKey int
+
+	// keys in leaf nodes are front-coded against their predecessor when it reduces node size
+	prefixCompression bool
+
 	// options below are only set during initialization and stored as metadata
 	maxNodeSize  int
 	maxKeySize   int
@@ -92,6 +110,7 @@ func DefaultOptions() *Options {
 		maxActiveSnapshots:    DefaultMaxActiveSnapshots,
 		renewSnapRootAfter:    DefaultRenewSnapRootAfter,
 		cacheSize:             DefaultCacheSize,
+		cacheShards:           DefaultCacheShards,
 		readOnly:              false,
 		fileMode:              DefaultFileMode,
 		compactionThld:        DefaultCompactionThld,
@@ -174,14 +193,30 @@ func (opts *Options) Validate() error {
 		return fmt.Errorf("%w: invalid CacheSize", ErrInvalidOptions)
 	}
 
+	if opts.cacheShards < 1 {
+		return fmt.Errorf("%w: invalid CacheShards", ErrInvalidOptions)
+	}
+
 	if opts.compactionThld <= 0 {
 		return fmt.Errorf("%w: invalid CompactionThld", ErrInvalidOptions)
 	}
 
+	if opts.compactionRateLimit < 0 {
+		return fmt.Errorf("%w: invalid CompactionRateLimit", ErrInvalidOptions)
+	}
+
+	if opts.bloomFilterBitsPerKey < 0 || opts.bloomFilterBitsPerKey > MaxBloomFilterBitsPerKey {
+		return fmt.Errorf("%w: invalid BloomFilterBitsPerKey", ErrInvalidOptions)
+	}
+
 	if opts.logger == nil {
 		return fmt.Errorf("%w: invalid Logger", ErrInvalidOptions)
 	}
 
+	if opts.encryptionKeyID != "" && opts.keyProvider == nil {
+		return fmt.Errorf("%w: invalid KeyProvider", ErrInvalidOptions)
+	}
+
 	return nil
 }
 
@@ -230,6 +265,20 @@ func (opts *Options) WithCacheSize(cacheSize int) *Options {
 	return opts
 }
 
+// WithCacheShards splits the node cache into shards, each guarded by its own lock,
+// to reduce contention when the tree is read concurrently
+func (opts *Options) WithCacheShards(cacheShards int) *Options {
+	opts.cacheShards = cacheShards
+	return opts
+}
+
+// WithSharedCache makes the tree cache its nodes in a cache bounded by bytes which may be
+// shared with other trees, cacheSize is ignored when a shared cache is set
+func (opts *Options) WithSharedCache(c *SharedCache) *Options {
+	opts.sharedCache = c
+	return opts
+}
+
 func (opts *Options) WithReadOnly(readOnly bool) *Options {
 	opts.readOnly = readOnly
 	return opts
@@ -240,6 +289,18 @@ func (opts *Options) WithFileMode(fileMode os.FileMode) *Options {
 	return opts
 }
 
+func (opts *Options) WithEncryption(keyID string, keyProvider appendable.KeyProvider) *Options {
+	opts.encryptionKeyID = keyID
+	opts.keyProvider = keyProvider
+	return opts
+}
+
+// WithMmap makes reads of log chunks which are no longer written be served from a memory mapping
+func (opts *Options) WithMmap(mmap bool) *Options {
+	opts.mmap = mmap
+	return opts
+}
+
 func (opts *Options) WithNodesLogMaxOpenedFiles(nodesLogMaxOpenedFiles int) *Options {
 	opts.nodesLogMaxOpenedFiles = nodesLogMaxOpenedFiles
 	return opts
@@ -265,6 +326,24 @@ func (opts *Options) WithMaxValueSize(maxValueSize int) *Options {
 	return opts
 }
 
+// WithBloomFilterBitsPerKey enables bloom filters on leaf nodes so lookups of absent keys
+// don't need to read them. Filters are added as leaf nodes get written, thus indexes
+// created with them enabled can not be opened by releases without bloom filter support.
+// The option has no effect on indexes created without it
+func (opts *Options) WithBloomFilterBitsPerKey(bitsPerKey int) *Options {
+	opts.bloomFilterBitsPerKey = bitsPerKey
+	return opts
+}
+
+// WithPrefixCompression enables front-coding of keys within leaf nodes, so keys sharing
+// long prefixes take less space in the nodes log and more of them fit in a single node.
+// Indexes created with it enabled can not be opened by releases without its support.
+// The option has no effect on indexes created without it
+func (opts *Options) WithPrefixCompression(enabled bool) *Options {
+	opts.prefixCompression = enabled
+	return opts
+}
+
 func (opts *Options) WithMaxNodeSize(maxNodeSize int) *Options {
 	opts.maxNodeSize = maxNodeSize
 	return opts
@@ -284,3 +363,10 @@ func (opts *Options) WithDelayDuringCompaction(delay time.Duration) *Options {
 	opts.delayDuringCompaction = delay
 	return opts
 }
+
+// WithCompactionRateLimit bounds the amount of bytes per second written while the index is
+// being dumped during compaction, so regular flushes don't compete with it for IO bandwidth
+func (opts *Options) WithCompactionRateLimit(bytesPerSecond int) *Options {
+	opts.compactionRateLimit = bytesPerSecond
+	return opts
+}
@@ -43,6 +43,7 @@ func TestInvalidOptions(t *testing.T) {
 		{"MaxActiveSnapshots", DefaultOptions().WithMaxActiveSnapshots(0)},
 		{"RenewSnapRootAfter", DefaultOptions().WithRenewSnapRootAfter(-1)},
 		{"CacheSize", DefaultOptions().WithCacheSize(0)},
+		{"CacheShards", DefaultOptions().WithCacheShards(0)},
 		{"CompactionThld", DefaultOptions().WithCompactionThld(-1)},
 		{"MaxKeySize", DefaultOptions().WithMaxKeySize(0)},
 		{"MaxValueSize", DefaultOptions().WithMaxValueSize(0)},
@@ -50,6 +51,10 @@ func TestInvalidOptions(t *testing.T) {
 		{"NodesLogMaxOpenedFiles", DefaultOptions().WithNodesLogMaxOpenedFiles(0)},
 		{"HistoryLogMaxOpenedFiles", DefaultOptions().WithHistoryLogMaxOpenedFiles(0)},
 		{"CommitLogMaxOpenedFiles", DefaultOptions().WithCommitLogMaxOpenedFiles(0)},
+		{"KeyProvider", DefaultOptions().WithEncryption("key1", nil)},
+		{"CompactionRateLimit", DefaultOptions().WithCompactionRateLimit(-1)},
+		{"BloomFilterBitsPerKey<0", DefaultOptions().WithBloomFilterBitsPerKey(-1)},
+		{"BloomFilterBitsPerKey>Max", DefaultOptions().WithBloomFilterBitsPerKey(MaxBloomFilterBitsPerKey + 1)},
 	} {
 		t.Run(d.n, func(t *testing.T) {
 			require.ErrorIs(t, d.opts.Validate(), ErrInvalidOptions)
@@ -65,6 +70,7 @@ func TestValidOptions(t *testing.T) {
 	opts := &Options{}
 
 	require.Equal(t, DefaultCacheSize, opts.WithCacheSize(DefaultCacheSize).cacheSize)
+	require.Equal(t, 8, opts.WithCacheShards(8).cacheShards)
 	require.Equal(t, DefaultFileMode, opts.WithFileMode(DefaultFileMode).fileMode)
 	require.Equal(t, DefaultFileSize, opts.WithFileSize(DefaultFileSize).fileSize)
 	require.Equal(t, DefaultFlushThld, opts.WithFlushThld(DefaultFlushThld).flushThld)
@@ -87,6 +93,8 @@ func TestValidOptions(t *testing.T) {
 	require.Equal(t, 2, opts.WithNodesLogMaxOpenedFiles(2).nodesLogMaxOpenedFiles)
 	require.Equal(t, 3, opts.WithHistoryLogMaxOpenedFiles(3).historyLogMaxOpenedFiles)
 	require.Equal(t, 1, opts.WithCommitLogMaxOpenedFiles(1).commitLogMaxOpenedFiles)
+	require.True(t, opts.WithPrefixCompression(true).prefixCompression)
+	require.Equal(t, 1<<20, opts.WithCompactionRateLimit(1<<20).compactionRateLimit)
 
 	require.NoError(t, opts.Validate())
 
@@ -104,6 +112,10 @@ func TestValidOptions(t *testing.T) {
 	require.NotNil(t, opts.WithAppFactory(appFactory).appFactory)
 	require.NoError(t, opts.Validate())
 
+	require.Equal(t, "key1", opts.WithEncryption("key1", nil).encryptionKeyID)
+	require.ErrorIs(t, opts.Validate(), ErrInvalidOptions)
+	require.Equal(t, "", opts.WithEncryption("", nil).encryptionKeyID)
+
 	opts.appFactory("", "", nil)
 	require.True(t, appFactoryCalled)
 
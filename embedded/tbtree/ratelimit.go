@@ -0,0 +1,69 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tbtree
+
+import (
+	"io"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// rateLimitedWriter bounds the amount of bytes per second written into the underlying writer,
+// it's used when dumping the index during compaction so to not compete in IO with regular flushes
+type rateLimitedWriter struct {
+	w io.Writer
+
+	rate    int64 // bytes per second
+	start   time.Time
+	written int64
+
+	throttled prometheus.Counter
+
+	sleep func(time.Duration)
+}
+
+func newRateLimitedWriter(w io.Writer, rate int, throttled prometheus.Counter) *rateLimitedWriter {
+	return &rateLimitedWriter{
+		w:         w,
+		rate:      int64(rate),
+		start:     time.Now(),
+		throttled: throttled,
+		sleep:     time.Sleep,
+	}
+}
+
+func (rw *rateLimitedWriter) Write(b []byte) (int, error) {
+	n, err := rw.w.Write(b)
+	if err != nil {
+		return n, err
+	}
+
+	rw.written += int64(n)
+
+	expected := time.Duration(float64(rw.written) / float64(rw.rate) * float64(time.Second))
+
+	elapsed := time.Since(rw.start)
+	if expected > elapsed {
+		delay := expected - elapsed
+
+		rw.throttled.Add(delay.Seconds())
+		rw.sleep(delay)
+	}
+
+	return n, nil
+}
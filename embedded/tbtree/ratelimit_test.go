@@ -0,0 +1,118 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tbtree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type failingWriter struct{}
+
+func (w *failingWriter) Write(b []byte) (int, error) {
+	return 0, errors.New("write error")
+}
+
+func TestRateLimitedWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	throttled := metricsCompactionThrottledSeconds.WithLabelValues(t.Name())
+
+	rw := newRateLimitedWriter(&buf, 1000, throttled)
+
+	var slept time.Duration
+	rw.sleep = func(d time.Duration) {
+		// time passing is simulated by moving the start backwards
+		rw.start = rw.start.Add(-d)
+		slept += d
+	}
+
+	for i := 0; i < 10; i++ {
+		n, err := rw.Write(make([]byte, 100))
+		require.NoError(t, err)
+		require.Equal(t, 100, n)
+	}
+
+	require.Equal(t, 1000, buf.Len())
+
+	// writing 1000 bytes at 1000 bytes per second takes about one second
+	require.Greater(t, slept, 900*time.Millisecond)
+	require.LessOrEqual(t, slept, time.Second)
+	require.Greater(t, testutil.ToFloat64(throttled), 0.9)
+
+	_, err := newRateLimitedWriter(&failingWriter{}, 1000, throttled).Write([]byte{1})
+	require.Error(t, err)
+}
+
+func TestCompactionWithRateLimit(t *testing.T) {
+	dir := t.TempDir()
+
+	tree, err := Open(dir, DefaultOptions().WithCompactionThld(1))
+	require.NoError(t, err)
+	defer tree.Close()
+
+	for i := 0; i < 10_000; i++ {
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], uint64(i))
+
+		err = tree.Insert(k[:], k[:])
+		require.NoError(t, err)
+	}
+
+	_, _, err = tree.Flush()
+	require.NoError(t, err)
+
+	size, err := tree.nLog.Size()
+	require.NoError(t, err)
+
+	// dumping the index is expected to take about half a second
+	tree.compactionRateLimit = int(size * 2)
+
+	compacted := make(chan error)
+
+	start := time.Now()
+
+	go func() {
+		_, err := tree.Compact()
+		compacted <- err
+	}()
+
+	// insertions are not blocked by an ongoing compaction
+	for i := 10_000; i < 10_100; i++ {
+		var k [8]byte
+		binary.BigEndian.PutUint64(k[:], uint64(i))
+
+		err = tree.Insert(k[:], k[:])
+		require.NoError(t, err)
+	}
+
+	insertionsDone := time.Since(start)
+
+	require.NoError(t, <-compacted)
+
+	compactionDone := time.Since(start)
+
+	require.Greater(t, compactionDone, 400*time.Millisecond)
+	require.Less(t, insertionsDone, compactionDone)
+	require.Greater(t, testutil.ToFloat64(metricsCompactionThrottledSeconds.WithLabelValues(dir)), float64(0))
+}
@@ -545,3 +545,54 @@ func TestFullScanDescendingOrder(t *testing.T) {
 	}
 	require.Equal(t, keyCount, i)
 }
+
+func TestReverseReaderWithPrefix(t *testing.T) {
+	tbtree, err := Open(t.TempDir(), DefaultOptions())
+	require.NoError(t, err)
+	defer tbtree.Close()
+
+	for _, group := range []string{"a", "b", "c"} {
+		for i := 0; i < 100; i++ {
+			err = tbtree.Insert([]byte(group+"/"+string([]byte{byte(i)})), []byte(group))
+			require.NoError(t, err)
+		}
+	}
+
+	// keys right after the "b/" prefix must not be returned
+	err = tbtree.Insert([]byte("b0"), []byte("b0"))
+	require.NoError(t, err)
+
+	snapshot, err := tbtree.Snapshot()
+	require.NoError(t, err)
+	defer snapshot.Close()
+
+	r, err := snapshot.NewReverseReader([]byte("b/"))
+	require.NoError(t, err)
+	defer r.Close()
+
+	for i := 99; i >= 0; i-- {
+		k, v, _, _, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, []byte("b/"+string([]byte{byte(i)})), k)
+		require.Equal(t, []byte("b"), v)
+	}
+
+	_, _, _, _, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreEntries)
+
+	k, v, _, hc, err := snapshot.GetLastWithPrefix([]byte("c/"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("c/"+string([]byte{99})), k)
+	require.Equal(t, []byte("c"), v)
+	require.Equal(t, uint64(1), hc)
+
+	k, _, _, _, err = snapshot.GetLastWithPrefix(nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte("c/"+string([]byte{99})), k)
+
+	_, _, _, _, err = snapshot.GetLastWithPrefix([]byte("d/"))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	_, _, _, _, err = snapshot.GetLastWithPrefix(make([]byte, tbtree.maxKeySize+1))
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
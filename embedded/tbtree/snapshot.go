@@ -19,6 +19,7 @@ package tbtree
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"io"
 	"math"
 	"sync"
@@ -27,6 +28,8 @@ import (
 const (
 	InnerNodeType = iota
 	LeafNodeType
+	InnerNodeWithFiltersType // inner node holding a bloom filter for each leaf child
+	LeafNodeWithPrefixesType // leaf node with keys front-coded against the preceding one
 )
 
 type Snapshot struct {
@@ -141,6 +144,34 @@ func (s *Snapshot) GetWithPrefix(prefix []byte, neq []byte) (key []byte, value [
 	return leafValue.key, cp(leafValue.value), leafValue.ts, leafValue.hCount + uint64(len(leafValue.tss)), nil
 }
 
+// GetLastWithPrefix returns the greatest key starting with the given prefix
+func (s *Snapshot) GetLastWithPrefix(prefix []byte) (key []byte, value []byte, ts uint64, hc uint64, err error) {
+	r, err := s.NewReverseReader(prefix)
+	if err != nil {
+		return nil, nil, 0, 0, err
+	}
+	defer r.Close()
+
+	key, value, ts, hc, err = r.Read()
+	if errors.Is(err, ErrNoMoreEntries) {
+		return nil, nil, 0, 0, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, nil, 0, 0, err
+	}
+
+	return key, value, ts, hc, nil
+}
+
+// NewReverseReader returns a reader of the keys starting with the given prefix in descending order,
+// thus positioned at the greatest key under the prefix
+func (s *Snapshot) NewReverseReader(prefix []byte) (*Reader, error) {
+	return s.NewReader(ReaderSpec{
+		Prefix:    prefix,
+		DescOrder: true,
+	})
+}
+
 func (s *Snapshot) NewHistoryReader(spec *HistoryReaderSpec) (*HistoryReader, error) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
@@ -310,17 +341,32 @@ func (n *innerNode) writeTo(nw, hw io.Writer, writeOpts *WriteOpts, buf []byte)
 		return 0, 0, cnw, chw, err
 	}
 
+	withFilters := n.t.bloomFilterBitsPerKey > 0
+
+	var filters []*bloomFilter
+	if withFilters {
+		filters = make([]*bloomFilter, len(n.nodes))
+	}
+
 	bi := 0
 
-	buf[bi] = InnerNodeType
+	if withFilters {
+		buf[bi] = InnerNodeWithFiltersType
+	} else {
+		buf[bi] = InnerNodeType
+	}
 	bi++
 
 	binary.BigEndian.PutUint16(buf[bi:], uint16(len(n.nodes)))
 	bi += 2
 
 	for i, c := range n.nodes {
-		n := writeNodeRefToWithOffset(c, offsets[i], minOffsets[i], buf[bi:])
-		bi += n
+		bi += writeNodeRefToWithOffset(c, offsets[i], minOffsets[i], buf[bi:])
+
+		if withFilters {
+			filters[i] = n.t.bloomFilterOf(c)
+			bi += writeBloomFilterTo(filters[i], buf[bi:])
+		}
 	}
 
 	wn, err := nw.Write(buf[:bi])
@@ -345,7 +391,7 @@ func (n *innerNode) writeTo(nw, hw io.Writer, writeOpts *WriteOpts, buf []byte)
 					continue
 				}
 
-				n.nodes[i] = &nodeRef{
+				ref := &nodeRef{
 					t:       n.t,
 					_minKey: c.minKey(),
 					_ts:     c.ts(),
@@ -353,6 +399,12 @@ func (n *innerNode) writeTo(nw, hw io.Writer, writeOpts *WriteOpts, buf []byte)
 					_minOff: c.minOffset(),
 				}
 
+				if withFilters {
+					ref._filter = filters[i]
+				}
+
+				n.nodes[i] = ref
+
 				n.t.cachePut(c)
 			}
 		}
@@ -368,14 +420,15 @@ func (l *leafNode) writeTo(nw, hw io.Writer, writeOpts *WriteOpts, buf []byte) (
 		return l.off, l.off, 0, 0, nil
 	}
 
-	size, err := l.size()
-	if err != nil {
-		return 0, 0, 0, 0, err
-	}
+	withPrefixes, size := l.encoding()
 
 	bi := 0
 
-	buf[bi] = LeafNodeType
+	if withPrefixes {
+		buf[bi] = LeafNodeWithPrefixesType
+	} else {
+		buf[bi] = LeafNodeType
+	}
 	bi++
 
 	binary.BigEndian.PutUint16(buf[bi:], uint16(len(l.values)))
@@ -383,12 +436,27 @@ func (l *leafNode) writeTo(nw, hw io.Writer, writeOpts *WriteOpts, buf []byte) (
 
 	accH := int64(0)
 
+	var prevKey []byte
+
 	for _, v := range l.values {
-		binary.BigEndian.PutUint16(buf[bi:], uint16(len(v.key)))
+		suffix := v.key
+
+		if withPrefixes {
+			shared := commonPrefixLen(prevKey, v.key)
+
+			binary.BigEndian.PutUint16(buf[bi:], uint16(shared))
+			bi += 2
+
+			suffix = v.key[shared:]
+		}
+
+		binary.BigEndian.PutUint16(buf[bi:], uint16(len(suffix)))
 		bi += 2
 
-		copy(buf[bi:], v.key)
-		bi += len(v.key)
+		copy(buf[bi:], suffix)
+		bi += len(suffix)
+
+		prevKey = v.key
 
 		binary.BigEndian.PutUint16(buf[bi:], uint16(len(v.value)))
 		bi += 2
@@ -485,6 +553,26 @@ func (n *nodeRef) writeTo(nw, hw io.Writer, writeOpts *WriteOpts, buf []byte) (n
 	return off, mOff, wn, wh, nil
 }
 
+func writeBloomFilterTo(f *bloomFilter, buf []byte) int {
+	if f == nil {
+		binary.BigEndian.PutUint16(buf, 0)
+		return 2
+	}
+
+	i := 0
+
+	binary.BigEndian.PutUint16(buf[i:], uint16(len(f.bits)))
+	i += 2
+
+	buf[i] = f.k
+	i++
+
+	copy(buf[i:], f.bits)
+	i += len(f.bits)
+
+	return i
+}
+
 func writeNodeRefToWithOffset(n node, offset, minOff int64, buf []byte) int {
 	i := 0
 
@@ -62,7 +62,35 @@ var ErrTargetPathAlreadyExists = errors.New("tbtree: target folder already exist
 var ErrNoMoreEntries = fmt.Errorf("tbtree: %w", embedded.ErrNoMoreEntries)
 var ErrReadersNotClosed = errors.New("tbtree: readers not closed")
 
-const Version = 3
+// Version is the latest version of the index data format which can be read
+const Version = 5
+
+// MinCompatibleVersion is the oldest version of the index data format which can still be read
+const MinCompatibleVersion = 3
+
+// PrefixCompressionVersion is the version of the index data format required to store
+// leaf nodes with front-coded keys
+const PrefixCompressionVersion = 4
+
+// BloomFilterVersion is the version of the index data format required to store
+// inner nodes holding bloom filters of their leaf children
+const BloomFilterVersion = 5
+
+// RequiredVersion returns the oldest version of the index data format supporting the
+// enabled features, indexes are created with it so they stay readable by older releases
+func RequiredVersion(opts *Options) int {
+	version := MinCompatibleVersion
+
+	if opts.prefixCompression {
+		version = PrefixCompressionVersion
+	}
+
+	if opts.bloomFilterBitsPerKey > 0 {
+		version = BloomFilterVersion
+	}
+
+	return version
+}
 
 const (
 	MetaVersion      = "VERSION"
@@ -169,7 +197,7 @@ type TBtree struct {
 	logger logger.Logger
 
 	nLog   appendable.Appendable
-	cache  *cache.LRUCache
+	cache  nodeCache
 	nmutex sync.Mutex // mutex for cache and file reading
 
 	hLog appendable.Appendable
@@ -190,15 +218,23 @@ type TBtree struct {
 	renewSnapRootAfter         time.Duration
 	readOnly                   bool
 	cacheSize                  int
+	cacheShards                int
+	sharedCache                *SharedCache
 	fileSize                   int
 	fileMode                   os.FileMode
+	encryptionKeyID            string
+	keyProvider                appendable.KeyProvider
+	mmap                       bool
 	maxKeySize                 int
 	maxValueSize               int
 	compactionThld             int
 	delayDuringCompaction      time.Duration
+	compactionRateLimit        int
 	nodesLogMaxOpenedFiles     int
 	historyLogMaxOpenedFiles   int
 	commitLogMaxOpenedFiles    int
+	bloomFilterBitsPerKey      int
+	prefixCompression          bool
 
 	snapshots      map[uint64]*Snapshot
 	maxSnapshotID  uint64
@@ -212,10 +248,24 @@ type TBtree struct {
 
 	compacting bool
 
+	// progress is guarded by its own mutex as flushes and compactions hold rwmutex
+	progress      WriteProgress
+	progressMutex sync.Mutex
+
 	closed  bool
 	rwmutex sync.RWMutex
 }
 
+// WriteProgress reports the number of entries written by the ongoing
+// or the last flush and compaction of the tree
+type WriteProgress struct {
+	Flushing       bool
+	FlushedEntries int
+
+	Compacting       bool
+	CompactedEntries int
+}
+
 type path []*pathNode
 
 type pathNode struct {
@@ -273,6 +323,7 @@ type nodeRef struct {
 	_ts     uint64
 	off     int64
 	_minOff int64
+	_filter *bloomFilter // only set for leaf nodes written with bloom filters enabled
 }
 
 type leafValue struct {
@@ -304,7 +355,7 @@ func Open(path string, opts *Options) (*TBtree, error) {
 	}
 
 	metadata := appendable.NewMetadata(nil)
-	metadata.PutInt(MetaVersion, Version)
+	metadata.PutInt(MetaVersion, RequiredVersion(opts))
 	metadata.PutInt(MetaMaxNodeSize, opts.maxNodeSize)
 	metadata.PutInt(MetaMaxKeySize, opts.maxKeySize)
 	metadata.PutInt(MetaMaxValueSize, opts.maxValueSize)
@@ -315,6 +366,8 @@ func Open(path string, opts *Options) (*TBtree, error) {
 		WithFileSize(opts.fileSize).
 		WithFileMode(opts.fileMode).
 		WithWriteBufferSize(opts.flushBufferSize).
+		WithEncryption(opts.encryptionKeyID, opts.keyProvider).
+		WithMmap(opts.mmap).
 		WithMetadata(metadata.Bytes())
 
 	appFactory := opts.appFactory
@@ -506,9 +559,25 @@ func OpenWith(path string, nLog, hLog, cLog appendable.Appendable, opts *Options
 	if !ok {
 		return nil, ErrCorruptedCLog
 	}
-	if version < Version {
+	if version < MinCompatibleVersion {
 		return nil, fmt.Errorf("%w: index data was generated using older and incompatible version", ErrIncompatibleDataFormat)
 	}
+	if version > Version {
+		return nil, fmt.Errorf("%w: index data was generated using newer and incompatible version", ErrIncompatibleDataFormat)
+	}
+
+	// the version is set when the index is created, features requiring
+	// a more recent one are not used so older releases can still read it
+	prefixCompression := opts.prefixCompression && version >= PrefixCompressionVersion
+	if opts.prefixCompression && !prefixCompression {
+		opts.logger.Warningf("Prefix compression disabled for index '%s', it was created using version %d", path, version)
+	}
+
+	bloomFilterBitsPerKey := opts.bloomFilterBitsPerKey
+	if bloomFilterBitsPerKey > 0 && version < BloomFilterVersion {
+		opts.logger.Warningf("Bloom filters disabled for index '%s', it was created using version %d", path, version)
+		bloomFilterBitsPerKey = 0
+	}
 
 	maxNodeSize, ok := metadata.GetInt(MetaMaxNodeSize)
 	if !ok {
@@ -543,9 +612,15 @@ func OpenWith(path string, nLog, hLog, cLog appendable.Appendable, opts *Options
 		}
 	}
 
-	cache, err := cache.NewLRUCache(opts.cacheSize)
-	if err != nil {
-		return nil, err
+	var nodeCache nodeCache
+
+	if opts.sharedCache != nil {
+		nodeCache = opts.sharedCache
+	} else {
+		nodeCache, err = newLRUNodeCache(opts.cacheSize, opts.cacheShards)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	t := &TBtree{
@@ -554,7 +629,7 @@ func OpenWith(path string, nLog, hLog, cLog appendable.Appendable, opts *Options
 		nLog:                     nLog,
 		hLog:                     hLog,
 		cLog:                     cLog,
-		cache:                    cache,
+		cache:                    nodeCache,
 		maxNodeSize:              maxNodeSize,
 		maxKeySize:               maxKeySize,
 		maxValueSize:             maxValueSize,
@@ -566,12 +641,20 @@ func OpenWith(path string, nLog, hLog, cLog appendable.Appendable, opts *Options
 		maxActiveSnapshots:       opts.maxActiveSnapshots,
 		fileSize:                 opts.fileSize,
 		cacheSize:                opts.cacheSize,
+		cacheShards:              opts.cacheShards,
+		sharedCache:              opts.sharedCache,
 		fileMode:                 opts.fileMode,
+		encryptionKeyID:          opts.encryptionKeyID,
+		keyProvider:              opts.keyProvider,
+		mmap:                     opts.mmap,
 		compactionThld:           opts.compactionThld,
 		delayDuringCompaction:    opts.delayDuringCompaction,
+		compactionRateLimit:      opts.compactionRateLimit,
 		nodesLogMaxOpenedFiles:   opts.nodesLogMaxOpenedFiles,
 		historyLogMaxOpenedFiles: opts.historyLogMaxOpenedFiles,
 		commitLogMaxOpenedFiles:  opts.commitLogMaxOpenedFiles,
+		bloomFilterBitsPerKey:    bloomFilterBitsPerKey,
+		prefixCompression:        prefixCompression,
 		readOnly:                 opts.readOnly,
 		snapshots:                make(map[uint64]*Snapshot),
 	}
@@ -691,11 +774,14 @@ func (t *TBtree) GetOptions() *Options {
 	return DefaultOptions().
 		WithReadOnly(t.readOnly).
 		WithFileMode(t.fileMode).
+		WithEncryption(t.encryptionKeyID, t.keyProvider).
+		WithMmap(t.mmap).
 		WithFileSize(t.fileSize).
 		WithMaxKeySize(t.maxKeySize).
 		WithMaxValueSize(t.maxValueSize).
 		WithLogger(t.logger).
 		WithCacheSize(t.cacheSize).
+		WithCacheShards(t.cacheShards).
 		WithFlushThld(t.flushThld).
 		WithSyncThld(t.syncThld).
 		WithFlushBufferSize(t.flushBufferSize).
@@ -705,18 +791,22 @@ func (t *TBtree) GetOptions() *Options {
 		WithRenewSnapRootAfter(t.renewSnapRootAfter).
 		WithCompactionThld(t.compactionThld).
 		WithDelayDuringCompaction(t.delayDuringCompaction).
+		WithCompactionRateLimit(t.compactionRateLimit).
 		WithNodesLogMaxOpenedFiles(t.nodesLogMaxOpenedFiles).
 		WithHistoryLogMaxOpenedFiles(t.historyLogMaxOpenedFiles).
-		WithCommitLogMaxOpenedFiles(t.commitLogMaxOpenedFiles)
+		WithCommitLogMaxOpenedFiles(t.commitLogMaxOpenedFiles).
+		WithBloomFilterBitsPerKey(t.bloomFilterBitsPerKey).
+		WithPrefixCompression(t.prefixCompression).
+		WithSharedCache(t.sharedCache)
 }
 
 func (t *TBtree) cachePut(n node) {
 	t.nmutex.Lock()
 	defer t.nmutex.Unlock()
 
-	r, _, _ := t.cache.Put(n.offset(), n)
-	if r != nil {
-		metricsCacheEvict.WithLabelValues(t.path).Inc()
+	evicted := t.cache.put(t, n)
+	if evicted > 0 {
+		metricsCacheEvict.WithLabelValues(t.path).Add(float64(evicted))
 	}
 }
 
@@ -724,13 +814,13 @@ func (t *TBtree) nodeAt(offset int64, updateCache bool) (node, error) {
 	t.nmutex.Lock()
 	defer t.nmutex.Unlock()
 
-	size := t.cache.EntriesCount()
+	size := t.cache.entriesCount()
 	metricsCacheSizeStats.WithLabelValues(t.path).Set(float64(size))
 
-	v, err := t.cache.Get(offset)
+	n, err := t.cache.get(t, offset)
 	if err == nil {
 		metricsCacheHit.WithLabelValues(t.path).Inc()
-		return v.(node), nil
+		return n, nil
 	}
 
 	if err == cache.ErrKeyNotFound {
@@ -742,9 +832,9 @@ func (t *TBtree) nodeAt(offset int64, updateCache bool) (node, error) {
 		}
 
 		if updateCache {
-			r, _, _ := t.cache.Put(n.offset(), n)
-			if r != nil {
-				metricsCacheEvict.WithLabelValues(t.path).Inc()
+			evicted := t.cache.put(t, n)
+			if evicted > 0 {
+				metricsCacheEvict.WithLabelValues(t.path).Add(float64(evicted))
 			}
 		}
 
@@ -768,15 +858,15 @@ func (t *TBtree) readNodeFrom(r *appendable.Reader) (node, error) {
 	}
 
 	switch nodeType {
-	case InnerNodeType:
-		n, err := t.readInnerNodeFrom(r)
+	case InnerNodeType, InnerNodeWithFiltersType:
+		n, err := t.readInnerNodeFrom(r, nodeType == InnerNodeWithFiltersType)
 		if err != nil {
 			return nil, err
 		}
 		n.off = off
 		return n, nil
-	case LeafNodeType:
-		n, err := t.readLeafNodeFrom(r)
+	case LeafNodeType, LeafNodeWithPrefixesType:
+		n, err := t.readLeafNodeFrom(r, nodeType == LeafNodeWithPrefixesType)
 		if err != nil {
 			return nil, err
 		}
@@ -787,7 +877,7 @@ func (t *TBtree) readNodeFrom(r *appendable.Reader) (node, error) {
 	return nil, ErrReadingFileContent
 }
 
-func (t *TBtree) readInnerNodeFrom(r *appendable.Reader) (*innerNode, error) {
+func (t *TBtree) readInnerNodeFrom(r *appendable.Reader, withFilters bool) (*innerNode, error) {
 	childCount, err := r.ReadUint16()
 	if err != nil {
 		return nil, err
@@ -805,6 +895,13 @@ func (t *TBtree) readInnerNodeFrom(r *appendable.Reader) (*innerNode, error) {
 			return nil, err
 		}
 
+		if withFilters {
+			nref._filter, err = t.readBloomFilterFrom(r)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		n.nodes[c] = nref
 
 		if n._ts < nref._ts {
@@ -855,7 +952,39 @@ func (t *TBtree) readNodeRefFrom(r *appendable.Reader) (*nodeRef, error) {
 	}, nil
 }
 
-func (t *TBtree) readLeafNodeFrom(r *appendable.Reader) (*leafNode, error) {
+func (t *TBtree) readBloomFilterFrom(r *appendable.Reader) (*bloomFilter, error) {
+	size, err := r.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+
+	if size == 0 {
+		return nil, nil
+	}
+
+	k, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	if k == 0 {
+		return nil, ErrReadingFileContent
+	}
+
+	f := &bloomFilter{
+		k:    k,
+		bits: make([]byte, size),
+	}
+
+	_, err = r.Read(f.bits)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (t *TBtree) readLeafNodeFrom(r *appendable.Reader, withPrefixes bool) (*leafNode, error) {
 	valueCount, err := r.ReadUint16()
 	if err != nil {
 		return nil, err
@@ -866,18 +995,39 @@ func (t *TBtree) readLeafNodeFrom(r *appendable.Reader) (*leafNode, error) {
 		values: make([]*leafValue, valueCount),
 	}
 
+	var prevKey []byte
+
 	for c := 0; c < int(valueCount); c++ {
+		shared := 0
+
+		if withPrefixes {
+			sharedLen, err := r.ReadUint16()
+			if err != nil {
+				return nil, err
+			}
+
+			if int(sharedLen) > len(prevKey) {
+				return nil, ErrReadingFileContent
+			}
+
+			shared = int(sharedLen)
+		}
+
 		ksize, err := r.ReadUint16()
 		if err != nil {
 			return nil, err
 		}
 
-		key := make([]byte, ksize)
-		_, err = r.Read(key)
+		key := make([]byte, shared+int(ksize))
+		copy(key, prevKey[:shared])
+
+		_, err = r.Read(key[shared:])
 		if err != nil {
 			return nil, err
 		}
 
+		prevKey = key
+
 		vsize, err := r.ReadUint16()
 		if err != nil {
 			return nil, err
@@ -1067,6 +1217,7 @@ func (t *TBtree) flushTree(cleanupPercentageHint float32, forceSync bool, forceC
 		metricsFlushedEntriesLastCycle,
 		metricsFlushedEntriesTotal,
 		"Flushing",
+		false,
 		t.root.ts(),
 		time.Minute,
 	)
@@ -1255,6 +1406,7 @@ func (t *TBtree) buildWriteProgressOutput(
 	entriesLastCycle *prometheus.GaugeVec,
 	entriesTotal *prometheus.CounterVec,
 	action string,
+	compaction bool,
 	snapTS uint64,
 	logReportDelay time.Duration,
 ) (
@@ -1274,6 +1426,8 @@ func (t *TBtree) buildWriteProgressOutput(
 	leafNodes := 0
 	entries := 0
 
+	t.setWriteProgress(compaction, true, 0)
+
 	lastProgressTime := time.Now()
 	progressFunc := func(innerNodesWritten, leafNodesWritten, entriesWritten int) {
 
@@ -1281,6 +1435,8 @@ func (t *TBtree) buildWriteProgressOutput(
 		leafNodes += leafNodesWritten
 		entries += entriesWritten
 
+		t.setWriteProgress(compaction, true, entries)
+
 		iTotal.Add(float64(innerNodesWritten))
 		lTotal.Add(float64(leafNodesWritten))
 		eTotal.Add(float64(entriesWritten))
@@ -1296,6 +1452,8 @@ func (t *TBtree) buildWriteProgressOutput(
 	}
 
 	finishFunc := func() {
+		t.setWriteProgress(compaction, false, entries)
+
 		iLastCycle.Set(float64(innerNodes))
 		lLastCycle.Set(float64(leafNodes))
 		eLastCycle.Set(float64(entries))
@@ -1309,6 +1467,27 @@ func (t *TBtree) buildWriteProgressOutput(
 	return progressFunc, finishFunc
 }
 
+func (t *TBtree) setWriteProgress(compaction, inProgress bool, entries int) {
+	t.progressMutex.Lock()
+	defer t.progressMutex.Unlock()
+
+	if compaction {
+		t.progress.Compacting = inProgress
+		t.progress.CompactedEntries = entries
+	} else {
+		t.progress.Flushing = inProgress
+		t.progress.FlushedEntries = entries
+	}
+}
+
+// WriteProgress can be called while the tree is being flushed or compacted
+func (t *TBtree) WriteProgress() WriteProgress {
+	t.progressMutex.Lock()
+	defer t.progressMutex.Unlock()
+
+	return t.progress
+}
+
 func (t *TBtree) Compact() (uint64, error) {
 	t.rwmutex.Lock()
 	defer t.rwmutex.Unlock()
@@ -1352,6 +1531,7 @@ func (t *TBtree) Compact() (uint64, error) {
 		metricsCompactedEntriesLastCycle,
 		metricsCompactedEntriesTotal,
 		"Dumping",
+		true,
 		snap.Ts(),
 		time.Minute,
 	)
@@ -1378,6 +1558,8 @@ func (t *TBtree) fullDump(snap *Snapshot, progressOutput writeProgressOutputFunc
 		WithFileSize(t.fileSize).
 		WithFileMode(t.fileMode).
 		WithWriteBufferSize(t.flushBufferSize).
+		WithEncryption(t.encryptionKeyID, t.keyProvider).
+		WithMmap(t.mmap).
 		WithMetadata(t.cLog.Metadata())
 
 	appendableOpts.WithFileExt("n")
@@ -1417,7 +1599,13 @@ func (t *TBtree) fullDumpTo(snapshot *Snapshot, nLog, cLog appendable.Appendable
 		reportProgress: progressOutput,
 	}
 
-	_, _, wN, _, err := snapshot.WriteTo(&appendableWriter{nLog}, nil, wopts)
+	var nw io.Writer = &appendableWriter{nLog}
+
+	if t.compactionRateLimit > 0 {
+		nw = newRateLimitedWriter(nw, t.compactionRateLimit, metricsCompactionThrottledSeconds.WithLabelValues(t.path))
+	}
+
+	_, _, wN, _, err := snapshot.WriteTo(nw, nil, wopts)
 	if err != nil {
 		return err
 	}
@@ -1517,6 +1705,8 @@ func (t *TBtree) Close() error {
 	err = t.cLog.Close()
 	merrors.Append(err)
 
+	t.cache.release(t)
+
 	err = merrors.Reduce()
 	if err != nil {
 		return t.wrapNwarn("Closing index '%s' {ts=%d} returned: %v", t.path, t.root.ts(), err)
@@ -1648,7 +1838,12 @@ func (t *TBtree) bulkInsert(kvts []*KVT) error {
 		}
 	}
 
-	nodes, depth, err := t.root.insert(immutableKVTs)
+	return t.insert(immutableKVTs, newTs, "BulkInsert")
+}
+
+// insert adds already validated entries into the tree, newTs being the greatest timestamp among them
+func (t *TBtree) insert(kvts []*KVT, newTs uint64, src string) error {
+	nodes, depth, err := t.root.insert(kvts)
 	if err != nil {
 		// INVARIANT: if !node.mutated() then for every node 'n' in the subtree with node as root !n.mutated() also holds
 		// if t.root is not mutated it means no change was made on any node of the tree. Thus no rollback is needed
@@ -1686,12 +1881,12 @@ func (t *TBtree) bulkInsert(kvts []*KVT) error {
 
 	metricsBtreeDepth.WithLabelValues(t.path).Set(float64(depth))
 
-	t.insertionCountSinceFlush += len(immutableKVTs)
-	t.insertionCountSinceSync += len(immutableKVTs)
-	t.insertionCountSinceCleanup += len(immutableKVTs)
+	t.insertionCountSinceFlush += len(kvts)
+	t.insertionCountSinceSync += len(kvts)
+	t.insertionCountSinceCleanup += len(kvts)
 
 	if t.insertionCountSinceFlush >= t.flushThld {
-		_, _, err := t.flushTree(t.cleanupPercentage, false, false, "BulkInsert")
+		_, _, err := t.flushTree(t.cleanupPercentage, false, false, src)
 		return err
 	}
 
@@ -2013,11 +2208,58 @@ func (n *innerNode) size() (int, error) {
 		size += 8               // ts
 		size += 8               // offset
 		size += 8               // min offset
+
+		if n.t.bloomFilterBitsPerKey > 0 {
+			size += 2 // filter length
+
+			if fsize := n.t.bloomFilterSizeOf(c); fsize > 0 {
+				size += 1     // number of hash functions
+				size += fsize // filter
+			}
+		}
 	}
 
 	return size, nil
 }
 
+// bloomFilterSizeOf returns the number of bytes taken by the filter of a child node,
+// zero when the child is not summarized by a filter
+func (t *TBtree) bloomFilterSizeOf(c node) int {
+	var size int
+
+	switch c := c.(type) {
+	case *leafNode:
+		size = bloomFilterSize(len(c.values), t.bloomFilterBitsPerKey)
+	case *nodeRef:
+		if c._filter != nil {
+			size = len(c._filter.bits)
+		}
+	}
+
+	// filters are bounded so inner nodes with few children always fit within a node
+	if size > t.maxNodeSize/8 {
+		return 0
+	}
+
+	return size
+}
+
+// bloomFilterOf returns the filter of a child node or nil when it's not summarized by one
+func (t *TBtree) bloomFilterOf(c node) *bloomFilter {
+	if t.bloomFilterSizeOf(c) == 0 {
+		return nil
+	}
+
+	switch c := c.(type) {
+	case *leafNode:
+		return newBloomFilter(c.values, t.bloomFilterBitsPerKey)
+	case *nodeRef:
+		return c._filter
+	}
+
+	return nil
+}
+
 func (n *innerNode) mutated() bool {
 	return n.mut
 }
@@ -2125,6 +2367,11 @@ func (r *nodeRef) insert(kvts []*KVT) (nodes []node, depth int, err error) {
 }
 
 func (r *nodeRef) get(key []byte) (value []byte, ts uint64, hc uint64, err error) {
+	if r._filter != nil && !r._filter.mayContain(key) {
+		metricsBloomFilterNegatives.WithLabelValues(r.t.path).Inc()
+		return nil, 0, 0, ErrKeyNotFound
+	}
+
 	n, err := r.t.nodeAt(r.off, true)
 	if err != nil {
 		return nil, 0, 0, err
@@ -2133,6 +2380,11 @@ func (r *nodeRef) get(key []byte) (value []byte, ts uint64, hc uint64, err error
 }
 
 func (r *nodeRef) history(key []byte, offset uint64, descOrder bool, limit int) ([]uint64, uint64, error) {
+	if r._filter != nil && !r._filter.mayContain(key) {
+		metricsBloomFilterNegatives.WithLabelValues(r.t.path).Inc()
+		return nil, 0, ErrKeyNotFound
+	}
+
 	n, err := r.t.nodeAt(r.off, true)
 	if err != nil {
 		return nil, 0, err
@@ -2468,11 +2720,20 @@ func (l *leafNode) setTs(ts uint64) (node, error) {
 // size calculates the amount of bytes required to serialize a leaf node
 // note: requiredNodeSize must be revised if this function is modified
 func (l *leafNode) size() (int, error) {
-	size := 1 // Node type
+	_, size := l.encoding()
+	return size, nil
+}
+
+// encoding returns whether keys are serialized front-coded and the resulting node size,
+// prefixes are only used when enabled and they actually reduce the size of the node
+func (l *leafNode) encoding() (withPrefixes bool, size int) {
+	size = 1 // Node type
 
 	size += 2 // kv count
 
-	for _, kv := range l.values {
+	shared := 0
+
+	for i, kv := range l.values {
 		size += 2             // Key length
 		size += len(kv.key)   // Key
 		size += 2             // Value length
@@ -2480,9 +2741,33 @@ func (l *leafNode) size() (int, error) {
 		size += 8             // Ts
 		size += 8             // hOff
 		size += 8             // hCount
+
+		if i > 0 {
+			shared += commonPrefixLen(l.values[i-1].key, kv.key)
+		}
 	}
 
-	return size, nil
+	// front-coding adds the shared length to every key while removing the shared bytes
+	if l.t.prefixCompression && shared > 2*len(l.values) {
+		return true, size + 2*len(l.values) - shared
+	}
+
+	return false, size
+}
+
+func commonPrefixLen(a, b []byte) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+
+	return n
 }
 
 func (l *leafNode) mutated() bool {
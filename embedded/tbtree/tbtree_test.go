@@ -72,6 +72,17 @@ func TestEdgeCases(t *testing.T) {
 		require.ErrorIs(t, err, ErrIncompatibleDataFormat)
 	})
 
+	t.Run("Should fail reading a newer version from metadata", func(t *testing.T) {
+		cLog.MetadataFn = func() []byte {
+			md := appendable.NewMetadata(nil)
+			md.PutInt(MetaVersion, Version+1)
+			return md.Bytes()
+		}
+
+		_, err = OpenWith(path, nLog, hLog, cLog, DefaultOptions())
+		require.ErrorIs(t, err, ErrIncompatibleDataFormat)
+	})
+
 	t.Run("Should fail reading cLogSize", func(t *testing.T) {
 		cLog.MetadataFn = func() []byte {
 			md := appendable.NewMetadata(nil)
@@ -1528,3 +1539,103 @@ func TestMultiTimedBulkInsertion(t *testing.T) {
 	err = tbtree.Close()
 	require.NoError(t, err)
 }
+
+func TestTBTreeWithPrefixCompression(t *testing.T) {
+	keyCount := 10_000
+
+	key := func(i int) []byte {
+		return []byte(fmt.Sprintf("https://example.com/resources/collection/items/%08d", i))
+	}
+
+	buildTree := func(t *testing.T, dir string, opts *Options) int64 {
+		tree, err := Open(dir, opts)
+		require.NoError(t, err)
+
+		for i := 0; i < keyCount; i++ {
+			err = tree.Insert(key(i), []byte(fmt.Sprintf("value%d", i)))
+			require.NoError(t, err)
+		}
+
+		_, _, err = tree.Flush()
+		require.NoError(t, err)
+
+		size, err := tree.nLog.Size()
+		require.NoError(t, err)
+
+		err = tree.Close()
+		require.NoError(t, err)
+
+		return size
+	}
+
+	checkTree := func(t *testing.T, dir string, opts *Options) {
+		tree, err := Open(dir, opts)
+		require.NoError(t, err)
+		defer tree.Close()
+
+		for i := 0; i < keyCount; i++ {
+			v, _, hc, err := tree.Get(key(i))
+			require.NoError(t, err)
+			require.Equal(t, []byte(fmt.Sprintf("value%d", i)), v)
+			require.Equal(t, uint64(1), hc)
+		}
+
+		snap, err := tree.Snapshot()
+		require.NoError(t, err)
+		defer snap.Close()
+
+		r, err := snap.NewReader(ReaderSpec{Prefix: []byte("https://example.com/")})
+		require.NoError(t, err)
+		defer r.Close()
+
+		for i := 0; i < keyCount; i++ {
+			k, _, _, _, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, key(i), k)
+		}
+
+		_, _, _, _, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreEntries)
+	}
+
+	plainDir := t.TempDir()
+	plainSize := buildTree(t, plainDir, DefaultOptions())
+
+	dir := t.TempDir()
+	size := buildTree(t, dir, DefaultOptions().WithPrefixCompression(true))
+
+	require.Less(t, size, plainSize/2)
+
+	t.Run("compressed nodes are read back", func(t *testing.T) {
+		checkTree(t, dir, DefaultOptions().WithPrefixCompression(true))
+	})
+
+	t.Run("compressed nodes are readable with compression disabled", func(t *testing.T) {
+		checkTree(t, dir, DefaultOptions())
+	})
+
+	t.Run("uncompressed nodes are readable with compression enabled", func(t *testing.T) {
+		checkTree(t, plainDir, DefaultOptions().WithPrefixCompression(true))
+	})
+
+	t.Run("only indexes with compressed nodes should require the new version", func(t *testing.T) {
+		versionOf := func(dir string, opts *Options) (int, bool) {
+			tree, err := Open(dir, opts)
+			require.NoError(t, err)
+			defer tree.Close()
+
+			version, ok := appendable.NewMetadata(tree.cLog.Metadata()).GetInt(MetaVersion)
+			require.True(t, ok)
+
+			return version, tree.prefixCompression
+		}
+
+		version, compressed := versionOf(dir, DefaultOptions().WithPrefixCompression(true))
+		require.Equal(t, PrefixCompressionVersion, version)
+		require.True(t, compressed)
+
+		version, compressed = versionOf(plainDir, DefaultOptions().WithPrefixCompression(true))
+		require.Equal(t, MinCompatibleVersion, version)
+		require.False(t, compressed)
+	})
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watchers
+
+import "fmt"
+
+const DefaultMaxWaiting = 1000
+const DefaultMaxBacklog = 1000
+
+// OverflowPolicy defines the behaviour of WaitFor when the max number of waiting points is reached
+type OverflowPolicy int
+
+const (
+	// OverflowFail immediately returns ErrMaxWaitessLimitExceeded
+	OverflowFail OverflowPolicy = iota
+	// OverflowBlock blocks until a waiting slot is freed or the context is done
+	OverflowBlock
+	// OverflowQueue behaves as OverflowBlock but up to a bounded backlog,
+	// ErrMaxWaitessLimitExceeded is returned once the backlog is full
+	OverflowQueue
+)
+
+type Options struct {
+	maxWaiting     int
+	overflowPolicy OverflowPolicy
+	maxBacklog     int
+}
+
+func DefaultOptions() *Options {
+	return &Options{
+		maxWaiting:     DefaultMaxWaiting,
+		overflowPolicy: OverflowFail,
+		maxBacklog:     DefaultMaxBacklog,
+	}
+}
+
+func (opts *Options) Validate() error {
+	if opts == nil {
+		return fmt.Errorf("%w: nil options", ErrInvalidOptions)
+	}
+
+	if opts.maxWaiting < 0 {
+		return fmt.Errorf("%w: invalid MaxWaiting", ErrInvalidOptions)
+	}
+
+	if opts.overflowPolicy < OverflowFail || opts.overflowPolicy > OverflowQueue {
+		return fmt.Errorf("%w: invalid OverflowPolicy", ErrInvalidOptions)
+	}
+
+	if opts.overflowPolicy == OverflowQueue && opts.maxBacklog <= 0 {
+		return fmt.Errorf("%w: invalid MaxBacklog", ErrInvalidOptions)
+	}
+
+	return nil
+}
+
+func (opts *Options) WithMaxWaiting(maxWaiting int) *Options {
+	opts.maxWaiting = maxWaiting
+	return opts
+}
+
+func (opts *Options) WithOverflowPolicy(policy OverflowPolicy) *Options {
+	opts.overflowPolicy = policy
+	return opts
+}
+
+// WithMaxBacklog sets the max number of callers queued for a waiting slot, only used with OverflowQueue
+func (opts *Options) WithMaxBacklog(maxBacklog int) *Options {
+	opts.maxBacklog = maxBacklog
+	return opts
+}
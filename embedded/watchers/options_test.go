@@ -0,0 +1,64 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watchers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvalidOptions(t *testing.T) {
+	for _, d := range []struct {
+		n    string
+		opts *Options
+	}{
+		{"nil", nil},
+		{"MaxWaiting", DefaultOptions().WithMaxWaiting(-1)},
+		{"OverflowPolicy", DefaultOptions().WithOverflowPolicy(OverflowQueue + 1)},
+		{"MaxBacklog", DefaultOptions().WithOverflowPolicy(OverflowQueue).WithMaxBacklog(0)},
+	} {
+		t.Run(d.n, func(t *testing.T) {
+			require.ErrorIs(t, d.opts.Validate(), ErrInvalidOptions)
+
+			_, err := New(0, d.opts)
+			require.ErrorIs(t, err, ErrInvalidOptions)
+		})
+	}
+}
+
+func TestDefaultOptions(t *testing.T) {
+	opts := DefaultOptions()
+	require.NoError(t, opts.Validate())
+
+	require.Equal(t, DefaultMaxWaiting, opts.maxWaiting)
+	require.Equal(t, OverflowFail, opts.overflowPolicy)
+	require.Equal(t, DefaultMaxBacklog, opts.maxBacklog)
+}
+
+func TestValidOptions(t *testing.T) {
+	opts := DefaultOptions().
+		WithMaxWaiting(10).
+		WithOverflowPolicy(OverflowQueue).
+		WithMaxBacklog(5)
+
+	require.NoError(t, opts.Validate())
+
+	require.Equal(t, 10, opts.maxWaiting)
+	require.Equal(t, OverflowQueue, opts.overflowPolicy)
+	require.Equal(t, 5, opts.maxBacklog)
+}
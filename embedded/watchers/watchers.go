@@ -19,11 +19,14 @@ package watchers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 )
 
 var ErrMaxWaitessLimitExceeded = errors.New("watchers: max waiting limit exceeded")
 var ErrAlreadyClosed = errors.New("watchers: already closed")
+var ErrIllegalArguments = errors.New("watchers: illegal arguments")
+var ErrInvalidOptions = fmt.Errorf("%w: invalid options", ErrIllegalArguments)
 
 type WatchersHub struct {
 	wpoints map[uint64]*waitingPoint
@@ -33,6 +36,18 @@ type WatchersHub struct {
 	maxWaiting int
 	waiting    int
 
+	overflowPolicy OverflowPolicy
+	maxBacklog     int
+	queued         int
+	slotFreed      chan struct{} // closed and renewed when queued callers may take a waiting slot
+
+	subscriptions map[uint64]*subscription
+	nextSubID     uint64
+
+	peakWaiting   int
+	wakeups       uint64
+	cancellations uint64
+
 	closed bool
 
 	mutex sync.Mutex
@@ -44,12 +59,36 @@ type waitingPoint struct {
 	count int
 }
 
-func New(doneUpto uint64, maxWaiting int) *WatchersHub {
-	return &WatchersHub{
-		wpoints:    make(map[uint64]*waitingPoint, 0),
-		doneUpto:   doneUpto,
-		maxWaiting: maxWaiting,
+// Metrics is a snapshot of the hub activity, counters are accumulated since the hub was created
+type Metrics struct {
+	DoneUpto      uint64
+	Waiting       int
+	PeakWaiting   int
+	Queued        int
+	Wakeups       uint64
+	Cancellations uint64
+}
+
+type subscription struct {
+	ch   chan uint64
+	last uint64
+}
+
+func New(doneUpto uint64, opts *Options) (*WatchersHub, error) {
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
 	}
+
+	return &WatchersHub{
+		wpoints:        make(map[uint64]*waitingPoint, 0),
+		doneUpto:       doneUpto,
+		maxWaiting:     opts.maxWaiting,
+		overflowPolicy: opts.overflowPolicy,
+		maxBacklog:     opts.maxBacklog,
+		slotFreed:      make(chan struct{}),
+		subscriptions:  make(map[uint64]*subscription),
+	}, nil
 }
 
 func (w *WatchersHub) Status() (doneUpto uint64, waiting int, err error) {
@@ -63,6 +102,21 @@ func (w *WatchersHub) Status() (doneUpto uint64, waiting int, err error) {
 	return w.doneUpto, w.waiting, nil
 }
 
+// Metrics can be called even after the hub is closed
+func (w *WatchersHub) Metrics() Metrics {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return Metrics{
+		DoneUpto:      w.doneUpto,
+		Waiting:       w.waiting,
+		PeakWaiting:   w.peakWaiting,
+		Queued:        w.queued,
+		Wakeups:       w.wakeups,
+		Cancellations: w.cancellations,
+	}
+}
+
 func (w *WatchersHub) DoneUpto(t uint64) error {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
@@ -83,6 +137,7 @@ func (w *WatchersHub) DoneUpto(t uint64) error {
 		wp, waiting := w.wpoints[i]
 		if waiting {
 			close(wp.ch)
+			w.wakeups += uint64(wp.count)
 			w.waiting -= wp.count
 			wp.count = 0
 			delete(w.wpoints, i)
@@ -91,9 +146,71 @@ func (w *WatchersHub) DoneUpto(t uint64) error {
 
 	w.doneUpto = t
 
+	w.notifySlotFreed()
+
+	for _, sub := range w.subscriptions {
+		sub.notify(t)
+	}
+
 	return nil
 }
 
+// notify delivers the point without blocking, a pending notification not yet
+// consumed is replaced as progress is monotonic and only the latest point matters
+func (sub *subscription) notify(t uint64) {
+	if t <= sub.last {
+		return
+	}
+
+	select {
+	case <-sub.ch:
+	default:
+	}
+
+	sub.ch <- t
+	sub.last = t
+}
+
+// Subscribe returns a channel where progress beyond fromPoint is notified, together with
+// the function to be called to cancel the subscription. Subscriptions don't consume waiting slots.
+// Slow consumers only get the latest point, the channel is closed when cancelling or closing the hub
+func (w *WatchersHub) Subscribe(fromPoint uint64) (<-chan uint64, func()) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	sub := &subscription{
+		ch:   make(chan uint64, 1),
+		last: fromPoint,
+	}
+
+	if w.closed {
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+
+	id := w.nextSubID
+	w.nextSubID++
+
+	w.subscriptions[id] = sub
+
+	sub.notify(w.doneUpto)
+
+	cancel := func() {
+		w.mutex.Lock()
+		defer w.mutex.Unlock()
+
+		_, ok := w.subscriptions[id]
+		if !ok {
+			return
+		}
+
+		close(sub.ch)
+		delete(w.subscriptions, id)
+	}
+
+	return sub.ch, cancel
+}
+
 func (w *WatchersHub) WaitFor(ctx context.Context, t uint64) error {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
@@ -107,7 +224,15 @@ func (w *WatchersHub) WaitFor(ctx context.Context, t uint64) error {
 	}
 
 	if w.waiting == w.maxWaiting {
-		return ErrMaxWaitessLimitExceeded
+		err := w.waitForSlot(ctx, t)
+		if err != nil {
+			return err
+		}
+
+		// the point may have been reached while waiting for a slot
+		if w.doneUpto >= t {
+			return nil
+		}
 	}
 
 	wp, waiting := w.wpoints[t]
@@ -119,6 +244,10 @@ func (w *WatchersHub) WaitFor(ctx context.Context, t uint64) error {
 	wp.count++
 	w.waiting++
 
+	if w.waiting > w.peakWaiting {
+		w.peakWaiting = w.waiting
+	}
+
 	w.mutex.Unlock()
 
 	cancelled := false
@@ -143,6 +272,7 @@ func (w *WatchersHub) WaitFor(ctx context.Context, t uint64) error {
 		// happen simultaneously), otherwise its necessary to cleanup after we stopped waiting.
 		if wp.count > 0 {
 			w.waiting--
+			w.cancellations++
 			wp.count--
 
 			if wp.count == 0 {
@@ -151,6 +281,8 @@ func (w *WatchersHub) WaitFor(ctx context.Context, t uint64) error {
 				close(wp.ch)
 				delete(w.wpoints, t)
 			}
+
+			w.notifySlotFreed()
 		}
 
 		return ctx.Err()
@@ -159,6 +291,78 @@ func (w *WatchersHub) WaitFor(ctx context.Context, t uint64) error {
 	return nil
 }
 
+// WaitForAny blocks until any of the given points is reached and returns the one which fired.
+// Points are reached in ascending order, thus only the lowest one is waited for,
+// consuming a single waiting slot regardless of the number of points
+func (w *WatchersHub) WaitForAny(ctx context.Context, points ...uint64) (uint64, error) {
+	if len(points) == 0 {
+		return 0, ErrIllegalArguments
+	}
+
+	t := points[0]
+
+	for _, p := range points[1:] {
+		if p < t {
+			t = p
+		}
+	}
+
+	err := w.WaitFor(ctx, t)
+	if err != nil {
+		return 0, err
+	}
+
+	return t, nil
+}
+
+// waitForSlot is called with the mutex held when all waiting slots are taken,
+// it returns with the mutex held as soon as a slot is available or the point is reached
+func (w *WatchersHub) waitForSlot(ctx context.Context, t uint64) error {
+	if w.overflowPolicy == OverflowFail {
+		return ErrMaxWaitessLimitExceeded
+	}
+
+	if w.overflowPolicy == OverflowQueue && w.queued == w.maxBacklog {
+		return ErrMaxWaitessLimitExceeded
+	}
+
+	w.queued++
+	defer func() { w.queued-- }()
+
+	for w.waiting == w.maxWaiting && w.doneUpto < t {
+		slotFreed := w.slotFreed
+
+		w.mutex.Unlock()
+
+		select {
+		case <-slotFreed:
+		case <-ctx.Done():
+		}
+
+		w.mutex.Lock()
+
+		if w.closed {
+			return ErrAlreadyClosed
+		}
+
+		if ctx.Err() != nil {
+			w.cancellations++
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+func (w *WatchersHub) notifySlotFreed() {
+	if w.queued == 0 {
+		return
+	}
+
+	close(w.slotFreed)
+	w.slotFreed = make(chan struct{})
+}
+
 func (w *WatchersHub) Close() error {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
@@ -175,5 +379,12 @@ func (w *WatchersHub) Close() error {
 		wp.count = 0
 	}
 
+	for id, sub := range w.subscriptions {
+		close(sub.ch)
+		delete(w.subscriptions, id)
+	}
+
+	w.notifySlotFreed()
+
 	return nil
 }
@@ -30,14 +30,15 @@ import (
 func TestWatchersHub(t *testing.T) {
 	waitessCount := 1_000
 
-	wHub := New(0, waitessCount*2)
+	wHub, err := New(0, DefaultOptions().WithMaxWaiting(waitessCount*2))
+	require.NoError(t, err)
 
 	wHub.DoneUpto(0)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
 	defer cancel()
 
-	err := wHub.WaitFor(ctx, 1)
+	err = wHub.WaitFor(ctx, 1)
 	require.ErrorIs(t, err, context.DeadlineExceeded)
 
 	doneUpto, waiting, err := wHub.Status()
@@ -130,7 +131,8 @@ func TestWatchersHub(t *testing.T) {
 }
 
 func TestSimultaneousCancellationAndNotification(t *testing.T) {
-	wHub := New(0, 30)
+	wHub, err := New(0, DefaultOptions().WithMaxWaiting(30))
+	require.NoError(t, err)
 
 	const maxIterations = 100
 
@@ -174,3 +176,275 @@ func TestSimultaneousCancellationAndNotification(t *testing.T) {
 	assert.Zero(t, wHub.waiting)
 	assert.Empty(t, wHub.wpoints)
 }
+
+func TestWaitForAny(t *testing.T) {
+	wHub, err := New(0, DefaultOptions().WithMaxWaiting(1))
+	require.NoError(t, err)
+
+	_, err = wHub.WaitForAny(context.Background())
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = wHub.DoneUpto(2)
+	require.NoError(t, err)
+
+	t1, err := wHub.WaitForAny(context.Background(), 5, 1, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), t1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = wHub.WaitForAny(ctx, 7, 5)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		fired, err := wHub.WaitForAny(context.Background(), 10, 5, 7)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(5), fired)
+	}()
+
+	require.Eventually(t, func() bool {
+		_, waiting, err := wHub.Status()
+		return err == nil && waiting == 1
+	}, time.Second, time.Millisecond)
+
+	// a single slot is consumed regardless of the number of points
+	_, err = wHub.WaitForAny(context.Background(), 8)
+	require.ErrorIs(t, err, ErrMaxWaitessLimitExceeded)
+
+	err = wHub.DoneUpto(6)
+	require.NoError(t, err)
+
+	<-done
+
+	_, waiting, err := wHub.Status()
+	require.NoError(t, err)
+	require.Equal(t, 0, waiting)
+
+	err = wHub.Close()
+	require.NoError(t, err)
+
+	_, err = wHub.WaitForAny(context.Background(), 10)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestSubscribe(t *testing.T) {
+	wHub, err := New(5, DefaultOptions().WithMaxWaiting(1))
+	require.NoError(t, err)
+
+	ch1, cancel1 := wHub.Subscribe(2)
+	require.Equal(t, uint64(5), <-ch1)
+
+	ch2, cancel2 := wHub.Subscribe(10)
+	defer cancel2()
+
+	select {
+	case <-ch2:
+		require.Fail(t, "no progress beyond the requested point yet")
+	default:
+	}
+
+	err = wHub.DoneUpto(8)
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(8), <-ch1)
+
+	// slow consumers only get the latest point
+	err = wHub.DoneUpto(11)
+	require.NoError(t, err)
+
+	err = wHub.DoneUpto(12)
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(12), <-ch1)
+	require.Equal(t, uint64(12), <-ch2)
+
+	// subscriptions don't consume waiting slots
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		assert.NoError(t, wHub.WaitFor(context.Background(), 13))
+	}()
+
+	require.Eventually(t, func() bool {
+		_, waiting, err := wHub.Status()
+		return err == nil && waiting == 1
+	}, time.Second, time.Millisecond)
+
+	err = wHub.DoneUpto(13)
+	require.NoError(t, err)
+
+	<-done
+
+	require.Equal(t, uint64(13), <-ch1)
+
+	cancel1()
+	cancel1()
+
+	_, ok := <-ch1
+	require.False(t, ok)
+
+	require.Equal(t, uint64(13), <-ch2)
+
+	err = wHub.Close()
+	require.NoError(t, err)
+
+	_, ok = <-ch2
+	require.False(t, ok)
+
+	ch3, cancel3 := wHub.Subscribe(0)
+	cancel3()
+
+	_, ok = <-ch3
+	require.False(t, ok)
+}
+
+func TestOverflowPolicyBlock(t *testing.T) {
+	wHub, err := New(0, DefaultOptions().WithMaxWaiting(1).WithOverflowPolicy(OverflowBlock))
+	require.NoError(t, err)
+
+	waitFor := func(t uint64) <-chan error {
+		ch := make(chan error, 1)
+		go func() { ch <- wHub.WaitFor(context.Background(), t) }()
+		return ch
+	}
+
+	waiting1 := waitFor(1)
+
+	require.Eventually(t, func() bool {
+		_, waiting, err := wHub.Status()
+		return err == nil && waiting == 1
+	}, time.Second, time.Millisecond)
+
+	// the caller blocks until the slot is freed instead of failing
+	waiting2 := waitFor(2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = wHub.WaitFor(ctx, 3)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	err = wHub.DoneUpto(1)
+	require.NoError(t, err)
+
+	require.NoError(t, <-waiting1)
+
+	require.Eventually(t, func() bool {
+		_, waiting, err := wHub.Status()
+		return err == nil && waiting == 1
+	}, time.Second, time.Millisecond)
+
+	err = wHub.DoneUpto(2)
+	require.NoError(t, err)
+
+	require.NoError(t, <-waiting2)
+
+	waiting3 := waitFor(3)
+	waiting4 := waitFor(4)
+
+	require.Eventually(t, func() bool {
+		_, waiting, err := wHub.Status()
+		return err == nil && waiting == 1
+	}, time.Second, time.Millisecond)
+
+	// blocked callers are released when the hub is closed
+	err = wHub.Close()
+	require.NoError(t, err)
+
+	require.ErrorIs(t, <-waiting3, ErrAlreadyClosed)
+	require.ErrorIs(t, <-waiting4, ErrAlreadyClosed)
+}
+
+func TestOverflowPolicyQueue(t *testing.T) {
+	wHub, err := New(0, DefaultOptions().
+		WithMaxWaiting(1).
+		WithOverflowPolicy(OverflowQueue).
+		WithMaxBacklog(1),
+	)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	for i := 1; i <= 2; i++ {
+		go func(i uint64) {
+			defer wg.Done()
+			assert.NoError(t, wHub.WaitFor(context.Background(), i))
+		}(uint64(i))
+
+		// ensure the first caller takes the slot and the second one is queued
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	err = wHub.WaitFor(context.Background(), 3)
+	require.ErrorIs(t, err, ErrMaxWaitessLimitExceeded)
+
+	err = wHub.DoneUpto(2)
+	require.NoError(t, err)
+
+	wg.Wait()
+
+	_, waiting, err := wHub.Status()
+	require.NoError(t, err)
+	require.Equal(t, 0, waiting)
+}
+
+func TestMetrics(t *testing.T) {
+	wHub, err := New(3, DefaultOptions().WithMaxWaiting(2).WithOverflowPolicy(OverflowBlock))
+	require.NoError(t, err)
+
+	require.Equal(t, Metrics{DoneUpto: 3}, wHub.Metrics())
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	for i := 4; i <= 5; i++ {
+		go func(i uint64) {
+			defer wg.Done()
+			assert.NoError(t, wHub.WaitFor(context.Background(), i))
+		}(uint64(i))
+	}
+
+	require.Eventually(t, func() bool {
+		return wHub.Metrics().Waiting == 2
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// queued caller cancelled while waiting for a slot
+	err = wHub.WaitFor(ctx, 6)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	err = wHub.DoneUpto(5)
+	require.NoError(t, err)
+
+	wg.Wait()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// waiting caller cancelled
+	err = wHub.WaitFor(ctx, 6)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.Equal(t, Metrics{
+		DoneUpto:      5,
+		Waiting:       0,
+		PeakWaiting:   2,
+		Queued:        0,
+		Wakeups:       2,
+		Cancellations: 2,
+	}, wHub.Metrics())
+
+	err = wHub.Close()
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(5), wHub.Metrics().DoneUpto)
+}
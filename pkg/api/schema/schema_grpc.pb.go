@@ -46,6 +46,7 @@ type ImmuServiceClient interface {
 	VerifiableGet(ctx context.Context, in *VerifiableGetRequest, opts ...grpc.CallOption) (*VerifiableEntry, error)
 	Delete(ctx context.Context, in *DeleteKeysRequest, opts ...grpc.CallOption) (*TxHeader, error)
 	GetAll(ctx context.Context, in *KeyListRequest, opts ...grpc.CallOption) (*Entries, error)
+	VerifiableGetAll(ctx context.Context, in *VerifiableGetAllRequest, opts ...grpc.CallOption) (*VerifiableEntries, error)
 	ExecAll(ctx context.Context, in *ExecAllRequest, opts ...grpc.CallOption) (*TxHeader, error)
 	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*Entries, error)
 	// NOT YET SUPPORTED
@@ -102,6 +103,7 @@ type ImmuServiceClient interface {
 	StreamZScan(ctx context.Context, in *ZScanRequest, opts ...grpc.CallOption) (ImmuService_StreamZScanClient, error)
 	StreamHistory(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (ImmuService_StreamHistoryClient, error)
 	StreamExecAll(ctx context.Context, opts ...grpc.CallOption) (ImmuService_StreamExecAllClient, error)
+	StreamTx(ctx context.Context, in *StreamTxRequest, opts ...grpc.CallOption) (ImmuService_StreamTxClient, error)
 	// Replication
 	ExportTx(ctx context.Context, in *ExportTxRequest, opts ...grpc.CallOption) (ImmuService_ExportTxClient, error)
 	ReplicateTx(ctx context.Context, opts ...grpc.CallOption) (ImmuService_ReplicateTxClient, error)
@@ -111,6 +113,8 @@ type ImmuServiceClient interface {
 	DescribeTable(ctx context.Context, in *Table, opts ...grpc.CallOption) (*SQLQueryResult, error)
 	VerifiableSQLGet(ctx context.Context, in *VerifiableSQLGetRequest, opts ...grpc.CallOption) (*VerifiableSQLEntry, error)
 	TruncateDatabase(ctx context.Context, in *TruncateDatabaseRequest, opts ...grpc.CallOption) (*TruncateDatabaseResponse, error)
+	CloneDatabase(ctx context.Context, in *CloneDatabaseRequest, opts ...grpc.CallOption) (*CloneDatabaseResponse, error)
+	RenameDatabase(ctx context.Context, in *RenameDatabaseRequest, opts ...grpc.CallOption) (*RenameDatabaseResponse, error)
 }
 
 type immuServiceClient struct {
@@ -332,6 +336,15 @@ func (c *immuServiceClient) GetAll(ctx context.Context, in *KeyListRequest, opts
 	return out, nil
 }
 
+func (c *immuServiceClient) VerifiableGetAll(ctx context.Context, in *VerifiableGetAllRequest, opts ...grpc.CallOption) (*VerifiableEntries, error) {
+	out := new(VerifiableEntries)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/VerifiableGetAll", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *immuServiceClient) ExecAll(ctx context.Context, in *ExecAllRequest, opts ...grpc.CallOption) (*TxHeader, error) {
 	out := new(TxHeader)
 	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/ExecAll", in, out, opts...)
@@ -887,8 +900,40 @@ func (x *immuServiceStreamExecAllClient) CloseAndRecv() (*TxHeader, error) {
 	return m, nil
 }
 
+func (c *immuServiceClient) StreamTx(ctx context.Context, in *StreamTxRequest, opts ...grpc.CallOption) (ImmuService_StreamTxClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ImmuService_ServiceDesc.Streams[8], "/immudb.schema.ImmuService/streamTx", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &immuServiceStreamTxClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ImmuService_StreamTxClient interface {
+	Recv() (*StreamedTx, error)
+	grpc.ClientStream
+}
+
+type immuServiceStreamTxClient struct {
+	grpc.ClientStream
+}
+
+func (x *immuServiceStreamTxClient) Recv() (*StreamedTx, error) {
+	m := new(StreamedTx)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *immuServiceClient) ExportTx(ctx context.Context, in *ExportTxRequest, opts ...grpc.CallOption) (ImmuService_ExportTxClient, error) {
-	stream, err := c.cc.NewStream(ctx, &ImmuService_ServiceDesc.Streams[8], "/immudb.schema.ImmuService/exportTx", opts...)
+	stream, err := c.cc.NewStream(ctx, &ImmuService_ServiceDesc.Streams[9], "/immudb.schema.ImmuService/exportTx", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -920,7 +965,7 @@ func (x *immuServiceExportTxClient) Recv() (*Chunk, error) {
 }
 
 func (c *immuServiceClient) ReplicateTx(ctx context.Context, opts ...grpc.CallOption) (ImmuService_ReplicateTxClient, error) {
-	stream, err := c.cc.NewStream(ctx, &ImmuService_ServiceDesc.Streams[9], "/immudb.schema.ImmuService/replicateTx", opts...)
+	stream, err := c.cc.NewStream(ctx, &ImmuService_ServiceDesc.Streams[10], "/immudb.schema.ImmuService/replicateTx", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -1007,6 +1052,24 @@ func (c *immuServiceClient) TruncateDatabase(ctx context.Context, in *TruncateDa
 	return out, nil
 }
 
+func (c *immuServiceClient) CloneDatabase(ctx context.Context, in *CloneDatabaseRequest, opts ...grpc.CallOption) (*CloneDatabaseResponse, error) {
+	out := new(CloneDatabaseResponse)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/CloneDatabase", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *immuServiceClient) RenameDatabase(ctx context.Context, in *RenameDatabaseRequest, opts ...grpc.CallOption) (*RenameDatabaseResponse, error) {
+	out := new(RenameDatabaseResponse)
+	err := c.cc.Invoke(ctx, "/immudb.schema.ImmuService/RenameDatabase", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ImmuServiceServer is the server API for ImmuService service.
 // All implementations should embed UnimplementedImmuServiceServer
 // for forward compatibility
@@ -1038,6 +1101,7 @@ type ImmuServiceServer interface {
 	VerifiableGet(context.Context, *VerifiableGetRequest) (*VerifiableEntry, error)
 	Delete(context.Context, *DeleteKeysRequest) (*TxHeader, error)
 	GetAll(context.Context, *KeyListRequest) (*Entries, error)
+	VerifiableGetAll(context.Context, *VerifiableGetAllRequest) (*VerifiableEntries, error)
 	ExecAll(context.Context, *ExecAllRequest) (*TxHeader, error)
 	Scan(context.Context, *ScanRequest) (*Entries, error)
 	// NOT YET SUPPORTED
@@ -1094,6 +1158,7 @@ type ImmuServiceServer interface {
 	StreamZScan(*ZScanRequest, ImmuService_StreamZScanServer) error
 	StreamHistory(*HistoryRequest, ImmuService_StreamHistoryServer) error
 	StreamExecAll(ImmuService_StreamExecAllServer) error
+	StreamTx(*StreamTxRequest, ImmuService_StreamTxServer) error
 	// Replication
 	ExportTx(*ExportTxRequest, ImmuService_ExportTxServer) error
 	ReplicateTx(ImmuService_ReplicateTxServer) error
@@ -1103,6 +1168,8 @@ type ImmuServiceServer interface {
 	DescribeTable(context.Context, *Table) (*SQLQueryResult, error)
 	VerifiableSQLGet(context.Context, *VerifiableSQLGetRequest) (*VerifiableSQLEntry, error)
 	TruncateDatabase(context.Context, *TruncateDatabaseRequest) (*TruncateDatabaseResponse, error)
+	CloneDatabase(context.Context, *CloneDatabaseRequest) (*CloneDatabaseResponse, error)
+	RenameDatabase(context.Context, *RenameDatabaseRequest) (*RenameDatabaseResponse, error)
 }
 
 // UnimplementedImmuServiceServer should be embedded to have forward compatible implementations.
@@ -1178,6 +1245,9 @@ func (UnimplementedImmuServiceServer) Delete(context.Context, *DeleteKeysRequest
 func (UnimplementedImmuServiceServer) GetAll(context.Context, *KeyListRequest) (*Entries, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetAll not implemented")
 }
+func (UnimplementedImmuServiceServer) VerifiableGetAll(context.Context, *VerifiableGetAllRequest) (*VerifiableEntries, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifiableGetAll not implemented")
+}
 func (UnimplementedImmuServiceServer) ExecAll(context.Context, *ExecAllRequest) (*TxHeader, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ExecAll not implemented")
 }
@@ -1298,6 +1368,9 @@ func (UnimplementedImmuServiceServer) StreamHistory(*HistoryRequest, ImmuService
 func (UnimplementedImmuServiceServer) StreamExecAll(ImmuService_StreamExecAllServer) error {
 	return status.Errorf(codes.Unimplemented, "method StreamExecAll not implemented")
 }
+func (UnimplementedImmuServiceServer) StreamTx(*StreamTxRequest, ImmuService_StreamTxServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamTx not implemented")
+}
 func (UnimplementedImmuServiceServer) ExportTx(*ExportTxRequest, ImmuService_ExportTxServer) error {
 	return status.Errorf(codes.Unimplemented, "method ExportTx not implemented")
 }
@@ -1322,6 +1395,12 @@ func (UnimplementedImmuServiceServer) VerifiableSQLGet(context.Context, *Verifia
 func (UnimplementedImmuServiceServer) TruncateDatabase(context.Context, *TruncateDatabaseRequest) (*TruncateDatabaseResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method TruncateDatabase not implemented")
 }
+func (UnimplementedImmuServiceServer) CloneDatabase(context.Context, *CloneDatabaseRequest) (*CloneDatabaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloneDatabase not implemented")
+}
+func (UnimplementedImmuServiceServer) RenameDatabase(context.Context, *RenameDatabaseRequest) (*RenameDatabaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenameDatabase not implemented")
+}
 
 // UnsafeImmuServiceServer may be embedded to opt out of forward compatibility for this service.
 // Use of this interface is not recommended, as added methods to ImmuServiceServer will
@@ -1748,6 +1827,24 @@ func _ImmuService_GetAll_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ImmuService_VerifiableGetAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifiableGetAllRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).VerifiableGetAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/VerifiableGetAll",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).VerifiableGetAll(ctx, req.(*VerifiableGetAllRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _ImmuService_ExecAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ExecAllRequest)
 	if err := dec(in); err != nil {
@@ -2507,6 +2604,27 @@ func (x *immuServiceStreamExecAllServer) Recv() (*Chunk, error) {
 	return m, nil
 }
 
+func _ImmuService_StreamTx_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamTxRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ImmuServiceServer).StreamTx(m, &immuServiceStreamTxServer{stream})
+}
+
+type ImmuService_StreamTxServer interface {
+	Send(*StreamedTx) error
+	grpc.ServerStream
+}
+
+type immuServiceStreamTxServer struct {
+	grpc.ServerStream
+}
+
+func (x *immuServiceStreamTxServer) Send(m *StreamedTx) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _ImmuService_ExportTx_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(ExportTxRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -2662,6 +2780,42 @@ func _ImmuService_TruncateDatabase_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ImmuService_CloneDatabase_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloneDatabaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).CloneDatabase(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/CloneDatabase",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).CloneDatabase(ctx, req.(*CloneDatabaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImmuService_RenameDatabase_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameDatabaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImmuServiceServer).RenameDatabase(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/immudb.schema.ImmuService/RenameDatabase",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImmuServiceServer).RenameDatabase(ctx, req.(*RenameDatabaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ImmuService_ServiceDesc is the grpc.ServiceDesc for ImmuService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -2761,6 +2915,10 @@ var ImmuService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetAll",
 			Handler:    _ImmuService_GetAll_Handler,
 		},
+		{
+			MethodName: "VerifiableGetAll",
+			Handler:    _ImmuService_VerifiableGetAll_Handler,
+		},
 		{
 			MethodName: "ExecAll",
 			Handler:    _ImmuService_ExecAll_Handler,
@@ -2913,6 +3071,14 @@ var ImmuService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "TruncateDatabase",
 			Handler:    _ImmuService_TruncateDatabase_Handler,
 		},
+		{
+			MethodName: "CloneDatabase",
+			Handler:    _ImmuService_CloneDatabase_Handler,
+		},
+		{
+			MethodName: "RenameDatabase",
+			Handler:    _ImmuService_RenameDatabase_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -2955,6 +3121,11 @@ var ImmuService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _ImmuService_StreamExecAll_Handler,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "streamTx",
+			Handler:       _ImmuService_StreamTx_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "exportTx",
 			Handler:       _ImmuService_ExportTx_Handler,
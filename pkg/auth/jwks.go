@@ -0,0 +1,264 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwksMinRefreshInterval bounds how often keys are fetched when tokens signed
+// with unknown keys are presented, e.g. right after the issuer rotated its keys
+const jwksMinRefreshInterval = time.Minute
+
+// maxJWKSSize bounds the size of the documents fetched from the identity provider
+const maxJWKSSize = 1 << 20
+
+// JWKS is the set of public keys an identity provider signs tokens with,
+// fetched from the provider and refreshed when an unknown key is requested
+type JWKS struct {
+	url    string
+	issuer string // the url is discovered from the issuer when not set
+	client *http.Client
+
+	mutex     sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time // time of the latest fetch attempt
+	fetchErr  error     // error of the latest fetch attempt
+}
+
+// NewJWKS returns the key set published at url
+func NewJWKS(url string) *JWKS {
+	return &JWKS{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NewDiscoveredJWKS returns the key set of an OpenID Connect issuer, as advertised by
+// its discovery document. The document is fetched the first time keys are needed
+func NewDiscoveredJWKS(issuer string) *JWKS {
+	jwks := NewJWKS("")
+	jwks.issuer = issuer
+	return jwks
+}
+
+func (s *JWKS) discover(ctx context.Context) error {
+	var discovery struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+
+	err := s.getJSON(ctx, strings.TrimSuffix(s.issuer, "/")+"/.well-known/openid-configuration", &discovery)
+	if err != nil {
+		return err
+	}
+
+	if discovery.JWKSURI == "" {
+		return fmt.Errorf("%w: issuer '%s' does not advertise its keys", ErrInvalidJWT, s.issuer)
+	}
+
+	s.url = discovery.JWKSURI
+
+	return nil
+}
+
+// Key returns the public key identified by kid
+func (s *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key, ok := s.keys[kid]
+	if ok {
+		return key, nil
+	}
+
+	if !s.fetchedAt.IsZero() && time.Since(s.fetchedAt) < jwksMinRefreshInterval {
+		if s.fetchErr != nil {
+			return nil, s.fetchErr
+		}
+		return nil, fmt.Errorf("%w: unknown key '%s'", ErrInvalidJWT, kid)
+	}
+
+	keys, err := s.fetch(ctx)
+	if err != nil && ctx.Err() != nil {
+		// the request was cancelled, the provider is not to blame
+		return nil, err
+	}
+
+	// failed attempts are recorded as well, so an unavailable provider
+	// is not queried on every authentication attempt
+	s.fetchedAt = time.Now()
+	s.fetchErr = err
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.keys = keys
+
+	key, ok = s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key '%s'", ErrInvalidJWT, kid)
+	}
+
+	return key, nil
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (s *JWKS) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	if s.url == "" {
+		err := s.discover(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	err := s.getJSON(ctx, s.url, &jwks)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			// keys of unsupported types are ignored
+			continue
+		}
+
+		keys[k.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (s *JWKS) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status fetching '%s': %s", url, resp.Status)
+	}
+
+	bs, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(bs, v)
+}
+
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, ErrInvalidJWT
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, ErrInvalidJWT
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, ErrInvalidJWT
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, ErrInvalidJWT
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	bs, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(bs) == 0 {
+		return nil, ErrInvalidJWT
+	}
+
+	return new(big.Int).SetBytes(bs), nil
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/errors"
+)
+
+var ErrInvalidJWT = errors.New("invalid token").WithCode(errors.CodInvalidAuthorizationSpecification)
+
+// jwtLeeway tolerates clock skews between immudb and the identity provider
+const jwtLeeway = time.Minute
+
+// permissionsByName are the permission names accepted in token claims,
+// system administration can not be granted by external identity providers
+var permissionsByName = map[string]uint32{
+	"read":      PermissionR,
+	"readwrite": PermissionRW,
+	"admin":     PermissionAdmin,
+}
+
+// KeySource provides the public keys tokens are verified with, e.g. a JWKS
+type KeySource interface {
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+// JWTVerifier authenticates users through tokens (JWT) issued by an external identity provider.
+// Permissions are taken from a claim mapping database names to permission names,
+// e.g. {"immudb_permissions": {"defaultdb": "readwrite", "reports": "read"}}
+type JWTVerifier struct {
+	issuer           string
+	audience         string
+	keys             KeySource
+	usernameClaim    string
+	permissionsClaim string
+
+	now func() time.Time
+}
+
+// NewJWTVerifier returns a verifier of the tokens issued by issuer for audience (if not empty).
+// The username is taken from usernameClaim ("sub" when empty) and permissions from
+// permissionsClaim ("immudb_permissions" when empty)
+func NewJWTVerifier(issuer, audience string, keys KeySource, usernameClaim, permissionsClaim string) *JWTVerifier {
+	if usernameClaim == "" {
+		usernameClaim = "sub"
+	}
+	if permissionsClaim == "" {
+		permissionsClaim = "immudb_permissions"
+	}
+
+	return &JWTVerifier{
+		issuer:           issuer,
+		audience:         audience,
+		keys:             keys,
+		usernameClaim:    usernameClaim,
+		permissionsClaim: permissionsClaim,
+		now:              time.Now,
+	}
+}
+
+// IsJWT returns true if s looks like a signed JWT, it's not verified
+func IsJWT(s []byte) bool {
+	pieces := strings.Split(string(s), ".")
+	if len(pieces) != 3 {
+		return false
+	}
+
+	var hdr jwtHeader
+
+	bs, err := base64.RawURLEncoding.DecodeString(pieces[0])
+	if err != nil {
+		return false
+	}
+
+	return json.Unmarshal(bs, &hdr) == nil && hdr.Alg != ""
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks the signature and claims of a token, returning the user it identifies.
+// The returned user is not stored, it only lives while the token is in use
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*User, error) {
+	pieces := strings.Split(token, ".")
+	if len(pieces) != 3 {
+		return nil, ErrInvalidJWT
+	}
+
+	var hdr jwtHeader
+
+	err := decodeJWTPiece(pieces[0], &hdr)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(pieces[2])
+	if err != nil {
+		return nil, ErrInvalidJWT
+	}
+
+	key, err := v.keys.Key(ctx, hdr.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	err = verifyJWTSignature(hdr.Alg, key, []byte(pieces[0]+"."+pieces[1]), signature)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+
+	err = decodeJWTPiece(pieces[1], &claims)
+	if err != nil {
+		return nil, err
+	}
+
+	err = v.validateClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	username, _ := claims[v.usernameClaim].(string)
+	if username == "" || username == SysAdminUsername {
+		return nil, fmt.Errorf("%w: invalid '%s' claim", ErrInvalidJWT, v.usernameClaim)
+	}
+
+	user := &User{
+		Username:  username,
+		Active:    true,
+		CreatedBy: v.issuer,
+	}
+
+	perms, _ := claims[v.permissionsClaim].(map[string]interface{})
+
+	for db, p := range perms {
+		name, _ := p.(string)
+
+		permission, ok := permissionsByName[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown permission '%v' on database '%s'", ErrInvalidJWT, p, db)
+		}
+
+		user.GrantPermission(db, permission)
+	}
+
+	return user, nil
+}
+
+func (v *JWTVerifier) validateClaims(claims map[string]interface{}) error {
+	if iss, _ := claims["iss"].(string); iss != v.issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrInvalidJWT)
+	}
+
+	if v.audience != "" && !hasAudience(claims["aud"], v.audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidJWT)
+	}
+
+	now := v.now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("%w: missing expiration", ErrInvalidJWT)
+	}
+	if now.Add(-jwtLeeway).After(time.Unix(int64(exp), 0)) {
+		return fmt.Errorf("%w: token has expired", ErrInvalidJWT)
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(jwtLeeway).Before(time.Unix(int64(nbf), 0)) {
+		return fmt.Errorf("%w: token not yet valid", ErrInvalidJWT)
+	}
+
+	return nil
+}
+
+func hasAudience(aud interface{}, audience string) bool {
+	switch a := aud.(type) {
+	case string:
+		return a == audience
+	case []interface{}:
+		for _, e := range a {
+			if e == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeJWTPiece(piece string, v interface{}) error {
+	bs, err := base64.RawURLEncoding.DecodeString(piece)
+	if err != nil {
+		return ErrInvalidJWT
+	}
+
+	err = json.Unmarshal(bs, v)
+	if err != nil {
+		return ErrInvalidJWT
+	}
+
+	return nil
+}
+
+func verifyJWTSignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	if len(alg) != len("RS256") {
+		return fmt.Errorf("%w: unsupported algorithm '%s'", ErrInvalidJWT, alg)
+	}
+
+	var h hash.Hash
+	var hashFunc crypto.Hash
+
+	switch alg[2:] {
+	case "256":
+		h, hashFunc = sha256.New(), crypto.SHA256
+	case "384":
+		h, hashFunc = sha512.New384(), crypto.SHA384
+	case "512":
+		h, hashFunc = sha512.New(), crypto.SHA512
+	default:
+		return fmt.Errorf("%w: unsupported algorithm '%s'", ErrInvalidJWT, alg)
+	}
+
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			break
+		}
+		if rsa.VerifyPKCS1v15(k, hashFunc, digest, signature) != nil {
+			return fmt.Errorf("%w: invalid signature", ErrInvalidJWT)
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") {
+			break
+		}
+
+		// signatures are the concatenation of r and s, each as long as the curve size
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("%w: invalid signature", ErrInvalidJWT)
+		}
+
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+
+		if !ecdsa.Verify(k, digest, r, s) {
+			return fmt.Errorf("%w: invalid signature", ErrInvalidJWT)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w: unsupported algorithm '%s'", ErrInvalidJWT, alg)
+}
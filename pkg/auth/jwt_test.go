@@ -0,0 +1,261 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testIdentityProvider struct {
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+	server *httptest.Server
+}
+
+func newTestIdentityProvider(t *testing.T) *testIdentityProvider {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	idp := &testIdentityProvider{rsaKey: rsaKey, ecKey: ecKey}
+
+	enc := base64.RawURLEncoding
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"jwks_uri": idp.server.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": "rsa",
+				"use": "sig",
+				"n":   enc.EncodeToString(rsaKey.N.Bytes()),
+				"e":   enc.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+			},
+			{
+				"kty": "EC",
+				"kid": "ec",
+				"crv": "P-256",
+				"x":   enc.EncodeToString(ecKey.X.Bytes()),
+				"y":   enc.EncodeToString(ecKey.Y.Bytes()),
+			},
+			{
+				"kty": "oct",
+				"kid": "unsupported",
+			},
+		}})
+	})
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+func (idp *testIdentityProvider) token(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	enc := base64.RawURLEncoding
+
+	hdr, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signed := enc.EncodeToString(hdr) + "." + enc.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+
+	switch alg {
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, idp.rsaKey, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, idp.ecKey, digest[:])
+		require.NoError(t, err)
+
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+
+	return signed + "." + enc.EncodeToString(signature)
+}
+
+func (idp *testIdentityProvider) claims(overrides map[string]interface{}) map[string]interface{} {
+	claims := map[string]interface{}{
+		"iss": idp.server.URL,
+		"aud": []string{"immudb"},
+		"sub": "alice",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"immudb_permissions": map[string]string{
+			"defaultdb": "readwrite",
+			"reports":   "read",
+		},
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(claims, k)
+		} else {
+			claims[k] = v
+		}
+	}
+	return claims
+}
+
+func TestJWTVerifier(t *testing.T) {
+	idp := newTestIdentityProvider(t)
+
+	v := NewJWTVerifier(idp.server.URL, "immudb", NewDiscoveredJWKS(idp.server.URL), "", "")
+
+	for _, alg := range []string{"RS256", "ES256"} {
+		t.Run("valid "+alg+" token", func(t *testing.T) {
+			kid := "rsa"
+			if alg == "ES256" {
+				kid = "ec"
+			}
+
+			token := idp.token(t, alg, kid, idp.claims(nil))
+			require.True(t, IsJWT([]byte(token)))
+
+			user, err := v.Verify(context.Background(), token)
+			require.NoError(t, err)
+			require.Equal(t, "alice", user.Username)
+			require.True(t, user.Active)
+			require.False(t, user.IsSysAdmin)
+			require.True(t, user.HasPermission("defaultdb", PermissionRW))
+			require.True(t, user.HasPermission("reports", PermissionR))
+			require.False(t, user.HasPermission("reports", PermissionRW))
+		})
+	}
+
+	t.Run("invalid tokens", func(t *testing.T) {
+		for name, token := range map[string]string{
+			"malformed":          "not.a.jwt",
+			"unknown key":        idp.token(t, "RS256", "missing", idp.claims(nil)),
+			"unsupported key":    idp.token(t, "RS256", "unsupported", idp.claims(nil)),
+			"algorithm mismatch": idp.token(t, "ES256", "rsa", idp.claims(nil)),
+			"unsupported alg":    idp.token(t, "none", "rsa", idp.claims(nil)),
+			"wrong issuer":       idp.token(t, "RS256", "rsa", idp.claims(map[string]interface{}{"iss": "other"})),
+			"wrong audience":     idp.token(t, "RS256", "rsa", idp.claims(map[string]interface{}{"aud": "other"})),
+			"expired":            idp.token(t, "RS256", "rsa", idp.claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})),
+			"no expiration":      idp.token(t, "RS256", "rsa", idp.claims(map[string]interface{}{"exp": nil})),
+			"not yet valid":      idp.token(t, "RS256", "rsa", idp.claims(map[string]interface{}{"nbf": time.Now().Add(time.Hour).Unix()})),
+			"no username":        idp.token(t, "RS256", "rsa", idp.claims(map[string]interface{}{"sub": nil})),
+			"sysadmin username":  idp.token(t, "RS256", "rsa", idp.claims(map[string]interface{}{"sub": SysAdminUsername})),
+			"unknown permission": idp.token(t, "RS256", "rsa", idp.claims(map[string]interface{}{"immudb_permissions": map[string]string{"defaultdb": "sysadmin"}})),
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := v.Verify(context.Background(), token)
+				require.ErrorIs(t, err, ErrInvalidJWT)
+			})
+		}
+
+		token := idp.token(t, "RS256", "rsa", idp.claims(nil))
+		_, err := v.Verify(context.Background(), token[:len(token)-4]+"AAAA")
+		require.ErrorIs(t, err, ErrInvalidJWT)
+	})
+
+	t.Run("custom claims", func(t *testing.T) {
+		v := NewJWTVerifier(idp.server.URL, "", NewJWKS(idp.server.URL+"/jwks"), "email", "roles")
+
+		user, err := v.Verify(context.Background(), idp.token(t, "ES256", "ec", idp.claims(map[string]interface{}{
+			"aud":   "any",
+			"email": "bob@example.com",
+			"roles": map[string]string{"defaultdb": "admin"},
+		})))
+		require.NoError(t, err)
+		require.Equal(t, "bob@example.com", user.Username)
+		require.True(t, user.HasPermission("defaultdb", PermissionAdmin))
+	})
+}
+
+func TestIsJWT(t *testing.T) {
+	require.False(t, IsJWT([]byte("password")))
+	require.False(t, IsJWT([]byte("a.b.c")))
+	require.False(t, IsJWT([]byte(base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT"}`))+".b.c")))
+	require.True(t, IsJWT([]byte(base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256"}`))+".b.c")))
+}
+
+func TestDiscoveredJWKSFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	_, err := NewDiscoveredJWKS(server.URL).Key(context.Background(), "kid")
+	require.ErrorIs(t, err, ErrInvalidJWT)
+
+	server.Close()
+
+	_, err = NewDiscoveredJWKS(server.URL).Key(context.Background(), "kid")
+	require.Error(t, err)
+}
+
+func TestJWKSRefreshBackoff(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	jwks := NewJWKS(server.URL)
+
+	_, err := jwks.Key(context.Background(), "kid")
+	require.Error(t, err)
+
+	_, err2 := jwks.Key(context.Background(), "kid")
+	require.Equal(t, err, err2)
+	require.EqualValues(t, 1, atomic.LoadInt32(&requests))
+
+	jwks.fetchedAt = time.Now().Add(-jwksMinRefreshInterval)
+
+	_, err = jwks.Key(context.Background(), "kid")
+	require.Error(t, err)
+	require.EqualValues(t, 2, atomic.LoadInt32(&requests))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	jwks.fetchedAt = time.Time{}
+
+	_, err = jwks.Key(ctx, "kid")
+	require.Error(t, err)
+	require.True(t, jwks.fetchedAt.IsZero())
+}
@@ -32,6 +32,7 @@ var maintenanceMethods = map[string]struct{}{
 	"StreamHistory":       {},
 	"TxByID":              {},
 	"TxScan":              {},
+	"StreamTx":            {},
 	"ExportTx":            {},
 	"ReplicateTx":         {},
 	"Count":               {},
@@ -73,6 +74,7 @@ var methodsPermissions = map[string][]uint32{
 	"StreamVerifiableSet":    {PermissionSysAdmin, PermissionAdmin, PermissionRW},
 	"Get":                    {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"VerifiableGet":          {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"VerifiableGetAll":       {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"StreamGet":              {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"StreamVerifiableGet":    {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"GetAll":                 {PermissionSysAdmin, PermissionAdmin, PermissionRW},
@@ -92,6 +94,7 @@ var methodsPermissions = map[string][]uint32{
 	"StreamHistory":          {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"TxByID":                 {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"TxScan":                 {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
+	"StreamTx":               {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"Count":                  {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"CountAll":               {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
 	"DatabaseList":           {PermissionSysAdmin, PermissionAdmin, PermissionRW, PermissionR},
@@ -0,0 +1,295 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+)
+
+var (
+	ErrIllegalArguments        = errors.New("illegal arguments")
+	ErrNothingToBackup         = errors.New("no new transactions to backup")
+	ErrMissingBackup           = errors.New("missing backup")
+	ErrSchedulerAlreadyRunning = errors.New("backup scheduler already running")
+	ErrSchedulerAlreadyStopped = errors.New("backup scheduler already stopped")
+)
+
+// lastBackedUpTx returns the last transaction included in the backup files of a database
+func lastBackedUpTx(ctx context.Context, target Target, db string) (uint64, error) {
+	names, err := target.List(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+
+	var lastTx uint64
+
+	for _, name := range names {
+		_, toTx, ok := parseBackupFileName(name)
+		if ok && toTx > lastTx {
+			lastTx = toTx
+		}
+	}
+
+	return lastTx, nil
+}
+
+// Backup writes the transactions committed since the latest backup of the database
+// into a new backup file. The first backup of a database holds all of its transactions,
+// thus working as the base the following incremental backups are applied on top of.
+// The range of transactions included in the new backup file is returned
+func Backup(ctx context.Context, db database.DB, target Target) (fromTx, toTx uint64, err error) {
+	if db == nil || target == nil {
+		return 0, 0, ErrIllegalArguments
+	}
+
+	lastTx, err := lastBackedUpTx(ctx, target, db.GetName())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	state, err := db.CurrentState()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if state.TxId <= lastTx {
+		return 0, 0, ErrNothingToBackup
+	}
+
+	fromTx = lastTx + 1
+	toTx = state.TxId
+
+	f, err := ioutil.TempFile("", "immudb-backup-")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer os.Remove(f.Name())
+
+	err = db.ExportTxRange(fromTx, toTx, f)
+
+	cerr := f.Close()
+	if err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	err = target.Put(ctx, db.GetName(), backupFileName(fromTx, toTx), f.Name())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return fromTx, toTx, nil
+}
+
+// Restore replays the backup files of the database sourceDB into db, the base backup first
+// followed by the incremental ones, stopping right before the first transaction for which
+// stop returns true (when provided). Transactions are replicated, so the linear hash linkage
+// of every replayed transaction is verified against the already restored ones.
+// The database must be a replica and restoring continues from its latest transaction,
+// which must be the last one of a backup file (or none, when restoring from scratch).
+// The last restored transaction is returned, nil if none was restored
+func Restore(ctx context.Context, db database.DB, target Target, sourceDB string, stop func(hdr *store.TxHeader) bool) (*schema.TxHeader, error) {
+	if db == nil || target == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	if !db.IsReplica() {
+		return nil, database.ErrNotReplica
+	}
+
+	names, err := target.List(ctx, sourceDB)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := db.CurrentState()
+	if err != nil {
+		return nil, err
+	}
+
+	nextTx := state.TxId + 1
+
+	var lastHdr *schema.TxHeader
+
+	for _, name := range names {
+		fromTx, toTx, ok := parseBackupFileName(name)
+		if !ok || toTx < nextTx {
+			continue
+		}
+
+		if fromTx != nextTx {
+			return lastHdr, fmt.Errorf("%w: no backup file of database '%s' starts at tx %d", ErrMissingBackup, sourceDB, nextTx)
+		}
+
+		hdr, err := restoreFile(ctx, db, target, sourceDB, name, stop)
+		if hdr != nil {
+			lastHdr = hdr
+			nextTx = hdr.Id + 1
+		}
+		if err != nil {
+			return lastHdr, err
+		}
+
+		if nextTx <= toTx {
+			// stopped before the end of the backup file
+			return lastHdr, nil
+		}
+	}
+
+	return lastHdr, nil
+}
+
+func restoreFile(ctx context.Context, db database.DB, target Target, sourceDB, name string, stop func(hdr *store.TxHeader) bool) (*schema.TxHeader, error) {
+	r, err := target.Open(ctx, sourceDB, name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return db.ImportTxRange(ctx, r, stop)
+}
+
+// UntilTx returns a stop condition restoring transactions up to txID (included)
+func UntilTx(txID uint64) func(hdr *store.TxHeader) bool {
+	return func(hdr *store.TxHeader) bool {
+		return hdr.ID > txID
+	}
+}
+
+// UntilTime returns a stop condition restoring transactions committed up to ts (included)
+func UntilTime(ts time.Time) func(hdr *store.TxHeader) bool {
+	return func(hdr *store.TxHeader) bool {
+		return hdr.Ts > ts.Unix()
+	}
+}
+
+// Scheduler periodically takes incremental backups of a set of databases
+type Scheduler struct {
+	mu sync.Mutex
+
+	backupMutex sync.Mutex // serializes backups so ranges are never backed up twice
+
+	hasStarted bool
+
+	target    Target
+	interval  time.Duration
+	databases func() []database.DB
+
+	logger logger.Logger
+
+	donech chan struct{}
+	stopch chan struct{}
+}
+
+func NewScheduler(target Target, interval time.Duration, databases func() []database.DB, logger logger.Logger) *Scheduler {
+	return &Scheduler{
+		target:    target,
+		interval:  interval,
+		databases: databases,
+		logger:    logger,
+		donech:    make(chan struct{}),
+		stopch:    make(chan struct{}),
+	}
+}
+
+// Target returns where backup files are written to
+func (s *Scheduler) Target() Target {
+	return s.target
+}
+
+func (s *Scheduler) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hasStarted {
+		return ErrSchedulerAlreadyRunning
+	}
+
+	if s.interval <= 0 {
+		return ErrIllegalArguments
+	}
+
+	s.hasStarted = true
+	s.logger.Infof("starting backup scheduler with interval '%vs'", s.interval.Seconds())
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		for {
+			select {
+			case <-s.stopch:
+				ticker.Stop()
+				s.donech <- struct{}{}
+				return
+			case <-ticker.C:
+				for _, db := range s.databases() {
+					s.Backup(context.Background(), db)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Backup takes an incremental backup of a database
+func (s *Scheduler) Backup(ctx context.Context, db database.DB) error {
+	s.backupMutex.Lock()
+	defer s.backupMutex.Unlock()
+
+	fromTx, toTx, err := Backup(ctx, db, s.target)
+	if errors.Is(err, ErrNothingToBackup) {
+		return err
+	}
+	if err != nil {
+		s.logger.Errorf("failed to backup database '%s' {err = %v}", db.GetName(), err)
+		return err
+	}
+
+	s.logger.Infof("database '%s' backed up {fromTx = %d, toTx = %d}", db.GetName(), fromTx, toTx)
+
+	return nil
+}
+
+func (s *Scheduler) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.hasStarted {
+		return ErrSchedulerAlreadyStopped
+	}
+
+	s.logger.Infof("Stopping backup scheduler...")
+	s.stopch <- struct{}{}
+	<-s.donech
+	s.hasStarted = false
+	s.logger.Infof("Backup scheduler successfully stopped")
+
+	return nil
+}
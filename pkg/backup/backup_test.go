@@ -0,0 +1,256 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/remotestorage/memory"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/codenotary/immudb/pkg/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func makeDbWith(t *testing.T, dbName string, opts *database.Options) database.DB {
+	d, err := database.NewDB(dbName, nil, opts, logger.NewSimpleLogger("immudb ", os.Stderr))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err := d.Close()
+		if !t.Failed() {
+			require.NoError(t, err)
+		}
+	})
+
+	return d
+}
+
+func makePrimaryDb(t *testing.T) database.DB {
+	return makeDbWith(t, "db", database.DefaultOption().WithDBRootPath(t.TempDir()).WithCorruptionChecker(false))
+}
+
+func makeReplicaDb(t *testing.T) database.DB {
+	return makeDbWith(t, "restored", database.DefaultOption().WithDBRootPath(t.TempDir()).WithCorruptionChecker(false).AsReplica(true))
+}
+
+func setKeys(t *testing.T, db database.DB, from, to int) {
+	for i := from; i <= to; i++ {
+		_, err := db.Set(context.Background(), &schema.SetRequest{KVs: []*schema.KeyValue{{
+			Key:   []byte(fmt.Sprintf("key_%d", i)),
+			Value: []byte(fmt.Sprintf("val_%d", i)),
+		}}})
+		require.NoError(t, err)
+	}
+}
+
+func requireSameState(t *testing.T, expected, actual database.DB, txID uint64) {
+	hdr, err := expected.TxByID(context.Background(), &schema.TxRequest{Tx: txID})
+	require.NoError(t, err)
+
+	state, err := actual.CurrentState()
+	require.NoError(t, err)
+	require.Equal(t, txID, state.TxId)
+
+	expectedHdr := schema.TxHeaderFromProto(hdr.Header)
+	alh := expectedHdr.Alh()
+	require.Equal(t, alh[:], state.TxHash)
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	targets := map[string]func(t *testing.T) Target{
+		"dir": func(t *testing.T) Target {
+			return NewDirTarget(t.TempDir())
+		},
+		"remote": func(t *testing.T) Target {
+			return NewRemoteTarget(memory.Open())
+		},
+	}
+
+	for name, newTarget := range targets {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			target := newTarget(t)
+			db := makePrimaryDb(t)
+
+			setKeys(t, db, 1, 10)
+
+			fromTx, baseTx, err := Backup(ctx, db, target)
+			require.NoError(t, err)
+			require.Equal(t, uint64(1), fromTx)
+
+			_, _, err = Backup(ctx, db, target)
+			require.ErrorIs(t, err, ErrNothingToBackup)
+
+			setKeys(t, db, 11, 15)
+
+			fromTx, lastTx, err := Backup(ctx, db, target)
+			require.NoError(t, err)
+			require.Equal(t, baseTx+1, fromTx)
+			require.Equal(t, baseTx+5, lastTx)
+
+			names, err := target.List(ctx, "db")
+			require.NoError(t, err)
+			require.Equal(t, []string{backupFileName(1, baseTx), backupFileName(baseTx+1, lastTx)}, names)
+
+			t.Run("restoring requires a replica", func(t *testing.T) {
+				_, err := Restore(ctx, makePrimaryDb(t), target, "db", nil)
+				require.ErrorIs(t, err, database.ErrNotReplica)
+			})
+
+			t.Run("restoring up to a transaction", func(t *testing.T) {
+				restored := makeReplicaDb(t)
+
+				hdr, err := Restore(ctx, restored, target, "db", UntilTx(baseTx+2))
+				require.NoError(t, err)
+				require.Equal(t, baseTx+2, hdr.Id)
+				requireSameState(t, db, restored, baseTx+2)
+
+				// restoring can only continue from the end of a backup file
+				_, err = Restore(ctx, restored, target, "db", nil)
+				require.ErrorIs(t, err, ErrMissingBackup)
+			})
+
+			t.Run("restoring the base backup and resuming afterwards", func(t *testing.T) {
+				restored := makeReplicaDb(t)
+
+				hdr, err := Restore(ctx, restored, target, "db", UntilTx(baseTx))
+				require.NoError(t, err)
+				require.Equal(t, baseTx, hdr.Id)
+				requireSameState(t, db, restored, baseTx)
+
+				hdr, err = Restore(ctx, restored, target, "db", nil)
+				require.NoError(t, err)
+				require.Equal(t, lastTx, hdr.Id)
+				requireSameState(t, db, restored, lastTx)
+
+				hdr, err = Restore(ctx, restored, target, "db", nil)
+				require.NoError(t, err)
+				require.Nil(t, hdr)
+			})
+
+			t.Run("restoring up to a point in time", func(t *testing.T) {
+				restored := makeReplicaDb(t)
+
+				hdr, err := Restore(ctx, restored, target, "db", UntilTime(time.Unix(0, 0)))
+				require.NoError(t, err)
+				require.Nil(t, hdr)
+
+				hdr, err = Restore(ctx, restored, target, "db", UntilTime(time.Now().Add(time.Hour)))
+				require.NoError(t, err)
+				require.Equal(t, lastTx, hdr.Id)
+				requireSameState(t, db, restored, lastTx)
+			})
+
+			t.Run("restoring should fail if a backup is missing", func(t *testing.T) {
+				// nothing to restore from an unknown database
+				hdr, err := Restore(ctx, makeReplicaDb(t), target, "unknown", nil)
+				require.NoError(t, err)
+				require.Nil(t, hdr)
+
+				// only the incremental backup is available
+				incrementalTarget := NewRemoteTarget(memory.Open())
+
+				f, err := ioutil.TempFile(t.TempDir(), "backup")
+				require.NoError(t, err)
+				defer f.Close()
+
+				err = db.ExportTxRange(baseTx+1, lastTx, f)
+				require.NoError(t, err)
+
+				err = incrementalTarget.Put(ctx, "db", backupFileName(baseTx+1, lastTx), f.Name())
+				require.NoError(t, err)
+
+				_, err = Restore(ctx, makeReplicaDb(t), incrementalTarget, "db", nil)
+				require.ErrorIs(t, err, ErrMissingBackup)
+			})
+		})
+	}
+}
+
+func TestDirTargetIgnoresPartialFiles(t *testing.T) {
+	dir := t.TempDir()
+	target := NewDirTarget(dir)
+
+	names, err := target.List(context.Background(), "db")
+	require.NoError(t, err)
+	require.Empty(t, names)
+
+	err = target.Put(context.Background(), "db", "file.txs", filepath.Join(dir, "missing"))
+	require.Error(t, err)
+
+	names, err = target.List(context.Background(), "db")
+	require.NoError(t, err)
+	require.Empty(t, names)
+}
+
+func TestParseBackupFileName(t *testing.T) {
+	fromTx, toTx, ok := parseBackupFileName(backupFileName(3, 7))
+	require.True(t, ok)
+	require.Equal(t, uint64(3), fromTx)
+	require.Equal(t, uint64(7), toTx)
+
+	for _, name := range []string{"", "1-2", "a-2.txs", "1-b.txs", "1-2-3.txs", "0-2.txs", "3-2.txs"} {
+		_, _, ok := parseBackupFileName(name)
+		require.False(t, ok, name)
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	db := makePrimaryDb(t)
+	setKeys(t, db, 1, 5)
+
+	target := NewRemoteTarget(memory.Open())
+
+	s := NewScheduler(target, 10*time.Millisecond, func() []database.DB {
+		return []database.DB{db}
+	}, logger.NewSimpleLogger("immudb ", os.Stderr))
+
+	require.Equal(t, target, s.Target())
+
+	err := s.Stop()
+	require.ErrorIs(t, err, ErrSchedulerAlreadyStopped)
+
+	err = s.Start()
+	require.NoError(t, err)
+
+	err = s.Start()
+	require.ErrorIs(t, err, ErrSchedulerAlreadyRunning)
+
+	state, err := db.CurrentState()
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		lastTx, err := lastBackedUpTx(context.Background(), target, "db")
+		return err == nil && lastTx == state.TxId
+	}, 5*time.Second, 10*time.Millisecond)
+
+	err = s.Stop()
+	require.NoError(t, err)
+
+	err = s.Backup(context.Background(), db)
+	require.ErrorIs(t, err, ErrNothingToBackup)
+
+	err = NewScheduler(target, 0, nil, logger.NewSimpleLogger("immudb ", os.Stderr)).Start()
+	require.ErrorIs(t, err, ErrIllegalArguments)
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/remotestorage"
+	"github.com/codenotary/immudb/embedded/store"
+)
+
+const backupFileExt = ".txs"
+
+// Target is where backup files are written to, backup files are grouped by database
+type Target interface {
+	// List returns the names of the backup files of a database, sorted alphabetically
+	List(ctx context.Context, db string) ([]string, error)
+
+	// Put saves a local file as a backup file of a database
+	Put(ctx context.Context, db, name, fileName string) error
+
+	// Open reads a backup file of a database
+	Open(ctx context.Context, db, name string) (io.ReadCloser, error)
+}
+
+// backupFileName returns the name of the file holding transactions fromTx to toTx.
+// Names are zero padded so sorting them alphabetically sorts them by transaction
+func backupFileName(fromTx, toTx uint64) string {
+	return fmt.Sprintf("%020d-%020d%s", fromTx, toTx, backupFileExt)
+}
+
+func parseBackupFileName(name string) (fromTx, toTx uint64, ok bool) {
+	if !strings.HasSuffix(name, backupFileExt) {
+		return 0, 0, false
+	}
+
+	txs := strings.Split(strings.TrimSuffix(name, backupFileExt), "-")
+	if len(txs) != 2 {
+		return 0, 0, false
+	}
+
+	fromTx, err := strconv.ParseUint(txs[0], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	toTx, err = strconv.ParseUint(txs[1], 10, 64)
+	if err != nil || fromTx == 0 || fromTx > toTx {
+		return 0, 0, false
+	}
+
+	return fromTx, toTx, true
+}
+
+type dirTarget struct {
+	dir string
+}
+
+// NewDirTarget returns a target writing backup files into a local directory,
+// e.g. a mounted network volume
+func NewDirTarget(dir string) Target {
+	return &dirTarget{dir: dir}
+}
+
+func (t *dirTarget) List(ctx context.Context, db string) ([]string, error) {
+	files, err := ioutil.ReadDir(filepath.Join(t.dir, db))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+
+	for _, f := range files {
+		if !f.IsDir() {
+			names = append(names, f.Name())
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (t *dirTarget) Put(ctx context.Context, db, name, fileName string) error {
+	dbDir := filepath.Join(t.dir, db)
+
+	err := os.MkdirAll(dbDir, store.DefaultFileMode)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// the file is copied under a temporary name so partially written files are never listed
+	dst, err := ioutil.TempFile(dbDir, "."+name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dst, src)
+	if err == nil {
+		err = dst.Sync()
+	}
+
+	cerr := dst.Close()
+	if err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Rename(dst.Name(), filepath.Join(dbDir, name))
+	}
+
+	if err != nil {
+		os.Remove(dst.Name())
+		return err
+	}
+
+	return nil
+}
+
+func (t *dirTarget) Open(ctx context.Context, db, name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(t.dir, db, name))
+}
+
+type remoteTarget struct {
+	storage remotestorage.Storage
+}
+
+// NewRemoteTarget returns a target writing backup files into a remote storage, e.g. s3
+func NewRemoteTarget(storage remotestorage.Storage) Target {
+	return &remoteTarget{storage: storage}
+}
+
+func (t *remoteTarget) List(ctx context.Context, db string) ([]string, error) {
+	entries, _, err := t.storage.ListEntries(ctx, db+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(entries))
+
+	for i, e := range entries {
+		names[i] = e.Name
+	}
+
+	return names, nil
+}
+
+func (t *remoteTarget) Put(ctx context.Context, db, name, fileName string) error {
+	return t.storage.Put(ctx, db+"/"+name, fileName)
+}
+
+func (t *remoteTarget) Open(ctx context.Context, db, name string) (io.ReadCloser, error) {
+	return t.storage.Get(ctx, db+"/"+name, 0, -1)
+}
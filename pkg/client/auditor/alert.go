@@ -0,0 +1,58 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditor
+
+import (
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+)
+
+// AlertReason describes the tampering evidence an alert is raised for
+type AlertReason string
+
+const (
+	// AlertConsistencyNotVerified the current state of the database is not consistent with the previously audited one
+	AlertConsistencyNotVerified AlertReason = "consistency not verified"
+	// AlertDatabaseEmptied the database is empty while a previously audited state exists
+	AlertDatabaseEmptied AlertReason = "database emptied"
+	// AlertInvalidSignature the state received from the server is not properly signed
+	AlertInvalidSignature AlertReason = "invalid state signature"
+)
+
+// Alert holds the details of a tampering evidence found during an audit
+type Alert struct {
+	Reason        AlertReason
+	ServerID      string
+	ServerAddress string
+	Database      string
+	RunAt         time.Time
+	PreviousState *schema.ImmutableState
+	CurrentState  *schema.ImmutableState
+	Err           error
+}
+
+func (a *defaultAuditor) alert(alert *Alert) {
+	if a.notificationConfig.AlertFunc == nil {
+		return
+	}
+
+	alert.ServerAddress = a.serverAddress
+	alert.RunAt = time.Now()
+
+	a.notificationConfig.AlertFunc(alert)
+}
@@ -50,7 +50,7 @@ type Auditor interface {
 }
 
 // AuditNotificationConfig holds the URL and credentials used to publish audit
-// result to ledger compliance.
+// result to ledger compliance, and the callback invoked on tampering evidence.
 type AuditNotificationConfig struct {
 	URL            string
 	Username       string
@@ -58,6 +58,9 @@ type AuditNotificationConfig struct {
 	RequestTimeout time.Duration
 
 	PublishFunc func(*http.Request) (*http.Response, error)
+
+	// AlertFunc, if set, is called synchronously for every tampering evidence found
+	AlertFunc func(*Alert)
 }
 
 type defaultAuditor struct {
@@ -272,6 +275,13 @@ func (a *defaultAuditor) audit() error {
 
 	if err := a.verifyStateSignature(serverID, state); err != nil {
 		a.logger.Errorf("audit #%d aborted: %v", a.index, err)
+		a.alert(&Alert{
+			Reason:       AlertInvalidSignature,
+			ServerID:     serverID,
+			Database:     dbName,
+			CurrentState: state,
+			Err:          err,
+		})
 		withError = true
 		return noErr
 	}
@@ -292,6 +302,13 @@ func (a *defaultAuditor) audit() error {
 				"audit #%d aborted: database is empty on server %s @ %s, "+
 					"but locally a previous state exists with hash %x at id %d",
 				a.index, serverID, a.serverAddress, prevState.TxHash, prevState.TxId)
+			a.alert(&Alert{
+				Reason:        AlertDatabaseEmptied,
+				ServerID:      serverID,
+				Database:      dbName,
+				PreviousState: prevState,
+				CurrentState:  state,
+			})
 			withError = true
 			return noErr
 		}
@@ -377,6 +394,13 @@ func (a *defaultAuditor) audit() error {
 			"audit #%d detected possible tampering of db %s remote state (at id %d) "+
 				"so it will not overwrite the previous local state (at id %d)",
 			a.index, dbName, state.TxId, prevState.TxId)
+		a.alert(&Alert{
+			Reason:        AlertConsistencyNotVerified,
+			ServerID:      serverID,
+			Database:      dbName,
+			PreviousState: prevState,
+			CurrentState:  state,
+		})
 	} else if prevState == nil || state.TxId != prevState.TxId {
 		if err := a.history.Set(serverID, dbName, state); err != nil {
 			a.logger.Errorf(err.Error())
@@ -445,3 +445,102 @@ func TestPublishAuditNotification(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "invalid control character in URL")
 }
+
+type uuidProviderMock string
+
+func (p uuidProviderMock) CurrentUUID(ctx context.Context) (string, error) {
+	return string(p), nil
+}
+
+func TestDefaultAuditorAlertOnEmptiedDatabase(t *testing.T) {
+	defer os.RemoveAll(dirname)
+
+	serviceClient := &clienttest.ImmuServiceClientMock{
+		LoginF: func(ctx context.Context, in *schema.LoginRequest, opts ...grpc.CallOption) (*schema.LoginResponse, error) {
+			return &schema.LoginResponse{Token: ""}, nil
+		},
+		LogoutF: func(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error) {
+			return new(empty.Empty), nil
+		},
+		DatabaseListF: func(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*schema.DatabaseListResponse, error) {
+			return &schema.DatabaseListResponse{
+				Databases: []*schema.Database{{DatabaseName: "someDB"}},
+			}, nil
+		},
+		UseDatabaseF: func(ctx context.Context, in *schema.Database, opts ...grpc.CallOption) (*schema.UseDatabaseReply, error) {
+			return &schema.UseDatabaseReply{Token: ""}, nil
+		},
+		CurrentStateF: func(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*schema.ImmutableState, error) {
+			return &schema.ImmutableState{Db: "someDB"}, nil
+		},
+	}
+
+	history := cache.NewHistoryFileCache(dirname)
+	prevState := &schema.ImmutableState{Db: "someDB", TxId: 3, TxHash: []byte{3}}
+	err := history.Set("server1", "someDB", prevState)
+	require.NoError(t, err)
+
+	var alerts []*Alert
+
+	da, err := DefaultAuditor(
+		time.Duration(0),
+		fmt.Sprintf("%s:%d", "address", 0),
+		[]grpc.DialOption{
+			grpc.WithInsecure(),
+		},
+		"immudb",
+		"immudb",
+		nil,
+		nil,
+		AuditNotificationConfig{
+			AlertFunc: func(alert *Alert) { alerts = append(alerts, alert) },
+		},
+		serviceClient,
+		uuidProviderMock("server1"),
+		history,
+		func(string, string, bool, bool, bool, *schema.ImmutableState, *schema.ImmutableState) {},
+		logger.NewSimpleLogger("test", os.Stdout),
+		nil)
+	require.NoError(t, err)
+
+	err = da.(*defaultAuditor).audit()
+	require.NoError(t, err)
+
+	require.Len(t, alerts, 1)
+	require.Equal(t, AlertDatabaseEmptied, alerts[0].Reason)
+	require.Equal(t, "server1", alerts[0].ServerID)
+	require.Equal(t, "address:0", alerts[0].ServerAddress)
+	require.Equal(t, "someDB", alerts[0].Database)
+	require.Equal(t, prevState.TxId, alerts[0].PreviousState.TxId)
+	require.Zero(t, alerts[0].CurrentState.TxId)
+}
+
+type auditorMock struct {
+	runs int
+	err  error
+}
+
+func (a *auditorMock) Run(interval time.Duration, singleRun bool, stopc <-chan struct{}, donec chan<- struct{}) error {
+	defer func() { donec <- struct{}{} }()
+	a.runs++
+	return a.err
+}
+
+func TestMultiAuditor(t *testing.T) {
+	a1 := &auditorMock{}
+	a2 := &auditorMock{}
+
+	donec := make(chan struct{}, 1)
+
+	err := NewMultiAuditor(a1, a2).Run(time.Second, true, nil, donec)
+	require.NoError(t, err)
+	require.Len(t, donec, 1)
+	require.Equal(t, 1, a1.runs)
+	require.Equal(t, 1, a2.runs)
+
+	a2.err = errors.New("some auditor error")
+
+	err = NewMultiAuditor(a1, a2).Run(time.Second, true, nil, make(chan struct{}, 1))
+	require.ErrorIs(t, err, a2.err)
+	require.Equal(t, 2, a1.runs)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditor
+
+import (
+	"sync"
+	"time"
+)
+
+type multiAuditor struct {
+	auditors []Auditor
+}
+
+// NewMultiAuditor returns an auditor running the given auditors concurrently,
+// typically one per audited server. stopc must be closed to stop all of them.
+func NewMultiAuditor(auditors ...Auditor) Auditor {
+	return &multiAuditor{auditors: auditors}
+}
+
+func (m *multiAuditor) Run(
+	interval time.Duration,
+	singleRun bool,
+	stopc <-chan struct{},
+	donec chan<- struct{},
+) error {
+	defer func() { donec <- struct{}{} }()
+
+	errs := make([]error, len(m.auditors))
+
+	var wg sync.WaitGroup
+
+	for i, a := range m.auditors {
+		wg.Add(1)
+
+		go func(i int, a Auditor) {
+			defer wg.Done()
+
+			errs[i] = a.Run(interval, singleRun, stopc, make(chan struct{}, 1))
+		}(i, a)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
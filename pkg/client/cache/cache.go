@@ -41,6 +41,10 @@ type Cache interface {
 	ServerIdentityCheck(serverIdentity, serverUUID string) error
 }
 
+// StateStore is the storage the client keeps the last verified state of each database in,
+// the file, in-memory and key-value caches are provided
+type StateStore = Cache
+
 // HistoryCache the history cache interface
 type HistoryCache interface {
 	Cache
@@ -21,4 +21,5 @@ import "errors"
 var (
 	ErrPrevStateNotFound   = errors.New("could not find previous state")
 	ErrLocalStateCorrupted = errors.New("local state is corrupted")
+	ErrKeyNotFound         = errors.New("key not found")
 )
@@ -17,56 +17,66 @@ limitations under the License.
 package cache
 
 import (
-	"fmt"
 	"sync"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
 )
 
 type inMemoryCache struct {
-	serverUUID string
 	states     map[string]map[string]*schema.ImmutableState
 	identities map[string]string
 	lock       sync.RWMutex
+
+	// held while the cache is locked, a mutex can not be used since unlocking it if not locked panics
+	cacheLock chan struct{}
 }
 
-// NewInMemoryCache returns a new in-memory cache
+// NewInMemoryCache returns a new in-memory cache, states are lost once the process ends
 func NewInMemoryCache() Cache {
 	return &inMemoryCache{
-		states: map[string]map[string]*schema.ImmutableState{},
+		states:     map[string]map[string]*schema.ImmutableState{},
+		identities: map[string]string{},
+		cacheLock:  make(chan struct{}, 1),
 	}
 }
 
 func (imc *inMemoryCache) Get(serverUUID, db string) (*schema.ImmutableState, error) {
-	serverStates, ok := imc.states[serverUUID]
-	if !ok {
-		return nil, fmt.Errorf("no roots found for server %s", serverUUID)
-	}
-	state, ok := serverStates[db]
+	imc.lock.RLock()
+	defer imc.lock.RUnlock()
+
+	state, ok := imc.states[serverUUID][db]
 	if !ok {
-		return nil, fmt.Errorf(
-			"no state found for server %s and database %s", serverUUID, db)
+		return nil, ErrPrevStateNotFound
 	}
+
 	return state, nil
 }
 
 func (imc *inMemoryCache) Set(serverUUID, db string, state *schema.ImmutableState) error {
 	imc.lock.Lock()
 	defer imc.lock.Unlock()
+
 	if _, ok := imc.states[serverUUID]; !ok {
 		imc.states[serverUUID] = map[string]*schema.ImmutableState{db: state}
 		return nil
 	}
+
 	imc.states[serverUUID][db] = state
 	return nil
 }
 
 func (imc *inMemoryCache) Lock(serverUUID string) (err error) {
-	return fmt.Errorf("not implemented")
+	imc.cacheLock <- struct{}{}
+	return nil
 }
 
 func (imc *inMemoryCache) Unlock() (err error) {
-	return fmt.Errorf("not implemented")
+	select {
+	case <-imc.cacheLock:
+		return nil
+	default:
+		return ErrCacheNotLocked
+	}
 }
 
 func (imc *inMemoryCache) ServerIdentityCheck(serverIdentity, serverUUID string) error {
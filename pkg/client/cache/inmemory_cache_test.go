@@ -50,14 +50,25 @@ func TestInMemoryCache(t *testing.T) {
 	require.Equal(t, []byte{21}, root.GetTxHash())
 
 	_, err = imc.Get("unknownServer", "db11")
-	require.Error(t, err)
+	require.ErrorIs(t, err, ErrPrevStateNotFound)
 	_, err = imc.Get("server1", "unknownDb")
-	require.Error(t, err)
+	require.ErrorIs(t, err, ErrPrevStateNotFound)
+
+	err = imc.Unlock()
+	require.ErrorIs(t, err, ErrCacheNotLocked)
 
 	err = imc.Lock("server1")
-	require.Error(t, err)
+	require.NoError(t, err)
 
 	err = imc.Unlock()
-	require.Error(t, err)
+	require.NoError(t, err)
+
+	err = imc.ServerIdentityCheck("identity1", "server1")
+	require.NoError(t, err)
+
+	err = imc.ServerIdentityCheck("identity1", "server1")
+	require.NoError(t, err)
 
+	err = imc.ServerIdentityCheck("identity1", "server2")
+	require.ErrorIs(t, err, ErrServerIdentityValidationFailed)
 }
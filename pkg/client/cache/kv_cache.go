@@ -0,0 +1,140 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/golang/protobuf/proto"
+)
+
+// KVStore is a key-value storage states can be kept in, e.g. a user-provided durable storage
+// for clients with no persistent local filesystem
+type KVStore interface {
+	// Get returns the value of the key, ErrKeyNotFound if there is none
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte) error
+}
+
+// KVStoreLocker is implemented by key-value storages able to lock keys across all the clients
+// using them. Without it, states of a server are only protected from concurrent updates
+// made by the same process.
+type KVStoreLocker interface {
+	Lock(key string) error
+	Unlock(key string) error
+}
+
+const (
+	kvStatePrefix    = "state/"
+	kvIdentityPrefix = "identity/"
+	kvLockPrefix     = "lock/"
+)
+
+type kvCache struct {
+	kv KVStore
+
+	lockedServerUUID string
+
+	// held while the cache is locked
+	cacheLock chan struct{}
+}
+
+// NewKVCache returns a new cache keeping states in a key-value storage
+func NewKVCache(kv KVStore) Cache {
+	return &kvCache{
+		kv:        kv,
+		cacheLock: make(chan struct{}, 1),
+	}
+}
+
+func (kvc *kvCache) Get(serverUUID, db string) (*schema.ImmutableState, error) {
+	raw, err := kvc.kv.Get(kvStatePrefix + serverUUID + "/" + db)
+	if err == ErrKeyNotFound {
+		return nil, ErrPrevStateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := &schema.ImmutableState{}
+
+	err = proto.Unmarshal(raw, state)
+	if err != nil || len(raw) == 0 {
+		return nil, ErrLocalStateCorrupted
+	}
+
+	return state, nil
+}
+
+func (kvc *kvCache) Set(serverUUID, db string, state *schema.ImmutableState) error {
+	raw, err := proto.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return kvc.kv.Set(kvStatePrefix+serverUUID+"/"+db, raw)
+}
+
+func (kvc *kvCache) Lock(serverUUID string) error {
+	kvc.cacheLock <- struct{}{}
+
+	if locker, ok := kvc.kv.(KVStoreLocker); ok {
+		err := locker.Lock(kvLockPrefix + serverUUID)
+		if err != nil {
+			<-kvc.cacheLock
+			return err
+		}
+	}
+
+	kvc.lockedServerUUID = serverUUID
+
+	return nil
+}
+
+func (kvc *kvCache) Unlock() error {
+	if len(kvc.cacheLock) == 0 {
+		return ErrCacheNotLocked
+	}
+
+	var err error
+
+	if locker, ok := kvc.kv.(KVStoreLocker); ok {
+		err = locker.Unlock(kvLockPrefix + kvc.lockedServerUUID)
+	}
+
+	<-kvc.cacheLock
+
+	return err
+}
+
+func (kvc *kvCache) ServerIdentityCheck(serverIdentity, serverUUID string) error {
+	key := kvIdentityPrefix + serverIdentity
+
+	previousUUID, err := kvc.kv.Get(key)
+	if err == ErrKeyNotFound {
+		return kvc.kv.Set(key, []byte(serverUUID))
+	}
+	if err != nil {
+		return err
+	}
+
+	// Server with this identity was seen before, ensure it did not change
+	if string(previousUUID) != serverUUID {
+		return ErrServerIdentityValidationFailed
+	}
+
+	return nil
+}
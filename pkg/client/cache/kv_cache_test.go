@@ -0,0 +1,149 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+)
+
+type mapKVStore struct {
+	values map[string][]byte
+	locked map[string]bool
+	mutex  sync.Mutex
+}
+
+func (s *mapKVStore) Get(key string) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	v, ok := s.values[key]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return v, nil
+}
+
+func (s *mapKVStore) Set(key string, value []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.values[key] = value
+	return nil
+}
+
+type lockingMapKVStore struct {
+	mapKVStore
+}
+
+func (s *lockingMapKVStore) Lock(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.locked[key] {
+		return errors.New("already locked")
+	}
+	s.locked[key] = true
+	return nil
+}
+
+func (s *lockingMapKVStore) Unlock(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.locked, key)
+	return nil
+}
+
+func TestKVCache(t *testing.T) {
+	kv := &mapKVStore{values: map[string][]byte{}}
+
+	kvc := NewKVCache(kv)
+
+	_, err := kvc.Get("server1", "db1")
+	require.ErrorIs(t, err, ErrPrevStateNotFound)
+
+	err = kvc.Set("server1", "db1", &schema.ImmutableState{TxId: 1, TxHash: []byte{1}})
+	require.NoError(t, err)
+
+	err = kvc.Set("server1", "db2", &schema.ImmutableState{TxId: 2, TxHash: []byte{2}})
+	require.NoError(t, err)
+
+	state, err := kvc.Get("server1", "db1")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), state.TxId)
+	require.Equal(t, []byte{1}, state.TxHash)
+
+	// states are kept by the store, a new cache using it sees them
+	state, err = NewKVCache(kv).Get("server1", "db2")
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), state.TxId)
+
+	_, err = kvc.Get("server2", "db1")
+	require.ErrorIs(t, err, ErrPrevStateNotFound)
+
+	kv.values[kvStatePrefix+"server2/db1"] = []byte{0xff}
+
+	_, err = kvc.Get("server2", "db1")
+	require.ErrorIs(t, err, ErrLocalStateCorrupted)
+
+	err = kvc.Unlock()
+	require.ErrorIs(t, err, ErrCacheNotLocked)
+
+	err = kvc.Lock("server1")
+	require.NoError(t, err)
+
+	err = kvc.Unlock()
+	require.NoError(t, err)
+
+	err = kvc.ServerIdentityCheck("identity1", "server1")
+	require.NoError(t, err)
+
+	err = kvc.ServerIdentityCheck("identity1", "server1")
+	require.NoError(t, err)
+
+	err = kvc.ServerIdentityCheck("identity1", "server2")
+	require.ErrorIs(t, err, ErrServerIdentityValidationFailed)
+}
+
+func TestKVCacheWithLocker(t *testing.T) {
+	kv := &lockingMapKVStore{mapKVStore{
+		values: map[string][]byte{},
+		locked: map[string]bool{},
+	}}
+
+	kvc := NewKVCache(kv)
+
+	err := kvc.Lock("server1")
+	require.NoError(t, err)
+	require.True(t, kv.locked[kvLockPrefix+"server1"])
+
+	// the lock is held by another client
+	err = NewKVCache(kv).Lock("server1")
+	require.Error(t, err)
+
+	err = kvc.Unlock()
+	require.NoError(t, err)
+	require.False(t, kv.locked[kvLockPrefix+"server1"])
+
+	err = NewKVCache(kv).Lock("server1")
+	require.NoError(t, err)
+}
@@ -411,6 +411,13 @@ type ImmuClient interface {
 	// GetAll retrieves multiple entries in a single call.
 	GetAll(ctx context.Context, keys [][]byte) (*schema.Entries, error)
 
+	// VerifiedGetAll retrieves multiple entries in a single call and verifies them.
+	//
+	// All the entries are verified against a single server state, so the local state
+	// is advanced once for the whole batch.
+	// If verification does not succeed the store.ErrCorruptedData error is returned.
+	VerifiedGetAll(ctx context.Context, keys [][]byte) (*schema.Entries, error)
+
 	// Delete performs a logical deletion for a list of keys marking them as deleted.
 	Delete(ctx context.Context, req *schema.DeleteKeysRequest) (*schema.TxHeader, error)
 
@@ -470,6 +477,21 @@ type ImmuClient interface {
 	// using stream API to overcome limits of large keys and values.
 	StreamExecAll(ctx context.Context, req *stream.ExecAllRequest) (*schema.TxHeader, error)
 
+	// VerifiedSetLargeValue writes a value read from an io.Reader which can exceed the maximum size
+	// of a gRPC message and of a single value.
+	//
+	// The value is split into chunks written in their own transactions, the entry written for the key
+	// holds the digest of the whole value and is verified using the server-provided proof.
+	VerifiedSetLargeValue(ctx context.Context, key []byte, value io.Reader) (*schema.TxHeader, error)
+
+	// VerifiedGetLargeValue reads a value written by VerifiedSetLargeValue into an io.Writer.
+	//
+	// The entry for the key is verified using the server-provided proof, the digest of the value is computed
+	// while it's streamed and checked against the verified one once done. If verification does not succeed
+	// the store.ErrCorruptedData error is returned, in which case data already written must be discarded.
+	// The returned entry holds no value.
+	VerifiedGetLargeValue(ctx context.Context, key []byte, value io.Writer) (*schema.Entry, error)
+
 	// ExportTx retrieves serialized transaction object.
 	ExportTx(ctx context.Context, req *schema.ExportTxRequest) (schema.ImmuService_ExportTxClient, error)
 
@@ -511,6 +533,19 @@ type ImmuClient interface {
 	//
 	// This call requires SysAdmin permission level or admin permission to the database.
 	TruncateDatabase(ctx context.Context, db string, retentionPeriod time.Duration) error
+
+	// CloneDatabase creates a new database with a copy of the transactions of an existing one,
+	// up to untilTxID if not zero or to the latest committed transaction otherwise.
+	// The cloned database keeps serving requests in the meantime.
+	//
+	// This call requires SysAdmin permission level.
+	CloneDatabase(ctx context.Context, db, newDB string, untilTxID uint64) (*schema.CloneDatabaseResponse, error)
+
+	// RenameDatabase changes the name of a database.
+	// Clients using the database need to select it again with its new name.
+	//
+	// This call requires SysAdmin permission level.
+	RenameDatabase(ctx context.Context, db, newDB string) error
 }
 
 const DefaultDB = "defaultdb"
@@ -600,8 +635,13 @@ func NewImmuClient(options *Options) (*immuClient, error) {
 	stateProvider := state.NewStateProvider(serviceClient)
 	uuidProvider := state.NewUUIDProvider(serviceClient)
 
+	stateCache := options.StateStore
+	if stateCache == nil {
+		stateCache = cache.NewFileCache(options.Dir)
+	}
+
 	stateService, err := state.NewStateService(
-		cache.NewFileCache(options.Dir),
+		stateCache,
 		l,
 		stateProvider,
 		uuidProvider,
@@ -1456,6 +1496,179 @@ func (c *immuClient) GetAll(ctx context.Context, keys [][]byte) (*schema.Entries
 	return c.ServiceClient.GetAll(ctx, keyList)
 }
 
+// VerifiedGetAll retrieves multiple entries in a single call and verifies them.
+//
+// All the entries are verified against a single server state, so the local state
+// is advanced once for the whole batch.
+// If verification does not succeed the store.ErrCorruptedData error is returned.
+func (c *immuClient) VerifiedGetAll(ctx context.Context, keys [][]byte) (*schema.Entries, error) {
+	err := c.StateService.CacheLock()
+	if err != nil {
+		return nil, err
+	}
+	defer c.StateService.CacheUnlock()
+
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	start := time.Now()
+	defer func() {
+		c.Logger.Debugf("VerifiedGetAll finished in %s", time.Since(start))
+	}()
+
+	state, err := c.StateService.GetState(ctx, c.Options.CurrentDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &schema.VerifiableGetAllRequest{
+		KeyListRequest: &schema.KeyListRequest{Keys: keys},
+		ProveSinceTx:   state.TxId,
+	}
+
+	vEntries, err := c.ServiceClient.VerifiableGetAll(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if vEntries.DualProof == nil {
+		// nothing committed yet
+		if state.TxId > 0 || len(vEntries.Entries) > 0 {
+			return nil, store.ErrCorruptedData
+		}
+
+		return &schema.Entries{}, nil
+	}
+
+	if len(vEntries.InclusionProofs) != len(vEntries.Entries) {
+		return nil, store.ErrCorruptedData
+	}
+
+	dualProof := schema.DualProofFromProto(vEntries.DualProof)
+
+	targetID := dualProof.TargetTxHeader.ID
+	targetAlh := dualProof.TargetTxHeader.Alh()
+
+	if targetID < state.TxId {
+		return nil, store.ErrCorruptedData
+	}
+
+	txProofs := make(map[uint64]*store.DualProof, len(vEntries.TxProofs))
+
+	for _, p := range vEntries.TxProofs {
+		txProof := schema.DualProofFromProto(p)
+
+		if txProof.TargetTxHeader.ID != targetID || txProof.TargetTxHeader.Alh() != targetAlh {
+			return nil, store.ErrCorruptedData
+		}
+
+		txProofs[txProof.SourceTxHeader.ID] = txProof
+	}
+
+	requestedKeys := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		requestedKeys[string(k)] = struct{}{}
+	}
+
+	verifiedTxs := make(map[uint64]struct{}, len(txProofs))
+
+	for i, entry := range vEntries.Entries {
+		var vTx uint64
+		var vKey []byte
+		var e *store.EntrySpec
+
+		if entry.ReferencedBy == nil {
+			vTx = entry.Tx
+			vKey = entry.Key
+			e = database.EncodeEntrySpec(entry.Key, schema.KVMetadataFromProto(entry.Metadata), entry.Value)
+		} else {
+			ref := entry.ReferencedBy
+			vTx = ref.Tx
+			vKey = ref.Key
+			e = database.EncodeReference(ref.Key, schema.KVMetadataFromProto(ref.Metadata), entry.Key, ref.AtTx)
+		}
+
+		if _, ok := requestedKeys[string(vKey)]; !ok {
+			return nil, store.ErrCorruptedData
+		}
+
+		txProof, ok := txProofs[vTx]
+		if !ok {
+			return nil, store.ErrCorruptedData
+		}
+
+		entrySpecDigest, err := store.EntrySpecDigestFor(txProof.SourceTxHeader.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		verifies := store.VerifyInclusion(
+			schema.InclusionProofFromProto(vEntries.InclusionProofs[i]),
+			entrySpecDigest(e),
+			txProof.SourceTxHeader.Eh)
+		if !verifies {
+			return nil, store.ErrCorruptedData
+		}
+
+		if _, ok := verifiedTxs[vTx]; ok {
+			continue
+		}
+
+		err = c.verifyDualProof(
+			ctx,
+			txProof,
+			vTx,
+			targetID,
+			txProof.SourceTxHeader.Alh(),
+			targetAlh,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		verifiedTxs[vTx] = struct{}{}
+	}
+
+	if state.TxId > 0 {
+		err := c.verifyDualProof(
+			ctx,
+			dualProof,
+			state.TxId,
+			targetID,
+			schema.DigestFromProto(state.TxHash),
+			targetAlh,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	newState := &schema.ImmutableState{
+		Db:        c.currentDatabase(),
+		TxId:      targetID,
+		TxHash:    targetAlh[:],
+		Signature: vEntries.Signature,
+	}
+
+	if c.serverSigningPubKey != nil {
+		ok, err := newState.CheckSignature(c.serverSigningPubKey)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, store.ErrCorruptedData
+		}
+	}
+
+	err = c.StateService.SetState(c.Options.CurrentDatabase, newState)
+	if err != nil {
+		return nil, err
+	}
+
+	return &schema.Entries{Entries: vEntries.Entries}, nil
+}
+
 // Delete performs a logical deletion for a list of keys marking them as deleted.
 func (c *immuClient) Delete(ctx context.Context, req *schema.DeleteKeysRequest) (*schema.TxHeader, error) {
 	if !c.IsConnected() {
@@ -2343,3 +2556,40 @@ func (c *immuClient) TruncateDatabase(ctx context.Context, db string, retentionP
 
 	return err
 }
+
+// CloneDatabase creates a new database with a copy of the transactions of an existing one.
+func (c *immuClient) CloneDatabase(ctx context.Context, db, newDB string, untilTxID uint64) (*schema.CloneDatabaseResponse, error) {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return nil, ErrNotConnected
+	}
+
+	res, err := c.ServiceClient.CloneDatabase(ctx, &schema.CloneDatabaseRequest{
+		Database:    db,
+		NewDatabase: newDB,
+		UntilTxId:   untilTxID,
+	})
+
+	c.Logger.Debugf("CloneDatabase finished in %s", time.Since(start))
+
+	return res, err
+}
+
+// RenameDatabase changes the name of a database.
+func (c *immuClient) RenameDatabase(ctx context.Context, db, newDB string) error {
+	start := time.Now()
+
+	if !c.IsConnected() {
+		return ErrNotConnected
+	}
+
+	_, err := c.ServiceClient.RenameDatabase(ctx, &schema.RenameDatabaseRequest{
+		Database:    db,
+		NewDatabase: newDB,
+	})
+
+	c.Logger.Debugf("RenameDatabase finished in %s", time.Since(start))
+
+	return err
+}
@@ -0,0 +1,417 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	immuErrors "github.com/codenotary/immudb/pkg/client/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ClusterClient keeps pools of sessions to a primary server and its replicas.
+//
+// Writes are sent to the primary, reads can be routed to replicas. Sessions are health-checked
+// in background and reopened when failing. Operations failing with transient errors are retried
+// with backoff, failing over to another server when the one used is unreachable.
+type ClusterClient struct {
+	opts *ClusterOptions
+
+	primary  *clusterEndpoint
+	replicas []*clusterEndpoint
+
+	nextReplica uint32
+
+	running bool
+	done    chan struct{}
+	wg      sync.WaitGroup
+
+	mutex sync.RWMutex
+}
+
+type clusterEndpoint struct {
+	opts *Options
+
+	clients []ImmuClient
+	next    uint32
+
+	healthy bool
+	lagging bool
+
+	mutex sync.RWMutex
+}
+
+// NewClusterClient creates a new ClusterClient,
+// use OpenSession to establish the sessions to the servers.
+func NewClusterClient(opts *ClusterOptions) (*ClusterClient, error) {
+	if opts == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	cc := &ClusterClient{
+		opts:    opts,
+		primary: &clusterEndpoint{opts: opts.Primary},
+	}
+
+	for _, r := range opts.Replicas {
+		cc.replicas = append(cc.replicas, &clusterEndpoint{opts: r})
+	}
+
+	return cc, nil
+}
+
+// OpenSession opens the pools of sessions to the primary and the replicas.
+//
+// It fails if sessions to the primary can not be opened, replicas not reachable are not used
+// until sessions to them are opened by background health checks.
+func (cc *ClusterClient) OpenSession(ctx context.Context, user []byte, pass []byte, database string) error {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	if cc.running {
+		return ErrSessionAlreadyOpen
+	}
+
+	err := cc.primary.open(ctx, user, pass, database, cc.opts.PoolSize)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range cc.replicas {
+		// unreachable replicas are retried by health checks
+		r.open(ctx, user, pass, database, cc.opts.PoolSize)
+	}
+
+	cc.running = true
+	cc.done = make(chan struct{})
+
+	cc.wg.Add(1)
+	go cc.healthCheck(user, pass, database)
+
+	return nil
+}
+
+// CloseSession closes all the sessions to the servers
+func (cc *ClusterClient) CloseSession(ctx context.Context) error {
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	if !cc.running {
+		return ErrNotConnected
+	}
+
+	close(cc.done)
+	cc.wg.Wait()
+
+	cc.running = false
+
+	err := cc.primary.close(ctx)
+
+	for _, r := range cc.replicas {
+		rerr := r.close(ctx)
+		if err == nil {
+			err = rerr
+		}
+	}
+
+	return err
+}
+
+// Read runs a read-only operation on a healthy replica, or on the primary when reads are not
+// routed to replicas or none of them is healthy. It's retried on transient errors.
+func (cc *ClusterClient) Read(ctx context.Context, op func(ImmuClient) error) error {
+	return cc.run(ctx, cc.readEndpoints(), true, op)
+}
+
+// Write runs an operation on the primary. The operation may have been applied by the server
+// even if failing, so it's only retried when it could not be sent at all.
+// IdempotentWrite is meant for operations which can be safely repeated.
+func (cc *ClusterClient) Write(ctx context.Context, op func(ImmuClient) error) error {
+	return cc.run(ctx, []*clusterEndpoint{cc.primary}, false, op)
+}
+
+// IdempotentWrite runs on the primary an operation which can be safely repeated,
+// it's retried on transient errors.
+func (cc *ClusterClient) IdempotentWrite(ctx context.Context, op func(ImmuClient) error) error {
+	return cc.run(ctx, []*clusterEndpoint{cc.primary}, true, op)
+}
+
+func (cc *ClusterClient) run(ctx context.Context, endpoints []*clusterEndpoint, idempotent bool, op func(ImmuClient) error) error {
+	cc.mutex.RLock()
+	defer cc.mutex.RUnlock()
+
+	if !cc.running {
+		return ErrNotConnected
+	}
+
+	backoff := cc.opts.RetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := cc.runOnce(endpoints, op)
+		if err == nil {
+			return nil
+		}
+
+		retry := errors.Is(err, ErrNoHealthyServer) || (idempotent && isTransientError(err))
+		if !retry || attempt == cc.opts.MaxRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > cc.opts.MaxRetryBackoff {
+			backoff = cc.opts.MaxRetryBackoff
+		}
+	}
+}
+
+// runOnce runs the operation on the first healthy endpoint, an unreachable endpoint is not
+// used anymore until its sessions are reopened so retries fail over to the following ones
+func (cc *ClusterClient) runOnce(endpoints []*clusterEndpoint, op func(ImmuClient) error) error {
+	for _, e := range endpoints {
+		client := e.client()
+		if client == nil {
+			continue
+		}
+
+		err := op(client)
+		if isUnreachableError(err) {
+			e.setHealthy(false)
+		}
+
+		return err
+	}
+
+	return ErrNoHealthyServer
+}
+
+// readEndpoints returns the replicas not lagging behind, starting from a different one
+// at each call, followed by the primary
+func (cc *ClusterClient) readEndpoints() []*clusterEndpoint {
+	if !cc.opts.ReadFromReplicas || len(cc.replicas) == 0 {
+		return []*clusterEndpoint{cc.primary}
+	}
+
+	endpoints := make([]*clusterEndpoint, 0, len(cc.replicas)+1)
+
+	start := int(atomic.AddUint32(&cc.nextReplica, 1))
+
+	for i := range cc.replicas {
+		r := cc.replicas[(start+i)%len(cc.replicas)]
+
+		if !r.isLagging() {
+			endpoints = append(endpoints, r)
+		}
+	}
+
+	return append(endpoints, cc.primary)
+}
+
+func (cc *ClusterClient) healthCheck(user []byte, pass []byte, database string) {
+	defer cc.wg.Done()
+
+	ticker := time.NewTicker(cc.opts.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cc.done:
+			return
+		case <-ticker.C:
+		}
+
+		for _, e := range append([]*clusterEndpoint{cc.primary}, cc.replicas...) {
+			ctx, cancel := context.WithTimeout(context.Background(), cc.opts.HealthCheckTimeout)
+
+			lag, err := e.check(ctx)
+			if err == nil {
+				e.setLagging(e != cc.primary && cc.opts.MaxReplicationLag > 0 && lag > cc.opts.MaxReplicationLag)
+			} else {
+				e.setHealthy(false)
+				e.close(ctx)
+
+				// the endpoint is marked as healthy again once sessions are reopened
+				e.open(ctx, user, pass, database, cc.opts.PoolSize)
+			}
+
+			cancel()
+		}
+	}
+}
+
+func (e *clusterEndpoint) open(ctx context.Context, user []byte, pass []byte, database string, poolSize int) error {
+	clients := make([]ImmuClient, 0, poolSize)
+
+	for i := 0; i < poolSize; i++ {
+		// sessions update their options, each one gets its own copy
+		opts := *e.opts
+		opts.DialOptions = append([]grpc.DialOption(nil), e.opts.DialOptions...)
+
+		client := NewClient().WithOptions(&opts)
+
+		err := client.OpenSession(ctx, user, pass, database)
+		if err != nil {
+			for _, c := range clients {
+				c.CloseSession(ctx)
+			}
+			return err
+		}
+
+		clients = append(clients, client)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.clients = clients
+	e.healthy = true
+
+	return nil
+}
+
+// close closes the sessions of the endpoint, errors are only reported if it was healthy
+// as sessions to unreachable servers are expected to fail to be closed
+func (e *clusterEndpoint) close(ctx context.Context) error {
+	e.mutex.Lock()
+	clients := e.clients
+	healthy := e.healthy
+	e.clients = nil
+	e.healthy = false
+	e.mutex.Unlock()
+
+	var err error
+
+	for _, c := range clients {
+		cerr := c.CloseSession(ctx)
+		if err == nil && healthy {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+// check returns the replication lag of the endpoint if all its sessions are usable
+func (e *clusterEndpoint) check(ctx context.Context) (uint64, error) {
+	e.mutex.RLock()
+	clients := e.clients
+	healthy := e.healthy
+	e.mutex.RUnlock()
+
+	if !healthy || len(clients) == 0 {
+		return 0, ErrNoHealthyServer
+	}
+
+	var lag uint64
+
+	for _, c := range clients {
+		h, err := c.Health(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		if h.ReplicationLag > lag {
+			lag = h.ReplicationLag
+		}
+	}
+
+	return lag, nil
+}
+
+// client returns the sessions of the endpoint in turn, nil if the endpoint is not healthy
+func (e *clusterEndpoint) client() ImmuClient {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if !e.healthy || len(e.clients) == 0 {
+		return nil
+	}
+
+	i := atomic.AddUint32(&e.next, 1)
+
+	return e.clients[int(i)%len(e.clients)]
+}
+
+func (e *clusterEndpoint) setHealthy(healthy bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.healthy = healthy
+}
+
+func (e *clusterEndpoint) setLagging(lagging bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.lagging = lagging
+}
+
+func (e *clusterEndpoint) isLagging() bool {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	return e.lagging
+}
+
+// isUnreachableError tells if the server could not be reached
+func isUnreachableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, ErrNotConnected) {
+		return true
+	}
+
+	st, ok := status.FromError(err)
+
+	return ok && st.Code() == codes.Unavailable
+}
+
+// isTransientError tells if an operation may succeed if retried
+func isTransientError(err error) bool {
+	if isUnreachableError(err) {
+		return true
+	}
+
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.ResourceExhausted, codes.Aborted:
+			return true
+		}
+	}
+
+	var immuErr immuErrors.ImmuError
+
+	return errors.As(err, &immuErr) && immuErr.RetryDelay() > 0
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"time"
+)
+
+// ClusterOptions are the options of a ClusterClient
+type ClusterOptions struct {
+	Primary  *Options   // Connection options of the primary server, writes are always sent to it
+	Replicas []*Options // Connection options of the replicas reads can be routed to
+
+	PoolSize            int           // Number of sessions opened to each server
+	ReadFromReplicas    bool          // If set to true, reads are routed to healthy replicas, falling back to the primary
+	MaxReplicationLag   uint64        // Replicas lagging behind their primary by more transactions are not read from, 0 means no limit
+	HealthCheckInterval time.Duration // Duration between two consecutive health checks of the sessions to each server
+	HealthCheckTimeout  time.Duration // Maximum duration of the health check of each server, including reopening its sessions
+	MaxRetries          int           // Maximum number of retries of operations failing with transient errors
+	RetryBackoff        time.Duration // Delay before the first retry, doubled at each retry
+	MaxRetryBackoff     time.Duration // Maximum delay between two retries
+}
+
+// DefaultClusterOptions ...
+func DefaultClusterOptions() *ClusterOptions {
+	return &ClusterOptions{
+		Primary:             DefaultOptions(),
+		PoolSize:            4,
+		ReadFromReplicas:    true,
+		HealthCheckInterval: 5 * time.Second,
+		HealthCheckTimeout:  5 * time.Second,
+		MaxRetries:          3,
+		RetryBackoff:        100 * time.Millisecond,
+		MaxRetryBackoff:     2 * time.Second,
+	}
+}
+
+// WithPrimary sets the connection options of the primary server
+func (o *ClusterOptions) WithPrimary(primary *Options) *ClusterOptions {
+	o.Primary = primary
+	return o
+}
+
+// WithReplicas sets the connection options of the replicas
+func (o *ClusterOptions) WithReplicas(replicas ...*Options) *ClusterOptions {
+	o.Replicas = replicas
+	return o
+}
+
+// WithPoolSize sets the number of sessions opened to each server
+func (o *ClusterOptions) WithPoolSize(poolSize int) *ClusterOptions {
+	o.PoolSize = poolSize
+	return o
+}
+
+// WithReadFromReplicas sets if reads are routed to replicas
+func (o *ClusterOptions) WithReadFromReplicas(readFromReplicas bool) *ClusterOptions {
+	o.ReadFromReplicas = readFromReplicas
+	return o
+}
+
+// WithMaxReplicationLag sets the maximum replication lag of the replicas reads are routed to
+func (o *ClusterOptions) WithMaxReplicationLag(maxReplicationLag uint64) *ClusterOptions {
+	o.MaxReplicationLag = maxReplicationLag
+	return o
+}
+
+// WithHealthCheckInterval sets the duration between two consecutive health checks
+func (o *ClusterOptions) WithHealthCheckInterval(interval time.Duration) *ClusterOptions {
+	o.HealthCheckInterval = interval
+	return o
+}
+
+// WithHealthCheckTimeout sets the maximum duration of the health check of each server
+func (o *ClusterOptions) WithHealthCheckTimeout(timeout time.Duration) *ClusterOptions {
+	o.HealthCheckTimeout = timeout
+	return o
+}
+
+// WithMaxRetries sets the maximum number of retries of operations failing with transient errors
+func (o *ClusterOptions) WithMaxRetries(maxRetries int) *ClusterOptions {
+	o.MaxRetries = maxRetries
+	return o
+}
+
+// WithRetryBackoff sets the delay before the first retry
+func (o *ClusterOptions) WithRetryBackoff(backoff time.Duration) *ClusterOptions {
+	o.RetryBackoff = backoff
+	return o
+}
+
+// WithMaxRetryBackoff sets the maximum delay between two retries
+func (o *ClusterOptions) WithMaxRetryBackoff(maxBackoff time.Duration) *ClusterOptions {
+	o.MaxRetryBackoff = maxBackoff
+	return o
+}
+
+// Validate checks the options are consistent
+func (o *ClusterOptions) Validate() error {
+	if o.Primary == nil ||
+		o.PoolSize <= 0 ||
+		o.HealthCheckInterval <= 0 ||
+		o.HealthCheckTimeout <= 0 ||
+		o.MaxRetries < 0 ||
+		o.RetryBackoff < 0 ||
+		o.MaxRetryBackoff < o.RetryBackoff {
+		return ErrIllegalArguments
+	}
+
+	for _, r := range o.Replicas {
+		if r == nil {
+			return ErrIllegalArguments
+		}
+	}
+
+	return nil
+}
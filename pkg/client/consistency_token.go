@@ -0,0 +1,31 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// WithConsistencyToken returns a context whose reads are served once the transaction txID
+// is committed by the database. Passing the id of a write against a primary lets reads
+// against its replicas observe it, replicas wait until they catch up
+func WithConsistencyToken(ctx context.Context, txID uint64) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "min-tx-id", strconv.FormatUint(txID, 10))
+}
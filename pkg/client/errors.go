@@ -44,6 +44,12 @@ var (
 
 	// ErrSessionAlreadyOpen is used when trying to create a new session but there's a valid session already set up.
 	ErrSessionAlreadyOpen = errors.New("session already opened")
+
+	// ErrNoHealthyServer is used when none of the servers an operation can be sent to is healthy
+	ErrNoHealthyServer = errors.New("no healthy server")
+
+	// ErrNotALargeValue is used when reading as a large value an entry not written by VerifiedSetLargeValue
+	ErrNotALargeValue = errors.New("not a large value")
 )
 
 // Server errors mapping
@@ -0,0 +1,270 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/client/errors"
+	"github.com/codenotary/immudb/pkg/stream"
+)
+
+// DefaultLargeValueChunkSize is the default size of the chunks large values are split into
+const DefaultLargeValueChunkSize = 1 << 20 // 1Mb
+
+// largeValueMagic prefixes the entries describing large values
+var largeValueMagic = []byte("IMMUDB-LV1")
+
+// largeValueChunkKeyPrefix separates the key of a large value from the index of its chunks
+var largeValueChunkKeyPrefix = []byte("/__lvchunk/")
+
+// largeValueManifest is the entry written for the key of a large value.
+// Chunks are read at the transaction they were written in, so they are not affected by
+// later writes to the same keys
+type largeValueManifest struct {
+	size      uint64
+	chunkSize uint32
+	digest    [sha256.Size]byte
+	chunkTxs  []uint64
+}
+
+const largeValueManifestHeaderLen = 8 + 4 + sha256.Size + 4
+
+func (m *largeValueManifest) encode() []byte {
+	bs := make([]byte, len(largeValueMagic)+largeValueManifestHeaderLen+8*len(m.chunkTxs))
+
+	i := copy(bs, largeValueMagic)
+
+	binary.BigEndian.PutUint64(bs[i:], m.size)
+	i += 8
+
+	binary.BigEndian.PutUint32(bs[i:], m.chunkSize)
+	i += 4
+
+	i += copy(bs[i:], m.digest[:])
+
+	binary.BigEndian.PutUint32(bs[i:], uint32(len(m.chunkTxs)))
+	i += 4
+
+	for _, txID := range m.chunkTxs {
+		binary.BigEndian.PutUint64(bs[i:], txID)
+		i += 8
+	}
+
+	return bs
+}
+
+func decodeLargeValueManifest(bs []byte) (*largeValueManifest, error) {
+	if !bytes.HasPrefix(bs, largeValueMagic) {
+		return nil, ErrNotALargeValue
+	}
+
+	bs = bs[len(largeValueMagic):]
+
+	if len(bs) < largeValueManifestHeaderLen {
+		return nil, store.ErrCorruptedData
+	}
+
+	m := &largeValueManifest{}
+
+	m.size = binary.BigEndian.Uint64(bs)
+	bs = bs[8:]
+
+	m.chunkSize = binary.BigEndian.Uint32(bs)
+	bs = bs[4:]
+
+	copy(m.digest[:], bs)
+	bs = bs[sha256.Size:]
+
+	chunks := binary.BigEndian.Uint32(bs)
+	bs = bs[4:]
+
+	if uint64(len(bs)) != 8*uint64(chunks) || m.chunkSize == 0 ||
+		m.size > uint64(m.chunkSize)*uint64(chunks) || (chunks > 0 && m.size <= uint64(m.chunkSize)*uint64(chunks-1)) {
+		return nil, store.ErrCorruptedData
+	}
+
+	m.chunkTxs = make([]uint64, chunks)
+
+	for i := range m.chunkTxs {
+		m.chunkTxs[i] = binary.BigEndian.Uint64(bs)
+		bs = bs[8:]
+	}
+
+	return m, nil
+}
+
+func largeValueChunkKey(key []byte, chunk int) []byte {
+	ck := make([]byte, len(key)+len(largeValueChunkKeyPrefix)+4)
+
+	i := copy(ck, key)
+	i += copy(ck[i:], largeValueChunkKeyPrefix)
+	binary.BigEndian.PutUint32(ck[i:], uint32(chunk))
+
+	return ck
+}
+
+// VerifiedSetLargeValue writes a value read from an io.Reader which can exceed the maximum size
+// of a gRPC message and of a single value.
+//
+// The value is split into chunks written in their own transactions, the entry written for the key
+// holds the digest of the whole value and is verified using the server-provided proof.
+func (c *immuClient) VerifiedSetLargeValue(ctx context.Context, key []byte, value io.Reader) (*schema.TxHeader, error) {
+	if len(key) == 0 || value == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	chunkSize := c.Options.LargeValueChunkSize
+	if chunkSize <= 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	if !c.IsConnected() {
+		return nil, errors.FromError(ErrNotConnected)
+	}
+
+	start := time.Now()
+	defer func() { c.Logger.Debugf("VerifiedSetLargeValue finished in %s", time.Since(start)) }()
+
+	// chunks are sent in batches fitting the amount of data the server accepts in a single stream
+	chunksPerTx := stream.MaxTxValueLen / chunkSize
+	if chunksPerTx == 0 {
+		chunksPerTx = 1
+	}
+
+	m := &largeValueManifest{chunkSize: uint32(chunkSize)}
+	h := sha256.New()
+
+	var kvs []*stream.KeyValue
+	eof := false
+
+	for !eof {
+		chunk := make([]byte, chunkSize)
+
+		n, err := io.ReadFull(value, chunk)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			eof = true
+		} else if err != nil {
+			return nil, err
+		}
+
+		if n > 0 {
+			h.Write(chunk[:n])
+			m.size += uint64(n)
+
+			chunkKey := largeValueChunkKey(key, len(m.chunkTxs)+len(kvs))
+
+			kvs = append(kvs, &stream.KeyValue{
+				Key:   &stream.ValueSize{Content: bytes.NewReader(chunkKey), Size: len(chunkKey)},
+				Value: &stream.ValueSize{Content: bytes.NewReader(chunk[:n]), Size: n},
+			})
+		}
+
+		if len(kvs) == chunksPerTx || (eof && len(kvs) > 0) {
+			hdr, err := c._streamSet(ctx, kvs)
+			if err != nil {
+				return nil, errors.FromError(err)
+			}
+
+			if int(hdr.Nentries) != len(kvs) {
+				return nil, store.ErrCorruptedData
+			}
+
+			for range kvs {
+				m.chunkTxs = append(m.chunkTxs, hdr.Id)
+			}
+
+			kvs = kvs[:0]
+		}
+	}
+
+	copy(m.digest[:], h.Sum(nil))
+
+	return c.VerifiedSet(ctx, key, m.encode())
+}
+
+// VerifiedGetLargeValue reads a value written by VerifiedSetLargeValue into an io.Writer.
+//
+// The entry for the key is verified using the server-provided proof, the digest of the value is computed
+// while it's streamed and checked against the verified one once done. If verification does not succeed
+// the store.ErrCorruptedData error is returned, in which case data already written must be discarded.
+// The returned entry holds no value.
+func (c *immuClient) VerifiedGetLargeValue(ctx context.Context, key []byte, value io.Writer) (*schema.Entry, error) {
+	if len(key) == 0 || value == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	start := time.Now()
+	defer func() { c.Logger.Debugf("VerifiedGetLargeValue finished in %s", time.Since(start)) }()
+
+	entry, err := c.VerifiedGet(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := decodeLargeValueManifest(entry.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	remaining := m.size
+
+	for i, txID := range m.chunkTxs {
+		// chunks are written before the entry describing them
+		if txID >= entry.Tx {
+			return nil, store.ErrCorruptedData
+		}
+
+		chunk, err := c._streamGet(ctx, &schema.KeyRequest{Key: largeValueChunkKey(key, i), AtTx: txID})
+		if err != nil {
+			return nil, errors.FromError(err)
+		}
+
+		expectedLen := uint64(m.chunkSize)
+		if remaining < expectedLen {
+			expectedLen = remaining
+		}
+
+		if uint64(len(chunk.Value)) != expectedLen {
+			return nil, store.ErrCorruptedData
+		}
+
+		h.Write(chunk.Value)
+		remaining -= expectedLen
+
+		_, err = value.Write(chunk.Value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !bytes.Equal(h.Sum(nil), m.digest[:]) {
+		return nil, store.ErrCorruptedData
+	}
+
+	entry.Value = nil
+
+	return entry, nil
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLargeValueManifest(t *testing.T) {
+	m := &largeValueManifest{
+		size:      10,
+		chunkSize: 4,
+		digest:    [32]byte{1, 2, 3},
+		chunkTxs:  []uint64{2, 2, 3},
+	}
+
+	bs := m.encode()
+
+	decoded, err := decodeLargeValueManifest(bs)
+	require.NoError(t, err)
+	require.Equal(t, m, decoded)
+
+	_, err = decodeLargeValueManifest([]byte("value"))
+	require.ErrorIs(t, err, ErrNotALargeValue)
+
+	_, err = decodeLargeValueManifest(bs[:len(bs)-1])
+	require.ErrorIs(t, err, store.ErrCorruptedData)
+
+	// the last chunk can not be empty
+	m.size = 8
+	_, err = decodeLargeValueManifest(m.encode())
+	require.ErrorIs(t, err, store.ErrCorruptedData)
+
+	m.size = 13
+	_, err = decodeLargeValueManifest(m.encode())
+	require.ErrorIs(t, err, store.ErrCorruptedData)
+
+	require.Equal(t, []byte("key/__lvchunk/\x00\x00\x01\x02"), largeValueChunkKey([]byte("key"), 258))
+}
@@ -21,6 +21,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/codenotary/immudb/pkg/client/cache"
 	"github.com/codenotary/immudb/pkg/stream"
 
 	c "github.com/codenotary/immudb/cmd/helper"
@@ -57,6 +58,9 @@ type Options struct {
 	LogFileName         string // Name of the log file to use, used by immuclient in auditor mode (TODO: Do not store in immuclient options)
 	ServerSigningPubKey string // Name of the file containing public key for server signature validations
 	StreamChunkSize     int    // Maximum size of a data chunk in bytes for streaming operations (directly affects maximum GRPC packet size)
+	LargeValueChunkSize int    // Size of the chunks in bytes large values are split into by VerifiedSetLargeValue
+
+	StateStore cache.StateStore // Storage of the last verified state of each database, state files in Dir are used if not set
 
 	HeartBeatFrequency time.Duration // Duration between two consecutive heartbeat calls to the server for session heartbeats
 
@@ -81,6 +85,7 @@ func DefaultOptions() *Options {
 		LogFileName:          "",
 		ServerSigningPubKey:  "",
 		StreamChunkSize:      stream.DefaultChunkSize,
+		LargeValueChunkSize:  DefaultLargeValueChunkSize,
 		HeartBeatFrequency:   time.Minute * 1,
 		DisableIdentityCheck: false,
 	}
@@ -218,6 +223,19 @@ func (o *Options) WithStreamChunkSize(streamChunkSize int) *Options {
 	return o
 }
 
+// WithLargeValueChunkSize set the size of the chunks large values are split into
+func (o *Options) WithLargeValueChunkSize(chunkSize int) *Options {
+	o.LargeValueChunkSize = chunkSize
+	return o
+}
+
+// WithStateStore sets the storage of the verified states, e.g. cache.NewInMemoryCache()
+// or cache.NewKVCache() for clients with no persistent local filesystem
+func (o *Options) WithStateStore(store cache.StateStore) *Options {
+	o.StateStore = store
+	return o
+}
+
 // WithHeartBeatFrequency set the keep alive message frequency
 func (o *Options) WithHeartBeatFrequency(heartBeatFrequency time.Duration) *Options {
 	o.HeartBeatFrequency = heartBeatFrequency
@@ -63,7 +63,10 @@ func (c *immuClient) OpenSession(ctx context.Context, user []byte, pass []byte,
 		}
 	}()
 
-	stateCache := cache.NewFileCache(c.Options.Dir)
+	stateCache := c.Options.StateStore
+	if stateCache == nil {
+		stateCache = cache.NewFileCache(c.Options.Dir)
+	}
 	stateProvider := state.NewStateProvider(serviceClient)
 
 	stateService, err := state.NewStateServiceWithUUID(stateCache, c.Logger, stateProvider, resp.GetServerUUID())
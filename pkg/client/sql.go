@@ -332,7 +332,7 @@ func typedValueToRowValue(tv sql.TypedValue) *schema.SQLValue {
 		{
 			return &schema.SQLValue{Value: &schema.SQLValue_N{N: tv.Value().(int64)}}
 		}
-	case sql.VarcharType:
+	case sql.VarcharType, sql.JSONType, sql.DecimalType:
 		{
 			return &schema.SQLValue{Value: &schema.SQLValue_S{S: tv.Value().(string)}}
 		}
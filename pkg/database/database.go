@@ -17,11 +17,13 @@ limitations under the License.
 package database
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"path/filepath"
@@ -73,12 +75,15 @@ type DB interface {
 
 	Size() (uint64, error)
 
+	StoreStats() (*store.Stats, error)
+
 	// Key-Value
 	Set(ctx context.Context, req *schema.SetRequest) (*schema.TxHeader, error)
 	VerifiableSet(ctx context.Context, req *schema.VerifiableSetRequest) (*schema.VerifiableTx, error)
 
 	Get(ctx context.Context, req *schema.KeyRequest) (*schema.Entry, error)
 	VerifiableGet(ctx context.Context, req *schema.VerifiableGetRequest) (*schema.VerifiableEntry, error)
+	VerifiableGetAll(ctx context.Context, req *schema.VerifiableGetAllRequest) (*schema.VerifiableEntries, error)
 	GetAll(ctx context.Context, req *schema.KeyListRequest) (*schema.Entries, error)
 
 	Delete(ctx context.Context, req *schema.DeleteKeysRequest) (*schema.TxHeader, error)
@@ -124,11 +129,14 @@ type DB interface {
 	TxByID(ctx context.Context, req *schema.TxRequest) (*schema.Tx, error)
 	ExportTxByID(ctx context.Context, req *schema.ExportTxRequest) (txbs []byte, mayCommitUpToTxID uint64, mayCommitUpToAlh [sha256.Size]byte, err error)
 	ReplicateTx(ctx context.Context, exportedTx []byte) (*schema.TxHeader, error)
+	ExportTxRange(fromTx, toTx uint64, w io.Writer) error
+	ImportTxRange(ctx context.Context, r io.Reader, stop func(hdr *store.TxHeader) bool) (*schema.TxHeader, error)
 	AllowCommitUpto(txID uint64, alh [sha256.Size]byte) error
 	DiscardPrecommittedTxsSince(txID uint64) error
 
 	VerifiableTxByID(ctx context.Context, req *schema.VerifiableTxRequest) (*schema.VerifiableTx, error)
 	TxScan(ctx context.Context, req *schema.TxScanRequest) (*schema.TxList, error)
+	StreamTx(ctx context.Context, req *schema.StreamTxRequest, send func(*schema.StreamedTx) error) error
 
 	// Maintenance
 	FlushIndex(req *schema.FlushIndexRequest) error
@@ -199,7 +207,8 @@ func OpenDB(dbName string, multidbHandler sql.MultiDBHandler, op *Options, log l
 	}
 
 	stOpts := op.GetStoreOptions().
-		WithLogger(log).
+		WithLogger(logger.With(log, logger.FieldDatabase, dbName)).
+		WithMetricsCollector(newStoreMetrics(dbName)).
 		WithExternalCommitAllowance(op.syncReplication)
 
 	dbi.st, err = store.Open(dbDir, stOpts)
@@ -313,7 +322,9 @@ func NewDB(dbName string, multidbHandler sql.MultiDBHandler, op *Options, log lo
 		return nil, logErr(dbi.Logger, "Unable to create data folder: %s", err)
 	}
 
-	stOpts := op.GetStoreOptions().WithLogger(log)
+	stOpts := op.GetStoreOptions().
+		WithLogger(logger.With(log, logger.FieldDatabase, dbName)).
+		WithMetricsCollector(newStoreMetrics(dbName))
 	// TODO: it's not currently possible to set:
 	// WithExternalCommitAllowance(op.syncReplication) due to sql init steps
 
@@ -841,6 +852,115 @@ func (d *db) VerifiableGet(ctx context.Context, req *schema.VerifiableGetRequest
 	}, nil
 }
 
+// VerifiableGetAll reads a batch of keys along with the proofs needed to verify all of them
+// against a single target transaction, the latest committed one, so verifying clients only
+// advance their state once for the whole batch
+func (d *db) VerifiableGetAll(ctx context.Context, req *schema.VerifiableGetAllRequest) (*schema.VerifiableEntries, error) {
+	if req == nil || req.KeyListRequest == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	lastTxID, _ := d.st.CommittedAlh()
+	if lastTxID < req.ProveSinceTx {
+		return nil, ErrIllegalState
+	}
+
+	entries, err := d.GetAll(ctx, req.KeyListRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	targetTxID, _ := d.st.CommittedAlh()
+
+	for _, e := range entries.Entries {
+		vTxID := e.Tx
+		if e.ReferencedBy != nil {
+			vTxID = e.ReferencedBy.Tx
+		}
+
+		if vTxID > targetTxID {
+			targetTxID = vTxID
+		}
+	}
+
+	if targetTxID == 0 {
+		return &schema.VerifiableEntries{}, nil
+	}
+
+	targetTxHdr, err := d.st.ReadTxHeader(targetTxID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := d.allocTx()
+	if err != nil {
+		return nil, err
+	}
+	defer d.releaseTx(tx)
+
+	vEntries := &schema.VerifiableEntries{
+		Entries:         entries.Entries,
+		InclusionProofs: make([]*schema.InclusionProof, len(entries.Entries)),
+	}
+
+	provenTxs := make(map[uint64]struct{})
+
+	for i, e := range entries.Entries {
+		var vTxID uint64
+		var vKey []byte
+
+		if e.ReferencedBy == nil {
+			vTxID = e.Tx
+			vKey = e.Key
+		} else {
+			vTxID = e.ReferencedBy.Tx
+			vKey = e.ReferencedBy.Key
+		}
+
+		err = d.st.ReadTx(vTxID, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		inclusionProof, err := tx.Proof(EncodeKey(vKey))
+		if err != nil {
+			return nil, err
+		}
+
+		vEntries.InclusionProofs[i] = schema.InclusionProofToProto(inclusionProof)
+
+		if _, ok := provenTxs[vTxID]; ok {
+			continue
+		}
+
+		txProof, err := d.st.DualProof(tx.Header(), targetTxHdr)
+		if err != nil {
+			return nil, err
+		}
+
+		vEntries.TxProofs = append(vEntries.TxProofs, schema.DualProofToProto(txProof))
+		provenTxs[vTxID] = struct{}{}
+	}
+
+	sourceTxHdr := targetTxHdr
+
+	if req.ProveSinceTx > 0 {
+		sourceTxHdr, err = d.st.ReadTxHeader(req.ProveSinceTx, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dualProof, err := d.st.DualProof(sourceTxHdr, targetTxHdr)
+	if err != nil {
+		return nil, err
+	}
+
+	vEntries.DualProof = schema.DualProofToProto(dualProof)
+
+	return vEntries, nil
+}
+
 func (d *db) Delete(ctx context.Context, req *schema.DeleteKeysRequest) (*schema.TxHeader, error) {
 	if req == nil {
 		return nil, ErrIllegalArguments
@@ -926,6 +1046,11 @@ func (d *db) Size() (uint64, error) {
 	return d.st.TxCount(), nil
 }
 
+// StoreStats returns internal gauges of the underlying store e.g. the indexer lag
+func (d *db) StoreStats() (*store.Stats, error) {
+	return d.st.Stats()
+}
+
 // Count ...
 func (d *db) Count(ctx context.Context, prefix *schema.KeyPrefix) (*schema.EntryCount, error) {
 	return nil, fmt.Errorf("Functionality not yet supported: %s", "Count")
@@ -988,11 +1113,15 @@ func (d *db) serializeTx(tx *store.Tx, spec *schema.EntriesSpec, snap *store.Sna
 		return schema.TxToProto(tx), nil
 	}
 
+	return d.serializeTxEntries(tx.Header(), tx.Entries(), spec, snap)
+}
+
+func (d *db) serializeTxEntries(hdr *store.TxHeader, entries []*store.TxEntry, spec *schema.EntriesSpec, snap *store.Snapshot) (*schema.Tx, error) {
 	stx := &schema.Tx{
-		Header: schema.TxHeaderToProto(tx.Header()),
+		Header: schema.TxHeaderToProto(hdr),
 	}
 
-	for _, e := range tx.Entries() {
+	for _, e := range entries {
 		switch e.Key()[0] {
 		case SetKeyPrefix:
 			{
@@ -1026,7 +1155,7 @@ func (d *db) serializeTx(tx *store.Tx, spec *schema.EntriesSpec, snap *store.Sna
 					index = snap
 				}
 
-				kve, err := d.resolveValue(e.Key(), v, 0, tx.Header().ID, e.Metadata(), index, 0)
+				kve, err := d.resolveValue(e.Key(), v, 0, hdr.ID, e.Metadata(), index, 0)
 				if err == store.ErrKeyNotFound || err == store.ErrExpiredEntry {
 					// ignore deleted ones (referenced key may have been deleted)
 					break
@@ -1324,6 +1453,31 @@ func (d *db) ReplicateTx(ctx context.Context, exportedTx []byte) (*schema.TxHead
 	return schema.TxHeaderToProto(hdr), nil
 }
 
+// ExportTxRange writes transactions fromTx to toTx (both included) into w, e.g. to
+// produce an incremental backup of the database
+func (d *db) ExportTxRange(fromTx, toTx uint64, w io.Writer) error {
+	return d.st.ExportTxRange(fromTx, toTx, w)
+}
+
+// ImportTxRange replicates the transactions exported with ExportTxRange, stopping right
+// before the first transaction for which stop returns true (when provided).
+// As with ReplicateTx, transactions can only be imported into replicas
+func (d *db) ImportTxRange(ctx context.Context, r io.Reader, stop func(hdr *store.TxHeader) bool) (*schema.TxHeader, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if !d.isReplica() {
+		return nil, ErrNotReplica
+	}
+
+	hdr, err := d.st.ImportTxRangeUntil(ctx, r, stop)
+	if hdr == nil {
+		return nil, err
+	}
+
+	return schema.TxHeaderToProto(hdr), err
+}
+
 // AllowCommitUpto is used by replicas to commit transactions once committed in primary
 func (d *db) AllowCommitUpto(txID uint64, alh [sha256.Size]byte) error {
 	d.mutex.RLock()
@@ -1398,27 +1552,16 @@ func (d *db) VerifiableTxByID(ctx context.Context, req *schema.VerifiableTxReque
 		return nil, err
 	}
 
-	var sourceTxHdr, targetTxHdr *store.TxHeader
 	var rootTxHdr *store.TxHeader
 
-	if req.ProveSinceTx == 0 {
-		rootTxHdr = reqTx.Header()
-	} else {
+	if req.ProveSinceTx > 0 {
 		rootTxHdr, err = d.st.ReadTxHeader(req.ProveSinceTx, false)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	if req.ProveSinceTx <= req.Tx {
-		sourceTxHdr = rootTxHdr
-		targetTxHdr = reqTx.Header()
-	} else {
-		sourceTxHdr = reqTx.Header()
-		targetTxHdr = rootTxHdr
-	}
-
-	dualProof, err := d.st.DualProof(sourceTxHdr, targetTxHdr)
+	dualProof, err := d.txDualProof(reqTx.Header(), rootTxHdr)
 	if err != nil {
 		return nil, err
 	}
@@ -1434,6 +1577,20 @@ func (d *db) VerifiableTxByID(ctx context.Context, req *schema.VerifiableTxReque
 	}, nil
 }
 
+// txDualProof links the transaction with the root one, the proof is made
+// against the transaction itself when there is no root transaction
+func (d *db) txDualProof(txHdr, rootTxHdr *store.TxHeader) (*store.DualProof, error) {
+	if rootTxHdr == nil {
+		rootTxHdr = txHdr
+	}
+
+	if rootTxHdr.ID <= txHdr.ID {
+		return d.st.DualProof(rootTxHdr, txHdr)
+	}
+
+	return d.st.DualProof(txHdr, rootTxHdr)
+}
+
 // TxScan ...
 func (d *db) TxScan(ctx context.Context, req *schema.TxScanRequest) (*schema.TxList, error) {
 	if req == nil {
@@ -1497,6 +1654,129 @@ func (d *db) TxScan(ctx context.Context, req *schema.TxScanRequest) (*schema.TxL
 	return txList, nil
 }
 
+// StreamTx sends each transaction committed from req.FromTx on, waiting for new transactions
+// to be committed until the context is done or sending fails
+func (d *db) StreamTx(ctx context.Context, req *schema.StreamTxRequest, send func(*schema.StreamedTx) error) error {
+	if req == nil || send == nil {
+		return ErrIllegalArguments
+	}
+
+	lastTxID, _ := d.st.CommittedAlh()
+	if lastTxID < req.ProveSinceTx {
+		return fmt.Errorf("%w: latest txID=%d is lower than specified as initial tx=%d", ErrIllegalState, lastTxID, req.ProveSinceTx)
+	}
+
+	var rootTxHdr *store.TxHeader
+	var err error
+
+	if req.ProveSinceTx > 0 {
+		rootTxHdr, err = d.st.ReadTxHeader(req.ProveSinceTx, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	// streams may wait indefinitely for new transactions, thus they don't take
+	// a holder from the pool shared by the rest of the readers
+	tx := store.NewTx(d.st.MaxTxEntries(), d.st.MaxKeyLen())
+
+	txID := req.FromTx
+	if txID == 0 {
+		txID = 1
+	}
+
+	for ; ; txID++ {
+		err = d.WaitForTx(ctx, txID, false)
+		if err != nil {
+			return err
+		}
+
+		err = d.st.ReadTx(txID, tx)
+		if err != nil {
+			return err
+		}
+
+		stx, err := d.streamedTx(ctx, tx, req, rootTxHdr)
+		if err != nil {
+			return err
+		}
+		if stx == nil {
+			// no entry is selected by the prefix
+			continue
+		}
+
+		err = send(stx)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (d *db) streamedTx(ctx context.Context, tx *store.Tx, req *schema.StreamTxRequest, rootTxHdr *store.TxHeader) (*schema.StreamedTx, error) {
+	entries := tx.Entries()
+
+	var inclusionProofs []*schema.InclusionProof
+
+	if len(req.Prefix) > 0 {
+		entries = nil
+
+		for _, e := range tx.Entries() {
+			if e.Key()[0] != SetKeyPrefix || !bytes.HasPrefix(e.Key()[1:], req.Prefix) {
+				continue
+			}
+
+			proof, err := tx.Proof(e.Key())
+			if err != nil {
+				return nil, err
+			}
+
+			entries = append(entries, e)
+			inclusionProofs = append(inclusionProofs, schema.InclusionProofToProto(proof))
+		}
+
+		if len(entries) == 0 {
+			return nil, nil
+		}
+	}
+
+	var stx *schema.Tx
+
+	if req.EntriesSpec == nil {
+		stx = &schema.Tx{
+			Header:  schema.TxHeaderToProto(tx.Header()),
+			Entries: make([]*schema.TxEntry, len(entries)),
+		}
+
+		for i, e := range entries {
+			stx.Entries[i] = schema.TxEntryToProto(e)
+		}
+	} else {
+		snap, err := d.snapshotSince(ctx, tx.Header().ID)
+		if err != nil {
+			return nil, err
+		}
+		defer snap.Close()
+
+		stx, err = d.serializeTxEntries(tx.Header(), entries, req.EntriesSpec, snap)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dualProof, err := d.txDualProof(tx.Header(), rootTxHdr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &schema.StreamedTx{
+		VerifiableTx: &schema.VerifiableTx{
+			Tx:        stx,
+			DualProof: schema.DualProofToProto(dualProof),
+		},
+		InclusionProofs: inclusionProofs,
+	}, nil
+}
+
 // History ...
 func (d *db) History(ctx context.Context, req *schema.HistoryRequest) (*schema.Entries, error) {
 	if req == nil {
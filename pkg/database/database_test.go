@@ -506,6 +506,95 @@ func TestSetGetAll(t *testing.T) {
 	}
 }
 
+func TestVerifiableGetAll(t *testing.T) {
+	db := makeDb(t)
+
+	_, err := db.VerifiableGetAll(context.Background(), nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = db.VerifiableGetAll(context.Background(), &schema.VerifiableGetAllRequest{
+		KeyListRequest: &schema.KeyListRequest{Keys: [][]byte{[]byte("key1")}},
+		ProveSinceTx:   100,
+	})
+	require.ErrorIs(t, err, ErrIllegalState)
+
+	hdr1, err := db.Set(context.Background(), &schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("key1"), Value: []byte("value1")},
+		{Key: []byte("key2"), Value: []byte("value2")},
+	}})
+	require.NoError(t, err)
+
+	hdr2, err := db.Set(context.Background(), &schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("key3"), Value: []byte("value3")},
+	}})
+	require.NoError(t, err)
+
+	_, err = db.SetReference(context.Background(), &schema.ReferenceRequest{
+		Key:           []byte("ref1"),
+		ReferencedKey: []byte("key1"),
+	})
+	require.NoError(t, err)
+
+	state, err := db.CurrentState()
+	require.NoError(t, err)
+
+	vEntries, err := db.VerifiableGetAll(context.Background(), &schema.VerifiableGetAllRequest{
+		KeyListRequest: &schema.KeyListRequest{
+			Keys:    [][]byte{[]byte("key1"), []byte("key2"), []byte("key3"), []byte("ref1"), []byte("missing")},
+			SinceTx: state.TxId,
+		},
+		ProveSinceTx: hdr1.Id,
+	})
+	require.NoError(t, err)
+	require.Len(t, vEntries.Entries, 4)
+	require.Len(t, vEntries.InclusionProofs, 4)
+	require.Len(t, vEntries.TxProofs, 3)
+
+	dualProof := schema.DualProofFromProto(vEntries.DualProof)
+	require.Equal(t, hdr1.Id, dualProof.SourceTxHeader.ID)
+	require.Equal(t, state.TxId, dualProof.TargetTxHeader.ID)
+	require.True(t, store.VerifyDualProof(
+		dualProof,
+		hdr1.Id,
+		state.TxId,
+		dualProof.SourceTxHeader.Alh(),
+		schema.DigestFromProto(state.TxHash),
+	))
+
+	txProofs := make(map[uint64]*store.DualProof)
+	for _, p := range vEntries.TxProofs {
+		txProof := schema.DualProofFromProto(p)
+		require.Equal(t, state.TxId, txProof.TargetTxHeader.ID)
+		txProofs[txProof.SourceTxHeader.ID] = txProof
+	}
+	require.Contains(t, txProofs, hdr1.Id)
+	require.Contains(t, txProofs, hdr2.Id)
+
+	for i, e := range vEntries.Entries {
+		var vTx uint64
+		var spec *store.EntrySpec
+
+		if e.ReferencedBy == nil {
+			vTx = e.Tx
+			spec = EncodeEntrySpec(e.Key, schema.KVMetadataFromProto(e.Metadata), e.Value)
+		} else {
+			vTx = e.ReferencedBy.Tx
+			spec = EncodeReference(e.ReferencedBy.Key, schema.KVMetadataFromProto(e.ReferencedBy.Metadata), e.Key, e.ReferencedBy.AtTx)
+		}
+
+		txProof := txProofs[vTx]
+
+		entrySpecDigest, err := store.EntrySpecDigestFor(txProof.SourceTxHeader.Version)
+		require.NoError(t, err)
+
+		require.True(t, store.VerifyInclusion(
+			schema.InclusionProofFromProto(vEntries.InclusionProofs[i]),
+			entrySpecDigest(spec),
+			txProof.SourceTxHeader.Eh,
+		))
+	}
+}
+
 func TestTxByID(t *testing.T) {
 	db := makeDb(t)
 
@@ -945,6 +1034,156 @@ func TestTxScan(t *testing.T) {
 	})
 }
 
+func TestStreamTx(t *testing.T) {
+	db := makeDb(t)
+
+	err := db.StreamTx(context.Background(), nil, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	initialState, err := db.CurrentState()
+	require.NoError(t, err)
+
+	_, err = db.Set(context.Background(), &schema.SetRequest{KVs: []*schema.KeyValue{
+		{Key: []byte("user:1"), Value: []byte("alice")},
+		{Key: []byte("item:1"), Value: []byte("pen")},
+	}})
+	require.NoError(t, err)
+
+	_, err = db.Set(context.Background(), &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("item:2"), Value: []byte("book")}}})
+	require.NoError(t, err)
+
+	_, err = db.Set(context.Background(), &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("user:2"), Value: []byte("bob")}}})
+	require.NoError(t, err)
+
+	collect := func(req *schema.StreamTxRequest, n int) []*schema.StreamedTx {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var stxs []*schema.StreamedTx
+
+		err := db.StreamTx(ctx, req, func(stx *schema.StreamedTx) error {
+			stxs = append(stxs, stx)
+			if len(stxs) == n {
+				cancel()
+			}
+			return nil
+		})
+		require.ErrorIs(t, err, context.Canceled)
+
+		return stxs
+	}
+
+	t.Run("committed transactions should be streamed", func(t *testing.T) {
+		stxs := collect(&schema.StreamTxRequest{FromTx: initialState.TxId + 1}, 3)
+		require.Len(t, stxs, 3)
+
+		for i, stx := range stxs {
+			require.Equal(t, initialState.TxId+1+uint64(i), stx.VerifiableTx.Tx.Header.Id)
+			require.Empty(t, stx.InclusionProofs)
+		}
+
+		require.Len(t, stxs[0].VerifiableTx.Tx.Entries, 2)
+	})
+
+	t.Run("only entries with the prefix should be streamed", func(t *testing.T) {
+		stxs := collect(&schema.StreamTxRequest{
+			FromTx:       initialState.TxId + 1,
+			Prefix:       []byte("user:"),
+			ProveSinceTx: initialState.TxId,
+			EntriesSpec: &schema.EntriesSpec{
+				KvEntriesSpec: &schema.EntryTypeSpec{Action: schema.EntryTypeAction_RAW_VALUE},
+			},
+		}, 2)
+		require.Len(t, stxs, 2)
+
+		sourceHdr, err := db.st.ReadTxHeader(initialState.TxId, false)
+		require.NoError(t, err)
+
+		for i, stx := range stxs {
+			hdr := schema.TxHeaderFromProto(stx.VerifiableTx.Tx.Header)
+			require.Equal(t, []uint64{initialState.TxId + 1, initialState.TxId + 3}[i], hdr.ID)
+
+			require.Len(t, stx.VerifiableTx.Tx.Entries, 1)
+			require.Len(t, stx.InclusionProofs, 1)
+
+			e := stx.VerifiableTx.Tx.Entries[0]
+			require.Equal(t, []string{"user:1", "user:2"}[i], string(TrimPrefix(e.Key)))
+
+			entrySpecDigest, err := store.EntrySpecDigestFor(hdr.Version)
+			require.NoError(t, err)
+
+			digest := entrySpecDigest(&store.EntrySpec{Key: e.Key, Value: e.Value})
+			require.True(t, store.VerifyInclusion(schema.InclusionProofFromProto(stx.InclusionProofs[0]), digest, hdr.Eh))
+
+			dualProof := schema.DualProofFromProto(stx.VerifiableTx.DualProof)
+			require.True(t, store.VerifyDualProof(dualProof, sourceHdr.ID, hdr.ID, sourceHdr.Alh(), hdr.Alh()))
+		}
+	})
+
+	t.Run("transactions should be streamed as they get committed", func(t *testing.T) {
+		fromTx := initialState.TxId + 4
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+
+			_, err := db.Set(context.Background(), &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("user:3"), Value: []byte("carol")}}})
+			require.NoError(t, err)
+		}()
+
+		stxs := collect(&schema.StreamTxRequest{FromTx: fromTx}, 1)
+		require.Len(t, stxs, 1)
+		require.Equal(t, fromTx, stxs[0].VerifiableTx.Tx.Header.Id)
+	})
+
+	t.Run("sending errors should end the stream", func(t *testing.T) {
+		errSend := errors.New("send error")
+
+		err := db.StreamTx(context.Background(), &schema.StreamTxRequest{FromTx: 1}, func(stx *schema.StreamedTx) error {
+			return errSend
+		})
+		require.ErrorIs(t, err, errSend)
+	})
+
+	t.Run("proofs can not be generated since an uncommitted transaction", func(t *testing.T) {
+		err := db.StreamTx(context.Background(), &schema.StreamTxRequest{ProveSinceTx: 100}, func(stx *schema.StreamedTx) error {
+			return nil
+		})
+		require.ErrorIs(t, err, ErrIllegalState)
+	})
+
+	t.Run("waiting streams should not exhaust the read tx pool", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		state, err := db.CurrentState()
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < DefaultReadTxPoolSize+1; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				err := db.StreamTx(ctx, &schema.StreamTxRequest{FromTx: state.TxId + 1}, func(stx *schema.StreamedTx) error {
+					return nil
+				})
+				require.ErrorIs(t, err, context.Canceled)
+			}()
+		}
+
+		// streams get to wait for the next transaction
+		time.Sleep(100 * time.Millisecond)
+
+		tx, err := db.TxByID(context.Background(), &schema.TxRequest{Tx: state.TxId})
+		require.NoError(t, err)
+		require.Equal(t, state.TxId, tx.Header.Id)
+
+		cancel()
+		wg.Wait()
+	})
+}
+
 func TestHistory(t *testing.T) {
 	db := makeDb(t)
 
@@ -168,6 +168,12 @@ func (d *db) VerifiableSQLGet(ctx context.Context, req *schema.VerifiableSQLGetR
 		colLenByID[col.ID()] = int32(col.MaxLen())
 	}
 
+	// rows stored before a column was dropped still hold its values
+	for _, col := range table.DroppedColsByID() {
+		colTypesByID[col.ID()] = col.Type()
+		colLenByID[col.ID()] = int32(col.MaxLen())
+	}
+
 	pkIDs := make([]uint32, len(table.PrimaryIndex().Cols()))
 
 	for i, col := range table.PrimaryIndex().Cols() {
@@ -309,10 +315,14 @@ func (d *db) DescribeTable(ctx context.Context, tx *sql.SQLTx, tableName string)
 
 		var maxLen string
 
-		if c.MaxLen() > 0 && (c.Type() == sql.VarcharType || c.Type() == sql.BLOBType) {
+		if c.MaxLen() > 0 && (c.Type() == sql.VarcharType || c.Type() == sql.BLOBType || c.Type() == sql.JSONType) {
 			maxLen = fmt.Sprintf("[%d]", c.MaxLen())
 		}
 
+		if c.Type() == sql.DecimalType {
+			maxLen = fmt.Sprintf("(%d,%d)", c.Precision(), c.Scale())
+		}
+
 		res.Rows = append(res.Rows, &schema.Row{
 			Values: []*schema.SQLValue{
 				{Value: &schema.SQLValue_S{S: c.Name()}},
@@ -546,7 +556,7 @@ func typedValueToRowValue(tv sql.TypedValue) *schema.SQLValue {
 		{
 			return &schema.SQLValue{Value: &schema.SQLValue_N{N: tv.Value().(int64)}}
 		}
-	case sql.VarcharType:
+	case sql.VarcharType, sql.JSONType, sql.DecimalType:
 		{
 			return &schema.SQLValue{Value: &schema.SQLValue_S{S: tv.Value().(string)}}
 		}
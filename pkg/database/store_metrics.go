@@ -0,0 +1,98 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"time"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	metricsStoreCommitDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "immudb_store_commit_duration_seconds",
+		Help:    "Time taken to durably commit a transaction",
+		Buckets: prometheus.ExponentialBucketsRange(0.0001, 10.0, 16),
+	}, []string{"db"})
+
+	metricsStoreTxEntries = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "immudb_store_tx_entries",
+		Help:    "Number of entries per committed transaction",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{"db"})
+
+	metricsStoreValueLogBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "immudb_store_vlog_written_bytes_total",
+		Help: "Number of bytes written into the value logs",
+	}, []string{"db"})
+
+	metricsStoreIndexerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "immudb_store_indexer_lag",
+		Help: "Number of committed transactions not yet indexed",
+	}, []string{"db"})
+
+	metricsStoreCacheAccesses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "immudb_store_cache_accesses_total",
+		Help: "Number of lookups into the store caches",
+	}, []string{"db", "cache", "result"})
+)
+
+// storeMetrics exposes the metrics reported by the store of a database through prometheus
+type storeMetrics struct {
+	db string
+
+	commitDuration prometheus.Observer
+	txEntries      prometheus.Observer
+	valueLogBytes  prometheus.Counter
+	indexerLag     prometheus.Gauge
+}
+
+func newStoreMetrics(db string) *storeMetrics {
+	return &storeMetrics{
+		db:             db,
+		commitDuration: metricsStoreCommitDuration.WithLabelValues(db),
+		txEntries:      metricsStoreTxEntries.WithLabelValues(db),
+		valueLogBytes:  metricsStoreValueLogBytes.WithLabelValues(db),
+		indexerLag:     metricsStoreIndexerLag.WithLabelValues(db),
+	}
+}
+
+var _ store.MetricsCollector = (*storeMetrics)(nil)
+
+func (m *storeMetrics) ObserveCommit(latency time.Duration, entries int) {
+	m.commitDuration.Observe(latency.Seconds())
+	m.txEntries.Observe(float64(entries))
+}
+
+func (m *storeMetrics) AddValueLogBytes(n int) {
+	m.valueLogBytes.Add(float64(n))
+}
+
+func (m *storeMetrics) SetIndexerLag(txs uint64) {
+	m.indexerLag.Set(float64(txs))
+}
+
+func (m *storeMetrics) ObserveCacheAccess(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+
+	metricsStoreCacheAccesses.WithLabelValues(m.db, cache, result).Inc()
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreMetrics(t *testing.T) {
+	options := DefaultOption().WithDBRootPath(t.TempDir())
+
+	db := makeDbWith(t, "store-metrics-db", options)
+
+	_, err := db.Set(context.Background(), &schema.SetRequest{
+		KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}},
+	})
+	require.NoError(t, err)
+
+	require.GreaterOrEqual(t, testutil.ToFloat64(metricsStoreValueLogBytes.WithLabelValues("store-metrics-db")), float64(len("value1")))
+	require.GreaterOrEqual(t, testutil.CollectAndCount(metricsStoreCommitDuration), 1)
+}
@@ -19,6 +19,7 @@ package integration
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -971,6 +972,79 @@ func TestImmuClient_GetAll(t *testing.T) {
 	require.Len(t, entries.Entries, 2)
 }
 
+func TestImmuClient_VerifiedGetAll(t *testing.T) {
+	bs, client, ctx := setupTestServerAndClient(t)
+
+	entries, err := client.VerifiedGetAll(ctx, [][]byte{[]byte(`aaa`)})
+	require.NoError(t, err)
+	require.Empty(t, entries.Entries)
+
+	for i := 0; i < 10; i++ {
+		_, err = client.Set(ctx, []byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("val%d", i)))
+		require.NoError(t, err)
+	}
+
+	_, err = client.SetReference(ctx, []byte(`ref`), []byte(`key0`))
+	require.NoError(t, err)
+
+	keys := [][]byte{[]byte(`ref`), []byte(`missing`)}
+	for i := 0; i < 10; i++ {
+		keys = append(keys, []byte(fmt.Sprintf("key%d", i)))
+	}
+
+	entries, err = client.VerifiedGetAll(ctx, keys)
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 11)
+	require.Equal(t, []byte(`key0`), entries.Entries[0].Key)
+	require.Equal(t, []byte(`val0`), entries.Entries[0].Value)
+
+	state, err := client.CurrentState(ctx)
+	require.NoError(t, err)
+
+	lastTx, err := client.Set(ctx, []byte(`key0`), []byte(`val10`))
+	require.NoError(t, err)
+
+	entries, err = client.VerifiedGetAll(ctx, keys[2:])
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 10)
+	require.Equal(t, []byte(`val10`), entries.Entries[0].Value)
+
+	newState, err := client.CurrentState(ctx)
+	require.NoError(t, err)
+	require.Greater(t, newState.TxId, state.TxId)
+	require.Equal(t, lastTx.Id, newState.TxId)
+
+	bs.Server.PostVerifiableGetAllFn = func(ctx context.Context,
+		req *schema.VerifiableGetAllRequest, res *schema.VerifiableEntries, err error) (*schema.VerifiableEntries, error) {
+
+		if err != nil {
+			return res, err
+		}
+
+		res.Entries[1].Value = []byte(`tampered`)
+
+		return res, nil
+	}
+
+	_, err = client.VerifiedGetAll(ctx, keys[2:])
+	require.ErrorIs(t, err, store.ErrCorruptedData)
+
+	bs.Server.PostVerifiableGetAllFn = func(ctx context.Context,
+		req *schema.VerifiableGetAllRequest, res *schema.VerifiableEntries, err error) (*schema.VerifiableEntries, error) {
+
+		if err != nil {
+			return res, err
+		}
+
+		res.Entries[1].Key = []byte(`notrequested`)
+
+		return res, nil
+	}
+
+	_, err = client.VerifiedGetAll(ctx, keys[2:])
+	require.ErrorIs(t, err, store.ErrCorruptedData)
+}
+
 func TestImmuClient_Delete(t *testing.T) {
 	_, client, ctx := setupTestServerAndClient(t)
 
@@ -0,0 +1,156 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ic "github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/server/servertest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func setupClusterTestServer(t *testing.T) (*servertest.BufconnServer, *ic.Options) {
+	bs := servertest.NewBufconnServer(server.DefaultOptions().WithDir(t.TempDir()))
+
+	bs.Start()
+	t.Cleanup(func() { bs.Stop() })
+
+	opts := ic.DefaultOptions().
+		WithDir(t.TempDir()).
+		WithDialOptions([]grpc.DialOption{grpc.WithContextDialer(bs.Dialer), grpc.WithInsecure()})
+
+	return bs, opts
+}
+
+func TestClusterClient(t *testing.T) {
+	_, primaryOpts := setupClusterTestServer(t)
+	replica, replicaOpts := setupClusterTestServer(t)
+
+	ctx := context.Background()
+
+	_, err := ic.NewClusterClient(ic.DefaultClusterOptions().WithPoolSize(0))
+	require.ErrorIs(t, err, ic.ErrIllegalArguments)
+
+	cc, err := ic.NewClusterClient(ic.DefaultClusterOptions().
+		WithPrimary(primaryOpts).
+		WithReplicas(replicaOpts).
+		WithPoolSize(2).
+		WithHealthCheckInterval(100 * time.Millisecond).
+		WithRetryBackoff(10 * time.Millisecond).
+		WithMaxRetryBackoff(50 * time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	err = cc.Read(ctx, func(client ic.ImmuClient) error { return nil })
+	require.ErrorIs(t, err, ic.ErrNotConnected)
+
+	err = cc.OpenSession(ctx, []byte("immudb"), []byte("immudb"), "defaultdb")
+	require.NoError(t, err)
+
+	err = cc.OpenSession(ctx, []byte("immudb"), []byte("immudb"), "defaultdb")
+	require.ErrorIs(t, err, ic.ErrSessionAlreadyOpen)
+
+	t.Run("writes are sent to the primary", func(t *testing.T) {
+		err := cc.Write(ctx, func(client ic.ImmuClient) error {
+			_, err := client.Set(ctx, []byte("key"), []byte("primary"))
+			return err
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("reads are routed to replicas", func(t *testing.T) {
+		replicaClient, err := replica.NewAuthenticatedClient(ic.DefaultOptions().WithDir(t.TempDir()))
+		require.NoError(t, err)
+		defer replicaClient.CloseSession(ctx)
+
+		_, err = replicaClient.Set(ctx, []byte("key"), []byte("replica"))
+		require.NoError(t, err)
+
+		for i := 0; i < 4; i++ {
+			err = cc.Read(ctx, func(client ic.ImmuClient) error {
+				entry, err := client.Get(ctx, []byte("key"))
+				if err != nil {
+					return err
+				}
+
+				require.Equal(t, []byte("replica"), entry.Value)
+				return nil
+			})
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("transient errors are retried with idempotent operations only", func(t *testing.T) {
+		calls := 0
+
+		err := cc.IdempotentWrite(ctx, func(client ic.ImmuClient) error {
+			calls++
+			if calls < 3 {
+				return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, calls)
+
+		calls = 0
+
+		err = cc.Write(ctx, func(client ic.ImmuClient) error {
+			calls++
+			return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+		})
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+		require.Equal(t, 1, calls)
+
+		calls = 0
+
+		err = cc.Read(ctx, func(client ic.ImmuClient) error {
+			calls++
+			return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+		})
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+		require.Equal(t, ic.DefaultClusterOptions().MaxRetries+1, calls)
+	})
+
+	t.Run("reads fail over to the primary", func(t *testing.T) {
+		replica.Stop()
+
+		err := cc.Read(ctx, func(client ic.ImmuClient) error {
+			entry, err := client.Get(ctx, []byte("key"))
+			if err != nil {
+				return err
+			}
+
+			require.Equal(t, []byte("primary"), entry.Value)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	err = cc.CloseSession(ctx)
+	require.NoError(t, err)
+
+	err = cc.CloseSession(ctx)
+	require.ErrorIs(t, err, ic.ErrNotConnected)
+}
@@ -174,6 +174,23 @@ func TestReplication(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("replicadb@replica should report its replication lag", func(t *testing.T) {
+		health, err := replicaClient.Health(rctx)
+		require.NoError(t, err)
+		require.NotZero(t, health.ReplicatedTxId)
+		require.GreaterOrEqual(t, health.PrimaryTxId, health.ReplicatedTxId)
+		require.Equal(t, health.PrimaryTxId-health.ReplicatedTxId, health.ReplicationLag)
+	})
+
+	t.Run("reads with a consistency token should observe the write", func(t *testing.T) {
+		hdr, err := primaryClient.Set(pctx, []byte("key3"), []byte("value3"))
+		require.NoError(t, err)
+
+		entry, err := replicaClient.Get(ic.WithConsistencyToken(rctx, hdr.Id), []byte("key3"))
+		require.NoError(t, err)
+		require.Equal(t, []byte("value3"), entry.Value)
+	})
+
 	fdb, err = replicaClient.UseDatabase(rctx, &schema.Database{DatabaseName: "defaultdb"})
 	require.NoError(t, err)
 	require.NotNil(t, fdb)
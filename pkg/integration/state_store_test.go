@@ -0,0 +1,83 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	ic "github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/client/cache"
+	"github.com/codenotary/immudb/pkg/server"
+	"github.com/codenotary/immudb/pkg/server/servertest"
+	"github.com/stretchr/testify/require"
+)
+
+type testKVStore map[string][]byte
+
+func (s testKVStore) Get(key string) ([]byte, error) {
+	v, ok := s[key]
+	if !ok {
+		return nil, cache.ErrKeyNotFound
+	}
+	return v, nil
+}
+
+func (s testKVStore) Set(key string, value []byte) error {
+	s[key] = value
+	return nil
+}
+
+func TestImmuClient_StateStore(t *testing.T) {
+	bs := servertest.NewBufconnServer(server.DefaultOptions().WithDir(t.TempDir()))
+
+	bs.Start()
+	defer bs.Stop()
+
+	for name, newStore := range map[string]func() cache.Cache{
+		"in-memory": cache.NewInMemoryCache,
+		"kv":        func() cache.Cache { return cache.NewKVCache(testKVStore{}) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			store := newStore()
+			dir := t.TempDir()
+
+			client, err := bs.NewAuthenticatedClient(ic.DefaultOptions().WithDir(dir).WithStateStore(store))
+			require.NoError(t, err)
+			defer client.CloseSession(ctx)
+
+			hdr, err := client.VerifiedSet(ctx, []byte("key"), []byte("value"))
+			require.NoError(t, err)
+
+			entry, err := client.VerifiedGet(ctx, []byte("key"))
+			require.NoError(t, err)
+			require.Equal(t, []byte("value"), entry.Value)
+
+			// no state files are written
+			files, err := ioutil.ReadDir(dir)
+			require.NoError(t, err)
+			require.Empty(t, files)
+
+			state, err := store.Get(bs.Server.Srv.UUID.String(), "defaultdb")
+			require.NoError(t, err)
+			require.Equal(t, hdr.Id, state.TxId)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"math/rand"
+	"testing"
+
+	ic "github.com/codenotary/immudb/pkg/client"
+	"github.com/codenotary/immudb/pkg/stream"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmuClient_VerifiedSetGetLargeValue(t *testing.T) {
+	_, client := setupTest(t)
+
+	ctx := context.Background()
+
+	// larger than both the maximum size of a value and the data sent in a single stream
+	size := int64(stream.MaxTxValueLen + 3*ic.DefaultLargeValueChunkSize + 100)
+
+	hOrig := sha256.New()
+	value := io.TeeReader(io.LimitReader(rand.New(rand.NewSource(1)), size), hOrig)
+
+	hdr, err := client.VerifiedSetLargeValue(ctx, []byte("artifact"), value)
+	require.NoError(t, err)
+	require.NotNil(t, hdr)
+
+	// chunk keys written afterwards don't affect the value
+	_, err = client.Set(ctx, append([]byte("artifact"), []byte("/__lvchunk/\x00\x00\x00\x00")...), []byte("overwritten"))
+	require.NoError(t, err)
+
+	hRead := sha256.New()
+
+	entry, err := client.VerifiedGetLargeValue(ctx, []byte("artifact"), hRead)
+	require.NoError(t, err)
+	require.Equal(t, hdr.Id, entry.Tx)
+	require.Nil(t, entry.Value)
+	require.Equal(t, hOrig.Sum(nil), hRead.Sum(nil))
+
+	t.Run("empty value", func(t *testing.T) {
+		_, err := client.VerifiedSetLargeValue(ctx, []byte("empty"), bytes.NewReader(nil))
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+
+		_, err = client.VerifiedGetLargeValue(ctx, []byte("empty"), &buf)
+		require.NoError(t, err)
+		require.Zero(t, buf.Len())
+	})
+
+	t.Run("not a large value", func(t *testing.T) {
+		_, err := client.VerifiedSet(ctx, []byte("small"), []byte("value"))
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+
+		_, err = client.VerifiedGetLargeValue(ctx, []byte("small"), &buf)
+		require.ErrorIs(t, err, ic.ErrNotALargeValue)
+	})
+
+	t.Run("illegal arguments", func(t *testing.T) {
+		_, err := client.VerifiedSetLargeValue(ctx, nil, bytes.NewReader(nil))
+		require.ErrorIs(t, err, ic.ErrIllegalArguments)
+
+		_, err = client.VerifiedGetLargeValue(ctx, []byte("artifact"), nil)
+		require.ErrorIs(t, err, ic.ErrIllegalArguments)
+	})
+}
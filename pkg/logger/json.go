@@ -51,7 +51,7 @@ var (
 	defaultOutput io.Writer = os.Stderr
 )
 
-var _ Logger = (*JsonLogger)(nil)
+var _ StructuredLogger = (*JsonLogger)(nil)
 
 // JsonLogger is a logger implementation for json logging.
 type JsonLogger struct {
@@ -129,7 +129,7 @@ func (l *JsonLogger) logJSON(t time.Time, name string, level LogLevel, msg strin
 	vals := l.getVals(t, name, level, msg)
 
 	if args != nil && len(args) > 0 {
-		for i := 0; i < len(args); i = i + 2 {
+		for i := 0; i+1 < len(args); i = i + 2 {
 			val := args[i+1]
 			switch sv := val.(type) {
 			case error:
@@ -231,6 +231,50 @@ func (l *JsonLogger) Errorf(msg string, args ...interface{}) {
 	l.logWithFmt(l.Name(), LogError, msg, args...)
 }
 
+// With returns a logger writing the key-value pairs in every entry
+func (l *JsonLogger) With(keysAndValues ...interface{}) Logger {
+	return &jsonFieldsLogger{l: l, fields: keysAndValues}
+}
+
+func (l *JsonLogger) logWithFields(fields []interface{}, level LogLevel, msg string, args ...interface{}) {
+	l.log(l.Name(), level, fmt.Sprintf(msg, args...), fields...)
+}
+
+// jsonFieldsLogger shares the output of the JsonLogger it was derived from
+type jsonFieldsLogger struct {
+	l      *JsonLogger
+	fields []interface{}
+}
+
+func (fl *jsonFieldsLogger) Debugf(msg string, args ...interface{}) {
+	fl.l.logWithFields(fl.fields, LogDebug, msg, args...)
+}
+
+func (fl *jsonFieldsLogger) Infof(msg string, args ...interface{}) {
+	fl.l.logWithFields(fl.fields, LogInfo, msg, args...)
+}
+
+func (fl *jsonFieldsLogger) Warningf(msg string, args ...interface{}) {
+	fl.l.logWithFields(fl.fields, LogWarn, msg, args...)
+}
+
+func (fl *jsonFieldsLogger) Errorf(msg string, args ...interface{}) {
+	fl.l.logWithFields(fl.fields, LogError, msg, args...)
+}
+
+func (fl *jsonFieldsLogger) With(keysAndValues ...interface{}) Logger {
+	fields := make([]interface{}, 0, len(fl.fields)+len(keysAndValues))
+	fields = append(fields, fl.fields...)
+	fields = append(fields, keysAndValues...)
+
+	return &jsonFieldsLogger{l: fl.l, fields: fields}
+}
+
+// Close is a no-op, the output is owned by the parent logger
+func (fl *jsonFieldsLogger) Close() error {
+	return nil
+}
+
 // Update the logging level
 func (l *JsonLogger) SetLogLevel(level LogLevel) {
 	atomic.StoreInt32(&l.level, int32(level))
@@ -29,6 +29,33 @@ import (
 )
 
 func TestJSONLogger(t *testing.T) {
+	t.Run("log with fields", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := NewJSONLogger(&Options{
+			Name:   "test",
+			Output: &buf,
+		})
+		require.NoError(t, err)
+
+		l := With(logger, FieldDatabase, "db1").(StructuredLogger)
+		l = l.With(FieldTxID, 10).(StructuredLogger)
+
+		l.Warningf("test call %d", 1)
+
+		var raw map[string]interface{}
+		err = json.Unmarshal(buf.Bytes(), &raw)
+		require.NoError(t, err)
+
+		require.Equal(t, "test call 1", raw["message"])
+		require.Equal(t, "warn", raw["level"])
+		require.Equal(t, "db1", raw[FieldDatabase])
+		require.Equal(t, float64(10), raw[FieldTxID])
+		require.Contains(t, raw["caller"], "json_test.go")
+
+		require.NoError(t, l.Close())
+		require.NoError(t, logger.Close())
+	})
+
 	t.Run("log", func(t *testing.T) {
 		var buf bytes.Buffer
 		logger, err := NewJSONLogger(&Options{
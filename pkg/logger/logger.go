@@ -55,6 +55,33 @@ type Logger interface {
 	Close() error
 }
 
+// StructuredLogger is a Logger able to attach contextual fields to every entry
+type StructuredLogger interface {
+	Logger
+
+	// With returns a logger which includes the given key-value pairs in every entry
+	With(keysAndValues ...interface{}) Logger
+}
+
+// Keys of the contextual fields attached to the logs of the engine
+const (
+	FieldDatabase  = "database"
+	FieldComponent = "component"
+	FieldTxID      = "txID"
+)
+
+// With returns a logger which includes the given key-value pairs in every entry.
+// Loggers not implementing StructuredLogger are returned as they are, so the
+// output of plain text loggers is not altered
+func With(l Logger, keysAndValues ...interface{}) Logger {
+	sl, ok := l.(StructuredLogger)
+	if !ok || len(keysAndValues) == 0 {
+		return l
+	}
+
+	return sl.With(keysAndValues...)
+}
+
 func LogLevelFromEnvironment() LogLevel {
 	logLevel, _ := os.LookupEnv("LOG_LEVEL")
 	switch strings.ToLower(logLevel) {
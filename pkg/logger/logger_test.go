@@ -121,3 +121,11 @@ func TestNewLoggerWithFile(t *testing.T) {
 		})
 	}
 }
+
+func TestWithPlainLogger(t *testing.T) {
+	l := NewMemoryLogger()
+
+	if With(l, FieldDatabase, "db1") != Logger(l) {
+		t.Fatal("plain loggers must be returned unchanged")
+	}
+}
@@ -0,0 +1,62 @@
+//go:build go1.21
+
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+var _ StructuredLogger = (*SlogLogger)(nil)
+
+// SlogLogger routes the logs through a log/slog logger
+type SlogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlogLogger returns a logger writing to the given slog logger,
+// levels and contextual fields are mapped to their slog counterparts
+func NewSlogLogger(l *slog.Logger) *SlogLogger {
+	return &SlogLogger{l: l}
+}
+
+func (l *SlogLogger) Debugf(msg string, args ...interface{}) {
+	l.l.Debug(fmt.Sprintf(msg, args...))
+}
+
+func (l *SlogLogger) Infof(msg string, args ...interface{}) {
+	l.l.Info(fmt.Sprintf(msg, args...))
+}
+
+func (l *SlogLogger) Warningf(msg string, args ...interface{}) {
+	l.l.Warn(fmt.Sprintf(msg, args...))
+}
+
+func (l *SlogLogger) Errorf(msg string, args ...interface{}) {
+	l.l.Error(fmt.Sprintf(msg, args...))
+}
+
+func (l *SlogLogger) With(keysAndValues ...interface{}) Logger {
+	return &SlogLogger{l: l.l.With(keysAndValues...)}
+}
+
+// Close is a no-op, the handler is owned by the caller
+func (l *SlogLogger) Close() error {
+	return nil
+}
@@ -0,0 +1,51 @@
+//go:build go1.21
+
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	sl := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	l := With(NewSlogLogger(sl), FieldComponent, "store")
+
+	l.Debugf("not logged")
+	require.Zero(t, buf.Len())
+
+	l.Errorf("failure at %s", "somewhere")
+
+	var raw map[string]interface{}
+	err := json.Unmarshal(buf.Bytes(), &raw)
+	require.NoError(t, err)
+
+	require.Equal(t, "failure at somewhere", raw["msg"])
+	require.Equal(t, "ERROR", raw["level"])
+	require.Equal(t, "store", raw[FieldComponent])
+
+	require.NoError(t, l.Close())
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import "fmt"
+
+// ZapSugaredLogger is the subset of *zap.SugaredLogger used by ZapLogger,
+// it keeps this package from depending on zap
+type ZapSugaredLogger interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+	Sync() error
+}
+
+var _ StructuredLogger = (*ZapLogger)(nil)
+
+// ZapLogger routes the logs through a zap sugared logger
+type ZapLogger struct {
+	l      ZapSugaredLogger
+	fields []interface{}
+}
+
+// NewZapLogger returns a logger writing to the given zap sugared logger e.g. zap.S()
+func NewZapLogger(l ZapSugaredLogger) *ZapLogger {
+	return &ZapLogger{l: l}
+}
+
+func (l *ZapLogger) Debugf(msg string, args ...interface{}) {
+	l.l.Debugw(fmt.Sprintf(msg, args...), l.fields...)
+}
+
+func (l *ZapLogger) Infof(msg string, args ...interface{}) {
+	l.l.Infow(fmt.Sprintf(msg, args...), l.fields...)
+}
+
+func (l *ZapLogger) Warningf(msg string, args ...interface{}) {
+	l.l.Warnw(fmt.Sprintf(msg, args...), l.fields...)
+}
+
+func (l *ZapLogger) Errorf(msg string, args ...interface{}) {
+	l.l.Errorw(fmt.Sprintf(msg, args...), l.fields...)
+}
+
+func (l *ZapLogger) With(keysAndValues ...interface{}) Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(keysAndValues))
+	fields = append(fields, l.fields...)
+	fields = append(fields, keysAndValues...)
+
+	return &ZapLogger{l: l.l, fields: fields}
+}
+
+// Close flushes any buffered entry
+func (l *ZapLogger) Close() error {
+	return l.l.Sync()
+}
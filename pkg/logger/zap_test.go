@@ -0,0 +1,77 @@
+/*
+Copyright 2022 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type zapEntry struct {
+	level         string
+	msg           string
+	keysAndValues []interface{}
+}
+
+type zapSugaredLoggerMock struct {
+	entries []zapEntry
+	synced  bool
+}
+
+func (m *zapSugaredLoggerMock) Debugw(msg string, keysAndValues ...interface{}) {
+	m.entries = append(m.entries, zapEntry{"debug", msg, keysAndValues})
+}
+
+func (m *zapSugaredLoggerMock) Infow(msg string, keysAndValues ...interface{}) {
+	m.entries = append(m.entries, zapEntry{"info", msg, keysAndValues})
+}
+
+func (m *zapSugaredLoggerMock) Warnw(msg string, keysAndValues ...interface{}) {
+	m.entries = append(m.entries, zapEntry{"warn", msg, keysAndValues})
+}
+
+func (m *zapSugaredLoggerMock) Errorw(msg string, keysAndValues ...interface{}) {
+	m.entries = append(m.entries, zapEntry{"error", msg, keysAndValues})
+}
+
+func (m *zapSugaredLoggerMock) Sync() error {
+	m.synced = true
+	return nil
+}
+
+func TestZapLogger(t *testing.T) {
+	zl := &zapSugaredLoggerMock{}
+
+	l := NewZapLogger(zl)
+	l.Infof("opening %s", "db1")
+
+	dbl := With(l, FieldDatabase, "db1")
+	With(dbl, FieldTxID, uint64(3)).Warningf("tx %d", 3)
+	dbl.Debugf("debug")
+	dbl.Errorf("error")
+
+	require.Equal(t, []zapEntry{
+		{"info", "opening db1", nil},
+		{"warn", "tx 3", []interface{}{FieldDatabase, "db1", FieldTxID, uint64(3)}},
+		{"debug", "debug", []interface{}{FieldDatabase, "db1"}},
+		{"error", "error", []interface{}{FieldDatabase, "db1"}},
+	}, zl.entries)
+
+	require.NoError(t, l.Close())
+	require.True(t, zl.synced)
+}
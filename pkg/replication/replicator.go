@@ -25,6 +25,7 @@ import (
 	"io"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
@@ -48,6 +49,10 @@ type prefetchTxEntry struct {
 }
 
 type TxReplicator struct {
+	// accessed atomically as fetching txs may block while waiting for new ones,
+	// kept first for 64-bit alignment
+	primaryCommittedTxID uint64
+
 	uuid xid.ID
 
 	db   database.DB
@@ -369,8 +374,9 @@ func (txr *TxReplicator) fetchNextTx() error {
 		return err
 	}
 
+	md := exportTxStream.Trailer()
+
 	if syncReplicationEnabled {
-		md := exportTxStream.Trailer()
 
 		if len(md.Get("may-commit-up-to-txid-bin")) == 0 ||
 			len(md.Get("may-commit-up-to-alh-bin")) == 0 ||
@@ -390,7 +396,7 @@ func (txr *TxReplicator) fetchNextTx() error {
 		var mayCommitUpToAlh [sha256.Size]byte
 		copy(mayCommitUpToAlh[:], []byte(md.Get("may-commit-up-to-alh-bin")[0]))
 
-		txr.metrics.primaryCommittedTxID.Set(float64(committedTxID))
+		txr.setPrimaryCommittedTxID(committedTxID)
 		txr.metrics.allowCommitUpToTxID.Set(float64(mayCommitUpToTxID))
 
 		if mayCommitUpToTxID > commitState.TxId {
@@ -404,6 +410,11 @@ func (txr *TxReplicator) fetchNextTx() error {
 				return err
 			}
 		}
+	} else if len(md.Get("committed-txid-bin")) > 0 && len(md.Get("committed-txid-bin")[0]) == 8 {
+		txr.setPrimaryCommittedTxID(binary.BigEndian.Uint64([]byte(md.Get("committed-txid-bin")[0])))
+	} else if len(etx) > 0 && txr.lastTx+1 > txr.PrimaryCommittedTxID() {
+		// primaries not reporting their commit state have at least committed the exported tx
+		txr.setPrimaryCommittedTxID(txr.lastTx + 1)
 	}
 
 	if len(etx) > 0 {
@@ -418,6 +429,17 @@ func (txr *TxReplicator) fetchNextTx() error {
 	return nil
 }
 
+func (txr *TxReplicator) setPrimaryCommittedTxID(txID uint64) {
+	atomic.StoreUint64(&txr.primaryCommittedTxID, txID)
+	txr.metrics.primaryCommittedTxID.Set(float64(txID))
+}
+
+// PrimaryCommittedTxID returns the id of the latest transaction known to be committed
+// on the primary, zero if it's not known yet
+func (txr *TxReplicator) PrimaryCommittedTxID() uint64 {
+	return atomic.LoadUint64(&txr.primaryCommittedTxID)
+}
+
 func (txr *TxReplicator) Stop() error {
 	if txr.cancelFunc != nil {
 		txr.cancelFunc()
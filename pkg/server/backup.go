@@ -0,0 +1,148 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/backup"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+func (s *ImmuServer) createBackupTarget() (backup.Target, error) {
+	opts := s.Options.BackupOptions
+
+	if opts.RemoteStorage != nil {
+		storage, err := openRemoteStorage(opts.RemoteStorage)
+		if err != nil {
+			return nil, err
+		}
+		if storage == nil {
+			return nil, ErrIllegalArguments
+		}
+
+		return backup.NewRemoteTarget(storage), nil
+	}
+
+	return backup.NewDirTarget(opts.Dir), nil
+}
+
+// startBackups enables backups, which are also scheduled if an interval is set
+func (s *ImmuServer) startBackups() error {
+	if !s.Options.BackupOptions.isEnabled() {
+		return nil
+	}
+
+	target, err := s.createBackupTarget()
+	if err != nil {
+		return err
+	}
+
+	s.backupScheduler = backup.NewScheduler(target, s.Options.BackupOptions.Interval, s.backedUpDatabases, s.Logger)
+
+	if s.Options.BackupOptions.Interval <= 0 {
+		return nil
+	}
+
+	return s.backupScheduler.Start()
+}
+
+func (s *ImmuServer) stopBackups() {
+	if s.backupScheduler == nil {
+		return
+	}
+
+	err := s.backupScheduler.Stop()
+	if err != nil && err != backup.ErrSchedulerAlreadyStopped {
+		s.Logger.Warningf("Error stopping backup scheduler. Reason: %v", err)
+	}
+}
+
+// backedUpDatabases returns the loaded user databases, the system database is not backed up
+func (s *ImmuServer) backedUpDatabases() []database.DB {
+	var dbs []database.DB
+
+	for i := 0; i < s.dbList.Length(); i++ {
+		db, err := s.dbList.GetByIndex(i)
+		if err != nil || db.IsClosed() {
+			continue
+		}
+
+		dbs = append(dbs, db)
+	}
+
+	return dbs
+}
+
+// BackupDatabase takes an incremental backup of a database, the first backup of a database
+// holds all of its transactions. backup.ErrNothingToBackup is returned if no transaction
+// was committed since the latest backup
+func (s *ImmuServer) BackupDatabase(ctx context.Context, name string) error {
+	if s.backupScheduler == nil {
+		return ErrBackupsNotEnabled
+	}
+
+	db, err := s.dbList.GetByName(name)
+	if err != nil {
+		return err
+	}
+
+	return s.backupScheduler.Backup(ctx, db)
+}
+
+// RestoreDatabase replays the backups of sourceDB into the database name, stopping right
+// before the first transaction for which stop returns true, e.g. backup.UntilTx or
+// backup.UntilTime. The linear hash linkage of every transaction is verified while replaying.
+// The database must be a replica without a primary database, it can be promoted to
+// primary once restored
+func (s *ImmuServer) RestoreDatabase(ctx context.Context, name, sourceDB string, stop func(hdr *store.TxHeader) bool) (*schema.TxHeader, error) {
+	if s.backupScheduler == nil {
+		return nil, ErrBackupsNotEnabled
+	}
+
+	db, err := s.dbList.GetByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	dbOpts, err := s.loadDBOptions(name, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if !dbOpts.Replica || dbOpts.isReplicatorRequired() {
+		return nil, ErrRestoreRequiresReplica
+	}
+
+	s.Logger.Infof("restoring database '%s' from the backups of database '%s'...", name, sourceDB)
+
+	hdr, err := backup.Restore(ctx, db, s.backupScheduler.Target(), sourceDB, stop)
+	if err != nil {
+		s.Logger.Errorf("failed to restore database '%s' {err = %v}", name, err)
+		return hdr, err
+	}
+
+	if hdr == nil {
+		s.Logger.Infof("no transactions restored into database '%s'", name)
+	} else {
+		s.Logger.Infof("database '%s' restored up to tx %d", name, hdr.Id)
+	}
+
+	return hdr, nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/backup"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestServerBackupAndRestore(t *testing.T) {
+	serverOptions := DefaultOptions().
+		WithDir(t.TempDir()).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithAuth(true).
+		WithBackupOptions(DefaultBackupOptions().WithDir(t.TempDir()))
+
+	s, closer := testServer(serverOptions)
+	defer closer()
+
+	err := s.Initialize()
+	require.NoError(t, err)
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	_, err = s.CreateDatabaseV2(ctx, &schema.CreateDatabaseRequest{Name: "db"})
+	require.NoError(t, err)
+
+	db, err := s.dbList.GetByName("db")
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		_, err = db.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{
+			Key:   []byte(fmt.Sprintf("key_%d", i)),
+			Value: []byte(fmt.Sprintf("val_%d", i)),
+		}}})
+		require.NoError(t, err)
+	}
+
+	err = s.BackupDatabase(ctx, "db")
+	require.NoError(t, err)
+
+	err = s.BackupDatabase(ctx, "db")
+	require.ErrorIs(t, err, backup.ErrNothingToBackup)
+
+	err = s.BackupDatabase(ctx, "unknown")
+	require.Error(t, err)
+
+	state, err := db.CurrentState()
+	require.NoError(t, err)
+
+	t.Run("restoring requires a replica without a primary", func(t *testing.T) {
+		_, err = s.RestoreDatabase(ctx, "db", "db", nil)
+		require.ErrorIs(t, err, ErrRestoreRequiresReplica)
+	})
+
+	_, err = s.CreateDatabaseV2(ctx, &schema.CreateDatabaseRequest{
+		Name: "restored",
+		Settings: &schema.DatabaseNullableSettings{
+			ReplicationSettings: &schema.ReplicationNullableSettings{
+				Replica: &schema.NullableBool{Value: true},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	hdr, err := s.RestoreDatabase(ctx, "restored", "db", backup.UntilTx(state.TxId-1))
+	require.NoError(t, err)
+	require.Equal(t, state.TxId-1, hdr.Id)
+
+	restored, err := s.dbList.GetByName("restored")
+	require.NoError(t, err)
+
+	_, err = restored.Get(ctx, &schema.KeyRequest{Key: []byte("key_8"), SinceTx: hdr.Id})
+	require.NoError(t, err)
+
+	_, err = restored.Get(ctx, &schema.KeyRequest{Key: []byte("key_9"), SinceTx: hdr.Id})
+	require.Error(t, err)
+}
+
+func TestServerScheduledBackups(t *testing.T) {
+	backupDir := t.TempDir()
+
+	serverOptions := DefaultOptions().
+		WithDir(t.TempDir()).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithBackupOptions(DefaultBackupOptions().WithDir(backupDir).WithInterval(10 * time.Millisecond))
+
+	s, closer := testServer(serverOptions)
+	defer closer()
+
+	err := s.Initialize()
+	require.NoError(t, err)
+	defer s.stopBackups()
+
+	target := backup.NewDirTarget(backupDir)
+
+	require.Eventually(t, func() bool {
+		names, err := target.List(context.Background(), DefaultDBName)
+		return err == nil && len(names) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestServerBackupsNotEnabled(t *testing.T) {
+	s, closer := testServer(DefaultOptions().WithDir(t.TempDir()).WithPort(0).WithMetricsServer(false))
+	defer closer()
+
+	err := s.Initialize()
+	require.NoError(t, err)
+
+	err = s.BackupDatabase(context.Background(), DefaultDBName)
+	require.ErrorIs(t, err, ErrBackupsNotEnabled)
+
+	_, err = s.RestoreDatabase(context.Background(), DefaultDBName, DefaultDBName, nil)
+	require.ErrorIs(t, err, ErrBackupsNotEnabled)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// consistencyTokenKey is the request metadata key holding the consistency token of a read,
+// i.e. the id of a transaction which must be committed before the read is served.
+// Clients pass the id of their latest write so reads against replicas observe it
+const consistencyTokenKey = "min-tx-id"
+
+// consistencyTokenMaxWait bounds how long a read waits for its consistency token to be reached,
+// the deadline of the request takes precedence when it's shorter
+const consistencyTokenMaxWait = 30 * time.Second
+
+// consistencyToken returns the consistency token of a request, zero if none was provided
+func consistencyToken(ctx context.Context) (uint64, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get(consistencyTokenKey)) == 0 {
+		return 0, nil
+	}
+
+	txID, err := strconv.ParseUint(md.Get(consistencyTokenKey)[0], 10, 64)
+	if err != nil {
+		return 0, ErrInvalidConsistencyToken
+	}
+
+	return txID, nil
+}
+
+// waitForConsistencyToken blocks reads until the transaction of their consistency token
+// gets committed. Only replicas may lag behind a token, primaries reject the ones ahead of them
+func (s *ImmuServer) waitForConsistencyToken(ctx context.Context, method string) error {
+	if _, ok := readMethods[method]; !ok {
+		return nil
+	}
+
+	txID, err := consistencyToken(ctx)
+	if err != nil || txID == 0 {
+		return err
+	}
+
+	db, err := s.getDBFromCtx(ctx, method)
+	if err != nil {
+		// the error is reported by the method itself
+		return nil
+	}
+
+	state, err := db.CurrentState()
+	if err != nil {
+		return err
+	}
+
+	if txID <= state.TxId {
+		return nil
+	}
+
+	if !db.IsReplica() {
+		return ErrInvalidConsistencyToken
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, consistencyTokenMaxWait)
+	defer cancel()
+
+	err = db.WaitForTx(waitCtx, txID, false)
+	if err != nil && waitCtx.Err() != nil && ctx.Err() == nil {
+		return ErrConsistencyTokenNotReached
+	}
+
+	return err
+}
+
+// ConsistencyTokenInterceptor delays reads carrying a consistency token until it's reached
+func (s *ImmuServer) ConsistencyTokenInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	err := s.waitForConsistencyToken(ctx, methodName(info.FullMethod))
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// ConsistencyTokenStreamInterceptor delays streamed reads carrying a consistency token until it's reached
+func (s *ImmuServer) ConsistencyTokenStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	err := s.waitForConsistencyToken(ss.Context(), methodName(info.FullMethod))
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, ss)
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestConsistencyTokenInterceptor(t *testing.T) {
+	serverOptions := DefaultOptions().
+		WithDir(t.TempDir()).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithAuth(true)
+
+	s, closer := testServer(serverOptions)
+	defer closer()
+
+	err := s.Initialize()
+	require.NoError(t, err)
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	_, err = s.CreateDatabaseV2(ctx, &schema.CreateDatabaseRequest{Name: "primarydb"})
+	require.NoError(t, err)
+
+	_, err = s.CreateDatabaseV2(ctx, &schema.CreateDatabaseRequest{
+		Name: "replicadb",
+		Settings: &schema.DatabaseNullableSettings{
+			ReplicationSettings: &schema.ReplicationNullableSettings{
+				Replica: &schema.NullableBool{Value: true},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	primary, err := s.dbList.GetByName("primarydb")
+	require.NoError(t, err)
+
+	replica, err := s.dbList.GetByName("replicadb")
+	require.NoError(t, err)
+
+	hdr, err := primary.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	withToken := func(db string, token string) context.Context {
+		udr, err := s.UseDatabase(ctx, &schema.Database{DatabaseName: db})
+		require.NoError(t, err)
+
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			"authorization", udr.Token,
+			consistencyTokenKey, token,
+		))
+	}
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/immudb.schema.ImmuService/Get"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+
+	t.Run("tokens must be valid tx ids", func(t *testing.T) {
+		_, err := s.ConsistencyTokenInterceptor(withToken("primarydb", "tx1"), nil, info, handler)
+		require.ErrorIs(t, err, ErrInvalidConsistencyToken)
+	})
+
+	t.Run("primaries should serve reads with reached tokens", func(t *testing.T) {
+		_, err := s.ConsistencyTokenInterceptor(withToken("primarydb", strconv.FormatUint(hdr.Id, 10)), nil, info, handler)
+		require.NoError(t, err)
+	})
+
+	t.Run("primaries should reject tokens ahead of them", func(t *testing.T) {
+		_, err := s.ConsistencyTokenInterceptor(withToken("primarydb", strconv.FormatUint(hdr.Id+1, 10)), nil, info, handler)
+		require.ErrorIs(t, err, ErrInvalidConsistencyToken)
+	})
+
+	t.Run("writes should not wait for tokens", func(t *testing.T) {
+		info := &grpc.UnaryServerInfo{FullMethod: "/immudb.schema.ImmuService/Set"}
+
+		_, err := s.ConsistencyTokenInterceptor(withToken("primarydb", strconv.FormatUint(hdr.Id+1, 10)), nil, info, handler)
+		require.NoError(t, err)
+	})
+
+	t.Run("replicas should report their lag", func(t *testing.T) {
+		health, err := s.DatabaseHealth(withToken("replicadb", "0"), &empty.Empty{})
+		require.NoError(t, err)
+		require.Zero(t, health.ReplicatedTxId)
+		require.Zero(t, health.PrimaryTxId)
+		require.Zero(t, health.ReplicationLag)
+	})
+
+	t.Run("replicas should wait until reaching tokens", func(t *testing.T) {
+		tokenCtx, cancel := context.WithTimeout(withToken("replicadb", strconv.FormatUint(hdr.Id, 10)), 10*time.Second)
+		defer cancel()
+
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+
+			for txID := uint64(1); txID <= hdr.Id; txID++ {
+				etx, _, _, err := primary.ExportTxByID(ctx, &schema.ExportTxRequest{Tx: txID})
+				require.NoError(t, err)
+
+				_, err = replica.ReplicateTx(ctx, etx)
+				require.NoError(t, err)
+			}
+		}()
+
+		_, err := s.ConsistencyTokenInterceptor(tokenCtx, nil, info, handler)
+		require.NoError(t, err)
+
+		state, err := replica.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, hdr.Id, state.TxId)
+	})
+
+	t.Run("replicas should fail reads when the request expires", func(t *testing.T) {
+		tokenCtx, cancel := context.WithTimeout(withToken("replicadb", strconv.FormatUint(hdr.Id+1, 10)), 100*time.Millisecond)
+		defer cancel()
+
+		_, err := s.ConsistencyTokenInterceptor(tokenCtx, nil, info, handler)
+		require.Error(t, err)
+	})
+}
@@ -19,6 +19,7 @@ package server
 import (
 	"context"
 	"crypto/sha256"
+	"io"
 	"path/filepath"
 	"time"
 
@@ -80,6 +81,10 @@ func (db *closedDB) Size() (uint64, error) {
 	return 0, store.ErrAlreadyClosed
 }
 
+func (db *closedDB) StoreStats() (*store.Stats, error) {
+	return nil, store.ErrAlreadyClosed
+}
+
 func (db *closedDB) Set(ctx context.Context, req *schema.SetRequest) (*schema.TxHeader, error) {
 	return nil, store.ErrAlreadyClosed
 }
@@ -96,6 +101,10 @@ func (db *closedDB) VerifiableGet(ctx context.Context, req *schema.VerifiableGet
 	return nil, store.ErrAlreadyClosed
 }
 
+func (db *closedDB) VerifiableGetAll(ctx context.Context, req *schema.VerifiableGetAllRequest) (*schema.VerifiableEntries, error) {
+	return nil, store.ErrAlreadyClosed
+}
+
 func (db *closedDB) GetAll(ctx context.Context, req *schema.KeyListRequest) (*schema.Entries, error) {
 	return nil, store.ErrAlreadyClosed
 }
@@ -208,6 +217,14 @@ func (db *closedDB) ReplicateTx(ctx context.Context, exportedTx []byte) (*schema
 	return nil, store.ErrAlreadyClosed
 }
 
+func (db *closedDB) ExportTxRange(fromTx, toTx uint64, w io.Writer) error {
+	return store.ErrAlreadyClosed
+}
+
+func (db *closedDB) ImportTxRange(ctx context.Context, r io.Reader, stop func(hdr *store.TxHeader) bool) (*schema.TxHeader, error) {
+	return nil, store.ErrAlreadyClosed
+}
+
 func (db *closedDB) AllowCommitUpto(txID uint64, alh [sha256.Size]byte) error {
 	return store.ErrAlreadyClosed
 }
@@ -224,6 +241,10 @@ func (db *closedDB) TxScan(ctx context.Context, req *schema.TxScanRequest) (*sch
 	return nil, store.ErrAlreadyClosed
 }
 
+func (db *closedDB) StreamTx(ctx context.Context, req *schema.StreamTxRequest, send func(*schema.StreamedTx) error) error {
+	return store.ErrAlreadyClosed
+}
+
 func (db *closedDB) FlushIndex(req *schema.FlushIndexRequest) error {
 	return store.ErrAlreadyClosed
 }
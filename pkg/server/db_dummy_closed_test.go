@@ -160,6 +160,9 @@ func TestDummyClosedDatabase(t *testing.T) {
 	_, err = cdb.TxScan(context.Background(), nil)
 	require.ErrorIs(t, err, store.ErrAlreadyClosed)
 
+	err = cdb.StreamTx(context.Background(), nil, nil)
+	require.ErrorIs(t, err, store.ErrAlreadyClosed)
+
 	err = cdb.FlushIndex(nil)
 	require.ErrorIs(t, err, store.ErrAlreadyClosed)
 
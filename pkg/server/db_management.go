@@ -0,0 +1,412 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+)
+
+// CloneDatabase creates a new database holding a copy of the transactions of an existing one,
+// up to the requested transaction or to the latest one committed when the request is received.
+// The source database keeps serving requests while being cloned.
+// Clones keep the settings of their source but are never replicas, they are independent databases
+func (s *ImmuServer) CloneDatabase(ctx context.Context, req *schema.CloneDatabaseRequest) (res *schema.CloneDatabaseResponse, err error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	s.Logger.Infof("Cloning database '%s' into '%s'...", req.Database, req.NewDatabase)
+
+	defer func() {
+		if err == nil {
+			s.Logger.Infof("Database '%s' succesfully cloned into '%s'", req.Database, req.NewDatabase)
+		} else {
+			s.Logger.Infof("Database '%s' could not be cloned into '%s'. Reason: %v", req.Database, req.NewDatabase, err)
+		}
+	}()
+
+	if s.Options.GetMaintenance() {
+		return nil, ErrNotAllowedInMaintenanceMode
+	}
+
+	if !s.Options.GetAuth() {
+		return nil, ErrAuthMustBeEnabled
+	}
+
+	_, user, err := s.getLoggedInUserdataFromCtx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get loggedin user data")
+	}
+
+	if !user.IsSysAdmin {
+		return nil, fmt.Errorf("loggedin user does not have permissions for this operation")
+	}
+
+	if req.Database == s.Options.systemAdminDBName ||
+		req.NewDatabase == s.Options.defaultDBName ||
+		req.NewDatabase == s.Options.systemAdminDBName {
+		return nil, ErrReservedDatabase
+	}
+
+	req.NewDatabase = strings.ToLower(req.NewDatabase)
+	if err = isValidDBName(req.NewDatabase); err != nil {
+		return nil, err
+	}
+
+	s.dbListMutex.Lock()
+	defer s.dbListMutex.Unlock()
+
+	if s.dbList.GetId(req.NewDatabase) >= 0 {
+		return nil, database.ErrDatabaseAlreadyExists
+	}
+
+	srcDB, err := s.dbList.GetByName(req.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	if srcDB.IsClosed() {
+		return nil, store.ErrAlreadyClosed
+	}
+
+	state, err := srcDB.CurrentState()
+	if err != nil {
+		return nil, err
+	}
+
+	untilTxID := state.TxId
+
+	if req.UntilTxId > 0 {
+		if req.UntilTxId > state.TxId {
+			return nil, fmt.Errorf("%w: tx %d is not yet committed", ErrIllegalArguments, req.UntilTxId)
+		}
+
+		untilTxID = req.UntilTxId
+	}
+
+	srcOpts, err := s.loadDBOptions(req.Database, false)
+	if err != nil {
+		return nil, fmt.Errorf("%w: while loading database settings", err)
+	}
+
+	dbOpts := *srcOpts
+	dbOpts.Database = req.NewDatabase
+	dbOpts.Replica = false
+	dbOpts.SyncReplication = false
+	dbOpts.SyncAcks = 0
+	dbOpts.PrimaryDatabase = ""
+	dbOpts.PrimaryHost = ""
+	dbOpts.PrimaryPort = 0
+	dbOpts.PrimaryUsername = ""
+	dbOpts.PrimaryPassword = ""
+
+	// transactions can only be imported into replicas
+	importOpts := dbOpts
+	importOpts.Replica = true
+
+	err = s.saveDBOptions(&importOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	importDB, err := database.NewDB(req.NewDatabase, s.multidbHandler(), s.databaseOptionsFrom(&importOpts), s.Logger)
+	if err != nil {
+		s.deleteDBOptionsFor(req.NewDatabase)
+		return nil, err
+	}
+
+	cloned := false
+
+	defer func() {
+		if err != nil && !cloned {
+			importDB.Close()
+			os.RemoveAll(importDB.Path())
+			s.deleteDBOptionsFor(req.NewDatabase)
+		}
+	}()
+
+	if untilTxID > 0 {
+		pr, pw := io.Pipe()
+
+		go func() {
+			pw.CloseWithError(srcDB.ExportTxRange(1, untilTxID, pw))
+		}()
+
+		_, err = importDB.ImportTxRange(ctx, pr, nil)
+		pr.CloseWithError(err)
+		if err != nil {
+			return nil, fmt.Errorf("%w: while copying transactions", err)
+		}
+	}
+
+	err = importDB.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.saveDBOptions(&dbOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := database.OpenDB(req.NewDatabase, s.multidbHandler(), s.databaseOptionsFrom(&dbOpts), s.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	cloned = true
+
+	s.dbList.Put(db)
+	s.multidbmode = true
+
+	s.logDBOptions(db.GetName(), &dbOpts)
+
+	err = s.startTruncatorFor(db, &dbOpts)
+	if err != nil && err != ErrTruncatorNotNeeded {
+		return nil, fmt.Errorf("%w: while starting truncation", err)
+	}
+
+	return &schema.CloneDatabaseResponse{
+		Database: req.NewDatabase,
+		TxId:     untilTxID,
+	}, nil
+}
+
+// RenameDatabase changes the name of a database and moves the permissions granted on it.
+// The database is closed while being renamed, open sessions and tokens which had it selected
+// need to select it again with its new name
+func (s *ImmuServer) RenameDatabase(ctx context.Context, req *schema.RenameDatabaseRequest) (res *schema.RenameDatabaseResponse, err error) {
+	if req == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	s.Logger.Infof("Renaming database '%s' to '%s'...", req.Database, req.NewDatabase)
+
+	defer func() {
+		if err == nil {
+			s.Logger.Infof("Database '%s' succesfully renamed to '%s'", req.Database, req.NewDatabase)
+		} else {
+			s.Logger.Infof("Database '%s' could not be renamed to '%s'. Reason: %v", req.Database, req.NewDatabase, err)
+		}
+	}()
+
+	if s.Options.GetMaintenance() {
+		return nil, ErrNotAllowedInMaintenanceMode
+	}
+
+	if !s.Options.GetAuth() {
+		return nil, ErrAuthMustBeEnabled
+	}
+
+	_, user, err := s.getLoggedInUserdataFromCtx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get loggedin user data")
+	}
+
+	if !user.IsSysAdmin {
+		return nil, fmt.Errorf("loggedin user does not have permissions for this operation")
+	}
+
+	if req.Database == s.Options.defaultDBName ||
+		req.Database == s.Options.systemAdminDBName ||
+		req.NewDatabase == s.Options.defaultDBName ||
+		req.NewDatabase == s.Options.systemAdminDBName {
+		return nil, ErrReservedDatabase
+	}
+
+	req.NewDatabase = strings.ToLower(req.NewDatabase)
+	if err = isValidDBName(req.NewDatabase); err != nil {
+		return nil, err
+	}
+
+	if s.remoteStorage != nil {
+		return nil, fmt.Errorf("%w: databases stored in remote storage can not be renamed", ErrNotSupported)
+	}
+
+	s.dbListMutex.Lock()
+	defer s.dbListMutex.Unlock()
+
+	if s.dbList.GetId(req.NewDatabase) >= 0 {
+		return nil, database.ErrDatabaseAlreadyExists
+	}
+
+	db, err := s.dbList.GetByName(req.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	dbOpts, err := s.loadDBOptions(req.Database, false)
+	if err != nil {
+		return nil, fmt.Errorf("%w: while loading database settings", err)
+	}
+
+	loaded := !db.IsClosed()
+
+	if loaded {
+		err = s.stopReplicationFor(req.Database)
+		if err != nil && err != ErrReplicationNotInProgress {
+			return nil, fmt.Errorf("%w: while stopping replication", err)
+		}
+
+		err = s.stopTruncatorFor(req.Database)
+		if err != nil && err != ErrTruncatorNotInProgress {
+			return nil, fmt.Errorf("%w: while stopping truncation", err)
+		}
+
+		err = db.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = os.Rename(db.Path(), filepath.Join(s.Options.Dir, req.NewDatabase))
+	if err != nil {
+		if loaded {
+			// the database is left as it was
+			reopenErr := s.reopenDatabase(req.Database, dbOpts)
+			if reopenErr != nil {
+				s.Logger.Errorf("Database '%s' could not be reopened. Reason: %v", req.Database, reopenErr)
+			}
+		}
+
+		return nil, err
+	}
+
+	_, err = s.dbList.Delete(req.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	dbOpts.Database = req.NewDatabase
+
+	err = s.saveDBOptions(dbOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.deleteDBOptionsFor(req.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.renamePermissions(ctx, req.Database, req.NewDatabase)
+	if err != nil {
+		return nil, fmt.Errorf("%w: while moving user permissions", err)
+	}
+
+	if !loaded {
+		s.dbList.Put(&closedDB{name: req.NewDatabase, opts: s.databaseOptionsFrom(dbOpts)})
+	} else {
+		err = s.reopenDatabase(req.NewDatabase, dbOpts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &schema.RenameDatabaseResponse{
+		Database: req.NewDatabase,
+	}, nil
+}
+
+// reopenDatabase opens a database closed by the server, e.g. while it's being renamed
+func (s *ImmuServer) reopenDatabase(name string, dbOpts *dbOptions) error {
+	db, err := database.OpenDB(name, s.multidbHandler(), s.databaseOptionsFrom(dbOpts), s.Logger)
+	if err != nil {
+		s.dbList.Put(&closedDB{name: name, opts: s.databaseOptionsFrom(dbOpts)})
+		return fmt.Errorf("%w: while opening database", err)
+	}
+
+	s.dbList.Put(db)
+
+	err = s.startReplicationFor(db, dbOpts)
+	if err != nil && err != ErrReplicatorNotNeeded {
+		return fmt.Errorf("%w: while starting replication", err)
+	}
+
+	err = s.startTruncatorFor(db, dbOpts)
+	if err != nil && err != ErrTruncatorNotNeeded {
+		return fmt.Errorf("%w: while starting truncation", err)
+	}
+
+	return nil
+}
+
+// renamePermissions moves the permissions users have on a database to its new name
+// usersPageSize is the number of users read at once when the permissions of every user are updated
+var usersPageSize = 100
+
+func (s *ImmuServer) renamePermissions(ctx context.Context, oldName, newName string) error {
+	var seekKey []byte
+
+	for {
+		itemList, err := s.sysDB.Scan(ctx, &schema.ScanRequest{
+			Prefix:  []byte{KeyPrefixUser},
+			SeekKey: seekKey,
+			Limit:   uint64(usersPageSize),
+			NoWait:  true,
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(itemList.Entries) == 0 {
+			return nil
+		}
+
+		for _, e := range itemList.Entries {
+			var user auth.User
+
+			err = json.Unmarshal(e.Value, &user)
+			if err != nil {
+				return err
+			}
+
+			if user.IsSysAdmin {
+				continue
+			}
+
+			permission := user.WhichPermission(oldName)
+			if permission == auth.PermissionNone {
+				continue
+			}
+
+			user.RevokePermission(oldName)
+			user.GrantPermission(newName, permission)
+
+			err = s.saveUser(ctx, &user)
+			if err != nil {
+				return err
+			}
+
+			// logged in users get their permissions reloaded
+			s.removeUserFromLoginList(user.Username)
+		}
+
+		seekKey = itemList.Entries[len(itemList.Entries)-1].Key
+	}
+}
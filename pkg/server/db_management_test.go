@@ -0,0 +1,221 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestServerCloneAndRenameDatabase(t *testing.T) {
+	serverOptions := DefaultOptions().
+		WithDir(t.TempDir()).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithAuth(true)
+
+	s, closer := testServer(serverOptions)
+	defer closer()
+
+	err := s.Initialize()
+	require.NoError(t, err)
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	_, err = s.CreateDatabaseV2(ctx, &schema.CreateDatabaseRequest{Name: "db"})
+	require.NoError(t, err)
+
+	db, err := s.dbList.GetByName("db")
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		_, err = db.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{
+			Key:   []byte(fmt.Sprintf("key_%d", i)),
+			Value: []byte(fmt.Sprintf("val_%d", i)),
+		}}})
+		require.NoError(t, err)
+	}
+
+	state, err := db.CurrentState()
+	require.NoError(t, err)
+
+	t.Run("clone should include every committed tx", func(t *testing.T) {
+		res, err := s.CloneDatabase(ctx, &schema.CloneDatabaseRequest{Database: "db", NewDatabase: "dbclone"})
+		require.NoError(t, err)
+		require.Equal(t, "dbclone", res.Database)
+		require.Equal(t, state.TxId, res.TxId)
+
+		clone, err := s.dbList.GetByName("dbclone")
+		require.NoError(t, err)
+		require.False(t, clone.IsReplica())
+
+		cloneState, err := clone.CurrentState()
+		require.NoError(t, err)
+		require.Equal(t, state.TxHash, cloneState.TxHash)
+
+		entry, err := clone.Get(ctx, &schema.KeyRequest{Key: []byte("key_9")})
+		require.NoError(t, err)
+		require.Equal(t, []byte("val_9"), entry.Value)
+
+		_, err = clone.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key_10"), Value: []byte("val_10")}}})
+		require.NoError(t, err)
+
+		_, err = db.Get(ctx, &schema.KeyRequest{Key: []byte("key_10")})
+		require.Error(t, err)
+	})
+
+	t.Run("clone should stop at the requested tx", func(t *testing.T) {
+		res, err := s.CloneDatabase(ctx, &schema.CloneDatabaseRequest{Database: "db", NewDatabase: "dbuntil", UntilTxId: state.TxId - 1})
+		require.NoError(t, err)
+		require.Equal(t, state.TxId-1, res.TxId)
+
+		clone, err := s.dbList.GetByName("dbuntil")
+		require.NoError(t, err)
+
+		_, err = clone.Get(ctx, &schema.KeyRequest{Key: []byte("key_8")})
+		require.NoError(t, err)
+
+		_, err = clone.Get(ctx, &schema.KeyRequest{Key: []byte("key_9")})
+		require.Error(t, err)
+	})
+
+	t.Run("clone should fail with invalid requests", func(t *testing.T) {
+		_, err := s.CloneDatabase(ctx, nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = s.CloneDatabase(ctx, &schema.CloneDatabaseRequest{Database: "db", NewDatabase: "dbclone"})
+		require.ErrorIs(t, err, database.ErrDatabaseAlreadyExists)
+
+		_, err = s.CloneDatabase(ctx, &schema.CloneDatabaseRequest{Database: "db", NewDatabase: DefaultDBName})
+		require.ErrorIs(t, err, ErrReservedDatabase)
+
+		_, err = s.CloneDatabase(ctx, &schema.CloneDatabaseRequest{Database: SystemDBName, NewDatabase: "sysdbclone"})
+		require.ErrorIs(t, err, ErrReservedDatabase)
+
+		_, err = s.CloneDatabase(ctx, &schema.CloneDatabaseRequest{Database: "unknown", NewDatabase: "unknownclone"})
+		require.ErrorIs(t, err, database.ErrDatabaseNotExists)
+
+		_, err = s.CloneDatabase(ctx, &schema.CloneDatabaseRequest{Database: "db", NewDatabase: "dbahead", UntilTxId: state.TxId + 1})
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		require.Negative(t, s.dbList.GetId("dbahead"))
+	})
+
+	_, err = s.CreateUser(ctx, &schema.CreateUserRequest{
+		User:       testUsername,
+		Password:   testPassword,
+		Database:   "dbclone",
+		Permission: auth.PermissionRW,
+	})
+	require.NoError(t, err)
+
+	t.Run("rename should keep data and permissions", func(t *testing.T) {
+		_, err := s.RenameDatabase(ctx, &schema.RenameDatabaseRequest{Database: "dbclone", NewDatabase: "renamed"})
+		require.NoError(t, err)
+
+		_, err = s.dbList.GetByName("dbclone")
+		require.ErrorIs(t, err, database.ErrDatabaseNotExists)
+
+		renamed, err := s.dbList.GetByName("renamed")
+		require.NoError(t, err)
+		require.False(t, renamed.IsClosed())
+
+		entry, err := renamed.Get(ctx, &schema.KeyRequest{Key: []byte("key_10")})
+		require.NoError(t, err)
+		require.Equal(t, []byte("val_10"), entry.Value)
+
+		user, err := s.getUser(ctx, testUsername)
+		require.NoError(t, err)
+		require.Equal(t, uint32(auth.PermissionNone), user.WhichPermission("dbclone"))
+		require.Equal(t, uint32(auth.PermissionRW), user.WhichPermission("renamed"))
+
+		_, err = s.loadDBOptions("dbclone", false)
+		require.Error(t, err)
+	})
+
+	t.Run("rename should update the permissions of every user", func(t *testing.T) {
+		defer func(pageSize int) { usersPageSize = pageSize }(usersPageSize)
+		usersPageSize = 2
+
+		for i := 0; i < 5; i++ {
+			user := &auth.User{Username: fmt.Sprintf("user%d", i), Active: true}
+			user.GrantPermission("renamed", auth.PermissionR)
+
+			err := s.saveUser(ctx, user)
+			require.NoError(t, err)
+		}
+
+		_, err := s.RenameDatabase(ctx, &schema.RenameDatabaseRequest{Database: "renamed", NewDatabase: "renamedagain"})
+		require.NoError(t, err)
+
+		for i := 0; i < 5; i++ {
+			user, err := s.getUser(ctx, []byte(fmt.Sprintf("user%d", i)))
+			require.NoError(t, err)
+			require.Equal(t, uint32(auth.PermissionNone), user.WhichPermission("renamed"))
+			require.Equal(t, uint32(auth.PermissionR), user.WhichPermission("renamedagain"))
+		}
+
+		user, err := s.getUser(ctx, testUsername)
+		require.NoError(t, err)
+		require.Equal(t, uint32(auth.PermissionRW), user.WhichPermission("renamedagain"))
+	})
+
+	t.Run("rename should keep unloaded databases unloaded", func(t *testing.T) {
+		_, err := s.UnloadDatabase(ctx, &schema.UnloadDatabaseRequest{Database: "dbuntil"})
+		require.NoError(t, err)
+
+		_, err = s.RenameDatabase(ctx, &schema.RenameDatabaseRequest{Database: "dbuntil", NewDatabase: "dbuntilrenamed"})
+		require.NoError(t, err)
+
+		_, err = s.LoadDatabase(ctx, &schema.LoadDatabaseRequest{Database: "dbuntilrenamed"})
+		require.NoError(t, err)
+
+		renamed, err := s.dbList.GetByName("dbuntilrenamed")
+		require.NoError(t, err)
+
+		_, err = renamed.Get(ctx, &schema.KeyRequest{Key: []byte("key_8")})
+		require.NoError(t, err)
+	})
+
+	t.Run("rename should fail with invalid requests", func(t *testing.T) {
+		_, err := s.RenameDatabase(ctx, nil)
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = s.RenameDatabase(ctx, &schema.RenameDatabaseRequest{Database: "db", NewDatabase: "renamedagain"})
+		require.ErrorIs(t, err, database.ErrDatabaseAlreadyExists)
+
+		_, err = s.RenameDatabase(ctx, &schema.RenameDatabaseRequest{Database: DefaultDBName, NewDatabase: "defaultdbrenamed"})
+		require.ErrorIs(t, err, ErrReservedDatabase)
+
+		_, err = s.RenameDatabase(ctx, &schema.RenameDatabaseRequest{Database: "unknown", NewDatabase: "unknownrenamed"})
+		require.ErrorIs(t, err, database.ErrDatabaseNotExists)
+	})
+}
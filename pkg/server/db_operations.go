@@ -37,10 +37,26 @@ func (s *ImmuServer) DatabaseHealth(ctx context.Context, _ *empty.Empty) (*schem
 
 	waitingRequests, lastReleaseAt := db.Health()
 
-	return &schema.DatabaseHealthResponse{
+	res := &schema.DatabaseHealthResponse{
 		PendingRequests:        uint32(waitingRequests),
 		LastRequestCompletedAt: unixMilli(lastReleaseAt),
-	}, nil
+	}
+
+	if db.IsReplica() {
+		state, err := db.CurrentState()
+		if err != nil {
+			return nil, err
+		}
+
+		res.ReplicatedTxId = state.TxId
+		res.PrimaryTxId = s.primaryCommittedTxID(db.GetName())
+
+		if res.PrimaryTxId > res.ReplicatedTxId {
+			res.ReplicationLag = res.PrimaryTxId - res.ReplicatedTxId
+		}
+	}
+
+	return res, nil
 }
 
 // CurrentState ...
@@ -161,6 +177,39 @@ func (s *ImmuServer) VerifiableGet(ctx context.Context, req *schema.VerifiableGe
 	return vEntry, nil
 }
 
+// VerifiableGetAll reads a batch of keys with the proofs to verify them against a single state
+func (s *ImmuServer) VerifiableGetAll(ctx context.Context, req *schema.VerifiableGetAllRequest) (*schema.VerifiableEntries, error) {
+	db, err := s.getDBFromCtx(ctx, "VerifiableGetAll")
+	if err != nil {
+		return nil, err
+	}
+
+	vEntries, err := db.VerifiableGetAll(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.StateSigner != nil && vEntries.DualProof != nil {
+		hdr := schema.TxHeaderFromProto(vEntries.DualProof.TargetTxHeader)
+		alh := hdr.Alh()
+
+		newState := &schema.ImmutableState{
+			Db:     db.GetName(),
+			TxId:   hdr.ID,
+			TxHash: alh[:],
+		}
+
+		err = s.StateSigner.Sign(newState)
+		if err != nil {
+			return nil, err
+		}
+
+		vEntries.Signature = newState.Signature
+	}
+
+	return vEntries, nil
+}
+
 // Scan ...
 func (s *ImmuServer) Scan(ctx context.Context, req *schema.ScanRequest) (*schema.Entries, error) {
 	db, err := s.getDBFromCtx(ctx, "Scan")
@@ -56,6 +56,13 @@ var (
 	ErrDatabaseAlreadyLoaded       = errors.New("database already loaded")
 	ErrTruncatorNotNeeded          = errors.New("truncator is not needed")
 	ErrTruncatorNotInProgress      = errors.New("truncation is not in progress")
+	ErrBackupsNotEnabled           = errors.New("backups are not enabled")
+	ErrRestoreRequiresReplica      = errors.New("restoring requires a replica database without a primary")
+	ErrRateLimitExceeded           = status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	ErrExternalUserMismatch        = errors.New("username does not match the token")
+	ErrExternalUserConflict        = errors.New("an immudb user with the same username already exists")
+	ErrInvalidConsistencyToken     = status.Error(codes.InvalidArgument, "invalid consistency token")
+	ErrConsistencyTokenNotReached  = status.Error(codes.Unavailable, "database did not reach the consistency token in time")
 )
 
 func mapServerError(err error) error {
@@ -67,6 +74,9 @@ func mapServerError(err error) error {
 	case store.ErrTxReadConflict:
 		return ErrTxReadConflict
 	}
+	if goerrors.Is(err, sessions.ErrMaxUserSessionsReached) || goerrors.Is(err, sessions.ErrMaxDatabaseSessionsReached) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
 	if goerrors.Is(err, store.ErrPreconditionFailed) {
 		return errors.New(err.Error()).WithCode(errors.CodIntegrityConstraintViolation)
 	}
@@ -28,6 +28,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"google.golang.org/grpc/peer"
 
+	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/logger"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -55,6 +56,16 @@ type MetricsCollection struct {
 	computeDBEntries func() map[string]float64
 	DBEntriesGauges  *prometheus.GaugeVec
 
+	computeDBStoreStats            func() map[string]*store.Stats
+	DBIndexerLagGauges             *prometheus.GaugeVec
+	DBWatchersWaitingGauges        *prometheus.GaugeVec
+	DBVLogSizeGauges               *prometheus.GaugeVec
+	DBCacheHitRatioGauges          *prometheus.GaugeVec
+	DBIndexFlushInProgressGauges   *prometheus.GaugeVec
+	DBIndexFlushedEntriesGauges    *prometheus.GaugeVec
+	DBIndexCompactInProgressGauges *prometheus.GaugeVec
+	DBIndexCompactedEntriesGauges  *prometheus.GaugeVec
+
 	RPCsPerClientCounters        *prometheus.CounterVec
 	LastMessageAtPerClientGauges *prometheus.GaugeVec
 
@@ -97,6 +108,11 @@ func (mc *MetricsCollection) WithComputeDBEntries(f func() map[string]float64) {
 	mc.computeDBEntries = f
 }
 
+// WithComputeDBStoreStats ...
+func (mc *MetricsCollection) WithComputeDBStoreStats(f func() map[string]*store.Stats) {
+	mc.computeDBStoreStats = f
+}
+
 // UpdateDBMetrics ...
 func (mc *MetricsCollection) UpdateDBMetrics() {
 	if mc.computeDBSizes != nil {
@@ -109,6 +125,35 @@ func (mc *MetricsCollection) UpdateDBMetrics() {
 			mc.DBEntriesGauges.WithLabelValues(db).Set(nbEntries)
 		}
 	}
+	if mc.computeDBStoreStats != nil {
+		for db, stats := range mc.computeDBStoreStats() {
+			mc.updateDBStoreMetrics(db, stats)
+		}
+	}
+}
+
+func (mc *MetricsCollection) updateDBStoreMetrics(db string, stats *store.Stats) {
+	mc.DBIndexerLagGauges.WithLabelValues(db).Set(float64(stats.IndexerLag))
+	mc.DBWatchersWaitingGauges.WithLabelValues(db).Set(float64(stats.WatchersWaiting))
+	mc.DBVLogSizeGauges.WithLabelValues(db).Set(float64(stats.ValueLogSize))
+
+	if accesses := stats.CacheHits + stats.CacheMisses; accesses > 0 {
+		mc.DBCacheHitRatioGauges.WithLabelValues(db).Set(float64(stats.CacheHits) / float64(accesses))
+	}
+
+	progress := stats.IndexWriteProgress
+
+	mc.DBIndexFlushInProgressGauges.WithLabelValues(db).Set(boolToFloat(progress.Flushing))
+	mc.DBIndexFlushedEntriesGauges.WithLabelValues(db).Set(float64(progress.FlushedEntries))
+	mc.DBIndexCompactInProgressGauges.WithLabelValues(db).Set(boolToFloat(progress.Compacting))
+	mc.DBIndexCompactedEntriesGauges.WithLabelValues(db).Set(float64(progress.CompactedEntries))
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
 }
 
 // Metrics immudb Prometheus metrics collection
@@ -137,6 +182,70 @@ var Metrics = MetricsCollection{
 		},
 		[]string{"db"},
 	),
+	DBIndexerLagGauges: promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "db_indexer_lag_txs",
+			Help:      "Number of committed transactions not yet indexed by the database.",
+		},
+		[]string{"db"},
+	),
+	DBWatchersWaitingGauges: promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "db_watchers_waiting",
+			Help:      "Number of waiters for transactions to be committed or indexed by the database.",
+		},
+		[]string{"db"},
+	),
+	DBVLogSizeGauges: promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "db_vlog_size_bytes",
+			Help:      "Size of the value logs of the database in bytes.",
+		},
+		[]string{"db"},
+	),
+	DBCacheHitRatioGauges: promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "db_cache_hit_ratio",
+			Help:      "Ratio of lookups served by the transaction and value caches of the database.",
+		},
+		[]string{"db"},
+	),
+	DBIndexFlushInProgressGauges: promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "db_index_flush_in_progress",
+			Help:      "Set to 1 while the index of the database is being flushed.",
+		},
+		[]string{"db"},
+	),
+	DBIndexFlushedEntriesGauges: promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "db_index_flushed_entries",
+			Help:      "Number of index entries written by the ongoing or last flush of the database.",
+		},
+		[]string{"db"},
+	),
+	DBIndexCompactInProgressGauges: promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "db_index_compaction_in_progress",
+			Help:      "Set to 1 while the index of the database is being compacted.",
+		},
+		[]string{"db"},
+	),
+	DBIndexCompactedEntriesGauges: promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "db_index_compacted_entries",
+			Help:      "Number of index entries written by the ongoing or last compaction of the database.",
+		},
+		[]string{"db"},
+	),
 	LastMessageAtPerClientGauges: promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: metricsNamespace,
@@ -164,12 +273,14 @@ func StartMetrics(
 	uptimeCounter func() float64,
 	computeDBSizes func() map[string]float64,
 	computeDBEntries func() map[string]float64,
+	computeDBStoreStats func() map[string]*store.Stats,
 	addPProf bool,
 ) *http.Server {
 
 	Metrics.WithUptimeCounter(uptimeCounter)
 	Metrics.WithComputeDBSizes(computeDBSizes)
 	Metrics.WithComputeDBEntries(computeDBEntries)
+	Metrics.WithComputeDBStoreStats(computeDBStoreStats)
 
 	go func() {
 		Metrics.UpdateDBMetrics()
@@ -135,3 +135,46 @@ func (s *ImmuServer) metricFuncComputeDBEntries() (nbEntriesPerDB map[string]flo
 
 	return
 }
+
+func (s *ImmuServer) metricFuncComputeDBStoreStats() (statsPerDB map[string]*store.Stats) {
+	statsPerDB = make(map[string]*store.Stats)
+
+	if s.dbList != nil {
+		for i := 0; i < s.dbList.Length(); i++ {
+			db, err := s.dbList.GetByIndex(i)
+			if err != nil {
+				continue
+			}
+
+			dbName := db.GetName()
+			stats, err := db.StoreStats()
+			if err == store.ErrAlreadyClosed {
+				continue
+			}
+			if err != nil {
+				s.Logger.Errorf("error getting store stats of db %s to update metrics: %v", dbName, err)
+				continue
+			}
+			statsPerDB[dbName] = stats
+		}
+	} else {
+		s.Logger.Warningf(
+			"current update of store metrics for regular dbs was skipped: db list is nil")
+	}
+
+	// add systemdb
+	if s.sysDB != nil {
+		sysDBName := s.sysDB.GetName()
+		stats, err := s.sysDB.StoreStats()
+		if err != nil {
+			s.Logger.Errorf("error getting store stats of system db %s to update metrics: %v", sysDBName, err)
+		} else {
+			statsPerDB[sysDBName] = stats
+		}
+	} else {
+		s.Logger.Warningf(
+			"current update of store metrics for system db was skipped: system db is nil")
+	}
+
+	return
+}
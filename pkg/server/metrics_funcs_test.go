@@ -24,6 +24,7 @@ import (
 	"testing"
 
 	"github.com/codenotary/immudb/cmd/cmdtest"
+	"github.com/codenotary/immudb/embedded/store"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
 	"github.com/codenotary/immudb/pkg/database"
@@ -37,6 +38,14 @@ type dbMock struct {
 	currentStateF func() (*schema.ImmutableState, error)
 	getOptionsF   func() *database.Options
 	getNameF      func() string
+	storeStatsF   func() (*store.Stats, error)
+}
+
+func (dbm dbMock) StoreStats() (*store.Stats, error) {
+	if dbm.storeStatsF != nil {
+		return dbm.storeStatsF()
+	}
+	return &store.Stats{IndexerLag: 1}, nil
 }
 
 func (dbm dbMock) CurrentState() (*schema.ImmutableState, error) {
@@ -115,6 +124,58 @@ func TestMetricFuncComputeDBEntries(t *testing.T) {
 	s.metricFuncComputeDBEntries()
 }
 
+func TestMetricFuncComputeDBStoreStats(t *testing.T) {
+	dbList := database.NewDatabaseList()
+	dbList.Put(dbMock{
+		getNameF: func() string {
+			return "db1"
+		},
+	})
+	dbList.Put(dbMock{
+		getNameF: func() string {
+			return "db2"
+		},
+		storeStatsF: func() (*store.Stats, error) {
+			return nil, fmt.Errorf("some store stats error")
+		},
+	})
+	dbList.Put(dbMock{
+		getNameF: func() string {
+			return "closeddb"
+		},
+		storeStatsF: func() (*store.Stats, error) {
+			return nil, store.ErrAlreadyClosed
+		},
+	})
+
+	sysDB := dbMock{
+		getNameF: func() string {
+			return "systemdb"
+		},
+	}
+
+	var sw strings.Builder
+	s := ImmuServer{
+		dbList: dbList,
+		sysDB:  sysDB,
+		Logger: logger.NewSimpleLoggerWithLevel(
+			"TestMetricFuncComputeDBStoreStats",
+			&sw,
+			logger.LogError),
+	}
+
+	statsPerDB := s.metricFuncComputeDBStoreStats()
+	require.Len(t, statsPerDB, 2)
+	require.Equal(t, uint64(1), statsPerDB["db1"].IndexerLag)
+	require.Contains(t, statsPerDB, "systemdb")
+	require.Contains(t, sw.String(), "some store stats error")
+
+	// test warning paths (when dbList and sysDB are nil)
+	s.dbList = nil
+	s.sysDB = nil
+	require.Empty(t, s.metricFuncComputeDBStoreStats())
+}
+
 func TestMetricFuncServerUptimeCounter(t *testing.T) {
 	s := ImmuServer{}
 	s.metricFuncServerUptimeCounter()
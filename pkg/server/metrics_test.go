@@ -25,8 +25,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/codenotary/immudb/embedded/store"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/peer"
 )
@@ -39,6 +41,7 @@ func TestStartMetrics(t *testing.T) {
 		func() float64 { return 0 },
 		func() map[string]float64 { return make(map[string]float64) },
 		func() map[string]float64 { return make(map[string]float64) },
+		func() map[string]*store.Stats { return make(map[string]*store.Stats) },
 		false,
 	)
 	time.Sleep(200 * time.Millisecond)
@@ -59,6 +62,7 @@ func TestStartMetricsFail(t *testing.T) {
 		func() float64 { return 0 },
 		func() map[string]float64 { return make(map[string]float64) },
 		func() map[string]float64 { return make(map[string]float64) },
+		func() map[string]*store.Stats { return make(map[string]*store.Stats) },
 		false,
 	)
 	time.Sleep(200 * time.Millisecond)
@@ -142,6 +146,47 @@ func TestMetricsCollection_UpdateDBMetrics(t *testing.T) {
 	require.IsType(t, MetricsCollection{}, mc)
 }
 
+func TestMetricsCollection_UpdateDBStoreMetrics(t *testing.T) {
+	newGaugeVec := func(name string) *prometheus.GaugeVec {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name}, []string{"db"})
+	}
+
+	mc := MetricsCollection{
+		DBIndexerLagGauges:             newGaugeVec("indexer_lag"),
+		DBWatchersWaitingGauges:        newGaugeVec("watchers_waiting"),
+		DBVLogSizeGauges:               newGaugeVec("vlog_size"),
+		DBCacheHitRatioGauges:          newGaugeVec("cache_hit_ratio"),
+		DBIndexFlushInProgressGauges:   newGaugeVec("flush_in_progress"),
+		DBIndexFlushedEntriesGauges:    newGaugeVec("flushed_entries"),
+		DBIndexCompactInProgressGauges: newGaugeVec("compaction_in_progress"),
+		DBIndexCompactedEntriesGauges:  newGaugeVec("compacted_entries"),
+	}
+
+	mc.WithComputeDBStoreStats(func() map[string]*store.Stats {
+		stats := &store.Stats{
+			IndexerLag:      5,
+			WatchersWaiting: 2,
+			ValueLogSize:    1024,
+			CacheHits:       3,
+			CacheMisses:     1,
+		}
+		stats.IndexWriteProgress.Compacting = true
+		stats.IndexWriteProgress.CompactedEntries = 100
+
+		return map[string]*store.Stats{"db1": stats}
+	})
+
+	mc.UpdateDBMetrics()
+
+	require.Equal(t, 5.0, testutil.ToFloat64(mc.DBIndexerLagGauges.WithLabelValues("db1")))
+	require.Equal(t, 2.0, testutil.ToFloat64(mc.DBWatchersWaitingGauges.WithLabelValues("db1")))
+	require.Equal(t, 1024.0, testutil.ToFloat64(mc.DBVLogSizeGauges.WithLabelValues("db1")))
+	require.Equal(t, 0.75, testutil.ToFloat64(mc.DBCacheHitRatioGauges.WithLabelValues("db1")))
+	require.Equal(t, 0.0, testutil.ToFloat64(mc.DBIndexFlushInProgressGauges.WithLabelValues("db1")))
+	require.Equal(t, 1.0, testutil.ToFloat64(mc.DBIndexCompactInProgressGauges.WithLabelValues("db1")))
+	require.Equal(t, 100.0, testutil.ToFloat64(mc.DBIndexCompactedEntriesGauges.WithLabelValues("db1")))
+}
+
 func TestImmudbHealthHandlerFunc(t *testing.T) {
 	req, err := http.NewRequest("GET", "/initz", nil)
 	require.NoError(t, err)
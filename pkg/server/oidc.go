@@ -0,0 +1,60 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/pkg/auth"
+)
+
+func newJWTVerifier(opts *OIDCOptions) *auth.JWTVerifier {
+	var keys *auth.JWKS
+
+	if opts.JWKSURL != "" {
+		keys = auth.NewJWKS(opts.JWKSURL)
+	} else {
+		keys = auth.NewDiscoveredJWKS(opts.Issuer)
+	}
+
+	return auth.NewJWTVerifier(opts.Issuer, opts.Audience, keys, opts.UsernameClaim, opts.PermissionsClaim)
+}
+
+// getExternalUser returns the user identified by a token issued by the external identity provider,
+// the username is optional but must match the token when provided
+func (s *ImmuServer) getExternalUser(ctx context.Context, username []byte, token []byte) (*auth.User, error) {
+	user, err := s.jwtVerifier.Verify(ctx, string(token))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(username) > 0 && string(username) != user.Username {
+		return nil, ErrExternalUserMismatch
+	}
+
+	// external identities must not be confused with immudb users
+	_, err = s.getUser(ctx, []byte(user.Username))
+	if err == nil {
+		return nil, ErrExternalUserConflict
+	}
+	if err != store.ErrKeyNotFound {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestServerExternalAuthentication(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	enc := base64.RawURLEncoding
+
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key",
+			"n":   enc.EncodeToString(key.N.Bytes()),
+			"e":   enc.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer idp.Close()
+
+	token := func(username string, permissions map[string]string) []byte {
+		hdr, err := json.Marshal(map[string]string{"alg": "RS256", "kid": "key"})
+		require.NoError(t, err)
+
+		payload, err := json.Marshal(map[string]interface{}{
+			"iss":                "https://idp.example.com",
+			"aud":                "immudb",
+			"sub":                username,
+			"exp":                time.Now().Add(time.Hour).Unix(),
+			"immudb_permissions": permissions,
+		})
+		require.NoError(t, err)
+
+		signed := enc.EncodeToString(hdr) + "." + enc.EncodeToString(payload)
+		digest := sha256.Sum256([]byte(signed))
+
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+
+		return []byte(signed + "." + enc.EncodeToString(signature))
+	}
+
+	serverOptions := DefaultOptions().
+		WithDir(t.TempDir()).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithAuth(true).
+		WithOIDCOptions(DefaultOIDCOptions().
+			WithIssuer("https://idp.example.com").
+			WithAudience("immudb").
+			WithJWKSURL(idp.URL),
+		)
+
+	s, closer := testServer(serverOptions)
+	defer closer()
+
+	err = s.Initialize()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	t.Run("sessions can be opened with external tokens", func(t *testing.T) {
+		resp, err := s.OpenSession(ctx, &schema.OpenSessionRequest{
+			Password:     token("alice", map[string]string{DefaultDBName: "readwrite"}),
+			DatabaseName: DefaultDBName,
+		})
+		require.NoError(t, err)
+
+		sess, err := s.SessManager.GetSession(resp.SessionID)
+		require.NoError(t, err)
+		require.Equal(t, "alice", sess.GetUser().Username)
+		require.True(t, sess.GetUser().HasPermission(DefaultDBName, auth.PermissionRW))
+	})
+
+	t.Run("permissions come from the token", func(t *testing.T) {
+		_, err := s.OpenSession(ctx, &schema.OpenSessionRequest{
+			Password:     token("alice", map[string]string{"otherdb": "read"}),
+			DatabaseName: DefaultDBName,
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("tokens can be used to login", func(t *testing.T) {
+		_, err := s.Login(ctx, &schema.LoginRequest{
+			User:     []byte("alice"),
+			Password: token("alice", map[string]string{DefaultDBName: "read"}),
+		})
+		require.NoError(t, err)
+
+		_, err = s.Login(ctx, &schema.LoginRequest{
+			User:     []byte("bob"),
+			Password: token("alice", map[string]string{DefaultDBName: "read"}),
+		})
+		require.ErrorIs(t, err, ErrExternalUserMismatch)
+	})
+
+	t.Run("external users must not collide with immudb users", func(t *testing.T) {
+		lr, err := s.Login(ctx, &schema.LoginRequest{
+			User:     []byte(auth.SysAdminUsername),
+			Password: []byte(auth.SysAdminPassword),
+		})
+		require.NoError(t, err)
+
+		adminCtx := metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", lr.Token))
+
+		_, err = s.CreateUser(adminCtx, &schema.CreateUserRequest{
+			User:       []byte("carol"),
+			Password:   []byte("Carol1234!"),
+			Permission: auth.PermissionR,
+			Database:   DefaultDBName,
+		})
+		require.NoError(t, err)
+
+		_, err = s.Login(ctx, &schema.LoginRequest{
+			Password: token("carol", map[string]string{DefaultDBName: "admin"}),
+		})
+		require.ErrorIs(t, err, ErrExternalUserConflict)
+	})
+}
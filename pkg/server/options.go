@@ -22,6 +22,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/codenotary/immudb/pkg/logger"
 	"github.com/codenotary/immudb/pkg/server/sessions"
@@ -68,9 +69,13 @@ type Options struct {
 	PgsqlServer          bool
 	PgsqlServerPort      int
 	ReplicationOptions   *ReplicationOptions
+	BackupOptions        *BackupOptions
+	RateLimitOptions     *RateLimitOptions
+	OIDCOptions          *OIDCOptions
 	SessionsOptions      *sessions.Options
 	PProf                bool
 	LogFormat            string
+	SharedIndexCacheSize int // bytes of a node cache shared by the indexes of all databases, 0 means per-index caches
 }
 
 type RemoteStorageOptions struct {
@@ -81,6 +86,49 @@ type RemoteStorageOptions struct {
 	S3BucketName  string
 	S3Location    string
 	S3PathPrefix  string
+
+	AzureStorage                 bool
+	AzureEndpoint                string // defaults to the public endpoint of the account
+	AzureAccountName             string
+	AzureAccountKey              string `json:"-"`
+	AzureSASToken                string `json:"-"`
+	AzureManagedIdentityClientID string // used when neither an account key nor a SAS token are set
+	AzureContainerName           string
+	AzurePathPrefix              string
+
+	GCSStorage         bool
+	GCSEndpoint        string // defaults to the public endpoint
+	GCSCredentialsFile string // service account key file, the metadata server is used when empty
+	GCSBucketName      string
+	GCSPathPrefix      string
+
+	CacheDir  string // local directory caching remote chunks, required if CacheSize is set
+	CacheSize int64  // bytes of remote chunks cached on disk, 0 disables the cache
+}
+
+type BackupOptions struct {
+	Dir           string                // local directory backups are written to
+	RemoteStorage *RemoteStorageOptions // remote storage backups are written to instead of Dir, e.g. s3
+	Interval      time.Duration         // interval between incremental backups, 0 disables scheduled backups
+}
+
+// RateLimitOptions bound the load a single user or database can put on the server,
+// requests exceeding the limits are rejected with a RESOURCE_EXHAUSTED error
+type RateLimitOptions struct {
+	UserWritesPerSec          int // write requests per second of every user, 0 means no limit
+	UserReadEntriesPerSec     int // entries read per second by every user, 0 means no limit
+	DatabaseWritesPerSec      int // write requests per second on every database, 0 means no limit
+	DatabaseReadEntriesPerSec int // entries read per second from every database, 0 means no limit
+}
+
+// OIDCOptions enable authenticating users through tokens issued by an external
+// identity provider, presented as the password when logging in or opening a session
+type OIDCOptions struct {
+	Issuer           string // issuer tokens are accepted from, external authentication is disabled when empty
+	Audience         string // tokens must be issued for this audience, any audience is accepted when empty
+	JWKSURL          string // public keys of the issuer, discovered from the issuer when empty
+	UsernameClaim    string // claim holding the username, "sub" when empty
+	PermissionsClaim string // claim mapping databases to permissions, "immudb_permissions" when empty
 }
 
 type ReplicationOptions struct {
@@ -129,11 +177,26 @@ func DefaultOptions() *Options {
 		PgsqlServer:          false,
 		PgsqlServerPort:      5432,
 		ReplicationOptions:   &ReplicationOptions{IsReplica: false, SyncAcks: 0},
+		BackupOptions:        DefaultBackupOptions(),
+		RateLimitOptions:     DefaultRateLimitOptions(),
+		OIDCOptions:          DefaultOIDCOptions(),
 		SessionsOptions:      sessions.DefaultOptions(),
 		PProf:                false,
 	}
 }
 
+func DefaultBackupOptions() *BackupOptions {
+	return &BackupOptions{}
+}
+
+func DefaultRateLimitOptions() *RateLimitOptions {
+	return &RateLimitOptions{}
+}
+
+func DefaultOIDCOptions() *OIDCOptions {
+	return &OIDCOptions{}
+}
+
 func DefaultRemoteStorageOptions() *RemoteStorageOptions {
 	return &RemoteStorageOptions{
 		S3Storage: false,
@@ -297,6 +360,9 @@ func (o *Options) String() string {
 	opts = append(opts, rightPad("Default database", o.defaultDBName))
 	opts = append(opts, rightPad("Maintenance mode", o.maintenance))
 	opts = append(opts, rightPad("Synced mode", o.synced))
+	if o.SharedIndexCacheSize > 0 {
+		opts = append(opts, rightPad("Shared index cache size", o.SharedIndexCacheSize))
+	}
 	if o.SigningKey != "" {
 		opts = append(opts, rightPad("Signing key", o.SigningKey))
 	}
@@ -309,6 +375,57 @@ func (o *Options) String() string {
 		}
 		opts = append(opts, rightPad("   prefix", o.RemoteStorageOptions.S3PathPrefix))
 	}
+	if o.RemoteStorageOptions.AzureStorage {
+		opts = append(opts, "Azure blob storage")
+		if o.RemoteStorageOptions.AzureEndpoint != "" {
+			opts = append(opts, rightPad("   endpoint", o.RemoteStorageOptions.AzureEndpoint))
+		}
+		opts = append(opts, rightPad("   account name", o.RemoteStorageOptions.AzureAccountName))
+		opts = append(opts, rightPad("   container", o.RemoteStorageOptions.AzureContainerName))
+		opts = append(opts, rightPad("   prefix", o.RemoteStorageOptions.AzurePathPrefix))
+	}
+	if o.RemoteStorageOptions.GCSStorage {
+		opts = append(opts, "GCS storage")
+		if o.RemoteStorageOptions.GCSEndpoint != "" {
+			opts = append(opts, rightPad("   endpoint", o.RemoteStorageOptions.GCSEndpoint))
+		}
+		opts = append(opts, rightPad("   bucket name", o.RemoteStorageOptions.GCSBucketName))
+		opts = append(opts, rightPad("   prefix", o.RemoteStorageOptions.GCSPathPrefix))
+	}
+	if o.BackupOptions.isEnabled() {
+		opts = append(opts, "Backups")
+		if o.BackupOptions.RemoteStorage != nil {
+			opts = append(opts, rightPad("   remote storage", true))
+		} else {
+			opts = append(opts, rightPad("   dir", o.BackupOptions.Dir))
+		}
+		if o.BackupOptions.Interval > 0 {
+			opts = append(opts, rightPad("   interval", o.BackupOptions.Interval))
+		}
+	}
+	if o.OIDCOptions.isEnabled() {
+		opts = append(opts, rightPad("OIDC issuer", o.OIDCOptions.Issuer))
+	}
+	if o.RateLimitOptions.isEnabled() {
+		opts = append(opts, "Rate limits")
+		if o.RateLimitOptions.UserWritesPerSec > 0 {
+			opts = append(opts, rightPad("   user writes/sec", o.RateLimitOptions.UserWritesPerSec))
+		}
+		if o.RateLimitOptions.UserReadEntriesPerSec > 0 {
+			opts = append(opts, rightPad("   user reads/sec", o.RateLimitOptions.UserReadEntriesPerSec))
+		}
+		if o.RateLimitOptions.DatabaseWritesPerSec > 0 {
+			opts = append(opts, rightPad("   db writes/sec", o.RateLimitOptions.DatabaseWritesPerSec))
+		}
+		if o.RateLimitOptions.DatabaseReadEntriesPerSec > 0 {
+			opts = append(opts, rightPad("   db reads/sec", o.RateLimitOptions.DatabaseReadEntriesPerSec))
+		}
+	}
+	if o.RemoteStorageOptions.CacheSize > 0 {
+		opts = append(opts, "Remote storage cache")
+		opts = append(opts, rightPad("   dir", o.RemoteStorageOptions.CacheDir))
+		opts = append(opts, rightPad("   size", o.RemoteStorageOptions.CacheSize))
+	}
 	if o.AdminPassword == auth.SysAdminPassword {
 		opts = append(opts, "----------------------------------------")
 		opts = append(opts, "Superadmin default credentials")
@@ -440,6 +557,21 @@ func (o *Options) WithReplicationOptions(replicationOptions *ReplicationOptions)
 	return o
 }
 
+func (o *Options) WithBackupOptions(backupOptions *BackupOptions) *Options {
+	o.BackupOptions = backupOptions
+	return o
+}
+
+func (o *Options) WithRateLimitOptions(rateLimitOptions *RateLimitOptions) *Options {
+	o.RateLimitOptions = rateLimitOptions
+	return o
+}
+
+func (o *Options) WithOIDCOptions(oidcOptions *OIDCOptions) *Options {
+	o.OIDCOptions = oidcOptions
+	return o
+}
+
 func (o *Options) WithSessionOptions(options *sessions.Options) *Options {
 	o.SessionsOptions = options
 	return o
@@ -450,6 +582,96 @@ func (o *Options) WithPProf(pprof bool) *Options {
 	return o
 }
 
+// WithSharedIndexCacheSize bounds the memory used to cache index nodes of all databases together
+func (o *Options) WithSharedIndexCacheSize(size int) *Options {
+	o.SharedIndexCacheSize = size
+	return o
+}
+
+// BackupOptions
+
+func (opts *BackupOptions) WithDir(dir string) *BackupOptions {
+	opts.Dir = dir
+	return opts
+}
+
+func (opts *BackupOptions) WithRemoteStorage(remoteStorage *RemoteStorageOptions) *BackupOptions {
+	opts.RemoteStorage = remoteStorage
+	return opts
+}
+
+func (opts *BackupOptions) WithInterval(interval time.Duration) *BackupOptions {
+	opts.Interval = interval
+	return opts
+}
+
+// isEnabled returns true if a destination for backups is set
+func (opts *BackupOptions) isEnabled() bool {
+	return opts != nil && (opts.Dir != "" || opts.RemoteStorage != nil)
+}
+
+// RateLimitOptions
+
+func (opts *RateLimitOptions) WithUserWritesPerSec(writesPerSec int) *RateLimitOptions {
+	opts.UserWritesPerSec = writesPerSec
+	return opts
+}
+
+func (opts *RateLimitOptions) WithUserReadEntriesPerSec(readEntriesPerSec int) *RateLimitOptions {
+	opts.UserReadEntriesPerSec = readEntriesPerSec
+	return opts
+}
+
+func (opts *RateLimitOptions) WithDatabaseWritesPerSec(writesPerSec int) *RateLimitOptions {
+	opts.DatabaseWritesPerSec = writesPerSec
+	return opts
+}
+
+func (opts *RateLimitOptions) WithDatabaseReadEntriesPerSec(readEntriesPerSec int) *RateLimitOptions {
+	opts.DatabaseReadEntriesPerSec = readEntriesPerSec
+	return opts
+}
+
+// isEnabled returns true if any limit is set
+func (opts *RateLimitOptions) isEnabled() bool {
+	return opts != nil &&
+		(opts.UserWritesPerSec > 0 ||
+			opts.UserReadEntriesPerSec > 0 ||
+			opts.DatabaseWritesPerSec > 0 ||
+			opts.DatabaseReadEntriesPerSec > 0)
+}
+
+// OIDCOptions
+
+func (opts *OIDCOptions) WithIssuer(issuer string) *OIDCOptions {
+	opts.Issuer = issuer
+	return opts
+}
+
+func (opts *OIDCOptions) WithAudience(audience string) *OIDCOptions {
+	opts.Audience = audience
+	return opts
+}
+
+func (opts *OIDCOptions) WithJWKSURL(jwksURL string) *OIDCOptions {
+	opts.JWKSURL = jwksURL
+	return opts
+}
+
+func (opts *OIDCOptions) WithUsernameClaim(usernameClaim string) *OIDCOptions {
+	opts.UsernameClaim = usernameClaim
+	return opts
+}
+
+func (opts *OIDCOptions) WithPermissionsClaim(permissionsClaim string) *OIDCOptions {
+	opts.PermissionsClaim = permissionsClaim
+	return opts
+}
+
+func (opts *OIDCOptions) isEnabled() bool {
+	return opts != nil && opts.Issuer != ""
+}
+
 // RemoteStorageOptions
 
 func (opts *RemoteStorageOptions) WithS3Storage(S3Storage bool) *RemoteStorageOptions {
@@ -487,6 +709,81 @@ func (opts *RemoteStorageOptions) WithS3PathPrefix(s3PathPrefix string) *RemoteS
 	return opts
 }
 
+func (opts *RemoteStorageOptions) WithAzureStorage(azureStorage bool) *RemoteStorageOptions {
+	opts.AzureStorage = azureStorage
+	return opts
+}
+
+func (opts *RemoteStorageOptions) WithAzureEndpoint(azureEndpoint string) *RemoteStorageOptions {
+	opts.AzureEndpoint = azureEndpoint
+	return opts
+}
+
+func (opts *RemoteStorageOptions) WithAzureAccountName(azureAccountName string) *RemoteStorageOptions {
+	opts.AzureAccountName = azureAccountName
+	return opts
+}
+
+func (opts *RemoteStorageOptions) WithAzureAccountKey(azureAccountKey string) *RemoteStorageOptions {
+	opts.AzureAccountKey = azureAccountKey
+	return opts
+}
+
+func (opts *RemoteStorageOptions) WithAzureSASToken(azureSASToken string) *RemoteStorageOptions {
+	opts.AzureSASToken = azureSASToken
+	return opts
+}
+
+func (opts *RemoteStorageOptions) WithAzureManagedIdentityClientID(clientID string) *RemoteStorageOptions {
+	opts.AzureManagedIdentityClientID = clientID
+	return opts
+}
+
+func (opts *RemoteStorageOptions) WithAzureContainerName(azureContainerName string) *RemoteStorageOptions {
+	opts.AzureContainerName = azureContainerName
+	return opts
+}
+
+func (opts *RemoteStorageOptions) WithAzurePathPrefix(azurePathPrefix string) *RemoteStorageOptions {
+	opts.AzurePathPrefix = azurePathPrefix
+	return opts
+}
+
+func (opts *RemoteStorageOptions) WithGCSStorage(gcsStorage bool) *RemoteStorageOptions {
+	opts.GCSStorage = gcsStorage
+	return opts
+}
+
+func (opts *RemoteStorageOptions) WithGCSEndpoint(gcsEndpoint string) *RemoteStorageOptions {
+	opts.GCSEndpoint = gcsEndpoint
+	return opts
+}
+
+func (opts *RemoteStorageOptions) WithGCSCredentialsFile(gcsCredentialsFile string) *RemoteStorageOptions {
+	opts.GCSCredentialsFile = gcsCredentialsFile
+	return opts
+}
+
+func (opts *RemoteStorageOptions) WithGCSBucketName(gcsBucketName string) *RemoteStorageOptions {
+	opts.GCSBucketName = gcsBucketName
+	return opts
+}
+
+func (opts *RemoteStorageOptions) WithGCSPathPrefix(gcsPathPrefix string) *RemoteStorageOptions {
+	opts.GCSPathPrefix = gcsPathPrefix
+	return opts
+}
+
+func (opts *RemoteStorageOptions) WithCacheDir(cacheDir string) *RemoteStorageOptions {
+	opts.CacheDir = cacheDir
+	return opts
+}
+
+func (opts *RemoteStorageOptions) WithCacheSize(cacheSize int64) *RemoteStorageOptions {
+	opts.CacheSize = cacheSize
+	return opts
+}
+
 // ReplicationOptions
 
 func (opts *ReplicationOptions) WithIsReplica(isReplica bool) *ReplicationOptions {
@@ -54,6 +54,7 @@ func TestOptions(t *testing.T) {
 		op.PgsqlServer ||
 		op.PgsqlServerPort != 5432 ||
 		op.PProf != false ||
+		op.SharedIndexCacheSize != 0 ||
 		op.IsFileLogger() != false ||
 		op.IsJSONLogger() != false {
 		t.Errorf("database default options mismatch")
@@ -119,6 +120,7 @@ func TestSetOptions(t *testing.T) {
 		WithPgsqlServer(true).
 		WithPgsqlServerPort(123456).
 		WithPProf(true).
+		WithSharedIndexCacheSize(1 << 20).
 		WithLogFormat(logger.LogFormatJSON)
 
 	if op.GetAuth() != false ||
@@ -147,6 +149,7 @@ func TestSetOptions(t *testing.T) {
 		!op.PgsqlServer ||
 		op.PgsqlServerPort != 123456 ||
 		op.PProf != true ||
+		op.SharedIndexCacheSize != 1<<20 ||
 		op.IsJSONLogger() != true {
 		t.Errorf("database default options mismatch")
 	}
@@ -0,0 +1,344 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"google.golang.org/grpc"
+)
+
+// writeMethods are the methods counted as writes by rate limits
+var writeMethods = map[string]struct{}{
+	"Set":                    {},
+	"VerifiableSet":          {},
+	"streamSet":              {},
+	"streamVerifiableSet":    {},
+	"Delete":                 {},
+	"ExecAll":                {},
+	"streamExecAll":          {},
+	"SetReference":           {},
+	"VerifiableSetReference": {},
+	"ZAdd":                   {},
+	"VerifiableZAdd":         {},
+	"SQLExec":                {},
+	"TxSQLExec":              {},
+}
+
+// readMethods are the methods reading entries, the entries they return are counted by rate limits
+var readMethods = map[string]struct{}{
+	"Get":                 {},
+	"VerifiableGet":       {},
+	"streamGet":           {},
+	"streamVerifiableGet": {},
+	"GetAll":              {},
+	"VerifiableGetAll":    {},
+	"Scan":                {},
+	"streamScan":          {},
+	"ZScan":               {},
+	"streamZScan":         {},
+	"History":             {},
+	"streamHistory":       {},
+	"TxById":              {},
+	"VerifiableTxById":    {},
+	"TxScan":              {},
+	"streamTx":            {},
+	"SQLQuery":            {},
+	"TxSQLQuery":          {},
+	"VerifiableSQLGet":    {},
+}
+
+// rateLimiterSweepInterval is how often idle buckets are evicted
+const rateLimiterSweepInterval = time.Minute
+
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// rateLimiter keeps a token bucket per key, e.g. per user. Buckets hold up to a second
+// worth of tokens and requests are admitted while a whole token is available.
+// Buckets may go into debt, thus costs only known once a request is served
+// (e.g. the amount of entries read) are still accounted for.
+// Buckets idle long enough to be refilled are evicted, as they are equivalent to new ones
+type rateLimiter struct {
+	mutex sync.Mutex
+
+	rate    float64 // tokens per second
+	buckets map[string]*tokenBucket
+	sweptAt time.Time
+
+	now func() time.Time
+}
+
+func newRateLimiter(rate int) *rateLimiter {
+	return &rateLimiter{
+		rate:    float64(rate),
+		buckets: make(map[string]*tokenBucket),
+		sweptAt: time.Now(),
+		now:     time.Now,
+	}
+}
+
+// sweep evicts the buckets which would be full by now
+func (l *rateLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updatedAt).Seconds()*l.rate >= l.rate {
+			delete(l.buckets, key)
+		}
+	}
+
+	l.sweptAt = now
+}
+
+func (l *rateLimiter) bucket(key string) *tokenBucket {
+	now := l.now()
+
+	if now.Sub(l.sweptAt) >= rateLimiterSweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.rate, updatedAt: now}
+		l.buckets[key] = b
+		return b
+	}
+
+	b.tokens += now.Sub(b.updatedAt).Seconds() * l.rate
+	if b.tokens > l.rate {
+		b.tokens = l.rate
+	}
+	b.updatedAt = now
+
+	return b
+}
+
+// allowed returns true if the bucket of key holds a whole token
+func (l *rateLimiter) allowed(key string) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.bucket(key).tokens >= 1
+}
+
+// consume takes n tokens from the bucket of key
+func (l *rateLimiter) consume(key string, n int) {
+	if l == nil || n == 0 {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.bucket(key).tokens -= float64(n)
+}
+
+// rateLimits holds the limiters enabled in the rate limit options, nil means no limit
+type rateLimits struct {
+	userWrites     *rateLimiter
+	userReads      *rateLimiter
+	databaseWrites *rateLimiter
+	databaseReads  *rateLimiter
+}
+
+func newRateLimits(opts *RateLimitOptions) *rateLimits {
+	if !opts.isEnabled() {
+		return nil
+	}
+
+	limits := &rateLimits{}
+
+	if opts.UserWritesPerSec > 0 {
+		limits.userWrites = newRateLimiter(opts.UserWritesPerSec)
+	}
+	if opts.UserReadEntriesPerSec > 0 {
+		limits.userReads = newRateLimiter(opts.UserReadEntriesPerSec)
+	}
+	if opts.DatabaseWritesPerSec > 0 {
+		limits.databaseWrites = newRateLimiter(opts.DatabaseWritesPerSec)
+	}
+	if opts.DatabaseReadEntriesPerSec > 0 {
+		limits.databaseReads = newRateLimiter(opts.DatabaseReadEntriesPerSec)
+	}
+
+	return limits
+}
+
+// admit checks the limits of a request, writes are accounted for right away
+func (l *rateLimits) admit(method, user, db string) error {
+	if _, ok := writeMethods[method]; ok {
+		if !l.userWrites.allowed(user) || !l.databaseWrites.allowed(db) {
+			return ErrRateLimitExceeded
+		}
+
+		l.userWrites.consume(user, 1)
+		l.databaseWrites.consume(db, 1)
+
+		return nil
+	}
+
+	if _, ok := readMethods[method]; ok {
+		if !l.userReads.allowed(user) || !l.databaseReads.allowed(db) {
+			return ErrRateLimitExceeded
+		}
+	}
+
+	return nil
+}
+
+// accountRead accounts for the entries included in a response of a read method
+func (l *rateLimits) accountRead(method, user, db string, m interface{}) {
+	if _, ok := readMethods[method]; !ok {
+		return
+	}
+
+	n := readEntries(m)
+
+	l.userReads.consume(user, n)
+	l.databaseReads.consume(db, n)
+}
+
+// readEntries returns the amount of entries included in a response,
+// chunks of streamed values are not accounted for
+func readEntries(m interface{}) int {
+	switch r := m.(type) {
+	case *schema.Entry, *schema.VerifiableEntry:
+		return 1
+	case *schema.Entries:
+		return len(r.Entries)
+	case *schema.VerifiableEntries:
+		return len(r.Entries)
+	case *schema.ZEntries:
+		return len(r.Entries)
+	case *schema.Tx:
+		return len(r.Entries)
+	case *schema.VerifiableTx:
+		return len(r.Tx.GetEntries())
+	case *schema.TxList:
+		n := 0
+		for _, tx := range r.Txs {
+			n += len(tx.Entries)
+		}
+		return n
+	case *schema.StreamedTx:
+		return len(r.VerifiableTx.GetTx().GetEntries())
+	case *schema.SQLQueryResult:
+		return len(r.Rows)
+	case *schema.VerifiableSQLEntry:
+		return 1
+	}
+
+	return 0
+}
+
+// rateLimitedClient returns the user and database limits apply to, ok is false
+// when the request is not authenticated, it's then rejected by authentication
+func (s *ImmuServer) rateLimitedClient(ctx context.Context) (user, db string, ok bool) {
+	ind, usr, err := s.getLoggedInUserdataFromCtx(ctx)
+	if err != nil || usr == nil {
+		return "", "", false
+	}
+
+	if ind == sysDBIndex {
+		return usr.Username, SystemDBName, true
+	}
+
+	d, err := s.dbList.GetByIndex(ind)
+	if err != nil {
+		return usr.Username, "", true
+	}
+
+	return usr.Username, d.GetName(), true
+}
+
+func methodName(fullMethod string) string {
+	return fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+}
+
+// RateLimitInterceptor rejects requests of users or databases exceeding their rate limits
+func (s *ImmuServer) RateLimitInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if s.rateLimits == nil {
+		return handler(ctx, req)
+	}
+
+	user, db, ok := s.rateLimitedClient(ctx)
+	if !ok {
+		return handler(ctx, req)
+	}
+
+	method := methodName(info.FullMethod)
+
+	err := s.rateLimits.admit(method, user, db)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := handler(ctx, req)
+	if err == nil {
+		s.rateLimits.accountRead(method, user, db, m)
+	}
+
+	return m, err
+}
+
+// RateLimitStreamInterceptor rejects streams of users or databases exceeding their rate limits
+func (s *ImmuServer) RateLimitStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if s.rateLimits == nil {
+		return handler(srv, ss)
+	}
+
+	user, db, ok := s.rateLimitedClient(ss.Context())
+	if !ok {
+		return handler(srv, ss)
+	}
+
+	method := methodName(info.FullMethod)
+
+	err := s.rateLimits.admit(method, user, db)
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &rateLimitedStream{ServerStream: ss, limits: s.rateLimits, method: method, user: user, db: db})
+}
+
+// rateLimitedStream accounts for the entries sent through a stream
+type rateLimitedStream struct {
+	grpc.ServerStream
+
+	limits *rateLimits
+	method string
+	user   string
+	db     string
+}
+
+func (ss *rateLimitedStream) SendMsg(m interface{}) error {
+	err := ss.ServerStream.SendMsg(m)
+	if err == nil {
+		ss.limits.accountRead(ss.method, ss.user, ss.db, m)
+	}
+	return err
+}
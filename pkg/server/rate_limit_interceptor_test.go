@@ -0,0 +1,227 @@
+/*
+Copyright 2022 Codenotary Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/server/sessions"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+
+	l := newRateLimiter(2)
+	l.now = func() time.Time { return now }
+
+	require.True(t, l.allowed("user1"))
+
+	l.consume("user1", 3)
+	require.False(t, l.allowed("user1"))
+	require.True(t, l.allowed("user2"))
+
+	now = now.Add(time.Second)
+	require.True(t, l.allowed("user1"))
+
+	// buckets hold up to a second worth of tokens
+	now = now.Add(time.Hour)
+	l.consume("user1", 2)
+	require.False(t, l.allowed("user1"))
+
+	var disabled *rateLimiter
+	disabled.consume("user1", 10)
+	require.True(t, disabled.allowed("user1"))
+}
+
+func TestRateLimiterEvictsIdleBuckets(t *testing.T) {
+	now := time.Now()
+
+	l := newRateLimiter(2)
+	l.now = func() time.Time { return now }
+	l.sweptAt = now
+
+	for i := 0; i < 10; i++ {
+		require.True(t, l.allowed(fmt.Sprintf("peer%d", i)))
+	}
+
+	// a bucket in debt is kept until it gets refilled
+	l.consume("peer0", 300)
+	require.Len(t, l.buckets, 10)
+
+	now = now.Add(rateLimiterSweepInterval)
+	require.True(t, l.allowed("peer10"))
+	require.Len(t, l.buckets, 2)
+	require.False(t, l.allowed("peer0"))
+
+	now = now.Add(2 * rateLimiterSweepInterval)
+	require.True(t, l.allowed("peer11"))
+	require.Len(t, l.buckets, 1)
+	require.True(t, l.allowed("peer0"))
+}
+
+func TestReadEntries(t *testing.T) {
+	require.Equal(t, 1, readEntries(&schema.Entry{}))
+	require.Equal(t, 2, readEntries(&schema.Entries{Entries: []*schema.Entry{{}, {}}}))
+	require.Equal(t, 3, readEntries(&schema.TxList{Txs: []*schema.Tx{
+		{Entries: []*schema.TxEntry{{}}},
+		{Entries: []*schema.TxEntry{{}, {}}},
+	}}))
+	require.Equal(t, 1, readEntries(&schema.SQLQueryResult{Rows: []*schema.Row{{}}}))
+	require.Equal(t, 0, readEntries(&schema.StreamedTx{}))
+	require.Equal(t, 0, readEntries(&schema.Chunk{}))
+}
+
+type recordingServerStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent int
+}
+
+func (ss *recordingServerStream) Context() context.Context {
+	return ss.ctx
+}
+
+func (ss *recordingServerStream) SendMsg(m interface{}) error {
+	ss.sent++
+	return nil
+}
+
+func TestRateLimitInterceptor(t *testing.T) {
+	serverOptions := DefaultOptions().
+		WithDir(t.TempDir()).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithAuth(true).
+		WithRateLimitOptions(DefaultRateLimitOptions().
+			WithUserWritesPerSec(1).
+			WithDatabaseReadEntriesPerSec(2),
+		)
+
+	s, closer := testServer(serverOptions)
+	defer closer()
+
+	err := s.Initialize()
+	require.NoError(t, err)
+
+	require.NotNil(t, s.rateLimits)
+	require.Nil(t, s.rateLimits.databaseWrites)
+
+	now := time.Now()
+	s.rateLimits.userWrites.now = func() time.Time { return now }
+	s.rateLimits.databaseReads.now = func() time.Time { return now }
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", lr.Token))
+
+	handled := 0
+	handler := func(m interface{}) grpc.UnaryHandler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			handled++
+			return m, nil
+		}
+	}
+
+	info := func(method string) *grpc.UnaryServerInfo {
+		return &grpc.UnaryServerInfo{FullMethod: "/immudb.schema.ImmuService/" + method}
+	}
+
+	t.Run("writes should be limited", func(t *testing.T) {
+		_, err := s.RateLimitInterceptor(ctx, nil, info("Set"), handler(&schema.TxHeader{}))
+		require.NoError(t, err)
+
+		_, err = s.RateLimitInterceptor(ctx, nil, info("Set"), handler(&schema.TxHeader{}))
+		require.ErrorIs(t, err, ErrRateLimitExceeded)
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+		require.Equal(t, 1, handled)
+
+		now = now.Add(time.Second)
+
+		_, err = s.RateLimitInterceptor(ctx, nil, info("SQLExec"), handler(&schema.SQLExecResult{}))
+		require.NoError(t, err)
+		require.Equal(t, 2, handled)
+	})
+
+	t.Run("read entries should be limited", func(t *testing.T) {
+		entries := &schema.Entries{Entries: []*schema.Entry{{}, {}, {}}}
+
+		_, err := s.RateLimitInterceptor(ctx, nil, info("Scan"), handler(entries))
+		require.NoError(t, err)
+
+		_, err = s.RateLimitInterceptor(ctx, nil, info("Get"), handler(&schema.Entry{}))
+		require.ErrorIs(t, err, ErrRateLimitExceeded)
+
+		// methods neither reading nor writing entries are not limited
+		_, err = s.RateLimitInterceptor(ctx, nil, info("CurrentState"), handler(&schema.ImmutableState{}))
+		require.NoError(t, err)
+
+		now = now.Add(time.Second)
+
+		_, err = s.RateLimitInterceptor(ctx, nil, info("Get"), handler(&schema.Entry{}))
+		require.NoError(t, err)
+	})
+
+	t.Run("streams should be limited", func(t *testing.T) {
+		now = now.Add(time.Second)
+
+		ss := &recordingServerStream{ctx: ctx}
+
+		err := s.RateLimitStreamInterceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/immudb.schema.ImmuService/streamTx"},
+			func(srv interface{}, ss grpc.ServerStream) error {
+				for i := 0; i < 2; i++ {
+					err := ss.SendMsg(&schema.StreamedTx{VerifiableTx: &schema.VerifiableTx{
+						Tx: &schema.Tx{Entries: []*schema.TxEntry{{}}},
+					}})
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		require.NoError(t, err)
+		require.Equal(t, 2, ss.sent)
+
+		err = s.RateLimitStreamInterceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/immudb.schema.ImmuService/streamScan"},
+			func(srv interface{}, ss grpc.ServerStream) error { return nil })
+		require.ErrorIs(t, err, ErrRateLimitExceeded)
+	})
+
+	t.Run("unauthenticated requests should be left to authentication", func(t *testing.T) {
+		_, err := s.RateLimitInterceptor(context.Background(), nil, info("Set"), handler(&schema.TxHeader{}))
+		require.NoError(t, err)
+	})
+}
+
+func TestSessionLimitsAreResourceExhausted(t *testing.T) {
+	require.Equal(t, codes.ResourceExhausted, status.Code(mapServerError(sessions.ErrMaxUserSessionsReached)))
+	require.Equal(t, codes.ResourceExhausted, status.Code(mapServerError(sessions.ErrMaxDatabaseSessionsReached)))
+}
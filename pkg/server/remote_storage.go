@@ -28,31 +28,126 @@ import (
 	"github.com/codenotary/immudb/embedded/appendable/multiapp"
 	"github.com/codenotary/immudb/embedded/appendable/remoteapp"
 	"github.com/codenotary/immudb/embedded/remotestorage"
+	"github.com/codenotary/immudb/embedded/remotestorage/azblob"
+	"github.com/codenotary/immudb/embedded/remotestorage/gcs"
 	"github.com/codenotary/immudb/embedded/remotestorage/s3"
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/pkg/errors"
+	"github.com/codenotary/immudb/pkg/logger"
 )
 
 var (
 	ErrRemoteStorageDoesNotMatch = errors.New("remote storage does not match local files")
+	ErrMultipleRemoteStorages    = errors.New("only one remote storage can be enabled")
+	ErrRemoteStorageCacheNoDir   = errors.New("remote storage cache requires a directory")
 )
 
 func (s *ImmuServer) createRemoteStorageInstance() (remotestorage.Storage, error) {
-	if s.Options.RemoteStorageOptions.S3Storage {
+	return openRemoteStorage(s.Options.RemoteStorageOptions)
+}
+
+// openRemoteStorage returns the remote storage enabled in opts, nil if none is enabled
+func openRemoteStorage(opts *RemoteStorageOptions) (remotestorage.Storage, error) {
+	enabled := 0
+	for _, e := range []bool{
+		opts.S3Storage,
+		opts.AzureStorage,
+		opts.GCSStorage,
+	} {
+		if e {
+			enabled++
+		}
+	}
+	if enabled > 1 {
+		return nil, ErrMultipleRemoteStorages
+	}
+
+	if opts.S3Storage {
 		// S3 storage
 		return s3.Open(
-			s.Options.RemoteStorageOptions.S3Endpoint,
-			s.Options.RemoteStorageOptions.S3AccessKeyID,
-			s.Options.RemoteStorageOptions.S3SecretKey,
-			s.Options.RemoteStorageOptions.S3BucketName,
-			s.Options.RemoteStorageOptions.S3Location,
-			s.Options.RemoteStorageOptions.S3PathPrefix,
+			opts.S3Endpoint,
+			opts.S3AccessKeyID,
+			opts.S3SecretKey,
+			opts.S3BucketName,
+			opts.S3Location,
+			opts.S3PathPrefix,
+		)
+	}
+
+	if opts.AzureStorage {
+		// Azure blob storage
+		credential, err := azureCredential(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		return azblob.Open(
+			opts.AzureEndpoint,
+			opts.AzureAccountName,
+			opts.AzureContainerName,
+			opts.AzurePathPrefix,
+			credential,
+		)
+	}
+
+	if opts.GCSStorage {
+		// Google cloud storage
+		credential, err := gcsCredential(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		return gcs.Open(
+			opts.GCSEndpoint,
+			opts.GCSBucketName,
+			opts.GCSPathPrefix,
+			credential,
 		)
 	}
 
 	return nil, nil
 }
 
+func azureCredential(opts *RemoteStorageOptions) (azblob.Credential, error) {
+	if opts.AzureAccountKey != "" {
+		return azblob.SharedKeyCredential(opts.AzureAccountKey)
+	}
+
+	if opts.AzureSASToken != "" {
+		return azblob.SASCredential(opts.AzureSASToken)
+	}
+
+	return azblob.ManagedIdentityCredential(opts.AzureManagedIdentityClientID), nil
+}
+
+func gcsCredential(opts *RemoteStorageOptions) (gcs.Credential, error) {
+	if opts.GCSCredentialsFile == "" {
+		return gcs.MetadataCredential(), nil
+	}
+
+	jsonKey, err := ioutil.ReadFile(opts.GCSCredentialsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return gcs.ServiceAccountCredential(jsonKey)
+}
+
+func (s *ImmuServer) createRemoteStorageCache() (*remoteapp.DiskCache, error) {
+	if s.remoteStorage == nil || s.Options.RemoteStorageOptions.CacheSize <= 0 {
+		return nil, nil
+	}
+
+	if s.Options.RemoteStorageOptions.CacheDir == "" {
+		return nil, ErrRemoteStorageCacheNoDir
+	}
+
+	return remoteapp.NewDiskCache(
+		s.Options.RemoteStorageOptions.CacheDir,
+		s.Options.RemoteStorageOptions.CacheSize,
+	)
+}
+
 func (s *ImmuServer) initializeRemoteStorage(storage remotestorage.Storage) error {
 	if storage == nil {
 		// No remote storage
@@ -131,6 +226,11 @@ func (s *ImmuServer) storeOptionsForDB(name string, remoteStorage remotestorage.
 
 			remoteAppOpts := remoteapp.DefaultOptions()
 			remoteAppOpts.Options = *opts
+			remoteAppOpts.WithLogger(logger.With(s.Logger, logger.FieldDatabase, name, logger.FieldComponent, "remoteapp"))
+
+			if s.remoteStorageCache != nil {
+				remoteAppOpts.WithDiskCache(s.remoteStorageCache)
+			}
 
 			fsPath, err := filepath.Abs(filepath.Join(rootPath, subPath))
 			if err != nil {
@@ -162,5 +262,9 @@ func (s *ImmuServer) storeOptionsForDB(name string, remoteStorage remotestorage.
 		Metrics.RemoteStorageKind.WithLabelValues(name, "none").Set(1)
 	}
 
+	if s.indexCache != nil {
+		stOpts.IndexOpts.WithSharedCache(s.indexCache)
+	}
+
 	return stOpts
 }
@@ -26,6 +26,8 @@ import (
 	"testing"
 
 	"github.com/codenotary/immudb/embedded/remotestorage"
+	"github.com/codenotary/immudb/embedded/remotestorage/azblob"
+	"github.com/codenotary/immudb/embedded/remotestorage/gcs"
 	"github.com/codenotary/immudb/embedded/remotestorage/memory"
 	"github.com/codenotary/immudb/embedded/remotestorage/s3"
 	"github.com/codenotary/immudb/embedded/store"
@@ -114,6 +116,89 @@ func TestCreateRemoteStorage(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, storage)
 	require.IsType(t, &s3.Storage{}, storage)
+
+	s.WithOptions(DefaultOptions().WithRemoteStorageOptions(
+		DefaultRemoteStorageOptions().
+			WithAzureStorage(true).
+			WithAzureAccountName("account").
+			WithAzureSASToken("sv=2020-10-02&sig=signature").
+			WithAzureContainerName("container"),
+	))
+
+	storage, err = s.createRemoteStorageInstance()
+	require.NoError(t, err)
+	require.IsType(t, &azblob.Storage{}, storage)
+
+	s.WithOptions(DefaultOptions().WithRemoteStorageOptions(
+		DefaultRemoteStorageOptions().
+			WithAzureStorage(true).
+			WithAzureAccountName("account").
+			WithAzureAccountKey("invalid*key").
+			WithAzureContainerName("container"),
+	))
+
+	_, err = s.createRemoteStorageInstance()
+	require.ErrorIs(t, err, azblob.ErrInvalidArgumentsInvalidKey)
+
+	s.WithOptions(DefaultOptions().WithRemoteStorageOptions(
+		DefaultRemoteStorageOptions().
+			WithS3Storage(true).
+			WithS3BucketName("bucket").
+			WithAzureStorage(true).
+			WithAzureAccountName("account").
+			WithAzureContainerName("container"),
+	))
+
+	_, err = s.createRemoteStorageInstance()
+	require.ErrorIs(t, err, ErrMultipleRemoteStorages)
+
+	s.WithOptions(DefaultOptions().WithRemoteStorageOptions(
+		DefaultRemoteStorageOptions().
+			WithGCSStorage(true).
+			WithGCSBucketName("bucket"),
+	))
+
+	storage, err = s.createRemoteStorageInstance()
+	require.NoError(t, err)
+	require.IsType(t, &gcs.Storage{}, storage)
+
+	s.WithOptions(DefaultOptions().WithRemoteStorageOptions(
+		DefaultRemoteStorageOptions().
+			WithGCSStorage(true).
+			WithGCSCredentialsFile(filepath.Join(dir, "missing.json")).
+			WithGCSBucketName("bucket"),
+	))
+
+	_, err = s.createRemoteStorageInstance()
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestCreateRemoteStorageCache(t *testing.T) {
+	s := DefaultServer()
+
+	// No cache without remote storage
+	s.WithOptions(DefaultOptions().WithRemoteStorageOptions(
+		DefaultRemoteStorageOptions().WithCacheSize(1 << 20),
+	))
+
+	c, err := s.createRemoteStorageCache()
+	require.NoError(t, err)
+	require.Nil(t, c)
+
+	s.remoteStorage = memory.Open()
+
+	_, err = s.createRemoteStorageCache()
+	require.ErrorIs(t, err, ErrRemoteStorageCacheNoDir)
+
+	s.WithOptions(DefaultOptions().WithRemoteStorageOptions(
+		DefaultRemoteStorageOptions().
+			WithCacheDir(t.TempDir()).
+			WithCacheSize(1 << 20),
+	))
+
+	c, err = s.createRemoteStorageCache()
+	require.NoError(t, err)
+	require.NotNil(t, c)
 }
 
 func tmpFile(t *testing.T, data []byte) (fileName string, cleanup func()) {
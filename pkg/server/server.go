@@ -35,6 +35,7 @@ import (
 
 	"github.com/codenotary/immudb/embedded/remotestorage"
 	"github.com/codenotary/immudb/embedded/store"
+	"github.com/codenotary/immudb/embedded/tbtree"
 	"github.com/codenotary/immudb/pkg/errors"
 	"github.com/codenotary/immudb/pkg/replication"
 
@@ -127,6 +128,18 @@ func (s *ImmuServer) Initialize() error {
 		return logErr(s.Logger, "Unable to initialize remote storage: %v", err)
 	}
 
+	s.remoteStorageCache, err = s.createRemoteStorageCache()
+	if err != nil {
+		return logErr(s.Logger, "Unable to create remote storage cache: %v", err)
+	}
+
+	if s.Options.SharedIndexCacheSize > 0 {
+		s.indexCache, err = tbtree.NewSharedCache(s.Options.SharedIndexCacheSize, tbtree.DefaultSharedCacheShards)
+		if err != nil {
+			return logErr(s.Logger, "Unable to create shared index cache: %v", err)
+		}
+	}
+
 	if err = s.loadSystemDatabase(dataDir, s.remoteStorage, adminPassword, s.Options.ForceAdminPassword); err != nil {
 		return logErr(s.Logger, "Unable to load system database: %v", err)
 	}
@@ -150,6 +163,10 @@ func (s *ImmuServer) Initialize() error {
 		}
 	}
 
+	if err = s.startBackups(); err != nil {
+		return logErr(s.Logger, "Unable to start backups: %v", err)
+	}
+
 	s.multidbmode = s.mandatoryAuth()
 	if !s.Options.GetAuth() && s.multidbmode {
 		return ErrAuthMustBeEnabled
@@ -216,6 +233,12 @@ func (s *ImmuServer) Initialize() error {
 
 	uuidContext := NewUUIDContext(s.UUID)
 
+	s.rateLimits = newRateLimits(s.Options.RateLimitOptions)
+
+	if s.Options.OIDCOptions.isEnabled() {
+		s.jwtVerifier = newJWTVerifier(s.Options.OIDCOptions)
+	}
+
 	uis := []grpc.UnaryServerInterceptor{
 		ErrorMapper, // converts errors in gRPC ones. Need to be the first
 		s.KeepAliveSessionInterceptor,
@@ -223,6 +246,8 @@ func (s *ImmuServer) Initialize() error {
 		grpc_prometheus.UnaryServerInterceptor,
 		auth.ServerUnaryInterceptor,
 		s.SessionAuthInterceptor,
+		s.RateLimitInterceptor,
+		s.ConsistencyTokenInterceptor,
 	}
 	sss := []grpc.StreamServerInterceptor{
 		ErrorMapperStream, // converts errors in gRPC ones. Need to be the first
@@ -230,6 +255,8 @@ func (s *ImmuServer) Initialize() error {
 		uuidContext.UUIDStreamContextSetter,
 		grpc_prometheus.StreamServerInterceptor,
 		auth.ServerStreamInterceptor,
+		s.RateLimitStreamInterceptor,
+		s.ConsistencyTokenStreamInterceptor,
 	}
 	grpcSrvOpts = append(
 		grpcSrvOpts,
@@ -340,6 +367,7 @@ func (s *ImmuServer) setUpMetricsServer() error {
 		s.metricFuncServerUptimeCounter,
 		s.metricFuncComputeDBSizes,
 		s.metricFuncComputeDBEntries,
+		s.metricFuncComputeDBStoreStats,
 		s.Options.PProf,
 	)
 	return nil
@@ -638,6 +666,20 @@ func (s *ImmuServer) replicationInProgressFor(db string) bool {
 	return ok
 }
 
+// primaryCommittedTxID returns the id of the latest transaction known to be committed
+// on the primary of a database, zero if the database is not being replicated
+func (s *ImmuServer) primaryCommittedTxID(db string) uint64 {
+	s.replicationMutex.Lock()
+	defer s.replicationMutex.Unlock()
+
+	replicator, ok := s.replicators[db]
+	if !ok {
+		return 0
+	}
+
+	return replicator.PrimaryCommittedTxID()
+}
+
 func (s *ImmuServer) startReplicationFor(db database.DB, dbOpts *dbOptions) error {
 	if !dbOpts.isReplicatorRequired() {
 		s.Logger.Infof("Replication for database '%s' is not required.", db.GetName())
@@ -727,6 +769,8 @@ func (s *ImmuServer) Stop() error {
 
 	s.stopTruncation()
 
+	s.stopBackups()
+
 	return s.CloseDatabases()
 }
 
@@ -2113,3 +2113,32 @@ func TestServerDatabaseTruncate(t *testing.T) {
 	_, err = s.CloseSession(ctx, &emptypb.Empty{})
 	require.NoError(t, err)
 }
+
+func TestServerWithSharedIndexCache(t *testing.T) {
+	serverOptions := DefaultOptions().
+		WithDir(t.TempDir()).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithSharedIndexCacheSize(1 << 20)
+
+	s, closer := testServer(serverOptions)
+	defer closer()
+
+	err := s.Initialize()
+	require.NoError(t, err)
+	require.NotNil(t, s.indexCache)
+	require.Equal(t, 1<<20, s.indexCache.MaxSize())
+
+	db, err := s.dbList.GetByName(DefaultDBName)
+	require.NoError(t, err)
+
+	_, err = db.Set(context.Background(), &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key"), Value: []byte("value")}}})
+	require.NoError(t, err)
+
+	entry, err := db.Get(context.Background(), &schema.KeyRequest{Key: []byte("key"), SinceTx: 1})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), entry.Value)
+
+	require.Same(t, s.indexCache, s.storeOptionsForDB("db", nil, store.DefaultOptions()).IndexOpts.SharedCache)
+}
@@ -27,7 +27,8 @@ import (
 type ServerMock struct {
 	Srv *server.ImmuServer
 
-	PreVerifiableGetFn func(context.Context, *schema.VerifiableGetRequest)
+	PreVerifiableGetFn     func(context.Context, *schema.VerifiableGetRequest)
+	PostVerifiableGetAllFn func(context.Context, *schema.VerifiableGetAllRequest, *schema.VerifiableEntries, error) (*schema.VerifiableEntries, error)
 
 	PreVerifiableSetFn  func(context.Context, *schema.VerifiableSetRequest)
 	PostSetFn           func(context.Context, *schema.SetRequest, *schema.TxHeader, error) (*schema.TxHeader, error)
@@ -80,6 +81,10 @@ func (s *ServerMock) StreamExecAll(allServer schema.ImmuService_StreamExecAllSer
 	return s.Srv.StreamExecAll(allServer)
 }
 
+func (s *ServerMock) StreamTx(req *schema.StreamTxRequest, txServer schema.ImmuService_StreamTxServer) error {
+	return s.Srv.StreamTx(req, txServer)
+}
+
 func (s *ServerMock) StreamGet(request *schema.KeyRequest, getServer schema.ImmuService_StreamGetServer) error {
 	return s.Srv.StreamGet(request, getServer)
 }
@@ -182,6 +187,15 @@ func (s *ServerMock) GetAll(ctx context.Context, req *schema.KeyListRequest) (*s
 	return s.Srv.GetAll(ctx, req)
 }
 
+func (s *ServerMock) VerifiableGetAll(ctx context.Context, req *schema.VerifiableGetAllRequest) (*schema.VerifiableEntries, error) {
+	if s.PostVerifiableGetAllFn == nil {
+		return s.Srv.VerifiableGetAll(ctx, req)
+	}
+
+	rsp, err := s.Srv.VerifiableGetAll(ctx, req)
+	return s.PostVerifiableGetAllFn(ctx, req, rsp, err)
+}
+
 func (s *ServerMock) Delete(ctx context.Context, req *schema.DeleteKeysRequest) (*schema.TxHeader, error) {
 	return s.Srv.Delete(ctx, req)
 }
@@ -382,3 +396,11 @@ func (s *ServerMock) VerifiableSQLGet(ctx context.Context, req *schema.Verifiabl
 func (s *ServerMock) TruncateDatabase(ctx context.Context, req *schema.TruncateDatabaseRequest) (*schema.TruncateDatabaseResponse, error) {
 	return s.Srv.TruncateDatabase(ctx, req)
 }
+
+func (s *ServerMock) CloneDatabase(ctx context.Context, req *schema.CloneDatabaseRequest) (*schema.CloneDatabaseResponse, error) {
+	return s.Srv.CloneDatabase(ctx, req)
+}
+
+func (s *ServerMock) RenameDatabase(ctx context.Context, req *schema.RenameDatabaseRequest) (*schema.RenameDatabaseResponse, error) {
+	return s.Srv.RenameDatabase(ctx, req)
+}
@@ -36,6 +36,8 @@ var ErrGuardAlreadyRunning = errors.New("session guard already launched")
 var ErrGuardNotRunning = errors.New("session guard not running")
 var ErrCantCreateSession = errors.New("can not create new session")
 var ErrMaxSessionsReached = fmt.Errorf("%w: max sessions number reached", ErrCantCreateSession)
+var ErrMaxUserSessionsReached = fmt.Errorf("%w: max sessions number of the user reached", ErrCantCreateSession)
+var ErrMaxDatabaseSessionsReached = fmt.Errorf("%w: max sessions number of the database reached", ErrCantCreateSession)
 var ErrCantCreateSessionID = fmt.Errorf("%w: generation of session id failed", ErrCantCreateSession)
 var ErrWriteOnlyTXNotAllowed = errors.New("write only transaction not allowed")
 var ErrReadOnlyTXNotAllowed = errors.New("read only transaction not allowed")
@@ -91,6 +91,29 @@ func (sm *manager) NewSession(user *auth.User, db database.DB) (*Session, error)
 		return nil, ErrMaxSessionsReached
 	}
 
+	if sm.options.MaxSessionsPerUser > 0 || sm.options.MaxSessionsPerDatabase > 0 {
+		userSessions, dbSessions := 0, 0
+
+		for _, sess := range sm.sessions {
+			if sess.GetUser().Username == user.Username {
+				userSessions++
+			}
+			if sess.GetDatabase().GetName() == db.GetName() {
+				dbSessions++
+			}
+		}
+
+		if sm.options.MaxSessionsPerUser > 0 && userSessions >= sm.options.MaxSessionsPerUser {
+			sm.logger.Warningf("max sessions reached for user '%s'", user.Username)
+			return nil, ErrMaxUserSessionsReached
+		}
+
+		if sm.options.MaxSessionsPerDatabase > 0 && dbSessions >= sm.options.MaxSessionsPerDatabase {
+			sm.logger.Warningf("max sessions reached for database '%s'", db.GetName())
+			return nil, ErrMaxDatabaseSessionsReached
+		}
+	}
+
 	randomBytes := make([]byte, 32)
 	n, err := sm.options.RandSource.Read(randomBytes)
 	if err != nil {
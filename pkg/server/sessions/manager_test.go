@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/database"
 	"github.com/codenotary/immudb/pkg/logger"
 	"github.com/stretchr/testify/require"
 )
@@ -97,6 +98,49 @@ func TestManagerMaxSessions(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// namedDB only implements GetName, which is all sessions limits rely on
+type namedDB struct {
+	database.DB
+	name string
+}
+
+func (db *namedDB) GetName() string {
+	return db.name
+}
+
+func TestManagerMaxSessionsPerUserAndDatabase(t *testing.T) {
+	m, err := NewManager(DefaultOptions().WithMaxSessionsPerUser(2).WithMaxSessionsPerDatabase(3))
+	require.NoError(t, err)
+
+	db1 := &namedDB{name: "db1"}
+	db2 := &namedDB{name: "db2"}
+
+	_, err = m.NewSession(&auth.User{Username: "user1"}, db1)
+	require.NoError(t, err)
+
+	sess, err := m.NewSession(&auth.User{Username: "user1"}, db2)
+	require.NoError(t, err)
+
+	_, err = m.NewSession(&auth.User{Username: "user1"}, db2)
+	require.ErrorIs(t, err, ErrMaxUserSessionsReached)
+	require.ErrorIs(t, err, ErrCantCreateSession)
+
+	_, err = m.NewSession(&auth.User{Username: "user2"}, db1)
+	require.NoError(t, err)
+
+	_, err = m.NewSession(&auth.User{Username: "user3"}, db1)
+	require.NoError(t, err)
+
+	_, err = m.NewSession(&auth.User{Username: "user4"}, db1)
+	require.ErrorIs(t, err, ErrMaxDatabaseSessionsReached)
+
+	err = m.DeleteSession(sess.id)
+	require.NoError(t, err)
+
+	_, err = m.NewSession(&auth.User{Username: "user1"}, db2)
+	require.NoError(t, err)
+}
+
 func TestGetSessionNotFound(t *testing.T) {
 	m, err := NewManager(DefaultOptions())
 	require.NoError(t, err)
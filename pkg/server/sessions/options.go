@@ -33,6 +33,10 @@ type Options struct {
 	Timeout time.Duration
 	// Max number of simultaneous sessions
 	MaxSessions int
+	// Max number of simultaneous sessions of a single user, 0 means no limit other than MaxSessions
+	MaxSessionsPerUser int
+	// Max number of simultaneous sessions on a single database, 0 means no limit other than MaxSessions
+	MaxSessionsPerDatabase int
 	// Random number generator
 	RandSource io.Reader
 }
@@ -72,6 +76,16 @@ func (o *Options) WithMaxSessions(maxSessions int) *Options {
 	return o
 }
 
+func (o *Options) WithMaxSessionsPerUser(maxSessions int) *Options {
+	o.MaxSessionsPerUser = maxSessions
+	return o
+}
+
+func (o *Options) WithMaxSessionsPerDatabase(maxSessions int) *Options {
+	o.MaxSessionsPerDatabase = maxSessions
+	return o
+}
+
 func (o *Options) WithRandSource(src io.Reader) *Options {
 	o.RandSource = src
 	return o
@@ -93,6 +107,12 @@ func (o *Options) Validate() error {
 	if o.MaxSessions <= 0 {
 		return fmt.Errorf("%w: invalid MaxSessions", ErrInvalidOptionsProvided)
 	}
+	if o.MaxSessionsPerUser < 0 {
+		return fmt.Errorf("%w: invalid MaxSessionsPerUser", ErrInvalidOptionsProvided)
+	}
+	if o.MaxSessionsPerDatabase < 0 {
+		return fmt.Errorf("%w: invalid MaxSessionsPerDatabase", ErrInvalidOptionsProvided)
+	}
 	if o.RandSource == nil {
 		return fmt.Errorf("%w: invalid RandSource", ErrInvalidOptionsProvided)
 	}
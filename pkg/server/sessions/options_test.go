@@ -59,6 +59,8 @@ func TestOptionsValidate(t *testing.T) {
 		DefaultOptions().WithTimeout(-1 * time.Second),
 		DefaultOptions().WithMaxSessions(0),
 		DefaultOptions().WithMaxSessions(-1),
+		DefaultOptions().WithMaxSessionsPerUser(-1),
+		DefaultOptions().WithMaxSessionsPerDatabase(-1),
 		DefaultOptions().WithRandSource(nil),
 	} {
 		t.Run(fmt.Sprintf("%+v", op), func(t *testing.T) {
@@ -37,24 +37,24 @@ func (s *ImmuServer) ExportTx(req *schema.ExportTxRequest, txsServer schema.Immu
 	txbs, mayCommitUpToTxID, mayCommitUpToAlh, err := db.ExportTxByID(txsServer.Context(), req)
 
 	defer func() {
+		var bCommittedTxID [8]byte
+		state, err := db.CurrentState()
+		if err == nil {
+			binary.BigEndian.PutUint64(bCommittedTxID[:], state.TxId)
+		}
+
+		// the commit state lets replicas report how far behind they are
+		md := metadata.Pairs("committed-txid-bin", string(bCommittedTxID[:]))
+
 		if req.ReplicaState != nil {
 			var bMayCommitUpToTxID [8]byte
 			binary.BigEndian.PutUint64(bMayCommitUpToTxID[:], mayCommitUpToTxID)
 
-			var bCommittedTxID [8]byte
-			state, err := db.CurrentState()
-			if err == nil {
-				binary.BigEndian.PutUint64(bCommittedTxID[:], state.TxId)
-			}
-
-			md := metadata.Pairs(
-				"may-commit-up-to-txid-bin", string(bMayCommitUpToTxID[:]),
-				"may-commit-up-to-alh-bin", string(mayCommitUpToAlh[:]),
-				"committed-txid-bin", string(bCommittedTxID[:]),
-			)
-
-			txsServer.SetTrailer(md)
+			md.Append("may-commit-up-to-txid-bin", string(bMayCommitUpToTxID[:]))
+			md.Append("may-commit-up-to-alh-bin", string(mayCommitUpToAlh[:]))
 		}
+
+		txsServer.SetTrailer(md)
 	}()
 
 	if err != nil {
@@ -5,8 +5,10 @@ import (
 	"testing"
 
 	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/codenotary/immudb/pkg/auth"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 func TestImmuServer_StreamGetDbError(t *testing.T) {
@@ -32,6 +34,60 @@ func TestImmuServer_StreamGetDbError(t *testing.T) {
 	require.Error(t, err)
 	err = s.StreamExecAll(&StreamServerMock{})
 	require.Error(t, err)
+	err = s.StreamTx(&schema.StreamTxRequest{}, &StreamTxServerMock{ctx: context.Background()})
+	require.Error(t, err)
+}
+
+func TestImmuServer_StreamTx(t *testing.T) {
+	dir := t.TempDir()
+
+	serverOptions := DefaultOptions().
+		WithDir(dir).
+		WithMetricsServer(false).
+		WithAdminPassword(auth.SysAdminPassword).
+		WithSigningKey("./../../test/signer/ec1.key")
+
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+
+	s.Initialize()
+
+	err := s.StreamTx(nil, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	lr, err := s.Login(context.Background(), &schema.LoginRequest{
+		User:     []byte(auth.SysAdminUsername),
+		Password: []byte(auth.SysAdminPassword),
+	})
+	require.NoError(t, err)
+
+	md := metadata.Pairs("authorization", lr.Token)
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	hdr, err := s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key1"), Value: []byte("value1")}}})
+	require.NoError(t, err)
+
+	_, err = s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("other1"), Value: []byte("value2")}}})
+	require.NoError(t, err)
+
+	_, err = s.Set(ctx, &schema.SetRequest{KVs: []*schema.KeyValue{{Key: []byte("key2"), Value: []byte("value3")}}})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	str := &StreamTxServerMock{ctx: ctx, cancel: cancel, n: 2}
+
+	err = s.StreamTx(&schema.StreamTxRequest{FromTx: hdr.Id, Prefix: []byte("key")}, str)
+	require.ErrorIs(t, err, context.Canceled)
+
+	require.Len(t, str.stxs, 2)
+	require.Equal(t, hdr.Id, str.stxs[0].VerifiableTx.Tx.Header.Id)
+	require.Equal(t, hdr.Id+2, str.stxs[1].VerifiableTx.Tx.Header.Id)
+
+	for _, stx := range str.stxs {
+		require.NotNil(t, stx.VerifiableTx.Signature)
+		require.Len(t, stx.InclusionProofs, 1)
+	}
 }
 
 type StreamServerMock struct {
@@ -69,3 +125,23 @@ func (s *StreamVerifiableServerMock) Recv() (*schema.Chunk, error) {
 func (s *StreamVerifiableServerMock) Context() context.Context {
 	return context.TODO()
 }
+
+type StreamTxServerMock struct {
+	grpc.ServerStream
+	ctx    context.Context
+	cancel context.CancelFunc
+	n      int
+	stxs   []*schema.StreamedTx
+}
+
+func (s *StreamTxServerMock) Send(stx *schema.StreamedTx) error {
+	s.stxs = append(s.stxs, stx)
+	if len(s.stxs) == s.n {
+		s.cancel()
+	}
+	return nil
+}
+
+func (s *StreamTxServerMock) Context() context.Context {
+	return s.ctx
+}
@@ -479,3 +479,37 @@ func (s *ImmuServer) StreamExecAll(str schema.ImmuService_StreamExecAllServer) e
 
 	return nil
 }
+
+// StreamTx streams the transactions committed from the requested one on, as they get committed
+func (s *ImmuServer) StreamTx(req *schema.StreamTxRequest, str schema.ImmuService_StreamTxServer) error {
+	if req == nil || str == nil {
+		return ErrIllegalArguments
+	}
+
+	db, err := s.getDBFromCtx(str.Context(), "StreamTx")
+	if err != nil {
+		return err
+	}
+
+	return db.StreamTx(str.Context(), req, func(stx *schema.StreamedTx) error {
+		if s.StateSigner != nil {
+			hdr := schema.TxHeaderFromProto(stx.VerifiableTx.DualProof.TargetTxHeader)
+			alh := hdr.Alh()
+
+			newState := &schema.ImmutableState{
+				Db:     db.GetName(),
+				TxId:   hdr.ID,
+				TxHash: alh[:],
+			}
+
+			err := s.StateSigner.Sign(newState)
+			if err != nil {
+				return err
+			}
+
+			stx.VerifiableTx.Signature = newState.Signature
+		}
+
+		return str.Send(stx)
+	})
+}
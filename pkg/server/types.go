@@ -26,7 +26,9 @@ import (
 	"github.com/codenotary/immudb/pkg/server/sessions"
 	"github.com/codenotary/immudb/pkg/truncator"
 
+	"github.com/codenotary/immudb/embedded/appendable/remoteapp"
 	"github.com/codenotary/immudb/embedded/remotestorage"
+	"github.com/codenotary/immudb/embedded/tbtree"
 	pgsqlsrv "github.com/codenotary/immudb/pkg/pgsql/server"
 	"github.com/codenotary/immudb/pkg/replication"
 	"github.com/codenotary/immudb/pkg/stream"
@@ -37,6 +39,7 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/codenotary/immudb/pkg/auth"
+	"github.com/codenotary/immudb/pkg/backup"
 	"github.com/codenotary/immudb/pkg/immuos"
 	"github.com/codenotary/immudb/pkg/logger"
 )
@@ -64,6 +67,12 @@ type ImmuServer struct {
 	truncators     map[string]*truncator.Truncator
 	truncatorMutex sync.Mutex
 
+	backupScheduler *backup.Scheduler // nil unless backups are enabled
+
+	rateLimits *rateLimits // nil unless rate limits are enabled
+
+	jwtVerifier *auth.JWTVerifier // nil unless external authentication is enabled
+
 	Logger      logger.Logger
 	Options     *Options
 	Listener    net.Listener
@@ -83,7 +92,10 @@ type ImmuServer struct {
 	StreamServiceFactory stream.ServiceFactory
 	PgsqlSrv             pgsqlsrv.Server
 
-	remoteStorage remotestorage.Storage
+	remoteStorage      remotestorage.Storage
+	remoteStorageCache *remoteapp.DiskCache
+
+	indexCache *tbtree.SharedCache
 
 	SessManager sessions.Manager
 }
@@ -544,6 +544,10 @@ func (s *ImmuServer) insertNewUser(ctx context.Context, username []byte, plainPa
 }
 
 func (s *ImmuServer) getValidatedUser(ctx context.Context, username []byte, password []byte) (*auth.User, error) {
+	if s.jwtVerifier != nil && auth.IsJWT(password) {
+		return s.getExternalUser(ctx, username, password)
+	}
+
 	userdata, err := s.getUser(ctx, username)
 	if err != nil {
 		return nil, err
@@ -17,11 +17,18 @@ limitations under the License.
 package server
 
 import (
+	"bytes"
 	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/golang/protobuf/jsonpb"
 	"github.com/stretchr/testify/require"
 )
 
@@ -96,3 +103,129 @@ EKTcWGekdmdDPsHloRNtsiCa697B2O9IFA==
 		return err == nil
 	}, 10*time.Second, 30*time.Millisecond)
 }
+
+func TestWebServerAPI(t *testing.T) {
+	options := DefaultOptions().
+		WithDir(t.TempDir()).
+		WithPort(0).
+		WithMetricsServer(false).
+		WithWebServer(false).
+		WithPgsqlServer(false)
+
+	server, closer := testServer(options)
+	defer closer()
+
+	err := server.Initialize()
+	require.NoError(t, err)
+
+	webServer, err := StartWebServer("127.0.0.1:0", nil, server, &mockLogger{})
+	require.NoError(t, err)
+	defer webServer.Close()
+
+	ts := httptest.NewServer(webServer.Handler)
+	defer ts.Close()
+
+	var token string
+
+	call := func(method, path string, req interface{}, res interface{}) {
+		var body io.Reader
+		if req != nil {
+			bs, err := json.Marshal(req)
+			require.NoError(t, err)
+			body = bytes.NewReader(bs)
+		}
+
+		httpReq, err := http.NewRequest(method, ts.URL+"/api"+path, body)
+		require.NoError(t, err)
+
+		if token != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		httpRes, err := http.DefaultClient.Do(httpReq)
+		require.NoError(t, err)
+		defer httpRes.Body.Close()
+
+		bs, err := io.ReadAll(httpRes.Body)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, httpRes.StatusCode, string(bs))
+
+		if res != nil {
+			err = json.Unmarshal(bs, res)
+			require.NoError(t, err)
+		}
+	}
+
+	var loginRes struct {
+		Token string `json:"token"`
+	}
+	call(http.MethodPost, "/login", map[string]interface{}{
+		"user":     []byte("immudb"),
+		"password": []byte("immudb"),
+	}, &loginRes)
+	require.NotEmpty(t, loginRes.Token)
+	token = loginRes.Token
+
+	var useRes struct {
+		Token string `json:"token"`
+	}
+	call(http.MethodGet, "/db/use/defaultdb", nil, &useRes)
+	require.NotEmpty(t, useRes.Token)
+	token = useRes.Token
+
+	t.Run("set and get", func(t *testing.T) {
+		var hdr struct {
+			ID string `json:"id"`
+		}
+		call(http.MethodPost, "/db/set", map[string]interface{}{
+			"KVs": []map[string]interface{}{
+				{"key": []byte("key1"), "value": []byte("value1")},
+			},
+		}, &hdr)
+		require.NotEmpty(t, hdr.ID)
+
+		var entry struct {
+			Key   []byte `json:"key"`
+			Value []byte `json:"value"`
+		}
+		call(http.MethodGet, "/db/get/"+base64.URLEncoding.EncodeToString([]byte("key1")), nil, &entry)
+		require.Equal(t, []byte("key1"), entry.Key)
+		require.Equal(t, []byte("value1"), entry.Value)
+	})
+
+	t.Run("verifiable get", func(t *testing.T) {
+		var res map[string]json.RawMessage
+		call(http.MethodPost, "/db/verifiable/get", map[string]interface{}{
+			"keyRequest": map[string]interface{}{"key": []byte("key1")},
+		}, &res)
+		require.Contains(t, res, "entry")
+		require.Contains(t, res, "verifiableTx")
+		require.Contains(t, res, "inclusionProof")
+
+		var ventry schema.VerifiableEntry
+		bs, err := json.Marshal(res)
+		require.NoError(t, err)
+		err = jsonpb.UnmarshalString(string(bs), &ventry)
+		require.NoError(t, err)
+		require.Equal(t, []byte("value1"), ventry.Entry.Value)
+		require.NotNil(t, ventry.InclusionProof)
+	})
+
+	t.Run("sql exec and query", func(t *testing.T) {
+		call(http.MethodPost, "/db/sqlexec", map[string]interface{}{
+			"sql": "CREATE TABLE t1(id INTEGER, title VARCHAR, PRIMARY KEY id); INSERT INTO t1(id, title) VALUES (1, 'title1')",
+		}, nil)
+
+		var res struct {
+			Columns []struct {
+				Name string `json:"name"`
+			} `json:"columns"`
+			Rows []json.RawMessage `json:"rows"`
+		}
+		call(http.MethodPost, "/db/sqlquery", map[string]interface{}{
+			"sql": "SELECT id, title FROM t1",
+		}, &res)
+		require.Len(t, res.Columns, 2)
+		require.Len(t, res.Rows, 1)
+	})
+}